@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	kmssigner "github.com/tektoncd/chains/pkg/chains/signing/kms"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+)
+
+const bootstrapUsage = `Usage: chainsctl bootstrap [flags] x509|cosign|kms
+
+Sets up the signing key of Chains and prints its PEM encoded public key.
+
+x509 generates an ECDSA P-256 key, and cosign an encrypted cosign key pair with
+a random password unless -password-file is set. The key is stored in the signing
+secret Chains reads keys from: signing-secrets in the Chains namespace, or the
+secret of signers.secret.name in chains-config. The data of an existing secret
+is only replaced if it holds no keys yet, as after installing Chains, or with
+-force.
+
+kms creates the key of signers.kms.kmsref in chains-config, or of -kmsref, if
+the KMS doesn't have it yet.
+
+Flags:
+`
+
+// defaultSigningSecret is the secret the controller mounts the signing keys from.
+const defaultSigningSecret = "signing-secrets"
+
+// signingSecretLabels are the labels of the signing secret in the release manifests.
+var signingSecretLabels = map[string]string{
+	"app.kubernetes.io/instance": "default",
+	"app.kubernetes.io/part-of":  "tekton-chains",
+}
+
+func runBootstrap(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("bootstrap", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), bootstrapUsage)
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig. Optional, defaults to the standard kubeconfig loading rules.")
+	chainsNamespace := fs.String("chains-namespace", "tekton-chains", "Namespace Chains is installed in.")
+	passwordFile := fs.String("password-file", "", "Path to the password to encrypt the cosign key with. Optional, defaults to a random password.")
+	kmsRef := fs.String("kmsref", "", "Reference of the KMS key. Optional, defaults to signers.kms.kmsref.")
+	force := fs.Bool("force", false, "Replace the keys in the signing secret, if it already holds some.")
+	output := fs.String("o", "", "Path to write the public key to. Optional, defaults to stdout.")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	c, err := newCluster(*kubeconfig, "")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	cfg, err := c.chainsConfig(ctx, *chainsNamespace)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	var pub []byte
+	switch kind := fs.Arg(0); kind {
+	case "x509", "cosign":
+		var password []byte
+		if *passwordFile != "" {
+			if password, err = os.ReadFile(*passwordFile); err != nil {
+				fatalf("error reading password: %v", err)
+			}
+		}
+		data, p, err := generateKeys(kind, password)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		namespace, name := signingSecret(*cfg, *chainsNamespace)
+		if err := applySigningSecret(ctx, c.kc, namespace, name, data, *force); err != nil {
+			fatalf("%v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Stored the %s key in secret %s/%s\n", kind, namespace, name)
+		pub = p
+	case "kms":
+		if *kmsRef != "" {
+			cfg.Signers.KMS.KMSRef = *kmsRef
+		}
+		if pub, err = createKMSKey(ctx, cfg.Signers.KMS); err != nil {
+			fatalf("%v", err)
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *output == "" {
+		_, _ = os.Stdout.Write(pub)
+	} else if err := os.WriteFile(*output, pub, 0o644); err != nil {
+		fatalf("error writing public key: %v", err)
+	}
+}
+
+// generateKeys returns the signing secret data of a new key of kind, x509 or cosign,
+// and its PEM encoded public key. cosign keys are encrypted with password, or with a
+// random password if it is empty.
+func generateKeys(kind string, password []byte) (map[string][]byte, []byte, error) {
+	switch kind {
+	case "x509":
+		priv, pub, err := cryptoutils.GeneratePEMEncodedECDSAKeyPair(elliptic.P256(), nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error generating x509 key: %w", err)
+		}
+		return map[string][]byte{"x509.pem": priv}, pub, nil
+	case "cosign":
+		if len(password) == 0 {
+			b := make([]byte, 32)
+			if _, err := rand.Read(b); err != nil {
+				return nil, nil, fmt.Errorf("error generating password: %w", err)
+			}
+			password = []byte(base64.RawURLEncoding.EncodeToString(b))
+		}
+		keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return password, nil })
+		if err != nil {
+			return nil, nil, fmt.Errorf("error generating cosign key pair: %w", err)
+		}
+		return map[string][]byte{
+			"cosign.key":      keys.PrivateBytes,
+			"cosign.password": password,
+			"cosign.pub":      keys.PublicBytes,
+		}, keys.PublicBytes, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown key type %q, expected x509 or cosign", kind)
+	}
+}
+
+// signingSecret returns the namespace and name of the secret Chains reads the signing
+// keys from with cfg.
+func signingSecret(cfg config.Config, chainsNamespace string) (string, string) {
+	s := cfg.Signers.Secret
+	if s.Name == "" {
+		return chainsNamespace, defaultSigningSecret
+	}
+	if s.Namespace == "" {
+		return chainsNamespace, s.Name
+	}
+	return s.Namespace, s.Name
+}
+
+// applySigningSecret stores data in the signing secret namespace/name, creating it
+// with the labels of the release manifests if it doesn't exist. The data of an
+// existing secret is only replaced if it is empty or force is set.
+func applySigningSecret(ctx context.Context, kc kubernetes.Interface, namespace, name string, data map[string][]byte, force bool) error {
+	secrets := kc.CoreV1().Secrets(namespace)
+	s, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: signingSecretLabels},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       data,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("error creating secret %s/%s: %w", namespace, name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error getting secret %s/%s: %w", namespace, name, err)
+	}
+
+	if len(s.Data) > 0 && !force {
+		return fmt.Errorf("secret %s/%s already holds %s, set -force to replace them", namespace, name, strings.Join(sets.List(sets.KeySet(s.Data)), ", "))
+	}
+	if s.Labels == nil {
+		s.Labels = map[string]string{}
+	}
+	for k, v := range signingSecretLabels {
+		if _, ok := s.Labels[k]; !ok {
+			s.Labels[k] = v
+		}
+	}
+	s.Data = data
+	if _, err := secrets.Update(ctx, s, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating secret %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// createKMSKey creates the key of cfg if the KMS doesn't have it yet, with the default
+// algorithm of the KMS, and returns its PEM encoded public key.
+func createKMSKey(ctx context.Context, cfg config.KMSSigner) ([]byte, error) {
+	if cfg.KMSRef == "" {
+		return nil, errors.New("no KMS key is configured, set signers.kms.kmsref or -kmsref")
+	}
+	s, err := kmssigner.NewSigner(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error getting KMS key %s: %w", cfg.KMSRef, err)
+	}
+	k, ok := s.SignerVerifier.(kms.SignerVerifier)
+	if !ok {
+		return nil, fmt.Errorf("KMS key %s can't be created", cfg.KMSRef)
+	}
+	pub, err := k.CreateKey(ctx, k.DefaultAlgorithm())
+	if err != nil {
+		return nil, fmt.Errorf("error creating KMS key %s: %w", cfg.KMSRef, err)
+	}
+	pem, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding public key of KMS key %s: %w", cfg.KMSRef, err)
+	}
+	return pem, nil
+}
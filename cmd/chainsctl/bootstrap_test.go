@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing/x509"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestGenerateKeys(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	for _, kind := range []string{"x509", "cosign"} {
+		t.Run(kind, func(t *testing.T) {
+			data, pub, err := generateKeys(kind, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Chains loads the keys from the mounted secret.
+			dir := t.TempDir()
+			for name, content := range data {
+				if err := os.WriteFile(filepath.Join(dir, name), content, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s, err := x509.NewSigner(ctx, dir, config.Config{})
+			if err != nil {
+				t.Fatalf("Chains can't load the %s key: %v", kind, err)
+			}
+			got, err := s.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := cryptoutils.UnmarshalPEMToPublicKey(pub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cryptoutils.EqualKeys(want, got); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	if _, _, err := generateKeys("rsa", nil); err == nil {
+		t.Error("expected an error for an unknown key type")
+	}
+}
+
+func TestApplySigningSecret(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	data := map[string][]byte{"x509.pem": []byte("key")}
+	secret := func(data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-secrets", Namespace: "tekton-chains"}, Data: data}
+	}
+	tests := []struct {
+		name     string
+		existing []*corev1.Secret
+		force    bool
+		wantErr  bool
+		want     map[string][]byte
+	}{
+		{name: "created", want: data},
+		{name: "installed", existing: []*corev1.Secret{secret(nil)}, want: data},
+		{name: "holds keys", existing: []*corev1.Secret{secret(map[string][]byte{"cosign.key": []byte("old")})}, wantErr: true, want: map[string][]byte{"cosign.key": []byte("old")}},
+		{name: "forced", existing: []*corev1.Secret{secret(map[string][]byte{"cosign.key": []byte("old")})}, force: true, want: data},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kc := fake.NewSimpleClientset()
+			for _, s := range tt.existing {
+				if _, err := kc.CoreV1().Secrets(s.Namespace).Create(ctx, s, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := applySigningSecret(ctx, kc, "tekton-chains", "signing-secrets", data, tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applySigningSecret() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			got, err := kc.CoreV1().Secrets("tekton-chains").Get(ctx, "signing-secrets", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got.Data); diff != "" {
+				t.Errorf("data -want +got: %s", diff)
+			}
+			if !tt.wantErr && got.Labels["app.kubernetes.io/part-of"] != "tekton-chains" {
+				t.Errorf("expected the labels of the release manifests, got %v", got.Labels)
+			}
+		})
+	}
+}
+
+func TestSigningSecret(t *testing.T) {
+	tests := []struct {
+		secret        config.SigningSecretConfig
+		wantNamespace string
+		wantName      string
+	}{
+		{wantNamespace: "tekton-chains", wantName: "signing-secrets"},
+		{secret: config.SigningSecretConfig{Name: "keys"}, wantNamespace: "tekton-chains", wantName: "keys"},
+		{secret: config.SigningSecretConfig{Namespace: "platform", Name: "keys"}, wantNamespace: "platform", wantName: "keys"},
+	}
+	for _, tt := range tests {
+		cfg := config.Config{Signers: config.SignerConfigs{Secret: tt.secret}}
+		if namespace, name := signingSecret(cfg, "tekton-chains"); namespace != tt.wantNamespace || name != tt.wantName {
+			t.Errorf("signingSecret(%+v) = %s/%s, want %s/%s", tt.secret, namespace, name, tt.wantNamespace, tt.wantName)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	ociremote "github.com/sigstore/cosign/v2/pkg/oci/remote"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const getUsage = `Usage: chainsctl get [flags] IMAGE
+
+Prints the attestations of an image: the ones attached to it in its registry
+and, unless -registry-only is set, the ones Chains stored in the other storage
+backends of the signed runs that built it. The image is resolved to its digest
+first. Attestations are not verified, see chainsctl verify. Exits with status 1
+if no attestation was found.
+
+Flags:
+`
+
+// listPageSize is the number of runs listed per request.
+const listPageSize = 500
+
+// imageAttestation is an attestation of an image.
+type imageAttestation struct {
+	// Source is the registry, oci, or the storage backend the attestation was read from.
+	Source string `json:"source"`
+	Key    string `json:"key"`
+	// Run is the run the attestation was stored for, e.g. "taskrun ns/build", if it was
+	// read from a storage backend.
+	Run           string          `json:"run,omitempty"`
+	PredicateType string          `json:"predicateType"`
+	Subjects      []subject       `json:"subjects"`
+	Predicate     json.RawMessage `json:"predicate"`
+
+	// payload is the statement, to recognize attestations found more than once.
+	payload []byte
+}
+
+type subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// statement is an in-toto statement.
+type statement struct {
+	Type          string          `json:"_type"`
+	PredicateType string          `json:"predicateType"`
+	Subject       []subject       `json:"subject"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+func runGet(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), getUsage)
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig. Optional, defaults to the standard kubeconfig loading rules.")
+	namespace := fs.String("n", "", "Namespace of the runs to search. Optional, defaults to the namespace of the current context.")
+	allNamespaces := fs.Bool("A", false, "Search the runs of all namespaces.")
+	chainsNamespace := fs.String("chains-namespace", "tekton-chains", "Namespace Chains is installed in.")
+	registryOnly := fs.Bool("registry-only", false, "Only read the attestations attached to the image in its registry, without a cluster.")
+	repository := fs.String("repository", "", "Repository attestations are attached to the image in, as set in storage.oci.repository. Optional, defaults to the repository of the image.")
+	predicateType := fs.String("predicate-type", "", "Only print attestations with this predicate type. Optional.")
+	output := fs.String("o", "text", "Output format: text or json.")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ref, err := name.ParseReference(fs.Arg(0))
+	if err != nil {
+		fatalf("invalid image reference %s: %v", fs.Arg(0), err)
+	}
+	digest, err := ociremote.ResolveDigest(ref)
+	if err != nil {
+		fatalf("error resolving digest of %s: %v", ref, err)
+	}
+
+	var opts []ociremote.Option
+	if *repository != "" {
+		repo, err := name.NewRepository(*repository)
+		if err != nil {
+			fatalf("invalid repository %s: %v", *repository, err)
+		}
+		opts = append(opts, ociremote.WithTargetRepository(repo))
+	}
+
+	var found []imageAttestation
+	attached, err := registryAttestations(digest, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chainsctl: error reading attestations of %s from the registry: %v\n", digest, err)
+	}
+	found = append(found, attached...)
+	if !*registryOnly {
+		stored, err := storedAttestations(ctx, *kubeconfig, *namespace, *allNamespaces, *chainsNamespace, digest)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "chainsctl: error reading attestations of %s from the cluster: %v\n", digest, err)
+		}
+		found = append(found, stored...)
+	}
+
+	found = filterAttestations(found, *predicateType)
+	if err := printAttestations(os.Stdout, *output, found); err != nil {
+		fatalf("%v", err)
+	}
+	if len(found) == 0 {
+		fatalf("no attestations found for %s", digest)
+	}
+}
+
+// registryAttestations returns the attestations attached to the image digest in its
+// registry.
+func registryAttestations(digest name.Digest, opts ...ociremote.Option) ([]imageAttestation, error) {
+	se, err := ociremote.SignedEntity(digest, opts...)
+	if err != nil {
+		return nil, err
+	}
+	atts, err := se.Attestations()
+	if err != nil {
+		return nil, err
+	}
+	sigs, err := atts.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []imageAttestation
+	for _, sig := range sigs {
+		raw, err := sig.Payload()
+		if err != nil {
+			return nil, err
+		}
+		var env ssldsse.Envelope
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return nil, fmt.Errorf("invalid attestation envelope: %w", err)
+		}
+		payload, err := env.DecodeB64Payload()
+		if err != nil {
+			return nil, fmt.Errorf("invalid attestation envelope payload: %w", err)
+		}
+		if a, ok := newImageAttestation("oci", digest.String(), payload); ok {
+			found = append(found, a)
+		}
+	}
+	return found, nil
+}
+
+// storedAttestations returns the attestations about the image digest that Chains
+// stored for the signed runs in the cluster whose results mention it.
+func storedAttestations(ctx context.Context, kubeconfig, namespace string, allNamespaces bool, chainsNamespace string, digest name.Digest) ([]imageAttestation, error) {
+	c, err := newCluster(kubeconfig, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if allNamespaces {
+		c.namespace = ""
+	}
+	cfg, err := c.chainsConfig(ctx, chainsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	backends, err := storage.InitializeBackends(ctx, c.ps, c.kc, *cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing storage backends: %w", err)
+	}
+	_, hex, _ := strings.Cut(digest.DigestStr(), ":")
+	runs, err := c.candidateRuns(ctx, hex)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []imageAttestation
+	for _, obj := range runs {
+		stored, errs, err := chains.RetrieveAttestations(ctx, obj, backends, *cfg)
+		if err != nil {
+			continue
+		}
+		run := fmt.Sprintf("%s %s/%s", obj.GetKindName(), obj.GetNamespace(), obj.GetName())
+		for backend, err := range errs {
+			fmt.Fprintf(os.Stderr, "chainsctl: error reading attestations of %s from %s: %v\n", run, backend, err)
+		}
+		for _, s := range stored {
+			a, ok := newImageAttestation(s.Backend, s.Key, s.Payload)
+			if !ok || !a.about(digest) {
+				continue
+			}
+			a.Run = run
+			found = append(found, a)
+		}
+	}
+	return found, nil
+}
+
+// candidateRuns returns the TaskRuns and PipelineRuns Chains signed in the namespace
+// of c whose results, or for PipelineRuns the results of their TaskRuns, contain hex.
+// Subjects are read from results, so other runs can't have attestations about it.
+func (c *cluster) candidateRuns(ctx context.Context, hex string) ([]objects.TektonObject, error) {
+	var trs []v1beta1.TaskRun
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		list, err := c.ps.TektonV1beta1().TaskRuns(c.namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing TaskRuns: %w", err)
+		}
+		trs = append(trs, list.Items...)
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+	var prs []v1beta1.PipelineRun
+	opts = metav1.ListOptions{Limit: listPageSize}
+	for {
+		list, err := c.ps.TektonV1beta1().PipelineRuns(c.namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing PipelineRuns: %w", err)
+		}
+		prs = append(prs, list.Items...)
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+
+	// childMentions are the PipelineRuns, by namespace/name, with a TaskRun mentioning hex.
+	childMentions := map[string]bool{}
+	var runs []objects.TektonObject
+	for i := range trs {
+		obj := objects.NewTaskRunObject(&trs[i])
+		if !mentions(obj, hex) {
+			continue
+		}
+		if pr := trs[i].Labels[pipeline.PipelineRunLabelKey]; pr != "" {
+			childMentions[trs[i].Namespace+"/"+pr] = true
+		}
+		if signed(obj) {
+			runs = append(runs, obj)
+		}
+	}
+	for i := range prs {
+		obj := objects.NewPipelineRunObject(&prs[i])
+		if signed(obj) && (childMentions[prs[i].Namespace+"/"+prs[i].Name] || mentions(obj, hex)) {
+			runs = append(runs, obj)
+		}
+	}
+	return runs, nil
+}
+
+func signed(obj objects.TektonObject) bool {
+	return obj.GetAnnotations()[chains.ChainsAnnotation] == "true"
+}
+
+// mentions returns whether hex appears in the results of obj.
+func mentions(obj objects.TektonObject, hex string) bool {
+	raw, err := json.Marshal(obj.GetResults())
+	return err == nil && bytes.Contains(raw, []byte(hex))
+}
+
+// newImageAttestation returns the attestation with the in-toto statement payload, and
+// false if payload isn't a statement.
+func newImageAttestation(source, key string, payload []byte) (imageAttestation, bool) {
+	var st statement
+	if err := json.Unmarshal(payload, &st); err != nil || st.Type == "" {
+		return imageAttestation{}, false
+	}
+	return imageAttestation{
+		Source:        source,
+		Key:           key,
+		PredicateType: st.PredicateType,
+		Subjects:      st.Subject,
+		Predicate:     st.Predicate,
+		payload:       payload,
+	}, true
+}
+
+// about returns whether digest is a subject of a.
+func (a imageAttestation) about(digest name.Digest) bool {
+	algorithm, hex, _ := strings.Cut(digest.DigestStr(), ":")
+	for _, s := range a.Subjects {
+		if s.Digest[algorithm] == hex {
+			return true
+		}
+	}
+	return false
+}
+
+// filterAttestations returns the attestations with the given predicate type, or all of
+// them if it is empty, without the ones found more than once.
+func filterAttestations(found []imageAttestation, predicateType string) []imageAttestation {
+	seen := map[string]bool{}
+	filtered := []imageAttestation{}
+	for _, a := range found {
+		if predicateType != "" && a.PredicateType != predicateType {
+			continue
+		}
+		if seen[string(a.payload)] {
+			continue
+		}
+		seen[string(a.payload)] = true
+		filtered = append(filtered, a)
+	}
+	return filtered
+}
+
+// printAttestations writes the attestations to w in the given format.
+func printAttestations(w io.Writer, format string, found []imageAttestation) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(found)
+	case "text":
+		for _, a := range found {
+			fmt.Fprintf(w, "# %s %s: %s\n", a.Source, a.Key, a.PredicateType)
+			if a.Run != "" {
+				fmt.Fprintf(w, "# run: %s\n", a.Run)
+			}
+			for _, s := range a.Subjects {
+				for algorithm, hex := range s.Digest {
+					fmt.Fprintf(w, "# subject: %s@%s:%s\n", s.Name, algorithm, hex)
+				}
+			}
+			var indented bytes.Buffer
+			if len(a.Predicate) > 0 {
+				if err := json.Indent(&indented, a.Predicate, "", "  "); err != nil {
+					return err
+				}
+			}
+			indented.WriteString("\n\n")
+			if _, err := indented.WriteTo(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/cosign/v2/pkg/oci/mutate"
+	ociremote "github.com/sigstore/cosign/v2/pkg/oci/remote"
+	"github.com/sigstore/cosign/v2/pkg/oci/static"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakeversioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	remotetest "github.com/tektoncd/pipeline/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func statementAbout(image, hex string) []byte {
+	return []byte(`{"_type": "https://in-toto.io/Statement/v0.1", "predicateType": "https://slsa.dev/provenance/v0.2", ` +
+		`"subject": [{"name": "` + image + `", "digest": {"sha256": "` + hex + `"}}], "predicate": {"builder": {"id": "https://tekton.dev/chains/v2"}}}`)
+}
+
+func TestRegistryAttestations(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	image := u.Host + "/task/build"
+	pushed, err := remotetest.CreateImage(image, &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "build"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := name.NewDigest(pushed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, hex, _ := strings.Cut(digest.DigestStr(), ":")
+
+	payload := statementAbout(image, hex)
+	envelope, err := json.Marshal(ssldsse.Envelope{
+		PayloadType: in_toto.PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []ssldsse.Signature{{Sig: "c2ln"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	att, err := static.NewAttestation(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	se, err := ociremote.SignedEntity(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	se, err = mutate.AttachAttestationToEntity(se, att)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ociremote.WriteAttestations(digest.Repository, se); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := registryAttestations(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("got %d attestations, want 1", len(found))
+	}
+	a := found[0]
+	if a.Source != "oci" || a.PredicateType != "https://slsa.dev/provenance/v0.2" || !a.about(digest) {
+		t.Errorf("got attestation %+v", a)
+	}
+	if !bytes.Equal(a.payload, payload) {
+		t.Errorf("got payload %s, want %s", a.payload, payload)
+	}
+}
+
+func TestCandidateRuns(t *testing.T) {
+	const hex = "05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5"
+	signedAnnotations := map[string]string{chains.ChainsAnnotation: "true"}
+	digestResult := []v1beta1.TaskRunResult{{Name: "IMAGE_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:" + hex)}}
+
+	ps := fakeversioned.NewSimpleClientset(
+		&v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", Annotations: signedAnnotations},
+			Status:     v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{TaskRunResults: digestResult}},
+		},
+		&v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "unsigned", Namespace: "ns"},
+			Status:     v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{TaskRunResults: digestResult}},
+		},
+		&v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "ns", Annotations: signedAnnotations},
+		},
+		&v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "release-build", Namespace: "ns",
+				Labels: map[string]string{pipeline.PipelineRunLabelKey: "release"},
+			},
+			Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{TaskRunResults: digestResult}},
+		},
+		&v1beta1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "ns", Annotations: signedAnnotations}},
+		&v1beta1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", Annotations: signedAnnotations}},
+	)
+	c := &cluster{namespace: "ns", ps: ps}
+
+	runs, err := c.candidateRuns(context.Background(), hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, obj := range runs {
+		got = append(got, obj.GetKindName()+" "+obj.GetName())
+	}
+	if diff := cmp.Diff([]string{"taskrun build", "pipelinerun release"}, got); diff != "" {
+		t.Errorf("candidateRuns() (-want +got): %s", diff)
+	}
+}
+
+func TestFilterAttestations(t *testing.T) {
+	a, _ := newImageAttestation("oci", "gcr.io/foo/bar@sha256:abc", statementAbout("gcr.io/foo/bar", "abc"))
+	stored := a
+	stored.Source, stored.Run = "tekton", "taskrun ns/build"
+	other, _ := newImageAttestation("tekton", "taskrun-uid", []byte(`{"_type": "https://in-toto.io/Statement/v1", "predicateType": "https://spdx.dev/Document", "predicate": {}}`))
+
+	if got := filterAttestations([]imageAttestation{a, stored, other}, ""); len(got) != 2 || got[0].Source != "oci" {
+		t.Errorf("got %+v, want the oci and spdx attestations", got)
+	}
+	if got := filterAttestations([]imageAttestation{a, other}, "https://spdx.dev/Document"); len(got) != 1 || got[0].PredicateType != "https://spdx.dev/Document" {
+		t.Errorf("got %+v, want the spdx attestation", got)
+	}
+	if _, ok := newImageAttestation("tekton", "taskrun-uid", []byte(`{"status": "Succeeded"}`)); ok {
+		t.Error("expected a payload that isn't a statement to be skipped")
+	}
+}
+
+func TestPrintAttestations(t *testing.T) {
+	a, _ := newImageAttestation("tekton", "taskrun-uid", statementAbout("gcr.io/foo/bar", "abc"))
+	a.Run = "taskrun ns/build"
+	var buf bytes.Buffer
+	if err := printAttestations(&buf, "text", []imageAttestation{a}); err != nil {
+		t.Fatal(err)
+	}
+	want := `# tekton taskrun-uid: https://slsa.dev/provenance/v0.2
+# run: taskrun ns/build
+# subject: gcr.io/foo/bar@sha256:abc
+{
+  "builder": {
+    "id": "https://tekton.dev/chains/v2"
+  }
+}
+
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("printAttestations() (-want +got): %s", diff)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// chainsctl verifies and prints the attestations Chains produced for runs and images,
+// previews the payloads it would sign for runs, makes it sign runs again and sets up
+// its signing keys.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"knative.dev/pkg/logging"
+)
+
+const usage = `Usage: chainsctl COMMAND [flags] ARGS
+
+Commands:
+  verify     Verify the attestations of a TaskRun, a PipelineRun or an image
+  get        Print the attestations of an image
+  preview    Print the payloads Chains would sign for a TaskRun or a PipelineRun
+  resign     Make Chains sign runs it already handled again
+  policy     Print a policy-controller ClusterImagePolicy for the Chains configuration
+  bootstrap  Generate or create the signing key and store it in the signing secret
+
+Run chainsctl COMMAND -h for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	logger, _ := logging.NewLogger("", "warn")
+	ctx = logging.WithLogger(ctx, logger)
+
+	switch os.Args[1] {
+	case "verify":
+		runVerify(ctx, os.Args[2:])
+	case "get":
+		runGet(ctx, os.Args[2:])
+	case "preview":
+		runPreview(ctx, os.Args[2:])
+	case "resign":
+		runResign(ctx, os.Args[2:])
+	case "policy":
+		runPolicy(ctx, os.Args[2:])
+	case "bootstrap":
+		runBootstrap(ctx, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// cluster holds the clients of the cluster chainsctl reads runs and configuration from.
+type cluster struct {
+	namespace string
+	kc        kubernetes.Interface
+	ps        versioned.Interface
+}
+
+// newCluster returns the clients for the cluster of the given kubeconfig, defaulting
+// to the standard loading rules, and namespace, defaulting to the namespace of the
+// current context.
+func newCluster(kubeconfig, namespace string) (*cluster, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{})
+	if namespace == "" {
+		ns, _, err := clientConfig.Namespace()
+		if err != nil {
+			return nil, fmt.Errorf("error getting namespace: %w", err)
+		}
+		namespace = ns
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+	kc, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating kubernetes client: %w", err)
+	}
+	ps, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pipeline client: %w", err)
+	}
+	return &cluster{namespace: namespace, kc: kc, ps: ps}, nil
+}
+
+// chainsConfig returns the Chains configuration in the given namespace.
+func (c *cluster) chainsConfig(ctx context.Context, chainsNamespace string) (*config.Config, error) {
+	cm, err := c.kc.CoreV1().ConfigMaps(chainsNamespace).Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s config map: %w", config.ChainsConfig, err)
+	}
+	cfg, err := config.NewConfigFromConfigMap(cm)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s config map: %w", config.ChainsConfig, err)
+	}
+	return cfg, nil
+}
+
+// run returns the TaskRun or PipelineRun of the given kind and name.
+func (c *cluster) run(ctx context.Context, kind, name string) (objects.TektonObject, error) {
+	switch kind {
+	case "taskrun", "tr":
+		tr, err := c.ps.TektonV1beta1().TaskRuns(c.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting TaskRun %s/%s: %w", c.namespace, name, err)
+		}
+		return objects.NewTaskRunObject(tr), nil
+	case "pipelinerun", "pr":
+		pr, err := c.ps.TektonV1beta1().PipelineRuns(c.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting PipelineRun %s/%s: %w", c.namespace, name, err)
+		}
+		return objects.NewPipelineRunObject(pr), nil
+	default:
+		return nil, fmt.Errorf("unknown kind %q, expected taskrun or pipelinerun", kind)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "chainsctl: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/chains/publickeys"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/policy"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const policyUsage = `Usage: chainsctl policy [flags]
+
+Prints a sigstore policy-controller ClusterImagePolicy that requires the
+signatures and attestations Chains stores in OCI registries with its current
+configuration: one authority per signer, checking the predicate types of the
+configured formats and, if transparency is enabled, the Rekor entries.
+
+The public key of the x509 signer is read from -key or, if it isn't set, from
+the chains-public-keys ConfigMap when public keys are published. The identities
+of keyless signatures must be set with -issuer and -subject or -subject-regexp
+when Fulcio is enabled.
+
+Flags:
+`
+
+// stringList is a flag that can be set multiple times.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func runPolicy(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("policy", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), policyUsage)
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig. Optional, defaults to the standard kubeconfig loading rules.")
+	chainsNamespace := fs.String("chains-namespace", "tekton-chains", "Namespace Chains is installed in.")
+	name := fs.String("name", policy.DefaultName, "Name of the ClusterImagePolicy.")
+	key := fs.String("key", "", "Path to the PEM encoded public key of the x509 signer. Optional.")
+	issuer := fs.String("issuer", "", "OIDC issuer of the certificates of keyless signatures.")
+	subject := fs.String("subject", "", "Subject of the certificates of keyless signatures.")
+	subjectRegExp := fs.String("subject-regexp", "", "Regular expression of the subject of the certificates of keyless signatures.")
+	var images stringList
+	fs.Var(&images, "image", "Glob of the images the policy applies to. Can be repeated. Optional, defaults to all images.")
+	_ = fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	c, err := newCluster(*kubeconfig, "")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	cfg, err := c.chainsConfig(ctx, *chainsNamespace)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	opts := policy.Options{Name: *name, Images: images, PublicKeys: map[string][]byte{}}
+	if *issuer != "" || *subject != "" || *subjectRegExp != "" {
+		opts.Identities = []policy.Identity{{Issuer: *issuer, Subject: *subject, SubjectRegExp: *subjectRegExp}}
+	}
+	if *key != "" {
+		pem, err := os.ReadFile(*key)
+		if err != nil {
+			fatalf("error reading public key: %v", err)
+		}
+		opts.PublicKeys[signing.TypeX509] = pem
+	} else {
+		cm, err := c.kc.CoreV1().ConfigMaps(*chainsNamespace).Get(ctx, publickeys.ConfigMapName, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			fatalf("error getting %s config map: %v", publickeys.ConfigMapName, err)
+		default:
+			if pem, ok := cm.Data[signing.TypeX509+".pub"]; ok {
+				opts.PublicKeys[signing.TypeX509] = []byte(pem)
+			}
+		}
+	}
+
+	p, err := policy.Generate(*cfg, opts)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	out, err := yaml.Marshal(p)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	_, _ = os.Stdout.Write(out)
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/chains"
+	_ "github.com/tektoncd/chains/pkg/chains/formats/all"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const previewUsage = `Usage: chainsctl preview [flags] taskrun|pipelinerun NAME
+       chainsctl preview [flags] -f FILE
+
+Prints the payloads each configured format generates for a TaskRun or a
+PipelineRun, exactly as Chains would sign them, without signing anything. Runs
+are read from the cluster, or from a YAML or JSON file of runs or of records
+exported from Tekton Results. Exits with status 1 if a payload could not be
+generated.
+
+Flags:
+`
+
+// runPayloads are the payloads previewed for a run.
+type runPayloads struct {
+	Kind      string                  `json:"kind"`
+	Namespace string                  `json:"namespace"`
+	Name      string                  `json:"name"`
+	Payloads  []chains.PreviewPayload `json:"payloads"`
+}
+
+func runPreview(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), previewUsage)
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig. Optional, defaults to the standard kubeconfig loading rules.")
+	namespace := fs.String("n", "", "Namespace of the run. Optional, defaults to the namespace of the current context.")
+	chainsNamespace := fs.String("chains-namespace", "tekton-chains", "Namespace Chains is installed in.")
+	file := fs.String("f", "", "File to read runs from instead of the cluster, - for stdin.")
+	configFile := fs.String("config", "", "File of the chains-config config map to use instead of the one in the cluster.")
+	format := fs.String("format", "", "Format to generate the TaskRun and PipelineRun payloads in instead of the configured one.")
+	output := fs.String("o", "text", "Output format: text or json.")
+	_ = fs.Parse(args)
+	if (*file == "" && fs.NArg() != 2) || (*file != "" && fs.NArg() != 0) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var c *cluster
+	if *file == "" || *configFile == "" {
+		var err error
+		if c, err = newCluster(*kubeconfig, *namespace); err != nil {
+			fatalf("%v", err)
+		}
+	}
+
+	var cfg *config.Config
+	var err error
+	if *configFile != "" {
+		cfg, err = readConfig(*configFile)
+	} else {
+		cfg, err = c.chainsConfig(ctx, *chainsNamespace)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if *format != "" {
+		cfg.Artifacts.TaskRuns.Format = *format
+		cfg.Artifacts.PipelineRuns.Format = *format
+	}
+
+	var runs []objects.TektonObject
+	if *file != "" {
+		runs, err = readRuns(*file)
+	} else {
+		runs, err = clusterRun(ctx, c, fs.Arg(0), fs.Arg(1))
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	var previews []runPayloads
+	failed := false
+	for _, obj := range runs {
+		payloads, err := chains.Preview(ctx, obj, *cfg)
+		if err != nil {
+			fatalf("error previewing %s %s/%s: %v", obj.GetKindName(), obj.GetNamespace(), obj.GetName(), err)
+		}
+		for _, p := range payloads {
+			failed = failed || p.Error != ""
+		}
+		previews = append(previews, runPayloads{Kind: obj.GetKindName(), Namespace: obj.GetNamespace(), Name: obj.GetName(), Payloads: payloads})
+	}
+	if err := printPreviews(os.Stdout, *output, previews); err != nil {
+		fatalf("%v", err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// clusterRun returns the run of the given kind and name in the cluster. The TaskRuns
+// of a PipelineRun are fetched too, as the PipelineRun formats read them.
+func clusterRun(ctx context.Context, c *cluster, kind, name string) ([]objects.TektonObject, error) {
+	obj, err := c.run(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if pro, ok := obj.(*objects.PipelineRunObject); ok {
+		trs, err := c.ps.TektonV1beta1().TaskRuns(pro.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", pipeline.PipelineRunLabelKey, pro.Name),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing TaskRuns of PipelineRun %s/%s: %w", pro.Namespace, pro.Name, err)
+		}
+		for i := range trs.Items {
+			pro.AppendTaskRun(&trs.Items[i])
+		}
+	}
+	return []objects.TektonObject{obj}, nil
+}
+
+// readConfig reads the chains-config config map in the YAML or JSON file path.
+func readConfig(path string) (*config.Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cm corev1.ConfigMap
+	if err := yaml.Unmarshal(raw, &cm); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	cfg, err := config.NewConfigFromConfigMap(&cm)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s config map in %s: %w", config.ChainsConfig, path, err)
+	}
+	return cfg, nil
+}
+
+// readRuns reads the runs in the file path, or stdin if path is "-".
+func readRuns(path string) ([]objects.TektonObject, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	runs, err := decodeRuns(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading runs from %s: %w", path, err)
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no TaskRuns or PipelineRuns found in %s", path)
+	}
+	return runs, nil
+}
+
+// document is a YAML or JSON document holding runs: a run, a list of runs, or a
+// record or list of records exported from Tekton Results.
+type document struct {
+	metav1.TypeMeta `json:",inline"`
+	Items           []json.RawMessage `json:"items"`
+	Records         []json.RawMessage `json:"records"`
+	Data            *struct {
+		Type string `json:"type"`
+		// Value is the run, which is base64 encoded in the JSON of the Results API.
+		Value json.RawMessage `json:"value"`
+	} `json:"data"`
+}
+
+// decodeRuns decodes the runs in the YAML or JSON documents of r. PipelineRuns are
+// given the TaskRuns in r labelled as theirs, as the PipelineRun formats read them.
+func decodeRuns(r io.Reader) ([]objects.TektonObject, error) {
+	var runs []objects.TektonObject
+	d := k8syaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var raw json.RawMessage
+		if err := d.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if err := decodeDocument(raw, metav1.TypeMeta{}, &runs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, obj := range runs {
+		pro, ok := obj.(*objects.PipelineRunObject)
+		if !ok {
+			continue
+		}
+		for _, other := range runs {
+			if tro, ok := other.(*objects.TaskRunObject); ok && tro.Namespace == pro.Namespace && tro.Labels[pipeline.PipelineRunLabelKey] == pro.Name {
+				pro.AppendTaskRun(tro.TaskRun)
+			}
+		}
+	}
+	return runs, nil
+}
+
+// decodeDocument appends the runs in raw to runs. The type of raw defaults to typ.
+func decodeDocument(raw json.RawMessage, typ metav1.TypeMeta, runs *[]objects.TektonObject) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+	var doc document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+
+	switch {
+	case doc.Data != nil:
+		value := doc.Data.Value
+		var encoded string
+		if err := json.Unmarshal(value, &encoded); err == nil {
+			if value, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+				return fmt.Errorf("invalid record data: %w", err)
+			}
+		}
+		// Records are typed, e.g. tekton.dev/v1beta1.TaskRun, and the runs in them may not be.
+		typ = metav1.TypeMeta{}
+		if i := strings.LastIndex(doc.Data.Type, "."); i > 0 {
+			typ.APIVersion, typ.Kind = doc.Data.Type[:i], doc.Data.Type[i+1:]
+		}
+		return decodeDocument(value, typ, runs)
+	case doc.Items != nil || doc.Records != nil:
+		for _, item := range append(doc.Items, doc.Records...) {
+			if err := decodeDocument(item, typ, runs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if doc.APIVersion == "" && doc.Kind == "" {
+		doc.TypeMeta = typ
+	}
+	if doc.APIVersion != v1beta1.SchemeGroupVersion.String() {
+		return fmt.Errorf("unsupported object %s %s, only %s TaskRuns and PipelineRuns are supported", doc.APIVersion, doc.Kind, v1beta1.SchemeGroupVersion)
+	}
+	switch doc.Kind {
+	case pipeline.TaskRunControllerName:
+		var tr v1beta1.TaskRun
+		if err := json.Unmarshal(raw, &tr); err != nil {
+			return err
+		}
+		*runs = append(*runs, objects.NewTaskRunObject(&tr))
+	case pipeline.PipelineRunControllerName:
+		var pr v1beta1.PipelineRun
+		if err := json.Unmarshal(raw, &pr); err != nil {
+			return err
+		}
+		*runs = append(*runs, objects.NewPipelineRunObject(&pr))
+	default:
+		return fmt.Errorf("unsupported object %s %s, only %s TaskRuns and PipelineRuns are supported", doc.APIVersion, doc.Kind, v1beta1.SchemeGroupVersion)
+	}
+	return nil
+}
+
+// printPreviews writes previews to w in the given format.
+func printPreviews(w io.Writer, format string, previews []runPayloads) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(previews)
+	case "text":
+		for _, run := range previews {
+			for _, p := range run.Payloads {
+				fmt.Fprintf(w, "# %s %s/%s: %s %s (%s)\n", run.Kind, run.Namespace, run.Name, p.Type, p.Key, p.Format)
+				if p.Error != "" {
+					fmt.Fprintf(w, "error: %s\n\n", p.Error)
+					continue
+				}
+				if len(p.Truncated) > 0 {
+					fmt.Fprintf(w, "# truncated: %s\n", strings.Join(p.Truncated, ", "))
+				}
+				var indented bytes.Buffer
+				if err := json.Indent(&indented, p.Payload, "", "  "); err != nil {
+					return err
+				}
+				indented.WriteString("\n\n")
+				if _, err := indented.WriteTo(w); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
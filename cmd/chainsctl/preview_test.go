@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+)
+
+func TestDecodeRuns(t *testing.T) {
+	record := base64.StdEncoding.EncodeToString([]byte(`{"metadata": {"name": "from-results", "namespace": "ns"}}`))
+	tests := []struct {
+		name     string
+		input    string
+		want     []string
+		taskRuns map[string]int
+	}{{
+		name: "yaml documents",
+		input: `
+apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: pipeline
+  namespace: ns
+---
+apiVersion: tekton.dev/v1beta1
+kind: TaskRun
+metadata:
+  name: pipeline-build
+  namespace: ns
+  labels:
+    tekton.dev/pipelineRun: pipeline
+---
+apiVersion: tekton.dev/v1beta1
+kind: TaskRun
+metadata:
+  name: other
+  namespace: ns
+`,
+		want:     []string{"pipelinerun ns/pipeline", "taskrun ns/pipeline-build", "taskrun ns/other"},
+		taskRuns: map[string]int{"pipeline": 1},
+	}, {
+		name: "json list",
+		input: `{"apiVersion": "v1", "kind": "List", "items": [
+			{"apiVersion": "tekton.dev/v1beta1", "kind": "TaskRun", "metadata": {"name": "a", "namespace": "ns"}},
+			{"apiVersion": "tekton.dev/v1beta1", "kind": "TaskRun", "metadata": {"name": "b", "namespace": "ns"}}
+		]}`,
+		want: []string{"taskrun ns/a", "taskrun ns/b"},
+	}, {
+		name:  "results record",
+		input: `{"name": "ns/results/1/records/2", "data": {"type": "tekton.dev/v1beta1.TaskRun", "value": "` + record + `"}}`,
+		want:  []string{"taskrun ns/from-results"},
+	}, {
+		name: "results records",
+		input: `{"records": [
+			{"data": {"type": "tekton.dev/v1beta1.PipelineRun", "value": {"metadata": {"name": "p", "namespace": "ns"}}}}
+		]}`,
+		want: []string{"pipelinerun ns/p"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, err := decodeRuns(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, obj := range runs {
+				got = append(got, obj.GetKindName()+" "+obj.GetNamespace()+"/"+obj.GetName())
+				if pro, ok := obj.(*objects.PipelineRunObject); ok && len(pro.GetTaskRuns()) != tt.taskRuns[pro.Name] {
+					t.Errorf("PipelineRun %s has %d TaskRuns, want %d", pro.Name, len(pro.GetTaskRuns()), tt.taskRuns[pro.Name])
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("decodeRuns() (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeRuns_Unsupported(t *testing.T) {
+	for _, input := range []string{
+		`{"apiVersion": "tekton.dev/v1", "kind": "TaskRun", "metadata": {"name": "a"}}`,
+		`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "a"}}`,
+	} {
+		if _, err := decodeRuns(strings.NewReader(input)); err == nil {
+			t.Errorf("decodeRuns(%s) succeeded, expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/resign"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const resignUsage = `Usage: chainsctl resign [flags] taskrun|pipelinerun|all [NAME...]
+
+Clears what Chains recorded on the selected runs it already signed, or failed to
+sign, so that the controller signs them again with the current keys and formats.
+PipelineRuns are reset after their TaskRuns. Runs are selected by kind, names,
+namespace, labels and completion time, and with -outdated only the runs signed
+with a format version other than the one the current Chains configuration
+produces are selected, to regenerate their attestations after an upgrade or a
+format configuration change. Exits with status 1 if a run could not be reset.
+
+Flags:
+`
+
+func runResign(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("resign", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), resignUsage)
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig. Optional, defaults to the standard kubeconfig loading rules.")
+	namespace := fs.String("n", "", "Namespace of the runs. Optional, defaults to the namespace of the current context.")
+	allNamespaces := fs.Bool("A", false, "Select runs in all namespaces.")
+	selector := fs.String("l", "", "Label selector the runs must match. Optional.")
+	since := fs.String("since", "", "Select runs that completed at or after this RFC 3339 time, or this long ago, e.g. 72h. Optional.")
+	until := fs.String("until", "", "Select runs that completed before this RFC 3339 time, or this long ago. Optional.")
+	outdated := fs.Bool("outdated", false, "Select runs signed with a format version other than the one of the current Chains configuration.")
+	chainsNamespace := fs.String("chains-namespace", "tekton-chains", "Namespace Chains is installed in.")
+	dryRun := fs.Bool("dry-run", false, "Print the runs that would be reset without resetting them.")
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	r := resign.Resigner{
+		LabelSelector: *selector,
+		Names:         sets.New[string](fs.Args()[1:]...),
+		Outdated:      *outdated,
+		DryRun:        *dryRun,
+	}
+	switch fs.Arg(0) {
+	case "taskrun", "tr":
+		r.TaskRuns = true
+	case "pipelinerun", "pr":
+		r.PipelineRuns = true
+	case "all":
+		r.TaskRuns, r.PipelineRuns = true, true
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	now := time.Now()
+	var err error
+	if r.Since, err = parseTime(*since, now); err != nil {
+		fatalf("invalid -since: %v", err)
+	}
+	if r.Until, err = parseTime(*until, now); err != nil {
+		fatalf("invalid -until: %v", err)
+	}
+
+	c, err := newCluster(*kubeconfig, *namespace)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	r.Pipelineclientset, r.Namespace = c.ps, c.namespace
+	if *allNamespaces {
+		r.Namespace = ""
+	}
+	if *outdated {
+		cfg, err := c.chainsConfig(ctx, *chainsNamespace)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		r.Config = *cfg
+	}
+
+	result, err := r.Run(ctx)
+	if err != nil {
+		fatalf("error listing runs: %v", err)
+	}
+	verb := "reset"
+	if *dryRun {
+		verb = "would reset"
+	}
+	for _, run := range result.Reset {
+		fmt.Printf("%s %s\n", verb, run)
+	}
+	fmt.Printf("%d runs %s, %d failed\n", len(result.Reset), verb, result.Failed)
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseTime parses value as an RFC 3339 time, or as a duration before now. It returns
+// the zero time if value is empty.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "72h", want: now.Add(-72 * time.Hour)},
+		{value: "2023-05-01T10:00:00Z", want: time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTime(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
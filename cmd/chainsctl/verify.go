@@ -0,0 +1,173 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	sigs "github.com/sigstore/cosign/v2/pkg/signature"
+	rc "github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/sigstore/pkg/fulcioroots"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/verify"
+
+	// Register the provider-specific plugins, for keys in a KMS.
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/aws"
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/azure"
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/gcp"
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/hashivault"
+)
+
+const verifyUsage = `Usage: chainsctl verify [flags] taskrun|pipelinerun|image NAME
+
+Verifies the attestations Chains produced for a TaskRun, a PipelineRun or an
+image: their signatures, signing certificates, transparency log entries and
+SLSA predicates. Exits with status 1 if any check failed.
+
+Flags:
+`
+
+func runVerify(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), verifyUsage)
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to a kubeconfig. Optional, defaults to the standard kubeconfig loading rules.")
+	namespace := fs.String("n", "", "Namespace of the run. Optional, defaults to the namespace of the current context.")
+	chainsNamespace := fs.String("chains-namespace", "tekton-chains", "Namespace Chains is installed in.")
+	key := fs.String("key", "", "Public key to verify signatures with: a PEM file, a KMS URI or k8s://namespace/secret. Optional, certificates are verified against the Fulcio roots if unset.")
+	rekorURL := fs.String("rekor-url", "https://rekor.sigstore.dev", "Transparency log to check inclusion in. Empty to skip transparency checks.")
+	identity := fs.String("certificate-identity-regexp", "", "Regular expression the identity of signing certificates must match. Optional.")
+	issuer := fs.String("certificate-oidc-issuer-regexp", "", "Regular expression the OIDC issuer of signing certificates must match. Optional.")
+	output := fs.String("o", "text", "Output format: text or json.")
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	kind, target := fs.Arg(0), fs.Arg(1)
+
+	opts := verify.Options{}
+	if *identity != "" || *issuer != "" {
+		opts.Identities = []cosign.Identity{{SubjectRegExp: *identity, IssuerRegExp: *issuer}}
+	}
+	if *key != "" {
+		v, err := sigs.PublicKeyFromKeyRef(ctx, *key)
+		if err != nil {
+			fatalf("error loading key %s: %v", *key, err)
+		}
+		opts.Key = v
+	} else {
+		roots, err := fulcioroots.Get()
+		if err != nil {
+			fatalf("error getting Fulcio roots: %v", err)
+		}
+		intermediates, err := fulcioroots.GetIntermediates()
+		if err != nil {
+			fatalf("error getting Fulcio intermediates: %v", err)
+		}
+		opts.Roots, opts.Intermediates = roots, intermediates
+	}
+	if *rekorURL != "" {
+		rekor, err := rc.GetRekorClient(*rekorURL)
+		if err != nil {
+			fatalf("error creating Rekor client for %s: %v", *rekorURL, err)
+		}
+		pubs, err := cosign.GetRekorPubs(ctx)
+		if err != nil {
+			fatalf("error getting Rekor public keys: %v", err)
+		}
+		opts.Rekor, opts.RekorPubKeys = rekor, pubs
+	}
+
+	var results []verify.Result
+	switch kind {
+	case "image":
+		ref, err := name.ParseReference(target)
+		if err != nil {
+			fatalf("invalid image reference %s: %v", target, err)
+		}
+		results = verify.Image(ctx, ref, opts)
+	case "taskrun", "tr", "pipelinerun", "pr":
+		var err error
+		results, err = verifyRun(ctx, *kubeconfig, *namespace, *chainsNamespace, kind, target, opts)
+		if err != nil {
+			fatalf("%v", err)
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := printResults(os.Stdout, *output, results); err != nil {
+		fatalf("%v", err)
+	}
+	for _, r := range results {
+		if r.Failed() {
+			os.Exit(1)
+		}
+	}
+}
+
+// verifyRun verifies the attestations of the run of the given kind and name, with the
+// Chains configuration and storage backends of the cluster.
+func verifyRun(ctx context.Context, kubeconfig, namespace, chainsNamespace, kind, name string, opts verify.Options) ([]verify.Result, error) {
+	c, err := newCluster(kubeconfig, namespace)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := c.chainsConfig(ctx, chainsNamespace)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := c.run(ctx, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	backends, err := storage.InitializeBackends(ctx, c.ps, c.kc, *cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing storage backends: %w", err)
+	}
+	return verify.Run(ctx, obj, backends, *cfg, opts)
+}
+
+// printResults writes results to w in the given format.
+func printResults(w io.Writer, format string, results []verify.Result) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SOURCE\tKEY\tCHECK\tSTATUS\tDETAIL")
+		for _, r := range results {
+			for _, c := range r.Checks {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Source, r.Key, c.Name, c.Status, c.Detail)
+			}
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
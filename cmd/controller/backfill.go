@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/backfill"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/events"
+	"github.com/tektoncd/chains/pkg/chains/limits"
+	"github.com/tektoncd/chains/pkg/chains/metrics"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/reconciler/taskrun"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+)
+
+// serviceAccountTokenPath is where the token of the service account of the pod is mounted.
+const serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// resultsOptions configure backfilling the runs archived in Tekton Results. The runs of
+// the cluster are backfilled when url is empty.
+type resultsOptions struct {
+	url       string
+	tokenPath string
+	caPath    string
+}
+
+// client returns the client of the Tekton Results API of o.
+func (o resultsOptions) client() (*backfill.ResultsClient, error) {
+	token, err := os.ReadFile(o.tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading Tekton Results token: %w", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.caPath != "" {
+		ca, err := os.ReadFile(o.caPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading Tekton Results CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no PEM certificates in %s", o.caPath)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &backfill.ResultsClient{
+		URL:    o.url,
+		Token:  strings.TrimSpace(string(token)),
+		Client: &http.Client{Transport: transport},
+	}, nil
+}
+
+// runBackfill signs existing completed, unsigned runs once and exits, instead of
+// running the controllers. With results configured, the runs archived in Tekton Results
+// that are no longer in the cluster are signed instead.
+func runBackfill(ctx context.Context, maxAge time.Duration, results resultsOptions) {
+	logger, _ := logging.NewLogger("", "info")
+	defer func() { _ = logger.Sync() }()
+	ctx = logging.WithLogger(ctx, logger)
+
+	ctx, _ = injection.EnableInjectionOrDie(ctx, injection.ParseAndGetRESTConfigOrDie())
+	kubeClient := kubeclient.Get(ctx)
+	pipelineClient := pipelineclient.Get(ctx)
+
+	cm, err := kubeClient.CoreV1().ConfigMaps(system.Namespace()).Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+	if err != nil {
+		logger.Fatalf("error getting %s config map: %v", config.ChainsConfig, err)
+	}
+	cfg, err := config.NewConfigFromConfigMap(cm)
+	if err != nil {
+		logger.Fatalf("error parsing %s config map: %v", config.ChainsConfig, err)
+	}
+	ctx = config.ToContext(ctx, cfg)
+	if err := audit.Setup(cfg.Audit); err != nil {
+		logger.Errorf("error configuring audit log: %v", err)
+	}
+	if err := events.Setup(cfg.Events); err != nil {
+		logger.Errorf("error configuring CloudEvents: %v", err)
+	}
+	limits.Setup(cfg.Concurrency)
+	metrics.Setup(cfg.Metrics)
+
+	var skip sets.Set[types.UID]
+	if results.url != "" {
+		rc, err := results.client()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		trs, prs, err := rc.Runs(ctx, injection.GetNamespaceScope(ctx))
+		if err != nil {
+			logger.Fatalf("error listing the runs archived in Tekton Results: %v", err)
+		}
+		if skip, err = backfill.InCluster(ctx, pipelineClient, injection.GetNamespaceScope(ctx)); err != nil {
+			logger.Fatalf("error listing the runs of the cluster: %v", err)
+		}
+		logger.Infof("backfilling %d TaskRuns and %d PipelineRuns archived in Tekton Results", len(trs), len(prs))
+		pipelineClient = backfill.Offline(trs, prs)
+	}
+
+	backends, err := storage.InitializeBackends(ctx, pipelineClient, kubeClient, *cfg)
+	if err != nil {
+		logger.Error(err)
+	}
+	b := &backfill.Backfiller{
+		Signer: &chains.ObjectSigner{
+			Backends:          backends,
+			SecretPath:        taskrun.SecretPath,
+			Pipelineclientset: pipelineClient,
+			KubeClient:        kubeClient,
+			DynamicClient:     dynamicclient.Get(ctx),
+		},
+		Pipelineclientset: pipelineClient,
+		Namespace:         injection.GetNamespaceScope(ctx),
+		MaxAge:            maxAge,
+		Skip:              skip,
+	}
+	result, err := b.Run(ctx)
+	if err != nil {
+		logger.Fatalf("error backfilling runs: %v", err)
+	}
+	logger.Infof("backfill complete: %d signed, %d skipped, %d failed", result.Signed, result.Skipped, result.Failed)
+}
@@ -15,7 +15,13 @@ package main
 
 import (
 	"flag"
+	"log"
 
+	"github.com/tektoncd/chains/pkg/chains/multicluster"
+	"github.com/tektoncd/chains/pkg/chains/reduce"
+	"github.com/tektoncd/chains/pkg/chains/runtypes"
+	"github.com/tektoncd/chains/pkg/reconciler/chainsconfig"
+	"github.com/tektoncd/chains/pkg/reconciler/customrun"
 	"github.com/tektoncd/chains/pkg/reconciler/pipelinerun"
 	"github.com/tektoncd/chains/pkg/reconciler/taskrun"
 	"knative.dev/pkg/injection"
@@ -35,11 +41,53 @@ import (
 	_ "github.com/sigstore/sigstore/pkg/signature/kms/hashivault"
 )
 
-var namespace = flag.String("namespace", "", "Namespace to restrict informer to. Optional, defaults to all namespaces.")
+var (
+	namespace      = flag.String("namespace", "", "Namespace to restrict informer to. Optional, defaults to all namespaces.")
+	backfillMode   = flag.Bool("backfill", false, "Sign existing completed, unsigned runs once and exit instead of running the controller.")
+	backfillMaxAge = flag.Duration("backfill-max-age", 0, "Only backfill runs that completed within this duration. Optional, defaults to all runs.")
+	resultsURL     = flag.String("backfill-results-url", "", "Address of the REST API of Tekton Results to backfill the archived runs of, including the runs deleted from the cluster, instead of the runs of the cluster. Optional.")
+	resultsToken   = flag.String("backfill-results-token-path", serviceAccountTokenPath, "Path of the bearer token to call the Tekton Results API with.")
+	resultsCA      = flag.String("backfill-results-ca-path", "", "Path of the PEM CA certificates to verify the Tekton Results API with. Optional, defaults to the system certificates.")
+	migrateConfig  = flag.Bool("migrate-config", false, "Create the chains-config ChainsConfig from the chains-config config map and exit instead of running the controller.")
+	reducedCache   = flag.Bool("reduced-informers", false, "Cache runs without managed fields and the parts of their spec and status that aren't needed to decide whether to sign them, fetching the full runs when signing them.")
+	pipelineRuns   = flag.Bool("pipelineruns-only", false, "Only sign PipelineRuns, without reconciling or caching TaskRuns, which are fetched from the API server when their PipelineRun is signed.")
+	kubeconfigs    = flag.String("cluster-kubeconfigs", "", "Directory of the kubeconfigs of workload clusters to also watch and sign runs in, each named after its cluster. Optional.")
+)
 
 func main() {
 	flag.Parse()
 	ctx := injection.WithNamespaceScope(signals.NewContext(), *namespace)
 
-	sharedmain.MainWithContext(ctx, "watcher", taskrun.NewController, pipelinerun.NewController)
+	if *backfillMode {
+		runBackfill(ctx, *backfillMaxAge, resultsOptions{url: *resultsURL, tokenPath: *resultsToken, caPath: *resultsCA})
+		return
+	}
+	if *migrateConfig {
+		runMigrateConfig(ctx)
+		return
+	}
+
+	if *reducedCache {
+		ctx = reduce.WithEnabled(ctx)
+	}
+	runCtors := []injection.ControllerConstructor{taskrun.NewController, pipelinerun.NewController, customrun.NewController}
+	if *pipelineRuns {
+		ctx = runtypes.WithPipelineRunsOnly(ctx)
+		injection.Default = runtypes.WithoutTaskRunInformer(injection.Default)
+		runCtors = []injection.ControllerConstructor{pipelinerun.NewController, customrun.NewController}
+	}
+	ctors := append(append([]injection.ControllerConstructor{}, runCtors...), chainsconfig.NewController)
+	clusters, err := multicluster.Load(*kubeconfigs)
+	if err != nil {
+		log.Fatalf("error loading cluster kubeconfigs: %v", err)
+	}
+	for _, c := range clusters {
+		ctors = append(ctors, c.Controllers(runCtors...)...)
+	}
+	if runningAsStatefulSet() {
+		cfg := injection.ParseAndGetRESTConfigOrDie()
+		sharedmain.MainWithConfig(withStatefulSetConfigOrDie(ctx, cfg), "watcher", cfg, ctors...)
+		return
+	}
+	sharedmain.MainWithContext(ctx, "watcher", ctors...)
 }
@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	"github.com/tektoncd/chains/pkg/reconciler/chainsconfig"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+)
+
+// runMigrateConfig creates the chains-config ChainsConfig from the chains-config
+// ConfigMap once and exits, instead of running the controllers.
+func runMigrateConfig(ctx context.Context) {
+	logger, _ := logging.NewLogger("", "info")
+	defer func() { _ = logger.Sync() }()
+	ctx = logging.WithLogger(ctx, logger)
+
+	ctx, _ = injection.EnableInjectionOrDie(ctx, injection.ParseAndGetRESTConfigOrDie())
+	created, err := chainsconfig.Migrate(ctx, dynamicclient.Get(ctx), kubeclient.Get(ctx), system.Namespace())
+	if err != nil {
+		logger.Fatalf("error migrating configuration: %v", err)
+	}
+	if created {
+		logger.Info("created ChainsConfig chains-config from the chains-config config map")
+	} else {
+		logger.Info("ChainsConfig chains-config already exists, nothing to migrate")
+	}
+}
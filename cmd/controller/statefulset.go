@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"knative.dev/pkg/leaderelection"
+	"knative.dev/pkg/system"
+)
+
+// statefulSetOrdinalEnv is set to the pod name when the controller runs as a
+// StatefulSet. Knative then assigns each replica the bucket of its ordinal instead
+// of electing bucket leaders through leases.
+const statefulSetOrdinalEnv = "STATEFUL_CONTROLLER_ORDINAL"
+
+// runningAsStatefulSet returns whether the controller is deployed as a StatefulSet
+// that partitions the key space by ordinal.
+func runningAsStatefulSet() bool {
+	_, ok := os.LookupEnv(statefulSetOrdinalEnv)
+	return ok
+}
+
+// withStatefulSetConfigOrDie loads the leader election config and checks that the
+// ordinal of this replica has a bucket. Knative silently falls back to lease-based
+// election otherwise, and this replica would then compete for the buckets that the
+// other replicas own by ordinal. The config is added to ctx so that the controllers
+// use the one that was checked.
+func withStatefulSetConfigOrDie(ctx context.Context, cfg *rest.Config) context.Context {
+	kc, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		log.Fatalf("error creating kubernetes client: %v", err)
+	}
+	cm, err := kc.CoreV1().ConfigMaps(system.Namespace()).Get(ctx, leaderelection.ConfigMapName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = nil
+	} else if err != nil {
+		log.Fatalf("error getting %s config map: %v", leaderelection.ConfigMapName(), err)
+	}
+	lec, err := leaderelection.NewConfigFromConfigMap(cm)
+	if err != nil {
+		log.Fatalf("error parsing %s config map: %v", leaderelection.ConfigMapName(), err)
+	}
+	if err := checkStatefulSetOrdinal(lec); err != nil {
+		log.Fatal(err)
+	}
+	return leaderelection.WithConfig(ctx, lec)
+}
+
+// checkStatefulSetOrdinal returns an error if the StatefulSet environment of this
+// replica is invalid or its ordinal is not smaller than the number of buckets.
+func checkStatefulSetOrdinal(lec *leaderelection.Config) error {
+	if _, _, err := leaderelection.NewStatefulSetBucketAndSet(int(lec.Buckets)); err != nil {
+		return fmt.Errorf("running as a StatefulSet with %d buckets: %w; the number of buckets in %s must be the number of replicas", lec.Buckets, err, leaderelection.ConfigMapName())
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"knative.dev/pkg/leaderelection"
+)
+
+func TestCheckStatefulSetOrdinal(t *testing.T) {
+	tests := []struct {
+		name    string
+		ordinal string
+		buckets uint32
+		wantErr bool
+	}{
+		{name: "last replica", ordinal: "tekton-chains-controller-2", buckets: 3},
+		{name: "more replicas than buckets", ordinal: "tekton-chains-controller-3", buckets: 3, wantErr: true},
+		{name: "invalid ordinal", ordinal: "tekton-chains-controller", buckets: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SYSTEM_NAMESPACE", "tekton-chains")
+			t.Setenv(statefulSetOrdinalEnv, tt.ordinal)
+			t.Setenv("STATEFUL_SERVICE_NAME", "tekton-chains-controller")
+
+			err := checkStatefulSetOrdinal(&leaderelection.Config{Buckets: tt.buckets})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkStatefulSetOrdinal() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+
+	chainswebhook "github.com/tektoncd/chains/pkg/webhook"
+	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/signals"
+	"knative.dev/pkg/webhook"
+	"knative.dev/pkg/webhook/certificates"
+)
+
+func main() {
+	serviceName := os.Getenv("WEBHOOK_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "tekton-chains-webhook"
+	}
+
+	ctx := webhook.WithOptions(signals.NewContext(), webhook.Options{
+		ServiceName: serviceName,
+		Port:        webhook.PortFromEnv(8443),
+		SecretName:  webhook.SecretNameFromEnv("tekton-chains-webhook-certs"),
+	})
+
+	sharedmain.MainWithContext(ctx, serviceName,
+		certificates.NewController,
+		chainswebhook.NewAdmissionController,
+	)
+}
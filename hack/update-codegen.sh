@@ -27,7 +27,7 @@ go install k8s.io/code-generator/cmd/deepcopy-gen
 ${GOPATH}/bin/deepcopy-gen \
   -O zz_generated.deepcopy \
   --go-header-file "${boilerplate}" \
-  -i github.com/tektoncd/chains/pkg/config
+  -i github.com/tektoncd/chains/pkg/config,github.com/tektoncd/chains/pkg/apis/chains/v1alpha1
 
 # Make sure our dependencies are up-to-date
 ${REPO_ROOT_DIR}/hack/update-deps.sh
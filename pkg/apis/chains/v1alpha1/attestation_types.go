@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Attestation is a signed payload of a run stored by the attestation storage backend.
+// It lives in the namespace of the run and is only written by the controller, but it
+// isn't owned by the run, so that it can be retained after the run is deleted.
+type Attestation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AttestationSpec `json:"spec,omitempty"`
+}
+
+// AttestationSpec is the signed payload and what it attests.
+type AttestationSpec struct {
+	Run RunReference `json:"run"`
+	// Key is the key of the signed artifact of the run.
+	Key string `json:"key"`
+	// Format is the payload format of the signed artifact.
+	Format string `json:"format"`
+	// Subjects are the subjects of in-toto attestations.
+	Subjects []AttestationSubject `json:"subjects,omitempty"`
+	// Payload is the signed payload.
+	Payload []byte `json:"payload"`
+	// Signature is the signature of the payload, the DSSE envelope of in-toto
+	// attestations.
+	Signature []byte `json:"signature"`
+	// Cert is the PEM signing certificate and Chain its PEM certificate chain, if any.
+	Cert  string `json:"cert,omitempty"`
+	Chain string `json:"chain,omitempty"`
+}
+
+// AttestationSubject is a subject of an in-toto attestation.
+type AttestationSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AttestationList is a list of Attestations.
+type AttestationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Attestation `json:"items"`
+}
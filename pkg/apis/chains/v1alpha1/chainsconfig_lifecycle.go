@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// ConditionApplied is true once the spec was rendered into the chains-config ConfigMap.
+	ConditionApplied apis.ConditionType = "Applied"
+	// ConditionConformant reports the outcome of the latest conformance probe. It does
+	// not affect Ready.
+	ConditionConformant apis.ConditionType = "Conformant"
+	// ConditionHealthy reports the outcome of the latest health checks of the storage
+	// backends and signers. It does not affect Ready.
+	ConditionHealthy apis.ConditionType = "Healthy"
+
+	// ReasonInvalid is set when the spec does not parse into a valid configuration.
+	ReasonInvalid = "InvalidConfiguration"
+	// ReasonUpdateFailed is set when the chains-config ConfigMap could not be written.
+	ReasonUpdateFailed = "UpdateFailed"
+	// ReasonIgnored is set on ChainsConfigs that are not named chains-config.
+	ReasonIgnored = "Ignored"
+	// ReasonProbePassed and ReasonProbeFailed are set on the Conformant condition.
+	ReasonProbePassed = "ProbePassed"
+	ReasonProbeFailed = "ProbeFailed"
+	// ReasonChecksPassed and ReasonChecksFailed are set on the Healthy condition.
+	ReasonChecksPassed = "ChecksPassed"
+	ReasonChecksFailed = "ChecksFailed"
+)
+
+var chainsConfigCondSet = apis.NewLivingConditionSet(ConditionApplied)
+
+// InitializeConditions sets the conditions that are not set yet to Unknown.
+func (s *ChainsConfigStatus) InitializeConditions() {
+	chainsConfigCondSet.Manage(s).InitializeConditions()
+}
+
+// MarkApplied records that the spec was rendered into data.
+func (s *ChainsConfigStatus) MarkApplied(data map[string]string) {
+	s.AppliedData = data
+	chainsConfigCondSet.Manage(s).MarkTrue(ConditionApplied)
+}
+
+// MarkNotApplied records why the spec could not be applied. The previously applied
+// data is kept, since it is still in effect.
+func (s *ChainsConfigStatus) MarkNotApplied(reason, messageFormat string, messageA ...interface{}) {
+	chainsConfigCondSet.Manage(s).MarkFalse(ConditionApplied, reason, messageFormat, messageA...)
+}
+
+// MarkConformant records that the latest conformance probe passed.
+func (s *ChainsConfigStatus) MarkConformant(messageFormat string, messageA ...interface{}) {
+	chainsConfigCondSet.Manage(s).MarkTrueWithReason(ConditionConformant, ReasonProbePassed, messageFormat, messageA...)
+}
+
+// MarkNotConformant records why the latest conformance probe failed.
+func (s *ChainsConfigStatus) MarkNotConformant(messageFormat string, messageA ...interface{}) {
+	chainsConfigCondSet.Manage(s).MarkFalse(ConditionConformant, ReasonProbeFailed, messageFormat, messageA...)
+}
+
+// IsReady returns whether the spec is in effect.
+func (s *ChainsConfigStatus) IsReady() bool {
+	return chainsConfigCondSet.Manage(s).IsHappy()
+}
+
+// MarkHealthy records that the latest health checks passed.
+func (s *ChainsConfigStatus) MarkHealthy(messageFormat string, messageA ...interface{}) {
+	chainsConfigCondSet.Manage(s).MarkTrueWithReason(ConditionHealthy, ReasonChecksPassed, messageFormat, messageA...)
+}
+
+// MarkNotHealthy records which of the latest health checks failed.
+func (s *ChainsConfigStatus) MarkNotHealthy(messageFormat string, messageA ...interface{}) {
+	chainsConfigCondSet.Manage(s).MarkFalse(ConditionHealthy, ReasonChecksFailed, messageFormat, messageA...)
+}
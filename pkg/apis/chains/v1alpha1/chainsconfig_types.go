@@ -0,0 +1,540 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ChainsConfig is the cluster-wide configuration of the Chains controller. Only the
+// object named "chains-config" is applied; it is rendered into the chains-config
+// ConfigMap, which it then owns.
+type ChainsConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ChainsConfigSpec   `json:"spec,omitempty"`
+	Status ChainsConfigStatus `json:"status,omitempty"`
+}
+
+// ChainsConfigSpec is the desired configuration. Unset fields keep their defaults.
+type ChainsConfigSpec struct {
+	Artifacts    ArtifactsSpec    `json:"artifacts,omitempty"`
+	Storage      StorageSpec      `json:"storage,omitempty"`
+	Signers      SignersSpec      `json:"signers,omitempty"`
+	Builder      BuilderSpec      `json:"builder,omitempty"`
+	Transparency TransparencySpec `json:"transparency,omitempty"`
+	Namespaces   NamespacesSpec   `json:"namespaces,omitempty"`
+	// LabelSelector is a label selector runs must match to be signed.
+	LabelSelector string                  `json:"labelSelector,omitempty"`
+	Retry         RetrySpec               `json:"retry,omitempty"`
+	Finalizer     FinalizerSpec           `json:"finalizer,omitempty"`
+	DryRun        DryRunSpec              `json:"dryRun,omitempty"`
+	Audit         AuditSpec               `json:"audit,omitempty"`
+	Webhook       WebhookSpec             `json:"webhook,omitempty"`
+	Overrides     OverridesSpec           `json:"overrides,omitempty"`
+	Concurrency   ConcurrencySpec         `json:"concurrency,omitempty"`
+	Lease         LeaseSpec               `json:"lease,omitempty"`
+	Shutdown      ShutdownSpec            `json:"shutdown,omitempty"`
+	SigningStatus SigningStatusConfigSpec `json:"signingStatus,omitempty"`
+	Events        EventsSpec              `json:"events,omitempty"`
+	Query         QuerySpec               `json:"query,omitempty"`
+	GRPC          GRPCSpec                `json:"grpc,omitempty"`
+	PublicKeys    PublicKeysSpec          `json:"publicKeys,omitempty"`
+	Tracing       TracingSpec             `json:"tracing,omitempty"`
+	Conformance   ConformanceSpec         `json:"conformance,omitempty"`
+	Health        HealthSpec              `json:"health,omitempty"`
+	Metrics       MetricsSpec             `json:"metrics,omitempty"`
+	Provenance    ProvenanceSpec          `json:"provenance,omitempty"`
+	Isolation     IsolationSpec           `json:"isolation,omitempty"`
+	Policy        PolicySpec              `json:"policy,omitempty"`
+	Materials     MaterialsSpec           `json:"materials,omitempty"`
+	Clusters      ClustersSpec            `json:"clusters,omitempty"`
+	Profiling     ProfilingSpec           `json:"profiling,omitempty"`
+	Sigstore      SigstoreSpec            `json:"sigstore,omitempty"`
+}
+
+// ArtifactsSpec configures how each artifact type is formatted, signed and stored.
+type ArtifactsSpec struct {
+	TaskRuns     ArtifactSpec `json:"taskRuns,omitempty"`
+	PipelineRuns ArtifactSpec `json:"pipelineRuns,omitempty"`
+	OCI          ArtifactSpec `json:"oci,omitempty"`
+	// VEX configures the OpenVEX documents produced by runs. Only Storage,
+	// Signer and Disabled are used.
+	VEX ArtifactSpec `json:"vex,omitempty"`
+	// TektonBundles configures the attestations of the Tekton bundles built by
+	// runs. Only Storage, Signer and Disabled are used.
+	TektonBundles ArtifactSpec `json:"tektonBundles,omitempty"`
+	// Source configures the attestations of the source commits runs built from,
+	// produced with keyless signing. Only Storage and Disabled are used.
+	Source ArtifactSpec `json:"source,omitempty"`
+	// CustomRuns configures the provenance of CustomRuns, which is only signed
+	// when Storage is set.
+	CustomRuns ArtifactSpec `json:"customRuns,omitempty"`
+}
+
+// ArtifactSpec configures a single artifact type.
+type ArtifactSpec struct {
+	Format  string   `json:"format,omitempty"`
+	Storage []string `json:"storage,omitempty"`
+	Signer  string   `json:"signer,omitempty"`
+	// Disabled turns off signing the artifact type.
+	Disabled bool `json:"disabled,omitempty"`
+	// EnableDeepInspection inspects the TaskRuns of a PipelineRun for artifacts.
+	// Only used for PipelineRuns.
+	EnableDeepInspection bool `json:"enableDeepInspection,omitempty"`
+	// BundleStorage are the backends an aggregated bundle of envelopes is stored in.
+	// Only used for PipelineRuns.
+	BundleStorage []string `json:"bundleStorage,omitempty"`
+	// ResultDigests records the digest of every result in its byproduct.
+	// Only used for PipelineRuns.
+	ResultDigests bool `json:"resultDigests,omitempty"`
+	// FetchRuns records how TaskRuns that only fetch source fetched the commit.
+	// Only used for source commits.
+	FetchRuns bool `json:"fetchRuns,omitempty"`
+}
+
+// StorageSpec configures the storage backends.
+type StorageSpec struct {
+	GCS     *GCSStorageSpec     `json:"gcs,omitempty"`
+	OCI     *OCIStorageSpec     `json:"oci,omitempty"`
+	Tekton  *TektonStorageSpec  `json:"tekton,omitempty"`
+	DocDB   *DocDBStorageSpec   `json:"docdb,omitempty"`
+	Grafeas *GrafeasStorageSpec `json:"grafeas,omitempty"`
+	PubSub  *PubSubStorageSpec  `json:"pubsub,omitempty"`
+	IPFS    *IPFSStorageSpec    `json:"ipfs,omitempty"`
+	GitHub  *GitHubStorageSpec  `json:"github,omitempty"`
+	GitLab  *GitLabStorageSpec  `json:"gitlab,omitempty"`
+}
+
+type GCSStorageSpec struct {
+	Bucket string `json:"bucket,omitempty"`
+	// NameTemplate is the template of the names of the objects payloads are stored in.
+	NameTemplate string `json:"nameTemplate,omitempty"`
+}
+
+type OCIStorageSpec struct {
+	Repository string `json:"repository,omitempty"`
+	Insecure   bool   `json:"insecure,omitempty"`
+	// Tags are templates of the tags to apply to the attestations stored in OCI
+	// registries, like "$(pipeline.name)-$(params.version)".
+	Tags []string `json:"tags,omitempty"`
+	// AttachToPlatforms also attaches the attestations of image indexes to each
+	// platform-specific image of the index.
+	AttachToPlatforms bool `json:"attachToPlatforms,omitempty"`
+}
+
+type TektonStorageSpec struct {
+	// MaxAnnotationSize is the size in bytes above which values stored in annotations
+	// are split into chunks across several annotations.
+	MaxAnnotationSize int `json:"maxAnnotationSize,omitempty"`
+}
+
+type DocDBStorageSpec struct {
+	URL string `json:"url,omitempty"`
+	// NameTemplate is the template of the names documents are keyed by.
+	NameTemplate string `json:"nameTemplate,omitempty"`
+}
+
+type GrafeasStorageSpec struct {
+	ProjectID string `json:"projectID,omitempty"`
+	NoteID    string `json:"noteID,omitempty"`
+	NoteHint  string `json:"noteHint,omitempty"`
+}
+
+type PubSubStorageSpec struct {
+	Provider string `json:"provider,omitempty"`
+	Topic    string `json:"topic,omitempty"`
+	// KafkaBootstrapServers are the Kafka brokers used by the kafka provider.
+	KafkaBootstrapServers string `json:"kafkaBootstrapServers,omitempty"`
+	// EventHubsNamespace is the Event Hubs namespace used by the eventhubs provider.
+	EventHubsNamespace string `json:"eventHubsNamespace,omitempty"`
+	// BatchSize is the number of payloads published together in a single message.
+	BatchSize int `json:"batchSize,omitempty"`
+	// BatchLinger is how long a batch waits for more payloads.
+	BatchLinger *metav1.Duration `json:"batchLinger,omitempty"`
+	// GRPCAddress is the address of the collector the grpc provider streams payloads to.
+	GRPCAddress string `json:"grpcAddress,omitempty"`
+	// GRPCInsecure connects to the collector without TLS.
+	GRPCInsecure bool `json:"grpcInsecure,omitempty"`
+	// GRPCCAPath is the path of a PEM bundle of the CAs of the collector.
+	GRPCCAPath string `json:"grpcCAPath,omitempty"`
+	// GRPCMaxInFlight is the number of payloads the collector may not have acknowledged.
+	GRPCMaxInFlight int `json:"grpcMaxInFlight,omitempty"`
+	// GRPCTimeout is how long payloads wait for the collector to acknowledge them.
+	GRPCTimeout *metav1.Duration `json:"grpcTimeout,omitempty"`
+	// MQTTBroker is the URL of the broker the mqtt provider publishes payloads to.
+	MQTTBroker string `json:"mqttBroker,omitempty"`
+	// MQTTQoS is the quality of service payloads are published with, 0, 1 or 2.
+	MQTTQoS string `json:"mqttQoS,omitempty"`
+	// MQTTClientID identifies the controller to the broker.
+	MQTTClientID string `json:"mqttClientID,omitempty"`
+	// MQTTCredentialsPath is the path of a file holding user:password.
+	MQTTCredentialsPath string `json:"mqttCredentialsPath,omitempty"`
+	// MQTTCAPath is the path of a PEM bundle of the CAs of the broker.
+	MQTTCAPath string `json:"mqttCAPath,omitempty"`
+	// SchemaRegistryURL is the URL of the schema registry the messages are encoded with.
+	SchemaRegistryURL string `json:"schemaRegistryURL,omitempty"`
+	// SchemaRegistryFormat is avro or protobuf.
+	SchemaRegistryFormat string `json:"schemaRegistryFormat,omitempty"`
+	// SchemaRegistrySubjectNameStrategy is topic-name, record-name or topic-record-name.
+	SchemaRegistrySubjectNameStrategy string `json:"schemaRegistrySubjectNameStrategy,omitempty"`
+	// SchemaRegistryBasicAuthPath is the path of a file holding user:password.
+	SchemaRegistryBasicAuthPath string `json:"schemaRegistryBasicAuthPath,omitempty"`
+}
+
+type IPFSStorageSpec struct {
+	URL string `json:"url,omitempty"`
+	// TokenPath is the path of a file holding the bearer token of the API.
+	TokenPath string `json:"tokenPath,omitempty"`
+}
+
+type GitHubStorageSpec struct {
+	URL            string `json:"url,omitempty"`
+	Repository     string `json:"repository,omitempty"`
+	AppID          int64  `json:"appID,omitempty"`
+	InstallationID int64  `json:"installationID,omitempty"`
+	PrivateKeyPath string `json:"privateKeyPath,omitempty"`
+}
+
+type GitLabStorageSpec struct {
+	URL       string `json:"url,omitempty"`
+	Project   string `json:"project,omitempty"`
+	TokenPath string `json:"tokenPath,omitempty"`
+	Package   string `json:"package,omitempty"`
+}
+
+// SignersSpec configures the signers.
+type SignersSpec struct {
+	X509     *X509SignerSpec      `json:"x509,omitempty"`
+	KMS      *KMSSignerSpec       `json:"kms,omitempty"`
+	Secret   *SigningSecretSpec   `json:"secret,omitempty"`
+	External *ExternalSecretsSpec `json:"external,omitempty"`
+}
+
+// ExternalSecretsSpec references the files of the signing secrets in external secret
+// managers.
+type ExternalSecretsSpec struct {
+	Refs            map[string]string `json:"refs,omitempty"`
+	RefreshInterval *metav1.Duration  `json:"refreshInterval,omitempty"`
+}
+
+// SigningSecretSpec references the secret the signing keys are read from.
+type SigningSecretSpec struct {
+	Namespace         string   `json:"namespace,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+}
+
+type X509SignerSpec struct {
+	Fulcio                *FulcioSpec `json:"fulcio,omitempty"`
+	IdentityTokenFile     string      `json:"identityTokenFile,omitempty"`
+	IdentityTokenCommand  string      `json:"identityTokenCommand,omitempty"`
+	IdentityTokenAudience string      `json:"identityTokenAudience,omitempty"`
+	TUFMirrorURL          string      `json:"tufMirrorURL,omitempty"`
+}
+
+type FulcioSpec struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	Address  string `json:"address,omitempty"`
+	Issuer   string `json:"issuer,omitempty"`
+	Provider string `json:"provider,omitempty"`
+}
+
+type KMSSignerSpec struct {
+	KMSRef string       `json:"kmsRef,omitempty"`
+	Auth   *KMSAuthSpec `json:"auth,omitempty"`
+}
+
+type KMSAuthSpec struct {
+	Address       string `json:"address,omitempty"`
+	Token         string `json:"token,omitempty"`
+	OIDCPath      string `json:"oidcPath,omitempty"`
+	OIDCRole      string `json:"oidcRole,omitempty"`
+	SpireSock     string `json:"spireSock,omitempty"`
+	SpireAudience string `json:"spireAudience,omitempty"`
+}
+
+type BuilderSpec struct {
+	ID        string `json:"id,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+	BuildType string `json:"buildType,omitempty"`
+}
+
+// TransparencySpec configures uploading entries to a transparency log.
+type TransparencySpec struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// VerifyAnnotation only uploads entries for runs annotated with
+	// chains.tekton.dev/transparency-upload. It implies Enabled.
+	VerifyAnnotation bool   `json:"verifyAnnotation,omitempty"`
+	URL              string `json:"url,omitempty"`
+	// Version is the Rekor API entries are uploaded with, v1 or v2.
+	Version string `json:"version,omitempty"`
+	// CAPath is the path of a PEM bundle of the CAs of a private Rekor instance.
+	CAPath string `json:"caPath,omitempty"`
+	// CertPath and KeyPath are the paths of the PEM client certificate and key
+	// presented to Rekor instances requiring mTLS.
+	CertPath string `json:"certPath,omitempty"`
+	KeyPath  string `json:"keyPath,omitempty"`
+	// ProxyURL is the URL of the proxy Rekor is connected to through.
+	ProxyURL string                `json:"proxyURL,omitempty"`
+	Queue    TransparencyQueueSpec `json:"queue,omitempty"`
+	// Timeout bounds how long uploading an entry may take.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// TransparencyQueueSpec configures queueing entries while the transparency log is
+// unreachable.
+type TransparencyQueueSpec struct {
+	Enabled  bool             `json:"enabled,omitempty"`
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// NamespacesSpec restricts the namespaces runs are signed in.
+type NamespacesSpec struct {
+	Watched  []string `json:"watched,omitempty"`
+	Excluded []string `json:"excluded,omitempty"`
+}
+
+// RetrySpec configures how failures to sign or store provenance are retried.
+type RetrySpec struct {
+	MaxRetries        int              `json:"maxRetries,omitempty"`
+	InitialBackoff    *metav1.Duration `json:"initialBackoff,omitempty"`
+	BackoffMultiplier float64          `json:"backoffMultiplier,omitempty"`
+	MaxBackoff        *metav1.Duration `json:"maxBackoff,omitempty"`
+	Jitter            float64          `json:"jitter,omitempty"`
+	NoRetryErrors     []string         `json:"noRetryErrors,omitempty"`
+}
+
+// FinalizerSpec configures how long the Chains finalizer holds runs that are being deleted.
+type FinalizerSpec struct {
+	BlockDeletion bool             `json:"blockDeletion,omitempty"`
+	Timeout       *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// DryRunSpec configures generating payloads without signing or storing them.
+type DryRunSpec struct {
+	Enabled    bool     `json:"enabled,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	Directory  string   `json:"directory,omitempty"`
+}
+
+// AuditSpec configures the structured audit log of signing decisions.
+type AuditSpec struct {
+	Sink     string `json:"sink,omitempty"`
+	FilePath string `json:"filePath,omitempty"`
+	KeyUsage bool   `json:"keyUsage,omitempty"`
+}
+
+// WebhookSpec configures the optional validating admission webhook.
+type WebhookSpec struct {
+	RejectInvalid bool `json:"rejectInvalid,omitempty"`
+}
+
+// OverridesSpec configures which keys runs may override with annotations.
+type OverridesSpec struct {
+	AllowedKeys []string `json:"allowedKeys,omitempty"`
+}
+
+// ConcurrencySpec tunes signing throughput against KMS and registry quotas.
+type ConcurrencySpec struct {
+	TaskRunWorkers     int     `json:"taskRunWorkers,omitempty"`
+	PipelineRunWorkers int     `json:"pipelineRunWorkers,omitempty"`
+	SigningRate        float64 `json:"signingRate,omitempty"`
+	SigningBurst       int     `json:"signingBurst,omitempty"`
+	// BackendLimits caps the number of concurrent uploads to each storage backend.
+	BackendLimits     map[string]int `json:"backendLimits,omitempty"`
+	UploadParallelism int            `json:"uploadParallelism,omitempty"`
+	// BackendTimeouts and SignerTimeouts bound how long storing a payload in each
+	// storage backend and signing it with each signer may take.
+	BackendTimeouts map[string]metav1.Duration `json:"backendTimeouts,omitempty"`
+	SignerTimeouts  map[string]metav1.Duration `json:"signerTimeouts,omitempty"`
+	// NamespaceLimit caps the number of runs of each namespace signed at once, and
+	// NamespaceLimits overrides it for some namespaces.
+	NamespaceLimit  int            `json:"namespaceLimit,omitempty"`
+	NamespaceLimits map[string]int `json:"namespaceLimits,omitempty"`
+}
+
+// LeaseSpec configures the Lease a controller replica holds on a run while signing it.
+type LeaseSpec struct {
+	Enabled  bool             `json:"enabled,omitempty"`
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// ShutdownSpec configures how the controller shuts down.
+type ShutdownSpec struct {
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+}
+
+// SigningStatusConfigSpec configures reporting the outcome of signing each run in a SigningStatus.
+type SigningStatusConfigSpec struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// EventsSpec configures emitting CloudEvents about attestations.
+type EventsSpec struct {
+	Sink string `json:"sink,omitempty"`
+}
+
+// PolicySpec configures the policies payloads are evaluated against before they are signed.
+type PolicySpec struct {
+	OPAURL  string `json:"opaURL,omitempty"`
+	OPAPath string `json:"opaPath,omitempty"`
+}
+
+// PublicKeysSpec configures publishing the verification material of the signers.
+type PublicKeysSpec struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// QuerySpec configures the attestation query API.
+type QuerySpec struct {
+	Address string `json:"address,omitempty"`
+	// CertPath and KeyPath are the paths of the PEM certificate and key the API is
+	// served with.
+	CertPath   string `json:"certPath,omitempty"`
+	KeyPath    string `json:"keyPath,omitempty"`
+	MaxResults int    `json:"maxResults,omitempty"`
+	// MaxRuns bounds the number of runs whose attestations are read for a query.
+	MaxRuns int `json:"maxRuns,omitempty"`
+}
+
+// GRPCSpec configures the on-demand attestation gRPC API.
+type GRPCSpec struct {
+	Address string `json:"address,omitempty"`
+	// CertPath and KeyPath are the paths of the PEM certificate and key the API is
+	// served with.
+	CertPath string `json:"certPath,omitempty"`
+	KeyPath  string `json:"keyPath,omitempty"`
+	// ClientCAPath is the path of a PEM bundle of the CAs client certificates are
+	// verified with.
+	ClientCAPath string `json:"clientCAPath,omitempty"`
+}
+
+// TracingSpec configures exporting OpenTelemetry traces.
+type TracingSpec struct {
+	OTLPEndpoint string `json:"otlpEndpoint,omitempty"`
+	OTLPInsecure bool   `json:"otlpInsecure,omitempty"`
+}
+
+// ConformanceSpec configures the conformance probe.
+type ConformanceSpec struct {
+	Enabled   bool             `json:"enabled,omitempty"`
+	Interval  *metav1.Duration `json:"interval,omitempty"`
+	Timeout   *metav1.Duration `json:"timeout,omitempty"`
+	Namespace string           `json:"namespace,omitempty"`
+	Image     string           `json:"image,omitempty"`
+}
+
+// HealthSpec configures the health checks of the storage backends and signers.
+type HealthSpec struct {
+	Enabled  bool             `json:"enabled,omitempty"`
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	Timeout  *metav1.Duration `json:"timeout,omitempty"`
+	Address  string           `json:"address,omitempty"`
+}
+
+// MetricsSpec configures the namespace and pipeline labels of the signing and storage
+// metrics.
+type MetricsSpec struct {
+	NamespaceLabel bool     `json:"namespaceLabel,omitempty"`
+	PipelineLabel  bool     `json:"pipelineLabel,omitempty"`
+	Namespaces     []string `json:"namespaces,omitempty"`
+	MaxLabelValues int      `json:"maxLabelValues,omitempty"`
+}
+
+// ProvenanceSpec caps the size of the param and result values recorded in provenance,
+// and of the attestations, and configures chaining the attestations of input artifacts
+// and recording the environment and configuration runs were signed in, validating
+// payloads, embedding the content of small input artifacts, computing the
+// completeness of SLSA v0.2 predicates and recording the env vars of steps.
+type ProvenanceSpec struct {
+	MaxValueKB             int      `json:"maxValueKB,omitempty"`
+	OversizedValues        string   `json:"oversizedValues,omitempty"`
+	MaxAttestationKB       int      `json:"maxAttestationKB,omitempty"`
+	ChainInputAttestations bool     `json:"chainInputAttestations,omitempty"`
+	RecordEnvironment      bool     `json:"recordEnvironment,omitempty"`
+	ConfigSnapshot         string   `json:"configSnapshot,omitempty"`
+	ValidatePayloads       bool     `json:"validatePayloads,omitempty"`
+	DigestAlgorithms       []string `json:"digestAlgorithms,omitempty"`
+	MaxInlineContentBytes  int      `json:"maxInlineContentBytes,omitempty"`
+	ComputeCompleteness    bool     `json:"computeCompleteness,omitempty"`
+	StepEnv                string   `json:"stepEnv,omitempty"`
+	StepEnvAllow           []string `json:"stepEnvAllow,omitempty"`
+	StepEnvDeny            []string `json:"stepEnvDeny,omitempty"`
+}
+
+// IsolationSpec configures the signals that show the pods of a run were isolated.
+type IsolationSpec struct {
+	SandboxRuntimeClasses []string `json:"sandboxRuntimeClasses,omitempty"`
+	NetworkLabels         []string `json:"networkLabels,omitempty"`
+}
+
+// MaterialsSpec configures the registries and URI prefixes materials are allowed to come from.
+type MaterialsSpec struct {
+	AllowedPrefixes []string `json:"allowedPrefixes,omitempty"`
+	Enforcement     string   `json:"enforcement,omitempty"`
+}
+
+// ClustersSpec configures the workload clusters Chains watches runs in.
+type ClustersSpec struct {
+	// BuilderIDs are the builder IDs of the runs of each cluster, keyed by cluster name.
+	BuilderIDs map[string]string `json:"builderIDs,omitempty"`
+}
+
+// SigstoreSpec maps namespaces to the Sigstore stacks their runs are signed with.
+type SigstoreSpec struct {
+	// Stacks are the Sigstore stacks, keyed by name.
+	Stacks map[string]SigstoreStackSpec `json:"stacks,omitempty"`
+}
+
+// SigstoreStackSpec is a Sigstore deployment and the namespaces signed with it.
+type SigstoreStackSpec struct {
+	Namespaces    []string `json:"namespaces,omitempty"`
+	TUFMirrorURL  string   `json:"tufMirrorURL,omitempty"`
+	FulcioAddress string   `json:"fulcioAddress,omitempty"`
+	FulcioIssuer  string   `json:"fulcioIssuer,omitempty"`
+	RekorURL      string   `json:"rekorURL,omitempty"`
+}
+
+// ProfilingSpec configures the pprof endpoints and periodic heap profiles of the controller.
+type ProfilingSpec struct {
+	Address      string           `json:"address,omitempty"`
+	HeapInterval *metav1.Duration `json:"heapInterval,omitempty"`
+	HeapDir      string           `json:"heapDir,omitempty"`
+	HeapKeep     int              `json:"heapKeep,omitempty"`
+}
+
+// ChainsConfigStatus reports whether the configuration was applied.
+type ChainsConfigStatus struct {
+	duckv1.Status `json:",inline"`
+
+	// AppliedData is the chains-config data the spec was last rendered to.
+	AppliedData map[string]string `json:"appliedData,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ChainsConfigList is a list of ChainsConfigs.
+type ChainsConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ChainsConfig `json:"items"`
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the API group of the Chains resources.
+const GroupName = "chains.tekton.dev"
+
+// SchemeGroupVersion is the group version used to register these objects.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// ChainsConfigResource is the resource the ChainsConfig kind is served as.
+var ChainsConfigResource = SchemeGroupVersion.WithResource("chainsconfigs")
+
+// SigningStatusResource is the resource the SigningStatus kind is served as.
+var SigningStatusResource = SchemeGroupVersion.WithResource("signingstatuses")
+
+// TransparencyEntryResource is the resource the TransparencyEntry kind is served as.
+var TransparencyEntryResource = SchemeGroupVersion.WithResource("transparencyentries")
+
+// AttestationResource is the resource the Attestation kind is served as.
+var AttestationResource = SchemeGroupVersion.WithResource("attestations")
+
+var (
+	schemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+	// AddToScheme adds the types of this group version to a scheme.
+	AddToScheme = schemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion,
+		&ChainsConfig{},
+		&ChainsConfigList{},
+		&SigningStatus{},
+		&SigningStatusList{},
+		&TransparencyEntry{},
+		&TransparencyEntryList{},
+		&Attestation{},
+		&AttestationList{},
+	)
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaxSigningAttempts is the number of attempts a SigningStatus keeps in its history.
+const MaxSigningAttempts = 10
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SigningStatus reports how Chains handled a single TaskRun or PipelineRun. It lives in
+// the namespace of the run, is owned by it and is only written by the controller.
+type SigningStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SigningStatusSpec   `json:"spec,omitempty"`
+	Status SigningStatusStatus `json:"status,omitempty"`
+}
+
+// SigningStatusSpec identifies the run a SigningStatus reports on.
+type SigningStatusSpec struct {
+	Run RunReference `json:"run"`
+}
+
+// RunReference refers to a TaskRun or PipelineRun in the namespace of the SigningStatus.
+type RunReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	UID  string `json:"uid,omitempty"`
+}
+
+// SigningStatusStatus is the outcome of the last attempt to sign the run.
+type SigningStatusStatus struct {
+	// Decision is the outcome of the last attempt: signed, failed or dry-run.
+	Decision string `json:"decision,omitempty"`
+	// Reason explains why the last attempt failed.
+	Reason string `json:"reason,omitempty"`
+	// Artifacts are the signable objects of the run as of the last attempt.
+	Artifacts []SignedArtifact `json:"artifacts,omitempty"`
+	// Attempts is the history of attempts to sign the run, oldest first. Only the
+	// last MaxSigningAttempts are kept.
+	Attempts []SigningAttempt `json:"attempts,omitempty"`
+}
+
+// SignedArtifact describes how a single signable object of a run was handled.
+type SignedArtifact struct {
+	Type     string   `json:"type"`
+	Key      string   `json:"key,omitempty"`
+	Format   string   `json:"format,omitempty"`
+	Subjects []string `json:"subjects,omitempty"`
+	Signer   string   `json:"signer,omitempty"`
+	// Identity is the identity in the signing certificate, if any.
+	Identity string `json:"identity,omitempty"`
+	// Signatures are the base64-encoded signatures of the payload.
+	Signatures []string `json:"signatures,omitempty"`
+	// Storage are the storage backends the signature was written to.
+	Storage []string `json:"storage,omitempty"`
+	// Transparency is the transparency log entry of the signature, if any.
+	Transparency string `json:"transparency,omitempty"`
+	// Locations are where the payload was stored, by storage backend. The UUID of the
+	// transparency log entry is recorded under rekor.
+	Locations map[string][]string `json:"locations,omitempty"`
+	// Error is set if the artifact was not signed or not stored in all backends.
+	Error string `json:"error,omitempty"`
+}
+
+// SigningAttempt is a single attempt to sign a run.
+type SigningAttempt struct {
+	Time     metav1.Time `json:"time"`
+	Decision string      `json:"decision"`
+	Reason   string      `json:"reason,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SigningStatusList is a list of SigningStatuses.
+type SigningStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []SigningStatus `json:"items"`
+}
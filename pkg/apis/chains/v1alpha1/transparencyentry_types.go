@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TransparencyEntry is a signature of a run that couldn't be uploaded to the
+// transparency log because it was unreachable, queued until it is uploaded. It lives in
+// the namespace of the run, is owned by it and is only written by the controller.
+type TransparencyEntry struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TransparencyEntrySpec   `json:"spec,omitempty"`
+	Status TransparencyEntryStatus `json:"status,omitempty"`
+}
+
+// TransparencyEntrySpec is what is uploaded to the transparency log.
+type TransparencyEntrySpec struct {
+	Run RunReference `json:"run"`
+	// Key is the key of the signed artifact of the run.
+	Key string `json:"key"`
+	// Format is the payload format of the signed artifact.
+	Format string `json:"format"`
+	// URL and Version are the transparency log the entry is uploaded to and its API.
+	URL     string `json:"url"`
+	Version string `json:"version,omitempty"`
+	// Signature is the signature of the payload, the DSSE envelope of in-toto
+	// attestations.
+	Signature []byte `json:"signature"`
+	// Payload is the signed payload. It is only set for formats that aren't wrapped in
+	// a DSSE envelope.
+	Payload []byte `json:"payload,omitempty"`
+	// Cert is the PEM signing certificate, if any, and PublicKey the PEM public key of
+	// the signer otherwise.
+	Cert      string `json:"cert,omitempty"`
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+// TransparencyEntryStatus records the attempts to upload the entry.
+type TransparencyEntryStatus struct {
+	// Attempts is the number of failed attempts to upload the entry.
+	Attempts int `json:"attempts,omitempty"`
+	// LastAttempt is when the entry was last attempted to be uploaded.
+	LastAttempt *metav1.Time `json:"lastAttempt,omitempty"`
+	// LastError is why the last attempt failed.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TransparencyEntryList is a list of TransparencyEntries.
+type TransparencyEntryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []TransparencyEntry `json:"items"`
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	"github.com/opencontainers/go-digest"
 	"github.com/tektoncd/chains/internal/backport"
+	"github.com/tektoncd/chains/pkg/chains/formats"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
@@ -139,6 +140,56 @@ func (pa *PipelineRunArtifact) Enabled(cfg config.Config) bool {
 	return cfg.Artifacts.PipelineRuns.Enabled()
 }
 
+type CustomRunArtifact struct{}
+
+var _ Signable = &CustomRunArtifact{}
+
+func (ca *CustomRunArtifact) ShortKey(obj interface{}) string {
+	cro := obj.(*objects.CustomRunObject)
+	return "customrun-" + string(cro.UID)
+}
+
+func (ca *CustomRunArtifact) FullKey(obj interface{}) string {
+	cro := obj.(*objects.CustomRunObject)
+	gvk := cro.GetGroupVersionKind()
+	return fmt.Sprintf("%s-%s-%s-%s", gvk.Group, gvk.Version, gvk.Kind, cro.UID)
+}
+
+func (ca *CustomRunArtifact) ExtractObjects(ctx context.Context, obj objects.TektonObject) []interface{} {
+	return []interface{}{obj}
+}
+
+func (ca *CustomRunArtifact) Type() string {
+	return "tekton-custom-run"
+}
+
+func (ca *CustomRunArtifact) StorageBackend(cfg config.Config) sets.Set[string] {
+	return cfg.Artifacts.CustomRuns.StorageBackend
+}
+
+// PayloadFormat returns the format configured for CustomRuns, in-toto by default.
+func (ca *CustomRunArtifact) PayloadFormat(cfg config.Config) config.PayloadType {
+	if cfg.Artifacts.CustomRuns.Format != "" {
+		return config.PayloadType(cfg.Artifacts.CustomRuns.Format)
+	}
+	return formats.PayloadTypeInTotoIte6
+}
+
+// Signer returns the signer configured for CustomRuns, defaulting to the signer of
+// TaskRuns.
+func (ca *CustomRunArtifact) Signer(cfg config.Config) string {
+	if cfg.Artifacts.CustomRuns.Signer != "" {
+		return cfg.Artifacts.CustomRuns.Signer
+	}
+	return cfg.Artifacts.TaskRuns.Signer
+}
+
+// Enabled returns whether CustomRuns are signed. Like VEX documents, they are only
+// signed when storage backends are configured for them.
+func (ca *CustomRunArtifact) Enabled(cfg config.Config) bool {
+	return cfg.Artifacts.CustomRuns.StorageBackend.Len() > 0 && cfg.Artifacts.CustomRuns.Enabled()
+}
+
 type OCIArtifact struct{}
 
 var _ Signable = &OCIArtifact{}
@@ -150,10 +201,13 @@ type image struct {
 
 // StructuredSignable contains info for signable targets to become either subjects or materials in intoto Statements.
 // URI is the resource uri for the target needed iff the target is a material.
-// Digest is the target's SHA digest.
+// Digest is the target's SHA digest, or a comma separated list of its digests.
+// Content is the base64 encoded content of the target, which inputs may provide to be
+// embedded in provenance.
 type StructuredSignable struct {
-	URI    string
-	Digest string
+	URI     string
+	Digest  string
+	Content string
 }
 
 func (oa *OCIArtifact) ExtractObjects(ctx context.Context, obj objects.TektonObject) []interface{} {
@@ -251,7 +305,7 @@ func ExtractSignableTargetFromResults(ctx context.Context, obj objects.TektonObj
 		if s == nil || s.Digest == "" || s.URI == "" {
 			continue
 		}
-		if _, _, err := ParseDigest(s.Digest); err != nil {
+		if _, err := ParseDigests(s.Digest); err != nil {
 			logger.Errorf("error getting digest %s: %v", s.Digest, err)
 			continue
 		}
@@ -310,14 +364,14 @@ func RetrieveMaterialsFromStructuredResults(ctx context.Context, obj objects.Tek
 	mats := []common.ProvenanceMaterial{}
 	ssts := ExtractStructuredTargetFromResults(ctx, obj, ArtifactsInputsResultName)
 	for _, s := range ssts {
-		alg, digest, err := ParseDigest(s.Digest)
+		digests, err := ParseDigests(s.Digest)
 		if err != nil {
 			logger.Debugf("Digest for %s not in the right format: %s, %v", s.URI, s.Digest, err)
 			continue
 		}
 		mats = append(mats, common.ProvenanceMaterial{
 			URI:    s.URI,
-			Digest: map[string]string{alg: digest},
+			Digest: digests,
 		})
 	}
 	return mats
@@ -348,7 +402,7 @@ func ExtractStructuredTargetFromResults(ctx context.Context, obj objects.TektonO
 			}
 			if valid {
 				logger.Debugf("Extracted Structured data from Result %s, %s", res.Value.ObjectVal["uri"], res.Value.ObjectVal["digest"])
-				objs = append(objs, &StructuredSignable{URI: res.Value.ObjectVal["uri"], Digest: res.Value.ObjectVal["digest"]})
+				objs = append(objs, &StructuredSignable{URI: res.Value.ObjectVal["uri"], Digest: res.Value.ObjectVal["digest"], Content: res.Value.ObjectVal["content"]})
 			}
 		}
 	}
@@ -368,7 +422,7 @@ func isStructuredResult(res objects.Result, categoryMarker string) (bool, error)
 	if res.Value.ObjectVal["digest"] == "" {
 		return false, fmt.Errorf("%s should have digest field: %v", res.Name, res.Value.ObjectVal)
 	}
-	if _, _, err := ParseDigest(res.Value.ObjectVal["digest"]); err != nil {
+	if _, err := ParseDigests(res.Value.ObjectVal["digest"]); err != nil {
 		return false, fmt.Errorf("error getting digest %s: %v", res.Value.ObjectVal["digest"], err)
 	}
 	return true, nil
@@ -402,6 +456,23 @@ func ParseDigest(dig string) (algo_string string, hex string, err error) {
 	return algo_string, hex, nil
 }
 
+// ParseDigests parses a comma separated list of digests, e.g. sha256:abc,sha512:def, and
+// returns the hex section of each of them by algorithm.
+func ParseDigests(digests string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, dig := range strings.Split(digests, ",") {
+		algo, hex, err := ParseDigest(dig)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := parsed[algo]; ok && other != hex {
+			return nil, fmt.Errorf("digest string %s has conflicting %s digests", digests, algo)
+		}
+		parsed[algo] = hex
+	}
+	return parsed, nil
+}
+
 // split allows IMAGES to be separated either by commas (for backwards compatibility)
 // or by newlines
 func split(r rune) bool {
@@ -16,6 +16,7 @@ package artifacts
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -638,6 +639,40 @@ func TestValidateResults(t *testing.T) {
 	}
 }
 
+func TestParseDigests(t *testing.T) {
+	hex := func(digest string) string {
+		return strings.SplitN(digest, ":", 2)[1]
+	}
+	tests := []struct {
+		name    string
+		digests string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "single", digests: digest1, want: map[string]string{"sha256": hex(digest1)}},
+		{name: "sha384", digests: digest_sha384, want: map[string]string{"sha384": hex(digest_sha384)}},
+		{
+			name:    "several",
+			digests: digest1 + ", " + digest_sha512 + "," + digest_sha1,
+			want:    map[string]string{"sha256": hex(digest1), "sha512": hex(digest_sha512), "sha1": hex(digest_sha1)},
+		},
+		{name: "conflicting", digests: digest1 + "," + digest2, wantErr: true},
+		{name: "invalid", digests: digest1 + "," + digest_incorrect_sha512, wantErr: true},
+		{name: "empty", digests: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDigests(tt.digests)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDigests() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseDigests() diff (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
 func createDigest(t *testing.T, dgst string) name.Digest {
 	result, err := name.NewDigest(dgst)
 	if err != nil {
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifacts
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// GitCommitHint and GitURLHint are the names of the params and results that hold
+	// the source repository and commit a run built from.
+	GitCommitHint = "CHAINS-GIT_COMMIT"
+	GitURLHint    = "CHAINS-GIT_URL"
+)
+
+// Sha256Regexp matches the object names of repositories using the SHA-256 object format.
+var Sha256Regexp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// SourceCommit is the source commit a run built from.
+type SourceCommit struct {
+	URL    string
+	Commit string
+	Run    RunReference
+	// Fetch is how the commit was fetched, for TaskRuns that only fetch source.
+	Fetch *SourceFetch
+}
+
+// SourceFetch is how a TaskRun that only fetches source, like git-clone, fetched a
+// commit.
+type SourceFetch struct {
+	// Params are the params of the TaskRun, with the defaults of its Task.
+	Params map[string]v1beta1.ParamValue
+}
+
+type SourceArtifact struct{}
+
+var _ Signable = &SourceArtifact{}
+
+// ExtractObjects returns the SourceCommit of the CHAINS-GIT_URL and CHAINS-GIT_COMMIT
+// params and results of obj, if both are set. Like in provenance, results take
+// precedence over params, which take precedence over param defaults. The fetch of the
+// commit is recorded for TaskRuns that only fetch source: those whose results hold
+// both hints and that output no artifacts.
+func (sa *SourceArtifact) ExtractObjects(ctx context.Context, obj objects.TektonObject) []interface{} {
+	logger := logging.FromContext(ctx)
+
+	var url, commit string
+	hint := func(name, value string) {
+		switch name {
+		case GitURLHint:
+			url = value
+		case GitCommitHint:
+			commit = value
+		}
+	}
+	fetched := sets.New[string]()
+	specs := func(params []v1beta1.ParamSpec) {
+		for _, p := range params {
+			if p.Default != nil {
+				hint(p.Name, p.Default.StringVal)
+			}
+		}
+	}
+	switch o := obj.GetObject().(type) {
+	case *v1beta1.TaskRun:
+		if o.Status.TaskSpec != nil {
+			specs(o.Status.TaskSpec.Params)
+		}
+		for _, p := range o.Spec.Params {
+			hint(p.Name, p.Value.StringVal)
+		}
+	case *v1beta1.PipelineRun:
+		if o.Status.PipelineSpec != nil {
+			specs(o.Status.PipelineSpec.Params)
+		}
+		for _, p := range o.Spec.Params {
+			hint(p.Name, p.Value.StringVal)
+		}
+	case *v1beta1.CustomRun:
+		for _, p := range o.Spec.Params {
+			hint(p.Name, p.Value.StringVal)
+		}
+	}
+	for _, r := range obj.GetResults() {
+		hint(r.Name, r.Value.StringVal)
+		fetched.Insert(r.Name)
+	}
+
+	if url == "" || commit == "" {
+		return []interface{}{}
+	}
+	if !Sha1Regexp.MatchString(commit) && !Sha256Regexp.MatchString(commit) {
+		logger.Errorf("error getting source commit of %s: %q is not a full commit SHA", url, commit)
+		return []interface{}{}
+	}
+	c := &SourceCommit{
+		URL:    url,
+		Commit: commit,
+		Run: RunReference{
+			Kind:      obj.GetKindName(),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			UID:       string(obj.GetUID()),
+		},
+	}
+	if tr, ok := obj.GetObject().(*v1beta1.TaskRun); ok && fetched.HasAll(GitURLHint, GitCommitHint) && !outputsArtifacts(ctx, obj) {
+		c.Fetch = &SourceFetch{Params: map[string]v1beta1.ParamValue{}}
+		if tr.Status.TaskSpec != nil {
+			for _, p := range tr.Status.TaskSpec.Params {
+				if p.Default != nil {
+					c.Fetch.Params[p.Name] = *p.Default
+				}
+			}
+		}
+		for _, p := range tr.Spec.Params {
+			c.Fetch.Params[p.Name] = p.Value
+		}
+	}
+	return []interface{}{c}
+}
+
+// outputsArtifacts returns whether the results of obj hint at artifacts it built.
+func outputsArtifacts(ctx context.Context, obj objects.TektonObject) bool {
+	return len(ExtractOCIImagesFromResults(ctx, obj)) > 0 ||
+		len(ExtractSignableTargetFromResults(ctx, obj)) > 0 ||
+		len(ExtractStructuredTargetFromResults(ctx, obj, ArtifactsOutputsResultName)) > 0
+}
+
+func (sa *SourceArtifact) Type() string {
+	return "source"
+}
+
+func (sa *SourceArtifact) StorageBackend(cfg config.Config) sets.Set[string] {
+	return cfg.Artifacts.Source.StorageBackend
+}
+
+func (sa *SourceArtifact) PayloadFormat(cfg config.Config) config.PayloadType {
+	return formats.PayloadTypeSource
+}
+
+// Signer returns x509: source attestations are only produced with keyless signing, so
+// that they bind the workload identity of the build to the commit.
+func (sa *SourceArtifact) Signer(cfg config.Config) string {
+	return "x509"
+}
+
+func (sa *SourceArtifact) ShortKey(obj interface{}) string {
+	return "source-" + obj.(*SourceCommit).Commit[:12]
+}
+
+func (sa *SourceArtifact) FullKey(obj interface{}) string {
+	return "source-" + obj.(*SourceCommit).Commit
+}
+
+// Enabled returns whether source commits are attested: when storage backends are
+// configured for them and x509 signing is keyless.
+func (sa *SourceArtifact) Enabled(cfg config.Config) bool {
+	return cfg.Artifacts.Source.StorageBackend.Len() > 0 && cfg.Artifacts.Source.Enabled() && cfg.Signers.X509.FulcioEnabled
+}
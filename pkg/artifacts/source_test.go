@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifacts
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestSourceArtifact_ExtractObjects(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	commit := "50c56a48cfb3a5a80fa36ed91c739bdac8381cbe"
+	taskRun := func(params []v1beta1.Param, results []v1beta1.TaskRunResult) objects.TektonObject {
+		return objects.NewTaskRunObject(&v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default", UID: "uid"},
+			Spec:       v1beta1.TaskRunSpec{Params: params},
+			Status: v1beta1.TaskRunStatus{
+				TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+					TaskSpec: &v1beta1.TaskSpec{Params: []v1beta1.ParamSpec{
+						{Name: GitURLHint, Default: v1beta1.NewStructuredValues("https://github.com/org/default")},
+					}},
+					TaskRunResults: results,
+				},
+			},
+		})
+	}
+	run := RunReference{Kind: "taskrun", Namespace: "default", Name: "build", UID: "uid"}
+
+	tests := []struct {
+		name string
+		obj  objects.TektonObject
+		want []interface{}
+	}{
+		{
+			name: "params",
+			obj:  taskRun([]v1beta1.Param{{Name: GitCommitHint, Value: *v1beta1.NewStructuredValues(commit)}}, nil),
+			want: []interface{}{&SourceCommit{URL: "https://github.com/org/default", Commit: commit, Run: run}},
+		},
+		{
+			name: "results",
+			obj: taskRun(
+				[]v1beta1.Param{{Name: GitURLHint, Value: *v1beta1.NewStructuredValues("https://github.com/org/param")}},
+				[]v1beta1.TaskRunResult{
+					{Name: GitURLHint, Value: *v1beta1.NewStructuredValues("https://github.com/org/repo")},
+					{Name: GitCommitHint, Value: *v1beta1.NewStructuredValues(commit)},
+				},
+			),
+			want: []interface{}{&SourceCommit{URL: "https://github.com/org/repo", Commit: commit, Run: run, Fetch: &SourceFetch{
+				Params: map[string]v1beta1.ParamValue{GitURLHint: *v1beta1.NewStructuredValues("https://github.com/org/param")},
+			}}},
+		},
+		{
+			name: "results and outputs",
+			obj: taskRun(nil, []v1beta1.TaskRunResult{
+				{Name: GitURLHint, Value: *v1beta1.NewStructuredValues("https://github.com/org/repo")},
+				{Name: GitCommitHint, Value: *v1beta1.NewStructuredValues(commit)},
+				{Name: "IMAGE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/bar")},
+				{Name: "IMAGE_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")},
+			}),
+			want: []interface{}{&SourceCommit{URL: "https://github.com/org/repo", Commit: commit, Run: run}},
+		},
+		{
+			name: "no commit",
+			obj:  taskRun(nil, nil),
+			want: []interface{}{},
+		},
+		{
+			name: "abbreviated commit",
+			obj:  taskRun([]v1beta1.Param{{Name: GitCommitHint, Value: *v1beta1.NewStructuredValues("50c56a4")}}, nil),
+			want: []interface{}{},
+		},
+		{
+			name: "pipelinerun",
+			obj: objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "pipeline", Namespace: "default", UID: "uid"},
+				Spec: v1beta1.PipelineRunSpec{Params: []v1beta1.Param{
+					{Name: GitURLHint, Value: *v1beta1.NewStructuredValues("https://github.com/org/repo")},
+					{Name: GitCommitHint, Value: *v1beta1.NewStructuredValues(commit)},
+				}},
+			}),
+			want: []interface{}{&SourceCommit{
+				URL:    "https://github.com/org/repo",
+				Commit: commit,
+				Run:    RunReference{Kind: "pipelinerun", Namespace: "default", Name: "pipeline", UID: "uid"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := (&SourceArtifact{}).ExtractObjects(ctx, tt.obj)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ExtractObjects() -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSourceArtifact_Enabled(t *testing.T) {
+	sa := &SourceArtifact{}
+	tests := []struct {
+		name   string
+		source config.Artifact
+		fulcio bool
+		want   bool
+	}{
+		{name: "unset", fulcio: true},
+		{name: "disabled", source: config.Artifact{StorageBackend: sets.New[string]("")}, fulcio: true},
+		{name: "not keyless", source: config.Artifact{StorageBackend: sets.New[string]("tekton")}},
+		{name: "enabled", source: config.Artifact{StorageBackend: sets.New[string]("tekton")}, fulcio: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{
+				Artifacts: config.ArtifactConfigs{Source: tt.source},
+				Signers:   config.SignerConfigs{X509: config.X509Signer{FulcioEnabled: tt.fulcio}},
+			}
+			if got := sa.Enabled(cfg); got != tt.want {
+				t.Errorf("Enabled() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
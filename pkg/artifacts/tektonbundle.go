@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// TektonBundleURLSuffix and TektonBundleDigestSuffix are the suffixes of the
+	// names of the pairs of results that hold the Tekton bundles built by a run.
+	TektonBundleURLSuffix    = "BUNDLE_URL"
+	TektonBundleDigestSuffix = "BUNDLE_DIGEST"
+)
+
+// TektonBundle is a Tekton bundle, an OCI image of Tasks and Pipelines, built by a
+// run.
+type TektonBundle struct {
+	ResultName string
+	Digest     name.Digest
+	Run        RunReference
+}
+
+// RunReference identifies the run that built a Tekton bundle.
+type RunReference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UID       string `json:"uid"`
+}
+
+type TektonBundleArtifact struct{}
+
+var _ Signable = &TektonBundleArtifact{}
+
+// ExtractObjects returns a TektonBundle for every pair of *BUNDLE_URL and
+// *BUNDLE_DIGEST results of obj.
+func (ta *TektonBundleArtifact) ExtractObjects(ctx context.Context, obj objects.TektonObject) []interface{} {
+	logger := logging.FromContext(ctx)
+	run := RunReference{
+		Kind:      obj.GetKindName(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		UID:       string(obj.GetUID()),
+	}
+
+	ss := extractTargetFromResults(ctx, obj, TektonBundleURLSuffix, TektonBundleDigestSuffix)
+	markers := make([]string, 0, len(ss))
+	for marker := range ss {
+		markers = append(markers, marker)
+	}
+	sort.Strings(markers)
+
+	objs := []interface{}{}
+	for _, marker := range markers {
+		s := ss[marker]
+		if s == nil || s.Digest == "" || s.URI == "" {
+			continue
+		}
+		if !strings.HasPrefix(s.Digest, "sha256:") {
+			logger.Errorf("error getting digest of Tekton bundle %s: unsupported digest %s", s.URI, s.Digest)
+			continue
+		}
+		dgst, err := name.NewDigest(fmt.Sprintf("%s@%s", s.URI, s.Digest))
+		if err != nil {
+			logger.Errorf("error getting digest of Tekton bundle: %v", err)
+			continue
+		}
+		objs = append(objs, &TektonBundle{ResultName: marker + TektonBundleURLSuffix, Digest: dgst, Run: run})
+	}
+	return objs
+}
+
+func (ta *TektonBundleArtifact) Type() string {
+	return "tekton-bundle"
+}
+
+func (ta *TektonBundleArtifact) StorageBackend(cfg config.Config) sets.Set[string] {
+	return cfg.Artifacts.TektonBundles.StorageBackend
+}
+
+func (ta *TektonBundleArtifact) PayloadFormat(cfg config.Config) config.PayloadType {
+	return formats.PayloadTypeTektonBundle
+}
+
+// Signer returns the signer configured for Tekton bundles, defaulting to the signer
+// of OCI artifacts since bundles are OCI images.
+func (ta *TektonBundleArtifact) Signer(cfg config.Config) string {
+	if cfg.Artifacts.TektonBundles.Signer != "" {
+		return cfg.Artifacts.TektonBundles.Signer
+	}
+	return cfg.Artifacts.OCI.Signer
+}
+
+func (ta *TektonBundleArtifact) ShortKey(obj interface{}) string {
+	return "tekton-bundle-" + strings.TrimPrefix(obj.(*TektonBundle).Digest.DigestStr(), "sha256:")[:12]
+}
+
+func (ta *TektonBundleArtifact) FullKey(obj interface{}) string {
+	return "tekton-bundle-" + strings.TrimPrefix(obj.(*TektonBundle).Digest.DigestStr(), "sha256:")
+}
+
+// Enabled returns whether Tekton bundles are attested. Like VEX documents, they are
+// only attested when storage backends are configured for them.
+func (ta *TektonBundleArtifact) Enabled(cfg config.Config) bool {
+	return cfg.Artifacts.TektonBundles.StorageBackend.Len() > 0 && cfg.Artifacts.TektonBundles.Enabled()
+}
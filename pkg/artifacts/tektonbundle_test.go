@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifacts
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestTektonBundleArtifact_ExtractObjects(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "build-bundle", Namespace: "default", UID: "uid"},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{Name: "BUNDLE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/tasks")},
+					{Name: "BUNDLE_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")},
+					{Name: "PIPELINES_BUNDLE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/pipelines")},
+					{Name: "PIPELINES_BUNDLE_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:6e1f4d8b0bbd4e5a7c4cd4b2d0d2a0b5bd6a5b5a1f1c7e0e2b1c2d3e4f5a6b7c")},
+					{Name: "MISSING_BUNDLE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/missing")},
+					{Name: "SHA1_BUNDLE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/sha1")},
+					{Name: "SHA1_BUNDLE_DIGEST", Value: *v1beta1.NewStructuredValues("sha1:4e1243bd22c66e76c2ba9eddc1f91394e57f9f83")},
+				},
+			},
+		},
+	}
+	tasks, err := name.NewDigest("gcr.io/foo/tasks@sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipelines, err := name.NewDigest("gcr.io/foo/pipelines@sha256:6e1f4d8b0bbd4e5a7c4cd4b2d0d2a0b5bd6a5b5a1f1c7e0e2b1c2d3e4f5a6b7c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := RunReference{Kind: "taskrun", Namespace: "default", Name: "build-bundle", UID: "uid"}
+	got := (&TektonBundleArtifact{}).ExtractObjects(ctx, objects.NewTaskRunObject(tr))
+	want := []interface{}{
+		&TektonBundle{ResultName: "BUNDLE_URL", Digest: tasks, Run: run},
+		&TektonBundle{ResultName: "PIPELINES_BUNDLE_URL", Digest: pipelines, Run: run},
+	}
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(x, y name.Digest) bool { return x.String() == y.String() })); diff != "" {
+		t.Errorf("ExtractObjects() -want +got: %s", diff)
+	}
+}
+
+func TestTektonBundleArtifact_Config(t *testing.T) {
+	ta := &TektonBundleArtifact{}
+	tests := []struct {
+		name        string
+		bundles     config.Artifact
+		wantEnabled bool
+		wantSigner  string
+	}{
+		{name: "unset", wantSigner: "kms"},
+		{name: "disabled", bundles: config.Artifact{StorageBackend: sets.New[string]("")}, wantSigner: "kms"},
+		{name: "enabled", bundles: config.Artifact{StorageBackend: sets.New[string]("oci"), Signer: "x509"}, wantEnabled: true, wantSigner: "x509"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{Artifacts: config.ArtifactConfigs{
+				OCI:           config.Artifact{Signer: "kms"},
+				TektonBundles: tt.bundles,
+			}}
+			if got := ta.Enabled(cfg); got != tt.wantEnabled {
+				t.Errorf("Enabled() = %t, want %t", got, tt.wantEnabled)
+			}
+			if got := ta.Signer(cfg); got != tt.wantSigner {
+				t.Errorf("Signer() = %q, want %q", got, tt.wantSigner)
+			}
+		})
+	}
+}
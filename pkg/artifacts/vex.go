@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// VEXResultName is the name of the result, or the suffix of the names of the
+	// results, that hold OpenVEX documents produced by a run.
+	VEXResultName = "VEX"
+)
+
+// VEXDocument is an OpenVEX document produced by a run, along with the images of
+// the run that it applies to.
+type VEXDocument struct {
+	ResultName string
+	Document   []byte
+	Subjects   []name.Digest
+}
+
+// digest returns the hex encoded sha256 of the document.
+func (d *VEXDocument) digest() string {
+	sum := sha256.Sum256(d.Document)
+	return hex.EncodeToString(sum[:])
+}
+
+type VEXArtifact struct{}
+
+var _ Signable = &VEXArtifact{}
+
+// ExtractObjects returns a VEXDocument for every VEX result of obj. The documents
+// apply to all the images the run produced.
+func (va *VEXArtifact) ExtractObjects(ctx context.Context, obj objects.TektonObject) []interface{} {
+	var subjects []name.Digest
+	for _, o := range (&OCIArtifact{}).ExtractObjects(ctx, obj) {
+		if d, ok := o.(name.Digest); ok {
+			subjects = append(subjects, d)
+		}
+	}
+
+	objs := []interface{}{}
+	for _, res := range obj.GetResults() {
+		if res.Name != VEXResultName && !strings.HasSuffix(res.Name, "_"+VEXResultName) {
+			continue
+		}
+		doc := strings.TrimSpace(res.Value.StringVal)
+		if doc == "" {
+			continue
+		}
+		objs = append(objs, &VEXDocument{ResultName: res.Name, Document: []byte(doc), Subjects: subjects})
+	}
+	return objs
+}
+
+func (va *VEXArtifact) Type() string {
+	return "vex"
+}
+
+func (va *VEXArtifact) StorageBackend(cfg config.Config) sets.Set[string] {
+	return cfg.Artifacts.VEX.StorageBackend
+}
+
+func (va *VEXArtifact) PayloadFormat(cfg config.Config) config.PayloadType {
+	return formats.PayloadTypeOpenVEX
+}
+
+// Signer returns the signer configured for VEX documents, defaulting to the signer
+// of OCI artifacts since the documents are about the same images.
+func (va *VEXArtifact) Signer(cfg config.Config) string {
+	if cfg.Artifacts.VEX.Signer != "" {
+		return cfg.Artifacts.VEX.Signer
+	}
+	return cfg.Artifacts.OCI.Signer
+}
+
+func (va *VEXArtifact) ShortKey(obj interface{}) string {
+	return "vex-" + obj.(*VEXDocument).digest()[:12]
+}
+
+func (va *VEXArtifact) FullKey(obj interface{}) string {
+	return "vex-" + obj.(*VEXDocument).digest()
+}
+
+// Enabled returns whether VEX documents are signed. Unlike the other artifacts,
+// they are only signed when storage backends are configured for them.
+func (va *VEXArtifact) Enabled(cfg config.Config) bool {
+	return cfg.Artifacts.VEX.StorageBackend.Len() > 0 && cfg.Artifacts.VEX.Enabled()
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifacts
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestVEXArtifact_ExtractObjects(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	tr := &v1beta1.TaskRun{
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{Name: "IMAGES", Value: *v1beta1.NewStructuredValues("gcr.io/foo/bar@sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")},
+					{Name: "VEX", Value: *v1beta1.NewStructuredValues(" {\"@id\": \"1\"}\n")},
+					{Name: "SCAN_VEX", Value: *v1beta1.NewStructuredValues(`{"@id": "2"}`)},
+					{Name: "EMPTY_VEX", Value: *v1beta1.NewStructuredValues("")},
+					{Name: "VEXED", Value: *v1beta1.NewStructuredValues(`{"@id": "3"}`)},
+				},
+			},
+		},
+	}
+	digest, err := name.NewDigest("gcr.io/foo/bar@sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := (&VEXArtifact{}).ExtractObjects(ctx, objects.NewTaskRunObject(tr))
+	want := []interface{}{
+		&VEXDocument{ResultName: "VEX", Document: []byte(`{"@id": "1"}`), Subjects: []name.Digest{digest}},
+		&VEXDocument{ResultName: "SCAN_VEX", Document: []byte(`{"@id": "2"}`), Subjects: []name.Digest{digest}},
+	}
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(x, y name.Digest) bool { return x.String() == y.String() })); diff != "" {
+		t.Errorf("ExtractObjects() -want +got: %s", diff)
+	}
+}
+
+func TestVEXArtifact_Config(t *testing.T) {
+	va := &VEXArtifact{}
+	tests := []struct {
+		name        string
+		vex         config.Artifact
+		wantEnabled bool
+		wantSigner  string
+	}{
+		{name: "unset", wantSigner: "kms"},
+		{name: "disabled", vex: config.Artifact{StorageBackend: sets.New[string]("")}, wantSigner: "kms"},
+		{name: "enabled", vex: config.Artifact{StorageBackend: sets.New[string]("oci"), Signer: "x509"}, wantEnabled: true, wantSigner: "x509"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{Artifacts: config.ArtifactConfigs{
+				OCI: config.Artifact{Signer: "kms"},
+				VEX: tt.vex,
+			}}
+			if got := va.Enabled(cfg); got != tt.wantEnabled {
+				t.Errorf("Enabled() = %t, want %t", got, tt.wantEnabled)
+			}
+			if got := va.Signer(cfg); got != tt.wantSigner {
+				t.Errorf("Signer() = %q, want %q", got, tt.wantSigner)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package attestation serves a gRPC API generating the signed attestations of completed
+// runs on demand, so CI orchestrators and tests get them synchronously instead of
+// waiting for the controller to sign the runs.
+package attestation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tektoncd/chains/pkg/attestation/attestationpb"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	taskRunKind     = "TaskRun"
+	pipelineRunKind = "PipelineRun"
+
+	// attestationsSubresource is the subresource of runs callers must be allowed to
+	// create to get their attestations.
+	attestationsSubresource = "attestations"
+)
+
+var (
+	// setupMu serializes Setup, which may wait for the requests of the server it stops.
+	setupMu sync.Mutex
+	served  config.GRPCConfig
+	server  *grpc.Server
+
+	// mu guards the config and signer requests are served with.
+	mu     sync.Mutex
+	cfg    config.Config
+	signer *chains.ObjectSigner
+)
+
+// Server implements the Attestations service, reading runs with Pipelineclientset and
+// authenticating and authorizing callers with KubeClient.
+type Server struct {
+	attestationpb.UnimplementedAttestationsServer
+
+	Pipelineclientset versioned.Interface
+	KubeClient        kubernetes.Interface
+	// State returns the config the attestations are generated with and the signer
+	// generating them.
+	State  func() (config.Config, *chains.ObjectSigner)
+	Logger *zap.SugaredLogger
+}
+
+// Setup serves the API with TLS on the address in cfg, generating attestations with cfg
+// and s. It is safe to call on every config update, from the controller of any kind of
+// run: the server is only restarted when the address or TLS config changed, and stopped
+// when the address is empty, and requests use the config and signer of the last call.
+func Setup(ctx context.Context, c config.Config, s *chains.ObjectSigner) error {
+	setupMu.Lock()
+	defer setupMu.Unlock()
+
+	mu.Lock()
+	cfg, signer = c, s
+	mu.Unlock()
+	if c.GRPC == served {
+		return nil
+	}
+	if server != nil {
+		server.GracefulStop()
+		server = nil
+	}
+	served = config.GRPCConfig{}
+	if c.GRPC.Address == "" {
+		return nil
+	}
+
+	creds, err := serverCredentials(c.GRPC)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", c.GRPC.Address)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", c.GRPC.Address, err)
+	}
+	srv := grpc.NewServer(grpc.Creds(creds))
+	attestationpb.RegisterAttestationsServer(srv, &Server{
+		Pipelineclientset: s.Pipelineclientset,
+		KubeClient:        s.KubeClient,
+		State:             state,
+		Logger:            logging.FromContext(ctx),
+	})
+	server, served = srv, c.GRPC
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			logging.FromContext(ctx).Errorf("attestation gRPC API on %s stopped: %v", ln.Addr(), err)
+		}
+	}()
+	return nil
+}
+
+// serverCredentials returns the TLS credentials of c. The certificate is read again for
+// each handshake, so that it can be rotated without restarting the server, and client
+// certificates are required if c has client CAs.
+func serverCredentials(c config.GRPCConfig) (credentials.TransportCredentials, error) {
+	if c.CertPath == "" || c.KeyPath == "" {
+		return nil, errors.New("the attestation gRPC API is only served with a TLS certificate and key")
+	}
+	if _, err := tls.LoadX509KeyPair(c.CertPath, c.KeyPath); err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	tc := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(c.CertPath, c.KeyPath)
+			return &cert, err
+		},
+	}
+	if c.ClientCAPath != "" {
+		pem, err := os.ReadFile(c.ClientCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CAs: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.ClientCAPath)
+		}
+		tc.ClientCAs = pool
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(tc), nil
+}
+
+// state returns the config and signer of the last Setup.
+func state() (config.Config, *chains.ObjectSigner) {
+	mu.Lock()
+	defer mu.Unlock()
+	return cfg, signer
+}
+
+// Attest generates and signs the attestations of the run of req, if the caller is
+// allowed to.
+func (s *Server) Attest(ctx context.Context, req *attestationpb.AttestRequest) (*attestationpb.AttestResponse, error) {
+	c, signer := s.State()
+	if s.Logger != nil {
+		ctx = logging.WithLogger(ctx, s.Logger)
+	}
+	user, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := s.resolve(ctx, user, req)
+	if err != nil {
+		return nil, err
+	}
+	if !obj.IsDone() {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s %s/%s is not done", obj.GetKindName(), obj.GetNamespace(), obj.GetName())
+	}
+	if pro, ok := obj.(*objects.PipelineRunObject); ok {
+		if err := s.appendTaskRuns(ctx, pro); err != nil {
+			return nil, err
+		}
+	}
+
+	attestations, err := signer.Attest(config.ToContext(ctx, &c), obj)
+	if err != nil {
+		var ce *chains.ClassifiedError
+		if errors.As(err, &ce) && ce.Class == chains.ErrorClassPolicy {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &attestationpb.AttestResponse{}
+	for _, a := range attestations {
+		resp.Attestations = append(resp.Attestations, &attestationpb.Attestation{
+			Type:      a.Type,
+			Key:       a.Key,
+			Format:    a.Format,
+			Payload:   a.Payload,
+			Signature: a.Signature,
+			Cert:      a.Cert,
+			Chain:     a.Chain,
+		})
+	}
+	return resp, nil
+}
+
+// authenticate returns the user of the bearer token of the request, reviewed by the API
+// server.
+func (s *Server) authenticate(ctx context.Context) (authenticationv1.UserInfo, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	for _, v := range md.Get("authorization") {
+		if strings.HasPrefix(v, "Bearer ") {
+			token = strings.TrimPrefix(v, "Bearer ")
+		}
+	}
+	if token == "" {
+		return authenticationv1.UserInfo{}, status.Error(codes.Unauthenticated, "a bearer token is required")
+	}
+	review, err := s.KubeClient.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return authenticationv1.UserInfo{}, status.Errorf(codes.Internal, "reviewing token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return authenticationv1.UserInfo{}, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	return review.Status.User, nil
+}
+
+// authorize returns an error unless user may create the attestations of the run of the
+// given kind.
+func (s *Server) authorize(ctx context.Context, user authenticationv1.UserInfo, kind, namespace, name string) error {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	review, err := s.KubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        "create",
+				Group:       v1beta1.SchemeGroupVersion.Group,
+				Resource:    strings.ToLower(kind) + "s",
+				Subresource: attestationsSubresource,
+				Name:        name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return status.Errorf(codes.Internal, "reviewing access: %v", err)
+	}
+	if !review.Status.Allowed {
+		return status.Errorf(codes.PermissionDenied, "%s can't create the attestations of %s %s/%s", user.Username, kind, namespace, name)
+	}
+	return nil
+}
+
+// resolve returns the copy in the cluster of the run referenced or submitted in req,
+// once user is authorized to attest it. Submitted runs must have the UID of the run in
+// the cluster.
+func (s *Server) resolve(ctx context.Context, user authenticationv1.UserInfo, req *attestationpb.AttestRequest) (objects.TektonObject, error) {
+	var kind, namespace, name, uid string
+	var submitted bool
+	switch run := req.GetRun().(type) {
+	case *attestationpb.AttestRequest_Ref:
+		kind, namespace, name = run.Ref.GetKind(), run.Ref.GetNamespace(), run.Ref.GetName()
+	case *attestationpb.AttestRequest_Object:
+		obj, err := decode(run.Object)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		kind, namespace, name, uid = obj.GetKindName(), obj.GetNamespace(), obj.GetName(), string(obj.GetUID())
+		submitted = true
+	default:
+		return nil, status.Error(codes.InvalidArgument, "either ref or object is required")
+	}
+	if namespace == "" || name == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace and name are required")
+	}
+	switch strings.ToLower(kind) {
+	case strings.ToLower(taskRunKind):
+		kind = taskRunKind
+	case strings.ToLower(pipelineRunKind):
+		kind = pipelineRunKind
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported kind %q, want %s or %s", kind, taskRunKind, pipelineRunKind)
+	}
+	if err := s.authorize(ctx, user, kind, namespace, name); err != nil {
+		return nil, err
+	}
+
+	obj, err := s.get(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if submitted && string(obj.GetUID()) != uid {
+		return nil, status.Errorf(codes.NotFound, "%s %s/%s with UID %s not found", kind, namespace, name, uid)
+	}
+	return obj, nil
+}
+
+// get fetches the run of the given kind from the cluster.
+func (s *Server) get(ctx context.Context, kind, namespace, name string) (objects.TektonObject, error) {
+	var obj objects.TektonObject
+	var err error
+	switch kind {
+	case taskRunKind:
+		var tr *v1beta1.TaskRun
+		if tr, err = s.Pipelineclientset.TektonV1beta1().TaskRuns(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+			obj = objects.NewTaskRunObject(tr)
+		}
+	case pipelineRunKind:
+		var pr *v1beta1.PipelineRun
+		if pr, err = s.Pipelineclientset.TektonV1beta1().PipelineRuns(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+			obj = objects.NewPipelineRunObject(pr)
+		}
+	}
+	if apierrors.IsNotFound(err) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return obj, nil
+}
+
+// appendTaskRuns adds the child TaskRuns of pro, read from the cluster, so they are
+// recorded in its provenance.
+func (s *Server) appendTaskRuns(ctx context.Context, pro *objects.PipelineRunObject) error {
+	for _, cr := range pro.Status.ChildReferences {
+		if cr.Kind != taskRunKind {
+			continue
+		}
+		tr, err := s.Pipelineclientset.TektonV1beta1().TaskRuns(pro.Namespace).Get(ctx, cr.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return status.Errorf(codes.FailedPrecondition, "taskrun %s of pipelinerun %s/%s not found", cr.Name, pro.Namespace, pro.Name)
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		pro.AppendTaskRun(tr)
+	}
+	return nil
+}
+
+// decode decodes the JSON or YAML of a TaskRun or PipelineRun.
+func decode(raw []byte) (objects.TektonObject, error) {
+	var meta metav1.TypeMeta
+	if err := yaml.Unmarshal(raw, &meta); err != nil {
+		return nil, fmt.Errorf("decoding object: %w", err)
+	}
+	if meta.APIVersion != v1beta1.SchemeGroupVersion.String() {
+		return nil, fmt.Errorf("unsupported apiVersion %q, want %s", meta.APIVersion, v1beta1.SchemeGroupVersion)
+	}
+	switch meta.Kind {
+	case taskRunKind:
+		tr := &v1beta1.TaskRun{}
+		if err := yaml.Unmarshal(raw, tr); err != nil {
+			return nil, fmt.Errorf("decoding TaskRun: %w", err)
+		}
+		return objects.NewTaskRunObject(tr), nil
+	case pipelineRunKind:
+		pr := &v1beta1.PipelineRun{}
+		if err := yaml.Unmarshal(raw, pr); err != nil {
+			return nil, fmt.Errorf("decoding PipelineRun: %w", err)
+		}
+		return objects.NewPipelineRunObject(pr), nil
+	default:
+		return nil, fmt.Errorf("unsupported kind %q, want %s or %s", meta.Kind, taskRunKind, pipelineRunKind)
+	}
+}
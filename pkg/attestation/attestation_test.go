@@ -0,0 +1,289 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/attestation/attestationpb"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rtesting "knative.dev/pkg/reconciler/testing"
+
+	_ "github.com/tektoncd/chains/pkg/chains/formats/all"
+)
+
+func taskRun(name, uid string, done bool) *v1beta1.TaskRun {
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID("uid-" + uid)}}
+	if done {
+		tr.Status.Status = duckv1.Status{Conditions: []apis.Condition{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue}}}
+	}
+	return tr
+}
+
+// writeTLS writes a self-signed certificate of localhost, valid for servers and clients,
+// and its key to dir, and returns the config serving the API with it and requiring
+// clients to present it.
+func writeTLS(t *testing.T, dir string) config.GRPCConfig {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := config.GRPCConfig{
+		CertPath:     filepath.Join(dir, "tls.crt"),
+		KeyPath:      filepath.Join(dir, "tls.key"),
+		ClientCAPath: filepath.Join(dir, "tls.crt"),
+	}
+	if err := os.WriteFile(c.CertPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.KeyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// fakeReviews makes kc authenticate the tokens of users by their names, and allow ci to
+// create the attestations of runs.
+func fakeReviews(kc *fakekube.Clientset, users ...string) {
+	kc.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		for _, u := range users {
+			if review.Spec.Token == u+"-token" {
+				review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: u}}
+			}
+		}
+		return true, review, nil
+	})
+	kc.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "ci" && attrs.Verb == "create" && attrs.Group == "tekton.dev" && attrs.Subresource == "attestations" && attrs.Namespace == "ns"
+		return true, review, nil
+	})
+}
+
+func TestAttest(t *testing.T) {
+	cfg := config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.New[string]("tekton"),
+				Signer:         "x509",
+			},
+		},
+	}
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	for _, tr := range []*v1beta1.TaskRun{taskRun("done", "1", true), taskRun("running", "2", false)} {
+		if _, err := ps.TektonV1beta1().TaskRuns("ns").Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kc := fakekube.NewSimpleClientset()
+	fakeReviews(kc, "ci", "dev")
+
+	signer := &chains.ObjectSigner{SecretPath: "../chains/signing/x509/testdata/", Pipelineclientset: ps}
+	srv := &Server{
+		Pipelineclientset: ps,
+		KubeClient:        kc,
+		State:             func() (config.Config, *chains.ObjectSigner) { return cfg, signer },
+	}
+	tlsConfig := writeTLS(t, t.TempDir())
+	creds, err := serverCredentials(tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer(grpc.Creds(creds))
+	attestationpb.RegisterAttestationsServer(gs, srv)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gs.Serve(ln)
+	defer gs.Stop()
+	cert, err := tls.LoadX509KeyPair(tlsConfig.CertPath, tlsConfig.KeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{cert},
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := attestationpb.NewAttestationsClient(conn)
+
+	ref := func(kind, name string) *attestationpb.AttestRequest {
+		return &attestationpb.AttestRequest{Run: &attestationpb.AttestRequest_Ref{Ref: &attestationpb.RunReference{Kind: kind, Namespace: "ns", Name: name}}}
+	}
+	object := func(raw string) *attestationpb.AttestRequest {
+		return &attestationpb.AttestRequest{Run: &attestationpb.AttestRequest_Object{Object: []byte(raw)}}
+	}
+	tests := []struct {
+		name     string
+		req      *attestationpb.AttestRequest
+		token    string
+		selector string
+		code     codes.Code
+	}{
+		{name: "reference", req: ref("TaskRun", "done")},
+		{name: "submitted", req: object("apiVersion: tekton.dev/v1beta1\nkind: TaskRun\nmetadata: {name: done, namespace: ns, uid: uid-1}\n")},
+		{name: "submitted json", req: object(`{"apiVersion":"tekton.dev/v1beta1","kind":"TaskRun","metadata":{"name":"done","namespace":"ns","uid":"uid-1"}}`)},
+		{
+			name: "submitted status",
+			req:  object(`{"apiVersion":"tekton.dev/v1beta1","kind":"TaskRun","metadata":{"name":"elsewhere","namespace":"ns"},"status":{"conditions":[{"type":"Succeeded","status":"True"}]}}`),
+			code: codes.NotFound,
+		},
+		{name: "other uid", req: object(`{"apiVersion":"tekton.dev/v1beta1","kind":"TaskRun","metadata":{"name":"done","namespace":"ns","uid":"other"}}`), code: codes.NotFound},
+		{name: "not in cluster", req: object(`{"apiVersion":"tekton.dev/v1beta1","kind":"TaskRun","metadata":{"name":"elsewhere","namespace":"ns"}}`), code: codes.NotFound},
+		{name: "not done", req: ref("TaskRun", "running"), code: codes.FailedPrecondition},
+		{name: "not found", req: ref("TaskRun", "missing"), code: codes.NotFound},
+		{name: "kind", req: ref("Pod", "done"), code: codes.InvalidArgument},
+		{name: "apiVersion", req: object(`{"apiVersion":"v1","kind":"Pod"}`), code: codes.InvalidArgument},
+		{name: "empty", req: &attestationpb.AttestRequest{}, code: codes.InvalidArgument},
+		{name: "no token", req: ref("TaskRun", "done"), token: "-", code: codes.Unauthenticated},
+		{name: "invalid token", req: ref("TaskRun", "done"), token: "other-token", code: codes.Unauthenticated},
+		{name: "not allowed", req: ref("TaskRun", "done"), token: "dev-token", code: codes.PermissionDenied},
+		{name: "not selected", req: ref("TaskRun", "done"), selector: "chains.tekton.dev/sign=true", code: codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Selector.LabelSelector = tt.selector
+			ctx := context.Background()
+			switch tt.token {
+			case "":
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer ci-token")
+			case "-":
+			default:
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tt.token)
+			}
+			resp, err := client.Attest(ctx, tt.req)
+			if got := status.Code(err); got != tt.code {
+				t.Fatalf("Attest() = %v, want code %s", err, tt.code)
+			}
+			if tt.code != codes.OK {
+				return
+			}
+			if len(resp.Attestations) != 1 {
+				t.Fatalf("got %d attestations, want 1", len(resp.Attestations))
+			}
+			a := resp.Attestations[0]
+			if a.Type != "tekton" || a.Format != "in-toto" || len(a.Payload) == 0 || len(a.Signature) == 0 {
+				t.Errorf("attestation = %+v", a)
+			}
+		})
+	}
+
+	got, err := ps.TektonV1beta1().TaskRuns("ns").Get(ctx, "done", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Annotations[chains.ChainsAnnotation]; ok {
+		t.Errorf("expected %s not to be set by the API", chains.ChainsAnnotation)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	signer := &chains.ObjectSigner{}
+	c := config.Config{GRPC: config.GRPCConfig{Address: "127.0.0.1:0"}}
+	if err := Setup(ctx, c, signer); err == nil {
+		t.Error("expected an error serving the API without TLS")
+	}
+
+	c.GRPC = writeTLS(t, t.TempDir())
+	c.GRPC.Address = "127.0.0.1:0"
+	if err := Setup(ctx, c, signer); err != nil {
+		t.Fatal(err)
+	}
+	if server == nil {
+		t.Fatal("expected the API to be served")
+	}
+	// The controllers of every kind of run set the API up with their own signer.
+	served := server
+	other := &chains.ObjectSigner{}
+	if err := Setup(ctx, c, other); err != nil {
+		t.Fatal(err)
+	}
+	if server != served {
+		t.Error("expected the API to be served by the same server")
+	}
+	if _, s := state(); s != other {
+		t.Error("expected requests to use the signer of the last Setup")
+	}
+	if err := Setup(ctx, config.Config{}, signer); err != nil {
+		t.Fatal(err)
+	}
+	if server != nil {
+		t.Error("expected the API to be stopped without an address")
+	}
+}
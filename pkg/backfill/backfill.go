@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backfill signs existing completed runs that were never signed, so
+// enabling Chains on an existing cluster retroactively covers recent builds.
+package backfill
+
+import (
+	"context"
+	"time"
+
+	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/reconciler/pipelinerun"
+	"github.com/tektoncd/chains/pkg/reconciler/taskrun"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/tracker"
+)
+
+// pageSize is the number of runs requested from the API server at a time.
+const pageSize = 500
+
+// Backfiller signs completed runs that don't carry the Chains signed annotation yet.
+type Backfiller struct {
+	Signer            signing.Signer
+	Pipelineclientset versioned.Interface
+	// Namespace restricts the backfill to a single namespace. All namespaces are
+	// backfilled when it is empty.
+	Namespace string
+	// MaxAge skips runs that completed longer ago than MaxAge. All runs are
+	// backfilled when it is zero.
+	MaxAge time.Duration
+	// Skip are the UIDs of runs that are not backfilled, like the runs archived in
+	// Tekton Results that are still in the cluster.
+	Skip sets.Set[types.UID]
+}
+
+// Result summarizes a backfill.
+type Result struct {
+	// Signed is the number of runs that were signed.
+	Signed int
+	// Skipped is the number of runs that were reconciled without being signed,
+	// for example PipelineRuns whose TaskRuns are not signed.
+	Skipped int
+	// Failed is the number of runs that could not be signed.
+	Failed int
+}
+
+// Run signs all completed, unsigned TaskRuns and then PipelineRuns that are
+// selected by the config in ctx. Failures to sign individual runs are logged and
+// counted in the result; an error is only returned if runs could not be listed.
+func (b *Backfiller) Run(ctx context.Context) (Result, error) {
+	logger := logging.FromContext(ctx)
+	cfg := config.FromContextOrDefaults(ctx)
+	cutoff := time.Time{}
+	if b.MaxAge > 0 {
+		cutoff = time.Now().Add(-b.MaxAge)
+	}
+	var result Result
+
+	trs, err := b.listTaskRuns(ctx)
+	if err != nil {
+		return result, err
+	}
+	trReconciler := &taskrun.Reconciler{
+		TaskRunSigner:     b.Signer,
+		Pipelineclientset: b.Pipelineclientset,
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for i := range trs {
+		tr := &trs[i]
+		if err := indexer.Add(tr); err != nil {
+			return result, err
+		}
+		if b.Skip.Has(tr.UID) || !needsSigning(cfg, tr, tr.Status.CompletionTime, tr.IsDone(), cutoff) {
+			continue
+		}
+		if err := trReconciler.ReconcileKind(ctx, tr); err != nil {
+			logger.Warnf("backfill: error signing taskrun %s/%s: %v", tr.Namespace, tr.Name, err)
+			result.Failed++
+			continue
+		}
+		b.record(ctx, &result, objects.NewTaskRunObject(tr))
+	}
+
+	prs, err := b.listPipelineRuns(ctx)
+	if err != nil {
+		return result, err
+	}
+	prReconciler := &pipelinerun.Reconciler{
+		PipelineRunSigner: b.Signer,
+		Pipelineclientset: b.Pipelineclientset,
+		TaskRunLister:     listers.NewTaskRunLister(indexer),
+		// TaskRuns that are still not reconciled are not waited for.
+		Tracker: tracker.New(func(types.NamespacedName) {}, time.Minute),
+	}
+	for i := range prs {
+		pr := &prs[i]
+		if b.Skip.Has(pr.UID) || !needsSigning(cfg, pr, pr.Status.CompletionTime, pr.IsDone(), cutoff) {
+			continue
+		}
+		if err := prReconciler.ReconcileKind(ctx, pr); err != nil {
+			logger.Warnf("backfill: error signing pipelinerun %s/%s: %v", pr.Namespace, pr.Name, err)
+			result.Failed++
+			continue
+		}
+		b.record(ctx, &result, objects.NewPipelineRunObject(pr))
+	}
+	return result, nil
+}
+
+// needsSigning returns whether a run is completed, selected by cfg, has not been
+// handled by Chains yet, and completed after the cutoff.
+func needsSigning(cfg *config.Config, obj metav1.Object, completed *metav1.Time, done bool, cutoff time.Time) bool {
+	if !done || !cfg.Selects(obj) {
+		return false
+	}
+	if _, ok := obj.GetAnnotations()[signing.ChainsAnnotation]; ok {
+		return false
+	}
+	return completed == nil || !completed.Time.Before(cutoff)
+}
+
+// record counts obj as signed if the reconciler marked it as such.
+func (b *Backfiller) record(ctx context.Context, result *Result, obj objects.TektonObject) {
+	annotations, err := obj.GetLatestAnnotations(ctx, b.Pipelineclientset)
+	if err != nil {
+		logging.FromContext(ctx).Warnf("backfill: error fetching annotations of %s %s/%s: %v", obj.GetGVK(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	if annotations[signing.ChainsAnnotation] == "true" {
+		result.Signed++
+		return
+	}
+	result.Skipped++
+}
+
+func (b *Backfiller) listTaskRuns(ctx context.Context) ([]v1beta1.TaskRun, error) {
+	var trs []v1beta1.TaskRun
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		list, err := b.Pipelineclientset.TektonV1beta1().TaskRuns(b.Namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		trs = append(trs, list.Items...)
+		if list.Continue == "" {
+			return trs, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+func (b *Backfiller) listPipelineRuns(ctx context.Context) ([]v1beta1.PipelineRun, error) {
+	var prs []v1beta1.PipelineRun
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		list, err := b.Pipelineclientset.TektonV1beta1().PipelineRuns(b.Namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, list.Items...)
+		if list.Continue == "" {
+			return prs, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backfill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+// markingSigner marks every object it signs as signed.
+type markingSigner struct {
+	ps     versioned.Interface
+	signed []string
+}
+
+func (s *markingSigner) Sign(ctx context.Context, obj objects.TektonObject) error {
+	s.signed = append(s.signed, obj.GetName())
+	return signing.MarkSigned(ctx, obj, s.ps, nil)
+}
+
+func completed() duckv1.Status {
+	return duckv1.Status{
+		Conditions: []apis.Condition{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue}},
+	}
+}
+
+func TestBackfiller_Run(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	now := time.Now()
+	recent := &metav1.Time{Time: now.Add(-time.Hour)}
+	old := &metav1.Time{Time: now.Add(-48 * time.Hour)}
+
+	taskRun := func(name string, annotations map[string]string, completion *metav1.Time, done bool) *v1beta1.TaskRun {
+		tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations}}
+		if done {
+			tr.Status.Status = completed()
+			tr.Status.CompletionTime = completion
+		}
+		return tr
+	}
+	for _, tr := range []*v1beta1.TaskRun{
+		taskRun("unsigned", nil, recent, true),
+		taskRun("signed", map[string]string{signing.ChainsAnnotation: "true"}, recent, true),
+		taskRun("running", nil, nil, false),
+		taskRun("too-old", nil, old, true),
+	} {
+		tekton.CreateObject(t, ctx, ps, objects.NewTaskRunObject(tr))
+	}
+	pr := &v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "pipeline", Namespace: "default"},
+	}
+	pr.Status.Status = completed()
+	pr.Status.CompletionTime = recent
+	pr.Status.ChildReferences = []v1beta1.ChildStatusReference{{Name: "unsigned"}}
+	tekton.CreateObject(t, ctx, ps, objects.NewPipelineRunObject(pr))
+
+	signer := &markingSigner{ps: ps}
+	b := &Backfiller{
+		Signer:            signer,
+		Pipelineclientset: ps,
+		MaxAge:            24 * time.Hour,
+	}
+	result, err := b.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Result{Signed: 2}); result != want {
+		t.Errorf("Run() = %+v, want %+v", result, want)
+	}
+	if len(signer.signed) != 2 || signer.signed[0] != "unsigned" || signer.signed[1] != "pipeline" {
+		t.Errorf("signed %v, want [unsigned pipeline]", signer.signed)
+	}
+}
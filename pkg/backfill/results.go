@@ -0,0 +1,230 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+)
+
+// The types of the records of TaskRuns and PipelineRuns in Tekton Results.
+const (
+	taskRunV1Beta1Record     = "tekton.dev/v1beta1.TaskRun"
+	taskRunV1Record          = "tekton.dev/v1.TaskRun"
+	pipelineRunV1Beta1Record = "tekton.dev/v1beta1.PipelineRun"
+	pipelineRunV1Record      = "tekton.dev/v1.PipelineRun"
+)
+
+// recordsPath is the path of the records of the results of a parent in the REST API of
+// Tekton Results, formatted with the parent. The "-" result lists the records of all
+// the results of the parent.
+const recordsPath = "/apis/results.tekton.dev/v1alpha2/parents/%s/results/-/records"
+
+// ResultsClient lists the TaskRuns and PipelineRuns archived in Tekton Results, so that
+// runs that were deleted from the cluster before Chains signed them can be backfilled.
+type ResultsClient struct {
+	// URL is the address of the REST API of Tekton Results.
+	URL string
+	// Token is the bearer token the API is called with, usually the token of the
+	// service account of the controller.
+	Token  string
+	Client *http.Client
+}
+
+// listRecordsResponse is the relevant subset of the response of the list records endpoint.
+type listRecordsResponse struct {
+	Records []struct {
+		Name string `json:"name"`
+		Data struct {
+			Type  string `json:"type"`
+			Value []byte `json:"value"`
+		} `json:"data"`
+	} `json:"records"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// Runs returns the TaskRuns and PipelineRuns archived in namespace, or in all namespaces
+// if it is empty. Records of API versions other than v1beta1 are converted to v1beta1.
+// Records that can't be decoded are logged and skipped.
+func (c *ResultsClient) Runs(ctx context.Context, namespace string) ([]v1beta1.TaskRun, []v1beta1.PipelineRun, error) {
+	logger := logging.FromContext(ctx)
+	parent := namespace
+	if parent == "" {
+		parent = "-"
+	}
+	filter := fmt.Sprintf("data_type in [%q, %q, %q, %q]", taskRunV1Beta1Record, taskRunV1Record, pipelineRunV1Beta1Record, pipelineRunV1Record)
+
+	var trs []v1beta1.TaskRun
+	var prs []v1beta1.PipelineRun
+	q := url.Values{}
+	q.Set("filter", filter)
+	q.Set("page_size", strconv.Itoa(pageSize))
+	for {
+		resp, err := c.listRecords(ctx, parent, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, r := range resp.Records {
+			switch r.Data.Type {
+			case taskRunV1Beta1Record, taskRunV1Record:
+				tr, err := decodeTaskRun(ctx, r.Data.Type, r.Data.Value)
+				if err != nil {
+					logger.Warnf("backfill: error decoding record %s: %v", r.Name, err)
+					continue
+				}
+				trs = append(trs, *tr)
+			case pipelineRunV1Beta1Record, pipelineRunV1Record:
+				pr, err := decodePipelineRun(ctx, r.Data.Type, r.Data.Value)
+				if err != nil {
+					logger.Warnf("backfill: error decoding record %s: %v", r.Name, err)
+					continue
+				}
+				prs = append(prs, *pr)
+			}
+		}
+		if resp.NextPageToken == "" {
+			return trs, prs, nil
+		}
+		q.Set("page_token", resp.NextPageToken)
+	}
+}
+
+func (c *ResultsClient) listRecords(ctx context.Context, parent string, query url.Values) (*listRecordsResponse, error) {
+	u := strings.TrimSuffix(c.URL, "/") + fmt.Sprintf(recordsPath, url.PathEscape(parent)) + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listing records of Tekton Results: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d listing records of Tekton Results: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	records := &listRecordsResponse{}
+	if err := json.Unmarshal(body, records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func decodeTaskRun(ctx context.Context, recordType string, value []byte) (*v1beta1.TaskRun, error) {
+	tr := &v1beta1.TaskRun{}
+	if recordType == taskRunV1Beta1Record {
+		return tr, json.Unmarshal(value, tr)
+	}
+	v1tr := &v1.TaskRun{}
+	if err := json.Unmarshal(value, v1tr); err != nil {
+		return nil, err
+	}
+	if err := tr.ConvertFrom(ctx, v1tr); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+func decodePipelineRun(ctx context.Context, recordType string, value []byte) (*v1beta1.PipelineRun, error) {
+	pr := &v1beta1.PipelineRun{}
+	if recordType == pipelineRunV1Beta1Record {
+		return pr, json.Unmarshal(value, pr)
+	}
+	v1pr := &v1.PipelineRun{}
+	if err := json.Unmarshal(value, v1pr); err != nil {
+		return nil, err
+	}
+	if err := pr.ConvertFrom(ctx, v1pr); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// Offline returns a clientset that serves trs and prs from memory, to backfill runs
+// that are no longer in the cluster like the runs of the cluster. The annotations
+// Chains sets on the runs, and the payloads the tekton backend stores in them, are
+// lost once the backfill completes. When several runs have the same name, like a run
+// that was deleted and created again, the one created last is served.
+func Offline(trs []v1beta1.TaskRun, prs []v1beta1.PipelineRun) versioned.Interface {
+	latest := map[string]metav1.Object{}
+	add := func(kind string, obj metav1.Object) {
+		key := kind + "/" + obj.GetNamespace() + "/" + obj.GetName()
+		if existing, ok := latest[key]; ok && obj.GetCreationTimestamp().Time.Before(existing.GetCreationTimestamp().Time) {
+			return
+		}
+		latest[key] = obj
+	}
+	for i := range trs {
+		add("taskrun", &trs[i])
+	}
+	for i := range prs {
+		add("pipelinerun", &prs[i])
+	}
+	objs := make([]runtime.Object, 0, len(latest))
+	for _, obj := range latest {
+		objs = append(objs, obj.(runtime.Object))
+	}
+	return fake.NewSimpleClientset(objs...)
+}
+
+// InCluster returns the UIDs of the runs of namespace, or of all namespaces if it is
+// empty, that are still in the cluster of ps. The controller signs them, so they are
+// skipped when backfilling the runs archived in Tekton Results.
+func InCluster(ctx context.Context, ps versioned.Interface, namespace string) (sets.Set[types.UID], error) {
+	b := &Backfiller{Pipelineclientset: ps, Namespace: namespace}
+	uids := sets.New[types.UID]()
+	trs, err := b.listTaskRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, tr := range trs {
+		uids.Insert(tr.UID)
+	}
+	prs, err := b.listPipelineRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range prs {
+		uids.Insert(pr.UID)
+	}
+	return uids, nil
+}
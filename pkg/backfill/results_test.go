@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backfill
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+type record struct {
+	Name string     `json:"name"`
+	Data recordData `json:"data"`
+}
+
+type recordData struct {
+	Type  string `json:"type"`
+	Value []byte `json:"value"`
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestResultsClient_Runs(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	v1TaskRun := &v1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1-taskrun", Namespace: "ns", UID: "uid-1"},
+		Spec:       v1.TaskRunSpec{ServiceAccountName: "builder"},
+	}
+	v1beta1PipelineRun := &v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "pipelinerun", Namespace: "ns", UID: "uid-2"},
+	}
+	pages := map[string]map[string]interface{}{
+		"": {
+			"records": []record{
+				{Name: "ns/results/a/records/1", Data: recordData{Type: taskRunV1Record, Value: mustJSON(t, v1TaskRun)}},
+				{Name: "ns/results/a/records/2", Data: recordData{Type: "results.tekton.dev/v1alpha2.Log", Value: []byte("logs")}},
+			},
+			"nextPageToken": "next",
+		},
+		"next": {
+			"records": []record{
+				{Name: "ns/results/b/records/3", Data: recordData{Type: pipelineRunV1Beta1Record, Value: mustJSON(t, v1beta1PipelineRun)}},
+				{Name: "ns/results/b/records/4", Data: recordData{Type: taskRunV1Beta1Record, Value: []byte("not json")}},
+			},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q, want the bearer token", got)
+		}
+		if want := "/apis/results.tekton.dev/v1alpha2/parents/ns/results/-/records"; r.URL.Path != want {
+			t.Errorf("path = %q, want %q", r.URL.Path, want)
+		}
+		if filter := r.URL.Query().Get("filter"); !strings.Contains(filter, taskRunV1Record) || !strings.Contains(filter, pipelineRunV1Record) {
+			t.Errorf("filter = %q, want the types of TaskRuns and PipelineRuns", filter)
+		}
+		page, ok := pages[r.URL.Query().Get("page_token")]
+		if !ok {
+			http.Error(w, "unknown page", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	c := &ResultsClient{URL: server.URL + "/", Token: "token"}
+	trs, prs, err := c.Runs(ctx, "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trs) != 1 || trs[0].Name != "v1-taskrun" || trs[0].Spec.ServiceAccountName != "builder" {
+		t.Errorf("Runs() TaskRuns = %+v, want the converted v1 TaskRun", trs)
+	}
+	if len(prs) != 1 || prs[0].Name != "pipelinerun" {
+		t.Errorf("Runs() PipelineRuns = %+v, want the v1beta1 PipelineRun", prs)
+	}
+}
+
+func TestResultsClient_Runs_Error(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	c := &ResultsClient{URL: server.URL}
+	if _, _, err := c.Runs(ctx, ""); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Runs() error = %v, want the error of the API", err)
+	}
+}
+
+func TestBackfiller_Run_Offline(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	now := time.Now()
+	taskRun := func(name string, uid types.UID, created time.Time) v1beta1.TaskRun {
+		tr := v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "ns", UID: uid, CreationTimestamp: metav1.Time{Time: created},
+		}}
+		tr.Status.Status = completed()
+		return tr
+	}
+	trs := []v1beta1.TaskRun{
+		// A run that was deleted and created again: only the latest is signed.
+		taskRun("recreated", "new", now),
+		taskRun("recreated", "old", now.Add(-time.Hour)),
+		taskRun("pruned", "pruned", now),
+		taskRun("in-cluster", "in-cluster", now),
+	}
+	ps := Offline(trs, nil)
+
+	signer := &markingSigner{ps: ps}
+	b := &Backfiller{
+		Signer:            signer,
+		Pipelineclientset: ps,
+		Skip:              sets.New[types.UID]("in-cluster"),
+	}
+	result, err := b.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Result{Signed: 2}); result != want {
+		t.Errorf("Run() = %+v, want %+v", result, want)
+	}
+	got, err := ps.TektonV1beta1().TaskRuns("ns").Get(ctx, "recreated", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UID != "new" {
+		t.Errorf("got the TaskRun with UID %q, want the one created last", got.UID)
+	}
+	if diff := cmp.Diff([]string{"pruned", "recreated"}, sets.List(sets.New(signer.signed...))); diff != "" {
+		t.Errorf("signed (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package allowlist checks the step images and materials of runs against the registries
+// and URI prefixes they are allowed to come from, to support trusted base image policies.
+package allowlist
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Enabled returns whether any allowed prefixes are configured.
+func Enabled(prefixes sets.Set[string]) bool {
+	return prefixes.Clone().Delete("").Len() > 0
+}
+
+// Allowed returns whether uri starts with one of prefixes, with or without its scheme,
+// so that both oci://gcr.io/org/ and gcr.io/org/ allow oci://gcr.io/org/image.
+func Allowed(uri string, prefixes sets.Set[string]) bool {
+	_, unqualified, hasScheme := strings.Cut(uri, "://")
+	for _, p := range prefixes.UnsortedList() {
+		if p == "" {
+			continue
+		}
+		if strings.HasPrefix(uri, p) || (hasScheme && strings.HasPrefix(unqualified, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DisallowedURIs returns the uris that aren't allowed by prefixes, sorted and without
+// duplicates. It returns none if no prefixes are configured.
+func DisallowedURIs(uris []string, prefixes sets.Set[string]) []string {
+	if !Enabled(prefixes) {
+		return nil
+	}
+	disallowed := sets.New[string]()
+	for _, uri := range uris {
+		if uri != "" && !Allowed(uri, prefixes) {
+			disallowed.Insert(uri)
+		}
+	}
+	if disallowed.Len() == 0 {
+		return nil
+	}
+	l := disallowed.UnsortedList()
+	sort.Strings(l)
+	return l
+}
+
+// Disallowed returns the URIs of the materials of the in-toto statement in payload that
+// aren't allowed by prefixes: the materials of SLSA v0.2 predicates and the resolved
+// dependencies of SLSA v1.0 predicates, which include the step images of the run.
+// It returns none for payloads that aren't in-toto statements.
+func Disallowed(payload []byte, prefixes sets.Set[string]) []string {
+	var st struct {
+		Predicate struct {
+			Materials []struct {
+				URI string `json:"uri"`
+			} `json:"materials"`
+			BuildDefinition struct {
+				ResolvedDependencies []struct {
+					URI string `json:"uri"`
+				} `json:"resolvedDependencies"`
+			} `json:"buildDefinition"`
+		} `json:"predicate"`
+	}
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return nil
+	}
+	var uris []string
+	for _, m := range st.Predicate.Materials {
+		uris = append(uris, m.URI)
+	}
+	for _, rd := range st.Predicate.BuildDefinition.ResolvedDependencies {
+		uris = append(uris, rd.URI)
+	}
+	return DisallowedURIs(uris, prefixes)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package allowlist
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestDisallowed(t *testing.T) {
+	prefixes := sets.New[string]("gcr.io/trusted/", "git+https://github.com/org/")
+	tests := []struct {
+		name     string
+		payload  string
+		prefixes sets.Set[string]
+		want     []string
+	}{{
+		name: "slsa v0.2 materials",
+		payload: `{"predicate": {"materials": [
+			{"uri": "oci://gcr.io/trusted/golang"},
+			{"uri": "oci://docker.io/library/alpine"},
+			{"uri": "git+https://github.com/org/repo.git"},
+			{"uri": "oci://docker.io/library/alpine"}
+		]}}`,
+		prefixes: prefixes,
+		want:     []string{"oci://docker.io/library/alpine"},
+	}, {
+		name: "slsa v1 resolved dependencies",
+		payload: `{"predicate": {"buildDefinition": {"resolvedDependencies": [
+			{"uri": "oci://gcr.io/untrusted/golang"},
+			{"uri": "git+https://github.com/other/repo.git"},
+			{"name": "inputs/result"}
+		]}}}`,
+		prefixes: prefixes,
+		want:     []string{"git+https://github.com/other/repo.git", "oci://gcr.io/untrusted/golang"},
+	}, {
+		name:     "all allowed",
+		payload:  `{"predicate": {"materials": [{"uri": "oci://gcr.io/trusted/golang"}]}}`,
+		prefixes: prefixes,
+	}, {
+		name:     "no prefixes",
+		payload:  `{"predicate": {"materials": [{"uri": "oci://docker.io/library/alpine"}]}}`,
+		prefixes: sets.New[string](""),
+	}, {
+		name:     "not a statement",
+		payload:  `"signature"`,
+		prefixes: prefixes,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Disallowed([]byte(tt.payload), tt.prefixes)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Disallowed() -want +got: %s", diff)
+			}
+		})
+	}
+}
@@ -16,10 +16,15 @@ package chains
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage/github"
+	"github.com/tektoncd/chains/pkg/chains/storage/ipfs"
+	"github.com/tektoncd/chains/pkg/chains/storage/tekton"
 	"github.com/tektoncd/chains/pkg/patch"
 	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	"knative.dev/pkg/logging"
@@ -30,7 +35,13 @@ const (
 	ChainsAnnotation             = "chains.tekton.dev/signed"
 	RetryAnnotation              = "chains.tekton.dev/retries"
 	ChainsTransparencyAnnotation = "chains.tekton.dev/transparency"
-	MaxRetries                   = 3
+	// InvalidAnnotation lists the keys of the artifacts of a run whose payloads failed
+	// validation against the schema of their predicate type.
+	InvalidAnnotation = "chains.tekton.dev/invalid"
+	// DisallowedMaterialsAnnotation lists the materials of a run that aren't in
+	// materials.allowed-prefixes.
+	DisallowedMaterialsAnnotation = "chains.tekton.dev/disallowed-materials"
+	MaxRetries                    = 3
 )
 
 // Reconciled determines whether a Tekton object has already been reconciled.
@@ -60,9 +71,10 @@ func reconciledFromAnnotations(annotations map[string]string) bool {
 	return val == "true" || val == "failed"
 }
 
-// MarkSigned marks a Tekton object as signed.
+// MarkSigned marks a Tekton object as signed. Objects previously marked as failed
+// are marked as signed once they are signed successfully.
 func MarkSigned(ctx context.Context, obj objects.TektonObject, ps versioned.Interface, annotations map[string]string) error {
-	if _, ok := obj.GetAnnotations()[ChainsAnnotation]; ok {
+	if obj.GetAnnotations()[ChainsAnnotation] == "true" {
 		return nil
 	}
 	return AddAnnotation(ctx, obj, ps, ChainsAnnotation, "true", annotations)
@@ -73,6 +85,10 @@ func MarkFailed(ctx context.Context, obj objects.TektonObject, ps versioned.Inte
 }
 
 func RetryAvailable(obj objects.TektonObject) bool {
+	return retryAvailable(obj, MaxRetries)
+}
+
+func retryAvailable(obj objects.TektonObject, maxRetries int) bool {
 	ann, ok := obj.GetAnnotations()[RetryAnnotation]
 	if !ok {
 		return true
@@ -81,7 +97,7 @@ func RetryAvailable(obj objects.TektonObject) bool {
 	if err != nil {
 		return false
 	}
-	return val < MaxRetries
+	return val < maxRetries
 }
 
 func AddRetry(ctx context.Context, obj objects.TektonObject, ps versioned.Interface, annotations map[string]string) error {
@@ -112,3 +128,53 @@ func AddAnnotation(ctx context.Context, obj objects.TektonObject, ps versioned.I
 	}
 	return nil
 }
+
+// storedAnnotationFormats are the formats of the annotations storage backends store
+// the results of signing a run in, formatted with the key of the signed object.
+var storedAnnotationFormats = []string{
+	tekton.PayloadAnnotationFormat,
+	tekton.SignatureAnnotationFormat,
+	tekton.CertAnnotationsFormat,
+	tekton.ChainAnnotationFormat,
+	ipfs.CIDAnnotationFormat,
+	github.AttestationAnnotationFormat,
+}
+
+// ResetAnnotations returns the sorted keys of the annotations that record how Chains
+// handled a run: whether it was signed and with which format version, its retries,
+// transparency log entry and whether it is pending, dry run, truncated and invalid payloads, disallowed
+// materials, the locations of its attestations, and the payloads and signatures stored
+// in it or by the storage backends. Annotations set by users,
+// such as config overrides, are not included.
+func ResetAnnotations(annotations map[string]string) []string {
+	var keys []string
+	for key := range annotations {
+		switch key {
+		case ChainsAnnotation, RetryAnnotation, ChainsTransparencyAnnotation, DryRunAnnotation, TruncatedAnnotation, InvalidAnnotation, DisallowedMaterialsAnnotation, FormatVersionAnnotation, TransparencyPendingAnnotation, LocationsAnnotation:
+			keys = append(keys, key)
+			continue
+		}
+		for _, format := range storedAnnotationFormats {
+			if strings.HasPrefix(key, strings.TrimSuffix(format, "%s")) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Reset removes the annotations returned by ResetAnnotations from obj, so that the
+// controller signs it again, with the current keys and formats, once it sees the update.
+func Reset(ctx context.Context, obj objects.TektonObject, ps versioned.Interface) error {
+	keys := ResetAnnotations(obj.GetAnnotations())
+	if len(keys) == 0 {
+		return nil
+	}
+	patchBytes, err := patch.GetAnnotationsRemovalPatch(keys)
+	if err != nil {
+		return err
+	}
+	return obj.Patch(ctx, ps, patchBytes)
+}
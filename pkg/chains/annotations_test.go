@@ -16,6 +16,7 @@ package chains
 import (
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/test/tekton"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
@@ -372,3 +373,39 @@ func TestAddRetry(t *testing.T) {
 		})
 	}
 }
+
+func TestReset(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "mytaskrun", Annotations: map[string]string{
+			ChainsAnnotation:                                   "failed",
+			RetryAnnotation:                                    "3",
+			ChainsTransparencyAnnotation:                       "https://rekor.sigstore.dev/api/v1/log/entries?logIndex=1",
+			"chains.tekton.dev/payload-taskrun-uid":            "payload",
+			"chains.tekton.dev/signature-taskrun-uid":          "signature",
+			LocationsAnnotation:                                `{"oci":["gcr.io/foo/bar"]}`,
+			"chains.tekton.dev/github-attestation-taskrun-uid": "1",
+			RekorAnnotation:                                    "true",
+			OverrideAnnotationPrefix + "format":                "slsa/v1",
+			"other":                                            "value",
+		}},
+	})
+	tekton.CreateObject(t, ctx, c, tro)
+
+	if err := Reset(ctx, tro, c); err != nil {
+		t.Fatal(err)
+	}
+	reset, err := tekton.GetObject(t, ctx, c, tro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		RekorAnnotation:                     "true",
+		OverrideAnnotationPrefix + "format": "slsa/v1",
+		"other":                             "value",
+	}
+	if diff := cmp.Diff(want, reset.GetAnnotations()); diff != "" {
+		t.Errorf("annotations after Reset() (-want +got): %s", diff)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"knative.dev/pkg/logging"
+)
+
+// Attestation is a signed payload of an artifact of a run.
+type Attestation struct {
+	Type   string
+	Key    string
+	Format string
+	// Payload is the signed payload and Signature its signature, the DSSE envelope of
+	// in-toto attestations.
+	Payload   []byte
+	Signature []byte
+	// Cert and Chain are the PEM signing certificate and its chain, if any.
+	Cert  string
+	Chain string
+}
+
+// Attest generates and signs the payloads of tektonObj like Sign, with the same checks,
+// and returns them instead of storing them. They are not uploaded to the transparency
+// log and the object is not annotated. Unlike Sign, it fails if tektonObj isn't selected
+// by the config, or if any of the payloads couldn't be generated, was denied or couldn't
+// be signed.
+func (o *ObjectSigner) Attest(ctx context.Context, tektonObj objects.TektonObject) ([]Attestation, error) {
+	cfg := *config.FromContext(ctx)
+	logger := logging.FromContext(ctx)
+
+	if !cfg.Selects(tektonObj) {
+		return nil, classify(ErrorClassPolicy, fmt.Errorf("%s %s/%s isn't selected for signing", tektonObj.GetKindName(), tektonObj.GetNamespace(), tektonObj.GetName()))
+	}
+	ctx, signableTypes, err := o.prepare(ctx, tektonObj, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	secretClient := o.LeaseClient
+	if secretClient == nil {
+		secretClient = o.KubeClient
+	}
+	sp, err := SigningSecretPath(ctx, secretClient, o.SecretPath, cfg)
+	if err != nil {
+		return nil, err
+	}
+	signers := AllSigners(ctx, sp, cfg)
+
+	attestations := []Attestation{}
+	for _, signableType := range signableTypes {
+		if !signableType.Enabled(cfg) {
+			continue
+		}
+		payloadFormat := signableType.PayloadFormat(cfg)
+		payloader, err := formats.GetPayloader(payloadFormat, cfg)
+		if err != nil {
+			logger.Warnf("Format %s configured for %s: %v was not found", payloadFormat, tektonObj.GetGVK(), signableType.Type())
+			continue
+		}
+
+		for _, obj := range signableType.ExtractObjects(ctx, payloadObject(tektonObj, cfg.Provenance)) {
+			artifact := &audit.Artifact{
+				Type:   signableType.Type(),
+				Key:    signableType.ShortKey(obj),
+				Format: string(payloadFormat),
+			}
+			signed, err := signArtifact(ctx, cfg, tektonObj, signableType, payloader, signers, obj, artifact)
+			if err != nil {
+				return nil, err
+			}
+			if signed == nil {
+				return nil, fmt.Errorf("signing %s payload of %s: %s", payloadFormat, artifact.Key, artifact.Error)
+			}
+			attestations = append(attestations, Attestation{
+				Type:      artifact.Type,
+				Key:       artifact.Key,
+				Format:    artifact.Format,
+				Payload:   signed.payload,
+				Signature: signed.signature,
+				Cert:      signed.signer.Cert(),
+				Chain:     signed.signer.Chain(),
+			})
+		}
+	}
+	return attestations, nil
+}
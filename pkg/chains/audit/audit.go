@@ -0,0 +1,264 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit writes a machine-readable stream of the decisions Chains makes
+// for every run, one JSON event per line.
+package audit
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/tektoncd/chains/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/logging"
+)
+
+// Schema identifies audit events, so they can be told apart from log lines
+// written to the same stream.
+const Schema = "chains.tekton.dev/audit/v1"
+
+// Decisions recorded in audit events.
+const (
+	DecisionSigned  = "signed"
+	DecisionFailed  = "failed"
+	DecisionSkipped = "skipped"
+	DecisionDryRun  = "dry-run"
+)
+
+// Event is a decision Chains made for a run.
+type Event struct {
+	Schema    string    `json:"schema"`
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       string    `json:"uid,omitempty"`
+	// Cluster is the workload cluster the run executed in, if it isn't the cluster the
+	// controller runs in.
+	Cluster   string      `json:"cluster,omitempty"`
+	Decision  string      `json:"decision"`
+	Reason    string      `json:"reason,omitempty"`
+	Artifacts []*Artifact `json:"artifacts,omitempty"`
+}
+
+// Artifact describes how a single signable object of a run was handled.
+type Artifact struct {
+	Type     string   `json:"type"`
+	Key      string   `json:"key"`
+	Format   string   `json:"format"`
+	Subjects []string `json:"subjects,omitempty"`
+	Signer   string   `json:"signer,omitempty"`
+	// Identity is the identity in the signing certificate, if any.
+	Identity string `json:"identity,omitempty"`
+	// Signatures are the base64-encoded signatures of the payload.
+	Signatures []string `json:"signatures,omitempty"`
+	// KeyID is the SHA-256 fingerprint of the public key the payload was signed with.
+	KeyID string `json:"keyID,omitempty"`
+	// PayloadDigest is the SHA-256 digest of the signed payload.
+	PayloadDigest string `json:"payloadDigest,omitempty"`
+	// Backends are the storage backends the signature was written to.
+	Backends []string `json:"backends,omitempty"`
+	// Transparency is the transparency log entry of the signature, if any.
+	Transparency string `json:"transparency,omitempty"`
+	// Locations are where the payload was stored, by backend, e.g. OCI references or
+	// bucket object paths. The UUID of the transparency log entry is recorded under rekor.
+	Locations map[string][]string `json:"locations,omitempty"`
+	// Truncated lists what was dropped from the attestation to fit in the maximum
+	// attestation size, if anything.
+	Truncated []string `json:"truncated,omitempty"`
+	// Invalid is why the payload failed validation against the schema of its predicate
+	// type, if it did.
+	Invalid string `json:"invalid,omitempty"`
+	// Disallowed lists the materials of the payload that aren't in the allowed
+	// prefixes, if any.
+	Disallowed []string `json:"disallowed,omitempty"`
+	// Denied lists the policy violations that kept the payload from being signed, if any.
+	Denied []string `json:"denied,omitempty"`
+	// Error is set if the artifact was not signed or not stored in all backends.
+	Error string `json:"error,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	current config.AuditConfig
+	out     io.Writer
+	closer  io.Closer
+)
+
+// Setup configures where audit events are written according to cfg. It is safe to
+// call on every config update: the sink is only reopened when the config changed.
+func Setup(cfg config.AuditConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cfg == current {
+		return nil
+	}
+	if closer != nil {
+		_ = closer.Close()
+		closer = nil
+	}
+	current, out = cfg, nil
+	switch cfg.Sink {
+	case "stdout":
+		out = os.Stdout
+	case "file":
+		f, err := os.OpenFile(cfg.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			current = config.AuditConfig{}
+			return err
+		}
+		out, closer = f, f
+	}
+	return nil
+}
+
+// Record writes ev to the audit sink, if one is configured.
+func Record(ctx context.Context, ev Event) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if out == nil {
+		return
+	}
+	ev.Schema = Schema
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	raw, err := json.Marshal(ev)
+	if err != nil {
+		logging.FromContext(ctx).Warnf("error marshalling audit event: %v", err)
+		return
+	}
+	if _, err := out.Write(append(raw, '\n')); err != nil {
+		logging.FromContext(ctx).Warnf("error writing audit event: %v", err)
+	}
+}
+
+// skippedSize bounds the number of runs whose skipped events are remembered.
+const skippedSize = 10000
+
+// skipped remembers the runs and reasons recorded by Skipped, so that informer resyncs
+// of runs that are never signed don't record them again.
+var skipped, _ = lru.New(skippedSize)
+
+// Skipped records that a run of the given kind was not signed for the given reason. It
+// is recorded once per run and reason.
+func Skipped(ctx context.Context, kind string, obj metav1.Object, reason string) {
+	if uid := obj.GetUID(); uid != "" {
+		if seen, _ := skipped.ContainsOrAdd(string(uid)+"/"+reason, nil); seen {
+			return
+		}
+	}
+	Record(ctx, Event{
+		Kind:      kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		UID:       string(obj.GetUID()),
+		Decision:  DecisionSkipped,
+		Reason:    reason,
+	})
+}
+
+// Identity returns the identity encoded in a PEM signing certificate: its first email
+// address or URI, or its subject common name. It returns an empty string if cert is
+// empty or invalid.
+func Identity(cert string) string {
+	block, _ := pem.Decode([]byte(cert))
+	if block == nil {
+		return ""
+	}
+	c, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case len(c.EmailAddresses) > 0:
+		return c.EmailAddresses[0]
+	case len(c.URIs) > 0:
+		return c.URIs[0].String()
+	default:
+		return c.Subject.CommonName
+	}
+}
+
+// KeyID returns the SHA-256 fingerprint of the DER-encoded public key pub as
+// sha256:<hex>, or an empty string if pub can't be encoded.
+func KeyID(pub crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return ""
+	}
+	return Digest(der)
+}
+
+// Digest returns the SHA-256 digest of data as sha256:<hex>.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Signatures returns the base64-encoded signatures in signature, which is either a raw
+// signature or a DSSE envelope.
+func Signatures(signature []byte) []string {
+	var envelope dsse.Envelope
+	if err := json.Unmarshal(signature, &envelope); err == nil && len(envelope.Signatures) > 0 {
+		sigs := make([]string, 0, len(envelope.Signatures))
+		for _, s := range envelope.Signatures {
+			sigs = append(sigs, s.Sig)
+		}
+		return sigs
+	}
+	return []string{base64.StdEncoding.EncodeToString(signature)}
+}
+
+// Subjects returns the subjects of the JSON-encoded in-toto statement payload as
+// name@algorithm:digest. It takes the encoded payload, which is signed anyway, rather
+// than encoding the payload once more. It returns nothing for payloads that aren't
+// in-toto statements.
+func Subjects(payload []byte) []string {
+	var statement struct {
+		Subject []struct {
+			Name   string            `json:"name"`
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil
+	}
+	var subjects []string
+	for _, s := range statement.Subject {
+		subject := s.Name
+		for _, alg := range []string{"sha256", "sha512", "sha1"} {
+			if d, ok := s.Digest[alg]; ok {
+				subject += "@" + alg + ":" + d
+				break
+			}
+		}
+		subjects = append(subjects, subject)
+	}
+	return subjects
+}
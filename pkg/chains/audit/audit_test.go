@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := Setup(config.AuditConfig{Sink: "file", FilePath: path}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := Setup(config.AuditConfig{}); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	Record(ctx, Event{
+		Kind:      "taskrun",
+		Namespace: "default",
+		Name:      "build",
+		Decision:  DecisionSigned,
+		Artifacts: []*Artifact{{Type: "tekton", Key: "taskrun-uid", Format: "in-toto", Backends: []string{"oci"}}},
+	})
+	Skipped(ctx, "pipelinerun", &v1beta1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "release"}}, "not selected")
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit events, got %d: %s", len(lines), raw)
+	}
+	var events []Event
+	for _, l := range lines {
+		var ev Event
+		if err := json.Unmarshal([]byte(l), &ev); err != nil {
+			t.Fatalf("error parsing audit event %q: %v", l, err)
+		}
+		if ev.Schema != Schema || ev.Time.IsZero() {
+			t.Errorf("audit event is missing its schema or time: %s", l)
+		}
+		events = append(events, ev)
+	}
+	if events[0].Decision != DecisionSigned || events[0].Artifacts[0].Backends[0] != "oci" {
+		t.Errorf("unexpected signed event %+v", events[0])
+	}
+	if events[1].Decision != DecisionSkipped || events[1].Reason != "not selected" || events[1].Name != "release" {
+		t.Errorf("unexpected skipped event %+v", events[1])
+	}
+}
+
+func TestSkipped_Once(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := Setup(config.AuditConfig{Sink: "file", FilePath: path}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := Setup(config.AuditConfig{}); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	pr := &v1beta1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "release", UID: "skipped-once"}}
+	// Resyncs of the run reconcile it again.
+	for i := 0; i < 3; i++ {
+		Skipped(ctx, "pipelinerun", pr, "not selected")
+	}
+	Skipped(ctx, "pipelinerun", pr, "taskrun build not found")
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n"); len(lines) != 2 {
+		t.Errorf("expected one audit event per reason, got %d: %s", len(lines), raw)
+	}
+}
+
+func TestRecord_Disabled(t *testing.T) {
+	if err := Setup(config.AuditConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	// Must not panic without a sink.
+	Record(context.Background(), Event{Decision: DecisionSigned})
+}
+
+func TestSubjects(t *testing.T) {
+	payload := []byte(`{
+		"_type": "https://in-toto.io/Statement/v0.1",
+		"subject": [
+			{"name": "gcr.io/foo/bar", "digest": {"sha256": "abc"}},
+			{"name": "baz", "digest": {}}
+		]
+	}`)
+	want := []string{"gcr.io/foo/bar@sha256:abc", "baz"}
+	if diff := cmp.Diff(want, Subjects(payload)); diff != "" {
+		t.Errorf("Subjects() (-want +got): %s", diff)
+	}
+	if got := Subjects([]byte(`"not a statement"`)); got != nil {
+		t.Errorf("Subjects() = %v, want none", got)
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	envelope := []byte(`{"payloadType":"application/vnd.in-toto+json","payload":"e30=","signatures":[{"keyid":"","sig":"c2lnMQ=="},{"keyid":"","sig":"c2lnMg=="}]}`)
+	if diff := cmp.Diff([]string{"c2lnMQ==", "c2lnMg=="}, Signatures(envelope)); diff != "" {
+		t.Errorf("Signatures() of envelope (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{"c2ln"}, Signatures([]byte("sig"))); diff != "" {
+		t.Errorf("Signatures() of raw signature (-want +got): %s", diff)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	if got := Identity(""); got != "" {
+		t.Errorf("Identity() = %q, want empty", got)
+	}
+	if got := Identity("not a certificate"); got != "" {
+		t.Errorf("Identity() = %q, want empty", got)
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(der)
+	if got, want := KeyID(key.Public()), "sha256:"+hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("KeyID() = %q, want %q", got, want)
+	}
+	if got := KeyID("not a key"); got != "" {
+		t.Errorf("KeyID() = %q, want empty", got)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	if got, want := Digest([]byte("{}")), "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"; got != want {
+		t.Errorf("Digest() = %q, want %q", got, want)
+	}
+}
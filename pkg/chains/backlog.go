@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/metrics"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+)
+
+// backlogInterval is how often the backlog of unsigned runs is recorded.
+const backlogInterval = 30 * time.Second
+
+// Backlog records metrics on the completed runs of Kind that are not signed yet, so
+// operators can alert when Chains falls behind or fails to sign some of the runs.
+type Backlog struct {
+	Kind string
+	// Cluster is the name of the cluster the runs are watched in, empty for the
+	// cluster the controller runs in.
+	Cluster string
+	// List returns the runs of Kind to report on, usually from the informer cache.
+	List func(cfg config.Config) ([]objects.TektonObject, error)
+
+	mu   sync.Mutex
+	cfg  config.Config
+	stop context.CancelFunc
+}
+
+// Setup records the backlog with cfg every backlogInterval until ctx is done. It is
+// safe to call on every config update: the recording only starts on the first call.
+func (b *Backlog) Setup(ctx context.Context, cfg config.Config) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.cfg = cfg
+	if b.stop != nil {
+		return
+	}
+	ctx, b.stop = context.WithCancel(ctx)
+	go func() {
+		logger := logging.FromContext(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backlogInterval):
+			}
+			if err := b.Record(ctx, b.state(), time.Now()); err != nil {
+				logger.Warnf("error recording the backlog of unsigned %ss: %v", b.Kind, err)
+			}
+		}
+	}()
+}
+
+// state returns the config of the last Setup.
+func (b *Backlog) state() config.Config {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cfg
+}
+
+// Record records the metrics of the runs listed with cfg at now. Runs that are not done
+// or not selected for signing by cfg are left out.
+func (b *Backlog) Record(ctx context.Context, cfg config.Config, now time.Time) error {
+	runs, err := b.List(cfg)
+	if err != nil {
+		return err
+	}
+	max := maxRetries(cfg.Retry)
+	backlog := metrics.Backlog{MaxRetriesRemaining: max + 1}
+	for _, obj := range runs {
+		if !obj.IsDone() || !cfg.Selects(obj) {
+			continue
+		}
+		switch obj.GetAnnotations()[ChainsAnnotation] {
+		case "true":
+			continue
+		case "failed":
+			backlog.Failed++
+			continue
+		}
+		backlog.Ages = append(backlog.Ages, now.Sub(completionTime(obj)))
+		backlog.RetriesRemaining = append(backlog.RetriesRemaining, retriesRemaining(obj, max))
+	}
+	metrics.RecordBacklog(ctx, b.Kind, b.Cluster, backlog)
+	return nil
+}
+
+// retriesRemaining returns how many more times signing obj can fail before it is marked
+// as failed, with max retries.
+func retriesRemaining(obj objects.TektonObject, max int) int {
+	ann, ok := obj.GetAnnotations()[RetryAnnotation]
+	if !ok {
+		// The retry annotation is only added once signing failed.
+		return max + 1
+	}
+	val, err := strconv.Atoi(ann)
+	if err != nil || val >= max {
+		return 0
+	}
+	return max - val
+}
+
+// completionTime returns when obj completed, defaulting to when it was created.
+func completionTime(obj objects.TektonObject) time.Time {
+	var status interface {
+		GetCondition(apis.ConditionType) *apis.Condition
+	}
+	switch o := obj.GetObject().(type) {
+	case *v1beta1.TaskRun:
+		if o.Status.CompletionTime != nil {
+			return o.Status.CompletionTime.Time
+		}
+		status = &o.Status
+	case *v1beta1.PipelineRun:
+		if o.Status.CompletionTime != nil {
+			return o.Status.CompletionTime.Time
+		}
+		status = &o.Status
+	case *v1beta1.CustomRun:
+		if o.Status.CompletionTime != nil {
+			return o.Status.CompletionTime.Time
+		}
+		status = &o.Status
+	}
+	if status != nil {
+		if c := status.GetCondition(apis.ConditionSucceeded); c != nil && !c.LastTransitionTime.Inner.IsZero() {
+			return c.LastTransitionTime.Inner.Time
+		}
+	}
+	return obj.GetCreationTimestamp().Time
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"go.opencensus.io/stats/view"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestBacklogRecord(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	taskRun := func(name string, done bool, completed time.Time, annotations map[string]string) objects.TektonObject {
+		tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Annotations:       annotations,
+			CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour)),
+		}}
+		if done {
+			tr.Status.Status = duckv1.Status{Conditions: []apis.Condition{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue}}}
+			if !completed.IsZero() {
+				tr.Status.CompletionTime = &metav1.Time{Time: completed}
+			}
+		}
+		return objects.NewTaskRunObject(tr)
+	}
+	runs := []objects.TektonObject{
+		taskRun("running", false, time.Time{}, nil),
+		taskRun("signed", true, now.Add(-time.Minute), map[string]string{ChainsAnnotation: "true"}),
+		taskRun("failed", true, now.Add(-time.Minute), map[string]string{ChainsAnnotation: "failed"}),
+		taskRun("pending", true, now.Add(-time.Minute), nil),
+		taskRun("retrying", true, now.Add(-2*time.Hour), map[string]string{RetryAnnotation: "1"}),
+		taskRun("no completion time", true, time.Time{}, nil),
+	}
+	b := &Backlog{
+		Kind: "backlogtest",
+		List: func(config.Config) ([]objects.TektonObject, error) { return runs, nil },
+	}
+	if err := b.Record(context.Background(), config.Config{}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	values := func(name string) map[string]float64 {
+		rows, err := view.RetrieveData(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]float64{}
+		for _, row := range rows {
+			var kind, value string
+			for _, tg := range row.Tags {
+				switch tg.Key.Name() {
+				case "kind":
+					kind = tg.Value
+				case "age", "retries_remaining":
+					value = tg.Value
+				}
+			}
+			if kind == b.Kind {
+				got[value] = row.Data.(*view.LastValueData).Value
+			}
+		}
+		return got
+	}
+	if got := values("unsigned_runs"); got["5m"] != 1 || got["24h"] != 1 || got["+Inf"] != 1 {
+		t.Errorf("unsigned runs by age = %v, want one under 5m, one under 24h and one older", got)
+	}
+	if got := values("unsigned_runs_retries_remaining"); got["4"] != 2 || got["2"] != 1 {
+		t.Errorf("unsigned runs by retries remaining = %v, want 2 with 4 and 1 with 2", got)
+	}
+	if got := values("failed_runs"); got[""] != 1 {
+		t.Errorf("failed runs = %v, want 1", got)
+	}
+	if got := values("oldest_unsigned_run_age_seconds"); got[""] != (48 * time.Hour).Seconds() {
+		t.Errorf("oldest unsigned run age = %v, want the age of the run without completion time", got)
+	}
+}
+
+func TestRetriesRemaining(t *testing.T) {
+	tests := []struct {
+		annotation string
+		want       int
+	}{
+		{want: 4},
+		{annotation: "0", want: 3},
+		{annotation: "2", want: 1},
+		{annotation: "3", want: 0},
+		{annotation: "invalid", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.annotation, func(t *testing.T) {
+			tr := &v1beta1.TaskRun{}
+			if tt.annotation != "" {
+				tr.Annotations = map[string]string{RetryAnnotation: tt.annotation}
+			}
+			if got := retriesRemaining(objects.NewTaskRunObject(tr), 3); got != tt.want {
+				t.Errorf("retriesRemaining() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
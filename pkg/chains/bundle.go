@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/chains/limits"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/runtypes"
+	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// PayloadTypeBundle is the payload format used when storing a PipelineRun bundle.
+	PayloadTypeBundle config.PayloadType = "bundle/jsonl"
+	bundleKeyPrefix                      = "bundle-"
+)
+
+// storeBundle aggregates the envelopes of a PipelineRun and its signed TaskRuns into a single
+// JSON Lines document and stores it in each of the configured bundle storage backends.
+// The PipelineRun envelopes come first, followed by the TaskRuns in the order they were appended.
+// TaskRuns aren't bundled when only PipelineRuns are signed or TaskRun artifacts are
+// disabled, and TaskRuns that aren't selected by cfg or weren't signed are skipped.
+func (o *ObjectSigner) storeBundle(ctx context.Context, pro *objects.PipelineRunObject, envelopes [][]byte, cfg config.Config) error {
+	logger := logging.FromContext(ctx)
+
+	if !runtypes.PipelineRunsOnly(ctx) && (&artifacts.TaskRunArtifact{}).Enabled(cfg) {
+		for _, tr := range pro.GetTaskRuns() {
+			tro := objects.NewTaskRunObject(tr)
+			if !cfg.Selects(tro) || !o.signedTaskRun(ctx, tro) {
+				logger.Debugf("Not bundling the envelopes of TaskRun %s/%s: it isn't signed", tro.GetNamespace(), tro.GetName())
+				continue
+			}
+			signatures, err := o.retrieveTaskRunSignatures(ctx, tro, cfg)
+			if err != nil {
+				return fmt.Errorf("collecting envelopes for TaskRun %s/%s: %w", tro.GetNamespace(), tro.GetName(), err)
+			}
+			if len(signatures) == 0 {
+				logger.Warnf("Not bundling the envelopes of TaskRun %s/%s: none found in %v", tro.GetNamespace(), tro.GetName(), sets.List[string]((&artifacts.TaskRunArtifact{}).StorageBackend(cfg)))
+			}
+			envelopes = append(envelopes, signatures...)
+		}
+	}
+
+	bundle := &bytes.Buffer{}
+	for _, e := range envelopes {
+		// Every envelope must occupy exactly one line.
+		if err := json.Compact(bundle, e); err != nil {
+			return fmt.Errorf("envelope is not valid JSON: %w", err)
+		}
+		bundle.WriteByte('\n')
+	}
+
+	pa := &artifacts.PipelineRunArtifact{}
+	storageOpts := config.StorageOpts{
+		ShortKey:      bundleKeyPrefix + pa.ShortKey(pro),
+		FullKey:       bundleKeyPrefix + pa.FullKey(pro),
+		PayloadFormat: PayloadTypeBundle,
+		BuildGroup:    pro.GetAnnotations()[attest.BuildGroupAnnotation],
+	}
+
+	var merr *multierror.Error
+	for _, backend := range sets.List[string](cfg.Artifacts.PipelineRuns.BundleStorageBackend) {
+		b, ok := o.Backends[backend]
+		if !ok {
+			merr = multierror.Append(merr, fmt.Errorf("bundle storage backend %q is not configured", backend))
+			continue
+		}
+		release, err := limits.AcquireBackend(ctx, backend)
+		if err != nil {
+			return err
+		}
+		err = storePayload(ctx, cfg, backend, func(ctx context.Context) error {
+			return b.StorePayload(ctx, pro, bundle.Bytes(), "", storageOpts)
+		})
+		release()
+		if err != nil {
+			logger.Error(err)
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		logger.Infof("Stored bundle of %d envelopes for %s %s/%s in %s", len(envelopes), pro.GetGVK(), pro.GetNamespace(), pro.GetName(), backend)
+	}
+	return merr.ErrorOrNil()
+}
+
+// signedTaskRun returns whether tro was signed, according to its annotations or, if they
+// are stale, to the ones of its latest version.
+func (o *ObjectSigner) signedTaskRun(ctx context.Context, tro *objects.TaskRunObject) bool {
+	if tro.GetAnnotations()[ChainsAnnotation] == "true" {
+		return true
+	}
+	if o.Pipelineclientset == nil {
+		return false
+	}
+	annotations, err := tro.GetLatestAnnotations(ctx, o.Pipelineclientset)
+	if err != nil {
+		logging.FromContext(ctx).Warnf("Ignoring error when fetching latest annotations: %s", err)
+		return false
+	}
+	return annotations[ChainsAnnotation] == "true"
+}
+
+// retrieveTaskRunSignatures returns the envelopes of the given TaskRun from the first configured
+// TaskRun storage backend able to retrieve them, or none if no backend has any.
+func (o *ObjectSigner) retrieveTaskRunSignatures(ctx context.Context, tro *objects.TaskRunObject, cfg config.Config) ([][]byte, error) {
+	ta := &artifacts.TaskRunArtifact{}
+	opts := config.StorageOpts{
+		ShortKey: ta.ShortKey(tro),
+		FullKey:  ta.FullKey(tro),
+	}
+
+	var merr *multierror.Error
+	for _, backend := range sets.List[string](ta.StorageBackend(cfg)) {
+		b, ok := o.Backends[backend]
+		if !ok {
+			continue
+		}
+		signatures, err := b.RetrieveSignatures(ctx, tro, opts)
+		if err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		var envelopes [][]byte
+		for _, ref := range sets.List[string](sets.KeySet(signatures)) {
+			for _, s := range signatures[ref] {
+				if s != "" {
+					envelopes = append(envelopes, []byte(s))
+				}
+			}
+		}
+		if len(envelopes) > 0 {
+			return envelopes, nil
+		}
+	}
+	return nil, merr.ErrorOrNil()
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package chaining links the provenance of a run to the attestations of the artifacts
+// it consumed, so that the provenance of a multi-stage supply chain can be followed
+// from one stage to the next.
+package chaining
+
+import (
+	"context"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"knative.dev/pkg/logging"
+)
+
+// Attestation references an attestation stored for an artifact.
+type Attestation struct {
+	// URI is where the attestation is stored.
+	URI string
+	// Digest is the digest of the attestation.
+	Digest map[string]string
+}
+
+// Finder looks up the attestations stored for an artifact. It is implemented by the
+// storage backends that store attestations alongside the artifacts they are about.
+type Finder interface {
+	// FindAttestations returns the attestations of the artifact with the given uri and
+	// digest, using the credentials of obj. It returns none for artifacts the Finder
+	// doesn't store attestations for.
+	FindAttestations(ctx context.Context, obj objects.TektonObject, uri string, digest map[string]string) ([]Attestation, error)
+}
+
+type findersKey struct{}
+
+// WithFinders returns a context carrying the Finders to look up attestations with.
+func WithFinders(ctx context.Context, finders ...Finder) context.Context {
+	return context.WithValue(ctx, findersKey{}, finders)
+}
+
+// Find returns the attestations of an artifact found with the Finders in ctx. Errors
+// are logged, so that a registry that is unavailable doesn't fail signing.
+func Find(ctx context.Context, obj objects.TektonObject, uri string, digest map[string]string) []Attestation {
+	finders, _ := ctx.Value(findersKey{}).([]Finder)
+	var found []Attestation
+	for _, f := range finders {
+		atts, err := f.FindAttestations(ctx, obj, uri, digest)
+		if err != nil {
+			logging.FromContext(ctx).Warnf("error looking up the attestations of %s: %v", uri, err)
+			continue
+		}
+		found = append(found, atts...)
+	}
+	return found
+}
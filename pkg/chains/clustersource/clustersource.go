@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clustersource describes Tasks and Pipelines fetched by the cluster resolver.
+// The RefSource URI the cluster resolver records is only meaningful inside the cluster
+// it was resolved in, so provenance is enriched with where the resource came from.
+package clustersource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ClusterAnnotation is the name of the cluster the resource was resolved in.
+	ClusterAnnotation = "cluster"
+	// NamespaceAnnotation is the namespace of the resource.
+	NamespaceAnnotation = "namespace"
+	// KindAnnotation is the kind of the resource, "task" or "pipeline".
+	KindAnnotation = "kind"
+	// NameAnnotation is the name of the resource.
+	NameAnnotation = "name"
+	// UIDAnnotation is the uid of the resource.
+	UIDAnnotation = "uid"
+	// ResourceVersionAnnotation is the resource version of the resource whose content
+	// matches the digest of the RefSource.
+	ResourceVersionAnnotation = "resourceVersion"
+)
+
+// Source is a Task or Pipeline fetched by the cluster resolver.
+type Source struct {
+	APIVersion string
+	Namespace  string
+	Kind       string
+	Name       string
+	UID        string
+}
+
+// Parse parses the RefSource URI recorded by the cluster resolver, which is of the form
+// /apis/<group>/<version>/namespaces/<namespace>/<kind>/<name>@<uid>.
+func Parse(uri string) (Source, bool) {
+	parts := strings.Split(uri, "/")
+	if len(parts) != 8 || parts[0] != "" || parts[1] != "apis" || parts[4] != "namespaces" {
+		return Source{}, false
+	}
+	name, uid, ok := strings.Cut(parts[7], "@")
+	if !ok || name == "" || uid == "" || parts[5] == "" {
+		return Source{}, false
+	}
+	switch parts[6] {
+	case "task", "pipeline":
+	default:
+		return Source{}, false
+	}
+	return Source{
+		APIVersion: parts[2] + "/" + parts[3],
+		Namespace:  parts[5],
+		Kind:       parts[6],
+		Name:       name,
+		UID:        uid,
+	}, true
+}
+
+// Resolver looks up the resource version of a Source.
+type Resolver interface {
+	// ResourceVersion returns the resource version of src, or "" if src no longer
+	// exists or its content no longer matches digest.
+	ResourceVersion(ctx context.Context, src Source, digest map[string]string) (string, error)
+}
+
+type resolverKey struct{}
+
+// WithResolver returns a context carrying r.
+func WithResolver(ctx context.Context, r Resolver) context.Context {
+	return context.WithValue(ctx, resolverKey{}, r)
+}
+
+// FromContext returns the Resolver carried by ctx, or nil.
+func FromContext(ctx context.Context) Resolver {
+	r, _ := ctx.Value(resolverKey{}).(Resolver)
+	return r
+}
+
+// ClientResolver looks up Sources with the Tekton clientset. Lookups are cached, so a
+// ClientResolver should only be used for a single object.
+type ClientResolver struct {
+	Client versioned.Interface
+
+	mu    sync.Mutex
+	cache map[Source]resolved
+}
+
+type resolved struct {
+	resourceVersion string
+	digest          string
+}
+
+// ResourceVersion implements Resolver.
+func (c *ClientResolver) ResourceVersion(ctx context.Context, src Source, digest map[string]string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.cache[src]
+	if !ok {
+		var err error
+		r, err = c.get(ctx, src)
+		if err != nil {
+			return "", err
+		}
+		if c.cache == nil {
+			c.cache = map[Source]resolved{}
+		}
+		c.cache[src] = r
+	}
+	if r.digest != digest["sha256"] {
+		return "", nil
+	}
+	return r.resourceVersion, nil
+}
+
+// get fetches src and computes the digest of its spec the way the cluster resolver does.
+func (c *ClientResolver) get(ctx context.Context, src Source) (resolved, error) {
+	var meta metav1.ObjectMeta
+	var spec interface{}
+	switch src.Kind {
+	case "task":
+		t, err := c.Client.TektonV1().Tasks(src.Namespace).Get(ctx, src.Name, metav1.GetOptions{})
+		if err != nil {
+			return resolved{}, err
+		}
+		meta, spec = t.ObjectMeta, t.Spec
+	case "pipeline":
+		p, err := c.Client.TektonV1().Pipelines(src.Namespace).Get(ctx, src.Name, metav1.GetOptions{})
+		if err != nil {
+			return resolved{}, err
+		}
+		meta, spec = p.ObjectMeta, p.Spec
+	default:
+		return resolved{}, fmt.Errorf("unsupported kind %q", src.Kind)
+	}
+	if string(meta.UID) != src.UID {
+		// The resource was recreated since it was resolved.
+		return resolved{}, nil
+	}
+	b, err := yaml.Marshal(spec)
+	if err != nil {
+		return resolved{}, err
+	}
+	h := sha256.Sum256(b)
+	return resolved{resourceVersion: meta.ResourceVersion, digest: hex.EncodeToString(h[:])}, nil
+}
+
+// Annotations describes the resource a RefSource with the given uri and digest was
+// resolved from, for recording in provenance. It returns nil if uri was not recorded by
+// the cluster resolver. The resource version is only included if the resource can
+// still be looked up with the Resolver in ctx and is unchanged.
+func Annotations(ctx context.Context, cluster, uri string, digest map[string]string) map[string]interface{} {
+	src, ok := Parse(uri)
+	if !ok {
+		return nil
+	}
+	annotations := map[string]interface{}{
+		NamespaceAnnotation: src.Namespace,
+		KindAnnotation:      src.Kind,
+		NameAnnotation:      src.Name,
+		UIDAnnotation:       src.UID,
+	}
+	if cluster != "" {
+		annotations[ClusterAnnotation] = cluster
+	}
+	if r := FromContext(ctx); r != nil {
+		rv, err := r.ResourceVersion(ctx, src, digest)
+		if err != nil {
+			logging.FromContext(ctx).Warnf("error looking up the resource version of %s: %v", uri, err)
+		} else if rv != "" {
+			annotations[ResourceVersionAnnotation] = rv
+		}
+	}
+	return annotations
+}
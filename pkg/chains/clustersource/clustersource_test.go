@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustersource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	fakepipeline "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+	"sigs.k8s.io/yaml"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want Source
+		ok   bool
+	}{{
+		uri:  "/apis/tekton.dev/v1/namespaces/tasks/task/build@1234",
+		want: Source{APIVersion: "tekton.dev/v1", Namespace: "tasks", Kind: "task", Name: "build", UID: "1234"},
+		ok:   true,
+	}, {
+		uri:  "/apis/tekton.dev/v1/namespaces/default/pipeline/release@abcd",
+		want: Source{APIVersion: "tekton.dev/v1", Namespace: "default", Kind: "pipeline", Name: "release", UID: "abcd"},
+		ok:   true,
+	}, {
+		uri: "git+https://github.com/tektoncd/catalog.git",
+	}, {
+		uri: "/apis/tekton.dev/v1/namespaces/tasks/task/build",
+	}, {
+		uri: "/apis/tekton.dev/v1/namespaces/tasks/stepaction/build@1234",
+	}, {
+		uri: "/apis/tekton.dev/v1/tasks/task/build@1234",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, ok := Parse(tt.uri)
+			if ok != tt.ok {
+				t.Fatalf("Parse() ok = %v, want %v", ok, tt.ok)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Parse() -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	task := &pipelinev1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "tasks", UID: "1234", ResourceVersion: "42"},
+		Spec:       pipelinev1.TaskSpec{Steps: []pipelinev1.Step{{Name: "build", Image: "golang"}}},
+	}
+	b, err := yaml.Marshal(task.Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.Sum256(b)
+	digest := map[string]string{"sha256": hex.EncodeToString(h[:])}
+	ctx = WithResolver(ctx, &ClientResolver{Client: fakepipeline.NewSimpleClientset(task)})
+
+	tests := []struct {
+		name   string
+		uri    string
+		digest map[string]string
+		want   map[string]interface{}
+	}{{
+		name:   "unchanged",
+		uri:    "/apis/tekton.dev/v1/namespaces/tasks/task/build@1234",
+		digest: digest,
+		want: map[string]interface{}{
+			"cluster":         "prod-east",
+			"namespace":       "tasks",
+			"kind":            "task",
+			"name":            "build",
+			"uid":             "1234",
+			"resourceVersion": "42",
+		},
+	}, {
+		name:   "changed since it was resolved",
+		uri:    "/apis/tekton.dev/v1/namespaces/tasks/task/build@1234",
+		digest: map[string]string{"sha256": "0000"},
+		want: map[string]interface{}{
+			"cluster":   "prod-east",
+			"namespace": "tasks",
+			"kind":      "task",
+			"name":      "build",
+			"uid":       "1234",
+		},
+	}, {
+		name:   "recreated since it was resolved",
+		uri:    "/apis/tekton.dev/v1/namespaces/tasks/task/build@5678",
+		digest: digest,
+		want: map[string]interface{}{
+			"cluster":   "prod-east",
+			"namespace": "tasks",
+			"kind":      "task",
+			"name":      "build",
+			"uid":       "5678",
+		},
+	}, {
+		name:   "deleted",
+		uri:    "/apis/tekton.dev/v1/namespaces/tasks/pipeline/release@1234",
+		digest: digest,
+		want: map[string]interface{}{
+			"cluster":   "prod-east",
+			"namespace": "tasks",
+			"kind":      "pipeline",
+			"name":      "release",
+			"uid":       "1234",
+		},
+	}, {
+		name:   "not from the cluster resolver",
+		uri:    "git+https://github.com/tektoncd/catalog.git",
+		digest: map[string]string{"sha1": "04b1a67ab2ec1ee7e2df1f1b4f4b7ad6ced2c0c6"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Annotations(ctx, "prod-east", tt.uri, tt.digest)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Annotations() -want +got: %s", diff)
+			}
+		})
+	}
+}
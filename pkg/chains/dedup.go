@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
+	"knative.dev/pkg/leaderelection"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// defaultLeaseDuration is how long a signing Lease is held when the config doesn't say.
+	defaultLeaseDuration = time.Minute
+	// inProgressRequeueDelay is how long a run that is being signed elsewhere is requeued for.
+	inProgressRequeueDelay = 5 * time.Second
+	// namespaceRequeueDelay is how long a run whose namespace is at its limit of runs
+	// signed at once is first requeued for. The delay doubles each time it is requeued
+	// again, up to namespaceRequeueMaxDelay.
+	namespaceRequeueDelay    = time.Second
+	namespaceRequeueMaxDelay = time.Minute
+)
+
+// namespaceBackoff is the delay of the runs requeued because their namespace is at its
+// limit, by their UIDs, so that a busy namespace doesn't spin the workers.
+var namespaceBackoff = workqueue.NewItemExponentialFailureRateLimiter(namespaceRequeueDelay, namespaceRequeueMaxDelay)
+
+// ErrSigningInProgress is returned when a run is already being signed by another
+// reconcile, in this or another controller replica.
+var ErrSigningInProgress = errors.New("run is already being signed")
+
+var (
+	inFlightMu sync.Mutex
+	inFlight   = map[types.UID]struct{}{}
+
+	leaseHolderOnce sync.Once
+	leaseHolder     string
+)
+
+// acquireInFlight marks the run with the given UID as being signed by this process.
+// It returns false if it already is; otherwise the returned function must be called
+// once signing finished.
+func acquireInFlight(uid types.UID) (func(), bool) {
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	if _, ok := inFlight[uid]; ok {
+		return nil, false
+	}
+	inFlight[uid] = struct{}{}
+	return func() {
+		inFlightMu.Lock()
+		defer inFlightMu.Unlock()
+		delete(inFlight, uid)
+	}, true
+}
+
+// holderIdentity identifies this process as the holder of signing Leases.
+func holderIdentity() string {
+	leaseHolderOnce.Do(func() {
+		id, err := leaderelection.UniqueID()
+		if err != nil {
+			id = fmt.Sprintf("chains-%d", time.Now().UnixNano())
+		}
+		leaseHolder = id
+	})
+	return leaseHolder
+}
+
+// leaseName is the name of the Lease held while signing the run with the given UID.
+func leaseName(uid types.UID) string {
+	return "chains-signing-" + string(uid)
+}
+
+// acquireLease takes the Lease on obj in namespace for holder. It returns
+// ErrSigningInProgress if another holder has a Lease that didn't expire yet. Otherwise
+// the returned function must be called once signing finished to delete the Lease.
+func acquireLease(ctx context.Context, client kubernetes.Interface, namespace, holder string, cfg config.LeaseConfig, obj objects.TektonObject) (func(), error) {
+	duration := cfg.Duration
+	if duration <= 0 {
+		duration = defaultLeaseDuration
+	}
+	seconds := int32(duration.Seconds())
+	now := metav1.NewMicroTime(time.Now())
+	spec := coordinationv1.LeaseSpec{
+		HolderIdentity:       &holder,
+		LeaseDurationSeconds: &seconds,
+		AcquireTime:          &now,
+		RenewTime:            &now,
+	}
+
+	leases := client.CoordinationV1().Leases(namespace)
+	name := leaseName(obj.GetUID())
+	lease, err := leases.Create(ctx, &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       spec,
+	}, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		lease, err = takeOverLease(ctx, client, namespace, name, holder, spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Takeovers update the Lease, keeping its UID, so it is only deleted while it is
+	// at the version last written by this holder.
+	uid, resourceVersion := lease.UID, lease.ResourceVersion
+	return func() {
+		current, err := leases.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return
+		}
+		if err == nil && (heldBy(current) != holder || current.ResourceVersion != resourceVersion) {
+			logging.FromContext(ctx).Warnf("signing lease %s/%s was taken over by %s, not deleting it", namespace, name, heldBy(current))
+			return
+		}
+		err = leases.Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion}})
+		if err != nil && !apierrors.IsNotFound(err) {
+			logging.FromContext(ctx).Warnf("error deleting signing lease %s/%s: %v", namespace, name, err)
+		}
+	}, nil
+}
+
+// takeOverLease takes over the existing Lease with the given name if it expired.
+func takeOverLease(ctx context.Context, client kubernetes.Interface, namespace, name, holder string, spec coordinationv1.LeaseSpec) (*coordinationv1.Lease, error) {
+	leases := client.CoordinationV1().Leases(namespace)
+	existing, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if heldBy(existing) != holder && !leaseExpired(existing, time.Now()) {
+		return nil, fmt.Errorf("%w by %s", ErrSigningInProgress, heldBy(existing))
+	}
+	existing.Spec = spec
+	lease, err := leases.Update(ctx, existing, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		// Another holder took the Lease over first.
+		return nil, ErrSigningInProgress
+	}
+	return lease, err
+}
+
+func heldBy(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+// handledSince returns whether another reconcile signed, marked as failed or retried obj
+// since it was read. It fetches the latest version of obj from the cluster, like Reconciled.
+func handledSince(ctx context.Context, client versioned.Interface, obj objects.TektonObject) bool {
+	latest, err := obj.GetLatestAnnotations(ctx, client)
+	if err != nil {
+		logging.FromContext(ctx).Warnf("Ignoring error when fetching latest annotations: %s", err)
+		return false
+	}
+	cached := obj.GetAnnotations()
+	for _, key := range []string{ChainsAnnotation, RetryAnnotation} {
+		if latest[key] != cached[key] {
+			return true
+		}
+	}
+	return false
+}
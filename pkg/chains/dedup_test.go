@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/limits"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"knative.dev/pkg/controller"
+	logtesting "knative.dev/pkg/logging/testing"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestAcquireInFlight(t *testing.T) {
+	release, ok := acquireInFlight("uid")
+	if !ok {
+		t.Fatal("expected to acquire run")
+	}
+	if _, ok := acquireInFlight("uid"); ok {
+		t.Error("expected run that is being signed not to be acquired")
+	}
+	other, ok := acquireInFlight("other")
+	if !ok {
+		t.Error("expected other run to be acquired")
+	}
+	other()
+	release()
+	if release, ok := acquireInFlight("uid"); !ok {
+		t.Error("expected released run to be acquired")
+	} else {
+		release()
+	}
+}
+
+func TestAcquireLease(t *testing.T) {
+	const namespace = "tekton-chains"
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: types.UID("uid")},
+	})
+	heldLease := func(holder string, renewed time.Time) *coordinationv1.Lease {
+		seconds := int32(60)
+		renewTime := metav1.NewMicroTime(renewed)
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: leaseName("uid"), Namespace: namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &seconds,
+				RenewTime:            &renewTime,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing *coordinationv1.Lease
+		wantErr  error
+	}{
+		{
+			name: "no lease",
+		},
+		{
+			name:     "held by other replica",
+			existing: heldLease("other", time.Now()),
+			wantErr:  ErrSigningInProgress,
+		},
+		{
+			name:     "expired",
+			existing: heldLease("other", time.Now().Add(-2*time.Minute)),
+		},
+		{
+			name:     "held by this replica",
+			existing: heldLease("me", time.Now()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logtesting.TestContextWithLogger(t)
+			client := fakek8s.NewSimpleClientset()
+			if tt.existing != nil {
+				client = fakek8s.NewSimpleClientset(tt.existing)
+			}
+
+			release, err := acquireLease(ctx, client, namespace, "me", config.LeaseConfig{}, obj)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("acquireLease() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			lease, err := client.CoordinationV1().Leases(namespace).Get(ctx, leaseName("uid"), metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if heldBy(lease) != "me" {
+				t.Errorf("lease held by %q, want me", heldBy(lease))
+			}
+
+			release()
+			if _, err := client.CoordinationV1().Leases(namespace).Get(ctx, leaseName("uid"), metav1.GetOptions{}); err == nil {
+				t.Error("expected lease to be deleted once released")
+			}
+		})
+	}
+}
+
+func TestAcquireLease_TakenOver(t *testing.T) {
+	const namespace = "tekton-chains"
+	ctx := logtesting.TestContextWithLogger(t)
+	client := fakek8s.NewSimpleClientset()
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: types.UID("uid")},
+	})
+
+	release, err := acquireLease(ctx, client, namespace, "me", config.LeaseConfig{}, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The Lease expires while this replica is still signing, and another replica takes
+	// it over.
+	leases := client.CoordinationV1().Leases(namespace)
+	lease, err := leases.Get(ctx, leaseName("uid"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := metav1.NewMicroTime(time.Now().Add(-time.Hour))
+	lease.Spec.RenewTime = &expired
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acquireLease(ctx, client, namespace, "other", config.LeaseConfig{}, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	release()
+	lease, err = leases.Get(ctx, leaseName("uid"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the Lease of the new holder to be kept: %v", err)
+	}
+	if heldBy(lease) != "other" {
+		t.Errorf("lease held by %q, want other", heldBy(lease))
+	}
+}
+
+func TestSigner_InProgress(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, &config.Config{})
+
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: types.UID("in-progress")},
+	})
+	tekton.CreateObject(t, ctx, ps, obj)
+
+	release, ok := acquireInFlight(obj.GetUID())
+	if !ok {
+		t.Fatal("expected to acquire run")
+	}
+	defer release()
+
+	signer := &ObjectSigner{Pipelineclientset: ps}
+	err := signer.Sign(ctx, obj)
+	if ok, _ := controller.IsRequeueKey(err); !ok {
+		t.Errorf("Sign() error = %v, want the run to be requeued", err)
+	}
+}
+
+func TestSigner_NamespaceLimit(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, &config.Config{})
+	limits.Setup(config.ConcurrencyConfig{NamespaceLimit: 1})
+	defer limits.Setup(config.ConcurrencyConfig{})
+
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "busy", UID: types.UID("busy")},
+	})
+	tekton.CreateObject(t, ctx, ps, obj)
+
+	// Another run of the namespace is being signed.
+	release, ok := limits.TryAcquireNamespace("busy")
+	if !ok {
+		t.Fatal("expected to acquire namespace")
+	}
+
+	signer := &ObjectSigner{Pipelineclientset: ps}
+	for _, want := range []time.Duration{namespaceRequeueDelay, 2 * namespaceRequeueDelay, 4 * namespaceRequeueDelay} {
+		err := signer.Sign(ctx, obj)
+		if ok, delay := controller.IsRequeueKey(err); !ok || delay != want {
+			t.Errorf("Sign() error = %v, want the run to be requeued for %s", err, want)
+		}
+	}
+	if namespaceBackoff.NumRequeues(obj.GetUID()) != 3 {
+		t.Errorf("got %d requeues, want 3", namespaceBackoff.NumRequeues(obj.GetUID()))
+	}
+
+	// The backoff starts over once the run is signed.
+	release()
+	_ = signer.Sign(ctx, obj)
+	if n := namespaceBackoff.NumRequeues(obj.GetUID()); n != 0 {
+		t.Errorf("got %d requeues after the run was signed, want 0", n)
+	}
+}
+
+func TestHandledSince(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+	})
+	tekton.CreateObject(t, ctx, ps, obj)
+	if handledSince(ctx, ps, obj) {
+		t.Error("expected unchanged run not to be handled")
+	}
+
+	// A concurrent reconcile signs the run while obj is stale.
+	stale := objects.NewTaskRunObject(obj.DeepCopy())
+	if err := MarkSigned(ctx, obj, ps, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !handledSince(ctx, ps, stale) {
+		t.Error("expected run signed since it was read to be handled")
+	}
+}
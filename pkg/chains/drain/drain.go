@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package drain lets runs that are being signed when the controller shuts down
+// finish, while runs that weren't started yet are left to the next controller.
+package drain
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTimeout is how long runs in flight may take to finish after the controller
+// started shutting down, when the config doesn't say. It is shorter than the default
+// termination grace period of a Pod.
+const DefaultTimeout = 25 * time.Second
+
+// Drainer tracks whether the controller is shutting down. A nil Drainer never drains.
+type Drainer struct {
+	done <-chan struct{}
+}
+
+// New returns a Drainer that drains once ctx, the context the controller runs with,
+// is done.
+func New(ctx context.Context) *Drainer {
+	return &Drainer{done: ctx.Done()}
+}
+
+// Draining returns whether the controller is shutting down, in which case no new run
+// should be signed.
+func (d *Drainer) Draining() bool {
+	if d == nil {
+		return false
+	}
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Context returns a copy of ctx that is cancelled timeout after the controller started
+// shutting down, so that signing and storing a run in flight can finish but can't hold
+// up the shutdown indefinitely. DefaultTimeout is used if timeout is not positive. The
+// returned function must be called once the run was handled.
+func (d *Drainer) Context(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if d == nil {
+		return ctx, cancel
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.done:
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+			cancel()
+		}
+	}()
+	return ctx, cancel
+}
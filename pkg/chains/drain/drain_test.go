@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drain
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDrainer(t *testing.T) {
+	controllerCtx, shutdown := context.WithCancel(context.Background())
+	d := New(controllerCtx)
+	if d.Draining() {
+		t.Fatal("expected running controller not to drain")
+	}
+
+	ctx, cancel := d.Context(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	shutdown()
+	if !d.Draining() {
+		t.Error("expected controller to drain once shut down")
+	}
+	if ctx.Err() != nil {
+		t.Error("expected run in flight not to be cancelled right away")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("expected run in flight to be cancelled after the drain timeout")
+	}
+}
+
+func TestDrainer_Nil(t *testing.T) {
+	var d *Drainer
+	if d.Draining() {
+		t.Error("expected nil Drainer not to drain")
+	}
+	ctx, cancel := d.Context(context.Background(), time.Millisecond)
+	cancel()
+	if ctx.Err() == nil {
+		t.Error("expected context to be cancelled by its cancel function")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+)
+
+// DryRunAnnotation records what Chains would have done for a run handled in dry-run mode.
+const DryRunAnnotation = "chains.tekton.dev/dry-run"
+
+// DryRunRecord describes what Chains would have done for a single signable object of a run.
+type DryRunRecord struct {
+	Type         string   `json:"type"`
+	Key          string   `json:"key"`
+	Format       string   `json:"format"`
+	Signer       string   `json:"signer"`
+	Backends     []string `json:"backends"`
+	Transparency bool     `json:"transparency"`
+	// Payload is the path the unsigned payload was written to, if any.
+	Payload string `json:"payload,omitempty"`
+	// Truncated lists what was dropped from the payload to fit in the maximum
+	// attestation size, if anything.
+	Truncated []string `json:"truncated,omitempty"`
+	// Error is set if the payload could not be generated.
+	Error string `json:"error,omitempty"`
+}
+
+// dryRun generates the payloads for tektonObj without signing or storing them. The
+// payloads are written to the configured scratch directory, and what would have been
+// done is logged and recorded in the DryRunAnnotation. The object is not marked as
+// signed, so it is signed once dry-run mode is turned off.
+func (o *ObjectSigner) dryRun(ctx context.Context, tektonObj objects.TektonObject, signableTypes []artifacts.Signable, cfg config.Config, event *audit.Event) error {
+	logger := logging.FromContext(ctx)
+	if _, ok := tektonObj.GetAnnotations()[DryRunAnnotation]; ok {
+		event.Decision, event.Reason = audit.DecisionSkipped, "already handled in dry-run mode"
+		return nil
+	}
+
+	records := []DryRunRecord{}
+	for _, signableType := range signableTypes {
+		if !signableType.Enabled(cfg) {
+			continue
+		}
+		payloadFormat := signableType.PayloadFormat(cfg)
+		payloader, err := formats.GetPayloader(payloadFormat, cfg)
+		if err != nil {
+			logger.Warnf("Format %s configured for %s: %v was not found", payloadFormat, tektonObj.GetGVK(), signableType.Type())
+			continue
+		}
+
+		for _, obj := range signableType.ExtractObjects(ctx, payloadObject(tektonObj, cfg.Provenance)) {
+			record := DryRunRecord{
+				Type:         signableType.Type(),
+				Key:          signableType.ShortKey(obj),
+				Format:       string(payloadFormat),
+				Signer:       signableType.Signer(cfg),
+				Backends:     sets.List[string](signableType.StorageBackend(cfg)),
+				Transparency: shouldUploadTlog(cfg, tektonObj),
+			}
+			artifact := &audit.Artifact{Type: record.Type, Key: record.Key, Format: record.Format, Signer: record.Signer}
+			var raw []byte
+			payload, err := payloader.CreatePayload(ctx, obj)
+			if err == nil {
+				raw, err = json.Marshal(payload)
+			}
+			if err == nil {
+				raw, record.Truncated, err = truncate(raw, cfg.Provenance.MaxAttestationKB)
+				artifact.Truncated = record.Truncated
+			}
+			if err == nil {
+				artifact.Subjects = audit.Subjects(raw)
+				record.Payload, err = writeDryRunPayload(cfg.DryRun.Directory, tektonObj, record.Key, raw)
+			}
+			if err != nil {
+				record.Error = err.Error()
+				artifact.Error = record.Error
+			}
+			event.Artifacts = append(event.Artifacts, artifact)
+			logger.Infof("Dry run: would sign %s %s of %s %s/%s with %s and store it in %v (transparency: %t)",
+				record.Format, record.Key, tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName(), record.Signer, record.Backends, record.Transparency)
+			records = append(records, record)
+		}
+	}
+
+	raw, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return AddAnnotation(ctx, tektonObj, o.Pipelineclientset, DryRunAnnotation, string(raw), nil)
+}
+
+// writeDryRunPayload writes the JSON-encoded payload, indented, to
+// <dir>/<namespace>/<name>/<key>.json and returns its path. Nothing is written when
+// dir is empty.
+func writeDryRunPayload(dir string, obj objects.TektonObject, key string, payload []byte) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	var indented bytes.Buffer
+	indented.Grow(len(payload))
+	if err := json.Indent(&indented, payload, "", "  "); err != nil {
+		return "", err
+	}
+	objDir := filepath.Join(dir, obj.GetNamespace(), obj.GetName())
+	if err := os.MkdirAll(objDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(objDir, filepath.Base(key)+".json")
+	if err := os.WriteFile(path, indented.Bytes(), 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestSigner_DryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespace  string
+		dryRun     config.DryRunConfig
+		wantDryRun bool
+	}{
+		{
+			name:       "cluster-wide",
+			namespace:  "default",
+			dryRun:     config.DryRunConfig{Enabled: true},
+			wantDryRun: true,
+		},
+		{
+			name:       "namespace",
+			namespace:  "team-a",
+			dryRun:     config.DryRunConfig{Namespaces: sets.New[string]("team-a")},
+			wantDryRun: true,
+		},
+		{
+			name:      "other namespace",
+			namespace: "team-b",
+			dryRun:    config.DryRunConfig{Namespaces: sets.New[string]("team-a")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			dir := t.TempDir()
+			tt.dryRun.Directory = dir
+			ctx = config.ToContext(ctx, &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         "in-toto",
+						StorageBackend: sets.New[string]("mock"),
+						Signer:         "x509",
+					},
+				},
+				DryRun: tt.dryRun,
+			})
+
+			tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: tt.namespace, UID: "uid"},
+			})
+			tekton.CreateObject(t, ctx, ps, tro)
+			backend := &mockBackend{backendType: "mock"}
+			signer := &ObjectSigner{
+				Backends:          fakeAllBackends([]*mockBackend{backend}),
+				SecretPath:        "./signing/x509/testdata/",
+				Pipelineclientset: ps,
+			}
+			if err := signer.Sign(ctx, tro); err != nil {
+				t.Fatalf("Signer.Sign() error = %v", err)
+			}
+
+			updated, err := tekton.GetObject(t, ctx, ps, tro)
+			if err != nil {
+				t.Fatal(err)
+			}
+			annotations := updated.GetAnnotations()
+			if !tt.wantDryRun {
+				if _, ok := annotations[DryRunAnnotation]; ok {
+					t.Error("unexpected dry-run annotation")
+				}
+				if backend.storedPayload == nil {
+					t.Error("expected payload to be stored")
+				}
+				return
+			}
+
+			if backend.storedPayload != nil {
+				t.Error("expected no payload to be stored in dry-run mode")
+			}
+			if _, ok := annotations[ChainsAnnotation]; ok {
+				t.Error("expected object not to be marked as signed in dry-run mode")
+			}
+			var records []DryRunRecord
+			if err := json.Unmarshal([]byte(annotations[DryRunAnnotation]), &records); err != nil {
+				t.Fatalf("error parsing dry-run annotation: %v", err)
+			}
+			want := []DryRunRecord{{
+				Type:     "tekton",
+				Key:      "taskrun-uid",
+				Format:   "in-toto",
+				Signer:   "x509",
+				Backends: []string{"mock"},
+				Payload:  dir + "/" + tt.namespace + "/foo/taskrun-uid.json",
+			}}
+			if diff := cmp.Diff(want, records); diff != "" {
+				t.Errorf("dry-run records (-want +got): %s", diff)
+			}
+			if _, err := os.Stat(records[0].Payload); err != nil {
+				t.Errorf("expected payload to be written: %v", err)
+			}
+		})
+	}
+}
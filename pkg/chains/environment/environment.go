@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package environment describes the cluster and nodes the pods of a run executed on,
+// to help reproduce builds and scope incident response.
+package environment
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/logging"
+)
+
+// Environment is the cluster and nodes the pods of a run executed on.
+type Environment struct {
+	// KubernetesVersion is the version of the Kubernetes API server.
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// Nodes are the nodes the pods executed on, in the order of their names.
+	Nodes []Node `json:"nodes,omitempty"`
+}
+
+// Node is a node pods executed on.
+type Node struct {
+	Name             string `json:"name"`
+	OperatingSystem  string `json:"operatingSystem,omitempty"`
+	Architecture     string `json:"architecture,omitempty"`
+	OSImage          string `json:"osImage,omitempty"`
+	KernelVersion    string `json:"kernelVersion,omitempty"`
+	ContainerRuntime string `json:"containerRuntime,omitempty"`
+	KubeletVersion   string `json:"kubeletVersion,omitempty"`
+}
+
+// Collector looks up the environment pods executed in.
+type Collector interface {
+	// KubernetesVersion returns the version of the Kubernetes API server.
+	KubernetesVersion(ctx context.Context) (string, error)
+	// Node returns the node the pod with the given namespace and name executed on.
+	Node(ctx context.Context, namespace, pod string) (Node, error)
+}
+
+type collectorKey struct{}
+
+// WithCollector returns a context carrying c.
+func WithCollector(ctx context.Context, c Collector) context.Context {
+	return context.WithValue(ctx, collectorKey{}, c)
+}
+
+// FromContext returns the Collector carried by ctx, or nil.
+func FromContext(ctx context.Context) Collector {
+	c, _ := ctx.Value(collectorKey{}).(Collector)
+	return c
+}
+
+// ClientCollector looks up the environment with the Kubernetes clientset. Lookups are
+// cached, so a ClientCollector should only be used for a single object.
+type ClientCollector struct {
+	Client kubernetes.Interface
+
+	mu      sync.Mutex
+	version string
+	nodes   map[string]Node
+}
+
+// KubernetesVersion implements Collector.
+func (c *ClientCollector) KubernetesVersion(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == "" {
+		v, err := c.Client.Discovery().ServerVersion()
+		if err != nil {
+			return "", err
+		}
+		c.version = v.GitVersion
+	}
+	return c.version, nil
+}
+
+// Node implements Collector.
+func (c *ClientCollector) Node(ctx context.Context, namespace, pod string) (Node, error) {
+	p, err := c.Client.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		return Node{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n, ok := c.nodes[p.Spec.NodeName]; ok {
+		return n, nil
+	}
+	node, err := c.Client.CoreV1().Nodes().Get(ctx, p.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return Node{}, err
+	}
+	info := node.Status.NodeInfo
+	n := Node{
+		Name:             node.Name,
+		OperatingSystem:  info.OperatingSystem,
+		Architecture:     info.Architecture,
+		OSImage:          info.OSImage,
+		KernelVersion:    info.KernelVersion,
+		ContainerRuntime: info.ContainerRuntimeVersion,
+		KubeletVersion:   info.KubeletVersion,
+	}
+	if c.nodes == nil {
+		c.nodes = map[string]Node{}
+	}
+	c.nodes[n.Name] = n
+	return n, nil
+}
+
+// Collect returns the environment the pods of the given taskruns executed in, looked up
+// with the Collector in ctx. It returns nil if ctx doesn't carry a Collector. Errors are
+// logged, since the pods of a run may have been deleted by the time it is signed.
+func Collect(ctx context.Context, trs ...*v1beta1.TaskRun) *Environment {
+	c := FromContext(ctx)
+	if c == nil {
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+	env := &Environment{}
+	v, err := c.KubernetesVersion(ctx)
+	if err != nil {
+		logger.Warnf("error looking up the Kubernetes version: %v", err)
+	}
+	env.KubernetesVersion = v
+
+	seen := map[string]bool{}
+	for _, tr := range trs {
+		if tr.Status.PodName == "" {
+			continue
+		}
+		n, err := c.Node(ctx, tr.Namespace, tr.Status.PodName)
+		if err != nil {
+			logger.Warnf("error looking up the node of pod %s/%s: %v", tr.Namespace, tr.Status.PodName, err)
+			continue
+		}
+		if seen[n.Name] {
+			continue
+		}
+		seen[n.Name] = true
+		env.Nodes = append(env.Nodes, n)
+	}
+	sort.Slice(env.Nodes, func(i, j int) bool {
+		return env.Nodes[i].Name < env.Nodes[j].Name
+	})
+	return env
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package environment
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func node(name, arch string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{
+				OperatingSystem:         "linux",
+				Architecture:            arch,
+				OSImage:                 "Ubuntu 22.04.2 LTS",
+				KernelVersion:           "5.15.0-1041",
+				ContainerRuntimeVersion: "containerd://1.7.2",
+				KubeletVersion:          "v1.27.3",
+			},
+		},
+	}
+}
+
+func pod(name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func taskRun(podName string) *v1beta1.TaskRun {
+	return &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: "default"},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{PodName: podName},
+		},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	if got := Collect(ctx, taskRun("build-pod")); got != nil {
+		t.Fatalf("Collect() = %v without a Collector, want nil", got)
+	}
+
+	client := fakekube.NewSimpleClientset(
+		node("node-b", "arm64"), node("node-a", "amd64"),
+		pod("build-pod", "node-b"), pod("test-pod", "node-a"), pod("push-pod", "node-b"),
+	)
+	client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.27.3"}
+	ctx = WithCollector(ctx, &ClientCollector{Client: client})
+
+	got := Collect(ctx, taskRun("build-pod"), taskRun("test-pod"), taskRun("push-pod"), taskRun("deleted-pod"), taskRun(""))
+	want := &Environment{
+		KubernetesVersion: "v1.27.3",
+		Nodes: []Node{{
+			Name:             "node-a",
+			OperatingSystem:  "linux",
+			Architecture:     "amd64",
+			OSImage:          "Ubuntu 22.04.2 LTS",
+			KernelVersion:    "5.15.0-1041",
+			ContainerRuntime: "containerd://1.7.2",
+			KubeletVersion:   "v1.27.3",
+		}, {
+			Name:             "node-b",
+			OperatingSystem:  "linux",
+			Architecture:     "arm64",
+			OSImage:          "Ubuntu 22.04.2 LTS",
+			KernelVersion:    "5.15.0-1041",
+			ContainerRuntime: "containerd://1.7.2",
+			KubeletVersion:   "v1.27.3",
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Collect() -want +got: %s", diff)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events emits CloudEvents when attestations are created or stored, when a
+// payload is denied by policy, and when signing a run fails permanently, so other
+// systems can react without polling.
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/config"
+	"knative.dev/pkg/logging"
+)
+
+// Types of the events that are emitted.
+const (
+	// TypeAttestationCreated is emitted for every payload that was signed.
+	TypeAttestationCreated = "dev.tekton.chains.attestation.created.v1"
+	// TypeAttestationStored is emitted for every signed payload that was stored in all
+	// of its storage backends.
+	TypeAttestationStored = "dev.tekton.chains.attestation.stored.v1"
+	// TypeRunFailed is emitted when a run is marked as failed and won't be retried.
+	TypeRunFailed = "dev.tekton.chains.run.failed.v1"
+	// TypePolicyDenied is emitted for every payload that wasn't signed because it
+	// violates the policies.
+	TypePolicyDenied = "dev.tekton.chains.policy.denied.v1"
+)
+
+// sendTimeout bounds how long sending a single event may hold up signing.
+const sendTimeout = 10 * time.Second
+
+// Data is the payload of the events.
+type Data struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UID       string `json:"uid,omitempty"`
+	// Artifact is the attestation the event is about. It is not set for failed runs.
+	Artifact *audit.Artifact `json:"artifact,omitempty"`
+	// Reason is why signing the run failed.
+	Reason string `json:"reason,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	current config.EventsConfig
+	client  cloudevents.Client
+)
+
+// Setup configures the sink events are sent to according to cfg. It is safe to call
+// on every config update: the client is only recreated when the config changed.
+func Setup(cfg config.EventsConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cfg == current {
+		return nil
+	}
+	current, client = cfg, nil
+	if cfg.Sink == "" {
+		return nil
+	}
+	c, err := cloudevents.NewClientHTTP(cloudevents.WithTarget(cfg.Sink))
+	if err != nil {
+		current = config.EventsConfig{}
+		return fmt.Errorf("creating CloudEvents client for %s: %w", cfg.Sink, err)
+	}
+	client = c
+	return nil
+}
+
+// Emit sends the events for the outcome of signing a run recorded in ev, if a sink is
+// configured. failed is whether the run was marked as failed and won't be retried.
+// Errors are logged, as they must not fail signing.
+func Emit(ctx context.Context, ev audit.Event, failed bool) {
+	mu.Lock()
+	c := client
+	mu.Unlock()
+	if c == nil {
+		return
+	}
+
+	base := Data{Kind: ev.Kind, Namespace: ev.Namespace, Name: ev.Name, UID: ev.UID}
+	for _, a := range ev.Artifacts {
+		if len(a.Denied) > 0 {
+			data := base
+			data.Artifact = a
+			send(ctx, c, TypePolicyDenied, a.Key, data)
+		}
+	}
+	if failed {
+		data := base
+		data.Reason = ev.Reason
+		send(ctx, c, TypeRunFailed, "", data)
+		return
+	}
+	if ev.Decision != audit.DecisionSigned {
+		return
+	}
+	for _, a := range ev.Artifacts {
+		if len(a.Signatures) == 0 {
+			continue
+		}
+		data := base
+		data.Artifact = a
+		send(ctx, c, TypeAttestationCreated, a.Key, data)
+		if a.Error == "" && len(a.Backends) > 0 {
+			send(ctx, c, TypeAttestationStored, a.Key, data)
+		}
+	}
+}
+
+func send(ctx context.Context, c cloudevents.Client, eventType, subject string, data Data) {
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.NewString())
+	event.SetType(eventType)
+	event.SetSource(fmt.Sprintf("/apis/tekton.dev/v1beta1/namespaces/%s/%ss/%s", data.Namespace, data.Kind, data.Name))
+	if subject != "" {
+		event.SetSubject(subject)
+	}
+	event.SetTime(time.Now())
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		logging.FromContext(ctx).Warnf("error encoding %s event: %v", eventType, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	if result := c.Send(ctx, event); !cloudevents.IsACK(result) {
+		logging.FromContext(ctx).Warnf("error sending %s event for %s %s/%s: %v", eventType, data.Kind, data.Namespace, data.Name, result)
+	}
+}
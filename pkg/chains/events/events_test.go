@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+type received struct {
+	Type    string
+	Subject string
+	Data    Data
+}
+
+func newSink(t *testing.T) (*httptest.Server, func() []received) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		ev := received{Type: r.Header.Get("Ce-Type"), Subject: r.Header.Get("Ce-Subject")}
+		if err := json.Unmarshal(body, &ev.Data); err != nil {
+			t.Errorf("error decoding event data: %v", err)
+		}
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return events
+	}
+}
+
+func TestEmit(t *testing.T) {
+	stored := &audit.Artifact{
+		Type:       "tekton",
+		Key:        "taskrun-uid",
+		Format:     "in-toto",
+		Subjects:   []string{"gcr.io/foo/bar@sha256:abc"},
+		Signatures: []string{"c2ln"},
+		Backends:   []string{"oci"},
+	}
+	unsigned := &audit.Artifact{Type: "oci", Key: "oci", Error: "no signer kms configured"}
+	run := Data{Kind: "taskrun", Namespace: "ns", Name: "build", UID: "uid"}
+	withArtifact := run
+	withArtifact.Artifact = stored
+	withReason := run
+	withReason.Reason = "signing: key not found"
+	denied := &audit.Artifact{
+		Type:   "tekton",
+		Key:    "taskrun-uid",
+		Format: "in-toto",
+		Denied: []string{"builder.id is not trusted"},
+		Error:  "payload denied by policy: builder.id is not trusted",
+	}
+	withDenied := run
+	withDenied.Artifact = denied
+	withDeniedReason := withDenied
+	withDeniedReason.Artifact = nil
+	withDeniedReason.Reason = "policy: payload denied by policy: builder.id is not trusted"
+
+	tests := []struct {
+		name   string
+		ev     audit.Event
+		failed bool
+		want   []received
+	}{
+		{
+			name: "signed",
+			ev: audit.Event{
+				Kind: "taskrun", Namespace: "ns", Name: "build", UID: "uid",
+				Decision:  audit.DecisionSigned,
+				Artifacts: []*audit.Artifact{stored, unsigned},
+			},
+			want: []received{
+				{Type: TypeAttestationCreated, Subject: "taskrun-uid", Data: withArtifact},
+				{Type: TypeAttestationStored, Subject: "taskrun-uid", Data: withArtifact},
+			},
+		},
+		{
+			name: "failed permanently",
+			ev: audit.Event{
+				Kind: "taskrun", Namespace: "ns", Name: "build", UID: "uid",
+				Decision: audit.DecisionFailed,
+				Reason:   "signing: key not found",
+			},
+			failed: true,
+			want:   []received{{Type: TypeRunFailed, Data: withReason}},
+		},
+		{
+			name: "denied by policy",
+			ev: audit.Event{
+				Kind: "taskrun", Namespace: "ns", Name: "build", UID: "uid",
+				Decision:  audit.DecisionFailed,
+				Reason:    "policy: payload denied by policy: builder.id is not trusted",
+				Artifacts: []*audit.Artifact{denied},
+			},
+			failed: true,
+			want: []received{
+				{Type: TypePolicyDenied, Subject: "taskrun-uid", Data: withDenied},
+				{Type: TypeRunFailed, Data: withDeniedReason},
+			},
+		},
+		{
+			name: "failed, retried",
+			ev: audit.Event{
+				Kind: "taskrun", Namespace: "ns", Name: "build", UID: "uid",
+				Decision: audit.DecisionFailed,
+				Reason:   "storage: unavailable",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logtesting.TestContextWithLogger(t)
+			srv, got := newSink(t)
+			if err := Setup(config.EventsConfig{Sink: srv.URL}); err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = Setup(config.EventsConfig{}) }()
+
+			Emit(ctx, tt.ev, tt.failed)
+			if diff := cmp.Diff(tt.want, got()); diff != "" {
+				t.Errorf("events (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestEmit_Disabled(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	srv, got := newSink(t)
+	if err := Setup(config.EventsConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	Emit(ctx, audit.Event{Decision: audit.DecisionFailed}, true)
+	if len(got()) != 0 {
+		t.Errorf("expected no events without a sink, sent %d to %s", len(got()), srv.URL)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalsecrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// awsEndpoint returns the Secrets Manager endpoint of region. It is overridden in tests.
+var awsEndpoint = func(region string) string {
+	return fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+}
+
+// fetchAWS fetches the secret ref, e.g. awssm://chains/cosign-password or the ARN of the
+// secret, from AWS Secrets Manager with the default credentials of the controller. The
+// region of an ARN is used instead of the default region. The field query parameter
+// selects a field of a secret that is a JSON object.
+func fetchAWS(ctx context.Context, ref string, _ config.Config) ([]byte, error) {
+	id, field, err := splitField(ref)
+	if err != nil {
+		return nil, err
+	}
+	var opts []func(*awsconfig.LoadOptions) error
+	// ARNs are arn:<partition>:secretsmanager:<region>:<account>:secret:<name>.
+	if parts := strings.SplitN(id, ":", 5); len(parts) == 5 && parts[0] == "arn" {
+		opts = append(opts, awsconfig.WithRegion(parts[3]))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region to fetch %s from", id)
+	}
+	creds, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving AWS credentials: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, awsEndpoint(awsCfg.Region), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	sum := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "secretsmanager", awsCfg.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting secret %s: %s: %s", id, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var out struct {
+		SecretString *string
+		SecretBinary []byte
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("decoding secret %s: %w", id, err)
+	}
+	value := out.SecretBinary
+	if out.SecretString != nil {
+		value = []byte(*out.SecretString)
+	}
+	if field != "" {
+		return jsonField(value, field)
+	}
+	return value, nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package externalsecrets fetches the files of the signing secrets, such as the password
+// of the cosign key, from AWS Secrets Manager, GCP Secret Manager or Vault, so that they
+// don't have to be stored in Kubernetes secrets.
+package externalsecrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"golang.org/x/sync/singleflight"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// defaultRefreshInterval is how long fetched secrets are used when no refresh
+	// interval is configured.
+	defaultRefreshInterval = 5 * time.Minute
+	// failedRefreshDelay is how long a secret that failed to be fetched again is used
+	// before it is fetched again, if that is sooner than its refresh interval.
+	failedRefreshDelay = 30 * time.Second
+)
+
+// fetchFunc fetches the secret with the reference ref, without its scheme.
+type fetchFunc func(ctx context.Context, ref string, cfg config.Config) ([]byte, error)
+
+// fetchers are the fetchers of each scheme of references. They are overridden in tests.
+var fetchers = map[string]fetchFunc{
+	"awssm":      fetchAWS,
+	"gcpsm":      fetchGCP,
+	"hashivault": fetchVault,
+}
+
+// cache caches fetched secrets until their refresh interval passed.
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	// fetching fetches each secret once at a time, without holding mu, so that signing
+	// doesn't wait on the fetch of secrets that are still fresh.
+	fetching singleflight.Group
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+type entry struct {
+	value []byte
+	// refresh is when the secret is fetched again.
+	refresh time.Time
+}
+
+var secrets = &cache{now: time.Now}
+
+// Fetch returns the files of the signing secrets referenced by cfg, by their names.
+// Secrets are fetched again once their refresh interval passed, to pick up rotated
+// secrets. If fetching a secret again fails, the secret fetched before is used until it
+// is fetched successfully.
+func Fetch(ctx context.Context, cfg config.Config) (map[string][]byte, error) {
+	return secrets.fetch(ctx, cfg)
+}
+
+func (c *cache) fetch(ctx context.Context, cfg config.Config) (map[string][]byte, error) {
+	interval := cfg.Signers.External.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+
+	files := make(map[string][]byte, len(cfg.Signers.External.Refs))
+	for file, ref := range cfg.Signers.External.Refs {
+		if value, ok := c.cached(ref); ok {
+			files[file] = value
+			continue
+		}
+		v, err, _ := c.fetching.Do(ref, func() (interface{}, error) {
+			return c.refresh(ctx, file, ref, cfg, interval)
+		})
+		if err != nil {
+			return nil, err
+		}
+		files[file] = v.([]byte)
+	}
+	return files, nil
+}
+
+// cached returns the secret referenced by ref if it was fetched before its refresh
+// interval passed.
+func (c *cache) cached(ref string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[ref]
+	if !ok || !c.now().Before(e.refresh) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// refresh fetches the secret referenced by ref again. It returns the secret fetched
+// before if that fails.
+func (c *cache) refresh(ctx context.Context, file, ref string, cfg config.Config, interval time.Duration) ([]byte, error) {
+	value, err := fetch(ctx, ref, cfg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	e, ok := c.entries[ref]
+	if err != nil {
+		if !ok {
+			return nil, fmt.Errorf("fetching %s: %w", file, err)
+		}
+		logging.FromContext(ctx).Warnf("error fetching %s again, using the secret fetched before: %v", file, err)
+		e.refresh = now.Add(minDuration(interval, failedRefreshDelay))
+		return e.value, nil
+	}
+	if c.entries == nil {
+		c.entries = map[string]*entry{}
+	}
+	c.entries[ref] = &entry{value: value, refresh: now.Add(interval)}
+	return value, nil
+}
+
+// fetch fetches the secret referenced by ref with the fetcher of its scheme.
+func fetch(ctx context.Context, ref string, cfg config.Config) ([]byte, error) {
+	scheme, rest, ok := strings.Cut(ref, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid reference %q", ref)
+	}
+	f, ok := fetchers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported scheme %s of reference %q", scheme, ref)
+	}
+	return f(ctx, rest, cfg)
+}
+
+// splitField splits the field query parameter, e.g. ?field=password, off ref.
+func splitField(ref string) (string, string, error) {
+	path, query, ok := strings.Cut(ref, "?")
+	if !ok {
+		return ref, "", nil
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid query of reference %q: %w", ref, err)
+	}
+	return path, values.Get("field"), nil
+}
+
+// jsonField returns the string field of the JSON object in data.
+func jsonField(data []byte, field string) ([]byte, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("secret isn't a JSON object: %w", err)
+	}
+	return stringField(fields, field)
+}
+
+// stringField returns the string field of fields.
+func stringField(fields map[string]interface{}, field string) ([]byte, error) {
+	value, ok := fields[field].(string)
+	if !ok {
+		return nil, fmt.Errorf("secret has no string field %s", field)
+	}
+	return []byte(value), nil
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalsecrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestFetch_RefreshAndRotation(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	value, fail, calls := "first", false, 0
+	fetchers["test"] = func(_ context.Context, ref string, _ config.Config) ([]byte, error) {
+		calls++
+		if fail {
+			return nil, errors.New("unavailable")
+		}
+		return []byte(value), nil
+	}
+	defer delete(fetchers, "test")
+
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := &cache{now: func() time.Time { return now }}
+	cfg := config.Config{Signers: config.SignerConfigs{External: config.ExternalSecretsConfig{
+		Refs:            map[string]string{"cosign.password": "test://password"},
+		RefreshInterval: time.Minute,
+	}}}
+
+	get := func() string {
+		t.Helper()
+		files, err := c.fetch(ctx, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(files["cosign.password"])
+	}
+	if got := get(); got != "first" {
+		t.Errorf("cosign.password = %q, want %q", got, "first")
+	}
+	value = "rotated"
+	if got := get(); got != "first" || calls != 1 {
+		t.Errorf("cosign.password = %q after %d fetches before the refresh interval passed, want the cached secret", got, calls)
+	}
+
+	now = now.Add(time.Minute)
+	if got := get(); got != "rotated" {
+		t.Errorf("cosign.password = %q after the refresh interval passed, want %q", got, "rotated")
+	}
+
+	now = now.Add(time.Minute)
+	fail = true
+	if got := get(); got != "rotated" {
+		t.Errorf("cosign.password = %q when fetching it again failed, want the secret fetched before", got)
+	}
+	if _, err := (&cache{now: time.Now}).fetch(ctx, cfg); err == nil {
+		t.Error("expected an error for a secret that was never fetched")
+	}
+}
+
+func TestFetch_Concurrent(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	release := make(chan struct{})
+	var calls int32
+	fetchers["test"] = func(_ context.Context, ref string, _ config.Config) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		if ref == "slow" {
+			<-release
+		}
+		return []byte(ref), nil
+	}
+	defer delete(fetchers, "test")
+
+	c := &cache{now: time.Now}
+	slow := config.Config{Signers: config.SignerConfigs{External: config.ExternalSecretsConfig{
+		Refs: map[string]string{"cosign.password": "test://slow"},
+	}}}
+	fast := config.Config{Signers: config.SignerConfigs{External: config.ExternalSecretsConfig{
+		Refs: map[string]string{"cosign.password": "test://fast"},
+	}}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			files, err := c.fetch(ctx, slow)
+			if err != nil {
+				t.Error(err)
+			} else if got := string(files["cosign.password"]); got != "slow" {
+				t.Errorf("cosign.password = %q, want %q", got, "slow")
+			}
+		}()
+	}
+	// Other secrets are fetched while the slow one is.
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := c.fetch(ctx, fast); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	wg.Wait()
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("secrets were fetched %d times, want each fetched once", got)
+	}
+}
+
+func TestFetchAWS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "secretsmanager.GetSecretValue" {
+			t.Errorf("X-Amz-Target = %q", target)
+		}
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") || !strings.Contains(auth, "/us-west-2/secretsmanager/") {
+			t.Errorf("Authorization = %q, want a signature for secretsmanager in us-west-2", auth)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var in struct{ SecretId string }
+		if err := json.Unmarshal(body, &in); err != nil {
+			t.Fatal(err)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"password":"` + in.SecretId + `"}`})
+	}))
+	defer srv.Close()
+	defer func(f func(string) string) { awsEndpoint = f }(awsEndpoint)
+	awsEndpoint = func(string) string { return srv.URL }
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	arn := "arn:aws:secretsmanager:us-west-2:123456789012:secret:chains"
+	got, err := fetchAWS(context.Background(), arn+"?field=password", config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != arn {
+		t.Errorf("fetchAWS() = %q, want %q", got, arn)
+	}
+}
+
+func TestFetchGCP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/v1/projects/chains/secrets/cosign-password/versions/latest:access"; r.URL.Path != want {
+			t.Errorf("path = %q, want %q", r.URL.Path, want)
+		}
+		w.Write([]byte(`{"payload":{"data":"cGFzc3dvcmQ="}}`))
+	}))
+	defer srv.Close()
+	defer func(endpoint string, client func(context.Context) (*http.Client, error)) {
+		gcpEndpoint, gcpClient = endpoint, client
+	}(gcpEndpoint, gcpClient)
+	gcpEndpoint = srv.URL + "/v1/"
+	gcpClient = func(context.Context) (*http.Client, error) { return srv.Client(), nil }
+
+	got, err := fetchGCP(context.Background(), "projects/chains/secrets/cosign-password", config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "password" {
+		t.Errorf("fetchGCP() = %q, want %q", got, "password")
+	}
+	if _, err := fetchGCP(context.Background(), "cosign-password", config.Config{}); err == nil {
+		t.Error("expected an error for a secret without a project")
+	}
+}
+
+func TestFetchVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.Header.Get("X-Vault-Token"); token != "vault-token" {
+			t.Errorf("X-Vault-Token = %q", token)
+		}
+		if r.URL.Path != "/v1/secret/data/chains" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"password":"password"},"metadata":{"version":3}}}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{Signers: config.SignerConfigs{KMS: config.KMSSigner{Auth: config.KMSAuth{Address: srv.URL, Token: "vault-token"}}}}
+	got, err := fetchVault(context.Background(), "secret/data/chains?field=password", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "password" {
+		t.Errorf("fetchVault() = %q, want %q", got, "password")
+	}
+	for _, ref := range []string{"secret/data/chains", "secret/data/missing?field=password", "secret/data/chains?field=token"} {
+		if _, err := fetchVault(context.Background(), ref, cfg); err == nil {
+			t.Errorf("expected an error for %s", ref)
+		}
+	}
+}
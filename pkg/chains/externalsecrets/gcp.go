@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalsecrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"golang.org/x/oauth2/google"
+)
+
+// cloudPlatformScope is the OAuth scope Secret Manager is accessed with.
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+var (
+	// gcpEndpoint is the Secret Manager API endpoint. It is overridden in tests.
+	gcpEndpoint = "https://secretmanager.googleapis.com/v1/"
+	// gcpClient returns the client Secret Manager is accessed with. It is overridden in
+	// tests.
+	gcpClient = func(ctx context.Context) (*http.Client, error) {
+		return google.DefaultClient(ctx, cloudPlatformScope)
+	}
+)
+
+// fetchGCP fetches the secret version ref, e.g.
+// gcpsm://projects/chains/secrets/cosign-password/versions/2, from GCP Secret Manager
+// with the application default credentials of the controller. The latest version is
+// fetched if ref doesn't name one.
+func fetchGCP(ctx context.Context, ref string, _ config.Config) ([]byte, error) {
+	name := strings.Trim(ref, "/")
+	if !strings.HasPrefix(name, "projects/") || !strings.Contains(name, "/secrets/") {
+		return nil, fmt.Errorf("invalid secret %q: must be projects/<project>/secrets/<secret>[/versions/<version>]", ref)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	client, err := gcpClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating GCP client: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpEndpoint+name+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("accessing secret %s: %s: %s", name, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var out struct {
+		Payload struct {
+			Data []byte `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("decoding secret %s: %w", name, err)
+	}
+	return out.Payload.Data, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalsecrets
+
+import (
+	"context"
+	"fmt"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// fetchVault reads the field of the secret ref, e.g.
+// hashivault://secret/data/chains?field=password, from Vault. KV version 2 secrets are
+// read from their data path. Vault is addressed and authenticated to like the KMS
+// signer, with signers.kms.auth.address and signers.kms.auth.token, or VAULT_ADDR and
+// VAULT_TOKEN.
+func fetchVault(ctx context.Context, ref string, cfg config.Config) ([]byte, error) {
+	path, field, err := splitField(ref)
+	if err != nil {
+		return nil, err
+	}
+	if field == "" {
+		return nil, fmt.Errorf("invalid secret %q: the field query parameter is required", ref)
+	}
+	client, err := vault.NewClient(vault.DefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("creating Vault client: %w", err)
+	}
+	if addr := cfg.Signers.KMS.Auth.Address; addr != "" {
+		if err := client.SetAddress(addr); err != nil {
+			return nil, err
+		}
+	}
+	if token := cfg.Signers.KMS.Auth.Token; token != "" {
+		client.SetToken(token)
+	}
+
+	secret, err := client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading secret %s: %w", path, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secret %s not found", path)
+	}
+	fields := secret.Data
+	// The fields of KV version 2 secrets are nested in data.
+	if data, ok := fields["data"].(map[string]interface{}); ok {
+		fields = data
+	}
+	return stringField(fields, field)
+}
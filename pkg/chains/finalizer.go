@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultFinalizerTimeout is how long the finalizer of a deleted run is held when
+// finalizer.timeout is not set.
+const DefaultFinalizerTimeout = time.Hour
+
+// HoldFinalizer returns whether obj is being deleted and its finalizer should be held
+// until it is signed and stored, rather than released once retries are exhausted.
+// The finalizer is always released once the configured timeout has passed since the
+// deletion was requested, so runs can't be kept around forever.
+func HoldFinalizer(cfg config.FinalizerConfig, obj metav1.Object, now time.Time) bool {
+	deleted := obj.GetDeletionTimestamp()
+	if !cfg.BlockDeletion || deleted == nil {
+		return false
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultFinalizerTimeout
+	}
+	return now.Sub(deleted.Time) < timeout
+}
+
+// Failed returns whether signing obj was given up on.
+func Failed(obj objects.TektonObject) bool {
+	return obj.GetAnnotations()[ChainsAnnotation] == "failed"
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHoldFinalizer(t *testing.T) {
+	now := time.Now()
+	deletedAt := func(d time.Duration) *metav1.Time {
+		ts := metav1.NewTime(now.Add(-d))
+		return &ts
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.FinalizerConfig
+		deleted *metav1.Time
+		want    bool
+	}{
+		{
+			name:    "disabled",
+			cfg:     config.FinalizerConfig{},
+			deleted: deletedAt(time.Minute),
+			want:    false,
+		},
+		{
+			name: "not deleted",
+			cfg:  config.FinalizerConfig{BlockDeletion: true},
+			want: false,
+		},
+		{
+			name:    "within default timeout",
+			cfg:     config.FinalizerConfig{BlockDeletion: true},
+			deleted: deletedAt(time.Minute),
+			want:    true,
+		},
+		{
+			name:    "default timeout elapsed",
+			cfg:     config.FinalizerConfig{BlockDeletion: true},
+			deleted: deletedAt(2 * time.Hour),
+			want:    false,
+		},
+		{
+			name:    "configured timeout elapsed",
+			cfg:     config.FinalizerConfig{BlockDeletion: true, Timeout: 30 * time.Second},
+			deleted: deletedAt(time.Minute),
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: tt.deleted}}
+			if got := HoldFinalizer(tt.cfg, tr, now); got != tt.want {
+				t.Errorf("HoldFinalizer() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,8 +15,11 @@
 package all
 
 import (
+	_ "github.com/tektoncd/chains/pkg/chains/formats/openvex"
 	_ "github.com/tektoncd/chains/pkg/chains/formats/simple"
 	_ "github.com/tektoncd/chains/pkg/chains/formats/slsa/v1"
 	_ "github.com/tektoncd/chains/pkg/chains/formats/slsa/v2alpha1"
 	_ "github.com/tektoncd/chains/pkg/chains/formats/slsa/v2alpha2"
+	_ "github.com/tektoncd/chains/pkg/chains/formats/source"
+	_ "github.com/tektoncd/chains/pkg/chains/formats/tektonbundle"
 )
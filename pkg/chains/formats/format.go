@@ -34,6 +34,9 @@ const (
 	PayloadTypeSlsav1        config.PayloadType = "slsa/v1"
 	PayloadTypeSlsav2alpha1  config.PayloadType = "slsa/v2alpha1"
 	PayloadTypeSlsav2alpha2  config.PayloadType = "slsa/v2alpha2"
+	PayloadTypeOpenVEX       config.PayloadType = "openvex"
+	PayloadTypeTektonBundle  config.PayloadType = "tekton-bundle"
+	PayloadTypeSource        config.PayloadType = "source"
 )
 
 var (
@@ -42,6 +45,9 @@ var (
 		PayloadTypeSlsav1:       {},
 		PayloadTypeSlsav2alpha1: {},
 		PayloadTypeSlsav2alpha2: {},
+		PayloadTypeOpenVEX:      {},
+		PayloadTypeTektonBundle: {},
+		PayloadTypeSource:       {},
 	}
 	payloaderMap = map[config.PayloadType]PayloaderInit{}
 )
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openvex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+const (
+	PayloadTypeOpenVEX = formats.PayloadTypeOpenVEX
+
+	// PredicateType is the predicate type of OpenVEX attestations, as used by cosign.
+	PredicateType = "https://openvex.dev/ns"
+)
+
+func init() {
+	formats.RegisterPayloader(PayloadTypeOpenVEX, NewFormatter)
+}
+
+// OpenVEX is a formatter that wraps the OpenVEX documents produced by runs in
+// in-toto statements about the images of the runs.
+type OpenVEX struct{}
+
+// document is the subset of an OpenVEX document that is validated.
+type document struct {
+	Context    string            `json:"@context"`
+	ID         string            `json:"@id"`
+	Statements []json.RawMessage `json:"statements"`
+}
+
+func NewFormatter(config.Config) (formats.Payloader, error) {
+	return &OpenVEX{}, nil
+}
+
+// CreatePayload implements the Payloader interface.
+func (o *OpenVEX) CreatePayload(ctx context.Context, obj interface{}) (interface{}, error) {
+	v, ok := obj.(*artifacts.VEXDocument)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T", obj)
+	}
+	if err := validate(v.Document); err != nil {
+		return nil, fmt.Errorf("result %s is not a valid OpenVEX document: %w", v.ResultName, err)
+	}
+
+	subjects := []in_toto.Subject{}
+	for _, d := range v.Subjects {
+		subjects = append(subjects, in_toto.Subject{
+			Name:   d.Repository.Name(),
+			Digest: map[string]string{"sha256": strings.TrimPrefix(d.DigestStr(), "sha256:")},
+		})
+	}
+	return in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: PredicateType,
+			Subject:       subjects,
+		},
+		Predicate: json.RawMessage(v.Document),
+	}, nil
+}
+
+func (o *OpenVEX) Wrap() bool {
+	return true
+}
+
+func (o *OpenVEX) Type() config.PayloadType {
+	return formats.PayloadTypeOpenVEX
+}
+
+func validate(raw []byte) error {
+	var doc document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(doc.Context, PredicateType) {
+		return fmt.Errorf("unexpected @context %q", doc.Context)
+	}
+	if doc.ID == "" {
+		return errors.New("document has no @id")
+	}
+	if len(doc.Statements) == 0 {
+		return errors.New("document has no statements")
+	}
+	return nil
+}
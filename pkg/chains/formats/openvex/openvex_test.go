@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openvex
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+const vex = `{"@context":"https://openvex.dev/ns/v0.2.0","@id":"https://example.com/vex-1","statements":[{"vulnerability":{"name":"CVE-2023-1234"},"status":"not_affected"}]}`
+
+func TestCreatePayload(t *testing.T) {
+	digest, err := name.NewDigest("gcr.io/foo/bar@sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFormatter(config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := f.CreatePayload(context.Background(), &artifacts.VEXDocument{
+		ResultName: "VEX",
+		Document:   []byte(vex),
+		Subjects:   []name.Digest{digest},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: PredicateType,
+			Subject: []in_toto.Subject{{
+				Name:   "gcr.io/foo/bar",
+				Digest: map[string]string{"sha256": "05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5"},
+			}},
+		},
+		Predicate: json.RawMessage(vex),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CreatePayload() -want +got: %s", diff)
+	}
+}
+
+func TestCreatePayload_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{name: "not json", document: "CVE-2023-1234 is not_affected"},
+		{name: "wrong context", document: `{"@context":"https://cyclonedx.org","@id":"x","statements":[{}]}`},
+		{name: "no id", document: `{"@context":"https://openvex.dev/ns/v0.2.0","statements":[{}]}`},
+		{name: "no statements", document: `{"@context":"https://openvex.dev/ns/v0.2.0","@id":"x","statements":[]}`},
+	}
+	f, err := NewFormatter(config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.CreatePayload(context.Background(), &artifacts.VEXDocument{ResultName: "VEX", Document: []byte(tt.document)}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
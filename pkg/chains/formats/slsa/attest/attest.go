@@ -17,20 +17,43 @@ limitations under the License.
 package attest
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
 	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
 	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/environment"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	CommitParam                  = "CHAINS-GIT_COMMIT"
-	URLParam                     = "CHAINS-GIT_URL"
+	CommitParam                  = artifacts.GitCommitHint
+	URLParam                     = artifacts.GitURLHint
 	ChainsReproducibleAnnotation = "chains.tekton.dev/reproducible"
+
+	// EventListenerLabel, TriggerLabel and EventIDLabel are set by Tekton Triggers on
+	// the runs an EventListener creates.
+	EventListenerLabel = "triggers.tekton.dev/eventlistener"
+	TriggerLabel       = "triggers.tekton.dev/trigger"
+	EventIDLabel       = "triggers.tekton.dev/triggers-eventid"
+	// EventBodyAnnotation can be set to $(body) in a TriggerTemplate so that the digest
+	// of the event is recorded. The body itself is left out of provenance.
+	EventBodyAnnotation = "chains.tekton.dev/event-body"
+	// BuildGroupAnnotation groups the recurring runs of the same component, such as
+	// nightly builds, so that their provenance can be compared.
+	BuildGroupAnnotation = "chains.tekton.dev/build-group"
+	// RedactAnnotation lists, comma-separated, the params and results of a run whose
+	// values are replaced with their digest in its provenance. The values a PipelineRun
+	// redacts are also redacted in its TaskRuns.
+	RedactAnnotation = "chains.tekton.dev/redact"
+
+	// platformEnvironment is the invocation environment key the platform the pods of a
+	// run executed on is recorded under.
+	platformEnvironment = "platform"
 )
 
 type StepAttestation struct {
@@ -83,7 +106,7 @@ func Invocation(source *v1beta1.RefSource, params []v1beta1.Param, paramSpecs []
 	annotations := map[string]string{}
 	for name, value := range meta.GetAnnotations() {
 		// Ignore annotations that are not relevant to provenance information
-		if name == corev1.LastAppliedConfigAnnotation || strings.HasPrefix(name, "chains.tekton.dev/") {
+		if name == corev1.LastAppliedConfigAnnotation || (strings.HasPrefix(name, "chains.tekton.dev/") && name != BuildGroupAnnotation) {
 			continue
 		}
 		annotations[name] = value
@@ -104,6 +127,73 @@ func Invocation(source *v1beta1.RefSource, params []v1beta1.Param, paramSpecs []
 	return i
 }
 
+// WithPlatform returns the invocation environment env with the platform the pods of a
+// run executed on.
+func WithPlatform(env interface{}, platform *environment.Environment) interface{} {
+	withPlatform := map[string]interface{}{}
+	switch e := env.(type) {
+	case map[string]map[string]string:
+		for k, v := range e {
+			withPlatform[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range e {
+			withPlatform[k] = v
+		}
+	}
+	withPlatform[platformEnvironment] = platform
+	return withPlatform
+}
+
+// Redacts returns whether the RedactAnnotation of any of annotations redacts values.
+func Redacts(annotations ...map[string]string) bool {
+	for _, a := range annotations {
+		if strings.TrimSpace(strings.ReplaceAll(a[RedactAnnotation], ",", "")) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Completeness returns the completeness of the SLSA v0.2 provenance of a run. Its
+// parameters are complete unless oversized or redacted values were replaced, its environment when the
+// platform its pods executed on was recorded, and its materials when it was hermetic,
+// since it could then only use the materials it declared.
+func Completeness(valuesCapped bool, platform *environment.Environment, hermetic bool) slsa.ProvenanceComplete {
+	return slsa.ProvenanceComplete{
+		Parameters:  !valuesCapped,
+		Environment: platform != nil && platform.KubernetesVersion != "" && len(platform.Nodes) > 0,
+		Materials:   hermetic,
+	}
+}
+
+// Trigger returns the EventListener, Trigger and event that created a run, or nil
+// if it was not created by Tekton Triggers.
+func Trigger(meta metav1.Object) map[string]string {
+	labels := meta.GetLabels()
+	eventListener, ok := labels[EventListenerLabel]
+	if !ok {
+		return nil
+	}
+	trigger := map[string]string{"eventListener": eventListener}
+	if name, ok := labels[TriggerLabel]; ok {
+		trigger["trigger"] = name
+	}
+	if id, ok := labels[EventIDLabel]; ok {
+		trigger["eventID"] = id
+	}
+	if body, ok := meta.GetAnnotations()[EventBodyAnnotation]; ok {
+		h := sha256.Sum256([]byte(body))
+		trigger["eventBodyDigest"] = "sha256:" + hex.EncodeToString(h[:])
+	}
+	return trigger
+}
+
+// BuildGroup returns the build group of a run, or "" if it isn't grouped.
+func BuildGroup(meta metav1.Object) string {
+	return meta.GetAnnotations()[BuildGroupAnnotation]
+}
+
 func convertConfigSource(source *v1beta1.RefSource) slsa.ConfigSource {
 	if source == nil {
 		return slsa.ConfigSource{}
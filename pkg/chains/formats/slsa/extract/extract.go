@@ -32,12 +32,13 @@ import (
 	"knative.dev/pkg/logging"
 )
 
-// SubjectDigests returns software artifacts produced from the TaskRun/PipelineRun object
+// SubjectDigests returns software artifacts produced from the TaskRun/PipelineRun/CustomRun object
 // in the form of standard subject field of intoto statement.
 // The type hinting fields expected in results help identify the generated software artifacts.
 // Valid type hinting fields must:
 //   - have suffix `IMAGE_URL` & `IMAGE_DIGEST` or `ARTIFACT_URI` & `ARTIFACT_DIGEST` pair.
 //   - the `*_DIGEST` field must be in the format of "<algorithm>:<actual-sha>" where the algorithm must be "sha256" and actual sha must be valid per https://github.com/opencontainers/image-spec/blob/main/descriptor.md#sha-256.
+//     `ARTIFACT_DIGEST` fields may also use sha384 or sha512, or list several digests separated by commas.
 //   - the `*_URL` or `*_URI` fields cannot be empty.
 //
 //nolint:all
@@ -47,7 +48,7 @@ func SubjectDigests(ctx context.Context, obj objects.TektonObject, slsaconfig *s
 	switch obj.GetObject().(type) {
 	case *v1beta1.PipelineRun:
 		subjects = subjectsFromPipelineRun(ctx, obj, slsaconfig)
-	case *v1beta1.TaskRun:
+	case *v1beta1.TaskRun, *v1beta1.CustomRun:
 		subjects = subjectsFromTektonObject(ctx, obj)
 	}
 
@@ -62,52 +63,93 @@ func subjectsFromPipelineRun(ctx context.Context, obj objects.TektonObject, slsa
 		return prSubjects
 	}
 
-	logger := logging.FromContext(ctx)
 	// If deep inspection is enabled, collect subjects from child taskruns
-	var result []intoto.Subject
+	result := newSubjectSet()
 
 	pro := obj.(*objects.PipelineRunObject)
-
-	pSpec := pro.Status.PipelineSpec
-	if pSpec != nil {
-		pipelineTasks := append(pSpec.Tasks, pSpec.Finally...)
-		for _, t := range pipelineTasks {
-			tr := pro.GetTaskRunFromTask(t.Name)
-			// Ignore Tasks that did not execute during the PipelineRun.
-			if tr == nil || tr.Status.CompletionTime == nil {
-				logger.Infof("taskrun status not found for task %s", t.Name)
-				continue
-			}
-
-			trSubjects := subjectsFromTektonObject(ctx, objects.NewTaskRunObject(tr))
-			for _, s := range trSubjects {
-				result = addSubject(result, s)
-			}
+	_ = pro.ExecutedTasks(ctx, func(_ *v1beta1.PipelineTask, tr *v1beta1.TaskRun, _ bool) error {
+		for _, s := range subjectsFromTektonObject(ctx, objects.NewTaskRunObject(tr)) {
+			result.add(s)
 		}
-	}
+		return nil
+	})
 
 	// also add subjects observed from pipelinerun level with duplication removed
 	for _, s := range prSubjects {
-		result = addSubject(result, s)
+		result.add(s)
 	}
 
-	return result
+	return result.subjects
 }
 
-// addSubject adds a new subject item to the original slice.
-func addSubject(original []intoto.Subject, item intoto.Subject) []intoto.Subject {
+// MatrixSubject is a subject produced by one instance of a matrixed pipeline task.
+type MatrixSubject struct {
+	intoto.Subject
+	// PipelineTask is the name of the matrixed pipeline task.
+	PipelineTask string
+	// Params are the values of the matrix params of the instance that produced the subject.
+	Params map[string]v1beta1.ParamValue
+}
 
-	for i, s := range original {
-		// if there is an equivalent entry in the original slice, merge item's DigestSet
+// MatrixSubjects returns the subjects produced by each instance of the matrixed
+// pipeline tasks of a pipelinerun, along with the matrix params of the instance. Like
+// the subjects of child taskruns, they are only collected if deep inspection is enabled.
+func MatrixSubjects(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig) []MatrixSubject {
+	if !slsaconfig.DeepInspectionEnabled {
+		return nil
+	}
+	var subjects []MatrixSubject
+	_ = pro.ExecutedTasks(ctx, func(t *v1beta1.PipelineTask, tr *v1beta1.TaskRun, _ bool) error {
+		if !t.IsMatrixed() {
+			return nil
+		}
+		names := map[string]bool{}
+		for _, p := range t.Matrix.Params {
+			names[p.Name] = true
+		}
+		for _, include := range t.Matrix.Include {
+			for _, p := range include.Params {
+				names[p.Name] = true
+			}
+		}
+		params := map[string]v1beta1.ParamValue{}
+		for _, p := range tr.Spec.Params {
+			if names[p.Name] {
+				params[p.Name] = p.Value
+			}
+		}
+		for _, s := range subjectsFromTektonObject(ctx, objects.NewTaskRunObject(tr)) {
+			subjects = append(subjects, MatrixSubject{Subject: s, PipelineTask: t.Name, Params: params})
+		}
+		return nil
+	})
+	return subjects
+}
+
+// subjectSet accumulates subjects in the order they are added, merging the DigestSet
+// of equivalent subjects into the first one added. Subjects are indexed by name, as
+// only subjects with the same name can be equivalent.
+type subjectSet struct {
+	subjects []intoto.Subject
+	byName   map[string][]int
+}
+
+func newSubjectSet() *subjectSet {
+	return &subjectSet{byName: map[string][]int{}}
+}
+
+// add adds a new subject item to the set.
+func (s *subjectSet) add(item intoto.Subject) {
+	for _, i := range s.byName[item.Name] {
+		// if there is an equivalent entry in the set, merge item's DigestSet
 		// into the existing entry's DigestSet.
-		if subjectEqual(s, item) {
-			mergeMaps(original[i].Digest, item.Digest)
-			return original
+		if subjectEqual(s.subjects[i], item) {
+			mergeMaps(s.subjects[i].Digest, item.Digest)
+			return
 		}
 	}
-
-	original = append(original, item)
-	return original
+	s.byName[item.Name] = append(s.byName[item.Name], len(s.subjects))
+	s.subjects = append(s.subjects, item)
 }
 
 // two subjects are equal if and only if they have same name and have at least
@@ -148,29 +190,27 @@ func subjectsFromTektonObject(ctx context.Context, obj objects.TektonObject) []i
 
 	sts := artifacts.ExtractSignableTargetFromResults(ctx, obj)
 	for _, obj := range sts {
-		splits := strings.Split(obj.Digest, ":")
-		if len(splits) != 2 {
-			logger.Errorf("Digest %s should be in the format of: algorthm:abc", obj.Digest)
+		digests, err := artifacts.ParseDigests(obj.Digest)
+		if err != nil {
+			logger.Errorf("Digest %s should be in the format of: algorthm:abc: %v", obj.Digest, err)
 			continue
 		}
 		subjects = append(subjects, intoto.Subject{
-			Name: obj.URI,
-			Digest: common.DigestSet{
-				splits[0]: splits[1],
-			},
+			Name:   obj.URI,
+			Digest: digests,
 		})
 	}
 
 	ssts := artifacts.ExtractStructuredTargetFromResults(ctx, obj, artifacts.ArtifactsOutputsResultName)
 	for _, s := range ssts {
-		splits := strings.Split(s.Digest, ":")
-		alg := splits[0]
-		digest := splits[1]
+		digests, err := artifacts.ParseDigests(s.Digest)
+		if err != nil {
+			logger.Errorf("Digest %s should be in the format of: algorthm:abc: %v", s.Digest, err)
+			continue
+		}
 		subjects = append(subjects, intoto.Subject{
-			Name: s.URI,
-			Digest: common.DigestSet{
-				alg: digest,
-			},
+			Name:   s.URI,
+			Digest: digests,
 		})
 	}
 
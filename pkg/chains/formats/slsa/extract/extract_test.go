@@ -24,6 +24,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/extract"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/compare"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
@@ -38,6 +39,8 @@ const (
 	artifactDigest1 = "a2e500bebfe16cf12fc56316ba72c645e1d29054541dc1ab6c286197434170a9"
 	artifactURL2    = "us-central1-maven.pkg.dev/test/java"
 	artifactDigest2 = "b2e500bebfe16cf12fc56316ba72c645e1d29054541dc1ab6c286197434170a9"
+	// artifactDigest512 is a sha512 digest of the artifact at artifactURL1.
+	artifactDigest512 = "14697440701c3885f7c8d5faa59f336b471ca86332034eff0d3fddc02dc9b18b8356e840db54823c8fd2f2cbd0906969cf132cf8bb9c73dc769b4ffd817bd23d"
 )
 
 func TestSubjectDigestsAndRetrieveAllArtifactURIs(t *testing.T) {
@@ -124,6 +127,33 @@ func TestSubjectDigestsAndRetrieveAllArtifactURIs(t *testing.T) {
 	}
 }
 
+func TestSubjectDigestsWithSeveralAlgorithms(t *testing.T) {
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{Name: "ARTIFACT_URI", Value: *v1beta1.NewStructuredValues(artifactURL1)},
+					{Name: "ARTIFACT_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:" + artifactDigest1 + ",sha512:" + artifactDigest512)},
+					{Name: "invalid_ARTIFACT_URI", Value: *v1beta1.NewStructuredValues("gcr.io/test/invalid")},
+					{Name: "invalid_ARTIFACT_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:" + artifactDigest1 + ",sha512:a123")},
+					{Name: "jar_ARTIFACT_OUTPUTS", Value: *v1beta1.NewObject(map[string]string{
+						"uri":    artifactURL2,
+						"digest": "sha512:" + artifactDigest512 + ",sha256:" + artifactDigest2,
+					})},
+				},
+			},
+		},
+	})
+	want := []intoto.Subject{
+		{Name: artifactURL1, Digest: common.DigestSet{"sha256": artifactDigest1, "sha512": artifactDigest512}},
+		{Name: artifactURL2, Digest: common.DigestSet{"sha256": artifactDigest2, "sha512": artifactDigest512}},
+	}
+	got := extract.SubjectDigests(logtesting.TestContextWithLogger(t), tro, &slsaconfig.SlsaConfig{})
+	if diff := cmp.Diff(want, got, compare.SubjectCompareOption()); diff != "" {
+		t.Errorf("Wrong subjects extracted, diff=%s", diff)
+	}
+}
+
 func TestPipelineRunObserveModeForSubjects(t *testing.T) {
 	var tests = []struct {
 		name                  string
@@ -323,6 +353,70 @@ type artifact struct {
 // create a child taskrun for each result
 //
 //nolint:all
+func TestMatrixSubjects(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	matrixRun := func(platform, url, digest string) *v1beta1.TaskRun {
+		return &v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{objects.PipelineTaskLabel: "build"}},
+			Spec: v1beta1.TaskRunSpec{
+				Params: v1beta1.Params{
+					{Name: "platform", Value: *v1beta1.NewStructuredValues(platform)},
+					{Name: "context", Value: *v1beta1.NewStructuredValues(".")},
+				},
+			},
+			Status: v1beta1.TaskRunStatus{
+				TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+					CompletionTime: &metav1.Time{Time: time.Date(1995, time.December, 24, 6, 12, 12, 24, time.UTC)},
+					TaskRunResults: []v1beta1.TaskRunResult{
+						{Name: "IMAGE_URL", Value: *v1beta1.NewStructuredValues(url)},
+						{Name: "IMAGE_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:" + digest)},
+					},
+				},
+			},
+		}
+	}
+	pro := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		Status: v1beta1.PipelineRunStatus{
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				PipelineSpec: &v1beta1.PipelineSpec{
+					Tasks: []v1beta1.PipelineTask{{
+						Name: "build",
+						Matrix: &v1beta1.Matrix{Params: v1beta1.Params{
+							{Name: "platform", Value: *v1beta1.NewStructuredValues("linux/amd64", "linux/arm64")},
+						}},
+					}},
+				},
+			},
+		},
+	})
+	pro.AppendTaskRun(matrixRun("linux/amd64", artifactURL1, artifactDigest1))
+	pro.AppendTaskRun(matrixRun("linux/arm64", artifactURL1, artifactDigest2))
+
+	want := []extract.MatrixSubject{{
+		Subject:      intoto.Subject{Name: artifactURL1, Digest: map[string]string{"sha256": artifactDigest1}},
+		PipelineTask: "build",
+		Params:       map[string]v1beta1.ParamValue{"platform": *v1beta1.NewStructuredValues("linux/amd64")},
+	}, {
+		Subject:      intoto.Subject{Name: artifactURL1, Digest: map[string]string{"sha256": artifactDigest2}},
+		PipelineTask: "build",
+		Params:       map[string]v1beta1.ParamValue{"platform": *v1beta1.NewStructuredValues("linux/arm64")},
+	}}
+	got := extract.MatrixSubjects(ctx, pro, &slsaconfig.SlsaConfig{DeepInspectionEnabled: true})
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MatrixSubjects(): -want +got: %s", diff)
+	}
+
+	wantSubjects := []intoto.Subject{want[0].Subject, want[1].Subject}
+	gotSubjects := extract.SubjectDigests(ctx, pro, &slsaconfig.SlsaConfig{DeepInspectionEnabled: true})
+	if diff := cmp.Diff(wantSubjects, gotSubjects); diff != "" {
+		t.Errorf("SubjectDigests(): -want +got: %s", diff)
+	}
+
+	if got := extract.MatrixSubjects(ctx, pro, &slsaconfig.SlsaConfig{}); got != nil {
+		t.Errorf("MatrixSubjects() = %v without deep inspection, want none", got)
+	}
+}
+
 func createProWithTaskRunResults(pro *objects.PipelineRunObject, results []artifact) objects.TektonObject {
 	if pro == nil {
 		pro = objects.NewPipelineRunObject(&v1beta1.PipelineRun{
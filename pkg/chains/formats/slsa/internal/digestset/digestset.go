@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package digestset restricts the digest sets of the subjects and materials recorded in
+// provenance to the configured algorithms, for policies mandating stronger digests.
+package digestset
+
+import (
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Restrict returns the digests of d whose algorithm is one of algorithms. d is returned
+// as it is if no algorithms are configured or none of its digests use one of them, so
+// that the artifact can still be identified.
+func Restrict(d common.DigestSet, algorithms sets.Set[string]) common.DigestSet {
+	if algorithms.Clone().Delete("").Len() == 0 {
+		return d
+	}
+	restricted := common.DigestSet{}
+	for algo, hex := range d {
+		if algorithms.Has(algo) {
+			restricted[algo] = hex
+		}
+	}
+	if len(restricted) == 0 {
+		return d
+	}
+	return restricted
+}
+
+// Statement returns att with the digest sets of its subjects, and of the materials or
+// resolved dependencies of its SLSA v0.2 or v1.0 predicate, restricted to algorithms.
+// Other statements are returned as they are.
+func Statement(att interface{}, algorithms sets.Set[string]) interface{} {
+	if algorithms.Clone().Delete("").Len() == 0 {
+		return att
+	}
+	switch st := att.(type) {
+	case intoto.ProvenanceStatement:
+		st.Subject = subjects(st.Subject, algorithms)
+		if st.Predicate.Materials != nil {
+			materials := make([]common.ProvenanceMaterial, len(st.Predicate.Materials))
+			for i, m := range st.Predicate.Materials {
+				m.Digest = Restrict(m.Digest, algorithms)
+				materials[i] = m
+			}
+			st.Predicate.Materials = materials
+		}
+		return st
+	case intoto.ProvenanceStatementSLSA1:
+		st.Subject = subjects(st.Subject, algorithms)
+		if deps := st.Predicate.BuildDefinition.ResolvedDependencies; deps != nil {
+			restricted := make([]slsa.ResourceDescriptor, len(deps))
+			for i, rd := range deps {
+				rd.Digest = Restrict(rd.Digest, algorithms)
+				restricted[i] = rd
+			}
+			st.Predicate.BuildDefinition.ResolvedDependencies = restricted
+		}
+		return st
+	default:
+		return att
+	}
+}
+
+func subjects(subjects []intoto.Subject, algorithms sets.Set[string]) []intoto.Subject {
+	if subjects == nil {
+		return nil
+	}
+	restricted := make([]intoto.Subject, len(subjects))
+	for i, s := range subjects {
+		s.Digest = Restrict(s.Digest, algorithms)
+		restricted[i] = s
+	}
+	return restricted
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package digestset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	slsa02 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestRestrict(t *testing.T) {
+	d := common.DigestSet{"sha256": "a", "sha512": "b"}
+	tests := []struct {
+		name       string
+		algorithms sets.Set[string]
+		want       common.DigestSet
+	}{
+		{name: "unset", want: d},
+		{name: "empty", algorithms: sets.New[string](""), want: d},
+		{name: "stronger", algorithms: sets.New[string]("sha512"), want: common.DigestSet{"sha512": "b"}},
+		{name: "several", algorithms: sets.New[string]("sha256", "sha384", "sha512"), want: d},
+		{name: "none provided", algorithms: sets.New[string]("sha384"), want: d},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, Restrict(d, tt.algorithms)); diff != "" {
+				t.Errorf("Restrict() diff (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestStatement(t *testing.T) {
+	algorithms := sets.New[string]("sha512")
+	digests := func() common.DigestSet {
+		return common.DigestSet{"sha256": "a", "sha512": "b"}
+	}
+	restricted := common.DigestSet{"sha512": "b"}
+	gitCommit := common.DigestSet{"sha1": "c"}
+
+	v02 := intoto.ProvenanceStatement{
+		StatementHeader: intoto.StatementHeader{Subject: []intoto.Subject{{Name: "image", Digest: digests()}}},
+		Predicate: slsa02.ProvenancePredicate{Materials: []common.ProvenanceMaterial{
+			{URI: "oci://base", Digest: digests()},
+			{URI: "git+https://github.com/org/repo.git", Digest: gitCommit},
+		}},
+	}
+	got := Statement(v02, algorithms).(intoto.ProvenanceStatement)
+	want := intoto.ProvenanceStatement{
+		StatementHeader: intoto.StatementHeader{Subject: []intoto.Subject{{Name: "image", Digest: restricted}}},
+		Predicate: slsa02.ProvenancePredicate{Materials: []common.ProvenanceMaterial{
+			{URI: "oci://base", Digest: restricted},
+			{URI: "git+https://github.com/org/repo.git", Digest: gitCommit},
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Statement() diff (-want +got): %s", diff)
+	}
+	if len(v02.Subject[0].Digest) != 2 || len(v02.Predicate.Materials[0].Digest) != 2 {
+		t.Error("Statement() modified the digest sets of its argument")
+	}
+
+	v1 := intoto.ProvenanceStatementSLSA1{
+		StatementHeader: intoto.StatementHeader{Subject: []intoto.Subject{{Name: "image", Digest: digests()}}},
+		Predicate: slsa.ProvenancePredicate{BuildDefinition: slsa.ProvenanceBuildDefinition{
+			ResolvedDependencies: []slsa.ResourceDescriptor{{URI: "oci://base", Digest: digests()}},
+		}},
+	}
+	gotV1 := Statement(v1, algorithms).(intoto.ProvenanceStatementSLSA1)
+	if diff := cmp.Diff(restricted, gotV1.Subject[0].Digest); diff != "" {
+		t.Errorf("subject digest diff (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff(restricted, gotV1.Predicate.BuildDefinition.ResolvedDependencies[0].Digest); diff != "" {
+		t.Errorf("resolved dependency digest diff (-want +got): %s", diff)
+	}
+
+	if got := Statement("other", algorithms); got != "other" {
+		t.Errorf("Statement() = %v, want other statements as they are", got)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package isolation describes how isolated the pods of a run were, from the signals in
+// their pod template, labels and annotations.
+package isolation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+// Isolation describes how isolated the pods of a run were from the network and the node.
+type Isolation struct {
+	// Hermetic is set when the pods were cut off from the network.
+	Hermetic bool `json:"hermetic"`
+	// NetworkIsolated is set when the run has one of the configured network labels.
+	NetworkIsolated bool `json:"networkIsolated"`
+	// HostNetwork is set when the pods used the network namespace of the node.
+	HostNetwork bool `json:"hostNetwork"`
+	// RuntimeClass is the RuntimeClass of the pods.
+	RuntimeClass string `json:"runtimeClass,omitempty"`
+	// Sandboxed is set when the RuntimeClass is one of the configured sandboxes.
+	Sandboxed bool `json:"sandboxed"`
+}
+
+// Enabled returns whether any isolation signals are configured. Isolation is only
+// recorded when they are, since without them no run can be shown to be isolated.
+func Enabled(slsaconfig *slsaconfig.SlsaConfig) bool {
+	return slsaconfig.SandboxRuntimeClasses.Clone().Delete("").Len() > 0 ||
+		slsaconfig.NetworkLabels.Clone().Delete("").Len() > 0
+}
+
+// TaskRun returns the isolation of the pod of a taskrun.
+func TaskRun(tr *v1beta1.TaskRun, slsaconfig *slsaconfig.SlsaConfig) Isolation {
+	i := Isolation{}
+	if tpl := tr.Spec.PodTemplate; tpl != nil {
+		i.HostNetwork = tpl.HostNetwork
+		if tpl.RuntimeClassName != nil {
+			i.RuntimeClass = *tpl.RuntimeClassName
+		}
+	}
+	i.Sandboxed = i.RuntimeClass != "" && slsaconfig.SandboxRuntimeClasses.Has(i.RuntimeClass)
+	for _, l := range slsaconfig.NetworkLabels.UnsortedList() {
+		if l != "" && (matches(tr.Labels, l) || matches(tr.Annotations, l)) {
+			i.NetworkIsolated = true
+			break
+		}
+	}
+	i.Hermetic = i.NetworkIsolated && !i.HostNetwork
+	return i
+}
+
+// PipelineRun returns the isolation of the pods of the taskruns of a pipelinerun. The
+// pipelinerun is only as isolated as the least isolated of them, and has a RuntimeClass
+// if they all ran with the same one.
+func PipelineRun(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig) Isolation {
+	var combined *Isolation
+	_ = pro.ExecutedTasks(ctx, func(_ *v1beta1.PipelineTask, tr *v1beta1.TaskRun, _ bool) error {
+		i := TaskRun(tr, slsaconfig)
+		if combined == nil {
+			combined = &i
+			return nil
+		}
+		combined.Hermetic = combined.Hermetic && i.Hermetic
+		combined.NetworkIsolated = combined.NetworkIsolated && i.NetworkIsolated
+		combined.HostNetwork = combined.HostNetwork || i.HostNetwork
+		combined.Sandboxed = combined.Sandboxed && i.Sandboxed
+		if combined.RuntimeClass != i.RuntimeClass {
+			combined.RuntimeClass = ""
+		}
+		return nil
+	})
+	if combined == nil {
+		return Isolation{}
+	}
+	return *combined
+}
+
+// matches returns whether m has the key, or key=value, l.
+func matches(m map[string]string, l string) bool {
+	key, value, hasValue := strings.Cut(l, "=")
+	v, ok := m[key]
+	return ok && (!hasValue || v == value)
+}
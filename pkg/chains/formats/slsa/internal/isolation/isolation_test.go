@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package isolation
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+var cfg = &slsaconfig.SlsaConfig{
+	SandboxRuntimeClasses: sets.New[string]("gvisor"),
+	NetworkLabels:         sets.New[string]("network.example.com/egress=deny", "proxy-only"),
+}
+
+func taskRun(name string, labels, annotations map[string]string, tpl *pod.PodTemplate) *v1beta1.TaskRun {
+	now := metav1.Now()
+	return &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: v1beta1.TaskRunSpec{PodTemplate: tpl},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{CompletionTime: &now},
+		},
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	if Enabled(&slsaconfig.SlsaConfig{}) {
+		t.Error("Enabled() = true without isolation signals")
+	}
+	if Enabled(&slsaconfig.SlsaConfig{NetworkLabels: sets.New[string]("")}) {
+		t.Error("Enabled() = true with empty isolation signals")
+	}
+	if !Enabled(cfg) {
+		t.Error("Enabled() = false with isolation signals")
+	}
+}
+
+func TestTaskRun(t *testing.T) {
+	gvisor := "gvisor"
+	runc := "runc"
+	tests := []struct {
+		name string
+		tr   *v1beta1.TaskRun
+		want Isolation
+	}{{
+		name: "no signals",
+		tr:   taskRun("plain", nil, nil, nil),
+		want: Isolation{},
+	}, {
+		name: "network label",
+		tr:   taskRun("label", map[string]string{"network.example.com/egress": "deny"}, nil, nil),
+		want: Isolation{Hermetic: true, NetworkIsolated: true},
+	}, {
+		name: "network label with another value",
+		tr:   taskRun("other", map[string]string{"network.example.com/egress": "allow"}, nil, nil),
+		want: Isolation{},
+	}, {
+		name: "network annotation",
+		tr:   taskRun("annotation", nil, map[string]string{"proxy-only": "true"}, nil),
+		want: Isolation{Hermetic: true, NetworkIsolated: true},
+	}, {
+		name: "host network",
+		tr:   taskRun("host", map[string]string{"proxy-only": ""}, nil, &pod.PodTemplate{HostNetwork: true}),
+		want: Isolation{NetworkIsolated: true, HostNetwork: true},
+	}, {
+		name: "sandbox runtime class",
+		tr:   taskRun("sandbox", nil, nil, &pod.PodTemplate{RuntimeClassName: &gvisor}),
+		want: Isolation{RuntimeClass: "gvisor", Sandboxed: true},
+	}, {
+		name: "other runtime class",
+		tr:   taskRun("runc", nil, nil, &pod.PodTemplate{RuntimeClassName: &runc}),
+		want: Isolation{RuntimeClass: "runc"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, TaskRun(tt.tr, cfg)); diff != "" {
+				t.Errorf("TaskRun() -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	gvisor := "gvisor"
+	isolated := map[string]string{"proxy-only": "true"}
+	sandbox := &pod.PodTemplate{RuntimeClassName: &gvisor}
+	tests := []struct {
+		name string
+		trs  []*v1beta1.TaskRun
+		want Isolation
+	}{{
+		name: "no taskruns",
+		want: Isolation{},
+	}, {
+		name: "all isolated",
+		trs: []*v1beta1.TaskRun{
+			taskRun("first", isolated, nil, sandbox),
+			taskRun("second", isolated, nil, sandbox),
+		},
+		want: Isolation{Hermetic: true, NetworkIsolated: true, RuntimeClass: "gvisor", Sandboxed: true},
+	}, {
+		name: "one not isolated",
+		trs: []*v1beta1.TaskRun{
+			taskRun("first", isolated, nil, sandbox),
+			taskRun("second", nil, nil, &pod.PodTemplate{HostNetwork: true}),
+		},
+		want: Isolation{HostNetwork: true},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &v1beta1.PipelineRun{
+				Status: v1beta1.PipelineRunStatus{
+					PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+						PipelineSpec: &v1beta1.PipelineSpec{},
+					},
+				},
+			}
+			pro := objects.NewPipelineRunObject(pr)
+			for _, tr := range tt.trs {
+				pr.Status.PipelineSpec.Tasks = append(pr.Status.PipelineSpec.Tasks, v1beta1.PipelineTask{Name: tr.Name})
+				tr.Labels = mergeLabels(tr.Labels, map[string]string{objects.PipelineTaskLabel: tr.Name})
+				pro.AppendTaskRun(tr)
+			}
+			got := PipelineRun(logtesting.TestContextWithLogger(t), pro, cfg)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("PipelineRun() -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func mergeLabels(labels, extra map[string]string) map[string]string {
+	merged := map[string]string{}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
@@ -18,8 +18,9 @@ package material
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
@@ -29,7 +30,6 @@ import (
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
-	"knative.dev/pkg/logging"
 )
 
 const (
@@ -61,66 +61,53 @@ func TaskMaterials(ctx context.Context, tro *objects.TaskRunObject) ([]common.Pr
 	mats = append(mats, FromTaskResources(ctx, tro)...)
 
 	// remove duplicate materials
-	mats, err = removeDuplicateMaterials(mats)
-	if err != nil {
-		return mats, err
-	}
-	return mats, nil
+	return removeDuplicateMaterials(mats), nil
 }
 
+// PipelineMaterials constructs `predicate.materials` section of a pipelinerun. The
+// materials of each TaskRun are added as it is visited, leaving out duplicates as they
+// come, so that pipelines with many tasks don't hold the materials of all their
+// TaskRuns at once.
 func PipelineMaterials(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig) ([]common.ProvenanceMaterial, error) {
-	logger := logging.FromContext(ctx)
-	var mats []common.ProvenanceMaterial
+	set := newMaterialSet()
 	if p := pro.Status.Provenance; p != nil && p.RefSource != nil {
-		m := common.ProvenanceMaterial{
+		set.add(common.ProvenanceMaterial{
 			URI:    p.RefSource.URI,
 			Digest: p.RefSource.Digest,
-		}
-		mats = append(mats, m)
+		})
 	}
-	pSpec := pro.Status.PipelineSpec
-	if pSpec != nil {
-		pipelineTasks := append(pSpec.Tasks, pSpec.Finally...)
-		for _, t := range pipelineTasks {
-			tr := pro.GetTaskRunFromTask(t.Name)
-			// Ignore Tasks that did not execute during the PipelineRun.
-			if tr == nil || tr.Status.CompletionTime == nil {
-				logger.Infof("taskrun status not found for task %s", t.Name)
-				continue
-			}
-
-			stepMaterials, err := FromStepImages(tr.Status.Steps)
+	err := pro.ExecutedTasks(ctx, func(_ *v1beta1.PipelineTask, tr *v1beta1.TaskRun, _ bool) error {
+		// add step and sidecar images
+		for _, stepState := range tr.Status.Steps {
+			m, err := fromImageID(stepState.ImageID)
 			if err != nil {
-				return mats, err
+				return err
 			}
-			mats = append(mats, stepMaterials...)
-
-			// add sidecar images
-			sidecarMaterials, err := FromSidecarImages(tr.Status.Sidecars)
+			set.add(m)
+		}
+		for _, sidecarState := range tr.Status.Sidecars {
+			m, err := fromImageID(sidecarState.ImageID)
 			if err != nil {
-				return nil, err
-			}
-			mats = append(mats, sidecarMaterials...)
-
-			// add remote task configsource information in materials
-			if tr.Status.Provenance != nil && tr.Status.Provenance.RefSource != nil {
-				m := common.ProvenanceMaterial{
-					URI:    tr.Status.Provenance.RefSource.URI,
-					Digest: tr.Status.Provenance.RefSource.Digest,
-				}
-				mats = append(mats, m)
+				return err
 			}
+			set.add(m)
 		}
-	}
-
-	mats = append(mats, FromPipelineParamsAndResults(ctx, pro, slsaconfig)...)
 
-	// remove duplicate materials
-	mats, err := removeDuplicateMaterials(mats)
+		// add remote task configsource information in materials
+		if tr.Status.Provenance != nil && tr.Status.Provenance.RefSource != nil {
+			set.add(common.ProvenanceMaterial{
+				URI:    tr.Status.Provenance.RefSource.URI,
+				Digest: tr.Status.Provenance.RefSource.Digest,
+			})
+		}
+		return nil
+	})
 	if err != nil {
-		return mats, err
+		return nil, err
 	}
-	return mats, nil
+
+	pipelineParamsAndResults(ctx, pro, slsaconfig, set.add)
+	return set.mats, nil
 }
 
 // FromStepImages gets predicate.materials from step images
@@ -267,54 +254,129 @@ func FromTaskParamsAndResults(ctx context.Context, tro *objects.TaskRunObject) [
 	return mats
 }
 
+// CustomRunMaterials constructs `predicate.materials` section of a customrun from the
+// type hinted CHAINS-GIT_COMMIT and CHAINS-GIT_URL params and results, and the
+// structured ARTIFACT_INPUTS results. CustomRuns have no steps, so there are no images.
+func CustomRunMaterials(ctx context.Context, cro *objects.CustomRunObject) []common.ProvenanceMaterial {
+	var commit, url string
+	for _, p := range cro.Spec.Params {
+		if p.Name == attest.CommitParam {
+			commit = p.Value.StringVal
+			continue
+		}
+		if p.Name == attest.URLParam {
+			url = p.Value.StringVal
+		}
+	}
+
+	for _, r := range cro.Status.Results {
+		if r.Name == attest.CommitParam {
+			commit = r.Value
+		}
+		if r.Name == attest.URLParam {
+			url = r.Value
+		}
+	}
+
+	url = attest.SPDXGit(url, "")
+
+	mats := []common.ProvenanceMaterial{}
+	if commit != "" && url != "" {
+		mats = append(mats, common.ProvenanceMaterial{
+			URI:    url,
+			Digest: map[string]string{"sha1": commit},
+		})
+	}
+
+	sms := artifacts.RetrieveMaterialsFromStructuredResults(ctx, cro, artifacts.ArtifactsInputsResultName)
+	mats = append(mats, sms...)
+
+	return removeDuplicateMaterials(mats)
+}
+
 // removeDuplicateMaterials removes duplicate materials from the slice of materials.
 // Original order of materials is retained.
-func removeDuplicateMaterials(mats []common.ProvenanceMaterial) ([]common.ProvenanceMaterial, error) {
-	out := make([]common.ProvenanceMaterial, 0, len(mats))
+func removeDuplicateMaterials(mats []common.ProvenanceMaterial) []common.ProvenanceMaterial {
+	set := newMaterialSet()
+	set.mats = make([]common.ProvenanceMaterial, 0, len(mats))
+	set.add(mats...)
+	return set.mats
+}
+
+// materialSet accumulates materials in the order they are added, leaving out the
+// duplicates of materials already added.
+type materialSet struct {
+	mats []common.ProvenanceMaterial
+	seen map[string]bool
+	key  []byte
+}
+
+func newMaterialSet() *materialSet {
+	return &materialSet{mats: []common.ProvenanceMaterial{}, seen: map[string]bool{}}
+}
 
-	// make map to store seen materials
-	seen := map[string]bool{}
+func (s *materialSet) add(mats ...common.ProvenanceMaterial) {
 	for _, mat := range mats {
-		m, err := json.Marshal(mat)
-		if err != nil {
-			return nil, err
-		}
-		if seen[string(m)] {
+		s.key = AppendKey(s.key[:0], mat.URI, mat.Digest)
+		if s.seen[string(s.key)] {
 			continue
 		}
+		s.seen[string(s.key)] = true
+		s.mats = append(s.mats, mat)
+	}
+}
 
-		seen[string(m)] = true
-		out = append(out, mat)
+// AppendKey appends a key that identifies the uri and digest of a material to dst
+// and returns the extended buffer. Materials are duplicates if their keys are equal,
+// which is cheaper to check than comparing their JSON encodings.
+func AppendKey(dst []byte, uri string, digest common.DigestSet) []byte {
+	dst = appendField(dst, uri)
+	algs := make([]string, 0, len(digest))
+	for alg := range digest {
+		algs = append(algs, alg)
 	}
-	return out, nil
+	sort.Strings(algs)
+	for _, alg := range algs {
+		dst = appendField(dst, alg)
+		dst = appendField(dst, digest[alg])
+	}
+	return dst
+}
+
+// appendField appends s to dst prefixed with its length, so that fields can't run
+// into each other.
+func appendField(dst []byte, s string) []byte {
+	dst = strconv.AppendInt(dst, int64(len(s)), 10)
+	dst = append(dst, ':')
+	return append(dst, s...)
 }
 
 // FromPipelineParamsAndResults extracts type hinted params and results and adds the url and digest to materials.
 func FromPipelineParamsAndResults(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig) []common.ProvenanceMaterial {
 	mats := []common.ProvenanceMaterial{}
-	sms := artifacts.RetrieveMaterialsFromStructuredResults(ctx, pro, artifacts.ArtifactsInputsResultName)
-	mats = append(mats, sms...)
+	pipelineParamsAndResults(ctx, pro, slsaconfig, func(m ...common.ProvenanceMaterial) {
+		mats = append(mats, m...)
+	})
+	return mats
+}
+
+// pipelineParamsAndResults passes the materials of FromPipelineParamsAndResults to add
+// as they are found.
+func pipelineParamsAndResults(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig, add func(...common.ProvenanceMaterial)) {
+	add(artifacts.RetrieveMaterialsFromStructuredResults(ctx, pro, artifacts.ArtifactsInputsResultName)...)
 
 	var commit, url string
 
+	// search type hinting param/results from each individual taskruns
+	if slsaconfig.DeepInspectionEnabled {
+		_ = pro.ExecutedTasks(ctx, func(_ *v1beta1.PipelineTask, tr *v1beta1.TaskRun, _ bool) error {
+			add(FromTaskParamsAndResults(ctx, objects.NewTaskRunObject(tr))...)
+			return nil
+		})
+	}
+
 	pSpec := pro.Status.PipelineSpec
 	if pSpec != nil {
-		// search type hinting param/results from each individual taskruns
-		if slsaconfig.DeepInspectionEnabled {
-			logger := logging.FromContext(ctx)
-			pipelineTasks := append(pSpec.Tasks, pSpec.Finally...)
-			for _, t := range pipelineTasks {
-				tr := pro.GetTaskRunFromTask(t.Name)
-				// Ignore Tasks that did not execute during the PipelineRun.
-				if tr == nil || tr.Status.CompletionTime == nil {
-					logger.Infof("taskrun is not found or not completed for the task %s", t.Name)
-					continue
-				}
-				materialsFromTasks := FromTaskParamsAndResults(ctx, objects.NewTaskRunObject(tr))
-				mats = append(mats, materialsFromTasks...)
-			}
-		}
-
 		// search status.PipelineSpec.params
 		for _, p := range pSpec.Params {
 			if p.Default == nil {
@@ -352,10 +414,9 @@ func FromPipelineParamsAndResults(ctx context.Context, pro *objects.PipelineRunO
 	}
 	if len(commit) > 0 && len(url) > 0 {
 		url = attest.SPDXGit(url, "")
-		mats = append(mats, common.ProvenanceMaterial{
+		add(common.ProvenanceMaterial{
 			URI:    url,
 			Digest: map[string]string{"sha1": commit},
 		})
 	}
-	return mats
 }
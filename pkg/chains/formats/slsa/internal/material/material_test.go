@@ -704,10 +704,7 @@ func TestRemoveDuplicates(t *testing.T) {
 	}}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			mat, err := removeDuplicateMaterials(tc.mats)
-			if err != nil {
-				t.Fatalf("Did not expect an error but got %v", err)
-			}
+			mat := removeDuplicateMaterials(tc.mats)
 			if diff := cmp.Diff(tc.want, mat); diff != "" {
 				t.Errorf("materials(): -want +got: %s", diff)
 			}
@@ -715,6 +712,42 @@ func TestRemoveDuplicates(t *testing.T) {
 	}
 }
 
+func TestAppendKey(t *testing.T) {
+	key := func(uri string, digest common.DigestSet) string {
+		return string(AppendKey(nil, uri, digest))
+	}
+	tests := []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{{
+		name:  "digest order",
+		a:     key("gcr.io/foo", common.DigestSet{"sha256": "abc", "sha1": "def"}),
+		b:     key("gcr.io/foo", common.DigestSet{"sha1": "def", "sha256": "abc"}),
+		equal: true,
+	}, {
+		name:  "no digest",
+		a:     key("gcr.io/foo", nil),
+		b:     key("gcr.io/foo", common.DigestSet{}),
+		equal: true,
+	}, {
+		name: "different digest",
+		a:    key("gcr.io/foo", common.DigestSet{"sha256": "abc"}),
+		b:    key("gcr.io/foo", common.DigestSet{"sha256": "abd"}),
+	}, {
+		name: "fields don't run into each other",
+		a:    key("gcr.io/foo", common.DigestSet{"sha256": "abc"}),
+		b:    key("gcr.io/foo6:sha256", common.DigestSet{"": "abc"}),
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.a == tc.b; got != tc.equal {
+				t.Errorf("keys %q and %q equal = %t, want %t", tc.a, tc.b, got, tc.equal)
+			}
+		})
+	}
+}
+
 //nolint:all
 func TestFromPipelineParamsAndResults(t *testing.T) {
 	tests := []struct {
@@ -14,10 +14,62 @@ limitations under the License.
 */
 package slsaconfig
 
+import "k8s.io/apimachinery/pkg/util/sets"
+
 // SlsaConfig carries common information that is needed across different SLSA formatters.
 type SlsaConfig struct {
 	// BuilderID is the URI of the trusted build platform.
 	BuilderID string
+	// BuildType overrides the buildType of the format if set.
+	BuildType string
 	// DeepInspectionEnabled configures whether to dive into child taskruns in a pipelinerun
 	DeepInspectionEnabled bool
+	// ResultDigests configures whether to record the digests of the pipelinerun results
+	// in their byproducts.
+	ResultDigests bool
+	// ClusterName is recorded on the resolved dependencies fetched by the cluster resolver.
+	ClusterName string
+	// ChainInputAttestations configures whether to record the attestations of input
+	// artifacts as resolved dependencies.
+	ChainInputAttestations bool
+	// SandboxRuntimeClasses are the RuntimeClasses that run pods in a sandbox.
+	SandboxRuntimeClasses sets.Set[string]
+	// NetworkLabels are the labels and annotations that show a run was cut off from the network.
+	NetworkLabels sets.Set[string]
+	// AllowedMaterialPrefixes are the prefixes resolved dependencies must start with.
+	// The resolved dependencies that don't are recorded in the provenance if set.
+	AllowedMaterialPrefixes sets.Set[string]
+	// ConfigSnapshot is the configuration in force when the run is signed, recorded as a
+	// byproduct if set.
+	ConfigSnapshot *ConfigSnapshot
+	// DigestAlgorithms are the algorithms recorded in the digest sets of subjects and
+	// materials. All of them are recorded if it is empty.
+	DigestAlgorithms sets.Set[string]
+	// MaxInlineContentBytes is the size up to which the content of input artifacts is
+	// embedded in their resolved dependencies. Content isn't embedded if it is zero.
+	MaxInlineContentBytes int
+	// ComputeCompleteness configures whether to set the completeness flags of SLSA v0.2
+	// predicates from the capture features that are enabled.
+	ComputeCompleteness bool
+	// ValuesCapped is set when oversized param and result values are replaced with their
+	// digest or left out, and so aren't recorded completely.
+	ValuesCapped bool
+}
+
+// ConfigSnapshot is the configuration in force when a run is signed.
+type ConfigSnapshot struct {
+	// Digest is the hex encoded sha256 digest of Content.
+	Digest string
+	// Content is the JSON encoded chains-config data. It is only recorded if enabled.
+	Content []byte
+	// RecordContent configures whether to record Content along with Digest.
+	RecordContent bool
+}
+
+// BuildTypeOr returns the configured BuildType, or defaultType if it isn't set.
+func (s *SlsaConfig) BuildTypeOr(defaultType string) string {
+	if s == nil || s.BuildType == "" {
+		return defaultType
+	}
+	return s.BuildType
 }
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customrun
+
+import (
+	"context"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/extract"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/material"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+// BuildConfig is the custom Chains format to fill out the
+// "buildConfig" section of the slsa-provenance predicate of a customrun.
+// It records the custom task that was run, as its controller is the builder.
+type BuildConfig struct {
+	CustomRef  *v1beta1.TaskRef               `json:"customRef,omitempty"`
+	CustomSpec *v1beta1.EmbeddedCustomRunSpec `json:"customSpec,omitempty"`
+}
+
+func GenerateAttestation(ctx context.Context, cro *objects.CustomRunObject, slsaConfig *slsaconfig.SlsaConfig) (interface{}, error) {
+	subjects := extract.SubjectDigests(ctx, cro, slsaConfig)
+
+	m := metadata(cro)
+	if slsaConfig.ComputeCompleteness {
+		// The values of customruns aren't capped, and they don't run in pods of their own.
+		m.Completeness = attest.Completeness(false, nil, false)
+	}
+	att := intoto.ProvenanceStatement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: slsa.PredicateSLSAProvenance,
+			Subject:       subjects,
+		},
+		Predicate: slsa.ProvenancePredicate{
+			Builder: common.ProvenanceBuilder{
+				ID: slsaConfig.BuilderID,
+			},
+			BuildType:   slsaConfig.BuildTypeOr(cro.GetGVK()),
+			Invocation:  invocation(cro),
+			BuildConfig: BuildConfig{CustomRef: cro.Spec.CustomRef, CustomSpec: cro.Spec.CustomSpec},
+			Metadata:    m,
+			Materials:   material.CustomRunMaterials(ctx, cro),
+		},
+	}
+	return att, nil
+}
+
+// invocation describes the event that kicked off the customrun. CustomRuns don't
+// record where their custom task came from, so ConfigSource is not set.
+func invocation(cro *objects.CustomRunObject) slsa.ProvenanceInvocation {
+	return attest.Invocation(nil, cro.Spec.Params, nil, cro.GetObjectMeta())
+}
+
+// metadata adds customrun's start time, completion time and reproducibility labels
+// to the metadata section of the generated provenance.
+func metadata(cro *objects.CustomRunObject) *slsa.ProvenanceMetadata {
+	m := &slsa.ProvenanceMetadata{}
+	if cro.Status.StartTime != nil {
+		utc := cro.Status.StartTime.Time.UTC()
+		m.BuildStartedOn = &utc
+	}
+	if cro.Status.CompletionTime != nil {
+		utc := cro.Status.CompletionTime.Time.UTC()
+		m.BuildFinishedOn = &utc
+	}
+	for label, value := range cro.Labels {
+		if label == attest.ChainsReproducibleAnnotation && value == "true" {
+			m.Reproducible = true
+		}
+	}
+	return m
+}
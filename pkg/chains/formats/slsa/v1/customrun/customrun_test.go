@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customrun
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	runv1beta1 "github.com/tektoncd/pipeline/pkg/apis/run/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestGenerateAttestation(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	start := time.Unix(1617011400, 0)
+	finish := time.Unix(1617011415, 0)
+	customRef := &v1beta1.TaskRef{APIVersion: "example.dev/v1", Kind: "Builder", Name: "build"}
+	cr := &v1beta1.CustomRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "custom-build",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "builder"},
+		},
+		Spec: v1beta1.CustomRunSpec{
+			CustomRef: customRef,
+			Params: []v1beta1.Param{
+				{Name: "CHAINS-GIT_URL", Value: *v1beta1.NewStructuredValues("https://github.com/test")},
+				{Name: "CHAINS-GIT_COMMIT", Value: *v1beta1.NewStructuredValues("ab123")},
+			},
+		},
+		Status: v1beta1.CustomRunStatus{
+			CustomRunStatusFields: runv1beta1.CustomRunStatusFields{
+				StartTime:      &metav1.Time{Time: start},
+				CompletionTime: &metav1.Time{Time: finish},
+				Results: []v1beta1.CustomRunResult{
+					{Name: "IMAGE_URL", Value: "gcr.io/my/image"},
+					{Name: "IMAGE_DIGEST", Value: "sha256:827521c857fdcd4374f4da5442fbae2edb01e7fbae285c3ec15673d4c1daecb7"},
+					{Name: "ARTIFACT_INPUTS", Value: `{"uri": "git+https://github.com/other", "digest": "sha1:04b1a67ab2ec1ee7e2df1f1b4f4b7ad6ced2c0c6"}`},
+				},
+			},
+		},
+	}
+
+	got, err := GenerateAttestation(ctx, objects.NewCustomRunObject(cr), &slsaconfig.SlsaConfig{BuilderID: "test_builder"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startUTC, finishUTC := start.UTC(), finish.UTC()
+	want := intoto.ProvenanceStatement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: slsa.PredicateSLSAProvenance,
+			Subject: []intoto.Subject{{
+				Name:   "gcr.io/my/image",
+				Digest: common.DigestSet{"sha256": "827521c857fdcd4374f4da5442fbae2edb01e7fbae285c3ec15673d4c1daecb7"},
+			}},
+		},
+		Predicate: slsa.ProvenancePredicate{
+			Builder:   common.ProvenanceBuilder{ID: "test_builder"},
+			BuildType: "tekton.dev/v1beta1/CustomRun",
+			Invocation: slsa.ProvenanceInvocation{
+				Parameters: map[string]v1beta1.ParamValue{
+					"CHAINS-GIT_URL":    *v1beta1.NewStructuredValues("https://github.com/test"),
+					"CHAINS-GIT_COMMIT": *v1beta1.NewStructuredValues("ab123"),
+				},
+				Environment: map[string]map[string]string{
+					"labels": {"app": "builder"},
+				},
+			},
+			BuildConfig: BuildConfig{CustomRef: customRef},
+			Metadata: &slsa.ProvenanceMetadata{
+				BuildStartedOn:  &startUTC,
+				BuildFinishedOn: &finishUTC,
+			},
+			Materials: []common.ProvenanceMaterial{
+				{URI: "git+https://github.com/test.git", Digest: common.DigestSet{"sha1": "ab123"}},
+				{URI: "git+https://github.com/other", Digest: common.DigestSet{"sha1": "04b1a67ab2ec1ee7e2df1f1b4f4b7ad6ced2c0c6"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GenerateAttestation(): -want +got: %s", diff)
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"fmt"
 
 	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/digestset"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/v1/customrun"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/v1/pipelinerun"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/v1/taskrun"
 	"github.com/tektoncd/chains/pkg/chains/objects"
@@ -46,7 +48,13 @@ func NewFormatter(cfg config.Config) (formats.Payloader, error) {
 	return &InTotoIte6{
 		slsaConfig: &slsaconfig.SlsaConfig{
 			BuilderID:             cfg.Builder.ID,
+			BuildType:             cfg.Builder.BuildType,
 			DeepInspectionEnabled: cfg.Artifacts.PipelineRuns.DeepInspectionEnabled,
+			DigestAlgorithms:      cfg.Provenance.DigestAlgorithms,
+			SandboxRuntimeClasses: cfg.Isolation.SandboxRuntimeClasses,
+			NetworkLabels:         cfg.Isolation.NetworkLabels,
+			ComputeCompleteness:   cfg.Provenance.ComputeCompleteness,
+			ValuesCapped:          cfg.Provenance.MaxValueKB > 0,
 		},
 	}, nil
 }
@@ -56,14 +64,22 @@ func (i *InTotoIte6) Wrap() bool {
 }
 
 func (i *InTotoIte6) CreatePayload(ctx context.Context, obj interface{}) (interface{}, error) {
+	var att interface{}
+	var err error
 	switch v := obj.(type) {
 	case *objects.TaskRunObject:
-		return taskrun.GenerateAttestation(ctx, v, i.slsaConfig)
+		att, err = taskrun.GenerateAttestation(ctx, v, i.slsaConfig)
 	case *objects.PipelineRunObject:
-		return pipelinerun.GenerateAttestation(ctx, v, i.slsaConfig)
+		att, err = pipelinerun.GenerateAttestation(ctx, v, i.slsaConfig)
+	case *objects.CustomRunObject:
+		att, err = customrun.GenerateAttestation(ctx, v, i.slsaConfig)
 	default:
 		return nil, fmt.Errorf("intoto does not support type: %s", v)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return digestset.Statement(att, i.slsaConfig.DigestAlgorithms), nil
 }
 
 func (i *InTotoIte6) Type() config.PayloadType {
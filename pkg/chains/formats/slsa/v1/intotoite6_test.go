@@ -783,3 +783,28 @@ func TestCorrectPayloadType(t *testing.T) {
 		t.Errorf("Invalid type returned: %s", i.Type())
 	}
 }
+
+func TestBuildTypeOverride(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	tr, err := objectloader.TaskRunFromFile("../testdata/taskrun1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{
+		Builder: config.BuilderConfig{
+			ID:        "test_builder-1",
+			BuildType: "https://example.com/tekton/build/v1",
+		},
+	}
+	f, err := NewFormatter(cfg)
+	if err != nil {
+		t.Fatalf("Error creating formatter: %s", err)
+	}
+	got, err := f.CreatePayload(ctx, objects.NewTaskRunObject(tr))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if bt := got.(in_toto.ProvenanceStatement).Predicate.BuildType; bt != "https://example.com/tekton/build/v1" {
+		t.Errorf("buildType = %q, want the configured buildType", bt)
+	}
+}
@@ -20,15 +20,16 @@ import (
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	"github.com/tektoncd/chains/pkg/chains/environment"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/extract"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/isolation"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/material"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"knative.dev/pkg/apis"
-	"knative.dev/pkg/logging"
 )
 
 type BuildConfig struct {
@@ -45,6 +46,9 @@ type TaskAttestation struct {
 	Steps      []attest.StepAttestation  `json:"steps,omitempty"`
 	Invocation slsa.ProvenanceInvocation `json:"invocation,omitempty"`
 	Results    []v1beta1.TaskRunResult   `json:"results,omitempty"`
+	// Iteration is the index of the iteration of the custom task of the pipeline task
+	// the TaskRun ran for, if it did.
+	Iteration *int `json:"iteration,omitempty"`
 }
 
 func GenerateAttestation(ctx context.Context, pro *objects.PipelineRunObject, slsaConfig *slsaconfig.SlsaConfig) (interface{}, error) {
@@ -54,6 +58,19 @@ func GenerateAttestation(ctx context.Context, pro *objects.PipelineRunObject, sl
 	if err != nil {
 		return nil, err
 	}
+	platform := environment.Collect(ctx, pro.GetTaskRuns()...)
+	i := invocation(pro)
+	if platform != nil {
+		i.Environment = attest.WithPlatform(i.Environment, platform)
+	}
+	m := metadata(pro)
+	if slsaConfig.ComputeCompleteness {
+		annotations := []map[string]string{pro.Annotations}
+		for _, tr := range pro.GetTaskRuns() {
+			annotations = append(annotations, tr.Annotations)
+		}
+		m.Completeness = attest.Completeness(slsaConfig.ValuesCapped || attest.Redacts(annotations...), platform, isolation.PipelineRun(ctx, pro, slsaConfig).Hermetic)
+	}
 	att := intoto.ProvenanceStatement{
 		StatementHeader: intoto.StatementHeader{
 			Type:          intoto.StatementInTotoV01,
@@ -64,10 +81,10 @@ func GenerateAttestation(ctx context.Context, pro *objects.PipelineRunObject, sl
 			Builder: common.ProvenanceBuilder{
 				ID: slsaConfig.BuilderID,
 			},
-			BuildType:   pro.GetGVK(),
-			Invocation:  invocation(pro),
+			BuildType:   slsaConfig.BuildTypeOr(pro.GetGVK()),
+			Invocation:  i,
 			BuildConfig: buildConfig(ctx, pro),
-			Metadata:    metadata(pro),
+			Metadata:    m,
 			Materials:   mat,
 		},
 	}
@@ -87,25 +104,16 @@ func invocation(pro *objects.PipelineRunObject) slsa.ProvenanceInvocation {
 }
 
 func buildConfig(ctx context.Context, pro *objects.PipelineRunObject) BuildConfig {
-	logger := logging.FromContext(ctx)
 	tasks := []TaskAttestation{}
 
 	pSpec := pro.Status.PipelineSpec
 	if pSpec == nil {
 		return BuildConfig{}
 	}
-	pipelineTasks := append(pSpec.Tasks, pSpec.Finally...)
 
 	var last string
-	for i, t := range pipelineTasks {
-		tr := pro.GetTaskRunFromTask(t.Name)
-
-		// Ignore Tasks that did not execute during the PipelineRun.
-		if tr == nil || tr.Status.CompletionTime == nil {
-			logger.Infof("taskrun status not found for task %s", t.Name)
-			continue
-		}
-		steps := []attest.StepAttestation{}
+	_ = pro.ExecutedTasks(ctx, func(t *v1beta1.PipelineTask, tr *v1beta1.TaskRun, finally bool) error {
+		steps := make([]attest.StepAttestation, 0, len(tr.Status.Steps))
 		for i, stepState := range tr.Status.Steps {
 			step := tr.Status.TaskSpec.Steps[i]
 			steps = append(steps, attest.Step(&step, &stepState))
@@ -114,7 +122,7 @@ func buildConfig(ctx context.Context, pro *objects.PipelineRunObject) BuildConfi
 
 		// Establish task order by retrieving all task's referenced
 		// in the "when" and "params" fields
-		refs := v1beta1.PipelineTaskResultRefs(&t)
+		refs := v1beta1.PipelineTaskResultRefs(t)
 		for _, ref := range refs {
 
 			// Ensure task doesn't already exist in after
@@ -131,7 +139,7 @@ func buildConfig(ctx context.Context, pro *objects.PipelineRunObject) BuildConfi
 
 		// tr is a finally task without an explicit runAfter value. It must have executed
 		// after the last non-finally task, if any non-finally tasks were executed.
-		if len(after) == 0 && i >= len(pSpec.Tasks) && last != "" {
+		if len(after) == 0 && finally && last != "" {
 			after = append(after, last)
 		}
 		params := tr.Spec.Params
@@ -162,12 +170,21 @@ func buildConfig(ctx context.Context, pro *objects.PipelineRunObject) BuildConfi
 		if t.TaskRef != nil {
 			task.Ref = *t.TaskRef
 		}
+		if i, ok := objects.Iteration(tr); ok {
+			// The TaskRun ran a task of the custom task, whose ref is more useful than
+			// that of the custom task.
+			task.Iteration = &i
+			if tr.Spec.TaskRef != nil {
+				task.Ref = *tr.Spec.TaskRef
+			}
+		}
 
 		tasks = append(tasks, task)
-		if i < len(pSpec.Tasks) {
+		if !finally {
 			last = task.Name
 		}
-	}
+		return nil
+	})
 	return BuildConfig{Tasks: tasks}
 }
 
@@ -31,6 +31,7 @@ import (
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/internal/objectloader"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/selection"
 	logtesting "knative.dev/pkg/logging/testing"
 )
@@ -488,3 +489,37 @@ func TestSubjectDigests(t *testing.T) {
 		t.Errorf("Differences in subjects: -want +got: %s", diff)
 	}
 }
+
+func TestBuildConfigIterations(t *testing.T) {
+	pr := &v1beta1.PipelineRun{Status: v1beta1.PipelineRunStatus{PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+		PipelineSpec: &v1beta1.PipelineSpec{Tasks: []v1beta1.PipelineTask{{
+			Name:    "loop",
+			TaskRef: &v1beta1.TaskRef{APIVersion: "custom.tekton.dev/v1alpha1", Kind: "PipelineLoop", Name: "build-each"},
+		}}},
+	}}}
+	p := objects.NewPipelineRunObject(pr)
+	start, finish := metav1.NewTime(e1BuildStart), metav1.NewTime(e1BuildFinished)
+	for _, i := range []string{"0", "1"} {
+		p.AppendIterationTaskRun("loop", &v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "build-each-" + i, Labels: map[string]string{
+				"custom.tekton.dev/pipelineLoopRun":       "loop-run",
+				"custom.tekton.dev/pipelineLoopIteration": i,
+			}},
+			Spec: v1beta1.TaskRunSpec{TaskRef: &v1beta1.TaskRef{Name: "build"}},
+			Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				StartTime:      &start,
+				CompletionTime: &finish,
+			}},
+		})
+	}
+
+	got := buildConfig(logtesting.TestContextWithLogger(t), p)
+	if len(got.Tasks) != 2 {
+		t.Fatalf("buildConfig() has %d tasks, want one for each iteration", len(got.Tasks))
+	}
+	for i, task := range got.Tasks {
+		if task.Name != "loop" || task.Iteration == nil || *task.Iteration != i || task.Ref.Name != "build" {
+			t.Errorf("task %d = %+v, want the build task of iteration %d of loop", i, task, i)
+		}
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package taskrun
 
 import (
+	"context"
 	"reflect"
 	"strings"
 	"testing"
@@ -29,12 +30,16 @@ import (
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/tektoncd/chains/internal/backport"
 	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/environment"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/extract"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/compare"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"github.com/tektoncd/pipeline/pkg/apis/resource/v1alpha1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	logtesting "knative.dev/pkg/logging/testing"
 	"sigs.k8s.io/yaml"
 )
@@ -114,6 +119,7 @@ metadata:
     ann2: ann-two
     kubectl.kubernetes.io/last-applied-configuration: ignored
     chains.tekton.dev/any: "ignored"
+    chains.tekton.dev/build-group: widgets-nightly
   labels:
     label1: label-one
     label2: label-two
@@ -179,8 +185,9 @@ status:
 		},
 		Environment: map[string]map[string]string{
 			"annotations": {
-				"ann1": "ann-one",
-				"ann2": "ann-two",
+				"ann1":                          "ann-one",
+				"ann2":                          "ann-two",
+				"chains.tekton.dev/build-group": "widgets-nightly",
 			},
 			"labels": {
 				"label1": "label-one",
@@ -340,3 +347,82 @@ func TestGetSubjectDigests(t *testing.T) {
 		t.Errorf("Wrong subjects extracted, diff=%s", d)
 	}
 }
+
+type fakeCollector struct{}
+
+func (fakeCollector) KubernetesVersion(context.Context) (string, error) {
+	return "v1.27.3", nil
+}
+
+func (fakeCollector) Node(_ context.Context, _, pod string) (environment.Node, error) {
+	return environment.Node{Name: "node-" + pod, OperatingSystem: "linux", Architecture: "amd64", ContainerRuntime: "containerd://1.7.2"}, nil
+}
+
+func TestGenerateAttestationCompleteness(t *testing.T) {
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: v1.ObjectMeta{
+			Labels: map[string]string{"network.example.com/egress": "deny"},
+		},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{PodName: "build-pod"},
+		},
+	}
+	tests := []struct {
+		name       string
+		slsaConfig *slsaconfig.SlsaConfig
+		collect    bool
+		redact     string
+		want       slsa.ProvenanceComplete
+	}{{
+		name:       "static",
+		slsaConfig: &slsaconfig.SlsaConfig{},
+		collect:    true,
+	}, {
+		name:       "computed",
+		slsaConfig: &slsaconfig.SlsaConfig{ComputeCompleteness: true},
+		want:       slsa.ProvenanceComplete{Parameters: true},
+	}, {
+		name:       "capped values",
+		slsaConfig: &slsaconfig.SlsaConfig{ComputeCompleteness: true, ValuesCapped: true},
+	}, {
+		name:       "redacted values",
+		slsaConfig: &slsaconfig.SlsaConfig{ComputeCompleteness: true},
+		redact:     "token",
+	}, {
+		name:       "recorded environment",
+		slsaConfig: &slsaconfig.SlsaConfig{ComputeCompleteness: true},
+		collect:    true,
+		want:       slsa.ProvenanceComplete{Parameters: true, Environment: true},
+	}, {
+		name: "hermetic",
+		slsaConfig: &slsaconfig.SlsaConfig{
+			ComputeCompleteness: true,
+			NetworkLabels:       sets.New[string]("network.example.com/egress=deny"),
+		},
+		want: slsa.ProvenanceComplete{Parameters: true, Materials: true},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := logtesting.TestContextWithLogger(t)
+			if tc.collect {
+				ctx = environment.WithCollector(ctx, fakeCollector{})
+			}
+			run := tr.DeepCopy()
+			if tc.redact != "" {
+				run.Annotations = map[string]string{attest.RedactAnnotation: tc.redact}
+			}
+			got, err := GenerateAttestation(ctx, objects.NewTaskRunObject(run), tc.slsaConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			predicate := got.(in_toto.ProvenanceStatement).Predicate
+			if diff := cmp.Diff(tc.want, predicate.Metadata.Completeness); diff != "" {
+				t.Errorf("completeness: -want +got: %s", diff)
+			}
+			env, _ := predicate.Invocation.Environment.(map[string]interface{})
+			if _, ok := env["platform"]; ok != tc.collect {
+				t.Errorf("expected the platform to be recorded %t, got environment %v", tc.collect, predicate.Invocation.Environment)
+			}
+		})
+	}
+}
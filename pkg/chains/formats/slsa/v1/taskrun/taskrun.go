@@ -19,8 +19,10 @@ import (
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	"github.com/tektoncd/chains/pkg/chains/environment"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/extract"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/isolation"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/material"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/objects"
@@ -34,6 +36,15 @@ func GenerateAttestation(ctx context.Context, tro *objects.TaskRunObject, slsaCo
 	if err != nil {
 		return nil, err
 	}
+	platform := environment.Collect(ctx, tro.TaskRun)
+	i := invocation(tro)
+	if platform != nil {
+		i.Environment = attest.WithPlatform(i.Environment, platform)
+	}
+	m := Metadata(tro)
+	if slsaConfig.ComputeCompleteness {
+		m.Completeness = attest.Completeness(slsaConfig.ValuesCapped || attest.Redacts(tro.Annotations), platform, isolation.TaskRun(tro.TaskRun, slsaConfig).Hermetic)
+	}
 	att := intoto.ProvenanceStatement{
 		StatementHeader: intoto.StatementHeader{
 			Type:          intoto.StatementInTotoV01,
@@ -44,10 +55,10 @@ func GenerateAttestation(ctx context.Context, tro *objects.TaskRunObject, slsaCo
 			Builder: common.ProvenanceBuilder{
 				ID: slsaConfig.BuilderID,
 			},
-			BuildType:   tro.GetGVK(),
-			Invocation:  invocation(tro),
+			BuildType:   slsaConfig.BuildTypeOr(tro.GetGVK()),
+			Invocation:  i,
 			BuildConfig: buildConfig(tro),
-			Metadata:    Metadata(tro),
+			Metadata:    m,
 			Materials:   mat,
 		},
 	}
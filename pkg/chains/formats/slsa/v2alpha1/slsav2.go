@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/v2alpha1/taskrun"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/config"
@@ -35,12 +36,21 @@ func init() {
 }
 
 type Slsa struct {
-	builderID string
+	builderID  string
+	buildType  string
+	slsaConfig *slsaconfig.SlsaConfig
 }
 
 func NewFormatter(cfg config.Config) (formats.Payloader, error) {
 	return &Slsa{
 		builderID: cfg.Builder.ID,
+		buildType: cfg.Builder.BuildType,
+		slsaConfig: &slsaconfig.SlsaConfig{
+			SandboxRuntimeClasses: cfg.Isolation.SandboxRuntimeClasses,
+			NetworkLabels:         cfg.Isolation.NetworkLabels,
+			ComputeCompleteness:   cfg.Provenance.ComputeCompleteness,
+			ValuesCapped:          cfg.Provenance.MaxValueKB > 0,
+		},
 	}, nil
 }
 
@@ -51,7 +61,7 @@ func (s *Slsa) Wrap() bool {
 func (s *Slsa) CreatePayload(ctx context.Context, obj interface{}) (interface{}, error) {
 	switch v := obj.(type) {
 	case *objects.TaskRunObject:
-		return taskrun.GenerateAttestation(ctx, s.builderID, s.Type(), v)
+		return taskrun.GenerateAttestation(ctx, s.builderID, s.buildType, s.Type(), v, s.slsaConfig)
 	default:
 		return nil, fmt.Errorf("intoto does not support type: %s", v)
 	}
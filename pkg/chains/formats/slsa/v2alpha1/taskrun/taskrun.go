@@ -21,8 +21,12 @@ import (
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	"github.com/tektoncd/chains/pkg/chains/environment"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/extract"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/isolation"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/material"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	slsav1 "github.com/tektoncd/chains/pkg/chains/formats/slsa/v1/taskrun"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/config"
@@ -37,12 +41,27 @@ type BuildConfig struct {
 	TaskRunResults []v1beta1.TaskRunResult `json:"taskRunResults"`
 }
 
-func GenerateAttestation(ctx context.Context, builderID string, payloadType config.PayloadType, tro *objects.TaskRunObject) (interface{}, error) {
+// GenerateAttestation generates a provenance statement for a taskrun. buildType overrides
+// the buildType of the format if it isn't empty, and slsaConfig configures how its
+// completeness is set.
+func GenerateAttestation(ctx context.Context, builderID, buildType string, payloadType config.PayloadType, tro *objects.TaskRunObject, slsaConfig *slsaconfig.SlsaConfig) (interface{}, error) {
+	if buildType == "" {
+		buildType = fmt.Sprintf("https://chains.tekton.dev/format/%v/type/%s", payloadType, tro.GetGVK())
+	}
 	subjects := extract.SubjectDigests(ctx, tro, nil)
 	mat, err := material.TaskMaterials(ctx, tro)
 	if err != nil {
 		return nil, err
 	}
+	platform := environment.Collect(ctx, tro.TaskRun)
+	i := invocation(tro)
+	if platform != nil {
+		i.Environment = attest.WithPlatform(i.Environment, platform)
+	}
+	m := metadata(tro)
+	if slsaConfig.ComputeCompleteness {
+		m.Completeness = attest.Completeness(slsaConfig.ValuesCapped || attest.Redacts(tro.Annotations), platform, isolation.TaskRun(tro.TaskRun, slsaConfig).Hermetic)
+	}
 	att := intoto.ProvenanceStatement{
 		StatementHeader: intoto.StatementHeader{
 			Type:          intoto.StatementInTotoV01,
@@ -53,10 +72,10 @@ func GenerateAttestation(ctx context.Context, builderID string, payloadType conf
 			Builder: common.ProvenanceBuilder{
 				ID: builderID,
 			},
-			BuildType:   fmt.Sprintf("https://chains.tekton.dev/format/%v/type/%s", payloadType, tro.GetGVK()),
-			Invocation:  invocation(tro),
+			BuildType:   buildType,
+			Invocation:  i,
 			BuildConfig: BuildConfig{TaskSpec: tro.Status.TaskSpec, TaskRunResults: tro.Status.TaskRunResults},
-			Metadata:    metadata(tro),
+			Metadata:    m,
 			Materials:   mat,
 		},
 	}
@@ -15,19 +15,30 @@ package pipelinerun
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+	"github.com/tektoncd/chains/pkg/chains/environment"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/extract"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/isolation"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	resolveddependencies "github.com/tektoncd/chains/pkg/chains/formats/slsa/v2alpha2/internal/resolved_dependencies"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 )
 
 const (
-	pipelineRunResults = "pipelineRunResults/%s"
+	pipelineRunResults   = "pipelineRunResults/%s"
+	matrixSubjects       = "matrixSubjects/%s"
+	environmentByproduct = "environment"
+	configByproduct      = "chainsConfig"
+	// buildType is the buildType of the provenance unless it is overridden.
+	buildType = "https://tekton.dev/chains/v2/slsa"
 	// JsonMediaType is the media type of json encoded content used in resource descriptors
 	JsonMediaType = "application/json"
 )
@@ -38,7 +49,7 @@ func GenerateAttestation(ctx context.Context, pro *objects.PipelineRunObject, sl
 	if err != nil {
 		return nil, err
 	}
-	bp, err := byproducts(pro)
+	bp, err := byproducts(ctx, pro, slsaconfig)
 	if err != nil {
 		return nil, err
 	}
@@ -50,9 +61,9 @@ func GenerateAttestation(ctx context.Context, pro *objects.PipelineRunObject, sl
 		},
 		Predicate: slsa.ProvenancePredicate{
 			BuildDefinition: slsa.ProvenanceBuildDefinition{
-				BuildType:            "https://tekton.dev/chains/v2/slsa",
+				BuildType:            slsaconfig.BuildTypeOr(buildType),
 				ExternalParameters:   externalParameters(pro),
-				InternalParameters:   internalParameters(pro),
+				InternalParameters:   internalParameters(ctx, pro, slsaconfig, rd),
 				ResolvedDependencies: rd,
 			},
 			RunDetails: slsa.ProvenanceRunDetails{
@@ -83,12 +94,20 @@ func metadata(pro *objects.PipelineRunObject) slsa.BuildMetadata {
 }
 
 // internalParameters adds the tekton feature flags that were enabled
-// for the pipelinerun.
-func internalParameters(pro *objects.PipelineRunObject) map[string]any {
+// for the pipelinerun, how isolated the pods of its taskruns were if isolation
+// signals are configured, and the resolved dependencies that aren't allowed if they
+// are recorded in provenance.
+func internalParameters(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig, rd []slsa.ResourceDescriptor) map[string]any {
 	internalParams := make(map[string]any)
 	if pro.Status.Provenance != nil && pro.Status.Provenance.FeatureFlags != nil {
 		internalParams["tekton-pipelines-feature-flags"] = *pro.Status.Provenance.FeatureFlags
 	}
+	if isolation.Enabled(slsaconfig) {
+		internalParams["isolation"] = isolation.PipelineRun(ctx, pro, slsaconfig)
+	}
+	if disallowed := resolveddependencies.Disallowed(rd, slsaconfig); len(disallowed) > 0 {
+		internalParams["disallowedMaterials"] = disallowed
+	}
 	return internalParams
 }
 
@@ -118,12 +137,22 @@ func externalParameters(pro *objects.PipelineRunObject) map[string]any {
 		}
 		externalParams["buildConfigSource"] = buildConfigSource
 	}
+	if trigger := attest.Trigger(pro.GetObjectMeta()); trigger != nil {
+		externalParams["trigger"] = trigger
+	}
+	if group := attest.BuildGroup(pro.GetObjectMeta()); group != "" {
+		externalParams["buildGroup"] = group
+	}
 	externalParams["runSpec"] = pro.Spec
 	return externalParams
 }
 
-// byproducts contains the pipelineRunResults
-func byproducts(pro *objects.PipelineRunObject) ([]slsa.ResourceDescriptor, error) {
+// byproducts contains the pipelineRunResults, with their digests if enabled, the
+// subjects produced by matrixed
+// pipeline tasks annotated with the matrix params of the instance that produced them,
+// and the environment the taskruns executed in and the configuration the pipelinerun was
+// signed in if they are recorded.
+func byproducts(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig) ([]slsa.ResourceDescriptor, error) {
 	byProd := []slsa.ResourceDescriptor{}
 	for _, key := range pro.Status.PipelineResults {
 		content, err := json.Marshal(key.Value)
@@ -135,6 +164,44 @@ func byproducts(pro *objects.PipelineRunObject) ([]slsa.ResourceDescriptor, erro
 			Content:   content,
 			MediaType: JsonMediaType,
 		}
+		if slsaconfig.ResultDigests {
+			h := sha256.Sum256(content)
+			bp.Digest = common.DigestSet{"sha256": hex.EncodeToString(h[:])}
+		}
+		byProd = append(byProd, bp)
+	}
+	for _, s := range extract.MatrixSubjects(ctx, pro, slsaconfig) {
+		annotations := make(map[string]interface{}, len(s.Params))
+		for name, value := range s.Params {
+			annotations[name] = value
+		}
+		byProd = append(byProd, slsa.ResourceDescriptor{
+			Name:        fmt.Sprintf(matrixSubjects, s.PipelineTask),
+			URI:         s.Name,
+			Digest:      s.Digest,
+			Annotations: annotations,
+		})
+	}
+	if env := environment.Collect(ctx, pro.GetTaskRuns()...); env != nil {
+		content, err := json.Marshal(env)
+		if err != nil {
+			return nil, err
+		}
+		byProd = append(byProd, slsa.ResourceDescriptor{
+			Name:      environmentByproduct,
+			Content:   content,
+			MediaType: JsonMediaType,
+		})
+	}
+	if s := slsaconfig.ConfigSnapshot; s != nil {
+		bp := slsa.ResourceDescriptor{
+			Name:   configByproduct,
+			Digest: common.DigestSet{"sha256": s.Digest},
+		}
+		if s.RecordContent {
+			bp.Content = s.Content
+			bp.MediaType = JsonMediaType
+		}
 		byProd = append(byProd, bp)
 	}
 	return byProd, nil
@@ -17,7 +17,10 @@ limitations under the License.
 package pipelinerun
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -153,6 +156,35 @@ func TestExternalParameters(t *testing.T) {
 	}
 }
 
+func TestExternalParametersTrigger(t *testing.T) {
+	pr := &v1beta1.PipelineRun{
+		ObjectMeta: v1.ObjectMeta{
+			Labels: map[string]string{
+				"triggers.tekton.dev/eventlistener":    "github-listener",
+				"triggers.tekton.dev/trigger":          "github-push",
+				"triggers.tekton.dev/triggers-eventid": "6d6f0f5e-1c3a-4b8e-9c1e-6f2c0b1f3a2d",
+			},
+			Annotations: map[string]string{
+				"chains.tekton.dev/event-body": `{"ref":"refs/heads/main"}`,
+			},
+		},
+	}
+
+	want := map[string]any{
+		"trigger": map[string]string{
+			"eventListener":   "github-listener",
+			"trigger":         "github-push",
+			"eventID":         "6d6f0f5e-1c3a-4b8e-9c1e-6f2c0b1f3a2d",
+			"eventBodyDigest": "sha256:" + fmt.Sprintf("%x", sha256.Sum256([]byte(`{"ref":"refs/heads/main"}`))),
+		},
+		"runSpec": pr.Spec,
+	}
+	got := externalParameters(objects.NewPipelineRunObject(pr))
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("externalParameters (-want, +got):\n%s", d)
+	}
+}
+
 func TestInternalParameters(t *testing.T) {
 	pr := &v1beta1.PipelineRun{
 		Status: v1beta1.PipelineRunStatus{
@@ -183,7 +215,7 @@ func TestInternalParameters(t *testing.T) {
 			MaxResultSize:                    4096,
 		},
 	}
-	got := internalParameters(objects.NewPipelineRunObject(pr))
+	got := internalParameters(context.Background(), objects.NewPipelineRunObject(pr), &slsaconfig.SlsaConfig{}, nil)
 	if d := cmp.Diff(want, got); d != "" {
 		t.Fatalf("internalParameters (-want, +got):\n%s", d)
 	}
@@ -215,7 +247,86 @@ func TestByProducts(t *testing.T) {
 			MediaType: JsonMediaType,
 		},
 	}
-	got, err := byproducts(objects.NewPipelineRunObject(pr))
+	got, err := byproducts(logtesting.TestContextWithLogger(t), objects.NewPipelineRunObject(pr), &slsaconfig.SlsaConfig{})
+	if err != nil {
+		t.Fatalf("Could not extract byproducts: %s", err)
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("byproducts (-want, +got):\n%s", d)
+	}
+}
+
+func TestByProductsResultDigests(t *testing.T) {
+	pr := &v1beta1.PipelineRun{
+		Status: v1beta1.PipelineRunStatus{
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				PipelineResults: []v1beta1.PipelineRunResult{
+					{
+						Name:  "version",
+						Value: v1beta1.ResultValue{Type: "string", StringVal: "result-value"},
+					},
+				},
+			},
+		},
+	}
+
+	want := []slsa.ResourceDescriptor{
+		{
+			Name:      "pipelineRunResults/version",
+			Digest:    common.DigestSet{"sha256": "7fa904cd68fe0241c282d2c1aca043b72aef2385241c3d3914abe4dacce37d65"},
+			Content:   []byte(`"result-value"`),
+			MediaType: JsonMediaType,
+		},
+	}
+	got, err := byproducts(logtesting.TestContextWithLogger(t), objects.NewPipelineRunObject(pr), &slsaconfig.SlsaConfig{ResultDigests: true})
+	if err != nil {
+		t.Fatalf("Could not extract byproducts: %s", err)
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("byproducts (-want, +got):\n%s", d)
+	}
+}
+
+func TestByProductsMatrixSubjects(t *testing.T) {
+	pr := &v1beta1.PipelineRun{
+		Status: v1beta1.PipelineRunStatus{
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				PipelineSpec: &v1beta1.PipelineSpec{
+					Tasks: []v1beta1.PipelineTask{{
+						Name: "build",
+						Matrix: &v1beta1.Matrix{Params: v1beta1.Params{
+							{Name: "platform", Value: *v1beta1.NewStructuredValues("linux/amd64")},
+						}},
+					}},
+				},
+			},
+		},
+	}
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: v1.ObjectMeta{Labels: map[string]string{objects.PipelineTaskLabel: "build"}},
+		Spec: v1beta1.TaskRunSpec{
+			Params: v1beta1.Params{{Name: "platform", Value: *v1beta1.NewStructuredValues("linux/amd64")}},
+		},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				CompletionTime: &v1.Time{Time: time.Unix(1617011415, 0)},
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{Name: "IMAGE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/my/image")},
+					{Name: "IMAGE_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:827521c857fdcd4374f4da5442fbae2edb01e7fbae285c3ec15673d4c1daecb7")},
+				},
+			},
+		},
+	}
+	pro := objects.NewPipelineRunObject(pr)
+	pro.AppendTaskRun(tr)
+
+	want := []slsa.ResourceDescriptor{{
+		Name:        "matrixSubjects/build",
+		URI:         "gcr.io/my/image",
+		Digest:      common.DigestSet{"sha256": "827521c857fdcd4374f4da5442fbae2edb01e7fbae285c3ec15673d4c1daecb7"},
+		Annotations: map[string]interface{}{"platform": *v1beta1.NewStructuredValues("linux/amd64")},
+	}}
+	got, err := byproducts(logtesting.TestContextWithLogger(t), pro, &slsaconfig.SlsaConfig{DeepInspectionEnabled: true})
 	if err != nil {
 		t.Fatalf("Could not extract byproducts: %s", err)
 	}
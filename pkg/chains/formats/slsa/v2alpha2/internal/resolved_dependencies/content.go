@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolveddependencies
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	v1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/material"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"knative.dev/pkg/logging"
+)
+
+// contentHashes are the hashes the digests of inlined content are verified with.
+var contentHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// inlineContent embeds in the type-hinted resolved dependencies of rds the content the
+// structured ARTIFACT_INPUTS results of objs provide for them, so that small inputs like
+// policy files or lockfiles can be reviewed from the attestation alone. Content larger
+// than max bytes or that doesn't match the digests of its input is left out.
+func inlineContent(ctx context.Context, rds []v1.ResourceDescriptor, max int, objs ...objects.TektonObject) {
+	if max <= 0 {
+		return
+	}
+	logger := logging.FromContext(ctx)
+
+	contents := map[string][]byte{}
+	var key []byte
+	for _, obj := range objs {
+		for _, s := range artifacts.ExtractStructuredTargetFromResults(ctx, obj, artifacts.ArtifactsInputsResultName) {
+			if s.Content == "" {
+				continue
+			}
+			digests, err := artifacts.ParseDigests(s.Digest)
+			if err != nil {
+				continue
+			}
+			content, err := decodeContent(s.Content, digests, max)
+			if err != nil {
+				logger.Warnf("Not embedding the content of %s: %v", s.URI, err)
+				continue
+			}
+			key = material.AppendKey(key[:0], s.URI, digests)
+			contents[string(key)] = content
+		}
+	}
+	if len(contents) == 0 {
+		return
+	}
+	for i := range rds {
+		if rds[i].Name != inputResultName {
+			continue
+		}
+		key = material.AppendKey(key[:0], rds[i].URI, rds[i].Digest)
+		if content, ok := contents[string(key)]; ok {
+			rds[i].Content = content
+		}
+	}
+}
+
+// decodeContent returns the base64 encoded content, if it is at most max bytes and
+// matches every digest of digests.
+func decodeContent(encoded string, digests common.DigestSet, max int) ([]byte, error) {
+	if base64.StdEncoding.DecodedLen(len(encoded)) > max+2 {
+		return nil, fmt.Errorf("content exceeds the maximum of %d bytes", max)
+	}
+	content, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("content is not base64 encoded: %w", err)
+	}
+	if len(content) > max {
+		return nil, fmt.Errorf("content of %d bytes exceeds the maximum of %d bytes", len(content), max)
+	}
+	for algorithm, want := range digests {
+		newHash, ok := contentHashes[algorithm]
+		if !ok {
+			return nil, fmt.Errorf("unsupported digest algorithm %s", algorithm)
+		}
+		h := newHash()
+		h.Write(content)
+		if got := hex.EncodeToString(h.Sum(nil)); got != want {
+			return nil, fmt.Errorf("content has %s digest %s, want %s", algorithm, got, want)
+		}
+	}
+	return content, nil
+}
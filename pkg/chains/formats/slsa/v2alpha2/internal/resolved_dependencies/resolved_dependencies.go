@@ -18,15 +18,17 @@ package resolveddependencies
 
 import (
 	"context"
-	"encoding/json"
+	"reflect"
 
 	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	v1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+	"github.com/tektoncd/chains/pkg/chains/allowlist"
+	"github.com/tektoncd/chains/pkg/chains/chaining"
+	"github.com/tektoncd/chains/pkg/chains/clustersource"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/material"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/objects"
-	"go.uber.org/zap"
-	"knative.dev/pkg/logging"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 )
 
 const (
@@ -40,19 +42,27 @@ const (
 	inputResultName = "inputs/result"
 	// pipelineResourceName is the name of the resolved dependency of pipeline resource.
 	pipelineResourceName = "pipelineResource"
+	// inputAttestationName is the name of the resolved dependency of an attestation of an input artifact.
+	inputAttestationName = "inputs/attestation"
+	// attestationSubjectAnnotation is the annotation of an input attestation holding the artifact it is about.
+	attestationSubjectAnnotation = "subject"
+	// retryAttemptAnnotation is the annotation of the resolved dependencies of a failed attempt of a
+	// retried TaskRun holding the index of the attempt in its retriesStatus.
+	retryAttemptAnnotation = "retryAttempt"
 )
 
 // TaskRun constructs `predicate.resolvedDependencies` section by collecting all the artifacts that influence a taskrun such as source code repo and step&sidecar base images.
-func TaskRun(ctx context.Context, tro *objects.TaskRunObject) ([]v1.ResourceDescriptor, error) {
+func TaskRun(ctx context.Context, tro *objects.TaskRunObject, slsaconfig *slsaconfig.SlsaConfig) ([]v1.ResourceDescriptor, error) {
 	var resolvedDependencies []v1.ResourceDescriptor
 	var err error
 
 	// add top level task config
 	if p := tro.Status.Provenance; p != nil && p.RefSource != nil {
 		rd := v1.ResourceDescriptor{
-			Name:   taskConfigName,
-			URI:    p.RefSource.URI,
-			Digest: p.RefSource.Digest,
+			Name:        taskConfigName,
+			URI:         p.RefSource.URI,
+			Digest:      p.RefSource.Digest,
+			Annotations: clustersource.Annotations(ctx, slsaconfig.ClusterName, p.RefSource.URI, p.RefSource.Digest),
 		}
 		resolvedDependencies = append(resolvedDependencies, rd)
 	}
@@ -72,9 +82,21 @@ func TaskRun(ctx context.Context, tro *objects.TaskRunObject) ([]v1.ResourceDesc
 	mats = append(mats, sidecarMaterials...)
 	resolvedDependencies = append(resolvedDependencies, convertMaterialsToResolvedDependencies(mats, "")...)
 
+	// add the task config and images of failed attempts
+	rds, err := fromRetriedAttempts(ctx, tro.TaskRun, slsaconfig, taskConfigName)
+	if err != nil {
+		return nil, err
+	}
+	resolvedDependencies = append(resolvedDependencies, rds...)
+
 	mats = material.FromTaskParamsAndResults(ctx, tro)
 	// convert materials to resolved dependencies
 	resolvedDependencies = append(resolvedDependencies, convertMaterialsToResolvedDependencies(mats, inputResultName)...)
+	if slsaconfig.ChainInputAttestations {
+		resolvedDependencies = append(resolvedDependencies, inputAttestations(ctx, tro, mats)...)
+	}
+
+	inlineContent(ctx, resolvedDependencies, slsaconfig.MaxInlineContentBytes, tro)
 
 	// add task resources
 	mats = material.FromTaskResources(ctx, tro)
@@ -82,31 +104,27 @@ func TaskRun(ctx context.Context, tro *objects.TaskRunObject) ([]v1.ResourceDesc
 	resolvedDependencies = append(resolvedDependencies, convertMaterialsToResolvedDependencies(mats, pipelineResourceName)...)
 
 	// remove duplicate resolved dependencies
-	resolvedDependencies, err = removeDuplicateResolvedDependencies(resolvedDependencies)
-	if err != nil {
-		return nil, err
-	}
-	return resolvedDependencies, nil
+	return removeDuplicateResolvedDependencies(resolvedDependencies), nil
 }
 
 // PipelineRun constructs `predicate.resolvedDependencies` section by collecting all the artifacts that influence a pipeline run such as source code repo and step&sidecar base images.
 func PipelineRun(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig) ([]v1.ResourceDescriptor, error) {
 	var err error
 	var resolvedDependencies []v1.ResourceDescriptor
-	logger := logging.FromContext(ctx)
 
 	// add pipeline config to resolved dependencies
 	if p := pro.Status.Provenance; p != nil && p.RefSource != nil {
 		rd := v1.ResourceDescriptor{
-			Name:   pipelineConfigName,
-			URI:    p.RefSource.URI,
-			Digest: p.RefSource.Digest,
+			Name:        pipelineConfigName,
+			URI:         p.RefSource.URI,
+			Digest:      p.RefSource.Digest,
+			Annotations: clustersource.Annotations(ctx, slsaconfig.ClusterName, p.RefSource.URI, p.RefSource.Digest),
 		}
 		resolvedDependencies = append(resolvedDependencies, rd)
 	}
 
 	// add resolved dependencies from pipeline tasks
-	rds, err := fromPipelineTask(logger, pro)
+	rds, err := fromPipelineTask(ctx, pro, slsaconfig)
 	if err != nil {
 		return nil, err
 	}
@@ -116,13 +134,22 @@ func PipelineRun(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig
 	mats := material.FromPipelineParamsAndResults(ctx, pro, slsaconfig)
 	// convert materials to resolved dependencies
 	resolvedDependencies = append(resolvedDependencies, convertMaterialsToResolvedDependencies(mats, inputResultName)...)
+	if slsaconfig.ChainInputAttestations {
+		resolvedDependencies = append(resolvedDependencies, inputAttestations(ctx, pro, mats)...)
+	}
+	if slsaconfig.MaxInlineContentBytes > 0 {
+		objs := []objects.TektonObject{pro}
+		if slsaconfig.DeepInspectionEnabled {
+			_ = pro.ExecutedTasks(ctx, func(_ *v1beta1.PipelineTask, tr *v1beta1.TaskRun, _ bool) error {
+				objs = append(objs, objects.NewTaskRunObject(tr))
+				return nil
+			})
+		}
+		inlineContent(ctx, resolvedDependencies, slsaconfig.MaxInlineContentBytes, objs...)
+	}
 
 	// remove duplicate resolved dependencies
-	resolvedDependencies, err = removeDuplicateResolvedDependencies(resolvedDependencies)
-	if err != nil {
-		return nil, err
-	}
-	return resolvedDependencies, nil
+	return removeDuplicateResolvedDependencies(resolvedDependencies), nil
 }
 
 // convertMaterialToResolvedDependency converts a SLSAv0.2 Material to a resolved dependency
@@ -140,25 +167,50 @@ func convertMaterialsToResolvedDependencies(mats []common.ProvenanceMaterial, na
 	return rds
 }
 
+// inputAttestations looks up the attestations stored for the type-hinted input artifacts,
+// so that the provenance of the artifacts can be followed from the resolved dependencies.
+func inputAttestations(ctx context.Context, obj objects.TektonObject, mats []common.ProvenanceMaterial) []v1.ResourceDescriptor {
+	rds := []v1.ResourceDescriptor{}
+	for _, mat := range mats {
+		for _, att := range chaining.Find(ctx, obj, mat.URI, mat.Digest) {
+			rds = append(rds, v1.ResourceDescriptor{
+				Name:        inputAttestationName,
+				URI:         att.URI,
+				Digest:      att.Digest,
+				Annotations: map[string]interface{}{attestationSubjectAnnotation: mat.URI},
+			})
+		}
+	}
+	return rds
+}
+
+// Disallowed returns the URIs of the resolved dependencies that don't start with one of
+// the allowed material prefixes, or none if no prefixes are configured. The attestations
+// of input artifacts are not materials, so they are not checked.
+func Disallowed(rds []v1.ResourceDescriptor, slsaconfig *slsaconfig.SlsaConfig) []string {
+	uris := make([]string, 0, len(rds))
+	for _, rd := range rds {
+		if rd.Name != inputAttestationName {
+			uris = append(uris, rd.URI)
+		}
+	}
+	return allowlist.DisallowedURIs(uris, slsaconfig.AllowedMaterialPrefixes)
+}
+
 // removeDuplicateResolvedDependencies removes duplicate resolved dependencies from the slice of resolved dependencies.
 // Original order of resolved dependencies is retained.
-func removeDuplicateResolvedDependencies(resolvedDependencies []v1.ResourceDescriptor) ([]v1.ResourceDescriptor, error) {
+func removeDuplicateResolvedDependencies(resolvedDependencies []v1.ResourceDescriptor) []v1.ResourceDescriptor {
 	out := make([]v1.ResourceDescriptor, 0, len(resolvedDependencies))
 
 	// make map to store seen resolved dependencies
-	seen := map[string]bool{}
+	seen := make(map[string]bool, len(resolvedDependencies))
+	var key []byte
 	for _, resolvedDependency := range resolvedDependencies {
 		// Since resolvedDependencies contain names, we want to ignore those while checking for duplicates.
-		// Therefore, make a copy of the resolved dependency that only contains the uri and digest fields.
-		rDep := v1.ResourceDescriptor{}
-		rDep.URI = resolvedDependency.URI
-		rDep.Digest = resolvedDependency.Digest
+		// Therefore, the key only contains the uri and digest fields.
 		// This allows us to ignore dependencies that have the same uri and digest.
-		rd, err := json.Marshal(rDep)
-		if err != nil {
-			return nil, err
-		}
-		if seen[string(rd)] {
+		key = material.AppendKey(key[:0], resolvedDependency.URI, resolvedDependency.Digest)
+		if seen[string(key)] {
 			// We dont want to remove the top level pipeline/task config from the resolved dependencies
 			// because its critical to provide that information in the provenance. In SLSAv0.2 spec,
 			// we would put this in invocation.ConfigSource. In order to ensure that it is present in
@@ -168,55 +220,97 @@ func removeDuplicateResolvedDependencies(resolvedDependencies []v1.ResourceDescr
 				continue
 			}
 		}
-		seen[string(rd)] = true
+		seen[string(key)] = true
 		out = append(out, resolvedDependency)
 	}
-	return out, nil
+	return out
 }
 
 // fromPipelineTask adds the resolved dependencies from pipeline tasks
 // such as pipeline task uri/digest for remote pipeline tasks and step and sidecar images.
-func fromPipelineTask(logger *zap.SugaredLogger, pro *objects.PipelineRunObject) ([]v1.ResourceDescriptor, error) {
-	pSpec := pro.Status.PipelineSpec
+func fromPipelineTask(ctx context.Context, pro *objects.PipelineRunObject, slsaconfig *slsaconfig.SlsaConfig) ([]v1.ResourceDescriptor, error) {
 	resolvedDependencies := []v1.ResourceDescriptor{}
-	if pSpec != nil {
-		pipelineTasks := append(pSpec.Tasks, pSpec.Finally...)
-		for _, t := range pipelineTasks {
-			tr := pro.GetTaskRunFromTask(t.Name)
-			// Ignore Tasks that did not execute during the PipelineRun.
-			if tr == nil || tr.Status.CompletionTime == nil {
-				logger.Infof("taskrun status not found for task %s", t.Name)
-				continue
-			}
-			// add remote task configsource information in materials
-			if tr.Status.Provenance != nil && tr.Status.Provenance.RefSource != nil {
-				rd := v1.ResourceDescriptor{
-					Name:   pipelineTaskConfigName,
-					URI:    tr.Status.Provenance.RefSource.URI,
-					Digest: tr.Status.Provenance.RefSource.Digest,
-				}
-				resolvedDependencies = append(resolvedDependencies, rd)
+	err := pro.ExecutedTasks(ctx, func(_ *v1beta1.PipelineTask, tr *v1beta1.TaskRun, _ bool) error {
+		// add remote task configsource information in materials
+		if p := tr.Status.Provenance; p != nil && p.RefSource != nil {
+			rd := v1.ResourceDescriptor{
+				Name:        pipelineTaskConfigName,
+				URI:         p.RefSource.URI,
+				Digest:      p.RefSource.Digest,
+				Annotations: clustersource.Annotations(ctx, slsaconfig.ClusterName, p.RefSource.URI, p.RefSource.Digest),
 			}
+			resolvedDependencies = append(resolvedDependencies, rd)
+		}
 
-			mats := []common.ProvenanceMaterial{}
+		// add step images
+		stepMaterials, err := material.FromStepImages(tr.Status.Steps)
+		if err != nil {
+			return err
+		}
+		resolvedDependencies = append(resolvedDependencies, convertMaterialsToResolvedDependencies(stepMaterials, "")...)
 
-			// add step images
-			stepMaterials, err := material.FromStepImages(tr.Status.Steps)
-			if err != nil {
-				return nil, err
-			}
-			mats = append(mats, stepMaterials...)
+		// add sidecar images
+		sidecarMaterials, err := material.FromSidecarImages(tr.Status.Sidecars)
+		if err != nil {
+			return err
+		}
+		resolvedDependencies = append(resolvedDependencies, convertMaterialsToResolvedDependencies(sidecarMaterials, "")...)
 
-			// add sidecar images
-			sidecarMaterials, err := material.FromSidecarImages(tr.Status.Sidecars)
-			if err != nil {
-				return nil, err
-			}
-			mats = append(mats, sidecarMaterials...)
+		// add the pipeline task config and images of failed attempts
+		rds, err := fromRetriedAttempts(ctx, tr, slsaconfig, pipelineTaskConfigName)
+		if err != nil {
+			return err
+		}
+		resolvedDependencies = append(resolvedDependencies, rds...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resolvedDependencies, nil
+}
 
-			// convert materials to resolved dependencies
-			resolvedDependencies = append(resolvedDependencies, convertMaterialsToResolvedDependencies(mats, "")...)
+// fromRetriedAttempts adds the resolved dependencies of the failed attempts of a retried TaskRun, which
+// ran against the same workspaces as the attempt that completed: the task config, named configName, if it
+// differs from the one of the TaskRun, and the step and sidecar images. They are annotated with the index
+// of the attempt in the retriesStatus of the TaskRun, and are left out as duplicates if the attempt that
+// completed used them too.
+func fromRetriedAttempts(ctx context.Context, tr *v1beta1.TaskRun, slsaconfig *slsaconfig.SlsaConfig, configName string) ([]v1.ResourceDescriptor, error) {
+	resolvedDependencies := []v1.ResourceDescriptor{}
+	for i := range tr.Status.RetriesStatus {
+		attempt := &tr.Status.RetriesStatus[i]
+		var rds []v1.ResourceDescriptor
+		if p := attempt.Provenance; p != nil && p.RefSource != nil && !sameRefSource(p.RefSource, tr.Status.Provenance) {
+			rds = append(rds, v1.ResourceDescriptor{
+				Name:        configName,
+				URI:         p.RefSource.URI,
+				Digest:      p.RefSource.Digest,
+				Annotations: clustersource.Annotations(ctx, slsaconfig.ClusterName, p.RefSource.URI, p.RefSource.Digest),
+			})
 		}
+		stepMaterials, err := material.FromStepImages(attempt.Steps)
+		if err != nil {
+			return nil, err
+		}
+		rds = append(rds, convertMaterialsToResolvedDependencies(stepMaterials, "")...)
+		sidecarMaterials, err := material.FromSidecarImages(attempt.Sidecars)
+		if err != nil {
+			return nil, err
+		}
+		rds = append(rds, convertMaterialsToResolvedDependencies(sidecarMaterials, "")...)
+
+		for j := range rds {
+			if rds[j].Annotations == nil {
+				rds[j].Annotations = map[string]interface{}{}
+			}
+			rds[j].Annotations[retryAttemptAnnotation] = i
+		}
+		resolvedDependencies = append(resolvedDependencies, rds...)
 	}
 	return resolvedDependencies, nil
 }
+
+// sameRefSource returns whether p records ref as its ref source.
+func sameRefSource(ref *v1beta1.RefSource, p *v1beta1.Provenance) bool {
+	return p != nil && p.RefSource != nil && p.RefSource.URI == ref.URI && reflect.DeepEqual(p.RefSource.Digest, ref.Digest)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package resolveddependencies
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -25,6 +27,7 @@ import (
 	v1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
 	"github.com/tektoncd/chains/internal/backport"
 	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/chaining"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/compare"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/objects"
@@ -253,11 +256,113 @@ func TestTaskRun(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name: "resolvedDependencies from the cluster resolver",
+		taskRun: &v1beta1.TaskRun{
+			Status: v1beta1.TaskRunStatus{
+				TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+					Provenance: &v1beta1.Provenance{
+						RefSource: &v1beta1.RefSource{
+							URI:    "/apis/tekton.dev/v1/namespaces/tasks/task/build@7f1d1b8c",
+							Digest: map[string]string{"sha256": "4d7cbd0b2a1e0f401df8c3b7fe6b8d2e5f0a3a6d0b0f6a02bd1c36a1e2bb1b45"},
+						},
+					},
+				},
+			},
+		},
+		want: []v1.ResourceDescriptor{
+			{
+				Name:   "task",
+				URI:    "/apis/tekton.dev/v1/namespaces/tasks/task/build@7f1d1b8c",
+				Digest: common.DigestSet{"sha256": "4d7cbd0b2a1e0f401df8c3b7fe6b8d2e5f0a3a6d0b0f6a02bd1c36a1e2bb1b45"},
+				Annotations: map[string]interface{}{
+					"cluster":   "prod-east",
+					"namespace": "tasks",
+					"kind":      "task",
+					"name":      "build",
+					"uid":       "7f1d1b8c",
+				},
+			},
+		},
+	}, {
+		name: "resolvedDependencies from retried attempts",
+		taskRun: &v1beta1.TaskRun{
+			Status: v1beta1.TaskRunStatus{
+				TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+					Provenance: &v1beta1.Provenance{
+						RefSource: &v1beta1.RefSource{
+							URI:    "git+github.com/something.git",
+							Digest: map[string]string{"sha1": "abcd1234"},
+						},
+					},
+					Steps: []v1beta1.StepState{{
+						Name:    "build",
+						ImageID: "gcr.io/cloud-marketplace-containers/google/bazel@sha256:010a1ecd1a8c3610f12039a25b823e3a17bd3e8ae455a53e340dcfdd37a49964",
+					}},
+					RetriesStatus: []v1beta1.TaskRunStatus{{
+						TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+							// The first attempt ran an older revision of the task and image.
+							Provenance: &v1beta1.Provenance{
+								RefSource: &v1beta1.RefSource{
+									URI:    "git+github.com/something.git",
+									Digest: map[string]string{"sha1": "0123abcd"},
+								},
+							},
+							Steps: []v1beta1.StepState{{
+								Name:    "build",
+								ImageID: "gcr.io/cloud-marketplace-containers/google/bazel@sha256:b963f6e7a69617db57b685893256f978436277094c21d43b153994acd8a01247",
+							}},
+							Sidecars: []v1beta1.SidecarState{{
+								Name:    "sidecar-jwqcl",
+								ImageID: "gcr.io/tekton-releases/github.com/tektoncd/pipeline/cmd/sidecar-git-init@sha256:a1234f6e7a69617db57b685893256f978436277094c21d43b153994acd8a09567",
+							}},
+						},
+					}, {
+						TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+							// The second attempt ran what the TaskRun completed with.
+							Provenance: &v1beta1.Provenance{
+								RefSource: &v1beta1.RefSource{
+									URI:    "git+github.com/something.git",
+									Digest: map[string]string{"sha1": "abcd1234"},
+								},
+							},
+							Steps: []v1beta1.StepState{{
+								Name:    "build",
+								ImageID: "gcr.io/cloud-marketplace-containers/google/bazel@sha256:010a1ecd1a8c3610f12039a25b823e3a17bd3e8ae455a53e340dcfdd37a49964",
+							}},
+						},
+					}},
+				},
+			},
+		},
+		want: []v1.ResourceDescriptor{
+			{
+				Name:   "task",
+				URI:    "git+github.com/something.git",
+				Digest: common.DigestSet{"sha1": "abcd1234"},
+			}, {
+				URI:    "oci://gcr.io/cloud-marketplace-containers/google/bazel",
+				Digest: common.DigestSet{"sha256": "010a1ecd1a8c3610f12039a25b823e3a17bd3e8ae455a53e340dcfdd37a49964"},
+			}, {
+				Name:        "task",
+				URI:         "git+github.com/something.git",
+				Digest:      common.DigestSet{"sha1": "0123abcd"},
+				Annotations: map[string]interface{}{"retryAttempt": 0},
+			}, {
+				URI:         "oci://gcr.io/cloud-marketplace-containers/google/bazel",
+				Digest:      common.DigestSet{"sha256": "b963f6e7a69617db57b685893256f978436277094c21d43b153994acd8a01247"},
+				Annotations: map[string]interface{}{"retryAttempt": 0},
+			}, {
+				URI:         "oci://gcr.io/tekton-releases/github.com/tektoncd/pipeline/cmd/sidecar-git-init",
+				Digest:      common.DigestSet{"sha256": "a1234f6e7a69617db57b685893256f978436277094c21d43b153994acd8a09567"},
+				Annotations: map[string]interface{}{"retryAttempt": 0},
+			},
+		},
 	}}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			ctx := logtesting.TestContextWithLogger(t)
-			rd, err := TaskRun(ctx, objects.NewTaskRunObject(tc.taskRun))
+			rd, err := TaskRun(ctx, objects.NewTaskRunObject(tc.taskRun), &slsaconfig.SlsaConfig{ClusterName: "prod-east"})
 			if err != nil {
 				t.Fatalf("Did not expect an error but got %v", err)
 			}
@@ -473,10 +578,7 @@ func TestRemoveDuplicates(t *testing.T) {
 	}}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			rds, err := removeDuplicateResolvedDependencies(tc.rds)
-			if err != nil {
-				t.Fatalf("Did not expect an error but got %v", err)
-			}
+			rds := removeDuplicateResolvedDependencies(tc.rds)
 			if diff := cmp.Diff(tc.want, rds); diff != "" {
 				t.Errorf("resolvedDependencies(): -want +got: %s", diff)
 			}
@@ -555,3 +657,120 @@ func TestPipelineRunStructuredResult(t *testing.T) {
 		t.Errorf("resolvedDependencies(): -want +got: %s", diff)
 	}
 }
+
+type fakeFinder map[string][]chaining.Attestation
+
+func (f fakeFinder) FindAttestations(_ context.Context, _ objects.TektonObject, uri string, _ map[string]string) ([]chaining.Attestation, error) {
+	if uri == "gcr.io/unavailable" {
+		return nil, errors.New("registry unavailable")
+	}
+	return f[uri], nil
+}
+
+func TestTaskRunInputAttestations(t *testing.T) {
+	tr := &v1beta1.TaskRun{
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{
+						Name:  "base" + "-" + artifacts.ArtifactsInputsResultName,
+						Value: *v1beta1.NewObject(map[string]string{"uri": "gcr.io/foo/base", "digest": digest}),
+					}, {
+						Name:  "cache" + "-" + artifacts.ArtifactsInputsResultName,
+						Value: *v1beta1.NewObject(map[string]string{"uri": "gcr.io/unavailable", "digest": digest}),
+					},
+				},
+			},
+		},
+	}
+	finder := fakeFinder{"gcr.io/foo/base": {{
+		URI:    "oci://gcr.io/foo/base:sha256-05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b7.att",
+		Digest: map[string]string{"sha256": "9b1b6a4a8dd9c1f4a1c7a8de3f70c5e5bd36b9e5a1a4d7c3bd1e0a1f6b1a6c2d"},
+	}}}
+	ctx := chaining.WithFinders(logtesting.TestContextWithLogger(t), finder)
+
+	want := []v1.ResourceDescriptor{{
+		Name:   "inputs/result",
+		URI:    "gcr.io/foo/base",
+		Digest: common.DigestSet{"sha256": strings.TrimPrefix(digest, "sha256:")},
+	}, {
+		Name:   "inputs/result",
+		URI:    "gcr.io/unavailable",
+		Digest: common.DigestSet{"sha256": strings.TrimPrefix(digest, "sha256:")},
+	}, {
+		Name:        "inputs/attestation",
+		URI:         "oci://gcr.io/foo/base:sha256-05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b7.att",
+		Digest:      common.DigestSet{"sha256": "9b1b6a4a8dd9c1f4a1c7a8de3f70c5e5bd36b9e5a1a4d7c3bd1e0a1f6b1a6c2d"},
+		Annotations: map[string]interface{}{"subject": "gcr.io/foo/base"},
+	}}
+	got, err := TaskRun(ctx, objects.NewTaskRunObject(tr), &slsaconfig.SlsaConfig{ChainInputAttestations: true})
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ResolvedDependencies(): -want +got: %s", diff)
+	}
+
+	got, err = TaskRun(ctx, objects.NewTaskRunObject(tr), &slsaconfig.SlsaConfig{})
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %v", err)
+	}
+	if diff := cmp.Diff(want[:2], got); diff != "" {
+		t.Errorf("ResolvedDependencies() without chaining: -want +got: %s", diff)
+	}
+}
+
+func TestTaskRunInlineContent(t *testing.T) {
+	policyDigest := "sha256:4797bbada5d5e03ceb5398e211b27bdde2e387dd28c7c5252edae09c51b30f4e"
+	tr := &v1beta1.TaskRun{
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{
+						Name:  "policy" + "-" + artifacts.ArtifactsInputsResultName,
+						Value: *v1beta1.NewObject(map[string]string{"uri": "git+https://github.com/org/policies@main#policy.yaml", "digest": policyDigest, "content": "YWxsb3c6IHRydWUK"}),
+					}, {
+						// The content doesn't match the digest.
+						Name:  "lockfile" + "-" + artifacts.ArtifactsInputsResultName,
+						Value: *v1beta1.NewObject(map[string]string{"uri": "git+https://github.com/org/app@main#go.sum", "digest": digest, "content": "YWxsb3c6IHRydWUK"}),
+					},
+				},
+			},
+		},
+	}
+	ctx := logtesting.TestContextWithLogger(t)
+
+	policy := v1.ResourceDescriptor{
+		Name:   "inputs/result",
+		URI:    "git+https://github.com/org/policies@main#policy.yaml",
+		Digest: common.DigestSet{"sha256": strings.TrimPrefix(policyDigest, "sha256:")},
+	}
+	lockfile := v1.ResourceDescriptor{
+		Name:   "inputs/result",
+		URI:    "git+https://github.com/org/app@main#go.sum",
+		Digest: common.DigestSet{"sha256": strings.TrimPrefix(digest, "sha256:")},
+	}
+	inlined := policy
+	inlined.Content = []byte("allow: true\n")
+
+	tests := []struct {
+		name string
+		max  int
+		want []v1.ResourceDescriptor
+	}{
+		{name: "disabled", want: []v1.ResourceDescriptor{policy, lockfile}},
+		{name: "enabled", max: 4096, want: []v1.ResourceDescriptor{inlined, lockfile}},
+		{name: "too large", max: 4, want: []v1.ResourceDescriptor{policy, lockfile}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := TaskRun(ctx, objects.NewTaskRunObject(tr), &slsaconfig.SlsaConfig{MaxInlineContentBytes: tc.max})
+			if err != nil {
+				t.Fatalf("Did not expect an error but got %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ResolvedDependencies(): -want +got: %s", diff)
+			}
+		})
+	}
+}
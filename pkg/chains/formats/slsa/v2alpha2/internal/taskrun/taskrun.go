@@ -19,22 +19,32 @@ import (
 	"fmt"
 
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+	"github.com/tektoncd/chains/pkg/chains/environment"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/extract"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/isolation"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	resolveddependencies "github.com/tektoncd/chains/pkg/chains/formats/slsa/v2alpha2/internal/resolved_dependencies"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 )
 
-const taskRunResults = "taskRunResults/%s"
+const (
+	taskRunResults       = "taskRunResults/%s"
+	environmentByproduct = "environment"
+	configByproduct      = "chainsConfig"
+	// buildType is the buildType of the provenance unless it is overridden.
+	buildType = "https://tekton.dev/chains/v2/slsa"
+)
 
 // GenerateAttestation generates a provenance statement with SLSA v1.0 predicate for a task run.
 func GenerateAttestation(ctx context.Context, tro *objects.TaskRunObject, slsaConfig *slsaconfig.SlsaConfig) (interface{}, error) {
-	rd, err := resolveddependencies.TaskRun(ctx, tro)
+	rd, err := resolveddependencies.TaskRun(ctx, tro, slsaConfig)
 	if err != nil {
 		return nil, err
 	}
-	bp, err := byproducts(tro)
+	bp, err := byproducts(ctx, tro, slsaConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -46,9 +56,9 @@ func GenerateAttestation(ctx context.Context, tro *objects.TaskRunObject, slsaCo
 		},
 		Predicate: slsa.ProvenancePredicate{
 			BuildDefinition: slsa.ProvenanceBuildDefinition{
-				BuildType:            "https://tekton.dev/chains/v2/slsa",
+				BuildType:            slsaConfig.BuildTypeOr(buildType),
 				ExternalParameters:   externalParameters(tro),
-				InternalParameters:   internalParameters(tro),
+				InternalParameters:   internalParameters(tro, slsaConfig, rd),
 				ResolvedDependencies: rd,
 			},
 			RunDetails: slsa.ProvenanceRunDetails{
@@ -79,12 +89,19 @@ func metadata(tro *objects.TaskRunObject) slsa.BuildMetadata {
 }
 
 // internalParameters adds the tekton feature flags that were enabled
-// for the taskrun.
-func internalParameters(tro *objects.TaskRunObject) map[string]any {
+// for the taskrun, how isolated its pod was if isolation signals are configured, and
+// the resolved dependencies that aren't allowed if they are recorded in provenance.
+func internalParameters(tro *objects.TaskRunObject, slsaConfig *slsaconfig.SlsaConfig, rd []slsa.ResourceDescriptor) map[string]any {
 	internalParams := make(map[string]any)
 	if tro.Status.Provenance != nil && tro.Status.Provenance.FeatureFlags != nil {
 		internalParams["tekton-pipelines-feature-flags"] = *tro.Status.Provenance.FeatureFlags
 	}
+	if isolation.Enabled(slsaConfig) {
+		internalParams["isolation"] = isolation.TaskRun(tro.TaskRun, slsaConfig)
+	}
+	if disallowed := resolveddependencies.Disallowed(rd, slsaConfig); len(disallowed) > 0 {
+		internalParams["disallowedMaterials"] = disallowed
+	}
 	return internalParams
 }
 
@@ -112,12 +129,19 @@ func externalParameters(tro *objects.TaskRunObject) map[string]any {
 		}
 		externalParams["buildConfigSource"] = buildConfigSource
 	}
+	if trigger := attest.Trigger(tro.GetObjectMeta()); trigger != nil {
+		externalParams["trigger"] = trigger
+	}
+	if group := attest.BuildGroup(tro.GetObjectMeta()); group != "" {
+		externalParams["buildGroup"] = group
+	}
 	externalParams["runSpec"] = tro.Spec
 	return externalParams
 }
 
-// byproducts contains the taskRunResults
-func byproducts(tro *objects.TaskRunObject) ([]slsa.ResourceDescriptor, error) {
+// byproducts contains the taskRunResults, and the environment and configuration the
+// taskrun was signed in if they are recorded.
+func byproducts(ctx context.Context, tro *objects.TaskRunObject, slsaConfig *slsaconfig.SlsaConfig) ([]slsa.ResourceDescriptor, error) {
 	byProd := []slsa.ResourceDescriptor{}
 	for _, key := range tro.Status.TaskRunResults {
 		content, err := json.Marshal(key.Value)
@@ -131,5 +155,27 @@ func byproducts(tro *objects.TaskRunObject) ([]slsa.ResourceDescriptor, error) {
 		}
 		byProd = append(byProd, bp)
 	}
+	if env := environment.Collect(ctx, tro.TaskRun); env != nil {
+		content, err := json.Marshal(env)
+		if err != nil {
+			return nil, err
+		}
+		byProd = append(byProd, slsa.ResourceDescriptor{
+			Name:      environmentByproduct,
+			Content:   content,
+			MediaType: "application/json",
+		})
+	}
+	if s := slsaConfig.ConfigSnapshot; s != nil {
+		bp := slsa.ResourceDescriptor{
+			Name:   configByproduct,
+			Digest: common.DigestSet{"sha256": s.Digest},
+		}
+		if s.RecordContent {
+			bp.Content = s.Content
+			bp.MediaType = "application/json"
+		}
+		byProd = append(byProd, bp)
+	}
 	return byProd, nil
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package taskrun
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
@@ -26,6 +27,7 @@ import (
 	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
 
+	"github.com/tektoncd/chains/pkg/chains/environment"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/v2alpha2/internal/pipelinerun"
 	"github.com/tektoncd/chains/pkg/chains/objects"
@@ -33,6 +35,7 @@ import (
 	"github.com/tektoncd/pipeline/pkg/apis/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	logtesting "knative.dev/pkg/logging/testing"
 )
 
@@ -149,6 +152,23 @@ func TestExternalParameters(t *testing.T) {
 	}
 }
 
+func TestExternalParametersBuildGroup(t *testing.T) {
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: v1.ObjectMeta{
+			Annotations: map[string]string{"chains.tekton.dev/build-group": "widgets-nightly"},
+		},
+	}
+
+	want := map[string]any{
+		"buildGroup": "widgets-nightly",
+		"runSpec":    tr.Spec,
+	}
+	got := externalParameters(objects.NewTaskRunObject(tr))
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("externalParameters (-want, +got):\n%s", d)
+	}
+}
+
 func TestInternalParameters(t *testing.T) {
 	tr := &v1beta1.TaskRun{
 		Status: v1beta1.TaskRunStatus{
@@ -179,7 +199,24 @@ func TestInternalParameters(t *testing.T) {
 			MaxResultSize:                    4096,
 		},
 	}
-	got := internalParameters(objects.NewTaskRunObject(tr))
+	got := internalParameters(objects.NewTaskRunObject(tr), &slsaconfig.SlsaConfig{}, nil)
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("internalParameters (-want, +got):\n%s", d)
+	}
+}
+
+func TestInternalParametersDisallowedMaterials(t *testing.T) {
+	rd := []slsa.ResourceDescriptor{
+		{Name: "task", URI: "git+https://github.com/org/catalog.git"},
+		{URI: "oci://gcr.io/trusted/golang"},
+		{URI: "oci://docker.io/library/alpine"},
+		{Name: "inputs/attestation", URI: "oci://docker.io/library/alpine.att"},
+	}
+	cfg := &slsaconfig.SlsaConfig{AllowedMaterialPrefixes: sets.New[string]("gcr.io/trusted/", "git+https://github.com/org/")}
+	want := map[string]any{
+		"disallowedMaterials": []string{"oci://docker.io/library/alpine"},
+	}
+	got := internalParameters(objects.NewTaskRunObject(&v1beta1.TaskRun{}), cfg, rd)
 	if d := cmp.Diff(want, got); d != "" {
 		t.Fatalf("internalParameters (-want, +got):\n%s", d)
 	}
@@ -211,7 +248,66 @@ func TestByProducts(t *testing.T) {
 			MediaType: pipelinerun.JsonMediaType,
 		},
 	}
-	got, err := byproducts(objects.NewTaskRunObject(tr))
+	got, err := byproducts(context.Background(), objects.NewTaskRunObject(tr), &slsaconfig.SlsaConfig{})
+	if err != nil {
+		t.Fatalf("Could not extract byproducts: %s", err)
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Fatalf("byproducts (-want, +got):\n%s", d)
+	}
+}
+
+func TestByProductsConfigSnapshot(t *testing.T) {
+	content := []byte(`{"artifacts.taskrun.format":"slsa/v2alpha2"}`)
+	tests := []struct {
+		name string
+		cfg  *slsaconfig.ConfigSnapshot
+		want slsa.ResourceDescriptor
+	}{{
+		name: "digest",
+		cfg:  &slsaconfig.ConfigSnapshot{Digest: "abcd", Content: content},
+		want: slsa.ResourceDescriptor{Name: "chainsConfig", Digest: common.DigestSet{"sha256": "abcd"}},
+	}, {
+		name: "content",
+		cfg:  &slsaconfig.ConfigSnapshot{Digest: "abcd", Content: content, RecordContent: true},
+		want: slsa.ResourceDescriptor{Name: "chainsConfig", Digest: common.DigestSet{"sha256": "abcd"}, Content: content, MediaType: pipelinerun.JsonMediaType},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := byproducts(context.Background(), objects.NewTaskRunObject(&v1beta1.TaskRun{}), &slsaconfig.SlsaConfig{ConfigSnapshot: tt.cfg})
+			if err != nil {
+				t.Fatalf("Could not extract byproducts: %s", err)
+			}
+			if d := cmp.Diff([]slsa.ResourceDescriptor{tt.want}, got); d != "" {
+				t.Fatalf("byproducts (-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+type fakeCollector struct{}
+
+func (fakeCollector) KubernetesVersion(context.Context) (string, error) {
+	return "v1.27.3", nil
+}
+
+func (fakeCollector) Node(_ context.Context, _, pod string) (environment.Node, error) {
+	return environment.Node{Name: "node-" + pod, OperatingSystem: "linux", Architecture: "amd64", ContainerRuntime: "containerd://1.7.2"}, nil
+}
+
+func TestByProductsEnvironment(t *testing.T) {
+	tr := &v1beta1.TaskRun{
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{PodName: "build-pod"},
+		},
+	}
+	ctx := environment.WithCollector(logtesting.TestContextWithLogger(t), fakeCollector{})
+	want := []slsa.ResourceDescriptor{{
+		Name:      "environment",
+		Content:   []byte(`{"kubernetesVersion":"v1.27.3","nodes":[{"name":"node-build-pod","operatingSystem":"linux","architecture":"amd64","containerRuntime":"containerd://1.7.2"}]}`),
+		MediaType: pipelinerun.JsonMediaType,
+	}}
+	got, err := byproducts(ctx, objects.NewTaskRunObject(tr), &slsaconfig.SlsaConfig{})
 	if err != nil {
 		t.Fatalf("Could not extract byproducts: %s", err)
 	}
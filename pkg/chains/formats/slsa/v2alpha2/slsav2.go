@@ -18,14 +18,19 @@ package v2alpha2
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 
 	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/digestset"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/internal/slsaconfig"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/v2alpha2/internal/pipelinerun"
 	"github.com/tektoncd/chains/pkg/chains/formats/slsa/v2alpha2/internal/taskrun"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const (
@@ -41,27 +46,68 @@ type Slsa struct {
 }
 
 func NewFormatter(cfg config.Config) (formats.Payloader, error) {
+	snapshot, err := configSnapshot(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var allowedMaterialPrefixes sets.Set[string]
+	if cfg.Materials.Enforcement == config.MaterialsProvenance {
+		allowedMaterialPrefixes = cfg.Materials.AllowedPrefixes
+	}
 	return &Slsa{
 		slsaConfig: &slsaconfig.SlsaConfig{
-			BuilderID:             cfg.Builder.ID,
-			DeepInspectionEnabled: cfg.Artifacts.PipelineRuns.DeepInspectionEnabled,
+			BuilderID:               cfg.Builder.ID,
+			BuildType:               cfg.Builder.BuildType,
+			DeepInspectionEnabled:   cfg.Artifacts.PipelineRuns.DeepInspectionEnabled,
+			ResultDigests:           cfg.Artifacts.PipelineRuns.ResultDigests,
+			ClusterName:             cfg.Builder.Cluster,
+			ChainInputAttestations:  cfg.Provenance.ChainInputAttestations,
+			SandboxRuntimeClasses:   cfg.Isolation.SandboxRuntimeClasses,
+			NetworkLabels:           cfg.Isolation.NetworkLabels,
+			AllowedMaterialPrefixes: allowedMaterialPrefixes,
+			ConfigSnapshot:          snapshot,
+			DigestAlgorithms:        cfg.Provenance.DigestAlgorithms,
+			MaxInlineContentBytes:   cfg.Provenance.MaxInlineContentBytes,
 		},
 	}, nil
 }
 
+// configSnapshot returns the snapshot of cfg to record, or nil if it isn't recorded.
+func configSnapshot(cfg config.Config) (*slsaconfig.ConfigSnapshot, error) {
+	if cfg.Provenance.ConfigSnapshot == "" {
+		return nil, nil
+	}
+	content, err := json.Marshal(config.Snapshot(&cfg))
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(content)
+	return &slsaconfig.ConfigSnapshot{
+		Digest:        hex.EncodeToString(h[:]),
+		Content:       content,
+		RecordContent: cfg.Provenance.ConfigSnapshot == config.ConfigSnapshotContent,
+	}, nil
+}
+
 func (s *Slsa) Wrap() bool {
 	return true
 }
 
 func (s *Slsa) CreatePayload(ctx context.Context, obj interface{}) (interface{}, error) {
+	var att interface{}
+	var err error
 	switch v := obj.(type) {
 	case *objects.TaskRunObject:
-		return taskrun.GenerateAttestation(ctx, v, s.slsaConfig)
+		att, err = taskrun.GenerateAttestation(ctx, v, s.slsaConfig)
 	case *objects.PipelineRunObject:
-		return pipelinerun.GenerateAttestation(ctx, v, s.slsaConfig)
+		att, err = pipelinerun.GenerateAttestation(ctx, v, s.slsaConfig)
 	default:
 		return nil, fmt.Errorf("intoto does not support type: %s", v)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return digestset.Statement(att, s.slsaConfig.DigestAlgorithms), nil
 }
 
 func (s *Slsa) Type() config.PayloadType {
@@ -17,6 +17,8 @@ limitations under the License.
 package v2alpha2
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"testing"
 	"time"
@@ -59,6 +61,51 @@ func TestNewFormatter(t *testing.T) {
 	})
 }
 
+func TestNewFormatterConfigSnapshot(t *testing.T) {
+	tests := []struct {
+		snapshot    string
+		want        bool
+		wantContent bool
+	}{
+		{snapshot: ""},
+		{snapshot: config.ConfigSnapshotDigest, want: true},
+		{snapshot: config.ConfigSnapshotContent, want: true, wantContent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.snapshot, func(t *testing.T) {
+			cfg := config.Config{
+				Builder:    config.BuilderConfig{ID: "testid"},
+				Provenance: config.ProvenanceConfig{ConfigSnapshot: tt.snapshot},
+			}
+			f, err := NewFormatter(cfg)
+			if err != nil {
+				t.Fatalf("Error creating formatter: %s", err)
+			}
+			got := f.(*Slsa).slsaConfig.ConfigSnapshot
+			if (got != nil) != tt.want {
+				t.Fatalf("ConfigSnapshot = %v, want it recorded: %v", got, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			h := sha256.Sum256(got.Content)
+			if got.Digest != hex.EncodeToString(h[:]) {
+				t.Errorf("ConfigSnapshot digest %s doesn't match its content", got.Digest)
+			}
+			var data map[string]string
+			if err := json.Unmarshal(got.Content, &data); err != nil {
+				t.Fatal(err)
+			}
+			if data["builder.id"] != "testid" {
+				t.Errorf("ConfigSnapshot content = %v, want builder.id testid", data)
+			}
+			if got.RecordContent != tt.wantContent {
+				t.Errorf("ConfigSnapshot RecordContent = %v, want %v", got.RecordContent, tt.wantContent)
+			}
+		})
+	}
+}
+
 func TestCreatePayloadError(t *testing.T) {
 	ctx := logtesting.TestContextWithLogger(t)
 
@@ -501,3 +548,28 @@ func TestPipelineRunCreatePayload1(t *testing.T) {
 		t.Errorf("Slsa.CreatePayload(): -want +got: %s", diff)
 	}
 }
+
+func TestBuildTypeOverride(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	tr, err := objectloader.TaskRunFromFile("../testdata/v2alpha2/taskrun1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{
+		Builder: config.BuilderConfig{
+			ID:        "test_builder-1",
+			BuildType: "https://example.com/tekton/build/v1",
+		},
+	}
+	f, err := NewFormatter(cfg)
+	if err != nil {
+		t.Fatalf("Error creating formatter: %s", err)
+	}
+	got, err := f.CreatePayload(ctx, objects.NewTaskRunObject(tr))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if bt := got.(in_toto.ProvenanceStatementSLSA1).Predicate.BuildDefinition.BuildType; bt != "https://example.com/tekton/build/v1" {
+		t.Errorf("buildType = %q, want the configured buildType", bt)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+const (
+	PayloadTypeSource = formats.PayloadTypeSource
+
+	// PredicateType is the predicate type of source attestations.
+	PredicateType = "https://tekton.dev/chains/source/v1"
+)
+
+func init() {
+	formats.RegisterPayloader(PayloadTypeSource, NewFormatter)
+}
+
+// Source is a formatter that attests the source commits runs built from. Signed
+// keylessly, the attestation ties the identity of the build to the exact revision.
+type Source struct {
+	builderID string
+	fetchRuns bool
+}
+
+// Predicate is the predicate of source attestations.
+type Predicate struct {
+	// Repository is the URL of the repository, as in the CHAINS-GIT_URL hint.
+	Repository string `json:"repository"`
+	// Commit is the full SHA of the commit.
+	Commit  string  `json:"commit"`
+	Builder Builder `json:"builder"`
+	// BuildRun is the run that built from the commit.
+	BuildRun artifacts.RunReference `json:"buildRun"`
+	// Fetch is how the commit was fetched, if BuildRun only fetched source.
+	Fetch *Fetch `json:"fetch,omitempty"`
+}
+
+// Fetch is how a run that only fetches source, like git-clone, fetched a commit.
+type Fetch struct {
+	Params map[string]v1beta1.ParamValue `json:"params"`
+}
+
+// Builder identifies the builder that built from a commit.
+type Builder struct {
+	ID string `json:"id"`
+}
+
+func NewFormatter(cfg config.Config) (formats.Payloader, error) {
+	return &Source{builderID: cfg.Builder.ID, fetchRuns: cfg.Artifacts.Source.FetchRuns}, nil
+}
+
+// CreatePayload implements the Payloader interface. The subject is the repository in
+// SPDX form, as recorded in the materials of provenance, with the commit as its sha1
+// digest, or sha256 digest for repositories using the SHA-256 object format. The fetch
+// params of runs that only fetch source are recorded if configured.
+func (s *Source) CreatePayload(ctx context.Context, obj interface{}) (interface{}, error) {
+	c, ok := obj.(*artifacts.SourceCommit)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T", obj)
+	}
+	algorithm := "sha1"
+	if artifacts.Sha256Regexp.MatchString(c.Commit) {
+		algorithm = "sha256"
+	}
+	var fetch *Fetch
+	if s.fetchRuns && c.Fetch != nil {
+		fetch = &Fetch{Params: c.Fetch.Params}
+	}
+
+	return in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: PredicateType,
+			Subject: []in_toto.Subject{{
+				Name:   attest.SPDXGit(c.URL, ""),
+				Digest: map[string]string{algorithm: c.Commit},
+			}},
+		},
+		Predicate: Predicate{
+			Repository: c.URL,
+			Commit:     c.Commit,
+			Builder:    Builder{ID: s.builderID},
+			BuildRun:   c.Run,
+			Fetch:      fetch,
+		},
+	}, nil
+}
+
+func (s *Source) Wrap() bool {
+	return true
+}
+
+func (s *Source) Type() config.PayloadType {
+	return formats.PayloadTypeSource
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+func TestCreatePayload(t *testing.T) {
+	f, err := NewFormatter(config.Config{Builder: config.BuilderConfig{ID: "https://tekton.dev/chains/v2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := artifacts.RunReference{Kind: "taskrun", Namespace: "default", Name: "build", UID: "uid"}
+	sha1 := "50c56a48cfb3a5a80fa36ed91c739bdac8381cbe"
+	sha256 := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name   string
+		commit string
+		digest map[string]string
+	}{
+		{name: "sha1", commit: sha1, digest: map[string]string{"sha1": sha1}},
+		{name: "sha256 object format", commit: sha256, digest: map[string]string{"sha256": sha256}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.CreatePayload(context.Background(), &artifacts.SourceCommit{URL: "https://github.com/org/repo", Commit: tt.commit, Run: run})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := in_toto.Statement{
+				StatementHeader: in_toto.StatementHeader{
+					Type:          in_toto.StatementInTotoV01,
+					PredicateType: PredicateType,
+					Subject:       []in_toto.Subject{{Name: "git+https://github.com/org/repo.git", Digest: tt.digest}},
+				},
+				Predicate: Predicate{
+					Repository: "https://github.com/org/repo",
+					Commit:     tt.commit,
+					Builder:    Builder{ID: "https://tekton.dev/chains/v2"},
+					BuildRun:   run,
+				},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("CreatePayload() -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCreatePayload_Fetch(t *testing.T) {
+	commit := &artifacts.SourceCommit{
+		URL:    "https://github.com/org/repo",
+		Commit: "50c56a48cfb3a5a80fa36ed91c739bdac8381cbe",
+		Fetch:  &artifacts.SourceFetch{Params: map[string]v1beta1.ParamValue{"revision": *v1beta1.NewStructuredValues("main")}},
+	}
+	for _, fetchRuns := range []bool{false, true} {
+		cfg := config.Config{}
+		cfg.Artifacts.Source.FetchRuns = fetchRuns
+		f, err := NewFormatter(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := f.CreatePayload(context.Background(), commit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want *Fetch
+		if fetchRuns {
+			want = &Fetch{Params: commit.Fetch.Params}
+		}
+		if diff := cmp.Diff(want, got.(in_toto.Statement).Predicate.(Predicate).Fetch); diff != "" {
+			t.Errorf("fetch-runs %t: -want +got: %s", fetchRuns, diff)
+		}
+	}
+}
+
+func TestCreatePayload_UnsupportedType(t *testing.T) {
+	f, err := NewFormatter(config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.CreatePayload(context.Background(), &artifacts.VEXDocument{}); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonbundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/tektonbundles"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+const (
+	PayloadTypeTektonBundle = formats.PayloadTypeTektonBundle
+
+	// PredicateType is the predicate type of Tekton bundle attestations.
+	PredicateType = "https://tekton.dev/chains/bundle/v1"
+)
+
+func init() {
+	formats.RegisterPayloader(PayloadTypeTektonBundle, NewFormatter)
+}
+
+// TektonBundle is a formatter that attests the resources packaged in the Tekton
+// bundles built by runs, so that they can be verified as trusted resources.
+type TektonBundle struct {
+	builderID string
+}
+
+// Predicate is the predicate of Tekton bundle attestations.
+type Predicate struct {
+	// Bundle is the reference of the bundle, with its digest.
+	Bundle string `json:"bundle"`
+	// Resources are the resources packaged in the bundle, in the order of its layers.
+	Resources []tektonbundles.Resource `json:"resources"`
+	Builder   Builder                  `json:"builder"`
+	// BuildRun is the run that built the bundle.
+	BuildRun artifacts.RunReference `json:"buildRun"`
+}
+
+// Builder identifies the builder of a bundle.
+type Builder struct {
+	ID string `json:"id"`
+}
+
+func NewFormatter(cfg config.Config) (formats.Payloader, error) {
+	return &TektonBundle{builderID: cfg.Builder.ID}, nil
+}
+
+// CreatePayload implements the Payloader interface. The resources of the bundle are
+// read with the tektonbundles.Fetcher carried by ctx.
+func (t *TektonBundle) CreatePayload(ctx context.Context, obj interface{}) (interface{}, error) {
+	b, ok := obj.(*artifacts.TektonBundle)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T", obj)
+	}
+	f := tektonbundles.FromContext(ctx)
+	if f == nil {
+		return nil, errors.New("no Tekton bundle fetcher configured")
+	}
+	resources, err := f.Resources(ctx, b.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("reading the resources of Tekton bundle %s from result %s: %w", b.Digest, b.ResultName, err)
+	}
+
+	return in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: PredicateType,
+			Subject: []in_toto.Subject{{
+				Name:   b.Digest.Repository.Name(),
+				Digest: map[string]string{"sha256": strings.TrimPrefix(b.Digest.DigestStr(), "sha256:")},
+			}},
+		},
+		Predicate: Predicate{
+			Bundle:    b.Digest.String(),
+			Resources: resources,
+			Builder:   Builder{ID: t.builderID},
+			BuildRun:  b.Run,
+		},
+	}, nil
+}
+
+func (t *TektonBundle) Wrap() bool {
+	return true
+}
+
+func (t *TektonBundle) Type() config.PayloadType {
+	return formats.PayloadTypeTektonBundle
+}
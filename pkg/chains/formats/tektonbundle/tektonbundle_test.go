@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonbundle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/tektonbundles"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+type fakeFetcher map[string][]tektonbundles.Resource
+
+func (f fakeFetcher) Resources(_ context.Context, d name.Digest) ([]tektonbundles.Resource, error) {
+	return f[d.String()], nil
+}
+
+func TestCreatePayload(t *testing.T) {
+	digest, err := name.NewDigest("gcr.io/foo/tasks@sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := []tektonbundles.Resource{{
+		APIVersion: "tekton.dev/v1beta1",
+		Kind:       "task",
+		Name:       "build",
+		Digest:     map[string]string{"sha256": "6e1f4d8b0bbd4e5a7c4cd4b2d0d2a0b5bd6a5b5a1f1c7e0e2b1c2d3e4f5a6b7c"},
+	}}
+	f, err := NewFormatter(config.Config{Builder: config.BuilderConfig{ID: "https://tekton.dev/chains/v2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := artifacts.RunReference{Kind: "taskrun", Namespace: "default", Name: "build-bundle", UID: "uid"}
+	bundle := &artifacts.TektonBundle{ResultName: "BUNDLE_URL", Digest: digest, Run: run}
+
+	if _, err := f.CreatePayload(context.Background(), bundle); err == nil {
+		t.Error("expected an error without a Tekton bundle fetcher")
+	}
+
+	ctx := tektonbundles.WithFetcher(context.Background(), fakeFetcher{digest.String(): resources})
+	got, err := f.CreatePayload(ctx, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: PredicateType,
+			Subject: []in_toto.Subject{{
+				Name:   "gcr.io/foo/tasks",
+				Digest: map[string]string{"sha256": "05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5"},
+			}},
+		},
+		Predicate: Predicate{
+			Bundle:    digest.String(),
+			Resources: resources,
+			Builder:   Builder{ID: "https://tekton.dev/chains/v2"},
+			BuildRun:  run,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CreatePayload() -want +got: %s", diff)
+	}
+}
+
+func TestCreatePayload_UnsupportedType(t *testing.T) {
+	f, err := NewFormatter(config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.CreatePayload(context.Background(), &artifacts.VEXDocument{}); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+}
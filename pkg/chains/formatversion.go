@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+const (
+	// FormatVersionAnnotation records the format version, as returned by FormatVersion,
+	// a run was signed with.
+	FormatVersionAnnotation = "chains.tekton.dev/format-version"
+
+	// FormatsRevision is bumped whenever a change to the formatters changes the payloads
+	// they generate for the same run and configuration, so that runs signed before the
+	// change are considered outdated.
+	FormatsRevision = 1
+)
+
+// formatConfig is the configuration that changes the payloads generated for a run.
+type formatConfig struct {
+	Formats        map[string]string       `json:"formats"`
+	DeepInspection bool                    `json:"deepInspection"`
+	Builder        config.BuilderConfig    `json:"builder"`
+	Provenance     config.ProvenanceConfig `json:"provenance"`
+}
+
+// FormatVersion returns the format version of cfg: FormatsRevision and a digest of the
+// configuration that changes the payloads generated for a run, such as the formats of
+// the artifacts, the builder and the provenance options. Runs signed with a format
+// version other than the current one have attestations that differ from those Chains
+// would generate for them now.
+func FormatVersion(cfg config.Config) string {
+	b, err := json.Marshal(formatConfig{
+		Formats: map[string]string{
+			"taskrun":       cfg.Artifacts.TaskRuns.Format,
+			"pipelinerun":   cfg.Artifacts.PipelineRuns.Format,
+			"oci":           cfg.Artifacts.OCI.Format,
+			"customrun":     cfg.Artifacts.CustomRuns.Format,
+			"vex":           cfg.Artifacts.VEX.Format,
+			"tekton-bundle": cfg.Artifacts.TektonBundles.Format,
+		},
+		DeepInspection: cfg.Artifacts.PipelineRuns.DeepInspectionEnabled,
+		Builder:        cfg.Builder,
+		Provenance:     cfg.Provenance,
+	})
+	if err != nil {
+		// The configuration only holds values that can be marshaled.
+		panic(err)
+	}
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("%d-%s", FormatsRevision, hex.EncodeToString(sum[:6]))
+}
+
+// RunFormatVersion returns the format version obj would be signed with now under cfg,
+// including the config overrides set in its annotations.
+func RunFormatVersion(cfg config.Config, obj objects.TektonObject) (string, error) {
+	if err := cfg.ApplyOverrides(obj.GetKindName(), Overrides(obj)); err != nil {
+		return "", err
+	}
+	return FormatVersion(cfg), nil
+}
+
+// Outdated returns whether obj was signed with a format version other than the one it
+// would be signed with now under cfg. Runs signed before format versions were recorded
+// are outdated; runs that were not signed are not.
+func Outdated(cfg config.Config, obj objects.TektonObject) (bool, error) {
+	annotations := obj.GetAnnotations()
+	if annotations[ChainsAnnotation] != "true" {
+		return false, nil
+	}
+	current, err := RunFormatVersion(cfg, obj)
+	if err != nil {
+		return false, err
+	}
+	return annotations[FormatVersionAnnotation] != current, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"testing"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestOutdated(t *testing.T) {
+	cfg := config.Config{
+		Artifacts: config.ArtifactConfigs{TaskRuns: config.Artifact{Format: "in-toto"}},
+		Overrides: config.OverridesConfig{Allowed: sets.New[string](config.OverrideFormat)},
+	}
+	slsa := cfg
+	slsa.Artifacts.TaskRuns.Format = "slsa/v2alpha2"
+	if FormatVersion(cfg) == FormatVersion(slsa) {
+		t.Fatalf("FormatVersion() = %q for both formats", FormatVersion(cfg))
+	}
+	storage := cfg
+	storage.Artifacts.TaskRuns.StorageBackend = sets.New[string]("oci")
+	if FormatVersion(cfg) != FormatVersion(storage) {
+		t.Errorf("FormatVersion() changed with the storage backends")
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{{
+		name: "not signed",
+	}, {
+		name:        "failed",
+		annotations: map[string]string{ChainsAnnotation: "failed"},
+	}, {
+		name:        "current",
+		annotations: map[string]string{ChainsAnnotation: "true", FormatVersionAnnotation: FormatVersion(cfg)},
+	}, {
+		name:        "older format version",
+		annotations: map[string]string{ChainsAnnotation: "true", FormatVersionAnnotation: FormatVersion(slsa)},
+		want:        true,
+	}, {
+		name:        "signed before format versions were recorded",
+		annotations: map[string]string{ChainsAnnotation: "true"},
+		want:        true,
+	}, {
+		name: "current with overrides",
+		annotations: map[string]string{
+			ChainsAnnotation:                                 "true",
+			FormatVersionAnnotation:                          FormatVersion(slsa),
+			OverrideAnnotationPrefix + config.OverrideFormat: "slsa/v2alpha2",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}})
+			got, err := Outdated(cfg, obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Outdated() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// KeyUsedReason is the reason of the Kubernetes Events recording the use of a
+	// signing key.
+	KeyUsedReason = "SigningKeyUsed"
+
+	// Annotations of the Kubernetes Events recording the use of a signing key, so that
+	// they can be reconciled with signatures without parsing their message.
+	KeyIDEventAnnotation         = "chains.tekton.dev/key-id"
+	KeyRefEventAnnotation        = "chains.tekton.dev/key-ref"
+	ArtifactEventAnnotation      = "chains.tekton.dev/artifact"
+	PayloadDigestEventAnnotation = "chains.tekton.dev/payload-digest"
+)
+
+// recordKeyUsage records in artifact the key that signed payload and, if key usage
+// auditing is enabled, records the use of the key in a Kubernetes Event on obj and a
+// structured log line, so that signatures can be reconciled with key usage.
+func recordKeyUsage(ctx context.Context, cfg config.Config, obj objects.TektonObject, artifact *audit.Artifact, signer signing.Signer, payload []byte) {
+	artifact.PayloadDigest = audit.Digest(payload)
+	if pub, err := signer.PublicKey(); err == nil {
+		artifact.KeyID = audit.KeyID(pub)
+	}
+	if !cfg.Audit.KeyUsage {
+		return
+	}
+
+	var keyRef string
+	if artifact.Signer == signing.TypeKMS {
+		keyRef = cfg.Signers.KMS.KMSRef
+	}
+	logging.FromContext(ctx).Infow("Signing key used",
+		"keyID", artifact.KeyID,
+		"keyRef", keyRef,
+		"signer", artifact.Signer,
+		"identity", artifact.Identity,
+		"kind", obj.GetKindName(),
+		"namespace", obj.GetNamespace(),
+		"name", obj.GetName(),
+		"uid", string(obj.GetUID()),
+		"artifact", artifact.Key,
+		"payloadDigest", artifact.PayloadDigest,
+	)
+
+	recorder := controller.GetEventRecorder(ctx)
+	ro, ok := obj.GetObject().(runtime.Object)
+	if recorder == nil || !ok {
+		return
+	}
+	annotations := map[string]string{
+		KeyIDEventAnnotation:         artifact.KeyID,
+		ArtifactEventAnnotation:      artifact.Key,
+		PayloadDigestEventAnnotation: artifact.PayloadDigest,
+	}
+	if keyRef != "" {
+		annotations[KeyRefEventAnnotation] = keyRef
+	}
+	recorder.AnnotatedEventf(ro, annotations, corev1.EventTypeNormal, KeyUsedReason,
+		"Signed %s payload %s of %s with %s key %s", artifact.Type, artifact.PayloadDigest, artifact.Key, artifact.Signer, artifact.KeyID)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/controller"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestRecordKeyUsage(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := cachedSigner{SignerVerifier: sv, typ: "kms"}
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "default"}})
+	payload := []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`)
+
+	for _, enabled := range []bool{false, true} {
+		recorder := record.NewFakeRecorder(1)
+		ctx := controller.WithEventRecorder(logtesting.TestContextWithLogger(t), recorder)
+		cfg := config.Config{
+			Audit:   config.AuditConfig{KeyUsage: enabled},
+			Signers: config.SignerConfigs{KMS: config.KMSSigner{KMSRef: "gcpkms://projects/chains/keys/signing"}},
+		}
+		artifact := &audit.Artifact{Type: "tekton", Key: "taskrun-uid", Signer: "kms"}
+		recordKeyUsage(ctx, cfg, obj, artifact, signer, payload)
+
+		if want := audit.KeyID(priv.Public()); artifact.KeyID != want {
+			t.Errorf("KeyID = %q, want %q", artifact.KeyID, want)
+		}
+		if want := audit.Digest(payload); artifact.PayloadDigest != want {
+			t.Errorf("PayloadDigest = %q, want %q", artifact.PayloadDigest, want)
+		}
+
+		select {
+		case event := <-recorder.Events:
+			if !enabled {
+				t.Errorf("recorded event %q with key usage auditing disabled", event)
+			}
+			for _, want := range []string{KeyUsedReason, artifact.KeyID, artifact.PayloadDigest, artifact.Key} {
+				if !strings.Contains(event, want) {
+					t.Errorf("event %q doesn't contain %q", event, want)
+				}
+			}
+		default:
+			if enabled {
+				t.Error("no event recorded with key usage auditing enabled")
+			}
+		}
+	}
+}
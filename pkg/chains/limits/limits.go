@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package limits throttles signing and uploads to storage backends across all runs
+// reconciled by the controller, and caps the runs of each namespace signed at once.
+package limits
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+)
+
+var (
+	mu       sync.RWMutex
+	current  config.ConcurrencyConfig
+	limiter  *rate.Limiter
+	backends map[string]*semaphore.Weighted
+	// signing is the number of runs of each namespace being signed. It outlives config
+	// updates, so that runs acquired with a previous config are still released.
+	signing = map[string]int{}
+)
+
+// Setup installs the signing rate limiter and backend concurrency limits according to
+// cfg. It is safe to call on every config update: the limits are only replaced when
+// they changed. Uploads in flight keep holding the limit they acquired.
+func Setup(cfg config.ConcurrencyConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if reflect.DeepEqual(cfg, current) {
+		return
+	}
+	current = *cfg.DeepCopy()
+
+	limiter = nil
+	if cfg.SigningRate > 0 {
+		burst := cfg.SigningBurst
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(cfg.SigningRate), burst)
+	}
+
+	backends = map[string]*semaphore.Weighted{}
+	for backend, limit := range cfg.BackendLimits {
+		if limit > 0 {
+			backends[backend] = semaphore.NewWeighted(int64(limit))
+		}
+	}
+}
+
+// WaitSigning blocks until the signing rate limit allows signing another payload,
+// or ctx is done.
+func WaitSigning(ctx context.Context) error {
+	mu.RLock()
+	l := limiter
+	mu.RUnlock()
+	if l == nil {
+		return nil
+	}
+	return l.Wait(ctx)
+}
+
+// TryAcquireNamespace reserves signing a run of namespace if fewer runs of namespace than
+// its limit are being signed, without blocking. It returns false if as many are;
+// otherwise the returned function must be called once the run was signed.
+func TryAcquireNamespace(namespace string) (func(), bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	limit, ok := current.NamespaceLimits[namespace]
+	if !ok {
+		limit = current.NamespaceLimit
+	}
+	if limit <= 0 {
+		return func() {}, true
+	}
+	if signing[namespace] >= limit {
+		return nil, false
+	}
+	signing[namespace]++
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if signing[namespace]--; signing[namespace] <= 0 {
+			delete(signing, namespace)
+		}
+	}, true
+}
+
+// AcquireBackend blocks until an upload to backend is allowed, or ctx is done. The
+// returned function must be called once the upload finished.
+func AcquireBackend(ctx context.Context, backend string) (func(), error) {
+	mu.RLock()
+	sem := backends[backend]
+	mu.RUnlock()
+	if sem == nil {
+		return func() {}, nil
+	}
+	if err := sem.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	return func() { sem.Release(1) }, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limits
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+func TestAcquireBackend(t *testing.T) {
+	Setup(config.ConcurrencyConfig{BackendLimits: map[string]int{"oci": 1}})
+	defer Setup(config.ConcurrencyConfig{})
+
+	release, err := AcquireBackend(context.Background(), "oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := AcquireBackend(ctx, "oci"); err == nil {
+		t.Error("expected a second upload to oci to block until the first is released")
+	}
+	if r, err := AcquireBackend(ctx, "gcs"); err != nil {
+		t.Errorf("expected uploads to unlimited backends to proceed: %v", err)
+	} else {
+		r()
+	}
+
+	release()
+	if r, err := AcquireBackend(context.Background(), "oci"); err != nil {
+		t.Errorf("expected upload to proceed once released: %v", err)
+	} else {
+		r()
+	}
+}
+
+func TestTryAcquireNamespace(t *testing.T) {
+	Setup(config.ConcurrencyConfig{NamespaceLimit: 1, NamespaceLimits: map[string]int{"release": 2, "playground": 0}})
+	defer Setup(config.ConcurrencyConfig{})
+
+	release, ok := TryAcquireNamespace("team-a")
+	if !ok {
+		t.Fatal("expected the first run of team-a to be signed")
+	}
+	if _, ok := TryAcquireNamespace("team-a"); ok {
+		t.Error("expected a second run of team-a to wait until the first is signed")
+	}
+	if r, ok := TryAcquireNamespace("team-b"); !ok {
+		t.Error("expected the runs of team-b to be signed while team-a is at its limit")
+	} else {
+		r()
+	}
+	for _, namespace := range []string{"release", "release", "playground", "playground"} {
+		if _, ok := TryAcquireNamespace(namespace); !ok {
+			t.Errorf("expected a run of %s to be signed within its limit", namespace)
+		}
+	}
+	if _, ok := TryAcquireNamespace("release"); ok {
+		t.Error("expected a third run of release to wait")
+	}
+
+	release()
+	if r, ok := TryAcquireNamespace("team-a"); !ok {
+		t.Error("expected a run of team-a to be signed once the first was")
+	} else {
+		r()
+	}
+}
+
+func TestWaitSigning(t *testing.T) {
+	Setup(config.ConcurrencyConfig{SigningRate: 1, SigningBurst: 2})
+	defer Setup(config.ConcurrencyConfig{})
+
+	for i := 0; i < 2; i++ {
+		if err := WaitSigning(context.Background()); err != nil {
+			t.Fatalf("expected burst of 2 to be allowed: %v", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := WaitSigning(ctx); err == nil {
+		t.Error("expected signing above the burst to wait for the rate limit")
+	}
+
+	Setup(config.ConcurrencyConfig{})
+	if err := WaitSigning(ctx); err != nil {
+		t.Errorf("expected no rate limit once unset: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+const (
+	// LocationsAnnotation records where the payloads of the artifacts of a run were
+	// stored, as a JSON object of the locations of each artifact key by backend.
+	LocationsAnnotation = "chains.tekton.dev/locations"
+
+	// rekorLocation is the backend the UUIDs of transparency log entries are recorded
+	// under in the locations of artifacts.
+	rekorLocation = "rekor"
+)
+
+// addLocations records that the payload of artifact was stored at locs in backend.
+func addLocations(artifact *audit.Artifact, backend string, locs ...string) {
+	if len(locs) == 0 {
+		return
+	}
+	if artifact.Locations == nil {
+		artifact.Locations = map[string][]string{}
+	}
+	artifact.Locations[backend] = append(artifact.Locations[backend], locs...)
+}
+
+// locationsAnnotation returns the value of LocationsAnnotation for artifacts, or "" if
+// none of them recorded locations.
+func locationsAnnotation(artifacts []*audit.Artifact) string {
+	locations := map[string]map[string][]string{}
+	for _, a := range artifacts {
+		if len(a.Locations) > 0 {
+			locations[a.Key] = a.Locations
+		}
+	}
+	if len(locations) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(locations)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// rekorUUID returns the UUID of entry in the Rekor v1 log of cfg, or "" if it can't be
+// computed. Entries of Rekor v2 logs are only addressed by index.
+func rekorUUID(cfg config.TransparencyConfig, entry *models.LogEntryAnon) string {
+	if cfg.Version == config.TransparencyV2 || entry == nil || entry.Body == nil {
+		return ""
+	}
+	leaf, err := cosign.ComputeLeafHash(entry)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(leaf)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"testing"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+func TestLocationsAnnotation(t *testing.T) {
+	oci := &audit.Artifact{Key: "oci-1234"}
+	addLocations(oci, "oci", "registry.io/img:sha256-1234.att")
+	addLocations(oci, "tekton")
+	addLocations(oci, rekorLocation, "abcd")
+	tekton := &audit.Artifact{Key: "taskrun-uid"}
+
+	want := `{"oci-1234":{"oci":["registry.io/img:sha256-1234.att"],"rekor":["abcd"]}}`
+	if got := locationsAnnotation([]*audit.Artifact{oci, tekton}); got != want {
+		t.Errorf("locationsAnnotation() = %s, want %s", got, want)
+	}
+	if got := locationsAnnotation([]*audit.Artifact{tekton}); got != "" {
+		t.Errorf("locationsAnnotation() = %s, want no annotation without locations", got)
+	}
+}
+
+func TestRekorUUID(t *testing.T) {
+	if got := rekorUUID(config.TransparencyConfig{}, &models.LogEntryAnon{}); got != "" {
+		t.Errorf("rekorUUID() = %s, want none for entries without a body", got)
+	}
+	// The UUID is the hex-encoded RFC 6962 leaf hash of the body.
+	entry := &models.LogEntryAnon{Body: "Ym9keQ=="}
+	want := "05df4d09b44beff0c39cafd4b550c96c73fc6533a10523a213c5dee10be9056d"
+	if got := rekorUUID(config.TransparencyConfig{}, entry); got != want {
+		t.Errorf("rekorUUID() = %s, want %s", got, want)
+	}
+	if got := rekorUUID(config.TransparencyConfig{Version: config.TransparencyV2}, entry); got != "" {
+		t.Errorf("rekorUUID() = %s, want none for Rekor v2 entries", got)
+	}
+}
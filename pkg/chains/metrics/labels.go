@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"go.opencensus.io/tag"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// OtherValue is recorded for the namespaces outside metrics.labels.namespaces and
+	// the values beyond metrics.labels.max-values.
+	OtherValue = "other"
+
+	defaultMaxLabelValues = 100
+)
+
+var (
+	labelsMu sync.Mutex
+	labels   config.MetricsConfig
+	// recorded are the values recorded so far of each label, up to the maximum.
+	recorded = map[tag.Key]sets.Set[string]{}
+)
+
+// Setup configures the namespace and pipeline labels according to cfg. It is safe to
+// call on every config update: the values recorded so far are only forgotten when cfg
+// changed.
+func Setup(cfg config.MetricsConfig) {
+	labelsMu.Lock()
+	defer labelsMu.Unlock()
+
+	if reflect.DeepEqual(cfg, labels) {
+		return
+	}
+	labels = *cfg.DeepCopy()
+	recorded = map[tag.Key]sets.Set[string]{}
+}
+
+// WithRun returns ctx with the namespace and pipeline labels of a run in namespace, of
+// pipeline if it runs one, if they are enabled. They are recorded with the signing and
+// storage metrics recorded with the returned context.
+func WithRun(ctx context.Context, namespace, pipeline string) context.Context {
+	labelsMu.Lock()
+	defer labelsMu.Unlock()
+
+	var mutators []tag.Mutator
+	if labels.NamespaceLabel {
+		if labels.Namespaces.Len() > 0 && !labels.Namespaces.Has(namespace) {
+			namespace = OtherValue
+		}
+		mutators = append(mutators, tag.Upsert(NamespaceKey, capped(NamespaceKey, namespace)))
+	}
+	if labels.PipelineLabel && pipeline != "" {
+		mutators = append(mutators, tag.Upsert(PipelineKey, capped(PipelineKey, pipeline)))
+	}
+	if len(mutators) == 0 {
+		return ctx
+	}
+	// Errors only occur for invalid tag values, which are dropped rather than failing signing.
+	if tagged, err := tag.New(ctx, mutators...); err == nil {
+		return tagged
+	}
+	return ctx
+}
+
+// capped returns value if it was recorded for key before or fewer values than the
+// maximum were, and OtherValue otherwise. labelsMu must be held.
+func capped(key tag.Key, value string) string {
+	limit := labels.MaxLabelValues
+	if limit == 0 {
+		limit = defaultMaxLabelValues
+	}
+	values, ok := recorded[key]
+	if !ok {
+		values = sets.New[string]()
+		recorded[key] = values
+	}
+	if values.Has(value) || value == OtherValue {
+		return value
+	}
+	if values.Len() >= limit {
+		return OtherValue
+	}
+	values.Insert(value)
+	return value
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"go.opencensus.io/tag"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestWithRun(t *testing.T) {
+	t.Cleanup(func() { Setup(config.MetricsConfig{}) })
+	labelValues := func(namespace, pipeline string) (string, string) {
+		t.Helper()
+		m := tag.FromContext(WithRun(context.Background(), namespace, pipeline))
+		if m == nil {
+			return "", ""
+		}
+		ns, _ := m.Value(NamespaceKey)
+		p, _ := m.Value(PipelineKey)
+		return ns, p
+	}
+
+	// The labels are disabled by default.
+	if ns, p := labelValues("team-a", "build"); ns != "" || p != "" {
+		t.Errorf("expected no labels, got namespace %q and pipeline %q", ns, p)
+	}
+
+	Setup(config.MetricsConfig{
+		NamespaceLabel: true,
+		PipelineLabel:  true,
+		Namespaces:     sets.New[string]("team-a", "team-b", "team-c"),
+		MaxLabelValues: 2,
+	})
+	tests := []struct {
+		namespace, pipeline         string
+		wantNamespace, wantPipeline string
+	}{
+		{"team-a", "build", "team-a", "build"},
+		// Namespaces outside the allow-list are recorded as other.
+		{"sandbox", "test", "other", "test"},
+		// Values beyond the cap are recorded as other, but those recorded before aren't.
+		{"team-b", "deploy", "team-b", "other"},
+		{"team-c", "build", "other", "build"},
+		{"team-a", "", "team-a", ""},
+	}
+	for _, tt := range tests {
+		ns, p := labelValues(tt.namespace, tt.pipeline)
+		if ns != tt.wantNamespace || p != tt.wantPipeline {
+			t.Errorf("WithRun(%q, %q) = %q, %q, want %q, %q", tt.namespace, tt.pipeline, ns, p, tt.wantNamespace, tt.wantPipeline)
+		}
+	}
+
+	// A config change forgets the values recorded so far.
+	Setup(config.MetricsConfig{NamespaceLabel: true, MaxLabelValues: 1})
+	if ns, _ := labelValues("team-c", ""); ns != "team-c" {
+		t.Errorf("expected team-c to be recorded after the config changed, got %q", ns)
+	}
+}
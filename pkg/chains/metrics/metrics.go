@@ -0,0 +1,337 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the OpenCensus measures and views Chains records while
+// generating, signing and storing provenance. They are exported through the
+// Knative metrics exporter configured by config-observability.
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+)
+
+var (
+	KindKey    = tag.MustNewKey("kind")
+	FormatKey  = tag.MustNewKey("format")
+	SignerKey  = tag.MustNewKey("signer")
+	BackendKey = tag.MustNewKey("backend")
+	ResultKey  = tag.MustNewKey("result")
+	ClusterKey = tag.MustNewKey("cluster")
+	AgeKey     = tag.MustNewKey("age")
+	// ComponentKey is the storage backend or signer a health check checked, like
+	// "storage/gcs" or "signer/kms".
+	ComponentKey = tag.MustNewKey("component")
+	// NamespaceKey and PipelineKey are the namespace and pipeline of the run a payload
+	// was signed or stored for, with metrics.labels.namespace and metrics.labels.pipeline.
+	NamespaceKey = tag.MustNewKey("namespace")
+	PipelineKey  = tag.MustNewKey("pipeline")
+	// RetriesRemainingKey is the number of times signing a run is retried before it is
+	// marked as failed.
+	RetriesRemainingKey = tag.MustNewKey("retries_remaining")
+
+	payloadGenerationDuration = stats.Float64(
+		"payload_generation_duration_seconds",
+		"Time taken to generate a payload",
+		stats.UnitSeconds)
+
+	signingDuration = stats.Float64(
+		"signing_duration_seconds",
+		"Time taken to sign a payload",
+		stats.UnitSeconds)
+
+	signingErrors = stats.Int64(
+		"signing_errors_total",
+		"Number of failures signing a payload",
+		stats.UnitDimensionless)
+
+	uploadDuration = stats.Float64(
+		"storage_upload_duration_seconds",
+		"Time taken to store a signed payload in a storage backend",
+		stats.UnitSeconds)
+
+	uploadErrors = stats.Int64(
+		"storage_upload_errors_total",
+		"Number of failures storing a signed payload in a storage backend",
+		stats.UnitDimensionless)
+
+	attestationSize = stats.Int64(
+		"attestation_size_bytes",
+		"Size of the generated payloads",
+		stats.UnitBytes)
+
+	validationFailures = stats.Int64(
+		"payload_validation_failures_total",
+		"Number of payloads that failed validation against the schema of their predicate type",
+		stats.UnitDimensionless)
+
+	conformanceProbes = stats.Int64(
+		"conformance_probes_total",
+		"Number of conformance probes, by result",
+		stats.UnitDimensionless)
+
+	conformanceProbeDuration = stats.Float64(
+		"conformance_probe_duration_seconds",
+		"Time taken by a conformance probe, from creating the canary TaskRun to verifying its provenance",
+		stats.UnitSeconds)
+
+	healthChecks = stats.Int64(
+		"health_check_healthy",
+		"Whether the latest health check of a storage backend or signer passed, 1 if it did and 0 otherwise",
+		stats.UnitDimensionless)
+
+	unsignedRuns = stats.Int64(
+		"unsigned_runs",
+		"Number of completed runs that are not signed yet, by time since they completed",
+		stats.UnitDimensionless)
+
+	oldestUnsignedRunAge = stats.Float64(
+		"oldest_unsigned_run_age_seconds",
+		"Time since the oldest completed run that is not signed yet completed",
+		stats.UnitSeconds)
+
+	unsignedRunRetries = stats.Int64(
+		"unsigned_runs_retries_remaining",
+		"Number of completed runs that are not signed yet, by retries remaining before they are marked as failed",
+		stats.UnitDimensionless)
+
+	failedRuns = stats.Int64(
+		"failed_runs",
+		"Number of runs marked as failed to sign, which are not retried",
+		stats.UnitDimensionless)
+
+	// ageBuckets are the values of AgeKey: the unsigned runs that completed less than
+	// max ago are counted in the first bucket they fit in.
+	ageBuckets = []struct {
+		label string
+		max   time.Duration
+	}{
+		{"5m", 5 * time.Minute},
+		{"1h", time.Hour},
+		{"24h", 24 * time.Hour},
+		{"+Inf", 0},
+	}
+
+	durationBuckets = view.Distribution(metrics.Buckets125(0.001, 100)...)
+	sizeBuckets     = view.Distribution(metrics.BucketsNBy10(100, 7)...)
+
+	// Views are the views registered for the Chains measures.
+	Views = []*view.View{
+		{
+			Description: payloadGenerationDuration.Description(),
+			Measure:     payloadGenerationDuration,
+			Aggregation: durationBuckets,
+			TagKeys:     []tag.Key{KindKey, FormatKey},
+		},
+		{
+			Description: signingDuration.Description(),
+			Measure:     signingDuration,
+			Aggregation: durationBuckets,
+			TagKeys:     []tag.Key{KindKey, FormatKey, SignerKey, NamespaceKey, PipelineKey},
+		},
+		{
+			Description: signingErrors.Description(),
+			Measure:     signingErrors,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{KindKey, FormatKey, SignerKey, NamespaceKey, PipelineKey},
+		},
+		{
+			Description: uploadDuration.Description(),
+			Measure:     uploadDuration,
+			Aggregation: durationBuckets,
+			TagKeys:     []tag.Key{KindKey, FormatKey, BackendKey, NamespaceKey, PipelineKey},
+		},
+		{
+			Description: uploadErrors.Description(),
+			Measure:     uploadErrors,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{KindKey, FormatKey, BackendKey, NamespaceKey, PipelineKey},
+		},
+		{
+			Description: attestationSize.Description(),
+			Measure:     attestationSize,
+			Aggregation: sizeBuckets,
+			TagKeys:     []tag.Key{KindKey, FormatKey},
+		},
+		{
+			Description: validationFailures.Description(),
+			Measure:     validationFailures,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{KindKey, FormatKey},
+		},
+		{
+			Description: conformanceProbes.Description(),
+			Measure:     conformanceProbes,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{ResultKey},
+		},
+		{
+			Description: conformanceProbeDuration.Description(),
+			Measure:     conformanceProbeDuration,
+			Aggregation: durationBuckets,
+			TagKeys:     []tag.Key{ResultKey},
+		},
+		{
+			Description: healthChecks.Description(),
+			Measure:     healthChecks,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{ComponentKey},
+		},
+		{
+			Description: unsignedRuns.Description(),
+			Measure:     unsignedRuns,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{KindKey, ClusterKey, AgeKey},
+		},
+		{
+			Description: oldestUnsignedRunAge.Description(),
+			Measure:     oldestUnsignedRunAge,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{KindKey, ClusterKey},
+		},
+		{
+			Description: unsignedRunRetries.Description(),
+			Measure:     unsignedRunRetries,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{KindKey, ClusterKey, RetriesRemainingKey},
+		},
+		{
+			Description: failedRuns.Description(),
+			Measure:     failedRuns,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{KindKey, ClusterKey},
+		},
+	}
+)
+
+func init() {
+	if err := view.Register(Views...); err != nil {
+		panic(err)
+	}
+}
+
+// RecordPayloadGeneration records how long it took to generate a payload of the given format.
+func RecordPayloadGeneration(ctx context.Context, kind, format string, d time.Duration) {
+	record(ctx, payloadGenerationDuration.M(d.Seconds()), tag.Upsert(KindKey, kind), tag.Upsert(FormatKey, format))
+}
+
+// RecordSigning records how long the given signer took to sign a payload, counting it
+// as an error if err is non-nil.
+func RecordSigning(ctx context.Context, kind, format, signer string, d time.Duration, err error) {
+	mutators := []tag.Mutator{tag.Upsert(KindKey, kind), tag.Upsert(FormatKey, format), tag.Upsert(SignerKey, signer)}
+	record(ctx, signingDuration.M(d.Seconds()), mutators...)
+	if err != nil {
+		record(ctx, signingErrors.M(1), mutators...)
+	}
+}
+
+// RecordUpload records how long it took to store a payload in the given backend,
+// counting it as an error if err is non-nil.
+func RecordUpload(ctx context.Context, kind, format, backend string, d time.Duration, err error) {
+	mutators := []tag.Mutator{tag.Upsert(KindKey, kind), tag.Upsert(FormatKey, format), tag.Upsert(BackendKey, backend)}
+	record(ctx, uploadDuration.M(d.Seconds()), mutators...)
+	if err != nil {
+		record(ctx, uploadErrors.M(1), mutators...)
+	}
+}
+
+// RecordAttestationSize records the size of a serialized payload.
+func RecordAttestationSize(ctx context.Context, kind, format string, size int) {
+	record(ctx, attestationSize.M(int64(size)), tag.Upsert(KindKey, kind), tag.Upsert(FormatKey, format))
+}
+
+// RecordValidationFailure records a payload of the given format that failed validation.
+func RecordValidationFailure(ctx context.Context, kind, format string) {
+	record(ctx, validationFailures.M(1), tag.Upsert(KindKey, kind), tag.Upsert(FormatKey, format))
+}
+
+// RecordConformanceProbe records the outcome of a conformance probe and how long it took.
+func RecordConformanceProbe(ctx context.Context, passed bool, d time.Duration) {
+	result := "failed"
+	if passed {
+		result = "passed"
+	}
+	record(ctx, conformanceProbes.M(1), tag.Upsert(ResultKey, result))
+	record(ctx, conformanceProbeDuration.M(d.Seconds()), tag.Upsert(ResultKey, result))
+}
+
+// RecordHealthCheck records whether the latest health check of component passed.
+func RecordHealthCheck(ctx context.Context, component string, healthy bool) {
+	var value int64
+	if healthy {
+		value = 1
+	}
+	record(ctx, healthChecks.M(value), tag.Upsert(ComponentKey, component))
+}
+
+// Backlog is the state of the completed runs of a kind that are not signed yet.
+type Backlog struct {
+	// Ages are the times since the unsigned runs completed, and RetriesRemaining the
+	// retries remaining for each of them, up to MaxRetriesRemaining.
+	Ages                []time.Duration
+	RetriesRemaining    []int
+	MaxRetriesRemaining int
+	// Failed is the number of runs marked as failed.
+	Failed int
+}
+
+// RecordBacklog records the gauges of the unsigned runs of the given kind watched in
+// cluster. Every age bucket and number of retries remaining is recorded, so that the
+// gauges drop to zero once the runs are signed.
+func RecordBacklog(ctx context.Context, kind, cluster string, b Backlog) {
+	mutators := []tag.Mutator{tag.Upsert(KindKey, kind), tag.Upsert(ClusterKey, cluster)}
+
+	counts := make([]int64, len(ageBuckets))
+	var oldest time.Duration
+	for _, age := range b.Ages {
+		if age > oldest {
+			oldest = age
+		}
+		for i, bucket := range ageBuckets {
+			if bucket.max == 0 || age < bucket.max {
+				counts[i]++
+				break
+			}
+		}
+	}
+	for i, bucket := range ageBuckets {
+		record(ctx, unsignedRuns.M(counts[i]), append(mutators, tag.Upsert(AgeKey, bucket.label))...)
+	}
+	record(ctx, oldestUnsignedRunAge.M(oldest.Seconds()), mutators...)
+
+	retries := make([]int64, b.MaxRetriesRemaining+1)
+	for _, r := range b.RetriesRemaining {
+		if r < 0 {
+			r = 0
+		}
+		if r > b.MaxRetriesRemaining {
+			r = b.MaxRetriesRemaining
+		}
+		retries[r]++
+	}
+	for r, n := range retries {
+		record(ctx, unsignedRunRetries.M(n), append(mutators, tag.Upsert(RetriesRemainingKey, strconv.Itoa(r)))...)
+	}
+	record(ctx, failedRuns.M(int64(b.Failed)), mutators...)
+}
+
+func record(ctx context.Context, m stats.Measurement, mutators ...tag.Mutator) {
+	// Errors only occur for invalid tag values, which are dropped rather than failing signing.
+	_ = stats.RecordWithTags(ctx, mutators, m)
+}
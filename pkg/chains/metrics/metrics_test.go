@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+func TestRecordUpload(t *testing.T) {
+	ctx := context.Background()
+	RecordUpload(ctx, "taskrun", "in-toto", "oci", time.Second, nil)
+	RecordUpload(ctx, "taskrun", "in-toto", "oci", time.Second, errors.New("boom"))
+	RecordUpload(ctx, "taskrun", "in-toto", "oci", time.Second, errors.New("boom"))
+
+	rows, err := view.RetrieveData(uploadErrors.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+	if got := rows[0].Data.(*view.SumData).Value; got != 2 {
+		t.Errorf("expected 2 upload errors, got %v", got)
+	}
+	for _, tg := range rows[0].Tags {
+		if tg.Key == BackendKey && tg.Value != "oci" {
+			t.Errorf("unexpected backend tag %q", tg.Value)
+		}
+	}
+
+	rows, err = view.RetrieveData(uploadDuration.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+	if got := rows[0].Data.(*view.DistributionData).Count; got != 3 {
+		t.Errorf("expected 3 upload durations, got %d", got)
+	}
+}
+
+func TestRecordSigning(t *testing.T) {
+	ctx := context.Background()
+	RecordSigning(ctx, "pipelinerun", "slsa/v1", "x509", 10*time.Millisecond, nil)
+	RecordSigning(ctx, "pipelinerun", "slsa/v1", "kms", 10*time.Millisecond, errors.New("permission denied"))
+
+	rows, err := view.RetrieveData(signingDuration.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("expected one row per signer, got %d", len(rows))
+	}
+
+	rows, err = view.RetrieveData(signingErrors.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Data.(*view.SumData).Value != 1 {
+		t.Errorf("expected 1 signing error, got %+v", rows)
+	}
+}
+
+func TestRecordBacklog(t *testing.T) {
+	ctx := context.Background()
+	RecordBacklog(ctx, "taskrun", "", Backlog{
+		Ages:                []time.Duration{time.Minute, 2 * time.Minute, 2 * time.Hour},
+		RetriesRemaining:    []int{4, 4, 0},
+		MaxRetriesRemaining: 4,
+		Failed:              1,
+	})
+
+	lastValues := func(name string, key tag.Key) map[string]float64 {
+		rows, err := view.RetrieveData(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]float64{}
+		for _, row := range rows {
+			for _, tg := range row.Tags {
+				if tg.Key == key {
+					got[tg.Value] = row.Data.(*view.LastValueData).Value
+				}
+			}
+		}
+		return got
+	}
+	if diff := cmp.Diff(map[string]float64{"5m": 2, "1h": 0, "24h": 1, "+Inf": 0}, lastValues(unsignedRuns.Name(), AgeKey)); diff != "" {
+		t.Errorf("unsigned runs by age diff (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff(map[string]float64{"0": 1, "1": 0, "2": 0, "3": 0, "4": 2}, lastValues(unsignedRunRetries.Name(), RetriesRemainingKey)); diff != "" {
+		t.Errorf("unsigned runs by retries remaining diff (-want +got): %s", diff)
+	}
+	if got := lastValues(oldestUnsignedRunAge.Name(), KindKey)["taskrun"]; got != (2 * time.Hour).Seconds() {
+		t.Errorf("oldest unsigned run age = %v, want 7200", got)
+	}
+
+	// Once the runs are signed, the gauges drop to zero.
+	RecordBacklog(ctx, "taskrun", "", Backlog{MaxRetriesRemaining: 4})
+	if got := lastValues(unsignedRuns.Name(), AgeKey)["5m"]; got != 0 {
+		t.Errorf("unsigned runs = %v, want 0", got)
+	}
+	if got := lastValues(failedRuns.Name(), KindKey)["taskrun"]; got != 0 {
+		t.Errorf("failed runs = %v, want 0", got)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package multicluster lets one Chains controller watch and sign the runs of several
+// workload clusters, in addition to the cluster it runs in, so that the signing keys of
+// a fleet of small clusters are kept in a single place.
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"github.com/tektoncd/pipeline/pkg/client/informers/externalversions"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	customruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/customrun"
+	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/pipelinerun"
+	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+)
+
+// Cluster is a workload cluster whose runs are watched in addition to those of the
+// cluster the controller runs in.
+type Cluster struct {
+	// Name is the name of the cluster, the name of its kubeconfig file.
+	Name string
+	// Config is the config of the clients of the cluster.
+	Config *rest.Config
+
+	once    sync.Once
+	ctx     context.Context
+	factory externalversions.SharedInformerFactory
+}
+
+// Load returns the clusters of the kubeconfig files in dir, in the order of their names.
+// Files whose names start with a dot, like the ones Kubernetes adds to the volumes of
+// Secrets, are skipped. It returns none if dir is empty.
+func Load(dir string) ([]*Cluster, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var clusters []*Cluster
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		cfg, err := clientcmd.BuildConfigFromFlags("", path)
+		if err != nil {
+			return nil, fmt.Errorf("loading the kubeconfig of cluster %s: %w", e.Name(), err)
+		}
+		clusters = append(clusters, &Cluster{Name: e.Name(), Config: cfg})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, nil
+}
+
+type clusterKey struct{}
+
+type homeKubeClientKey struct{}
+
+// FromContext returns the name of the workload cluster the clients and informers in ctx
+// are for, or "" for the cluster the controller runs in.
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(clusterKey{}).(string)
+	return name
+}
+
+// HomeKubeClient returns the Kubernetes clientset of the cluster the controller runs in,
+// where leases are taken, even if the clients in ctx are for a workload cluster.
+func HomeKubeClient(ctx context.Context) kubernetes.Interface {
+	if kc, ok := ctx.Value(homeKubeClientKey{}).(kubernetes.Interface); ok {
+		return kc
+	}
+	return kubeclient.Get(ctx)
+}
+
+// Controllers returns constructors of the controllers of ctors that watch the runs of
+// c. The controllers are given the clients and TaskRun, PipelineRun and CustomRun
+// informers of c, and a queue name of their own so that they are elected leaders
+// independently of the controllers of the other clusters.
+func (c *Cluster) Controllers(ctors ...injection.ControllerConstructor) []injection.ControllerConstructor {
+	wrapped := make([]injection.ControllerConstructor, 0, len(ctors))
+	for _, ctor := range ctors {
+		ctor := ctor
+		wrapped = append(wrapped, func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+			impl := ctor(c.context(ctx), cmw)
+			impl.Name += "." + c.Name
+			// Only the informers requested by the controllers are started, so they are
+			// started once each controller was built.
+			c.factory.Start(ctx.Done())
+			return impl
+		})
+	}
+	return wrapped
+}
+
+// context returns ctx with the clients and informers of c in place of those of the
+// cluster the controller runs in. The informers are shared by the controllers of c.
+func (c *Cluster) context(ctx context.Context) context.Context {
+	c.once.Do(func() {
+		pc := versioned.NewForConfigOrDie(c.Config)
+		ctx = context.WithValue(ctx, homeKubeClientKey{}, kubeclient.Get(ctx))
+		ctx = context.WithValue(ctx, clusterKey{}, c.Name)
+		ctx = context.WithValue(ctx, kubeclient.Key{}, kubernetes.NewForConfigOrDie(c.Config))
+		ctx = context.WithValue(ctx, dynamicclient.Key{}, dynamic.NewForConfigOrDie(c.Config))
+		ctx = context.WithValue(ctx, pipelineclient.Key{}, pc)
+
+		c.factory = externalversions.NewSharedInformerFactoryWithOptions(pc, controller.GetResyncPeriod(ctx),
+			externalversions.WithNamespace(injection.GetNamespaceScope(ctx)))
+		ctx = context.WithValue(ctx, taskruninformer.Key{}, c.factory.Tekton().V1beta1().TaskRuns())
+		ctx = context.WithValue(ctx, pipelineruninformer.Key{}, c.factory.Tekton().V1beta1().PipelineRuns())
+		ctx = context.WithValue(ctx, customruninformer.Key{}, c.factory.Tekton().V1beta1().CustomRuns())
+		c.ctx = ctx
+	})
+	return c.ctx
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: edge
+  cluster:
+    server: https://edge.example.com:6443
+contexts:
+- name: edge
+  context:
+    cluster: edge
+    user: chains
+current-context: edge
+users:
+- name: chains
+  user:
+    token: secret
+`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"edge-2", "edge-1", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(kubeconfig), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Secret volumes hold the files of the Secret in a hidden directory.
+	if err := os.Mkdir(filepath.Join(dir, "..data"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	clusters, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	var names []string
+	for _, c := range clusters {
+		names = append(names, c.Name)
+		if c.Config.Host != "https://edge.example.com:6443" || c.Config.BearerToken != "secret" {
+			t.Errorf("unexpected config of cluster %s: %+v", c.Name, c.Config)
+		}
+	}
+	if diff := cmp.Diff([]string{"edge-1", "edge-2"}, names); diff != "" {
+		t.Errorf("Load() -want +got: %s", diff)
+	}
+
+	if clusters, err := Load(""); err != nil || clusters != nil {
+		t.Errorf("Load(\"\") = %v, %v, want none", clusters, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken"), []byte("not a kubeconfig"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Load() = nil, want an error for the broken kubeconfig")
+	}
+}
+
+type reconciler struct{}
+
+func (reconciler) Reconcile(context.Context, string) error { return nil }
+
+func TestControllers(t *testing.T) {
+	home := fakekube.NewSimpleClientset()
+	ctx := logtesting.TestContextWithLogger(t)
+	ctx = context.WithValue(ctx, kubeclient.Key{}, home)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c := &Cluster{Name: "edge", Config: &rest.Config{Host: "https://edge.example.com:6443"}}
+	var seen []context.Context
+	ctor := func(ctx context.Context, _ configmap.Watcher) *controller.Impl {
+		seen = append(seen, ctx)
+		taskruninformer.Get(ctx).Informer()
+		return controller.NewContext(ctx, reconciler{}, controller.ControllerOptions{WorkQueueName: "taskrun", Logger: logtesting.TestLogger(t)})
+	}
+	ctors := c.Controllers(ctor, ctor)
+	impls := make([]*controller.Impl, 0, len(ctors))
+	for _, ctor := range ctors {
+		impls = append(impls, ctor(ctx, configmap.NewStaticWatcher()))
+	}
+
+	for i, cctx := range seen {
+		if got := FromContext(cctx); got != "edge" {
+			t.Errorf("FromContext() = %q, want edge", got)
+		}
+		if kubeclient.Get(cctx) == home {
+			t.Error("expected the controllers to get the clients of the cluster")
+		}
+		if HomeKubeClient(cctx) != home {
+			t.Error("expected HomeKubeClient() to return the client of the cluster the controller runs in")
+		}
+		if impls[i].Name != "taskrun.edge" {
+			t.Errorf("Name = %q, want taskrun.edge", impls[i].Name)
+		}
+	}
+	if taskruninformer.Get(seen[0]) != taskruninformer.Get(seen[1]) {
+		t.Error("expected the controllers of a cluster to share informers")
+	}
+	if FromContext(ctx) != "" || HomeKubeClient(ctx) != home {
+		t.Error("expected the context of the cluster the controller runs in to be left as is")
+	}
+}
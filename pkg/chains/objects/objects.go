@@ -15,8 +15,10 @@ package objects
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
@@ -26,11 +28,40 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
 )
 
 // Label added to TaskRuns identifying the associated pipeline Task
 const PipelineTaskLabel = "tekton.dev/pipelineTask"
 
+// IterationLabel is a pair of labels custom tasks that fan out iterations set on the
+// TaskRuns of each iteration: the name of the CustomRun and the index of the iteration.
+type IterationLabel struct {
+	CustomRun string
+	Iteration string
+}
+
+// IterationLabels are the IterationLabels of the custom tasks whose iterations are
+// included in the provenance of their PipelineRun: those of PipelineLoops, and those
+// of Chains for other custom tasks to set.
+var IterationLabels = []IterationLabel{
+	{CustomRun: "chains.tekton.dev/custom-run", Iteration: "chains.tekton.dev/iteration"},
+	{CustomRun: "custom.tekton.dev/pipelineLoopRun", Iteration: "custom.tekton.dev/pipelineLoopIteration"},
+}
+
+// Iteration returns the index of the custom task iteration tr ran for, if it did.
+func Iteration(tr *v1beta1.TaskRun) (int, bool) {
+	for _, l := range IterationLabels {
+		if _, ok := tr.Labels[l.CustomRun]; !ok {
+			continue
+		}
+		if i, err := strconv.Atoi(tr.Labels[l.Iteration]); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 // Object is used as a base object of all Kubernetes objects
 // ref: https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.9.4/pkg/client#Object
 type Object interface {
@@ -64,6 +95,7 @@ type TektonObject interface {
 	IsSuccessful() bool
 	SupportsTaskRunArtifact() bool
 	SupportsPipelineRunArtifact() bool
+	SupportsCustomRunArtifact() bool
 	SupportsOCIArtifact() bool
 }
 
@@ -73,6 +105,8 @@ func NewTektonObject(i interface{}) (TektonObject, error) {
 		return NewPipelineRunObject(o), nil
 	case *v1beta1.TaskRun:
 		return NewTaskRunObject(o), nil
+	case *v1beta1.CustomRun:
+		return NewCustomRunObject(o), nil
 	default:
 		return nil, errors.New("unrecognized type when attempting to create tekton object")
 	}
@@ -103,7 +137,10 @@ func (tro *TaskRunObject) GetKindName() string {
 // Get the latest annotations on the TaskRun
 func (tro *TaskRunObject) GetLatestAnnotations(ctx context.Context, clientSet versioned.Interface) (map[string]string, error) {
 	tr, err := clientSet.TektonV1beta1().TaskRuns(tro.Namespace).Get(ctx, tro.Name, metav1.GetOptions{})
-	return tr.Annotations, err
+	if err != nil {
+		return nil, err
+	}
+	return tr.Annotations, nil
 }
 
 // Get the base TaskRun object
@@ -148,6 +185,10 @@ func (tro *TaskRunObject) SupportsPipelineRunArtifact() bool {
 	return false
 }
 
+func (tro *TaskRunObject) SupportsCustomRunArtifact() bool {
+	return false
+}
+
 func (tro *TaskRunObject) SupportsOCIArtifact() bool {
 	return true
 }
@@ -158,6 +199,17 @@ type PipelineRunObject struct {
 	*v1beta1.PipelineRun
 	// taskRuns that were apart of this PipelineRun
 	taskRuns []*v1beta1.TaskRun
+	// taskRunsByTask indexes taskRuns by the name of their pipeline task, as labeled
+	taskRunsByTask map[string][]*v1beta1.TaskRun
+	// taskRunsByName indexes taskRuns by name, to resolve the childReferences of the
+	// PipelineRun
+	taskRunsByName map[string][]*v1beta1.TaskRun
+	// children caches the taskRuns of each pipeline task resolved through the
+	// childReferences, until a TaskRun is appended
+	children map[string][]*v1beta1.TaskRun
+	// iterationsByTask indexes the taskRuns of the iterations of custom tasks by the name
+	// of the pipeline task of the custom task
+	iterationsByTask map[string][]*v1beta1.TaskRun
 }
 
 var _ TektonObject = &PipelineRunObject{}
@@ -180,7 +232,10 @@ func (pro *PipelineRunObject) GetKindName() string {
 // Request the current annotations on the PipelineRun object
 func (pro *PipelineRunObject) GetLatestAnnotations(ctx context.Context, clientSet versioned.Interface) (map[string]string, error) {
 	pr, err := clientSet.TektonV1beta1().PipelineRuns(pro.Namespace).Get(ctx, pro.Name, metav1.GetOptions{})
-	return pr.Annotations, err
+	if err != nil {
+		return nil, err
+	}
+	return pr.Annotations, nil
 }
 
 // Get the base PipelineRun
@@ -220,19 +275,145 @@ func (pro *PipelineRunObject) IsSuccessful() bool {
 // Append TaskRuns to this PipelineRun
 func (pro *PipelineRunObject) AppendTaskRun(tr *v1beta1.TaskRun) {
 	pro.taskRuns = append(pro.taskRuns, tr)
+	if pro.taskRunsByName == nil {
+		pro.taskRunsByName = map[string][]*v1beta1.TaskRun{}
+	}
+	pro.taskRunsByName[tr.Name] = append(pro.taskRunsByName[tr.Name], tr)
+	pro.children = nil
+	taskName, ok := tr.Labels[PipelineTaskLabel]
+	if !ok {
+		return
+	}
+	if pro.taskRunsByTask == nil {
+		pro.taskRunsByTask = map[string][]*v1beta1.TaskRun{}
+	}
+	pro.taskRunsByTask[taskName] = append(pro.taskRunsByTask[taskName], tr)
+}
+
+// AppendIterationTaskRun appends tr, a TaskRun of an iteration of the custom task of
+// the pipeline task with the given name, to this PipelineRun.
+func (pro *PipelineRunObject) AppendIterationTaskRun(taskName string, tr *v1beta1.TaskRun) {
+	pro.taskRuns = append(pro.taskRuns, tr)
+	if pro.iterationsByTask == nil {
+		pro.iterationsByTask = map[string][]*v1beta1.TaskRun{}
+	}
+	pro.iterationsByTask[taskName] = append(pro.iterationsByTask[taskName], tr)
 }
 
-// Get the associated TaskRun via the Task name
+// Get the TaskRuns that were appended to this PipelineRun
+func (pro *PipelineRunObject) GetTaskRuns() []*v1beta1.TaskRun {
+	return pro.taskRuns
+}
+
+// Get the associated TaskRun via the Task name. The first TaskRun of a task, as returned
+// by GetTaskRunsFromTask, is the one it resolves to.
 func (pro *PipelineRunObject) GetTaskRunFromTask(taskName string) *v1beta1.TaskRun {
-	for _, tr := range pro.taskRuns {
-		val, ok := tr.Labels[PipelineTaskLabel]
-		if ok && val == taskName {
-			return tr
-		}
+	if trs := pro.GetTaskRunsFromTask(taskName); len(trs) > 0 {
+		return trs[0]
 	}
 	return nil
 }
 
+// GetTaskRunsFromTask returns the appended TaskRuns of a task. A matrixed task has a
+// TaskRun for each combination of its matrix params. When the PipelineRun references
+// TaskRuns in its childReferences, the TaskRuns are resolved through them, in their
+// order: only the appended TaskRuns it references by name for the task, and that it
+// owns if they have an owner, are returned, so that TaskRuns of a recreated
+// PipelineRun of the same name or with a colliding tekton.dev/pipelineTask label are
+// left out. Otherwise the TaskRuns labeled with the task are returned in the order they
+// were appended.
+func (pro *PipelineRunObject) GetTaskRunsFromTask(taskName string) []*v1beta1.TaskRun {
+	if children := pro.childTaskRuns(); children != nil {
+		return children[taskName]
+	}
+	return pro.taskRunsByTask[taskName]
+}
+
+// childTaskRuns returns the appended TaskRuns of each pipeline task resolved through
+// the childReferences of the PipelineRun, or nil if it references no TaskRuns.
+func (pro *PipelineRunObject) childTaskRuns() map[string][]*v1beta1.TaskRun {
+	if pro.children != nil {
+		return pro.children
+	}
+	var children map[string][]*v1beta1.TaskRun
+	for _, cr := range pro.Status.ChildReferences {
+		if cr.Kind != "TaskRun" {
+			continue
+		}
+		if children == nil {
+			children = map[string][]*v1beta1.TaskRun{}
+		}
+		for _, tr := range pro.taskRunsByName[cr.Name] {
+			if pro.owns(tr) {
+				children[cr.PipelineTaskName] = append(children[cr.PipelineTaskName], tr)
+				break
+			}
+		}
+	}
+	pro.children = children
+	return children
+}
+
+// owns returns whether tr is owned by the PipelineRun, or has no owner to tell.
+func (pro *PipelineRunObject) owns(tr *v1beta1.TaskRun) bool {
+	refs := tr.GetOwnerReferences()
+	if len(refs) == 0 || pro.UID == "" {
+		return true
+	}
+	for _, ref := range refs {
+		if ref.UID == pro.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecutedTasks calls fn with each task, then each finally task, of the resolved
+// pipeline spec whose TaskRun completed, without copying the tasks or their TaskRuns,
+// so that payloads of pipelines with many tasks can be assembled one TaskRun at a
+// time. finally is true for finally tasks. Tasks that did not execute are skipped.
+// fn is called with each TaskRun of a matrixed task that completed, and with each
+// TaskRun of the iterations of a custom task.
+// Iteration stops at the first error returned by fn, which is returned.
+func (pro *PipelineRunObject) ExecutedTasks(ctx context.Context, fn func(t *v1beta1.PipelineTask, tr *v1beta1.TaskRun, finally bool) error) error {
+	pSpec := pro.Status.PipelineSpec
+	if pSpec == nil {
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+	visit := func(tasks []v1beta1.PipelineTask, finally bool) error {
+		for i := range tasks {
+			t := &tasks[i]
+			trs := pro.GetTaskRunsFromTask(t.Name)
+			if !t.IsMatrixed() && len(trs) > 1 {
+				trs = trs[:1]
+			}
+			if its := pro.iterationsByTask[t.Name]; len(its) > 0 {
+				trs = append(append([]*v1beta1.TaskRun{}, trs...), its...)
+			}
+			executed := false
+			for _, tr := range trs {
+				// Ignore TaskRuns that did not complete during the PipelineRun.
+				if tr.Status.CompletionTime == nil {
+					continue
+				}
+				executed = true
+				if err := fn(t, tr, finally); err != nil {
+					return err
+				}
+			}
+			if !executed {
+				logger.Infof("taskrun status not found for task %s", t.Name)
+			}
+		}
+		return nil
+	}
+	if err := visit(pSpec.Tasks, false); err != nil {
+		return err
+	}
+	return visit(pSpec.Finally, true)
+}
+
 // Get the imgPullSecrets from the pod template
 func (pro *PipelineRunObject) GetPullSecrets() []string {
 	return getPodPullSecrets(pro.Spec.PodTemplate)
@@ -246,10 +427,107 @@ func (pro *PipelineRunObject) SupportsPipelineRunArtifact() bool {
 	return true
 }
 
+func (pro *PipelineRunObject) SupportsCustomRunArtifact() bool {
+	return false
+}
+
 func (pro *PipelineRunObject) SupportsOCIArtifact() bool {
 	return false
 }
 
+// CustomRunObject extends v1beta1.CustomRun with additional functions.
+type CustomRunObject struct {
+	*v1beta1.CustomRun
+}
+
+var _ TektonObject = &CustomRunObject{}
+
+func NewCustomRunObject(cr *v1beta1.CustomRun) *CustomRunObject {
+	return &CustomRunObject{
+		cr,
+	}
+}
+
+// Get the CustomRun GroupVersionKind
+func (cro *CustomRunObject) GetGVK() string {
+	return fmt.Sprintf("%s/%s", cro.GetGroupVersionKind().GroupVersion().String(), cro.GetGroupVersionKind().Kind)
+}
+
+func (cro *CustomRunObject) GetKindName() string {
+	return strings.ToLower(cro.GetGroupVersionKind().Kind)
+}
+
+// Get the latest annotations on the CustomRun
+func (cro *CustomRunObject) GetLatestAnnotations(ctx context.Context, clientSet versioned.Interface) (map[string]string, error) {
+	cr, err := clientSet.TektonV1beta1().CustomRuns(cro.Namespace).Get(ctx, cro.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return cr.Annotations, nil
+}
+
+// Get the base CustomRun object
+func (cro *CustomRunObject) GetObject() interface{} {
+	return cro.CustomRun
+}
+
+// Patch the original CustomRun object
+func (cro *CustomRunObject) Patch(ctx context.Context, clientSet versioned.Interface, patchBytes []byte) error {
+	_, err := clientSet.TektonV1beta1().CustomRuns(cro.Namespace).Patch(
+		ctx, cro.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	return err
+}
+
+// Get the CustomRun results. Custom tasks can only produce string results, so
+// results holding a JSON object of strings, such as the structured type hinted
+// results, are returned as object values.
+func (cro *CustomRunObject) GetResults() []Result {
+	res := []Result{}
+	for _, key := range cro.Status.Results {
+		res = append(res, Result{
+			Name:  key.Name,
+			Value: customRunResultValue(key.Value),
+		})
+	}
+	return res
+}
+
+func customRunResultValue(value string) v1beta1.ParamValue {
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var obj map[string]string
+		if err := json.Unmarshal([]byte(value), &obj); err == nil {
+			return *v1beta1.NewObject(obj)
+		}
+	}
+	return *v1beta1.NewStructuredValues(value)
+}
+
+// Get the ServiceAccount declared in the CustomRun
+func (cro *CustomRunObject) GetServiceAccountName() string {
+	return cro.Spec.ServiceAccountName
+}
+
+// CustomRuns have no pod template, so there are no imgPullSecrets.
+func (cro *CustomRunObject) GetPullSecrets() []string {
+	return []string{}
+}
+
+func (cro *CustomRunObject) SupportsTaskRunArtifact() bool {
+	return false
+}
+
+func (cro *CustomRunObject) SupportsPipelineRunArtifact() bool {
+	return false
+}
+
+func (cro *CustomRunObject) SupportsCustomRunArtifact() bool {
+	return true
+}
+
+func (cro *CustomRunObject) SupportsOCIArtifact() bool {
+	return false
+}
+
 // Get the imgPullSecrets from a pod template, if they exist
 func getPodPullSecrets(podTemplate *pod.Template) []string {
 	imgPullSecrets := []string{}
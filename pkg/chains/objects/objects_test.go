@@ -14,6 +14,9 @@ limitations under the License.
 package objects
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,6 +24,8 @@ import (
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func getPullSecretTemplate(pullSecret string) *pod.PodTemplate {
@@ -205,6 +210,31 @@ func TestTaskRun_GetResults(t *testing.T) {
 
 }
 
+func TestCustomRun_GetResults(t *testing.T) {
+	cr := &v1beta1.CustomRun{}
+	cr.Status.Results = []v1beta1.CustomRunResult{
+		{Name: "img1_input_ARTIFACT_INPUTS", Value: `{"uri": "gcr.io/foo/bar", "digest": "sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b7"}`},
+		{Name: "IMAGE_URL", Value: "gcr.io/foo/bar"},
+		{Name: "NOT_AN_OBJECT", Value: `{"a": ["b"]}`},
+	}
+	got := NewCustomRunObject(cr).GetResults()
+	assert.ElementsMatch(t, got, []Result{
+		{
+			Name: "img1_input_ARTIFACT_INPUTS",
+			Value: *v1beta1.NewObject(map[string]string{
+				"uri":    "gcr.io/foo/bar",
+				"digest": "sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b7",
+			}),
+		},
+		{Name: "IMAGE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/bar")},
+		{Name: "NOT_AN_OBJECT", Value: *v1beta1.NewStructuredValues(`{"a": ["b"]}`)},
+	})
+}
+
+func TestCustomRun_GetKindName(t *testing.T) {
+	assert.Equal(t, "customrun", NewCustomRunObject(&v1beta1.CustomRun{}).GetKindName())
+}
+
 func TestPipelineRun_GetGVK(t *testing.T) {
 	assert.Equal(t, "tekton.dev/v1beta1/PipelineRun", NewPipelineRunObject(getPipelineRun()).GetGVK())
 }
@@ -238,6 +268,10 @@ func TestNewTektonObject(t *testing.T) {
 	assert.NoError(t, err)
 	assert.IsType(t, &PipelineRunObject{}, pro)
 
+	cro, err := NewTektonObject(&v1beta1.CustomRun{})
+	assert.NoError(t, err)
+	assert.IsType(t, &CustomRunObject{}, cro)
+
 	unknown, err := NewTektonObject("someting-else")
 	assert.Nil(t, unknown)
 	assert.ErrorContains(t, err, "unrecognized type")
@@ -253,4 +287,110 @@ func TestPipelineRun_GetTaskRunFromTask(t *testing.T) {
 	assert.Nil(t, pro.GetTaskRunFromTask("missing"))
 	tr := pro.GetTaskRunFromTask("foo-task")
 	assert.Equal(t, "foo", tr.Name)
+
+	second := getTaskRun()
+	second.Name = "foo-2"
+	pro.AppendTaskRun(second)
+	assert.Equal(t, "foo", pro.GetTaskRunFromTask("foo-task").Name)
+	assert.Equal(t, []*v1beta1.TaskRun{tr, second}, pro.GetTaskRunsFromTask("foo-task"))
+}
+
+func TestPipelineRun_GetTaskRunsFromTask_ChildReferences(t *testing.T) {
+	pr := getPipelineRun()
+	pr.UID = "pipelinerun-uid"
+	pr.Status.ChildReferences = []v1beta1.ChildStatusReference{
+		{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "foo-build", PipelineTaskName: "build"},
+		{TypeMeta: runtime.TypeMeta{Kind: "TaskRun"}, Name: "foo-test", PipelineTaskName: "test"},
+	}
+	pro := NewPipelineRunObject(pr)
+	taskRun := func(name, task string, owner types.UID) *v1beta1.TaskRun {
+		tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{PipelineTaskLabel: task}}}
+		if owner != "" {
+			tr.OwnerReferences = []metav1.OwnerReference{{Kind: "PipelineRun", Name: "foo", UID: owner}}
+		}
+		return tr
+	}
+	// A TaskRun of a previous PipelineRun of the same name, and one whose label collides
+	// with the build task.
+	stale := taskRun("foo-build", "build", "previous-uid")
+	collision := taskRun("other-build", "build", "")
+	build := taskRun("foo-build", "build", "pipelinerun-uid")
+	test := taskRun("foo-test", "test", "")
+	for _, tr := range []*v1beta1.TaskRun{stale, collision, build, test} {
+		pro.AppendTaskRun(tr)
+	}
+
+	assert.Equal(t, []*v1beta1.TaskRun{build}, pro.GetTaskRunsFromTask("build"))
+	assert.Equal(t, test, pro.GetTaskRunFromTask("test"))
+	assert.Nil(t, pro.GetTaskRunFromTask("missing"))
+
+	// Without childReferences, TaskRuns are resolved by their label.
+	pr.Status.ChildReferences = nil
+	pro = NewPipelineRunObject(pr)
+	pro.AppendTaskRun(collision)
+	assert.Equal(t, collision, pro.GetTaskRunFromTask("build"))
+}
+
+func TestPipelineRun_ExecutedTasks(t *testing.T) {
+	taskRun := func(name, task string, completed bool) *v1beta1.TaskRun {
+		tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{PipelineTaskLabel: task},
+		}}
+		if completed {
+			tr.Status.CompletionTime = &metav1.Time{}
+		}
+		return tr
+	}
+	pr := getPipelineRun()
+	pr.Status.PipelineSpec = &v1beta1.PipelineSpec{
+		Tasks: []v1beta1.PipelineTask{{Name: "build"}, {Name: "test"}, {Name: "skipped"}, {
+			Name: "scan",
+			Matrix: &v1beta1.Matrix{Params: v1beta1.Params{
+				{Name: "platform", Value: *v1beta1.NewStructuredValues("linux", "windows", "mac")},
+			}},
+		}},
+		Finally: []v1beta1.PipelineTask{{Name: "notify"}},
+	}
+	pr.Status.PipelineSpec.Tasks = append(pr.Status.PipelineSpec.Tasks, v1beta1.PipelineTask{
+		Name:    "loop",
+		TaskRef: &v1beta1.TaskRef{APIVersion: "custom.tekton.dev/v1alpha1", Kind: "PipelineLoop"},
+	})
+	pro := NewPipelineRunObject(pr)
+	pro.AppendTaskRun(taskRun("notify-run", "notify", true))
+	pro.AppendTaskRun(taskRun("build-run", "build", true))
+	pro.AppendTaskRun(taskRun("build-retry", "build", true))
+	pro.AppendTaskRun(taskRun("test-run", "test", false))
+	pro.AppendTaskRun(taskRun("scan-linux", "scan", true))
+	pro.AppendTaskRun(taskRun("scan-windows", "scan", false))
+	pro.AppendTaskRun(taskRun("scan-mac", "scan", true))
+	for i, name := range []string{"loop-0", "loop-1"} {
+		tr := taskRun(name, "compile", true)
+		tr.Labels["custom.tekton.dev/pipelineLoopRun"] = "loop-run"
+		tr.Labels["custom.tekton.dev/pipelineLoopIteration"] = fmt.Sprint(i)
+		pro.AppendIterationTaskRun("loop", tr)
+	}
+
+	var got []string
+	err := pro.ExecutedTasks(context.Background(), func(pt *v1beta1.PipelineTask, tr *v1beta1.TaskRun, finally bool) error {
+		got = append(got, fmt.Sprintf("%s/%s/%t", pt.Name, tr.Name, finally))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build/build-run/false", "scan/scan-linux/false", "scan/scan-mac/false", "loop/loop-0/false", "loop/loop-1/false", "notify/notify-run/true"}, got)
+	// The TaskRuns of iterations aren't indexed by the pipeline tasks they ran for.
+	assert.Empty(t, pro.GetTaskRunsFromTask("compile"))
+	_, ok := Iteration(pro.GetTaskRunFromTask("build"))
+	assert.False(t, ok)
+	i, ok := Iteration(pro.GetTaskRuns()[len(pro.GetTaskRuns())-1])
+	assert.True(t, ok)
+	assert.Equal(t, 1, i)
+
+	visited := 0
+	err = pro.ExecutedTasks(context.Background(), func(*v1beta1.PipelineTask, *v1beta1.TaskRun, bool) error {
+		visited++
+		return errors.New("stop")
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, 1, visited)
 }
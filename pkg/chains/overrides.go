@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// OverrideAnnotationPrefix is the prefix of the annotations that override a config key
+// for a single run, e.g. chains.tekton.dev/config.format.
+const OverrideAnnotationPrefix = "chains.tekton.dev/config."
+
+// Overrides returns the config overrides set in the annotations of obj, keyed by config key.
+func Overrides(obj objects.TektonObject) map[string]string {
+	var overrides map[string]string
+	for key, value := range obj.GetAnnotations() {
+		if !strings.HasPrefix(key, OverrideAnnotationPrefix) {
+			continue
+		}
+		if overrides == nil {
+			overrides = map[string]string{}
+		}
+		overrides[strings.TrimPrefix(key, OverrideAnnotationPrefix)] = value
+	}
+	return overrides
+}
+
+// applyOverrides applies the overrides in the annotations of obj to cfg. Storage backends
+// can only be overridden with backends the cluster-wide configuration initialized.
+func applyOverrides(cfg *config.Config, obj objects.TektonObject, backends map[string]storage.Backend) error {
+	overrides := Overrides(obj)
+	if len(overrides) == 0 {
+		return nil
+	}
+	overridden := *cfg
+	if err := overridden.ApplyOverrides(obj.GetKindName(), overrides); err != nil {
+		return err
+	}
+	if value, ok := overrides[config.OverrideStorage]; ok {
+		for _, b := range strings.Split(value, ",") {
+			if _, ok := backends[strings.TrimSpace(b)]; !ok {
+				return fmt.Errorf("storage backend %s is not configured", strings.TrimSpace(b))
+			}
+		}
+	}
+	*cfg = overridden
+	return nil
+}
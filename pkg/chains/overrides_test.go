@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"testing"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	backends := fakeAllBackends([]*mockBackend{{backendType: "tekton"}, {backendType: "oci"}})
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     bool
+		wantFormat  string
+		wantStorage sets.Set[string]
+	}{
+		{
+			name:        "no overrides",
+			wantFormat:  "in-toto",
+			wantStorage: sets.New[string]("tekton"),
+		},
+		{
+			name: "format and storage",
+			annotations: map[string]string{
+				OverrideAnnotationPrefix + "format":  "slsa/v1",
+				OverrideAnnotationPrefix + "storage": "oci",
+				"chains.tekton.dev/signed":           "false",
+			},
+			wantFormat:  "slsa/v1",
+			wantStorage: sets.New[string]("oci"),
+		},
+		{
+			name:        "backend not configured",
+			annotations: map[string]string{OverrideAnnotationPrefix + "storage": "gcs"},
+			wantErr:     true,
+			wantFormat:  "in-toto",
+			wantStorage: sets.New[string]("tekton"),
+		},
+		{
+			name:        "key not allowed",
+			annotations: map[string]string{OverrideAnnotationPrefix + "transparency.enabled": "true"},
+			wantErr:     true,
+			wantFormat:  "in-toto",
+			wantStorage: sets.New[string]("tekton"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")},
+				},
+				Overrides: config.OverridesConfig{Allowed: sets.New[string](config.OverrideFormat, config.OverrideStorage)},
+			}
+			obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+				TypeMeta:   metav1.TypeMeta{Kind: "TaskRun"},
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: tt.annotations},
+			})
+
+			err := applyOverrides(&cfg, obj, backends)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyOverrides() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got := cfg.Artifacts.TaskRuns.Format; got != tt.wantFormat {
+				t.Errorf("format = %q, want %q", got, tt.wantFormat)
+			}
+			if got := cfg.Artifacts.TaskRuns.StorageBackend; !got.Equal(tt.wantStorage) {
+				t.Errorf("storage = %v, want %v", sets.List(got), sets.List(tt.wantStorage))
+			}
+		})
+	}
+}
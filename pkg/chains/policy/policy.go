@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates payloads against Rego policies before they are signed, so
+// that Chains never signs provenance that violates the rules of an organization. The
+// policies are loaded in an OPA server, from a bundle or from ConfigMaps, and payloads
+// are evaluated with its Data API.
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// evaluateTimeout bounds how long evaluating a single payload may hold up signing.
+const evaluateTimeout = 10 * time.Second
+
+// client is the client payloads are evaluated with, so that a hung OPA server never
+// stalls signing.
+var client = &http.Client{Timeout: evaluateTimeout}
+
+// Input is the input payloads are evaluated with.
+type Input struct {
+	Kind        string          `json:"kind"`
+	Namespace   string          `json:"namespace"`
+	Name        string          `json:"name"`
+	PayloadType string          `json:"payloadType"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// DeniedError is returned for payloads that violate the policies.
+type DeniedError struct {
+	// Violations are the messages of the policy violations.
+	Violations []string
+}
+
+func (e *DeniedError) Error() string {
+	return "payload denied by policy: " + strings.Join(e.Violations, "; ")
+}
+
+// Evaluate evaluates input against the policies configured in cfg. It returns a
+// DeniedError if the payload violates them. Payloads are not evaluated when no OPA
+// server is configured.
+func Evaluate(ctx context.Context, cfg config.PolicyConfig, input Input) error {
+	if cfg.URL == "" {
+		return nil
+	}
+	path := cfg.Path
+	if path == "" {
+		path = config.DefaultPolicyPath
+	}
+	body, err := json.Marshal(struct {
+		Input Input `json:"input"`
+	}{Input: input})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, evaluateTimeout)
+	defer cancel()
+	url := strings.TrimSuffix(cfg.URL, "/") + "/v1/data/" + strings.Trim(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("evaluating policy %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("evaluating policy %s: %s: %s", path, resp.Status, bytes.TrimSpace(msg))
+	}
+
+	// The result is left out when the rule is undefined, e.g. because of a typo in the
+	// path, which must not allow the payload.
+	var result struct {
+		Result *[]json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding the result of policy %s, which must be a set of violations: %w", path, err)
+	}
+	if result.Result == nil {
+		return fmt.Errorf("policy %s is undefined", path)
+	}
+	if len(*result.Result) == 0 {
+		return nil
+	}
+	violations := make([]string, 0, len(*result.Result))
+	for _, v := range *result.Result {
+		var msg string
+		if err := json.Unmarshal(v, &msg); err != nil {
+			// Violations that aren't messages are recorded as they are.
+			msg = string(v)
+		}
+		violations = append(violations, msg)
+	}
+	return &DeniedError{Violations: violations}
+}
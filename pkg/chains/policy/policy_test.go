@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	input := Input{
+		Kind:        "taskrun",
+		Namespace:   "ns",
+		Name:        "build",
+		PayloadType: "in-toto",
+		Payload:     json.RawMessage(`{"predicate":{"builder":{"id":"https://tekton.dev/chains/v2"}}}`),
+	}
+	tests := []struct {
+		name       string
+		path       string
+		wantPath   string
+		status     int
+		response   string
+		want       []string
+		wantErr    bool
+		wantDenied bool
+	}{{
+		name:     "allowed",
+		wantPath: "/v1/data/chains/deny",
+		status:   http.StatusOK,
+		response: `{"result": []}`,
+	}, {
+		name:     "undefined",
+		wantPath: "/v1/data/chains/deny",
+		status:   http.StatusOK,
+		response: `{}`,
+		wantErr:  true,
+	}, {
+		name:       "denied",
+		path:       "/org/chains/deny/",
+		wantPath:   "/v1/data/org/chains/deny",
+		status:     http.StatusOK,
+		response:   `{"result": ["builder.id is not trusted", {"msg": "no subjects"}]}`,
+		want:       []string{"builder.id is not trusted", `{"msg": "no subjects"}`},
+		wantErr:    true,
+		wantDenied: true,
+	}, {
+		name:     "not a set",
+		wantPath: "/v1/data/chains/deny",
+		status:   http.StatusOK,
+		response: `{"result": true}`,
+		wantErr:  true,
+	}, {
+		name:     "server error",
+		wantPath: "/v1/data/chains/deny",
+		status:   http.StatusInternalServerError,
+		response: `{"code": "internal_error"}`,
+		wantErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.wantPath {
+					t.Errorf("path = %s, want %s", r.URL.Path, tt.wantPath)
+				}
+				var body struct {
+					Input Input `json:"input"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("error decoding input: %v", err)
+				}
+				if diff := cmp.Diff(input, body.Input); diff != "" {
+					t.Errorf("input -want +got: %s", diff)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			err := Evaluate(logtesting.TestContextWithLogger(t), config.PolicyConfig{URL: srv.URL, Path: tt.path}, input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var denied *DeniedError
+			if errors.As(err, &denied) != tt.wantDenied {
+				t.Fatalf("Evaluate() error = %v, want denied %v", err, tt.wantDenied)
+			}
+			if denied != nil {
+				if diff := cmp.Diff(tt.want, denied.Violations); diff != "" {
+					t.Errorf("Violations -want +got: %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluate_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	old := client
+	client = &http.Client{Timeout: 10 * time.Millisecond}
+	defer func() { client = old }()
+	if err := Evaluate(logtesting.TestContextWithLogger(t), config.PolicyConfig{URL: srv.URL}, Input{}); err == nil {
+		t.Error("Evaluate() = nil with a hung OPA server, want an error")
+	}
+}
+
+func TestEvaluate_Disabled(t *testing.T) {
+	if err := Evaluate(logtesting.TestContextWithLogger(t), config.PolicyConfig{}, Input{}); err != nil {
+		t.Errorf("Evaluate() = %v without an OPA server, want nil", err)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"knative.dev/pkg/logging"
+)
+
+// PreviewPayload is the payload a format generates for a single signable object of a run.
+type PreviewPayload struct {
+	Type   string `json:"type"`
+	Key    string `json:"key"`
+	Format string `json:"format"`
+	// Payload is the unsigned payload, exactly as it would be signed.
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Truncated lists what was dropped from the payload to fit in the maximum
+	// attestation size, if anything.
+	Truncated []string `json:"truncated,omitempty"`
+	// Error is set if the payload could not be generated.
+	Error string `json:"error,omitempty"`
+}
+
+// Preview generates the payloads Chains would sign for tektonObj with cfg and the
+// overrides in the annotations of tektonObj, without signing or storing them. The
+// formats must have been registered, e.g. by importing formats/all.
+func Preview(ctx context.Context, tektonObj objects.TektonObject, cfg config.Config) ([]PreviewPayload, error) {
+	if overrides := Overrides(tektonObj); len(overrides) > 0 {
+		overridden := cfg
+		if err := overridden.ApplyOverrides(tektonObj.GetKindName(), overrides); err != nil {
+			logging.FromContext(ctx).Warnf("Ignoring config overrides of %s %s/%s: %v", tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName(), err)
+		} else {
+			cfg = overridden
+		}
+	}
+	signableTypes, err := getSignableTypes(ctx, tektonObj)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := []PreviewPayload{}
+	for _, signableType := range signableTypes {
+		if !signableType.Enabled(cfg) {
+			continue
+		}
+		payloadFormat := signableType.PayloadFormat(cfg)
+		payloader, err := formats.GetPayloader(payloadFormat, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("format %s configured for %s: %w", payloadFormat, signableType.Type(), err)
+		}
+		for _, obj := range signableType.ExtractObjects(ctx, payloadObject(tektonObj, cfg.Provenance)) {
+			preview := PreviewPayload{
+				Type:   signableType.Type(),
+				Key:    signableType.ShortKey(obj),
+				Format: string(payloadFormat),
+			}
+			payload, err := payloader.CreatePayload(ctx, obj)
+			if err == nil {
+				preview.Payload, err = json.Marshal(payload)
+			}
+			if err == nil {
+				preview.Payload, preview.Truncated, err = truncate(preview.Payload, cfg.Provenance.MaxAttestationKB)
+			}
+			if err != nil {
+				preview.Error = err.Error()
+			}
+			previews = append(previews, preview)
+		}
+	}
+	return previews, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestPreview(t *testing.T) {
+	digest := "sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5"
+	cfg := config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")},
+			OCI:      config.Artifact{Format: "simplesigning", StorageBackend: sets.New[string]("oci")},
+		},
+		Overrides: config.OverridesConfig{Allowed: sets.New[string](config.OverrideFormat)},
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		cfg         func(*config.Config)
+		want        []PreviewPayload
+	}{{
+		name: "configured formats",
+		want: []PreviewPayload{
+			{Type: "tekton", Key: "taskrun-uid", Format: "in-toto"},
+			{Type: "oci", Key: "05f95b26ed10", Format: "simplesigning"},
+		},
+	}, {
+		name:        "format override",
+		annotations: map[string]string{OverrideAnnotationPrefix + config.OverrideFormat: "slsa/v1"},
+		want: []PreviewPayload{
+			{Type: "tekton", Key: "taskrun-uid", Format: "slsa/v1"},
+			{Type: "oci", Key: "05f95b26ed10", Format: "simplesigning"},
+		},
+	}, {
+		name: "disabled artifact",
+		cfg: func(cfg *config.Config) {
+			cfg.Artifacts.OCI.StorageBackend = sets.New[string]("")
+		},
+		want: []PreviewPayload{
+			{Type: "tekton", Key: "taskrun-uid", Format: "in-toto"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cfg
+			if tt.cfg != nil {
+				tt.cfg(&cfg)
+			}
+			tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", UID: "uid", Annotations: tt.annotations},
+				Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+					TaskRunResults: []v1beta1.TaskRunResult{
+						{Name: "IMAGE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/bar")},
+						{Name: "IMAGE_DIGEST", Value: *v1beta1.NewStructuredValues(digest)},
+					},
+				}},
+			})
+			got, err := Preview(context.Background(), tro, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range got {
+				if p.Error != "" || len(p.Payload) == 0 {
+					t.Errorf("no %s payload generated for %s: %s", p.Format, p.Key, p.Error)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(PreviewPayload{}, "Payload")); diff != "" {
+				t.Errorf("Preview() (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPreview_UnknownFormat(t *testing.T) {
+	cfg := config.Config{Artifacts: config.ArtifactConfigs{
+		TaskRuns: config.Artifact{Format: "unknown", StorageBackend: sets.New[string]("tekton")},
+	}}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}})
+	if _, err := Preview(context.Background(), tro, cfg); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
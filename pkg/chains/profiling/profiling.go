@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package profiling serves the pprof endpoints of the controller and periodically writes
+// heap profiles to disk, so that operators can diagnose memory growth in the informer
+// caches and payload generation under production load.
+package profiling
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/internal/httpserver"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// defaultHeapDir is the directory heap profiles are written to when none is configured.
+	defaultHeapDir = "/tmp/chains-profiles"
+	// defaultHeapKeep is the number of heap profiles kept when none is configured.
+	defaultHeapKeep = 10
+	// heapPattern matches the names of the heap profiles written to a directory, which
+	// sort in the order they were written.
+	heapPattern = "heap-*.pb.gz"
+)
+
+var (
+	mu       sync.Mutex
+	current  config.ProfilingConfig
+	stopHeap context.CancelFunc
+
+	server = &httpserver.Server{Name: "pprof endpoints"}
+)
+
+// Setup serves the pprof endpoints on the address in cfg and writes heap profiles at
+// its interval. It is safe to call on every config update: the server and the heap
+// profiles are only restarted when their config changed, and stopped when it is unset.
+func Setup(ctx context.Context, cfg config.ProfilingConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cfg.HeapInterval != current.HeapInterval || cfg.HeapDir != current.HeapDir || cfg.HeapKeep != current.HeapKeep {
+		if stopHeap != nil {
+			stopHeap()
+			stopHeap = nil
+		}
+		if cfg.HeapInterval > 0 {
+			heapCtx, cancel := context.WithCancel(ctx)
+			stopHeap = cancel
+			go captureHeap(heapCtx, cfg)
+		}
+		current.HeapInterval, current.HeapDir, current.HeapKeep = cfg.HeapInterval, cfg.HeapDir, cfg.HeapKeep
+	}
+
+	return server.Serve(ctx, cfg.Address, handler())
+}
+
+// handler serves the pprof endpoints under /debug/pprof/.
+func handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// captureHeap writes a heap profile at the interval of cfg until ctx is done.
+func captureHeap(ctx context.Context, cfg config.ProfilingConfig) {
+	dir := cfg.HeapDir
+	if dir == "" {
+		dir = defaultHeapDir
+	}
+	keep := cfg.HeapKeep
+	if keep <= 0 {
+		keep = defaultHeapKeep
+	}
+	logger := logging.FromContext(ctx)
+	ticker := time.NewTicker(cfg.HeapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			path, err := writeHeapProfile(dir, keep, now)
+			if err != nil {
+				logger.Errorf("error writing heap profile: %v", err)
+				continue
+			}
+			logger.Debugf("wrote heap profile %s", path)
+		}
+	}
+}
+
+// writeHeapProfile writes a heap profile taken at now to dir, and removes all but the
+// keep most recent profiles in it. It returns the path of the profile.
+func writeHeapProfile(dir string, keep int, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "heap-"+now.UTC().Format("20060102T150405.000Z")+".pb.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	// The heap profile reports the memory in use as of the last garbage collection.
+	runtime.GC()
+	if err := rpprof.Lookup("heap").WriteTo(f, 0); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	profiles, err := filepath.Glob(filepath.Join(dir, heapPattern))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(profiles)
+	for len(profiles) > keep {
+		if err := os.Remove(profiles[0]); err != nil {
+			return "", err
+		}
+		profiles = profiles[1:]
+	}
+	return path, nil
+}
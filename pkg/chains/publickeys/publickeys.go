@@ -0,0 +1,234 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package publickeys publishes the verification material of the signers, with the
+// history of the keys they used before, in a ConfigMap every authenticated user can
+// read, so verifiers can discover keys without exchanging them out of band.
+package publickeys
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap the keys are published in, in the
+	// namespace of the controller.
+	ConfigMapName = "chains-public-keys"
+	// KeysKey is the key of the Document in the ConfigMap. The PEM encoded public key
+	// of each signer is also published under its type with a ".pub" suffix, e.g. "x509.pub".
+	KeysKey = "keys.json"
+
+	pubSuffix = ".pub"
+	// maxRetired is the number of retired keys kept in the history.
+	maxRetired = 10
+)
+
+// Document is the verification material published in the ConfigMap.
+type Document struct {
+	// Keys are the keys in use, followed by the keys retired most recently first.
+	Keys []Key `json:"keys"`
+}
+
+// Key is a key a signer uses or used.
+type Key struct {
+	// KeyID is the ID of the key in DSSE envelopes.
+	KeyID  string `json:"keyID"`
+	Signer string `json:"signer"`
+	// PublicKey is the PEM encoded public key.
+	PublicKey string `json:"publicKey"`
+	// Certificate and Chain are the PEM encoded certificate and chain of the key, if any.
+	Certificate string `json:"certificate,omitempty"`
+	Chain       string `json:"chain,omitempty"`
+	// Published is when the key was first published.
+	Published metav1.Time `json:"published"`
+	// Retired is when the key was seen replaced, or its signer removed. It is not set
+	// for keys in use.
+	Retired *metav1.Time `json:"retired,omitempty"`
+}
+
+var (
+	mu sync.Mutex
+	// published are the key IDs last published by signer, to only update the ConfigMap
+	// when signers change.
+	published map[string]string
+)
+
+// Publish publishes the keys of signers in the ConfigMap in namespace, if cfg enables it.
+// Keys that are no longer in use are marked as retired. The ConfigMap is only updated
+// when the keys changed since it was last published. Signers issued certificates by
+// Fulcio are skipped, as their keys are ephemeral.
+func Publish(ctx context.Context, kc kubernetes.Interface, namespace string, cfg config.Config, signers map[string]signing.Signer) error {
+	if !cfg.PublicKeys.Enabled {
+		return nil
+	}
+
+	current := map[string]Key{}
+	ids := map[string]string{}
+	for typ, s := range signers {
+		if typ == signing.TypeX509 && cfg.Signers.X509.FulcioEnabled {
+			continue
+		}
+		k, err := keyOf(typ, s)
+		if err != nil {
+			return fmt.Errorf("reading public key of %s signer: %w", typ, err)
+		}
+		current[typ] = k
+		ids[typ] = k.KeyID
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if published != nil && equal(published, ids) {
+		return nil
+	}
+
+	now := metav1.NewTime(time.Now().UTC())
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := kc.CoreV1().ConfigMaps(namespace).Get(ctx, ConfigMapName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cm, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var doc Document
+		if cm != nil && cm.Data[KeysKey] != "" {
+			if err := json.Unmarshal([]byte(cm.Data[KeysKey]), &doc); err != nil {
+				return fmt.Errorf("parsing %s of ConfigMap %s/%s: %w", KeysKey, namespace, ConfigMapName, err)
+			}
+		}
+		doc = update(doc, current, now)
+		raw, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		data := map[string]string{KeysKey: string(raw)}
+		for typ, k := range current {
+			data[typ+pubSuffix] = k.PublicKey
+		}
+
+		if cm == nil {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ConfigMapName,
+					Namespace: namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/instance": "default",
+						"app.kubernetes.io/part-of":  "tekton-chains",
+					},
+				},
+				Data: data,
+			}
+			_, err = kc.CoreV1().ConfigMaps(namespace).Create(ctx, cm, metav1.CreateOptions{})
+			return err
+		}
+		cm = cm.DeepCopy()
+		cm.Data = data
+		_, err = kc.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("publishing public keys in ConfigMap %s/%s: %w", namespace, ConfigMapName, err)
+	}
+	published = ids
+	return nil
+}
+
+// update returns doc with the keys in use replaced by current, keyed by signer type.
+// Keys no longer in use are retired at now, and only the most recently retired ones
+// are kept.
+func update(doc Document, current map[string]Key, now metav1.Time) Document {
+	var active, retired []Key
+	seen := map[string]bool{}
+	for _, k := range doc.Keys {
+		if k.Retired != nil {
+			retired = append(retired, k)
+			continue
+		}
+		if c, ok := current[k.Signer]; ok && c.KeyID == k.KeyID {
+			// Keep when the key was first published, and pick up a renewed certificate.
+			k.Certificate, k.Chain = c.Certificate, c.Chain
+			active = append(active, k)
+			seen[k.Signer] = true
+			continue
+		}
+		k.Retired = &now
+		retired = append(retired, k)
+	}
+	for _, typ := range sortedKeys(current) {
+		if seen[typ] {
+			continue
+		}
+		k := current[typ]
+		k.Published = now
+		active = append(active, k)
+	}
+
+	sort.SliceStable(retired, func(i, j int) bool { return retired[i].Retired.After(retired[j].Retired.Time) })
+	if len(retired) > maxRetired {
+		retired = retired[:maxRetired]
+	}
+	return Document{Keys: append(active, retired...)}
+}
+
+func keyOf(typ string, s signing.Signer) (Key, error) {
+	pub, err := s.PublicKey()
+	if err != nil {
+		return Key{}, err
+	}
+	id, err := signing.KeyID(pub)
+	if err != nil {
+		return Key{}, err
+	}
+	pem, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{KeyID: id, Signer: typ, PublicKey: string(pem), Certificate: s.Cert(), Chain: s.Chain()}, nil
+}
+
+func equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]Key) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publickeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type fakeSigner struct {
+	signature.SignerVerifier
+	typ string
+}
+
+func (s fakeSigner) Type() string  { return s.typ }
+func (s fakeSigner) Cert() string  { return "" }
+func (s fakeSigner) Chain() string { return "" }
+
+func newSigner(t *testing.T, typ string) signing.Signer {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fakeSigner{SignerVerifier: sv, typ: typ}
+}
+
+func keyID(t *testing.T, s signing.Signer) string {
+	t.Helper()
+	pub, err := s.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := signing.KeyID(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func readDocument(t *testing.T, ctx context.Context, kc *fake.Clientset) (Document, map[string]string) {
+	t.Helper()
+	cm, err := kc.CoreV1().ConfigMaps("tekton-chains").Get(ctx, ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc Document
+	if err := json.Unmarshal([]byte(cm.Data[KeysKey]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc, cm.Data
+}
+
+// summary returns the signer, key ID and whether it was retired of each key in doc.
+func summary(doc Document) []string {
+	var out []string
+	for _, k := range doc.Keys {
+		out = append(out, fmt.Sprintf("%s %s retired=%t", k.Signer, k.KeyID, k.Retired != nil))
+	}
+	return out
+}
+
+func TestPublish(t *testing.T) {
+	published = nil
+	ctx := context.Background()
+	kc := fake.NewSimpleClientset()
+	writes := 0
+	kc.PrependReactor("*", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetVerb() == "create" || action.GetVerb() == "update" {
+			writes++
+		}
+		return false, nil, nil
+	})
+	cfg := config.Config{PublicKeys: config.PublicKeysConfig{Enabled: true}}
+
+	x509Signer, kmsSigner := newSigner(t, signing.TypeX509), newSigner(t, signing.TypeKMS)
+	signers := map[string]signing.Signer{signing.TypeX509: x509Signer, signing.TypeKMS: kmsSigner}
+	if err := Publish(ctx, kc, "tekton-chains", cfg, signers); err != nil {
+		t.Fatal(err)
+	}
+	doc, data := readDocument(t, ctx, kc)
+	want := []string{
+		fmt.Sprintf("kms %s retired=false", keyID(t, kmsSigner)),
+		fmt.Sprintf("x509 %s retired=false", keyID(t, x509Signer)),
+	}
+	if diff := cmp.Diff(want, summary(doc)); diff != "" {
+		t.Errorf("keys (-want +got): %s", diff)
+	}
+	if data["x509.pub"] != doc.Keys[1].PublicKey || data["kms.pub"] != doc.Keys[0].PublicKey {
+		t.Errorf("public keys not published by signer: %v", data)
+	}
+
+	// Publishing the same keys again doesn't write the ConfigMap.
+	if err := Publish(ctx, kc, "tekton-chains", cfg, signers); err != nil {
+		t.Fatal(err)
+	}
+	if writes != 1 {
+		t.Errorf("got %d writes, want 1", writes)
+	}
+
+	// Rotating the x509 key and removing the kms signer retires their keys.
+	rotated := newSigner(t, signing.TypeX509)
+	if err := Publish(ctx, kc, "tekton-chains", cfg, map[string]signing.Signer{signing.TypeX509: rotated}); err != nil {
+		t.Fatal(err)
+	}
+	doc, data = readDocument(t, ctx, kc)
+	want = []string{
+		fmt.Sprintf("x509 %s retired=false", keyID(t, rotated)),
+		fmt.Sprintf("kms %s retired=true", keyID(t, kmsSigner)),
+		fmt.Sprintf("x509 %s retired=true", keyID(t, x509Signer)),
+	}
+	if diff := cmp.Diff(want, summary(doc)); diff != "" {
+		t.Errorf("keys after rotation (-want +got): %s", diff)
+	}
+	if _, ok := data["kms.pub"]; ok {
+		t.Error("public key of the removed kms signer is still published")
+	}
+}
+
+func TestPublish_Fulcio(t *testing.T) {
+	published = nil
+	ctx := context.Background()
+	kc := fake.NewSimpleClientset()
+	cfg := config.Config{PublicKeys: config.PublicKeysConfig{Enabled: true}}
+	cfg.Signers.X509.FulcioEnabled = true
+
+	kmsSigner := newSigner(t, signing.TypeKMS)
+	signers := map[string]signing.Signer{signing.TypeX509: newSigner(t, signing.TypeX509), signing.TypeKMS: kmsSigner}
+	if err := Publish(ctx, kc, "tekton-chains", cfg, signers); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ := readDocument(t, ctx, kc)
+	want := []string{fmt.Sprintf("kms %s retired=false", keyID(t, kmsSigner))}
+	if diff := cmp.Diff(want, summary(doc)); diff != "" {
+		t.Errorf("keys (-want +got): %s", diff)
+	}
+}
+
+func TestUpdate_History(t *testing.T) {
+	var doc Document
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxRetired+3; i++ {
+		current := map[string]Key{signing.TypeX509: {KeyID: fmt.Sprintf("key-%d", i), Signer: signing.TypeX509}}
+		doc = update(doc, current, metav1.NewTime(start.Add(time.Duration(i)*time.Hour)))
+	}
+	if got, want := len(doc.Keys), maxRetired+1; got != want {
+		t.Fatalf("got %d keys, want %d", got, want)
+	}
+	if got := doc.Keys[0]; got.KeyID != fmt.Sprintf("key-%d", maxRetired+2) || got.Retired != nil {
+		t.Errorf("got key in use %+v", got)
+	}
+	if got := doc.Keys[1]; got.KeyID != fmt.Sprintf("key-%d", maxRetired+1) || got.Retired == nil {
+		t.Errorf("got most recently retired key %+v", got)
+	}
+	if got := doc.Keys[1].Published; !got.Equal(&metav1.Time{Time: start.Add(time.Duration(maxRetired+1) * time.Hour)}) {
+		t.Errorf("got published %v for the most recently retired key", got)
+	}
+}
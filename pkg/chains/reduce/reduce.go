@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reduce strips the runs cached by the informers down to what the controllers
+// need to decide whether to sign them, to cut the memory used on large clusters. The
+// full runs are fetched from the API server when they are signed.
+package reduce
+
+import (
+	"context"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// lastAppliedAnnotation holds a copy of the whole run when it was created with
+// kubectl apply.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+type enabledKey struct{}
+
+// WithEnabled returns a copy of ctx in which the controllers cache reduced runs.
+func WithEnabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, enabledKey{}, true)
+}
+
+// Enabled returns whether the controllers cache reduced runs in ctx.
+func Enabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(enabledKey{}).(bool)
+	return enabled
+}
+
+// TaskRun is an informer transform that strips TaskRuns to their metadata, without
+// managed fields, and the parts of their spec and status read before signing and by
+// the attestation query API: the conditions, start and completion times, results,
+// service account and pod template. Other objects are returned unchanged.
+func TaskRun(obj interface{}) (interface{}, error) {
+	tr, ok := obj.(*v1beta1.TaskRun)
+	if !ok {
+		return obj, nil
+	}
+	reduceMeta(&tr.ObjectMeta)
+	tr.Spec = v1beta1.TaskRunSpec{
+		ServiceAccountName: tr.Spec.ServiceAccountName,
+		PodTemplate:        tr.Spec.PodTemplate,
+	}
+	tr.Status = reduceTaskRunStatus(tr.Status)
+	return tr, nil
+}
+
+// PipelineRun is an informer transform that strips PipelineRuns like TaskRun, keeping
+// the references to their TaskRuns. Other objects are returned unchanged.
+func PipelineRun(obj interface{}) (interface{}, error) {
+	pr, ok := obj.(*v1beta1.PipelineRun)
+	if !ok {
+		return obj, nil
+	}
+	reduceMeta(&pr.ObjectMeta)
+	pr.Spec = v1beta1.PipelineRunSpec{
+		ServiceAccountName: pr.Spec.ServiceAccountName,
+		PodTemplate:        pr.Spec.PodTemplate,
+	}
+	status := v1beta1.PipelineRunStatus{Status: pr.Status.Status}
+	status.StartTime = pr.Status.StartTime
+	status.CompletionTime = pr.Status.CompletionTime
+	status.PipelineResults = pr.Status.PipelineResults
+	status.ChildReferences = pr.Status.ChildReferences
+	status.Runs = pr.Status.Runs //nolint:all //incompatible with pipelines v0.45
+	// The embedded status of TaskRuns is only checked for completion.
+	if pr.Status.TaskRuns != nil { //nolint:all //incompatible with pipelines v0.45
+		status.TaskRuns = make(map[string]*v1beta1.PipelineRunTaskRunStatus, len(pr.Status.TaskRuns)) //nolint:all //incompatible with pipelines v0.45
+		for name, trs := range pr.Status.TaskRuns {                                                   //nolint:all //incompatible with pipelines v0.45
+			reduced := &v1beta1.PipelineRunTaskRunStatus{PipelineTaskName: trs.PipelineTaskName}
+			if trs.Status != nil {
+				trStatus := reduceTaskRunStatus(*trs.Status)
+				reduced.Status = &trStatus
+			}
+			status.TaskRuns[name] = reduced //nolint:all //incompatible with pipelines v0.45
+		}
+	}
+	pr.Status = status
+	return pr, nil
+}
+
+func reduceMeta(meta *metav1.ObjectMeta) {
+	meta.ManagedFields = nil
+	delete(meta.Annotations, lastAppliedAnnotation)
+}
+
+func reduceTaskRunStatus(s v1beta1.TaskRunStatus) v1beta1.TaskRunStatus {
+	status := v1beta1.TaskRunStatus{Status: s.Status}
+	status.StartTime = s.StartTime
+	status.CompletionTime = s.CompletionTime
+	status.TaskRunResults = s.TaskRunResults
+	return status
+}
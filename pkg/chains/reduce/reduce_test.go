@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reduce
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+var (
+	meta = metav1.ObjectMeta{
+		Name:      "build",
+		Namespace: "default",
+		Labels:    map[string]string{"app": "foo"},
+		Annotations: map[string]string{
+			"chains.tekton.dev/signed": "true",
+			lastAppliedAnnotation:      `{"spec":{}}`,
+		},
+		Finalizers:    []string{"chains.tekton.dev"},
+		ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+	}
+	reducedMeta = metav1.ObjectMeta{
+		Name:        "build",
+		Namespace:   "default",
+		Labels:      map[string]string{"app": "foo"},
+		Annotations: map[string]string{"chains.tekton.dev/signed": "true"},
+		Finalizers:  []string{"chains.tekton.dev"},
+	}
+	conditions = duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: "True"}}}
+	completed  = &metav1.Time{}
+	template   = &pod.Template{NodeSelector: map[string]string{"disk": "ssd"}}
+)
+
+func TestTaskRun(t *testing.T) {
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: *meta.DeepCopy(),
+		Spec: v1beta1.TaskRunSpec{
+			ServiceAccountName: "builder",
+			PodTemplate:        template,
+			Params:             []v1beta1.Param{{Name: "url"}},
+			TaskSpec:           &v1beta1.TaskSpec{Steps: []v1beta1.Step{{Image: "busybox"}}},
+		},
+		Status: v1beta1.TaskRunStatus{
+			Status: conditions,
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				PodName:        "build-pod",
+				CompletionTime: completed,
+				TaskRunResults: []v1beta1.TaskRunResult{{Name: "IMAGE_DIGEST"}},
+				Steps:          []v1beta1.StepState{{Name: "build"}},
+				TaskSpec:       &v1beta1.TaskSpec{Steps: []v1beta1.Step{{Image: "busybox"}}},
+			},
+		},
+	}
+	want := &v1beta1.TaskRun{
+		ObjectMeta: reducedMeta,
+		Spec:       v1beta1.TaskRunSpec{ServiceAccountName: "builder", PodTemplate: template},
+		Status: v1beta1.TaskRunStatus{
+			Status: conditions,
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				CompletionTime: completed,
+				TaskRunResults: []v1beta1.TaskRunResult{{Name: "IMAGE_DIGEST"}},
+			},
+		},
+	}
+
+	got, err := TaskRun(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("TaskRun() -want +got: %s", diff)
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	pr := &v1beta1.PipelineRun{
+		ObjectMeta: *meta.DeepCopy(),
+		Spec: v1beta1.PipelineRunSpec{
+			ServiceAccountName: "builder",
+			PipelineSpec:       &v1beta1.PipelineSpec{Tasks: []v1beta1.PipelineTask{{Name: "build"}}},
+		},
+		Status: v1beta1.PipelineRunStatus{
+			Status: conditions,
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				CompletionTime:  completed,
+				PipelineResults: []v1beta1.PipelineRunResult{{Name: "IMAGE_DIGEST"}},
+				ChildReferences: []v1beta1.ChildStatusReference{{Name: "build-task"}},
+				PipelineSpec:    &v1beta1.PipelineSpec{Tasks: []v1beta1.PipelineTask{{Name: "build"}}},
+				TaskRuns: map[string]*v1beta1.PipelineRunTaskRunStatus{ //nolint:all //incompatible with pipelines v0.45
+					"build-task": {
+						PipelineTaskName: "build",
+						Status: &v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+							CompletionTime: completed,
+							Steps:          []v1beta1.StepState{{Name: "build"}},
+						}},
+					},
+				},
+			},
+		},
+	}
+	want := &v1beta1.PipelineRun{
+		ObjectMeta: reducedMeta,
+		Spec:       v1beta1.PipelineRunSpec{ServiceAccountName: "builder"},
+		Status: v1beta1.PipelineRunStatus{
+			Status: conditions,
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				CompletionTime:  completed,
+				PipelineResults: []v1beta1.PipelineRunResult{{Name: "IMAGE_DIGEST"}},
+				ChildReferences: []v1beta1.ChildStatusReference{{Name: "build-task"}},
+				TaskRuns: map[string]*v1beta1.PipelineRunTaskRunStatus{ //nolint:all //incompatible with pipelines v0.45
+					"build-task": {
+						PipelineTaskName: "build",
+						Status:           &v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{CompletionTime: completed}},
+					},
+				},
+			},
+		},
+	}
+
+	got, err := PipelineRun(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("PipelineRun() -want +got: %s", diff)
+	}
+}
+
+func TestOtherObjects(t *testing.T) {
+	obj := &v1beta1.Task{ObjectMeta: *meta.DeepCopy()}
+	for _, transform := range []func(interface{}) (interface{}, error){TaskRun, PipelineRun} {
+		got, err := transform(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != obj || len(obj.ManagedFields) == 0 {
+			t.Errorf("expected other objects to be returned unchanged, got %v", got)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	ctx := context.Background()
+	if Enabled(ctx) {
+		t.Error("expected reduced informers to be disabled by default")
+	}
+	if !Enabled(WithEnabled(ctx)) {
+		t.Error("expected reduced informers to be enabled")
+	}
+}
@@ -16,12 +16,23 @@ package chains
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
 
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
 	"github.com/pkg/errors"
 	"github.com/sigstore/cosign/v2/pkg/cosign"
 	rc "github.com/sigstore/rekor/pkg/client"
 	"github.com/sigstore/rekor/pkg/generated/client"
 	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/util"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/tektoncd/chains/pkg/chains/formats"
 	"github.com/tektoncd/chains/pkg/chains/objects"
@@ -73,8 +84,8 @@ func publicKeyOrCert(signer signing.Signer, cert string) ([]byte, error) {
 	return pem, nil
 }
 
-var getRekor = func(url string) (rekorClient, error) {
-	rekorClient, err := rc.GetRekorClient(url)
+var getRekor = func(cfg config.TransparencyConfig) (rekorClient, error) {
+	rekorClient, err := newRekorClient(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +94,82 @@ var getRekor = func(url string) (rekorClient, error) {
 	}, nil
 }
 
+// newRekorClient returns a client of the Rekor v1 API of cfg.URL, connecting to it
+// with the custom CA, client certificate and proxy of cfg if it has any.
+func newRekorClient(cfg config.TransparencyConfig) (*client.Rekor, error) {
+	if !cfg.CustomTransport() {
+		return rc.GetRekorClient(cfg.URL)
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := transparencyTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Requests are retried like those of the clients of rc.GetRekorClient.
+	retryableClient := retryablehttp.NewClient()
+	retryableClient.HTTPClient = &http.Client{Transport: transport}
+	retryableClient.RetryMax = rc.DefaultRetryCount
+	retryableClient.Logger = nil
+	if u.Path == "" {
+		u.Path = client.DefaultBasePath
+	}
+	rt := httptransport.NewWithClient(u.Host, u.Path, []string{u.Scheme}, retryableClient.StandardClient())
+	rt.Consumers["application/json"] = runtime.JSONConsumer()
+	rt.Consumers["application/x-pem-file"] = runtime.TextConsumer()
+	rt.Producers["application/json"] = runtime.JSONProducer()
+	registry := strfmt.Default
+	registry.Add("signedCheckpoint", &util.SignedNote{}, util.SignedCheckpointValidator)
+	return client.New(rt, registry), nil
+}
+
+// transparencyTransport returns the transport the transparency log of cfg is connected
+// to with. The server certificate is verified with the CA bundle at cfg.CAPath in
+// addition to the system roots, the client certificate and key at cfg.CertPath and
+// cfg.KeyPath are presented to it, and requests go through cfg.ProxyURL, or the
+// proxy of the environment when it is empty. The files are read again for every
+// client, so that rotated certificates are picked up.
+func transparencyTransport(cfg config.TransparencyConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing transparency proxy URL")
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if cfg.CAPath == "" && cfg.CertPath == "" {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAPath != "" {
+		pem, err := os.ReadFile(cfg.CAPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading transparency CA bundle")
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in transparency CA bundle %s", cfg.CAPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertPath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading transparency client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
 func shouldUploadTlog(cfg config.Config, obj objects.TektonObject) bool {
 	// if transparency isn't enabled, return false
 	if !cfg.Transparency.Enabled {
@@ -14,7 +14,22 @@ limitations under the License.
 package chains
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/config"
@@ -91,3 +106,90 @@ func TestShouldUploadTlog(t *testing.T) {
 		})
 	}
 }
+
+func TestTransparencyTransport(t *testing.T) {
+	dir := t.TempDir()
+	writePEM := func(name, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// A private Rekor instance requiring client certificates.
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "chains"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	cfg := config.TransparencyConfig{
+		URL:      srv.URL,
+		CAPath:   writePEM("ca.crt", "CERTIFICATE", srv.Certificate().Raw),
+		CertPath: writePEM("tls.crt", "CERTIFICATE", der),
+		KeyPath:  writePEM("tls.key", "EC PRIVATE KEY", keyDER),
+	}
+	get := func(cfg config.TransparencyConfig) error {
+		transport, err := transparencyTransport(cfg)
+		if err != nil {
+			return err
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(cfg.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	if err := get(cfg); err != nil {
+		t.Errorf("connecting with the custom CA and client certificate: %v", err)
+	}
+	withoutCert := cfg
+	withoutCert.CertPath, withoutCert.KeyPath = "", ""
+	if err := get(withoutCert); err == nil {
+		t.Error("expected an error connecting without a client certificate")
+	}
+	withoutCA := cfg
+	withoutCA.CAPath = ""
+	if err := get(withoutCA); err == nil {
+		t.Error("expected an error connecting without the custom CA")
+	}
+
+	// Requests go through the configured proxy.
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.Host == "rekor.corp"
+	}))
+	defer proxy.Close()
+	if err := get(config.TransparencyConfig{URL: "http://rekor.corp", ProxyURL: proxy.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if !proxied {
+		t.Error("request didn't go through the proxy")
+	}
+}
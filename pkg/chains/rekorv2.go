@@ -0,0 +1,320 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// rekorV2EntriesPath is the path entries are uploaded to in Rekor v2 logs.
+const rekorV2EntriesPath = "/api/v2/log/entries"
+
+// rekorV2 uploads entries to a tile-backed Rekor v2 log. In-toto attestations are
+// uploaded as dsse entries and other payloads as hashedrekord entries. Rekor v2 logs
+// return the inclusion proof of an entry and a checkpoint when it is uploaded, instead
+// of a signed entry timestamp.
+type rekorV2 struct {
+	url    string
+	client *http.Client
+}
+
+var getRekorV2 = func(cfg config.TransparencyConfig) (rekorClient, error) {
+	transport, err := transparencyTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &rekorV2{url: strings.TrimSuffix(cfg.URL, "/"), client: &http.Client{Transport: transport}}, nil
+}
+
+// The requests and responses of the Rekor v2 API, in the JSON encoding of their
+// protobuf messages.
+type (
+	rekorV2Request struct {
+		HashedRekord *rekorV2HashedRekord `json:"hashedRekordRequestV002,omitempty"`
+		DSSE         *rekorV2DSSE         `json:"dsseRequestV002,omitempty"`
+	}
+	rekorV2HashedRekord struct {
+		Digest    []byte           `json:"digest"`
+		Signature rekorV2Signature `json:"signature"`
+	}
+	rekorV2DSSE struct {
+		Envelope  json.RawMessage   `json:"envelope"`
+		Verifiers []rekorV2Verifier `json:"verifiers"`
+	}
+	rekorV2Signature struct {
+		Content  []byte          `json:"content"`
+		Verifier rekorV2Verifier `json:"verifier"`
+	}
+	rekorV2Verifier struct {
+		PublicKey       *rekorV2RawBytes `json:"publicKey,omitempty"`
+		X509Certificate *rekorV2RawBytes `json:"x509Certificate,omitempty"`
+		KeyDetails      string           `json:"keyDetails"`
+	}
+	rekorV2RawBytes struct {
+		RawBytes []byte `json:"rawBytes"`
+	}
+
+	rekorV2Entry struct {
+		LogIndex int64 `json:"logIndex,string"`
+		LogID    struct {
+			KeyID []byte `json:"keyId"`
+		} `json:"logId"`
+		IntegratedTime    int64                 `json:"integratedTime,string"`
+		InclusionProof    rekorV2InclusionProof `json:"inclusionProof"`
+		CanonicalizedBody []byte                `json:"canonicalizedBody"`
+	}
+	rekorV2InclusionProof struct {
+		LogIndex   int64    `json:"logIndex,string"`
+		RootHash   []byte   `json:"rootHash"`
+		TreeSize   int64    `json:"treeSize,string"`
+		Hashes     [][]byte `json:"hashes"`
+		Checkpoint struct {
+			Envelope string `json:"envelope"`
+		} `json:"checkpoint"`
+	}
+)
+
+func (r *rekorV2) UploadTlog(ctx context.Context, signer signing.Signer, signature, rawPayload []byte, cert, payloadFormat string) (*models.LogEntryAnon, error) {
+	verifier, err := rekorV2VerifierFor(signer, cert)
+	if err != nil {
+		return nil, errors.Wrap(err, "public key or cert")
+	}
+	var req rekorV2Request
+	if _, ok := formats.IntotoAttestationSet[config.PayloadType(payloadFormat)]; ok {
+		// The signature of in-toto attestations is their DSSE envelope.
+		req.DSSE = &rekorV2DSSE{Envelope: signature, Verifiers: []rekorV2Verifier{verifier}}
+	} else {
+		digest := sha256.Sum256(rawPayload)
+		req.HashedRekord = &rekorV2HashedRekord{
+			Digest:    digest[:],
+			Signature: rekorV2Signature{Content: signature, Verifier: verifier},
+		}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url+rekorV2EntriesPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "uploading entry")
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, &rekorV2StatusError{status: resp.Status, code: resp.StatusCode, body: strings.TrimSpace(string(data))}
+	}
+
+	var entry rekorV2Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, errors.Wrap(err, "decoding entry")
+	}
+	if err := verifyRekorV2Inclusion(entry); err != nil {
+		return nil, errors.Wrapf(err, "verifying inclusion of entry %d", entry.LogIndex)
+	}
+	return rekorV2LogEntry(entry), nil
+}
+
+// rekorV2StatusError is the error of a request the log responded to with a status other
+// than a success. Code returns its status code, like the errors of the Rekor v1 client.
+type rekorV2StatusError struct {
+	status string
+	code   int
+	body   string
+}
+
+func (e *rekorV2StatusError) Error() string {
+	return fmt.Sprintf("uploading entry: %s: %s", e.status, e.body)
+}
+
+func (e *rekorV2StatusError) Code() int {
+	return e.code
+}
+
+// verifyRekorV2Inclusion verifies that the inclusion proof of entry proves that its body
+// is included in the log at the size and root hash of its checkpoint. The signature of
+// the checkpoint is not verified, logs are not trusted with their keys here.
+func verifyRekorV2Inclusion(entry rekorV2Entry) error {
+	p := entry.InclusionProof
+	if p.LogIndex != entry.LogIndex || p.TreeSize <= p.LogIndex || p.LogIndex < 0 {
+		return fmt.Errorf("inclusion proof for index %d in a tree of size %d", p.LogIndex, p.TreeSize)
+	}
+	leaf := rfc6962.DefaultHasher.HashLeaf(entry.CanonicalizedBody)
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, uint64(p.LogIndex), uint64(p.TreeSize), leaf, p.Hashes, p.RootHash); err != nil {
+		return err
+	}
+	var checkpoint util.SignedCheckpoint
+	if err := checkpoint.UnmarshalText([]byte(p.Checkpoint.Envelope)); err != nil {
+		return errors.Wrap(err, "parsing checkpoint")
+	}
+	if checkpoint.Size != uint64(p.TreeSize) || !bytes.Equal(checkpoint.Hash, p.RootHash) {
+		return fmt.Errorf("checkpoint of size %d doesn't match the inclusion proof", checkpoint.Size)
+	}
+	return nil
+}
+
+// rekorV2LogEntry returns entry in the format of the entries of Rekor v1 logs: its body,
+// log ID and root and proof hashes are encoded like theirs, and its inclusion proof
+// takes the place of the signed entry timestamp.
+func rekorV2LogEntry(entry rekorV2Entry) *models.LogEntryAnon {
+	p := entry.InclusionProof
+	hashes := make([]string, 0, len(p.Hashes))
+	for _, h := range p.Hashes {
+		hashes = append(hashes, hex.EncodeToString(h))
+	}
+	logID := hex.EncodeToString(entry.LogID.KeyID)
+	rootHash := hex.EncodeToString(p.RootHash)
+	checkpoint := p.Checkpoint.Envelope
+	return &models.LogEntryAnon{
+		Body:           entry.CanonicalizedBody,
+		IntegratedTime: &entry.IntegratedTime,
+		LogID:          &logID,
+		LogIndex:       &entry.LogIndex,
+		Verification: &models.LogEntryAnonVerification{
+			InclusionProof: &models.InclusionProof{
+				Checkpoint: &checkpoint,
+				Hashes:     hashes,
+				LogIndex:   &p.LogIndex,
+				RootHash:   &rootHash,
+				TreeSize:   &p.TreeSize,
+			},
+		},
+	}
+}
+
+// rekorV2VerifierFor returns the verifier of the signatures of signer, its certificate
+// if it has one and its public key otherwise.
+func rekorV2VerifierFor(signer signing.Signer, cert string) (rekorV2Verifier, error) {
+	pkoc, err := publicKeyOrCert(signer, cert)
+	if err != nil {
+		return rekorV2Verifier{}, err
+	}
+	if cert != "" {
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(pkoc)
+		if err != nil {
+			return rekorV2Verifier{}, err
+		}
+		if len(certs) == 0 {
+			return rekorV2Verifier{}, errors.New("no certificate")
+		}
+		details, err := rekorV2KeyDetails(certs[0].PublicKey)
+		if err != nil {
+			return rekorV2Verifier{}, err
+		}
+		return rekorV2Verifier{X509Certificate: &rekorV2RawBytes{RawBytes: certs[0].Raw}, KeyDetails: details}, nil
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(pkoc)
+	if err != nil {
+		return rekorV2Verifier{}, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return rekorV2Verifier{}, err
+	}
+	details, err := rekorV2KeyDetails(pub)
+	if err != nil {
+		return rekorV2Verifier{}, err
+	}
+	return rekorV2Verifier{PublicKey: &rekorV2RawBytes{RawBytes: der}, KeyDetails: details}, nil
+}
+
+// rekorV2KeyDetails returns the PublicKeyDetails of the Sigstore specifications for
+// the keys Chains signs with.
+func rekorV2KeyDetails(pub crypto.PublicKey) (string, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return "PKIX_ECDSA_P256_SHA_256", nil
+		case elliptic.P384():
+			return "PKIX_ECDSA_P384_SHA_384", nil
+		case elliptic.P521():
+			return "PKIX_ECDSA_P521_SHA_512", nil
+		}
+	case ed25519.PublicKey:
+		return "PKIX_ED25519", nil
+	case *rsa.PublicKey:
+		switch k.Size() * 8 {
+		case 2048:
+			return "PKIX_RSA_PKCS1V15_2048_SHA256", nil
+		case 3072:
+			return "PKIX_RSA_PKCS1V15_3072_SHA256", nil
+		case 4096:
+			return "PKIX_RSA_PKCS1V15_4096_SHA256", nil
+		}
+	}
+	return "", fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// transparencyClient returns the client entries are uploaded to the transparency log
+// of cfg with.
+func transparencyClient(cfg config.TransparencyConfig) (rekorClient, error) {
+	if cfg.Version == config.TransparencyV2 {
+		return getRekorV2(cfg)
+	}
+	return getRekor(cfg)
+}
+
+// transparencyEntryURL returns the URL of the entry with the given index in the
+// transparency log of cfg. Rekor v2 logs don't serve entries by index: the URL of
+// the entry bundle tile holding the entry is returned for them, as specified by
+// https://c2sp.org/tlog-tiles. Until the tile is full, it is served as a partial tile.
+func transparencyEntryURL(cfg config.TransparencyConfig, logIndex int64) string {
+	if cfg.Version != config.TransparencyV2 {
+		return fmt.Sprintf("%s/api/v1/log/entries?logIndex=%d", cfg.URL, logIndex)
+	}
+	return fmt.Sprintf("%s/tile/entries/%s", strings.TrimSuffix(cfg.URL, "/"), tilePath(uint64(logIndex)/256))
+}
+
+// tilePath encodes the index of a tile as a path of 3-digit elements, all of them but
+// the last prefixed with x, e.g. x001/x234/067 for 1234067.
+func tilePath(n uint64) string {
+	elems := []string{fmt.Sprintf("%03d", n%1000)}
+	for n /= 1000; n > 0; n /= 1000 {
+		elems = append([]string{fmt.Sprintf("x%03d", n%1000)}, elems...)
+	}
+	return strings.Join(elems, "/")
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+func TestRekorV2_UploadTlog(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := cachedSigner{SignerVerifier: sv, typ: "x509"}
+
+	// The uploaded entry is the third leaf of the log.
+	h := rfc6962.DefaultHasher
+	h0, h1 := h.HashLeaf([]byte("first")), h.HashLeaf([]byte("second"))
+	var requests []rekorV2Request
+	corrupt := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != rekorV2EntriesPath {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req rekorV2Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, req)
+
+		root := h.HashChildren(h.HashChildren(h0, h1), h.HashLeaf(body))
+		if corrupt {
+			root = h.HashLeaf(root)
+		}
+		checkpoint := fmt.Sprintf("rekor.example.com\n3\n%s\n\n— rekor.example.com %s\n",
+			base64.StdEncoding.EncodeToString(root), base64.StdEncoding.EncodeToString([]byte("signature")))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"logIndex":       "2",
+			"logId":          map[string][]byte{"keyId": []byte("log")},
+			"kindVersion":    map[string]string{"kind": "hashedrekord", "version": "0.0.2"},
+			"integratedTime": "0",
+			"inclusionProof": map[string]interface{}{
+				"logIndex":   "2",
+				"rootHash":   root,
+				"treeSize":   "3",
+				"hashes":     [][]byte{h.HashChildren(h0, h1)},
+				"checkpoint": map[string]string{"envelope": checkpoint},
+			},
+			"canonicalizedBody": body,
+		})
+	}))
+	defer srv.Close()
+
+	rekor, err := getRekorV2(config.TransparencyConfig{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	payload := []byte(`{"spec":{}}`)
+	entry, err := rekor.UploadTlog(ctx, signer, []byte("sig"), payload, "", "tekton")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *entry.LogIndex != 2 || *entry.Verification.InclusionProof.TreeSize != 3 || *entry.LogID != hex.EncodeToString([]byte("log")) {
+		t.Errorf("entry = %+v", entry)
+	}
+	req := requests[0].HashedRekord
+	if digest := sha256.Sum256(payload); req == nil || string(req.Digest) != string(digest[:]) || string(req.Signature.Content) != "sig" {
+		t.Fatalf("hashedrekord request = %+v", req)
+	}
+	if req.Signature.Verifier.KeyDetails != "PKIX_ECDSA_P256_SHA_256" || req.Signature.Verifier.PublicKey == nil {
+		t.Errorf("verifier = %+v", req.Signature.Verifier)
+	}
+
+	envelope := []byte(`{"payloadType":"application/vnd.in-toto+json","payload":"e30=","signatures":[{"sig":"c2ln"}]}`)
+	if _, err := rekor.UploadTlog(ctx, signer, envelope, payload, "", "slsa/v1"); err != nil {
+		t.Fatal(err)
+	}
+	if dsse := requests[1].DSSE; dsse == nil || string(dsse.Envelope) != string(envelope) || len(dsse.Verifiers) != 1 {
+		t.Errorf("dsse request = %+v", dsse)
+	}
+
+	corrupt = true
+	if _, err := rekor.UploadTlog(ctx, signer, []byte("sig"), payload, "", "tekton"); err == nil {
+		t.Error("expected an error for an entry whose inclusion proof is invalid")
+	}
+}
+
+func TestTransparencyEntryURL(t *testing.T) {
+	tests := []struct {
+		cfg      config.TransparencyConfig
+		logIndex int64
+		want     string
+	}{
+		{config.TransparencyConfig{URL: "https://rekor.sigstore.dev"}, 1, "https://rekor.sigstore.dev/api/v1/log/entries?logIndex=1"},
+		{config.TransparencyConfig{URL: "https://rekor.example.com/", Version: config.TransparencyV2}, 255, "https://rekor.example.com/tile/entries/000"},
+		{config.TransparencyConfig{URL: "https://rekor.example.com", Version: config.TransparencyV2}, 1234067 * 256, "https://rekor.example.com/tile/entries/x001/x234/067"},
+	}
+	for _, tt := range tests {
+		if got := transparencyEntryURL(tt.cfg, tt.logIndex); got != tt.want {
+			t.Errorf("transparencyEntryURL(%d) = %q, want %q", tt.logIndex, got, tt.want)
+		}
+	}
+}
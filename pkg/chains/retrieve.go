@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// StoredAttestation is a signed payload of a run retrieved from a storage backend.
+type StoredAttestation struct {
+	// Backend is the name of the storage backend it was retrieved from.
+	Backend string
+	// Key is its reference in the backend.
+	Key       string
+	Payload   []byte
+	Signature []byte
+}
+
+// errBackendNotConfigured is returned for storage backends that are configured for a
+// run but weren't initialized.
+var errBackendNotConfigured = errors.New("storage backend is not configured")
+
+// RetrieveAttestations retrieves the attestations of the TaskRun, PipelineRun or CustomRun obj
+// from the storage backends that cfg, with the overrides in the annotations of obj,
+// configures for it. The errors of the backends that could not be read are returned
+// keyed by backend; an error is only returned if obj is not signed with cfg.
+func RetrieveAttestations(ctx context.Context, obj objects.TektonObject, backends map[string]storage.Backend, cfg config.Config) ([]StoredAttestation, map[string]error, error) {
+	if overrides := Overrides(obj); len(overrides) > 0 {
+		overridden := cfg
+		if err := overridden.ApplyOverrides(obj.GetKindName(), overrides); err == nil {
+			cfg = overridden
+		}
+	}
+
+	var signable artifacts.Signable
+	switch obj.(type) {
+	case *objects.TaskRunObject:
+		signable = &artifacts.TaskRunArtifact{}
+	case *objects.PipelineRunObject:
+		signable = &artifacts.PipelineRunArtifact{}
+	case *objects.CustomRunObject:
+		signable = &artifacts.CustomRunArtifact{}
+	default:
+		return nil, nil, fmt.Errorf("unsupported object %T", obj)
+	}
+	if !signable.Enabled(cfg) {
+		return nil, nil, fmt.Errorf("signing %ss is disabled in %s", obj.GetKindName(), config.ChainsConfig)
+	}
+	opts := config.StorageOpts{
+		ShortKey:      signable.ShortKey(obj),
+		FullKey:       signable.FullKey(obj),
+		PayloadFormat: signable.PayloadFormat(cfg),
+	}
+
+	var attestations []StoredAttestation
+	errs := map[string]error{}
+	for _, name := range sets.List[string](signable.StorageBackend(cfg)) {
+		b, ok := backends[name]
+		if !ok {
+			errs[name] = errBackendNotConfigured
+			continue
+		}
+		payloads, err := b.RetrievePayloads(ctx, obj, opts)
+		if err != nil {
+			errs[name] = fmt.Errorf("retrieving payloads: %w", err)
+			continue
+		}
+		signatures, err := b.RetrieveSignatures(ctx, obj, opts)
+		if err != nil {
+			errs[name] = fmt.Errorf("retrieving signatures: %w", err)
+			continue
+		}
+		for _, a := range pairAttestations(payloads, signatures) {
+			a.Backend = name
+			if a.Key == "" {
+				a.Key = opts.ShortKey
+			}
+			attestations = append(attestations, a)
+		}
+	}
+	return attestations, errs, nil
+}
+
+// pairAttestations matches the payloads and signatures retrieved from a storage
+// backend. They are matched by reference if both use the same references, otherwise a
+// single payload is matched with every signature, without a key. Empty payloads and
+// signatures are ignored.
+func pairAttestations(payloads map[string]string, signatures map[string][]string) []StoredAttestation {
+	var out []StoredAttestation
+	var single string
+	nonEmpty := 0
+	for _, p := range payloads {
+		if p != "" {
+			single = p
+			nonEmpty++
+		}
+	}
+	for _, ref := range sets.List[string](sets.KeySet(signatures)) {
+		payload, ok := payloads[ref]
+		key := ref
+		if !ok && nonEmpty == 1 {
+			payload, key = single, ""
+		}
+		for _, s := range signatures[ref] {
+			if s == "" || payload == "" {
+				continue
+			}
+			out = append(out, StoredAttestation{Key: key, Payload: []byte(payload), Signature: []byte(s)})
+		}
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+// Error classes that can be configured with retry.no-retry-errors.
+const (
+	ErrorClassSigning      = "signing"
+	ErrorClassStorage      = "storage"
+	ErrorClassTransparency = "transparency"
+	ErrorClassPolicy       = "policy"
+)
+
+// ClassifiedError is an error that occurred in a given stage of the signing pipeline.
+type ClassifiedError struct {
+	Class string
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string {
+	return e.Class + ": " + e.Err.Error()
+}
+
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+func classify(class string, err error) error {
+	return &ClassifiedError{Class: class, Err: err}
+}
+
+// isRetryable returns false if any of the errors belongs to a class that should not be retried.
+func isRetryable(merr *multierror.Error, cfg config.RetryConfig) bool {
+	for _, err := range merr.WrappedErrors() {
+		var ce *ClassifiedError
+		if errors.As(err, &ce) && cfg.NoRetryErrors.Has(ce.Class) {
+			return false
+		}
+	}
+	return true
+}
+
+// retryCount returns the number of retries already recorded on the object.
+func retryCount(obj objects.TektonObject) int {
+	val, err := strconv.Atoi(obj.GetAnnotations()[RetryAnnotation])
+	if err != nil {
+		return -1
+	}
+	return val
+}
+
+// Backoff returns the delay before the given retry (starting at 0) according to cfg.
+func Backoff(cfg config.RetryConfig, retry int) time.Duration {
+	if cfg.InitialBackoff <= 0 {
+		return 0
+	}
+	multiplier := cfg.BackoffMultiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(cfg.InitialBackoff) * math.Pow(multiplier, float64(retry))
+	if cfg.MaxBackoff > 0 && delay > float64(cfg.MaxBackoff) {
+		delay = float64(cfg.MaxBackoff)
+	}
+	if cfg.Jitter > 0 {
+		delay += delay * cfg.Jitter * rand.Float64() //nolint:gosec // jitter does not need a secure source
+	}
+	return time.Duration(delay)
+}
+
+// maxRetries returns the number of retries before an object is marked as failed.
+func maxRetries(cfg config.RetryConfig) int {
+	if cfg.MaxRetries <= 0 {
+		return MaxRetries
+	}
+	return cfg.MaxRetries
+}
+
+// failsPermanently returns whether handleFailure marks obj as failed for merr instead
+// of retrying it.
+func failsPermanently(obj objects.TektonObject, merr *multierror.Error, cfg config.RetryConfig) bool {
+	return !isRetryable(merr, cfg) || !retryAvailable(obj, maxRetries(cfg))
+}
+
+// handleFailure applies the configured retry policy after signing obj failed with merr.
+// It returns the error the reconciler should surface: a permanent error if the object was
+// marked as failed for a non-retryable error, a requeue request if a backoff is configured,
+// or merr itself so the controller's rate limiter schedules the retry.
+func handleFailure(ctx context.Context, obj objects.TektonObject, ps versioned.Interface, merr *multierror.Error, annotations map[string]string, cfg config.RetryConfig) error {
+	logger := logging.FromContext(ctx)
+
+	if !isRetryable(merr, cfg) {
+		logger.Warnf("Not retrying %s %s/%s: %v", obj.GetGVK(), obj.GetNamespace(), obj.GetName(), merr)
+		if err := MarkFailed(ctx, obj, ps, annotations); err != nil {
+			return multierror.Append(merr, err)
+		}
+		return controller.NewPermanentError(merr)
+	}
+
+	if !retryAvailable(obj, maxRetries(cfg)) {
+		if err := MarkFailed(ctx, obj, ps, annotations); err != nil {
+			return multierror.Append(merr, err)
+		}
+		return merr
+	}
+
+	if err := AddRetry(ctx, obj, ps, annotations); err != nil {
+		logger.Warnf("error handling retry: %v", err)
+		return multierror.Append(merr, err)
+	}
+	if delay := Backoff(cfg, retryCount(obj)+1); delay > 0 {
+		logger.Infof("Retrying %s %s/%s in %s: %v", obj.GetGVK(), obj.GetNamespace(), obj.GetName(), delay, merr)
+		return controller.NewRequeueAfter(delay)
+	}
+	return merr
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/controller"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.RetryConfig
+		retry int
+		want  time.Duration
+	}{
+		{
+			name:  "disabled",
+			cfg:   config.RetryConfig{},
+			retry: 2,
+			want:  0,
+		},
+		{
+			name:  "first retry",
+			cfg:   config.RetryConfig{InitialBackoff: time.Second, BackoffMultiplier: 2},
+			retry: 0,
+			want:  time.Second,
+		},
+		{
+			name:  "exponential",
+			cfg:   config.RetryConfig{InitialBackoff: time.Second, BackoffMultiplier: 2},
+			retry: 3,
+			want:  8 * time.Second,
+		},
+		{
+			name:  "capped",
+			cfg:   config.RetryConfig{InitialBackoff: time.Second, BackoffMultiplier: 2, MaxBackoff: 5 * time.Second},
+			retry: 3,
+			want:  5 * time.Second,
+		},
+		{
+			name:  "constant without multiplier",
+			cfg:   config.RetryConfig{InitialBackoff: time.Second},
+			retry: 3,
+			want:  time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Backoff(tt.cfg, tt.retry); got != tt.want {
+				t.Errorf("Backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	cfg := config.RetryConfig{InitialBackoff: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if got := Backoff(cfg, 0); got < time.Second || got > 1500*time.Millisecond {
+			t.Fatalf("Backoff() = %v, want between 1s and 1.5s", got)
+		}
+	}
+}
+
+func TestHandleFailure(t *testing.T) {
+	storageErr := multierror.Append(nil, classify(ErrorClassStorage, errors.New("registry unavailable")))
+	signingErr := multierror.Append(nil, classify(ErrorClassSigning, errors.New("key not found")))
+
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		merr          *multierror.Error
+		cfg           config.RetryConfig
+		wantPermanent bool
+		wantRequeue   time.Duration
+		wantFailed    bool
+		wantRetries   string
+	}{
+		{
+			name:        "retry with rate limiter",
+			merr:        storageErr,
+			wantRetries: "0",
+		},
+		{
+			name:        "retry with backoff",
+			annotations: map[string]string{RetryAnnotation: "1"},
+			merr:        storageErr,
+			cfg:         config.RetryConfig{InitialBackoff: time.Second, BackoffMultiplier: 2},
+			wantRequeue: 4 * time.Second,
+			wantRetries: "2",
+		},
+		{
+			name:        "retries exhausted",
+			annotations: map[string]string{RetryAnnotation: "5"},
+			merr:        storageErr,
+			cfg:         config.RetryConfig{MaxRetries: 5},
+			wantFailed:  true,
+			wantRetries: "5",
+		},
+		{
+			name:          "non-retryable error class",
+			merr:          signingErr,
+			cfg:           config.RetryConfig{NoRetryErrors: sets.New[string](ErrorClassSigning)},
+			wantPermanent: true,
+			wantFailed:    true,
+		},
+		{
+			name:        "retryable error class",
+			merr:        storageErr,
+			cfg:         config.RetryConfig{NoRetryErrors: sets.New[string](ErrorClassSigning)},
+			wantRetries: "0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: tt.annotations},
+			})
+			tekton.CreateObject(t, ctx, ps, obj)
+
+			if got := failsPermanently(obj, tt.merr, tt.cfg); got != tt.wantFailed {
+				t.Errorf("failsPermanently() = %t, want %t", got, tt.wantFailed)
+			}
+			err := handleFailure(ctx, obj, ps, tt.merr, nil, tt.cfg)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := controller.IsPermanentError(err); got != tt.wantPermanent {
+				t.Errorf("IsPermanentError() = %t, want %t", got, tt.wantPermanent)
+			}
+			ok, delay := controller.IsRequeueKey(err)
+			if ok != (tt.wantRequeue > 0) || delay != tt.wantRequeue {
+				t.Errorf("IsRequeueKey() = %t, %v, want %v", ok, delay, tt.wantRequeue)
+			}
+
+			updated, err := tekton.GetObject(t, ctx, ps, obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := updated.GetAnnotations()[ChainsAnnotation] == "failed"; got != tt.wantFailed {
+				t.Errorf("marked failed = %t, want %t", got, tt.wantFailed)
+			}
+			if got := updated.GetAnnotations()[RetryAnnotation]; got != tt.wantRetries {
+				t.Errorf("retries = %q, want %q", got, tt.wantRetries)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package runtypes restricts the types of runs the controller signs, so that clusters
+// that only want pipeline-level provenance don't reconcile, cache or annotate TaskRuns.
+package runtypes
+
+import (
+	"context"
+
+	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun"
+	"k8s.io/client-go/rest"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+)
+
+type pipelineRunsOnlyKey struct{}
+
+// WithPipelineRunsOnly returns a copy of ctx in which only PipelineRuns are signed.
+func WithPipelineRunsOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pipelineRunsOnlyKey{}, true)
+}
+
+// PipelineRunsOnly returns whether only PipelineRuns are signed in ctx, in which case the
+// TaskRuns of PipelineRuns are fetched from the API server instead of an informer.
+func PipelineRunsOnly(ctx context.Context) bool {
+	only, _ := ctx.Value(pipelineRunsOnlyKey{}).(bool)
+	return only
+}
+
+// WithoutTaskRunInformer returns inj without the TaskRun informer among the informers it
+// starts. The informer is still injected, since it is registered by every package that
+// links it, but it is never started, so TaskRuns are neither listed nor watched.
+func WithoutTaskRunInformer(inj injection.Interface) injection.Interface {
+	return withoutTaskRuns{Interface: inj}
+}
+
+type withoutTaskRuns struct {
+	injection.Interface
+}
+
+// SetupInformers implements injection.Interface.
+func (w withoutTaskRuns) SetupInformers(ctx context.Context, cfg *rest.Config) (context.Context, []controller.Informer) {
+	ctx, informers := w.Interface.SetupInformers(ctx, cfg)
+	skip := controller.Informer(taskruninformer.Get(ctx).Informer())
+	kept := make([]controller.Informer, 0, len(informers))
+	for _, inf := range informers {
+		if inf != skip {
+			kept = append(kept, inf)
+		}
+	}
+	return ctx, kept
+}
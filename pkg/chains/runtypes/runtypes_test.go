@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtypes
+
+import (
+	"testing"
+
+	fakepipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/pipelinerun/fake"
+	faketaskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun/fake"
+	"k8s.io/client-go/rest"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestWithoutTaskRunInformer(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	if PipelineRunsOnly(ctx) {
+		t.Error("PipelineRunsOnly() = true by default")
+	}
+	if !PipelineRunsOnly(WithPipelineRunsOnly(ctx)) {
+		t.Error("PipelineRunsOnly() = false after WithPipelineRunsOnly()")
+	}
+
+	_, all := injection.Fake.SetupInformers(ctx, &rest.Config{})
+	ctx, informers := WithoutTaskRunInformer(injection.Fake).SetupInformers(ctx, &rest.Config{})
+	if got, want := len(informers), len(all)-1; got != want {
+		t.Fatalf("SetupInformers() returned %d informers, want %d", got, want)
+	}
+	for _, inf := range informers {
+		if inf == controller.Informer(faketaskruninformer.Get(ctx).Informer()) {
+			t.Error("SetupInformers() returned the TaskRun informer")
+		}
+	}
+	var found bool
+	for _, inf := range informers {
+		found = found || inf == controller.Informer(fakepipelineruninformer.Get(ctx).Informer())
+	}
+	if !found {
+		t.Error("SetupInformers() didn't return the PipelineRun informer")
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// certRenewalMargin is how long before their certificate expires cached signers are
+	// loaded again, so that signatures are never made with an expired certificate.
+	certRenewalMargin = time.Minute
+	// signerCacheTTL bounds how long signers are cached. KMS signers authenticate with
+	// credentials fetched when they are loaded, like Spire JWT-SVIDs valid for minutes,
+	// which have no certificate telling when they expire.
+	signerCacheTTL = time.Minute
+	// maxCachedConfigs bounds the number of configurations signers are cached for.
+	maxCachedConfigs = 16
+)
+
+// signerCache caches the signers loaded for a configuration, so that signing secrets
+// aren't read and KMS clients and Fulcio certificates aren't created for every run.
+// Overrides can't change the signers, but runs of namespaces with their own Sigstore
+// stack are signed with its Fulcio instance, so the signers of each configuration are
+// cached, evicting the least recently used ones past maxCachedConfigs.
+type signerCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedSigners
+	// loading loads the signers of each configuration once at a time, without holding
+	// mu, since loading them can call Fulcio or a KMS.
+	loading singleflight.Group
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// cachedSigners are the signers loaded for a configuration.
+type cachedSigners struct {
+	signers map[string]signing.Signer
+	// expires is when the earliest signing certificate of the signers expires, or
+	// signerCacheTTL after they were loaded if that's earlier.
+	expires time.Time
+	// used is when the signers were last returned.
+	used time.Time
+}
+
+var signers = &signerCache{now: time.Now}
+
+// get returns the signers of cfg, calling load if they aren't cached or the cached
+// ones are stale. Concurrent calls for the same configuration share a single load,
+// and loads of other configurations don't wait for it. The signers are only cached if
+// all of them loaded.
+func (c *signerCache) get(ctx context.Context, sp string, cfg config.Config, load func() map[string]signing.Signer) map[string]signing.Signer {
+	key, err := signersKey(sp, cfg)
+	if err != nil {
+		// The signers can't be told apart from the cached ones, so they aren't cached.
+		return load()
+	}
+
+	if s, ok := c.cached(key); ok {
+		return s
+	}
+	v, _, _ := c.loading.Do(key, func() (interface{}, error) {
+		loaded := load()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.entries, key)
+		if len(loaded) < len(neededSigners(cfg)) {
+			return loaded, nil
+		}
+		expires, err := earliestExpiry(loaded)
+		if err != nil {
+			return loaded, nil
+		}
+		if ttl := c.now().Add(signerCacheTTL); expires.IsZero() || ttl.Before(expires) {
+			expires = ttl
+		}
+		if c.entries == nil {
+			c.entries = map[string]*cachedSigners{}
+		}
+		c.entries[key] = &cachedSigners{signers: loaded, expires: expires, used: c.now()}
+		c.evict()
+		return loaded, nil
+	})
+	return v.(map[string]signing.Signer)
+}
+
+// cached returns the signers cached for key, if they aren't stale.
+func (c *signerCache) cached(key string) (map[string]signing.Signer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+		e.used = now
+		return e.signers, true
+	}
+	return nil, false
+}
+
+// evict removes the least recently used signers past maxCachedConfigs.
+func (c *signerCache) evict() {
+	for len(c.entries) > maxCachedConfigs {
+		var oldest string
+		for key, e := range c.entries {
+			if oldest == "" || e.used.Before(c.entries[oldest].used) {
+				oldest = key
+			}
+		}
+		delete(c.entries, oldest)
+	}
+}
+
+// earliestExpiry returns when the earliest signing certificate of signers expires,
+// minus certRenewalMargin, or the zero time if none of them has a certificate.
+func earliestExpiry(signers map[string]signing.Signer) (time.Time, error) {
+	var earliest time.Time
+	for typ, s := range signers {
+		if s.Cert() == "" {
+			continue
+		}
+		block, _ := pem.Decode([]byte(s.Cert()))
+		if block == nil {
+			return time.Time{}, fmt.Errorf("invalid certificate of %s signer", typ)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid certificate of %s signer: %w", typ, err)
+		}
+		if expires := cert.NotAfter.Add(-certRenewalMargin); earliest.IsZero() || expires.Before(earliest) {
+			earliest = expires
+		}
+	}
+	return earliest, nil
+}
+
+// signersKey returns a hash of everything the signers of cfg are loaded from: the
+// signer configuration and the files of the signing secrets mounted at sp, identified
+// by their names, sizes and modification times. Kubernetes replaces the files when the
+// secret changes, which changes the key.
+func signersKey(sp string, cfg config.Config) (string, error) {
+	h := sha256.New()
+	needed := neededSigners(cfg)
+	types := make([]string, 0, len(needed))
+	for t := range needed {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	if err := json.NewEncoder(h).Encode(struct {
+		SecretPath string
+		Types      []string
+		Signers    config.SignerConfigs
+	}{sp, types, cfg.Signers}); err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(sp)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	for _, e := range entries {
+		// Stat follows the symlinks Kubernetes mounts the secret keys with.
+		info, err := os.Stat(filepath.Join(sp, e.Name()))
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			continue
+		}
+		fmt.Fprintf(h, "%s %d %d\n", e.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+type cachedSigner struct {
+	signature.SignerVerifier
+	typ  string
+	cert string
+}
+
+func (s cachedSigner) Type() string  { return s.typ }
+func (s cachedSigner) Cert() string  { return s.cert }
+func (s cachedSigner) Chain() string { return "" }
+
+// selfSigned returns a PEM encoded certificate that expires at notAfter.
+func selfSigned(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "chains"},
+		NotBefore:    notAfter.Add(-10 * time.Minute),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestSignerCache(t *testing.T) {
+	ctx := context.Background()
+	sp := t.TempDir()
+	keyPath := filepath.Join(sp, "cosign.key")
+	if err := os.WriteFile(keyPath, []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{Artifacts: config.ArtifactConfigs{
+		TaskRuns:     config.Artifact{Signer: "x509"},
+		PipelineRuns: config.Artifact{Signer: "x509"},
+		OCI:          config.Artifact{Signer: "x509"},
+	}}
+
+	now := time.Now()
+	c := &signerCache{now: func() time.Time { return now }}
+	loads := 0
+	cert := ""
+	load := func() map[string]signing.Signer {
+		loads++
+		return map[string]signing.Signer{"x509": cachedSigner{typ: "x509", cert: cert}}
+	}
+
+	c.get(ctx, sp, cfg, load)
+	c.get(ctx, sp, cfg, load)
+	if loads != 1 {
+		t.Fatalf("expected the signers to be cached, loaded %d times", loads)
+	}
+
+	// A new secret is mounted with a different key.
+	if err := os.WriteFile(keyPath, []byte("rotated key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c.get(ctx, sp, cfg, load)
+	if loads != 2 {
+		t.Fatalf("expected the signers to be loaded again after the secret changed, loaded %d times", loads)
+	}
+
+	cfg.Signers.KMS.KMSRef = "gcpkms://foo"
+	c.get(ctx, sp, cfg, load)
+	if loads != 3 {
+		t.Fatalf("expected the signers to be loaded again after the config changed, loaded %d times", loads)
+	}
+
+	// Signers without a certificate, like KMS ones, are loaded again after
+	// signerCacheTTL, as the credentials they were loaded with may expire.
+	now = now.Add(signerCacheTTL / 2)
+	c.get(ctx, sp, cfg, load)
+	if loads != 3 {
+		t.Fatalf("expected the signers to be reused before signerCacheTTL, loaded %d times", loads)
+	}
+	now = now.Add(signerCacheTTL / 2)
+	c.get(ctx, sp, cfg, load)
+	if loads != 4 {
+		t.Fatalf("expected the signers to be loaded again after signerCacheTTL, loaded %d times", loads)
+	}
+
+	// Fulcio certificates are renewed before they expire.
+	cert = selfSigned(t, now.Add(certRenewalMargin+signerCacheTTL/2))
+	cfg.Signers.X509.FulcioEnabled = true
+	c.get(ctx, sp, cfg, load)
+	now = now.Add(signerCacheTTL / 4)
+	c.get(ctx, sp, cfg, load)
+	if loads != 5 {
+		t.Fatalf("expected the certificate to be reused while it is valid, loaded %d times", loads)
+	}
+	now = now.Add(signerCacheTTL / 4)
+	c.get(ctx, sp, cfg, load)
+	if loads != 6 {
+		t.Fatalf("expected the certificate to be renewed before it expires, loaded %d times", loads)
+	}
+}
+
+func TestSignerCache_ConcurrentLoads(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Config{Artifacts: config.ArtifactConfigs{
+		TaskRuns:     config.Artifact{Signer: "x509"},
+		PipelineRuns: config.Artifact{Signer: "x509"},
+		OCI:          config.Artifact{Signer: "x509"},
+	}}
+	c := &signerCache{now: time.Now}
+	sp := t.TempDir()
+
+	// A slow load of one configuration, like a Fulcio or KMS call that hangs.
+	release := make(chan struct{})
+	var loads int32
+	slow := func() map[string]signing.Signer {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return map[string]signing.Signer{"x509": cachedSigner{typ: "x509"}}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.get(ctx, sp, cfg, slow)
+		}()
+	}
+
+	// The signers of another configuration are loaded meanwhile.
+	other := cfg
+	other.Signers.KMS.KMSRef = "gcpkms://foo"
+	done := make(chan struct{})
+	go func() {
+		c.get(ctx, sp, other, func() map[string]signing.Signer {
+			return map[string]signing.Signer{"x509": cachedSigner{typ: "x509"}}
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected loading signers not to wait for the loads of other configurations")
+	}
+
+	close(release)
+	wg.Wait()
+	if loads != 1 {
+		t.Errorf("expected concurrent gets of a configuration to share a load, loaded %d times", loads)
+	}
+}
+
+func TestSignerCache_PartialLoad(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Config{Artifacts: config.ArtifactConfigs{
+		TaskRuns:     config.Artifact{Signer: "x509"},
+		PipelineRuns: config.Artifact{Signer: "x509"},
+		OCI:          config.Artifact{Signer: "kms"},
+	}}
+	c := &signerCache{now: time.Now}
+	loads := 0
+	load := func() map[string]signing.Signer {
+		loads++
+		// The kms signer failed to load.
+		return map[string]signing.Signer{"x509": cachedSigner{typ: "x509"}}
+	}
+
+	sp := t.TempDir()
+	if got := c.get(ctx, sp, cfg, load); len(got) != 1 {
+		t.Fatalf("expected the loaded signers to be returned, got %v", got)
+	}
+	c.get(ctx, sp, cfg, load)
+	if loads != 2 {
+		t.Errorf("expected signers to be loaded again while some fail, loaded %d times", loads)
+	}
+}
+
+func TestSignerCache_SigstoreStacks(t *testing.T) {
+	ctx := context.Background()
+	sp := t.TempDir()
+	cfg := config.Config{Artifacts: config.ArtifactConfigs{
+		TaskRuns: config.Artifact{Signer: "x509"},
+	}}
+	private := cfg
+	private.Signers.X509.FulcioAddr = "https://fulcio.sigstore.example.com"
+
+	now := time.Now()
+	c := &signerCache{now: func() time.Time { return now }}
+	loads := 0
+	load := func() map[string]signing.Signer {
+		loads++
+		return map[string]signing.Signer{"x509": cachedSigner{typ: "x509"}}
+	}
+
+	// Runs of namespaces with their own stack are interleaved with the others.
+	for i := 0; i < 3; i++ {
+		c.get(ctx, sp, cfg, load)
+		c.get(ctx, sp, private, load)
+	}
+	if loads != 2 {
+		t.Fatalf("expected the signers of each stack to be cached, loaded %d times", loads)
+	}
+
+	// The least recently used signers are evicted.
+	for i := 0; i < maxCachedConfigs; i++ {
+		now = now.Add(time.Second)
+		other := cfg
+		other.Signers.X509.FulcioAddr = fmt.Sprintf("https://fulcio-%d.example.com", i)
+		c.get(ctx, sp, other, load)
+		c.get(ctx, sp, private, load)
+	}
+	if len(c.entries) != maxCachedConfigs {
+		t.Fatalf("expected %d cached configurations, got %d", maxCachedConfigs, len(c.entries))
+	}
+	loads = 0
+	c.get(ctx, sp, private, load)
+	if loads != 0 {
+		t.Error("expected recently used signers to be kept")
+	}
+	c.get(ctx, sp, cfg, load)
+	if loads != 1 {
+		t.Error("expected the least recently used signers to be evicted")
+	}
+}
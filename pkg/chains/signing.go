@@ -14,25 +14,57 @@ limitations under the License.
 package chains
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/allowlist"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/chaining"
+	"github.com/tektoncd/chains/pkg/chains/clustersource"
+	"github.com/tektoncd/chains/pkg/chains/environment"
+	"github.com/tektoncd/chains/pkg/chains/events"
 	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/chains/limits"
+	"github.com/tektoncd/chains/pkg/chains/metrics"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/policy"
+	"github.com/tektoncd/chains/pkg/chains/publickeys"
 	"github.com/tektoncd/chains/pkg/chains/signing"
 	"github.com/tektoncd/chains/pkg/chains/signing/kms"
 	"github.com/tektoncd/chains/pkg/chains/signing/x509"
 	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/storage/api"
+	"github.com/tektoncd/chains/pkg/chains/tektonbundles"
+	"github.com/tektoncd/chains/pkg/chains/tracing"
+	"github.com/tektoncd/chains/pkg/chains/validation"
 	"github.com/tektoncd/chains/pkg/config"
 	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
 )
 
+// DefaultUploadParallelism is the number of uploads of a payload, to its storage
+// backends and the transparency log, that run at once when the config doesn't say.
+const DefaultUploadParallelism = 4
+
+// pipelineLabel is set by Tekton on PipelineRuns and the TaskRuns of pipelines to the
+// name of their pipeline.
+const pipelineLabel = "tekton.dev/pipeline"
+
 type Signer interface {
 	Sign(ctx context.Context, obj objects.TektonObject) error
 }
@@ -44,19 +76,95 @@ type ObjectSigner struct {
 	Backends          map[string]storage.Backend
 	SecretPath        string
 	Pipelineclientset versioned.Interface
+	// KubeClient is used to take a Lease on runs while signing them, if enabled.
+	KubeClient kubernetes.Interface
+	// DynamicClient is used to write SigningStatuses, if enabled.
+	DynamicClient dynamic.Interface
+	// Cluster is the name of the workload cluster the runs are signed for, or empty for
+	// the cluster the controller runs in.
+	Cluster string
+	// LeaseClient is used instead of KubeClient to take Leases, in the cluster the
+	// controller runs in, when signing the runs of a workload cluster.
+	LeaseClient kubernetes.Interface
+}
+
+// guard prevents obj from being signed concurrently by this process and, if leases are
+// enabled, by other controller replicas. The returned function must be called once
+// signing finished.
+func (o *ObjectSigner) guard(ctx context.Context, cfg config.Config, obj objects.TektonObject) (func(), error) {
+	releaseInFlight, ok := acquireInFlight(obj.GetUID())
+	if !ok {
+		return nil, ErrSigningInProgress
+	}
+	leaseClient := o.LeaseClient
+	if leaseClient == nil {
+		leaseClient = o.KubeClient
+	}
+	if !cfg.Lease.Enabled || leaseClient == nil {
+		return releaseInFlight, nil
+	}
+	releaseLease, err := acquireLease(ctx, leaseClient, system.Namespace(), holderIdentity(), cfg.Lease, obj)
+	if err != nil {
+		releaseInFlight()
+		return nil, err
+	}
+	return func() {
+		releaseLease()
+		releaseInFlight()
+	}, nil
+}
+
+// AllSigners returns the signers cfg uses for any artifact, loaded from the signing
+// secrets mounted at sp. Signers that fail to load are logged and left out. The
+// signers are cached until the configuration or the signing secrets change, or their
+// signing certificate is about to expire.
+func AllSigners(ctx context.Context, sp string, cfg config.Config) map[string]signing.Signer {
+	return signers.get(ctx, sp, cfg, func() map[string]signing.Signer {
+		return loadSigners(ctx, sp, cfg)
+	})
+}
+
+// neededSigners returns the supported signers cfg uses for any artifact.
+func neededSigners(cfg config.Config) map[string]struct{} {
+	used := sets.New[string](
+		cfg.Artifacts.OCI.Signer,
+		cfg.Artifacts.TaskRuns.Signer,
+		cfg.Artifacts.PipelineRuns.Signer,
+		(&artifacts.VEXArtifact{}).Signer(cfg),
+		(&artifacts.TektonBundleArtifact{}).Signer(cfg),
+	)
+	if sa := (&artifacts.SourceArtifact{}); sa.Enabled(cfg) {
+		used.Insert(sa.Signer(cfg))
+	}
+	needed := map[string]struct{}{}
+	for _, s := range signing.AllSigners {
+		if used.Has(s) {
+			needed[s] = struct{}{}
+		}
+	}
+	return needed
 }
 
-func allSigners(ctx context.Context, sp string, cfg config.Config) map[string]signing.Signer {
+// NeededSigners returns the supported signers cfg uses for any artifact, in the order
+// of signing.AllSigners.
+func NeededSigners(cfg config.Config) []string {
+	needed := neededSigners(cfg)
+	var names []string
+	for _, s := range signing.AllSigners {
+		if _, ok := needed[s]; ok {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+func loadSigners(ctx context.Context, sp string, cfg config.Config) map[string]signing.Signer {
 	l := logging.FromContext(ctx)
 	all := map[string]signing.Signer{}
-	neededSigners := map[string]struct{}{
-		cfg.Artifacts.OCI.Signer:          {},
-		cfg.Artifacts.TaskRuns.Signer:     {},
-		cfg.Artifacts.PipelineRuns.Signer: {},
-	}
+	needed := neededSigners(cfg)
 
 	for _, s := range signing.AllSigners {
-		if _, ok := neededSigners[s]; !ok {
+		if _, ok := needed[s]; !ok {
 			continue
 		}
 		switch s {
@@ -82,6 +190,23 @@ func allSigners(ctx context.Context, sp string, cfg config.Config) map[string]si
 	return all
 }
 
+// finders returns the backends that can look up the attestations of input artifacts,
+// in the order of their names.
+func finders(backends map[string]storage.Backend) []chaining.Finder {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var fs []chaining.Finder
+	for _, name := range names {
+		if f, ok := backends[name].(chaining.Finder); ok {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
 // TODO: Hook this up to config.
 func getSignableTypes(ctx context.Context, obj objects.TektonObject) ([]artifacts.Signable, error) {
 	var types []artifacts.Signable
@@ -94,10 +219,18 @@ func getSignableTypes(ctx context.Context, obj objects.TektonObject) ([]artifact
 		types = append(types, &artifacts.PipelineRunArtifact{})
 	}
 
+	if obj.SupportsCustomRunArtifact() {
+		types = append(types, &artifacts.CustomRunArtifact{})
+	}
+
 	if obj.SupportsOCIArtifact() {
 		types = append(types, &artifacts.OCIArtifact{})
 	}
 
+	if len(types) > 0 {
+		types = append(types, &artifacts.VEXArtifact{}, &artifacts.TektonBundleArtifact{}, &artifacts.SourceArtifact{})
+	}
+
 	if len(types) == 0 {
 		return nil, fmt.Errorf("no signable artifacts found for %v", obj)
 	}
@@ -107,19 +240,101 @@ func getSignableTypes(ctx context.Context, obj objects.TektonObject) ([]artifact
 
 // Signs TaskRun and PipelineRun objects, as well as generates attesations for each
 // Follows process of extract payload, sign payload, store payload and signature
-func (o *ObjectSigner) Sign(ctx context.Context, tektonObj objects.TektonObject) error {
+func (o *ObjectSigner) Sign(ctx context.Context, tektonObj objects.TektonObject) (err error) {
 	cfg := *config.FromContext(ctx)
 	logger := logging.FromContext(ctx)
 
-	signableTypes, err := getSignableTypes(ctx, tektonObj)
+	ctx, span := tracing.Start(ctx, "Sign",
+		tracing.ObjectKeyAttr.String(tektonObj.GetNamespace()+"/"+tektonObj.GetName()),
+		tracing.KindAttr.String(tektonObj.GetKindName()))
+	defer func() { tracing.End(span, err) }()
+	ctx = metrics.WithRun(ctx, tektonObj.GetNamespace(), tektonObj.GetLabels()[pipelineLabel])
+
+	releaseNamespace, ok := limits.TryAcquireNamespace(tektonObj.GetNamespace())
+	if !ok {
+		// The worker moves on to the runs of other namespaces in the meantime.
+		delay := namespaceBackoff.When(tektonObj.GetUID())
+		logger.Debugf("Not signing %s %s/%s for %s: its namespace is at its limit of runs signed at once", tektonObj.GetKindName(), tektonObj.GetNamespace(), tektonObj.GetName(), delay)
+		return controller.NewRequeueAfter(delay)
+	}
+	namespaceBackoff.Forget(tektonObj.GetUID())
+	defer releaseNamespace()
+
+	release, err := o.guard(ctx, cfg, tektonObj)
+	if errors.Is(err, ErrSigningInProgress) {
+		logger.Infof("Not signing %s %s/%s: %v", tektonObj.GetKindName(), tektonObj.GetNamespace(), tektonObj.GetName(), err)
+		return controller.NewRequeueAfter(inProgressRequeueDelay)
+	}
 	if err != nil {
 		return err
 	}
-
-	signers := allSigners(ctx, o.SecretPath, cfg)
+	defer release()
+	if handledSince(ctx, o.Pipelineclientset, tektonObj) {
+		logger.Infof("%s %s/%s was reconciled concurrently", tektonObj.GetKindName(), tektonObj.GetNamespace(), tektonObj.GetName())
+		return nil
+	}
 
 	var merr *multierror.Error
+	event := audit.Event{
+		Kind:      tektonObj.GetKindName(),
+		Namespace: tektonObj.GetNamespace(),
+		Name:      tektonObj.GetName(),
+		UID:       string(tektonObj.GetUID()),
+		Cluster:   o.Cluster,
+	}
+	defer func() {
+		switch {
+		case merr.ErrorOrNil() != nil:
+			event.Decision, event.Reason = audit.DecisionFailed, merr.Error()
+		case err != nil:
+			event.Decision, event.Reason = audit.DecisionFailed, err.Error()
+		case event.Decision == "":
+			event.Decision = audit.DecisionSigned
+		}
+		audit.Record(ctx, event)
+		// The SigningStatus CRD is only installed in the cluster the controller runs in.
+		if cfg.SigningStatus.Enabled && o.Cluster == "" {
+			o.recordSigningStatus(ctx, tektonObj, event)
+		}
+		events.Emit(ctx, event, merr.ErrorOrNil() != nil && failsPermanently(tektonObj, merr, cfg.Retry))
+	}()
+
+	ctx, signableTypes, err := o.prepare(ctx, tektonObj, &cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.DryRun.Applies(tektonObj.GetNamespace()) {
+		event.Decision = audit.DecisionDryRun
+		return o.dryRun(ctx, tektonObj, signableTypes, cfg, &event)
+	}
+
+	// Signing secrets are read from the cluster the controller runs in.
+	secretClient := o.LeaseClient
+	if secretClient == nil {
+		secretClient = o.KubeClient
+	}
+	sp, err := SigningSecretPath(ctx, secretClient, o.SecretPath, cfg)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+	signers := AllSigners(ctx, sp, cfg)
+	// Public keys are published by the controllers of the cluster the controller runs in.
+	if cfg.PublicKeys.Enabled && o.KubeClient != nil && o.Cluster == "" {
+		if err := publickeys.Publish(ctx, o.KubeClient, system.Namespace(), cfg, signers); err != nil {
+			logger.Warnf("error publishing public keys: %v", err)
+		}
+	}
+
 	extraAnnotations := map[string]string{}
+	// envelopes collects the PipelineRun signatures that make up the bundle, if enabled.
+	var envelopes [][]byte
+	// truncatedKeys are the keys of the artifacts whose attestations were truncated.
+	var truncatedKeys []string
+	// invalidKeys are the keys of the artifacts whose payloads failed validation.
+	var invalidKeys []string
+	// disallowedMaterials are the materials of the artifacts that aren't allowed.
+	disallowedMaterials := sets.New[string]()
 	for _, signableType := range signableTypes {
 		if !signableType.Enabled(cfg) {
 			continue
@@ -134,92 +349,165 @@ func (o *ObjectSigner) Sign(ctx context.Context, tektonObj objects.TektonObject)
 
 		// Extract all the "things" to be signed.
 		// We might have a few of each type (several binaries, or images)
-		objects := signableType.ExtractObjects(ctx, tektonObj)
+		objects := signableType.ExtractObjects(ctx, payloadObject(tektonObj, cfg.Provenance))
 
 		// Go through each object one at a time.
 		for _, obj := range objects {
+			artifact := &audit.Artifact{
+				Type:   signableType.Type(),
+				Key:    signableType.ShortKey(obj),
+				Format: string(payloadFormat),
+			}
+			event.Artifacts = append(event.Artifacts, artifact)
 
-			payload, err := payloader.CreatePayload(ctx, obj)
-			if err != nil {
-				logger.Error(err)
+			signed, err := signArtifact(ctx, cfg, tektonObj, signableType, payloader, signers, obj, artifact)
+			if len(artifact.Truncated) > 0 {
+				truncatedKeys = append(truncatedKeys, artifact.Key)
+				extraAnnotations[TruncatedAnnotation] = strings.Join(truncatedKeys, ",")
+			}
+			if artifact.Invalid != "" {
+				invalidKeys = append(invalidKeys, artifact.Key)
+				extraAnnotations[InvalidAnnotation] = strings.Join(invalidKeys, ",")
+			}
+			if len(artifact.Disallowed) > 0 && cfg.Materials.Enforcement != config.MaterialsBlock {
+				disallowedMaterials.Insert(artifact.Disallowed...)
+				extraAnnotations[DisallowedMaterialsAnnotation] = strings.Join(sets.List(disallowedMaterials), ",")
+			}
+			var ce *ClassifiedError
+			if errors.As(err, &ce) {
+				merr = multierror.Append(merr, err)
 				continue
+			} else if err != nil {
+				return err
 			}
-			logger.Infof("Created payload of type %s for %s %s/%s", string(payloadFormat), tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName())
-
-			// Sign it!
-			signerType := signableType.Signer(cfg)
-			signer, ok := signers[signerType]
-			if !ok {
-				logger.Warnf("No signer %s configured for %s", signerType, signableType.Type())
+			if signed == nil {
 				continue
 			}
+			rawPayload, signature, signer := signed.payload, signed.signature, signed.signer
+
+			if _, ok := signableType.(*artifacts.PipelineRunArtifact); ok && payloader.Wrap() {
+				envelopes = append(envelopes, signature)
+			}
+
+			// Now store those, and upload them to the transparency log, in parallel.
+			backends := sets.List[string](signableType.StorageBackend(cfg))
+			storeErrs := make([]error, len(backends))
+			storeLocations := make([][]string, len(backends))
+			uploads := make([]func() error, 0, len(backends)+1)
+			for i, backend := range backends {
+				i, backend := i, backend
+				uploads = append(uploads, func() error {
+					b, ok := o.Backends[backend]
+					if !ok {
+						storeErrs[i] = fmt.Errorf("storage backend %q is not configured", backend)
+						return nil
+					}
+					storageOpts := config.StorageOpts{
+						ShortKey:      signableType.ShortKey(obj),
+						FullKey:       signableType.FullKey(obj),
+						Cert:          signer.Cert(),
+						Chain:         signer.Chain(),
+						PayloadFormat: payloadFormat,
+						BuildGroup:    tektonObj.GetAnnotations()[attest.BuildGroupAnnotation],
+					}
+					release, err := limits.AcquireBackend(ctx, backend)
+					if err != nil {
+						return err
+					}
+					start := time.Now()
+					bctx, bspan := tracing.Start(ctx, "StorePayload", tracing.FormatAttr.String(string(payloadFormat)), tracing.BackendAttr.String(backend))
+					bctx, locations := api.WithLocations(bctx)
+					err = storePayload(bctx, cfg, backend, func(ctx context.Context) error {
+						return b.StorePayload(ctx, tektonObj, rawPayload, string(signature), storageOpts)
+					})
+					tracing.End(bspan, err)
+					release()
+					storeLocations[i] = locations()
+					metrics.RecordUpload(ctx, tektonObj.GetKindName(), string(payloadFormat), backend, time.Since(start), err)
+					storeErrs[i] = err
+					return nil
+				})
+			}
 
-			if payloader.Wrap() {
-				wrapped, err := signing.Wrap(ctx, signer)
+			uploadTlog := shouldUploadTlog(cfg, tektonObj)
+			var entry *models.LogEntryAnon
+			var tlogErr error
+			if uploadTlog {
+				rekorClient, err := transparencyClient(cfg.Transparency)
 				if err != nil {
 					return err
 				}
-				logger.Infof("Using wrapped envelope signer for %s", payloader.Type())
-				signer = wrapped
+				uploads = append(uploads, func() error {
+					tctx, tspan := tracing.Start(ctx, "UploadTlog", tracing.FormatAttr.String(string(payloadFormat)))
+					entry, tlogErr = tlogUpload(tctx, cfg.Transparency, func(ctx context.Context) (*models.LogEntryAnon, error) {
+						return rekorClient.UploadTlog(ctx, signer, signature, rawPayload, signer.Cert(), string(payloadFormat))
+					})
+					tracing.End(tspan, tlogErr)
+					return nil
+				})
 			}
 
-			logger.Infof("Signing object with %s", signerType)
-			rawPayload, err := json.Marshal(payload)
-			if err != nil {
-				logger.Warnf("Unable to marshal payload: %v", signerType, obj)
-				continue
+			if err := runUploads(cfg.Concurrency.UploadParallelism, uploads); err != nil {
+				return err
 			}
 
-			signature, err := signer.SignMessage(bytes.NewReader(rawPayload))
-			if err != nil {
-				logger.Error(err)
-				continue
-			}
-
-			// Now store those!
-			for _, backend := range sets.List[string](signableType.StorageBackend(cfg)) {
-				b := o.Backends[backend]
-				storageOpts := config.StorageOpts{
-					ShortKey:      signableType.ShortKey(obj),
-					FullKey:       signableType.FullKey(obj),
-					Cert:          signer.Cert(),
-					Chain:         signer.Chain(),
-					PayloadFormat: payloadFormat,
-				}
-				if err := b.StorePayload(ctx, tektonObj, rawPayload, string(signature), storageOpts); err != nil {
+			for i, backend := range backends {
+				if err := storeErrs[i]; err != nil {
 					logger.Error(err)
-					merr = multierror.Append(merr, err)
+					merr = multierror.Append(merr, classify(ErrorClassStorage, err))
+					artifact.Error = err.Error()
+					continue
 				}
+				artifact.Backends = append(artifact.Backends, backend)
+				addLocations(artifact, backend, storeLocations[i]...)
 			}
 
-			if shouldUploadTlog(cfg, tektonObj) {
-				rekorClient, err := getRekor(cfg.Transparency.URL)
-				if err != nil {
-					return err
+			if uploadTlog && tlogErr != nil && cfg.Transparency.QueueEnabled && o.DynamicClient != nil && rekorUnreachable(tlogErr) {
+				// The entry is uploaded by the TransparencyQueue once the log is reachable.
+				if err := o.queueTransparencyEntry(ctx, tektonObj, cfg.Transparency, signableType.FullKey(obj), payloadFormat, signature, rawPayload, signer); err != nil {
+					logger.Warnf("error queueing tlog entry: %v", err)
+				} else {
+					logger.Warnf("Queued tlog entry, %s is unreachable: %v", cfg.Transparency.URL, tlogErr)
+					extraAnnotations[TransparencyPendingAnnotation] = "true"
+					uploadTlog = false
 				}
-
-				entry, err := rekorClient.UploadTlog(ctx, signer, signature, rawPayload, signer.Cert(), string(payloadFormat))
-				if err != nil {
-					logger.Warnf("error uploading entry to tlog: %v", err)
-					merr = multierror.Append(merr, err)
+			}
+			if uploadTlog {
+				if tlogErr != nil {
+					logger.Warnf("error uploading entry to tlog: %v", tlogErr)
+					merr = multierror.Append(merr, classify(ErrorClassTransparency, tlogErr))
+					artifact.Error = tlogErr.Error()
 				} else {
 					logger.Infof("Uploaded entry to %s with index %d", cfg.Transparency.URL, *entry.LogIndex)
 
-					extraAnnotations[ChainsTransparencyAnnotation] = fmt.Sprintf("%s/api/v1/log/entries?logIndex=%d", cfg.Transparency.URL, *entry.LogIndex)
+					extraAnnotations[ChainsTransparencyAnnotation] = transparencyEntryURL(cfg.Transparency, *entry.LogIndex)
+					artifact.Transparency = extraAnnotations[ChainsTransparencyAnnotation]
+					if uuid := rekorUUID(cfg.Transparency, entry); uuid != "" {
+						addLocations(artifact, rekorLocation, uuid)
+					}
 				}
 			}
+			if locations := locationsAnnotation(event.Artifacts); locations != "" {
+				extraAnnotations[LocationsAnnotation] = locations
+			}
 
 		}
 		if merr.ErrorOrNil() != nil {
-			if err := HandleRetry(ctx, tektonObj, o.Pipelineclientset, extraAnnotations); err != nil {
-				logger.Warnf("error handling retry: %v", err)
-				merr = multierror.Append(merr, err)
-			}
-			return merr
+			return handleFailure(ctx, tektonObj, o.Pipelineclientset, merr, extraAnnotations, cfg.Retry)
 		}
 	}
 
-	// Now mark the TektonObject as signed
+	if pro, ok := tektonObj.(*objects.PipelineRunObject); ok && cfg.Artifacts.PipelineRuns.BundleEnabled() && len(envelopes) > 0 {
+		if err := o.storeBundle(ctx, pro, envelopes, cfg); err != nil {
+			logger.Warnf("error storing bundle: %v", err)
+			merr = multierror.Append(merr, classify(ErrorClassStorage, err))
+			return handleFailure(ctx, tektonObj, o.Pipelineclientset, merr, extraAnnotations, cfg.Retry)
+		}
+	}
+
+	// Now mark the TektonObject as signed, with the format version its attestations were
+	// generated with.
+	extraAnnotations[FormatVersionAnnotation] = FormatVersion(cfg)
 	if err := MarkSigned(ctx, tektonObj, o.Pipelineclientset, extraAnnotations); err != nil {
 		return err
 	}
@@ -227,6 +515,191 @@ func (o *ObjectSigner) Sign(ctx context.Context, tektonObj objects.TektonObject)
 	return nil
 }
 
+// signedPayload is the payload of an artifact signed by signArtifact.
+type signedPayload struct {
+	payload   []byte
+	signature []byte
+	signer    signing.Signer
+}
+
+// signArtifact generates the payload of obj, an artifact of tektonObj, with payloader,
+// truncates and validates it, checks its materials and the policy of cfg, and signs it
+// with its signer of signers, recording the outcome in artifact. It returns no payload
+// if it couldn't be generated or signed for a reason recorded in artifact.Error, and a
+// ClassifiedError if it was denied or signing it failed. Other errors end the signing of
+// tektonObj.
+func signArtifact(ctx context.Context, cfg config.Config, tektonObj objects.TektonObject, signableType artifacts.Signable, payloader formats.Payloader, signers map[string]signing.Signer, obj interface{}, artifact *audit.Artifact) (*signedPayload, error) {
+	logger := logging.FromContext(ctx)
+	payloadFormat := signableType.PayloadFormat(cfg)
+
+	start := time.Now()
+	pctx, pspan := tracing.Start(ctx, "CreatePayload", tracing.FormatAttr.String(string(payloadFormat)))
+	payload, err := payloader.CreatePayload(pctx, obj)
+	tracing.End(pspan, err)
+	metrics.RecordPayloadGeneration(ctx, tektonObj.GetKindName(), string(payloadFormat), time.Since(start))
+	if err != nil {
+		logger.Error(err)
+		artifact.Error = err.Error()
+		return nil, nil
+	}
+	logger.Infof("Created payload of type %s for %s %s/%s", string(payloadFormat), tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName())
+
+	// Sign it!
+	signerType := signableType.Signer(cfg)
+	artifact.Signer = signerType
+	signer, ok := signers[signerType]
+	if !ok {
+		logger.Warnf("No signer %s configured for %s", signerType, signableType.Type())
+		artifact.Error = fmt.Sprintf("no signer %s configured", signerType)
+		return nil, nil
+	}
+
+	if payloader.Wrap() {
+		wrapped, err := signing.Wrap(ctx, signer)
+		if err != nil {
+			return nil, err
+		}
+		logger.Infof("Using wrapped envelope signer for %s", payloader.Type())
+		signer = wrapped
+	}
+
+	logger.Infof("Signing object with %s", signerType)
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		logger.Warnf("Unable to marshal payload: %v", signerType, obj)
+		artifact.Error = err.Error()
+		return nil, nil
+	}
+	rawPayload, truncated, err := truncate(rawPayload, cfg.Provenance.MaxAttestationKB)
+	if err != nil {
+		logger.Error(err)
+		artifact.Error = err.Error()
+		return nil, nil
+	}
+	if len(truncated) > 0 {
+		logger.Warnf("Truncated payload of type %s for %s %s/%s by dropping its %v", string(payloadFormat), tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName(), truncated)
+		artifact.Truncated = truncated
+	}
+	artifact.Subjects = audit.Subjects(rawPayload)
+	metrics.RecordAttestationSize(ctx, tektonObj.GetKindName(), string(payloadFormat), len(rawPayload))
+	if cfg.Provenance.ValidatePayloads {
+		_, err := validation.Validate(rawPayload)
+		if err != nil && !errors.Is(err, validation.ErrNotStatement) && !errors.Is(err, validation.ErrUnknownPredicateType) {
+			logger.Warnf("Payload of type %s for %s %s/%s is invalid: %v", string(payloadFormat), tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName(), err)
+			metrics.RecordValidationFailure(ctx, tektonObj.GetKindName(), string(payloadFormat))
+			artifact.Invalid = err.Error()
+		}
+	}
+
+	if cfg.Materials.Enforcement != config.MaterialsProvenance {
+		if disallowed := allowlist.Disallowed(rawPayload, cfg.Materials.AllowedPrefixes); len(disallowed) > 0 {
+			artifact.Disallowed = disallowed
+			if cfg.Materials.Enforcement == config.MaterialsBlock {
+				err := fmt.Errorf("materials not in materials.allowed-prefixes: %s", strings.Join(disallowed, ", "))
+				logger.Warnf("Not signing payload of type %s for %s %s/%s: %v", string(payloadFormat), tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName(), err)
+				artifact.Denied = []string{err.Error()}
+				artifact.Error = err.Error()
+				return nil, classify(ErrorClassPolicy, err)
+			}
+		}
+	}
+
+	if err := policy.Evaluate(ctx, cfg.Policy, policy.Input{
+		Kind:        tektonObj.GetKindName(),
+		Namespace:   tektonObj.GetNamespace(),
+		Name:        tektonObj.GetName(),
+		PayloadType: string(payloadFormat),
+		Payload:     rawPayload,
+	}); err != nil {
+		var denied *policy.DeniedError
+		if errors.As(err, &denied) {
+			logger.Warnf("Not signing payload of type %s for %s %s/%s: %v", string(payloadFormat), tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName(), err)
+			artifact.Denied = denied.Violations
+		} else {
+			logger.Error(err)
+		}
+		artifact.Error = err.Error()
+		return nil, classify(ErrorClassPolicy, err)
+	}
+
+	if err := limits.WaitSigning(ctx); err != nil {
+		return nil, err
+	}
+	start = time.Now()
+	_, sspan := tracing.Start(ctx, "SignMessage", tracing.FormatAttr.String(string(payloadFormat)), tracing.SignerAttr.String(signerType))
+	signature, err := signMessage(ctx, cfg, signerType, signer, rawPayload)
+	tracing.End(sspan, err)
+	metrics.RecordSigning(ctx, tektonObj.GetKindName(), string(payloadFormat), signerType, time.Since(start), err)
+	if err != nil {
+		logger.Error(err)
+		artifact.Error = err.Error()
+		return nil, classify(ErrorClassSigning, err)
+	}
+	artifact.Identity = audit.Identity(signer.Cert())
+	artifact.Signatures = audit.Signatures(signature)
+	recordKeyUsage(ctx, cfg, tektonObj, artifact, signer, rawPayload)
+	return &signedPayload{payload: rawPayload, signature: signature, signer: signer}, nil
+}
+
+// prepare applies the builder ID of the cluster, the Sigstore stack and the config
+// overrides of tektonObj to cfg, and returns the signable types of tektonObj and ctx
+// with what its payloads are generated with.
+func (o *ObjectSigner) prepare(ctx context.Context, tektonObj objects.TektonObject, cfg *config.Config) (context.Context, []artifacts.Signable, error) {
+	logger := logging.FromContext(ctx)
+	if id, ok := cfg.Clusters.BuilderIDs[o.Cluster]; ok && o.Cluster != "" {
+		cfg.Builder.ID = id
+	}
+	if stack := cfg.ApplySigstoreStack(tektonObj.GetNamespace()); stack != "" {
+		logger.Debugf("Signing %s %s/%s with Sigstore stack %s", tektonObj.GetKindName(), tektonObj.GetNamespace(), tektonObj.GetName(), stack)
+	}
+	if err := applyOverrides(cfg, tektonObj, o.Backends); err != nil {
+		logger.Warnf("Ignoring config overrides of %s %s/%s: %v", tektonObj.GetGVK(), tektonObj.GetNamespace(), tektonObj.GetName(), err)
+	}
+
+	signableTypes, err := getSignableTypes(ctx, tektonObj)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.Pipelineclientset != nil {
+		// Tasks and Pipelines fetched by the cluster resolver are looked up to record
+		// their resource version.
+		ctx = clustersource.WithResolver(ctx, &clustersource.ClientResolver{Client: o.Pipelineclientset})
+	}
+	if cfg.Provenance.ChainInputAttestations {
+		ctx = chaining.WithFinders(ctx, finders(o.Backends)...)
+	}
+	if cfg.Provenance.RecordEnvironment && o.KubeClient != nil {
+		ctx = environment.WithCollector(ctx, &environment.ClientCollector{Client: o.KubeClient})
+	}
+	if (&artifacts.TektonBundleArtifact{}).Enabled(*cfg) {
+		// Tekton bundles are read with the credentials of the run that built them.
+		ctx = tektonbundles.WithFetcher(ctx, &tektonbundles.RegistryFetcher{
+			Client:             o.KubeClient,
+			Namespace:          tektonObj.GetNamespace(),
+			ServiceAccountName: tektonObj.GetServiceAccountName(),
+			ImagePullSecrets:   tektonObj.GetPullSecrets(),
+		})
+	}
+
+	return ctx, signableTypes, nil
+}
+
+// runUploads runs uploads with at most parallelism of them at once, or
+// DefaultUploadParallelism if it is not positive, and returns the first error one of
+// them returned. Uploads record their own failures to store a payload, so that all of
+// them are reported: they only return an error when the run must be given up on.
+func runUploads(parallelism int, uploads []func() error) error {
+	if parallelism <= 0 {
+		parallelism = DefaultUploadParallelism
+	}
+	var g errgroup.Group
+	g.SetLimit(parallelism)
+	for _, upload := range uploads {
+		g.Go(upload)
+	}
+	return g.Wait()
+}
+
 func HandleRetry(ctx context.Context, obj objects.TektonObject, ps versioned.Interface, annotations map[string]string) error {
 	if RetryAvailable(obj) {
 		return AddRetry(ctx, obj, ps, annotations)
@@ -60,6 +60,16 @@ func Wrap(ctx context.Context, s Signer) (Signer, error) {
 	}, nil
 }
 
+// KeyID returns the ID of pub in the DSSE envelopes of wrapped signers: the SHA256
+// fingerprint of its SSH encoding, e.g. "SHA256:abc".
+func KeyID(pub crypto.PublicKey) (string, error) {
+	sshpk, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return ssh.FingerprintSHA256(sshpk), nil
+}
+
 // sslAdapter converts our signing objects into the type expected by the Envelope signer for wrapping.
 type sslAdapter struct {
 	wrapped Signer
@@ -113,7 +123,7 @@ func (w *sslSigner) Sign(ctx context.Context, payload []byte) ([]byte, []byte, e
 }
 
 func (w *sslSigner) SignMessage(payload io.Reader, opts ...signature.SignOption) ([]byte, error) {
-	m, err := io.ReadAll(payload)
+	m, err := readPayload(payload)
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +138,20 @@ func (w *sslSigner) SignMessage(payload io.Reader, opts ...signature.SignOption)
 	return b, nil
 }
 
+// readPayload reads all of payload. Payloads are usually passed as a *bytes.Reader,
+// whose length is known, so they are read into a buffer of the right size instead of
+// one that is grown until it fits.
+func readPayload(payload io.Reader) ([]byte, error) {
+	if r, ok := payload.(interface{ Len() int }); ok {
+		m := make([]byte, r.Len())
+		if _, err := io.ReadFull(payload, m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	return io.ReadAll(payload)
+}
+
 func (w *sslSigner) Cert() string {
 	return w.cert
 }
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package x509
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sigstore/cosign/v2/pkg/providers"
+)
+
+const (
+	commandProvider = "command"
+	// audienceEnv is the environment variable the audience of the token is passed to
+	// the command in.
+	audienceEnv = "CHAINS_OIDC_AUDIENCE"
+	// commandTimeout bounds how long the command may take to print a token.
+	commandTimeout = 30 * time.Second
+)
+
+func init() {
+	providers.Register(commandProvider, &command{})
+}
+
+type command struct{}
+
+var _ providers.Interface = (*command)(nil)
+
+// TokenCommand is the command and arguments run to get an OIDC token. If
+// identity.token.command is configured, this variable will be updated to match.
+// nolint
+var TokenCommand []string
+
+// Enabled implements providers.Interface
+func (c *command) Enabled(ctx context.Context) bool {
+	return len(TokenCommand) > 0
+}
+
+// Provide implements providers.Interface
+func (c *command) Provide(ctx context.Context, audience string) (string, error) {
+	if len(TokenCommand) == 0 {
+		return "", fmt.Errorf("no identity token command is configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, TokenCommand[0], TokenCommand[1:]...) //nolint:gosec // the command is configured by the operator
+	cmd.Env = append(os.Environ(), audienceEnv+"="+audience)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("running %s: %w: %s", TokenCommand[0], err, strings.TrimSpace(stderr.String()))
+	}
+	tok := strings.TrimSpace(string(out))
+	if tok == "" {
+		return "", fmt.Errorf("%s printed no token", TokenCommand[0])
+	}
+	return tok, nil
+}
@@ -26,6 +26,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/sigstore/cosign/v2/cmd/cosign/cli/fulcio"
@@ -44,6 +46,15 @@ const (
 	defaultOIDCClientID = "sigstore"
 )
 
+var (
+	// tufMu serializes creating Fulcio signers, since the TUF client of sigstore is
+	// global and the signers of each Sigstore stack verify their certificate with the
+	// trust root of their own TUF mirror.
+	tufMu sync.Mutex
+	// tufMirror is the TUF mirror the TUF client was initialized with.
+	tufMirror = tuf.DefaultRemoteRoot
+)
+
 // Signer exposes methods to sign payloads.
 type Signer struct {
 	cert  string
@@ -68,6 +79,10 @@ func NewSigner(ctx context.Context, secretPath string, cfg config.Config) (*Sign
 
 func fulcioSigner(ctx context.Context, cfg config.X509Signer) (*Signer, error) {
 	logger := logging.FromContext(ctx)
+	if cfg.IdentityTokenFile != "" && cfg.IdentityTokenCommand != "" {
+		return nil, errors.New("only one of identity.token.file and identity.token.command may be set")
+	}
+	TokenCommand = strings.Fields(cfg.IdentityTokenCommand)
 	if !providers.Enabled(ctx) && cfg.IdentityTokenFile != "" {
 		FilesystemTokenPath = cfg.IdentityTokenFile
 	}
@@ -76,10 +91,19 @@ func fulcioSigner(ctx context.Context, cfg config.X509Signer) (*Signer, error) {
 	}
 	var tok string
 	var err error
-	if cfg.TUFMirrorURL != tuf.DefaultRemoteRoot {
-		if err = initializeTUF(ctx, cfg.TUFMirrorURL); err != nil {
+	tufMu.Lock()
+	defer tufMu.Unlock()
+	if cfg.TUFMirrorURL != tufMirror {
+		if cfg.TUFMirrorURL == tuf.DefaultRemoteRoot {
+			// The root of the public good instance is embedded.
+			err = tuf.Initialize(ctx, tuf.DefaultRemoteRoot, nil)
+		} else {
+			err = initializeTUF(ctx, cfg.TUFMirrorURL)
+		}
+		if err != nil {
 			return nil, errors.Wrap(err, "initialize tuf")
 		}
+		tufMirror = cfg.TUFMirrorURL
 	}
 
 	if cfg.IdentityTokenFile != "" {
@@ -90,6 +114,13 @@ func fulcioSigner(ctx context.Context, cfg config.X509Signer) (*Signer, error) {
 			cfg.FulcioProvider = fsCustomTokenPathProvider
 		}
 	}
+	if cfg.IdentityTokenCommand != "" && cfg.FulcioProvider == "" {
+		cfg.FulcioProvider = commandProvider
+	}
+	audience := cfg.IdentityTokenAudience
+	if audience == "" {
+		audience = defaultOIDCClientID
+	}
 
 	if cfg.FulcioProvider != "" {
 		logger.Infof("Attempting to get id token from provider %s", cfg.FulcioProvider)
@@ -97,13 +128,13 @@ func fulcioSigner(ctx context.Context, cfg config.X509Signer) (*Signer, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "provide from")
 		}
-		tok, err = p.Provide(ctx, defaultOIDCClientID)
+		tok, err = p.Provide(ctx, audience)
 		if err != nil {
 			return nil, errors.Wrapf(err, "getting token from provider %s", cfg.FulcioProvider)
 		}
 	} else {
 		// if FulcioProvider is not set, all will be tried
-		tok, err = providers.Provide(ctx, defaultOIDCClientID)
+		tok, err = providers.Provide(ctx, audience)
 	}
 	if err != nil {
 		return nil, errors.Wrap(err, "getting provider")
@@ -122,7 +153,7 @@ func fulcioSigner(ctx context.Context, cfg config.X509Signer) (*Signer, error) {
 		FulcioURL:    cfg.FulcioAddr,
 		IDToken:      tok,
 		OIDCIssuer:   cfg.FulcioOIDCIssuer,
-		OIDCClientID: defaultOIDCClientID,
+		OIDCClientID: audience,
 	}, signer)
 	if err != nil {
 		return nil, errors.Wrap(err, "new signer")
@@ -200,3 +200,46 @@ func TestSigner_SignED25519(t *testing.T) {
 		t.Error("invalid signature")
 	}
 }
+
+func TestCommandProvider(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	defer func() { TokenCommand = nil }()
+
+	TokenCommand = nil
+	p := &command{}
+	if p.Enabled(ctx) {
+		t.Error("command provider enabled without a command")
+	}
+	TokenCommand = []string{"sh", "-c", `echo "token-for-$` + audienceEnv + `"`}
+	if !p.Enabled(ctx) {
+		t.Fatal("command provider not enabled with a command")
+	}
+	tok, err := p.Provide(ctx, "chains")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok != "token-for-chains" {
+		t.Errorf("Provide() = %q, want %q", tok, "token-for-chains")
+	}
+
+	TokenCommand = []string{"sh", "-c", "echo denied >&2; exit 1"}
+	if _, err := p.Provide(ctx, "chains"); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Provide() error = %v, want the output of the failed command", err)
+	}
+}
+
+func TestCreateSignerFulcioTokenFileAndCommand(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	data := map[string]string{
+		"signers.x509.fulcio.enabled":         "true",
+		"signers.x509.identity.token.file":    "/var/run/sigstore/cosign/oidc-token",
+		"signers.x509.identity.token.command": "get-token",
+	}
+	cfg, err := config.NewConfigFromMap(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSigner(ctx, t.TempDir(), *cfg); err == nil || !strings.Contains(err.Error(), "only one of") {
+		t.Errorf("NewSigner() error = %v, want an error about setting both token sources", err)
+	}
+}
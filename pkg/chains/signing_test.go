@@ -14,22 +14,41 @@ limitations under the License.
 package chains
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/runtypes"
 	"github.com/tektoncd/chains/pkg/chains/signing"
 	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/tektonbundles"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/chains/pkg/test/tekton"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
 	rtesting "knative.dev/pkg/reconciler/testing"
 
 	_ "github.com/tektoncd/chains/pkg/chains/formats/all"
@@ -106,6 +125,15 @@ func TestSigner_Sign(t *testing.T) {
 			object:  tro,
 			config:  tcfg,
 		},
+		{
+			name: "taskrun system not configured",
+			backends: []*mockBackend{
+				{backendType: "foo"},
+			},
+			wantErr: true,
+			object:  tro,
+			config:  tcfg,
+		},
 		{
 			name: "pipelinerun single system",
 			backends: []*mockBackend{
@@ -184,6 +212,281 @@ func TestSigner_Sign(t *testing.T) {
 	}
 }
 
+func TestSigner_SignVEX(t *testing.T) {
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+			UID:  "tr-uid",
+		},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{Name: "IMAGES", Value: *v1beta1.NewStructuredValues("gcr.io/foo/bar@sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")},
+					{Name: "SCAN_VEX", Value: *v1beta1.NewStructuredValues(`{"@context":"https://openvex.dev/ns/v0.2.0","@id":"https://example.com/vex-1","statements":[{"vulnerability":{"name":"CVE-2023-1234"},"status":"not_affected"}]}`)},
+				},
+			},
+		},
+	})
+
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				StorageBackend: sets.New[string](""),
+			},
+			OCI: config.Artifact{
+				StorageBackend: sets.New[string](""),
+			},
+			VEX: config.Artifact{
+				StorageBackend: sets.New[string]("mock"),
+				Signer:         "x509",
+			},
+		},
+	}
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, cfg)
+
+	b := &mockBackend{backendType: "mock"}
+	ts := &ObjectSigner{
+		Backends:          fakeAllBackends([]*mockBackend{b}),
+		SecretPath:        "./signing/x509/testdata/",
+		Pipelineclientset: ps,
+	}
+	tekton.CreateObject(t, ctx, ps, tro)
+
+	if err := ts.Sign(ctx, tro); err != nil {
+		t.Fatalf("Signer.Sign() error = %v", err)
+	}
+	if b.storedOpts.PayloadFormat != "openvex" {
+		t.Fatalf("expected an openvex payload to be stored, got format %q", b.storedOpts.PayloadFormat)
+	}
+	var statement struct {
+		PredicateType string `json:"predicateType"`
+		Subject       []struct {
+			Name string `json:"name"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(b.storedPayload, &statement); err != nil {
+		t.Fatal(err)
+	}
+	if statement.PredicateType != "https://openvex.dev/ns" || len(statement.Subject) != 1 || statement.Subject[0].Name != "gcr.io/foo/bar" {
+		t.Errorf("unexpected statement %s", b.storedPayload)
+	}
+}
+
+func TestSigner_SignTektonBundle(t *testing.T) {
+	reg := httptest.NewServer(registry.New())
+	defer reg.Close()
+	u, err := url.Parse(reg.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer([]byte("task/build"), types.DockerLayer),
+		Annotations: map[string]string{
+			tektonbundles.APIVersionAnnotation: "tekton.dev/v1beta1",
+			tektonbundles.KindAnnotation:       "task",
+			tektonbundles.NameAnnotation:       "build",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := name.NewTag(u.Host + "/tekton/bundle:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+			UID:  "tr-uid",
+		},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{Name: "BUNDLE_URL", Value: *v1beta1.NewStructuredValues(tag.Context().String())},
+					{Name: "BUNDLE_DIGEST", Value: *v1beta1.NewStructuredValues(digest.String())},
+				},
+			},
+		},
+	})
+
+	// The IPFS node only pins the attestation of the bundle.
+	var mu sync.Mutex
+	var pinned []string
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, h, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		pinned = append(pinned, h.Filename)
+		mu.Unlock()
+		fmt.Fprint(w, `{"Hash": "bafytektonbundle"}`)
+	}))
+	defer node.Close()
+
+	cfg, err := config.NewConfigFromMap(map[string]string{
+		"artifacts.taskrun.storage":       "",
+		"artifacts.oci.storage":           "",
+		"artifacts.tekton-bundle.storage": "ipfs",
+		"storage.ipfs.url":                node.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, cfg)
+
+	// The backends are initialized from the config, as by the controllers.
+	backends, err := storage.InitializeBackends(ctx, ps, fakekubeclient.Get(ctx), *cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := &ObjectSigner{
+		Backends:          backends,
+		SecretPath:        "./signing/x509/testdata/",
+		Pipelineclientset: ps,
+	}
+	tekton.CreateObject(t, ctx, ps, tro)
+
+	if err := ts.Sign(ctx, tro); err != nil {
+		t.Fatalf("Signer.Sign() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pinned) != 1 {
+		t.Errorf("expected the attestation of the bundle to be pinned, got %v", pinned)
+	}
+}
+
+func TestSigner_SignBundle(t *testing.T) {
+	newPipelineRun := func(signed bool) *objects.PipelineRunObject {
+		pro := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+				UID:  "pr-uid",
+			},
+		})
+		tr := &v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo-task",
+				UID:  "tr-uid",
+			},
+		}
+		if signed {
+			tr.Annotations = map[string]string{ChainsAnnotation: "true"}
+		}
+		pro.AppendTaskRun(tr)
+		return pro
+	}
+
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				StorageBackend: sets.New[string]("taskruns"),
+			},
+			PipelineRuns: config.Artifact{
+				Format:               "in-toto",
+				StorageBackend:       sets.New[string]("mock"),
+				Signer:               "x509",
+				BundleStorageBackend: sets.New[string]("bundle"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		signatures       map[string][]string
+		unsigned         bool
+		pipelineRunsOnly bool
+		wantErr          bool
+		wantEnvelopes    int
+	}{
+		{
+			name: "taskrun envelopes found",
+			signatures: map[string][]string{
+				"taskrun-tr-uid": {"{\n  \"payloadType\": \"application/vnd.in-toto+json\"\n}"},
+			},
+			wantEnvelopes: 2,
+		},
+		{
+			name:    "taskrun envelopes missing",
+			wantErr: true,
+		},
+		{
+			name:          "taskrun not signed",
+			unsigned:      true,
+			wantEnvelopes: 1,
+		},
+		{
+			name:             "pipelineruns only",
+			pipelineRunsOnly: true,
+			wantEnvelopes:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			ctx = config.ToContext(ctx, cfg.DeepCopy())
+			if tt.pipelineRunsOnly {
+				ctx = runtypes.WithPipelineRunsOnly(ctx)
+			}
+			pro := newPipelineRun(!tt.unsigned)
+
+			prBackend := &mockBackend{backendType: "mock"}
+			trBackend := &mockBackend{backendType: "taskruns", signatures: tt.signatures}
+			bundleBackend := &mockBackend{backendType: "bundle"}
+			os := &ObjectSigner{
+				Backends:          fakeAllBackends([]*mockBackend{prBackend, trBackend, bundleBackend}),
+				SecretPath:        "./signing/x509/testdata/",
+				Pipelineclientset: ps,
+			}
+
+			tekton.CreateObject(t, ctx, ps, pro)
+
+			if err := os.Sign(ctx, pro); (err != nil) != tt.wantErr {
+				t.Fatalf("Signer.Sign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if bundleBackend.storedPayload != nil {
+					t.Error("expected no bundle to be stored")
+				}
+				return
+			}
+
+			if got, want := bundleBackend.storedOpts.ShortKey, "bundle-pipelinerun-pr-uid"; got != want {
+				t.Errorf("bundle short key = %q, want %q", got, want)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(bundleBackend.storedPayload), "\n"), "\n")
+			if len(lines) != tt.wantEnvelopes {
+				t.Fatalf("expected %d envelopes in bundle, got %d: %s", tt.wantEnvelopes, len(lines), bundleBackend.storedPayload)
+			}
+			for _, l := range lines {
+				if !json.Valid([]byte(l)) {
+					t.Errorf("bundle line is not valid JSON: %s", l)
+				}
+			}
+			if tt.wantEnvelopes > 1 && lines[1] != `{"payloadType":"application/vnd.in-toto+json"}` {
+				t.Errorf("unexpected taskrun envelope in bundle: %s", lines[1])
+			}
+		})
+	}
+}
+
 func TestSigner_Transparency(t *testing.T) {
 	newTaskRun := func(name string) objects.TektonObject {
 		return objects.NewTaskRunObject(&v1beta1.TaskRun{
@@ -317,7 +620,11 @@ func TestSigner_Transparency(t *testing.T) {
 			}
 
 			// add in the annotation
+			obj = tt.getNewObject("myannotatedtektonobject")
 			setAnnotation(obj, RekorAnnotation, "true")
+
+			tekton.CreateObject(t, ctx, ps, obj)
+
 			if err := os.Sign(ctx, obj); err != nil {
 				t.Errorf("Signer.Sign() error = %v", err)
 			}
@@ -393,7 +700,7 @@ func TestSigningObjects(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, _ := rtesting.SetupFakeContext(t)
-			signers := allSigners(ctx, tt.SecretPath, tt.config)
+			signers := AllSigners(ctx, tt.SecretPath, tt.config)
 			var signerTypes []string
 			for _, signer := range signers {
 				signerTypes = append(signerTypes, signer.Type())
@@ -405,6 +712,307 @@ func TestSigningObjects(t *testing.T) {
 	}
 }
 
+func TestSigner_ParallelUploads(t *testing.T) {
+	// Each backend waits for the other one to start storing the payload, so signing
+	// only completes if they upload concurrently.
+	var started sync.WaitGroup
+	started.Add(2)
+	backends := []*concurrentBackend{
+		{mockBackend: mockBackend{backendType: "mock"}, started: &started},
+		{mockBackend: mockBackend{backendType: "foo"}, started: &started},
+	}
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.New[string]("mock", "foo"),
+				Signer:         "x509",
+			},
+		},
+	}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo"}})
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, cfg)
+	ts := &ObjectSigner{
+		Backends:          map[string]storage.Backend{"mock": backends[0], "foo": backends[1]},
+		SecretPath:        "./signing/x509/testdata/",
+		Pipelineclientset: ps,
+	}
+	tekton.CreateObject(t, ctx, ps, tro)
+
+	if err := ts.Sign(ctx, tro); err != nil {
+		t.Fatalf("Signer.Sign() error = %v", err)
+	}
+	for _, b := range backends {
+		if b.storedPayload == nil {
+			t.Errorf("expected payload to be stored in %s", b.backendType)
+		}
+	}
+}
+
+func TestSigner_PolicyDenied(t *testing.T) {
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result": ["builder.id is not trusted"]}`))
+	}))
+	defer opa.Close()
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.New[string]("mock"),
+				Signer:         "x509",
+			},
+		},
+		Policy: config.PolicyConfig{URL: opa.URL},
+	}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo"}})
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, cfg)
+	backend := &mockBackend{backendType: "mock"}
+	ts := &ObjectSigner{
+		Backends:          map[string]storage.Backend{"mock": backend},
+		SecretPath:        "./signing/x509/testdata/",
+		Pipelineclientset: ps,
+	}
+	tekton.CreateObject(t, ctx, ps, tro)
+
+	if err := ts.Sign(ctx, tro); err == nil {
+		t.Error("Signer.Sign() error = nil, want an error for the denied payload")
+	}
+	if backend.storedPayload != nil {
+		t.Error("expected the denied payload not to be stored")
+	}
+}
+
+func TestSigner_ClusterBuilderID(t *testing.T) {
+	cfg := &config.Config{
+		Builder: config.BuilderConfig{ID: "https://chains.example.com/home"},
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.New[string]("mock"),
+				Signer:         "x509",
+			},
+		},
+		Clusters: config.ClustersConfig{BuilderIDs: map[string]string{"edge": "https://chains.example.com/edge"}},
+	}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo"}})
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, cfg)
+	backend := &mockBackend{backendType: "mock"}
+	ts := &ObjectSigner{
+		Backends:          map[string]storage.Backend{"mock": backend},
+		SecretPath:        "./signing/x509/testdata/",
+		Pipelineclientset: ps,
+		Cluster:           "edge",
+	}
+	tekton.CreateObject(t, ctx, ps, tro)
+
+	if err := ts.Sign(ctx, tro); err != nil {
+		t.Fatalf("Signer.Sign() = %v", err)
+	}
+	if !bytes.Contains(backend.storedPayload, []byte(`"id":"https://chains.example.com/edge"`)) {
+		t.Errorf("expected the builder ID of the cluster in the payload, got %s", backend.storedPayload)
+	}
+}
+
+func TestSigner_SigstoreStack(t *testing.T) {
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.New[string]("mock"),
+				Signer:         "x509",
+			},
+		},
+		Transparency: config.TransparencyConfig{Enabled: true, URL: "https://rekor.sigstore.dev"},
+		Sigstore: config.SigstoreConfig{Stacks: map[string]config.SigstoreStack{
+			"private": {Namespaces: sets.New[string]("team-a"), RekorURL: "https://rekor.sigstore.example.com"},
+		}},
+	}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"}})
+
+	var rekorURL string
+	oldRekor := getRekor
+	getRekor = func(cfg config.TransparencyConfig) (rekorClient, error) {
+		rekorURL = cfg.URL
+		return &mockRekor{}, nil
+	}
+	defer func() { getRekor = oldRekor }()
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, cfg)
+	ts := &ObjectSigner{
+		Backends:          map[string]storage.Backend{"mock": &mockBackend{backendType: "mock"}},
+		SecretPath:        "./signing/x509/testdata/",
+		Pipelineclientset: ps,
+	}
+	tekton.CreateObject(t, ctx, ps, tro)
+
+	if err := ts.Sign(ctx, tro); err != nil {
+		t.Fatalf("Signer.Sign() = %v", err)
+	}
+	if want := "https://rekor.sigstore.example.com"; rekorURL != want {
+		t.Errorf("uploaded to %q, want the Rekor instance of the stack %q", rekorURL, want)
+	}
+}
+
+func TestSigner_InvalidPayload(t *testing.T) {
+	// No builder ID is configured, so the provenance has no builder.id.
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.New[string]("mock"),
+				Signer:         "x509",
+			},
+		},
+		Provenance: config.ProvenanceConfig{ValidatePayloads: true},
+	}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}})
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, cfg)
+	backend := &mockBackend{backendType: "mock"}
+	ts := &ObjectSigner{
+		Backends:          map[string]storage.Backend{"mock": backend},
+		SecretPath:        "./signing/x509/testdata/",
+		Pipelineclientset: ps,
+	}
+	tekton.CreateObject(t, ctx, ps, tro)
+
+	if err := ts.Sign(ctx, tro); err != nil {
+		t.Fatalf("Signer.Sign() = %v", err)
+	}
+	if backend.storedPayload == nil {
+		t.Error("expected the invalid payload to be signed and stored")
+	}
+	tr, err := ps.TektonV1beta1().TaskRuns(tro.Namespace).Get(ctx, tro.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tr.Annotations[InvalidAnnotation]; got != "taskrun-uid" {
+		t.Errorf("%s = %q, want %q", InvalidAnnotation, got, "taskrun-uid")
+	}
+}
+
+func TestSigner_DisallowedMaterials(t *testing.T) {
+	tests := []struct {
+		name        string
+		enforcement string
+		wantErr     bool
+		annotation  string
+	}{{
+		name:        "annotate",
+		enforcement: config.MaterialsAnnotate,
+		annotation:  "oci://docker.io/library/alpine",
+	}, {
+		name:        "block",
+		enforcement: config.MaterialsBlock,
+		wantErr:     true,
+	}, {
+		name:        "provenance",
+		enforcement: config.MaterialsProvenance,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         "in-toto",
+						StorageBackend: sets.New[string]("mock"),
+						Signer:         "x509",
+					},
+				},
+				Materials: config.MaterialsConfig{
+					AllowedPrefixes: sets.New[string]("gcr.io/trusted/"),
+					Enforcement:     tt.enforcement,
+				},
+			}
+			tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"},
+				Status: v1beta1.TaskRunStatus{
+					TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+						Steps: []v1beta1.StepState{{
+							Name:    "build",
+							ImageID: "docker.io/library/alpine@sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5",
+						}},
+					},
+				},
+			})
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			ctx = config.ToContext(ctx, cfg)
+			backend := &mockBackend{backendType: "mock"}
+			ts := &ObjectSigner{
+				Backends:          map[string]storage.Backend{"mock": backend},
+				SecretPath:        "./signing/x509/testdata/",
+				Pipelineclientset: ps,
+			}
+			tekton.CreateObject(t, ctx, ps, tro)
+
+			err := ts.Sign(ctx, tro)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Signer.Sign() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if stored := backend.storedPayload != nil; stored == tt.wantErr {
+				t.Errorf("payload stored = %t, want %t", stored, !tt.wantErr)
+			}
+			tr, err := ps.TektonV1beta1().TaskRuns(tro.Namespace).Get(ctx, tro.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tr.Annotations[DisallowedMaterialsAnnotation]; got != tt.annotation {
+				t.Errorf("%s = %q, want %q", DisallowedMaterialsAnnotation, got, tt.annotation)
+			}
+		})
+	}
+}
+
+func TestRunUploads(t *testing.T) {
+	var active, peak int32
+	release := make(chan struct{})
+	upload := func() error {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		<-release
+		return nil
+	}
+	uploads := []func() error{upload, upload, upload, upload, upload}
+
+	done := make(chan error)
+	go func() { done <- runUploads(2, uploads) }()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("runUploads() = %v", err)
+	}
+	if peak != 2 {
+		t.Errorf("expected 2 uploads to run at once, %d did", peak)
+	}
+
+	wantErr := errors.New("context canceled")
+	failing := func() error { return wantErr }
+	if err := runUploads(0, []func() error{func() error { return nil }, failing}); err != wantErr {
+		t.Errorf("runUploads() = %v, want %v", err, wantErr)
+	}
+}
+
 func fakeAllBackends(backends []*mockBackend) map[string]storage.Backend {
 	newBackends := map[string]storage.Backend{}
 	for _, m := range backends {
@@ -415,7 +1023,7 @@ func fakeAllBackends(backends []*mockBackend) map[string]storage.Backend {
 
 func setupMocks(rekor *mockRekor) func() {
 	oldRekor := getRekor
-	getRekor = func(_ string) (rekorClient, error) {
+	getRekor = func(config.TransparencyConfig) (rekorClient, error) {
 		return rekor, nil
 	}
 	return func() {
@@ -437,6 +1045,8 @@ func (r *mockRekor) UploadTlog(ctx context.Context, signer signing.Signer, signa
 
 type mockBackend struct {
 	storedPayload []byte
+	storedOpts    config.StorageOpts
+	signatures    map[string][]string
 	shouldErr     bool
 	backendType   string
 }
@@ -447,9 +1057,32 @@ func (b *mockBackend) StorePayload(ctx context.Context, _ objects.TektonObject,
 		return errors.New("mock error storing")
 	}
 	b.storedPayload = rawPayload
+	b.storedOpts = opts
 	return nil
 }
 
+// concurrentBackend is a mockBackend that only stores payloads once all the backends
+// sharing started are storing them.
+type concurrentBackend struct {
+	mockBackend
+	started *sync.WaitGroup
+}
+
+func (b *concurrentBackend) StorePayload(ctx context.Context, obj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
+	b.started.Done()
+	waited := make(chan struct{})
+	go func() {
+		b.started.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		return b.mockBackend.StorePayload(ctx, obj, rawPayload, signature, opts)
+	case <-time.After(5 * time.Second):
+		return errors.New("payload was not stored concurrently")
+	}
+}
+
 func (b *mockBackend) Type() string {
 	return b.backendType
 }
@@ -459,5 +1092,8 @@ func (b *mockBackend) RetrievePayloads(ctx context.Context, _ objects.TektonObje
 }
 
 func (b *mockBackend) RetrieveSignatures(ctx context.Context, _ objects.TektonObject, opts config.StorageOpts) (map[string][]string, error) {
+	if b.signatures != nil {
+		return b.signatures, nil
+	}
 	return nil, fmt.Errorf("not implemented")
 }
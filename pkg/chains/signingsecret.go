@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tektoncd/chains/pkg/chains/externalsecrets"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/system"
+)
+
+// signingSecretsDir is where referenced signing secrets are written to, so that signers
+// load them like the mounted ones. It is overridden in tests.
+var signingSecretsDir = filepath.Join(os.TempDir(), "chains-signing-secrets")
+
+var (
+	signingSecretsMu sync.Mutex
+	// signingSecretVersions are the resource versions of the referenced signing secrets
+	// written to signingSecretsDir, by their directory.
+	signingSecretVersions = map[string]string{}
+)
+
+var (
+	secretWatchersMu sync.Mutex
+	// secretWatchers watch the referenced signing secrets, so that they aren't read from
+	// the API server for every run that is signed.
+	secretWatchers = map[secretWatcherKey]*secretWatcher{}
+)
+
+type secretWatcherKey struct {
+	client          kubernetes.Interface
+	namespace, name string
+}
+
+// secretWatcher is an informer of a single secret.
+type secretWatcher struct {
+	informer cache.SharedIndexInformer
+	stop     chan struct{}
+}
+
+// watchSecret returns the informer of the secret namespace/name read with client,
+// starting it if it isn't watched yet. The watchers of the other secrets referenced
+// with client are stopped, as the configuration references one secret at a time.
+func watchSecret(client kubernetes.Interface, namespace, name string) cache.SharedIndexInformer {
+	key := secretWatcherKey{client: client, namespace: namespace, name: name}
+	secretWatchersMu.Lock()
+	defer secretWatchersMu.Unlock()
+	if w, ok := secretWatchers[key]; ok {
+		return w.informer
+	}
+	for k, w := range secretWatchers {
+		if k.client == client {
+			close(w.stop)
+			delete(secretWatchers, k)
+		}
+	}
+
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	secrets := client.CoreV1().Secrets(namespace)
+	lw := &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = selector
+			return secrets.List(context.Background(), opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = selector
+			return secrets.Watch(context.Background(), opts)
+		},
+	}
+	w := &secretWatcher{
+		informer: cache.NewSharedIndexInformer(lw, &corev1.Secret{}, 0, cache.Indexers{}),
+		stop:     make(chan struct{}),
+	}
+	go w.informer.Run(w.stop)
+	secretWatchers[key] = w
+	return w.informer
+}
+
+// SigningSecretPath returns the path the signing secrets of cfg are loaded from: sp,
+// where they are mounted, unless cfg references a secret, which is watched with client and
+// written to a directory of its own. The secret must be in the namespace Chains is
+// installed in, or in one of the namespaces it is allowed to be read from. Files of the
+// signing secrets fetched from external secret managers replace the ones of the secret.
+func SigningSecretPath(ctx context.Context, client kubernetes.Interface, sp string, cfg config.Config) (string, error) {
+	dir, err := referencedSecretPath(ctx, client, sp, cfg)
+	if err != nil || len(cfg.Signers.External.Refs) == 0 {
+		return dir, err
+	}
+	external, err := externalsecrets.Fetch(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	files, err := readSigningSecret(dir)
+	if err != nil {
+		return "", fmt.Errorf("reading signing secrets: %w", err)
+	}
+	for file, value := range external {
+		files[file] = value
+	}
+
+	// The files are written to a directory of their own for each of the directories
+	// they replace files of.
+	merged := filepath.Join(signingSecretsDir, "external", dir)
+	signingSecretsMu.Lock()
+	defer signingSecretsMu.Unlock()
+	if err := writeSigningSecret(merged, files); err != nil {
+		return "", fmt.Errorf("writing signing secrets: %w", err)
+	}
+	return merged, nil
+}
+
+// referencedSecretPath returns sp, or the directory the secret referenced by cfg is
+// written to.
+func referencedSecretPath(ctx context.Context, client kubernetes.Interface, sp string, cfg config.Config) (string, error) {
+	ref := cfg.Signers.Secret
+	if ref.Name == "" {
+		return sp, nil
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = system.Namespace()
+	}
+	if namespace != system.Namespace() && !ref.AllowedNamespaces.Has(namespace) {
+		return "", fmt.Errorf("signing secret %s/%s isn't in an allowed namespace", namespace, ref.Name)
+	}
+	if client == nil {
+		return "", fmt.Errorf("no client to read signing secret %s/%s with", namespace, ref.Name)
+	}
+	informer := watchSecret(client, namespace, ref.Name)
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return "", fmt.Errorf("reading signing secret %s/%s: %w", namespace, ref.Name, ctx.Err())
+	}
+	obj, ok, err := informer.GetStore().GetByKey(namespace + "/" + ref.Name)
+	if err != nil {
+		return "", fmt.Errorf("reading signing secret %s/%s: %w", namespace, ref.Name, err)
+	} else if !ok {
+		return "", fmt.Errorf("reading signing secret %s/%s: not found", namespace, ref.Name)
+	}
+	secret := obj.(*corev1.Secret)
+
+	dir := filepath.Join(signingSecretsDir, "referenced", namespace, ref.Name)
+	signingSecretsMu.Lock()
+	defer signingSecretsMu.Unlock()
+	if signingSecretVersions[dir] == secret.ResourceVersion {
+		return dir, nil
+	}
+	delete(signingSecretVersions, dir)
+	if err := writeSigningSecret(dir, secret.Data); err != nil {
+		return "", fmt.Errorf("writing signing secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	signingSecretVersions[dir] = secret.ResourceVersion
+	return dir, nil
+}
+
+// readSigningSecret returns the contents of the files of the signing secrets in dir, by
+// their names. It returns no files if dir doesn't exist.
+func readSigningSecret(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return files, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		// Kubernetes mounts the keys of secrets as symlinks into hidden directories.
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		if files[e.Name()], err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// writeSigningSecret writes a file to dir for each key of data, and removes the files of
+// keys that are no longer in it. Files are replaced by renaming them, so that signers
+// being loaded never read a partially written key, and files that didn't change are left
+// alone, so that the signers loaded from them stay cached.
+func writeSigningSecret(dir string, data map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if _, ok := data[e.Name()]; !ok {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	for key, value := range data {
+		if current, err := os.ReadFile(filepath.Join(dir, key)); err == nil && bytes.Equal(current, value) {
+			continue
+		}
+		tmp, err := os.CreateTemp(dir, "."+key+"-")
+		if err != nil {
+			return err
+		}
+		if _, err := tmp.Write(value); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+		if err := os.Rename(tmp.Name(), filepath.Join(dir, key)); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/system"
+)
+
+func TestSigningSecretPath(t *testing.T) {
+	t.Setenv(system.NamespaceEnvKey, "tekton-chains")
+	signingSecretsDir = t.TempDir()
+	ctx := logtesting.TestContextWithLogger(t)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "chains-signing-secrets", Namespace: "signing-keys", ResourceVersion: "1"},
+		Data: map[string][]byte{
+			"cosign.key":      []byte("key"),
+			"cosign.password": []byte("password"),
+		},
+	}
+	client := fakekube.NewSimpleClientset(secret)
+
+	cfg := config.Config{}
+	if got, err := SigningSecretPath(ctx, client, "/etc/signing-secrets", cfg); err != nil || got != "/etc/signing-secrets" {
+		t.Errorf("SigningSecretPath() = %q, %v without a referenced secret, want the mounted secrets", got, err)
+	}
+
+	cfg.Signers.Secret = config.SigningSecretConfig{Namespace: "signing-keys", Name: "chains-signing-secrets"}
+	if _, err := SigningSecretPath(ctx, client, "/etc/signing-secrets", cfg); err == nil {
+		t.Error("expected an error for a secret in a namespace that isn't allowed")
+	}
+
+	cfg.Signers.Secret.AllowedNamespaces = sets.New[string]("signing-keys")
+	dir, err := SigningSecretPath(ctx, client, "/etc/signing-secrets", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range secret.Data {
+		if got, err := os.ReadFile(filepath.Join(dir, key)); err != nil || string(got) != string(want) {
+			t.Errorf("%s = %q, %v, want %q", key, got, err, want)
+		}
+	}
+
+	secret.ResourceVersion = "2"
+	secret.Data = map[string][]byte{"cosign.key": []byte("rotated")}
+	if _, err := client.CoreV1().Secrets("signing-keys").Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// The rotated secret is written once the watch delivers it.
+	var got []byte
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, err := SigningSecretPath(ctx, client, "/etc/signing-secrets", cfg); err != nil {
+			t.Fatal(err)
+		}
+		if got, err = os.ReadFile(filepath.Join(dir, "cosign.key")); err == nil && string(got) == "rotated" {
+			break
+		}
+	}
+	if string(got) != "rotated" {
+		t.Errorf("cosign.key = %q, %v after the secret was rotated, want %q", got, err, "rotated")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cosign.password")); !os.IsNotExist(err) {
+		t.Errorf("cosign.password wasn't removed from the rotated secret: %v", err)
+	}
+
+	cfg.Signers.Secret = config.SigningSecretConfig{Name: "missing"}
+	if _, err := SigningSecretPath(ctx, client, "/etc/signing-secrets", cfg); err == nil {
+		t.Error("expected an error for a missing secret in the install namespace")
+	}
+}
+
+func TestSigningSecretPath_External(t *testing.T) {
+	signingSecretsDir = t.TempDir()
+	ctx := logtesting.TestContextWithLogger(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"data":{"password":"external"}}}`))
+	}))
+	defer srv.Close()
+
+	mounted := t.TempDir()
+	for file, value := range map[string]string{"cosign.key": "key", "cosign.password": "mounted"} {
+		if err := os.WriteFile(filepath.Join(mounted, file), []byte(value), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := config.Config{Signers: config.SignerConfigs{
+		KMS:      config.KMSSigner{Auth: config.KMSAuth{Address: srv.URL, Token: "token"}},
+		External: config.ExternalSecretsConfig{Refs: map[string]string{"cosign.password": "hashivault://secret/data/chains?field=password"}},
+	}}
+	dir, err := SigningSecretPath(ctx, nil, mounted, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string]string{"cosign.key": "key", "cosign.password": "external"} {
+		if got, err := os.ReadFile(filepath.Join(dir, file)); err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", file, got, err, want)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "cosign.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SigningSecretPath(ctx, nil, mounted, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := os.Stat(filepath.Join(dir, "cosign.key")); err != nil || !again.ModTime().Equal(info.ModTime()) {
+		t.Errorf("cosign.key was written again without changing: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+)
+
+// SigningStatusName returns the name of the SigningStatus of the run of the given kind
+// and name, e.g. "build-taskrun".
+func SigningStatusName(kind, name string) string {
+	return kmeta.ChildName(name, "-"+kind)
+}
+
+// recordSigningStatus creates or updates the SigningStatus of obj with the outcome in ev.
+// Errors are logged, as they must not fail signing.
+func (o *ObjectSigner) recordSigningStatus(ctx context.Context, obj objects.TektonObject, ev audit.Event) {
+	logger := logging.FromContext(ctx)
+	if o.DynamicClient == nil {
+		return
+	}
+
+	client := o.DynamicClient.Resource(v1alpha1.SigningStatusResource).Namespace(obj.GetNamespace())
+	name := SigningStatusName(obj.GetKindName(), obj.GetName())
+	existing, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Warnf("error getting SigningStatus %s/%s: %v", obj.GetNamespace(), name, err)
+		return
+	}
+
+	status := &v1alpha1.SigningStatus{}
+	if err == nil {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(existing.Object, status); err != nil {
+			logger.Warnf("error reading SigningStatus %s/%s: %v", obj.GetNamespace(), name, err)
+			return
+		}
+	}
+	create := status.Name == ""
+	if !create && status.Spec.Run.UID != string(obj.GetUID()) {
+		// The SigningStatus is left over from a deleted run with the same name.
+		status.Status = v1alpha1.SigningStatusStatus{}
+		status.OwnerReferences = nil
+	}
+	status.Name, status.Namespace = name, obj.GetNamespace()
+	status.Spec.Run = v1alpha1.RunReference{Kind: ownerKind(obj), Name: obj.GetName(), UID: string(obj.GetUID())}
+	if len(status.OwnerReferences) == 0 && obj.GetUID() != "" {
+		status.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: v1beta1.SchemeGroupVersion.String(),
+			Kind:       ownerKind(obj),
+			Name:       obj.GetName(),
+			UID:        obj.GetUID(),
+		}}
+	}
+	updateSigningStatus(&status.Status, ev, time.Now())
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+	if err != nil {
+		logger.Warnf("error converting SigningStatus %s/%s: %v", obj.GetNamespace(), name, err)
+		return
+	}
+	object := &unstructured.Unstructured{Object: u}
+	object.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("SigningStatus"))
+	if create {
+		_, err = client.Create(ctx, object, metav1.CreateOptions{})
+	} else {
+		_, err = client.Update(ctx, object, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		logger.Warnf("error writing SigningStatus %s/%s: %v", obj.GetNamespace(), name, err)
+	}
+}
+
+// updateSigningStatus records the outcome in ev as the latest attempt in status.
+func updateSigningStatus(status *v1alpha1.SigningStatusStatus, ev audit.Event, now time.Time) {
+	status.Decision, status.Reason = ev.Decision, ev.Reason
+	status.Artifacts = nil
+	for _, a := range ev.Artifacts {
+		status.Artifacts = append(status.Artifacts, v1alpha1.SignedArtifact{
+			Type:         a.Type,
+			Key:          a.Key,
+			Format:       a.Format,
+			Subjects:     a.Subjects,
+			Signer:       a.Signer,
+			Identity:     a.Identity,
+			Signatures:   a.Signatures,
+			Storage:      a.Backends,
+			Transparency: a.Transparency,
+			Locations:    a.Locations,
+			Error:        a.Error,
+		})
+	}
+	status.Attempts = append(status.Attempts, v1alpha1.SigningAttempt{
+		Time:     metav1.NewTime(now),
+		Decision: ev.Decision,
+		Reason:   ev.Reason,
+	})
+	if n := len(status.Attempts); n > v1alpha1.MaxSigningAttempts {
+		status.Attempts = status.Attempts[n-v1alpha1.MaxSigningAttempts:]
+	}
+}
+
+// ownerKind returns the kind of the run obj, e.g. "TaskRun".
+func ownerKind(obj objects.TektonObject) string {
+	switch obj.GetObject().(type) {
+	case *v1beta1.PipelineRun:
+		return "PipelineRun"
+	default:
+		return "TaskRun"
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestRecordSigningStatus(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	o := &ObjectSigner{DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme)}
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: types.UID("uid")},
+	})
+
+	failed := audit.Event{Decision: audit.DecisionFailed, Reason: "storage: unavailable"}
+	signed := audit.Event{
+		Decision: audit.DecisionSigned,
+		Artifacts: []*audit.Artifact{{
+			Type:         "tekton",
+			Key:          "taskrun-uid",
+			Format:       "in-toto",
+			Subjects:     []string{"gcr.io/foo/bar@sha256:abc"},
+			Signer:       "x509",
+			Signatures:   []string{"c2ln"},
+			Backends:     []string{"tekton", "oci"},
+			Transparency: "https://rekor.sigstore.dev/api/v1/log/entries?logIndex=1",
+		}},
+	}
+	o.recordSigningStatus(ctx, obj, failed)
+	o.recordSigningStatus(ctx, obj, signed)
+
+	u, err := o.DynamicClient.Resource(v1alpha1.SigningStatusResource).Namespace("ns").Get(ctx, "build-taskrun", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &v1alpha1.SigningStatus{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(v1alpha1.RunReference{Kind: "TaskRun", Name: "build", UID: "uid"}, got.Spec.Run); diff != "" {
+		t.Errorf("run reference (-want +got): %s", diff)
+	}
+	if len(got.OwnerReferences) != 1 || got.OwnerReferences[0].UID != "uid" {
+		t.Errorf("expected SigningStatus to be owned by the taskrun, got %v", got.OwnerReferences)
+	}
+	wantArtifacts := []v1alpha1.SignedArtifact{{
+		Type:         "tekton",
+		Key:          "taskrun-uid",
+		Format:       "in-toto",
+		Subjects:     []string{"gcr.io/foo/bar@sha256:abc"},
+		Signer:       "x509",
+		Signatures:   []string{"c2ln"},
+		Storage:      []string{"tekton", "oci"},
+		Transparency: "https://rekor.sigstore.dev/api/v1/log/entries?logIndex=1",
+	}}
+	if got.Status.Decision != audit.DecisionSigned {
+		t.Errorf("decision = %q, want %q", got.Status.Decision, audit.DecisionSigned)
+	}
+	if diff := cmp.Diff(wantArtifacts, got.Status.Artifacts); diff != "" {
+		t.Errorf("artifacts (-want +got): %s", diff)
+	}
+	var decisions []string
+	for _, a := range got.Status.Attempts {
+		decisions = append(decisions, a.Decision+":"+a.Reason)
+	}
+	if diff := cmp.Diff([]string{"failed:storage: unavailable", "signed:"}, decisions); diff != "" {
+		t.Errorf("attempts (-want +got): %s", diff)
+	}
+}
+
+func TestUpdateSigningStatus_History(t *testing.T) {
+	status := &v1alpha1.SigningStatusStatus{}
+	now := time.Now()
+	for i := 0; i < v1alpha1.MaxSigningAttempts+2; i++ {
+		updateSigningStatus(status, audit.Event{Decision: audit.DecisionFailed, Reason: fmt.Sprint(i)}, now)
+	}
+	if len(status.Attempts) != v1alpha1.MaxSigningAttempts {
+		t.Fatalf("kept %d attempts, want %d", len(status.Attempts), v1alpha1.MaxSigningAttempts)
+	}
+	if first := status.Attempts[0].Reason; first != "2" {
+		t.Errorf("oldest attempt kept = %s, want 2", first)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// StepEnvAnnotation can be set to "true" or "false" on a TaskRun, on the Task it runs or
+// on the metadata of a pipeline task, to record the env vars of its steps or leave them
+// out regardless of provenance.step-env. The env vars provenance.step-env.deny matches
+// are left out either way.
+const StepEnvAnnotation = "chains.tekton.dev/step-env"
+
+// stepEnvPolicy decides which env vars of steps are recorded in provenance.
+type stepEnvPolicy config.ProvenanceConfig
+
+// filterStepEnv returns a copy of obj, and for PipelineRuns of their TaskRuns, without
+// the env vars of steps, step templates, sidecars and pod templates the policy of cfg
+// leaves out, for payloads to be generated from. obj itself is returned if all of them
+// are recorded.
+func filterStepEnv(obj objects.TektonObject, cfg config.ProvenanceConfig) objects.TektonObject {
+	p := stepEnvPolicy(cfg)
+	switch o := obj.(type) {
+	case *objects.TaskRunObject:
+		if p.recordsAll(o.TaskRun.Annotations) {
+			return obj
+		}
+		return objects.NewTaskRunObject(p.taskRun(o.TaskRun))
+	case *objects.PipelineRunObject:
+		if p.recordsAll(o.PipelineRun.Annotations) && p.recordsAllOf(o) {
+			return obj
+		}
+		filtered := objects.NewPipelineRunObject(p.pipelineRun(o.PipelineRun))
+		for _, tr := range o.GetTaskRuns() {
+			filtered.AppendTaskRun(p.taskRun(tr))
+		}
+		return filtered
+	}
+	return obj
+}
+
+// recordsAll returns whether all the env vars of a resource with annotations are recorded.
+func (p stepEnvPolicy) recordsAll(annotations map[string]string) bool {
+	return p.StepEnvAllow.Len() == 0 && p.StepEnvDeny.Len() == 0 && p.include(annotations)
+}
+
+// recordsAllOf returns whether all the env vars of the pipeline tasks and TaskRuns of
+// pro are recorded.
+func (p stepEnvPolicy) recordsAllOf(pro *objects.PipelineRunObject) bool {
+	for _, ps := range []*v1beta1.PipelineSpec{pro.Spec.PipelineSpec, pro.Status.PipelineSpec} {
+		if ps == nil {
+			continue
+		}
+		for _, t := range append(ps.Tasks, ps.Finally...) {
+			if t.TaskSpec != nil && !p.include(t.TaskSpec.Metadata.Annotations) {
+				return false
+			}
+		}
+	}
+	for _, s := range pro.Spec.TaskRunSpecs {
+		if s.Metadata != nil && !p.include(s.Metadata.Annotations) {
+			return false
+		}
+	}
+	for _, tr := range pro.GetTaskRuns() {
+		if !p.include(tr.Annotations) {
+			return false
+		}
+	}
+	return true
+}
+
+// include returns whether the env vars of a resource are recorded, according to the
+// StepEnvAnnotation of the first of annotations that has one, or to StepEnv otherwise.
+func (p stepEnvPolicy) include(annotations ...map[string]string) bool {
+	for _, a := range annotations {
+		switch a[StepEnvAnnotation] {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return p.StepEnv != config.StepEnvExclude
+}
+
+// filter returns the env vars of env that are recorded, if include.
+func (p stepEnvPolicy) filter(env []corev1.EnvVar, include bool) []corev1.EnvVar {
+	if !include {
+		return nil
+	}
+	var recorded []corev1.EnvVar
+	for _, e := range env {
+		if matchesEnv(p.StepEnvDeny, e.Name) || (p.StepEnvAllow.Len() > 0 && !matchesEnv(p.StepEnvAllow, e.Name)) {
+			continue
+		}
+		recorded = append(recorded, e)
+	}
+	return recorded
+}
+
+// taskRun returns a copy of tr without the env vars that aren't recorded.
+func (p stepEnvPolicy) taskRun(tr *v1beta1.TaskRun) *v1beta1.TaskRun {
+	tr = tr.DeepCopy()
+	include := p.include(tr.Annotations)
+	p.taskSpec(tr.Spec.TaskSpec, include)
+	p.taskSpec(tr.Status.TaskSpec, include)
+	p.podTemplate(tr.Spec.PodTemplate, include)
+	return tr
+}
+
+// pipelineRun returns a copy of pr without the env vars that aren't recorded. The
+// annotations of pipeline tasks take precedence over those of pr.
+func (p stepEnvPolicy) pipelineRun(pr *v1beta1.PipelineRun) *v1beta1.PipelineRun {
+	pr = pr.DeepCopy()
+	for _, ps := range []*v1beta1.PipelineSpec{pr.Spec.PipelineSpec, pr.Status.PipelineSpec} {
+		if ps == nil {
+			continue
+		}
+		for _, tasks := range [][]v1beta1.PipelineTask{ps.Tasks, ps.Finally} {
+			for i := range tasks {
+				if t := tasks[i].TaskSpec; t != nil {
+					p.taskSpec(&t.TaskSpec, p.include(t.Metadata.Annotations, pr.Annotations))
+				}
+			}
+		}
+	}
+	p.podTemplate(pr.Spec.PodTemplate, p.include(pr.Annotations))
+	for i := range pr.Spec.TaskRunSpecs {
+		s := &pr.Spec.TaskRunSpecs[i]
+		var annotations map[string]string
+		if s.Metadata != nil {
+			annotations = s.Metadata.Annotations
+		}
+		p.podTemplate(s.TaskPodTemplate, p.include(annotations, pr.Annotations))
+	}
+	return pr
+}
+
+func (p stepEnvPolicy) taskSpec(ts *v1beta1.TaskSpec, include bool) {
+	if ts == nil {
+		return
+	}
+	for i := range ts.Steps {
+		ts.Steps[i].Env = p.filter(ts.Steps[i].Env, include)
+	}
+	if ts.StepTemplate != nil {
+		ts.StepTemplate.Env = p.filter(ts.StepTemplate.Env, include)
+	}
+	for i := range ts.Sidecars {
+		ts.Sidecars[i].Env = p.filter(ts.Sidecars[i].Env, include)
+	}
+}
+
+func (p stepEnvPolicy) podTemplate(tpl *pod.PodTemplate, include bool) {
+	if tpl != nil {
+		tpl.Env = p.filter(tpl.Env, include)
+	}
+}
+
+// matchesEnv returns whether name is one of names, or starts with the prefix of one of
+// them ending with *.
+func matchesEnv(names sets.Set[string], name string) bool {
+	if names.Has(name) {
+		return true
+	}
+	for n := range names {
+		if strings.HasSuffix(n, "*") && strings.HasPrefix(name, strings.TrimSuffix(n, "*")) {
+			return true
+		}
+	}
+	return false
+}
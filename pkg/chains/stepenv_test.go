@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func envVars(names ...string) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for _, n := range names {
+		env = append(env, corev1.EnvVar{Name: n, Value: "value"})
+	}
+	return env
+}
+
+// stepEnvNames returns the names of the env vars of the steps of ts.
+func stepEnvNames(ts *v1beta1.TaskSpec) []string {
+	var names []string
+	for _, s := range ts.Steps {
+		for _, e := range s.Env {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+func TestFilterStepEnv(t *testing.T) {
+	spec := func() *v1beta1.TaskSpec {
+		return &v1beta1.TaskSpec{
+			Steps:        []v1beta1.Step{{Name: "build", Env: envVars("GOFLAGS", "AWS_SECRET_ACCESS_KEY", "NPM_CONFIG_REGISTRY")}},
+			StepTemplate: &v1beta1.StepTemplate{Env: envVars("HOME")},
+			Sidecars:     []v1beta1.Sidecar{{Name: "docker", Env: envVars("DOCKER_TLS_CERTDIR")}},
+		}
+	}
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Spec: v1beta1.TaskRunSpec{
+			TaskSpec:    spec(),
+			PodTemplate: &pod.PodTemplate{Env: envVars("HTTP_PROXY")},
+		},
+		Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{TaskSpec: spec()}},
+	}
+
+	tests := []struct {
+		name        string
+		cfg         config.ProvenanceConfig
+		annotation  string
+		want        []string
+		wantRemoved bool
+	}{{
+		name: "recorded",
+		want: []string{"GOFLAGS", "AWS_SECRET_ACCESS_KEY", "NPM_CONFIG_REGISTRY"},
+	}, {
+		name:        "excluded",
+		cfg:         config.ProvenanceConfig{StepEnv: config.StepEnvExclude},
+		wantRemoved: true,
+	}, {
+		name:        "allowed",
+		cfg:         config.ProvenanceConfig{StepEnvAllow: sets.New[string]("GOFLAGS", "NPM_CONFIG_*")},
+		want:        []string{"GOFLAGS", "NPM_CONFIG_REGISTRY"},
+		wantRemoved: true,
+	}, {
+		name: "denied",
+		cfg:  config.ProvenanceConfig{StepEnvDeny: sets.New[string]("AWS_*")},
+		want: []string{"GOFLAGS", "NPM_CONFIG_REGISTRY"},
+	}, {
+		name:        "task opts out",
+		annotation:  "false",
+		wantRemoved: true,
+	}, {
+		// The deny list applies to the tasks that opt in too.
+		name:       "task opts in",
+		cfg:        config.ProvenanceConfig{StepEnv: config.StepEnvExclude, StepEnvDeny: sets.New[string]("AWS_*")},
+		annotation: "true",
+		want:       []string{"GOFLAGS", "NPM_CONFIG_REGISTRY"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := tr.DeepCopy()
+			if tt.annotation != "" {
+				run.Annotations = map[string]string{StepEnvAnnotation: tt.annotation}
+			}
+			got := filterStepEnv(objects.NewTaskRunObject(run), tt.cfg).(*objects.TaskRunObject)
+			for _, ts := range []*v1beta1.TaskSpec{got.Spec.TaskSpec, got.Status.TaskSpec} {
+				if diff := cmp.Diff(tt.want, stepEnvNames(ts)); diff != "" {
+					t.Errorf("env vars -want +got: %s", diff)
+				}
+			}
+			removed := len(got.Spec.PodTemplate.Env) == 0 && len(got.Status.TaskSpec.StepTemplate.Env) == 0 && len(got.Status.TaskSpec.Sidecars[0].Env) == 0
+			if removed != tt.wantRemoved {
+				t.Errorf("expected the env vars of the pod template, step template and sidecars to be removed %t, got %+v", tt.wantRemoved, got.Status.TaskSpec)
+			}
+		})
+	}
+	if len(stepEnvNames(tr.Status.TaskSpec)) != 3 {
+		t.Error("expected the run not to be modified")
+	}
+}
+
+func TestFilterStepEnv_PipelineRun(t *testing.T) {
+	pro := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Status: v1beta1.PipelineRunStatus{PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+			PipelineSpec: &v1beta1.PipelineSpec{Tasks: []v1beta1.PipelineTask{{
+				Name: "build",
+				TaskSpec: &v1beta1.EmbeddedTask{
+					TaskSpec: v1beta1.TaskSpec{Steps: []v1beta1.Step{{Name: "build", Env: envVars("GOFLAGS")}}},
+				},
+			}, {
+				Name: "deploy",
+				TaskSpec: &v1beta1.EmbeddedTask{
+					Metadata: v1beta1.PipelineTaskMetadata{Annotations: map[string]string{StepEnvAnnotation: "false"}},
+					TaskSpec: v1beta1.TaskSpec{Steps: []v1beta1.Step{{Name: "deploy", Env: envVars("KUBECONFIG")}}},
+				},
+			}}},
+		}},
+	})
+	pro.AppendTaskRun(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-deploy", Annotations: map[string]string{StepEnvAnnotation: "false"}},
+		Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+			TaskSpec: &v1beta1.TaskSpec{Steps: []v1beta1.Step{{Name: "deploy", Env: envVars("KUBECONFIG")}}},
+		}},
+	})
+
+	got := filterStepEnv(pro, config.ProvenanceConfig{}).(*objects.PipelineRunObject)
+	tasks := got.Status.PipelineSpec.Tasks
+	if diff := cmp.Diff([]string{"GOFLAGS"}, stepEnvNames(&tasks[0].TaskSpec.TaskSpec)); diff != "" {
+		t.Errorf("env vars of the build task -want +got: %s", diff)
+	}
+	if names := stepEnvNames(&tasks[1].TaskSpec.TaskSpec); len(names) != 0 {
+		t.Errorf("expected the env vars of the deploy task to be left out, got %v", names)
+	}
+	trs := got.GetTaskRuns()
+	if len(trs) != 1 || len(stepEnvNames(trs[0].Status.TaskSpec)) != 0 {
+		t.Errorf("expected the env vars of the deploy taskrun to be left out, got %v", trs)
+	}
+	if len(stepEnvNames(pro.GetTaskRuns()[0].Status.TaskSpec)) != 1 {
+		t.Error("expected the taskruns not to be modified")
+	}
+}
@@ -16,6 +16,7 @@ package api
 
 import (
 	"context"
+	"sync"
 
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/chains/signing"
@@ -35,10 +36,39 @@ type StoreRequest[Input any, Output any] struct {
 
 // StoreResponse contains metadata for the result of the store operation.
 type StoreResponse struct {
-	// currently empty, but may contain data in the future.
-	// present to allow for backwards compatible changes to the Storer interface in the future.
+	// Locations are where the payload was stored, e.g. OCI references or object paths.
+	Locations []string
 }
 
 type Storer[Input, Output any] interface {
 	Store(context.Context, *StoreRequest[Input, Output]) (*StoreResponse, error)
 }
+
+type locationsKey struct{}
+
+type locations struct {
+	mu   sync.Mutex
+	list []string
+}
+
+// WithLocations returns a context that collects the locations recorded with
+// RecordLocations by the storage backend it is passed to, and a function returning them.
+func WithLocations(ctx context.Context) (context.Context, func() []string) {
+	l := &locations{}
+	return context.WithValue(ctx, locationsKey{}, l), func() []string {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return append([]string(nil), l.list...)
+	}
+}
+
+// RecordLocations records where a payload was stored, if ctx collects locations.
+func RecordLocations(ctx context.Context, locs ...string) {
+	l, ok := ctx.Value(locationsKey{}).(*locations)
+	if !ok {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.list = append(l.list, locs...)
+}
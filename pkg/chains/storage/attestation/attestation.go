@@ -0,0 +1,175 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage/api"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	StorageBackendAttestation = "attestation"
+
+	// RunLabel labels Attestations with the UID of their run.
+	RunLabel = "chains.tekton.dev/run-uid"
+)
+
+// Backend is a storage backend that stores signed payloads in Attestations in the
+// namespace of the run, so that reading them can be granted with RBAC and they can be
+// kept after the run is deleted.
+type Backend struct {
+	client dynamic.Interface
+}
+
+// NewStorageBackend returns a new Attestation StorageBackend that stores payloads with client.
+func NewStorageBackend(client dynamic.Interface) *Backend {
+	return &Backend{
+		client: client,
+	}
+}
+
+func (b *Backend) Type() string {
+	return StorageBackendAttestation
+}
+
+// Name returns the name of the Attestation of the artifact with the given short key of
+// the run of the given kind and name.
+func Name(kind, name, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return kmeta.ChildName(name, fmt.Sprintf("-%s-%s", strings.ToLower(kind), hex.EncodeToString(sum[:4])))
+}
+
+// StorePayload implements the storage.Backend interface. The Attestation of the artifact
+// is replaced if it was stored before, like when the run is signed again.
+func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
+	logger := logging.FromContext(ctx)
+
+	kind := runKind(obj)
+	attestation := &v1alpha1.Attestation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      Name(kind, obj.GetName(), opts.ShortKey),
+			Namespace: obj.GetNamespace(),
+			Labels:    map[string]string{RunLabel: string(obj.GetUID())},
+		},
+		Spec: v1alpha1.AttestationSpec{
+			Run:       v1alpha1.RunReference{Kind: kind, Name: obj.GetName(), UID: string(obj.GetUID())},
+			Key:       opts.ShortKey,
+			Format:    string(opts.PayloadFormat),
+			Subjects:  subjects(rawPayload),
+			Payload:   rawPayload,
+			Signature: []byte(signature),
+			Cert:      opts.Cert,
+			Chain:     opts.Chain,
+		},
+	}
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(attestation)
+	if err != nil {
+		return err
+	}
+	object := &unstructured.Unstructured{Object: u}
+	object.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("Attestation"))
+	client := b.client.Resource(v1alpha1.AttestationResource).Namespace(obj.GetNamespace())
+	_, err = client.Create(ctx, object, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		existing, getErr := client.Get(ctx, attestation.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		object.SetResourceVersion(existing.GetResourceVersion())
+		_, err = client.Update(ctx, object, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("storing Attestation %s/%s: %w", attestation.Namespace, attestation.Name, err)
+	}
+	logger.Infof("Stored payload for %s %s/%s in Attestation %s", obj.GetGVK(), obj.GetNamespace(), obj.GetName(), attestation.Name)
+	api.RecordLocations(ctx, attestation.Namespace+"/"+attestation.Name)
+	return nil
+}
+
+// RetrievePayloads returns the payload of the Attestation of the artifact, keyed by its name.
+func (b *Backend) RetrievePayloads(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string]string, error) {
+	attestation, err := b.get(ctx, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{attestation.Name: string(attestation.Spec.Payload)}, nil
+}
+
+// RetrieveSignatures returns the signature of the Attestation of the artifact, keyed by its name.
+func (b *Backend) RetrieveSignatures(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string][]string, error) {
+	attestation, err := b.get(ctx, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]string{attestation.Name: {string(attestation.Spec.Signature)}}, nil
+}
+
+func (b *Backend) get(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (*v1alpha1.Attestation, error) {
+	name := Name(runKind(obj), obj.GetName(), opts.ShortKey)
+	u, err := b.client.Resource(v1alpha1.AttestationResource).Namespace(obj.GetNamespace()).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting Attestation %s/%s: %w", obj.GetNamespace(), name, err)
+	}
+	attestation := &v1alpha1.Attestation{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, attestation); err != nil {
+		return nil, err
+	}
+	// Attestations of a deleted run with the same name are not the ones of obj.
+	if obj.GetUID() != "" && attestation.Spec.Run.UID != string(obj.GetUID()) {
+		return nil, fmt.Errorf("found Attestation %s/%s of another run with UID %s", obj.GetNamespace(), name, attestation.Spec.Run.UID)
+	}
+	return attestation, nil
+}
+
+// subjects returns the subjects of the JSON-encoded in-toto statement payload, or
+// nothing for payloads that aren't in-toto statements.
+func subjects(payload []byte) []v1alpha1.AttestationSubject {
+	var statement struct {
+		Subject []v1alpha1.AttestationSubject `json:"subject"`
+	}
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil
+	}
+	return statement.Subject
+}
+
+// runKind returns the kind of obj.
+func runKind(obj objects.TektonObject) string {
+	switch obj.GetObject().(type) {
+	case *v1beta1.PipelineRun:
+		return "PipelineRun"
+	case *v1beta1.CustomRun:
+		return "CustomRun"
+	default:
+		return "TaskRun"
+	}
+}
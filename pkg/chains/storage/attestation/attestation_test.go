@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+const statement = `{"_type":"https://in-toto.io/Statement/v0.1","subject":[{"name":"registry.example.com/app","digest":{"sha256":"abc"}}]}`
+
+func TestBackend_StorePayload(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	dc := dynamicfake.NewSimpleDynamicClient(scheme)
+	b := NewStorageBackend(dc)
+
+	obj := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: types.UID("uid")},
+	})
+	opts := config.StorageOpts{ShortKey: "pipelinerun-uid", PayloadFormat: "slsa/v1", Cert: "cert"}
+	if err := b.StorePayload(ctx, obj, []byte("old"), "old-signature", opts); err != nil {
+		t.Fatal(err)
+	}
+	// Storing the payload again replaces the Attestation.
+	if err := b.StorePayload(ctx, obj, []byte(statement), "signature", opts); err != nil {
+		t.Fatal(err)
+	}
+
+	name := Name("PipelineRun", "build", "pipelinerun-uid")
+	u, err := dc.Resource(v1alpha1.AttestationResource).Namespace("ns").Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got v1alpha1.Attestation
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Labels[RunLabel] != "uid" || len(got.OwnerReferences) != 0 {
+		t.Errorf("got labels %v and owner references %v, want the run UID label and no owner", got.Labels, got.OwnerReferences)
+	}
+	want := v1alpha1.AttestationSpec{
+		Run:       v1alpha1.RunReference{Kind: "PipelineRun", Name: "build", UID: "uid"},
+		Key:       "pipelinerun-uid",
+		Format:    "slsa/v1",
+		Subjects:  []v1alpha1.AttestationSubject{{Name: "registry.example.com/app", Digest: map[string]string{"sha256": "abc"}}},
+		Payload:   []byte(statement),
+		Signature: []byte("signature"),
+		Cert:      "cert",
+	}
+	if diff := cmp.Diff(want, got.Spec); diff != "" {
+		t.Errorf("unexpected spec: (-want, +got): %s", diff)
+	}
+
+	payloads, err := b.RetrievePayloads(ctx, obj, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{name: statement}, payloads); diff != "" {
+		t.Errorf("unexpected payloads: (-want, +got): %s", diff)
+	}
+	sigs, err := b.RetrieveSignatures(ctx, obj, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string][]string{name: {"signature"}}, sigs); diff != "" {
+		t.Errorf("unexpected signatures: (-want, +got): %s", diff)
+	}
+
+	// A new run with the same name doesn't get the Attestations of the deleted one.
+	recreated := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: types.UID("other")},
+	})
+	if _, err := b.RetrievePayloads(ctx, recreated, opts); err == nil {
+		t.Error("RetrievePayloads() should fail for another run with the same name")
+	}
+}
+
+func TestName(t *testing.T) {
+	taskRun := Name("TaskRun", "build", "key")
+	if taskRun == Name("PipelineRun", "build", "key") || taskRun == Name("TaskRun", "build", "other") {
+		t.Errorf("Name() = %q should differ by kind and key", taskRun)
+	}
+	long := Name("TaskRun", "a-very-long-run-name-that-goes-on-and-on-and-on-and-on-and-on", "key")
+	if len(long) > 63 {
+		t.Errorf("Name() = %q is longer than 63 characters", long)
+	}
+}
@@ -17,23 +17,38 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage/api"
+	"github.com/tektoncd/chains/pkg/chains/storage/naming"
 	"github.com/tektoncd/chains/pkg/config"
 	"gocloud.dev/docstore"
 	_ "gocloud.dev/docstore/awsdynamodb"
 	_ "gocloud.dev/docstore/gcpfirestore"
 	_ "gocloud.dev/docstore/mongodocstore"
+	"gocloud.dev/gcerrors"
 )
 
 const (
 	StorageTypeDocDB = "docdb"
+
+	// DefaultNameTemplate is the template of the names documents are keyed by when the
+	// config doesn't say, the key of the payload.
+	DefaultNameTemplate = "$(key)"
+
+	// healthCheckDocument is the name of the document health checks read. It doesn't
+	// need to exist.
+	healthCheckDocument = ".chains-health-check"
 )
 
 // Backend is a storage backend that stores signed payloads in the TaskRun metadata as an annotation.
 // It is stored as base64 encoded JSON.
 type Backend struct {
 	coll *docstore.Collection
+	// nameTemplate is the template of the names documents are keyed by,
+	// DefaultNameTemplate if it is empty.
+	nameTemplate string
 }
 
 type SignedDocument struct {
@@ -43,6 +58,9 @@ type SignedDocument struct {
 	Chain     string
 	Object    interface{}
 	Name      string
+	// BuildGroup is the build group of the run, so that the documents of the
+	// recurring runs of a component can be queried together.
+	BuildGroup string
 }
 
 // NewStorageBackend returns a new Tekton StorageBackend that stores signatures on a TaskRun
@@ -54,29 +72,38 @@ func NewStorageBackend(ctx context.Context, cfg config.Config) (*Backend, error)
 	}
 
 	return &Backend{
-		coll: coll,
+		coll:         coll,
+		nameTemplate: cfg.Storage.DocDB.NameTemplate,
 	}, nil
 }
 
 // StorePayload implements the Payloader interface.
-func (b *Backend) StorePayload(ctx context.Context, _ objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
+func (b *Backend) StorePayload(ctx context.Context, tektonObj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
 	var obj interface{}
 	if err := json.Unmarshal(rawPayload, &obj); err != nil {
 		return err
 	}
+	name, err := naming.Render(b.template(), naming.Values(tektonObj, opts.ShortKey, string(opts.PayloadFormat), rawPayload), nil)
+	if err != nil {
+		return err
+	}
 
 	entry := SignedDocument{
-		Signed:    rawPayload,
-		Signature: base64.StdEncoding.EncodeToString([]byte(signature)),
-		Object:    obj,
-		Name:      opts.ShortKey,
-		Cert:      opts.Cert,
-		Chain:     opts.Chain,
+		Signed:     rawPayload,
+		Signature:  base64.StdEncoding.EncodeToString([]byte(signature)),
+		Object:     obj,
+		Name:       name,
+		Cert:       opts.Cert,
+		Chain:      opts.Chain,
+		BuildGroup: opts.BuildGroup,
 	}
 
 	if err := b.coll.Put(ctx, &entry); err != nil {
 		return err
 	}
+	// Documents are keyed by name. The URL of the collection is left out, as it may
+	// hold credentials.
+	api.RecordLocations(ctx, entry.Name)
 
 	return nil
 }
@@ -85,9 +112,9 @@ func (b *Backend) Type() string {
 	return StorageTypeDocDB
 }
 
-func (b *Backend) RetrieveSignatures(ctx context.Context, _ objects.TektonObject, opts config.StorageOpts) (map[string][]string, error) {
+func (b *Backend) RetrieveSignatures(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string][]string, error) {
 	// Retrieve the document.
-	documents, err := b.retrieveDocuments(ctx, opts)
+	documents, err := b.retrieveDocuments(ctx, obj, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -104,8 +131,8 @@ func (b *Backend) RetrieveSignatures(ctx context.Context, _ objects.TektonObject
 	return m, nil
 }
 
-func (b *Backend) RetrievePayloads(ctx context.Context, _ objects.TektonObject, opts config.StorageOpts) (map[string]string, error) {
-	documents, err := b.retrieveDocuments(ctx, opts)
+func (b *Backend) RetrievePayloads(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string]string, error) {
+	documents, err := b.retrieveDocuments(ctx, obj, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -118,10 +145,34 @@ func (b *Backend) RetrievePayloads(ctx context.Context, _ objects.TektonObject,
 	return m, nil
 }
 
-func (b *Backend) retrieveDocuments(ctx context.Context, opts config.StorageOpts) ([]SignedDocument, error) {
-	d := SignedDocument{Name: opts.ShortKey}
+func (b *Backend) retrieveDocuments(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) ([]SignedDocument, error) {
+	// Names with the subject digest of payloads can't be told without the payloads.
+	if naming.Uses(b.template(), naming.SubjectDigest) {
+		return nil, fmt.Errorf("documents named with %q can't be retrieved without their subject digest", b.template())
+	}
+	name, err := naming.Render(b.template(), naming.Values(obj, opts.ShortKey, string(opts.PayloadFormat), nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	d := SignedDocument{Name: name}
 	if err := b.coll.Get(ctx, &d); err != nil {
 		return []SignedDocument{}, err
 	}
 	return []SignedDocument{d}, nil
 }
+
+// CheckHealth reads a document from the collection, to check that it is reachable.
+func (b *Backend) CheckHealth(ctx context.Context) error {
+	d := SignedDocument{Name: healthCheckDocument}
+	if err := b.coll.Get(ctx, &d); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return fmt.Errorf("error reading collection: %w", err)
+	}
+	return nil
+}
+
+func (b *Backend) template() string {
+	if b.nameTemplate == "" {
+		return DefaultNameTemplate
+	}
+	return b.nameTemplate
+}
@@ -34,11 +34,14 @@ func TestBackend_StorePayload(t *testing.T) {
 		rawPayload interface{}
 		signature  string
 		key        string
+		buildGroup string
+		template   string
 	}
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
+		name     string
+		args     args
+		wantName string
+		wantErr  bool
 	}{
 		{
 			name: "no error",
@@ -56,6 +59,25 @@ func TestBackend_StorePayload(t *testing.T) {
 				key:        "moo",
 			},
 		},
+		{
+			name: "no error - build group",
+			args: args{
+				rawPayload: &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{UID: "bar"}},
+				signature:  "signature",
+				key:        "bar",
+				buildGroup: "widgets-nightly",
+			},
+		},
+		{
+			name: "no error - name template",
+			args: args{
+				rawPayload: &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{UID: "baz", Namespace: "team-a"}},
+				signature:  "signature",
+				key:        "baz",
+				template:   "$(run.namespace)/$(key)",
+			},
+			wantName: "team-a/baz",
+		},
 	}
 
 	memURL := "mem://chains/name"
@@ -70,7 +92,8 @@ func TestBackend_StorePayload(t *testing.T) {
 			ctx := logging.WithLogger(ctx, logtesting.TestLogger(t))
 			// Prepare the document.
 			b := &Backend{
-				coll: coll,
+				coll:         coll,
+				nameTemplate: tt.args.template,
 			}
 			sb, err := json.Marshal(tt.args.rawPayload)
 			if err != nil {
@@ -78,7 +101,7 @@ func TestBackend_StorePayload(t *testing.T) {
 			}
 
 			// Store the document.
-			opts := config.StorageOpts{ShortKey: tt.args.key}
+			opts := config.StorageOpts{ShortKey: tt.args.key, BuildGroup: tt.args.buildGroup}
 			tektonObj, err := objects.NewTektonObject(tt.args.rawPayload)
 			if err != nil {
 				t.Fatal(err)
@@ -86,12 +109,19 @@ func TestBackend_StorePayload(t *testing.T) {
 			if err := b.StorePayload(ctx, tektonObj, sb, tt.args.signature, opts); (err != nil) != tt.wantErr {
 				t.Fatalf("Backend.StorePayload() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			wantName := tt.wantName
+			if wantName == "" {
+				wantName = tt.args.key
+			}
 			obj := SignedDocument{
-				Name: tt.args.key,
+				Name: wantName,
 			}
 			if err := coll.Get(ctx, &obj); err != nil {
 				t.Fatal(err)
 			}
+			if obj.BuildGroup != tt.args.buildGroup {
+				t.Errorf("wrong build group, expected %q, got %q", tt.args.buildGroup, obj.BuildGroup)
+			}
 
 			// Check the signature.
 			signatures, err := b.RetrieveSignatures(ctx, tektonObj, opts)
@@ -117,3 +147,18 @@ func TestBackend_StorePayload(t *testing.T) {
 		})
 	}
 }
+
+func TestBackend_CheckHealth(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	coll, err := docstore.OpenCollection(ctx, "mem://health/name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer coll.Close()
+
+	// The document checks read doesn't need to exist.
+	b := &Backend{coll: coll}
+	if err := b.CheckHealth(ctx); err != nil {
+		t.Errorf("CheckHealth() = %v", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"knative.dev/pkg/logging"
@@ -25,6 +26,7 @@ import (
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/chains/signing"
 	"github.com/tektoncd/chains/pkg/chains/storage/api"
+	"github.com/tektoncd/chains/pkg/chains/storage/naming"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 )
@@ -34,6 +36,13 @@ const (
 	// taskrun-$namespace-$name/$key.<type>
 	SignatureNameFormat = "taskrun-%s-%s/%s.signature"
 	PayloadNameFormat   = "taskrun-%s-%s/%s.payload"
+
+	// DefaultNameTemplate is the template of the names of objects, without their
+	// .signature, .payload, .cert and .chain extensions, when the config doesn't say.
+	DefaultNameTemplate = "taskrun-$(run.namespace)-$(run.name)/$(key)"
+
+	// healthCheckObject is the object health checks write to the bucket.
+	healthCheckObject = ".chains-health-check"
 )
 
 // Backend is a storage backend that stores signed payloads in the TaskRun metadata as an annotation.
@@ -70,10 +79,12 @@ func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, ra
 	}
 
 	store := &TaskRunStorer{
-		writer: b.writer,
-		key:    opts.ShortKey,
+		writer:        b.writer,
+		key:           opts.ShortKey,
+		nameTemplate:  b.cfg.Storage.GCS.NameTemplate,
+		payloadFormat: string(opts.PayloadFormat),
 	}
-	if _, err := store.Store(ctx, &api.StoreRequest[*v1beta1.TaskRun, *in_toto.Statement]{
+	resp, err := store.Store(ctx, &api.StoreRequest[*v1beta1.TaskRun, *in_toto.Statement]{
 		Object:   obj,
 		Artifact: tr,
 		// We don't actually use payload - we store the raw bundle values directly.
@@ -84,10 +95,14 @@ func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, ra
 			Cert:      []byte(opts.Cert),
 			Chain:     []byte(opts.Chain),
 		},
-	}); err != nil {
+	})
+	if err != nil {
 		logger.Errorf("error writing to GCS: %w", err)
 		return err
 	}
+	for _, object := range resp.Locations {
+		api.RecordLocations(ctx, fmt.Sprintf("gs://%s/%s", b.cfg.Storage.GCS.Bucket, object))
+	}
 	return nil
 }
 
@@ -95,6 +110,21 @@ func (b *Backend) Type() string {
 	return StorageBackendGCS
 }
 
+// CheckHealth writes an object to the bucket, to check that it exists and that the
+// controller may write to it.
+func (b *Backend) CheckHealth(ctx context.Context) error {
+	w := b.writer.GetWriter(ctx, healthCheckObject)
+	if _, err := w.Write([]byte(time.Now().UTC().Format(time.RFC3339))); err != nil {
+		w.Close()
+		return fmt.Errorf("error writing to bucket %s: %w", b.cfg.Storage.GCS.Bucket, err)
+	}
+	// Objects are only written once the writer is closed.
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error writing to bucket %s: %w", b.cfg.Storage.GCS.Bucket, err)
+	}
+	return nil
+}
+
 type gcsWriter interface {
 	GetWriter(ctx context.Context, object string) io.WriteCloser
 }
@@ -122,9 +152,10 @@ func (r *reader) GetReader(ctx context.Context, object string) (io.ReadCloser, e
 }
 
 func (b *Backend) RetrieveSignatures(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string][]string, error) {
-	// TODO: Handle unsupported type gracefully
-	tr := obj.GetObject().(*v1beta1.TaskRun)
-	object := sigName(tr, opts)
+	object, err := b.retrievedName(obj, opts, ".signature")
+	if err != nil {
+		return nil, err
+	}
 	signature, err := b.retrieveObject(ctx, object)
 	if err != nil {
 		return nil, err
@@ -136,9 +167,10 @@ func (b *Backend) RetrieveSignatures(ctx context.Context, obj objects.TektonObje
 }
 
 func (b *Backend) RetrievePayloads(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string]string, error) {
-	// TODO: Handle unsupported type gracefully
-	tr := obj.GetObject().(*v1beta1.TaskRun)
-	object := payloadName(tr, opts)
+	object, err := b.retrievedName(obj, opts, ".payload")
+	if err != nil {
+		return nil, err
+	}
 	m := make(map[string]string)
 	payload, err := b.retrieveObject(ctx, object)
 	if err != nil {
@@ -163,12 +195,25 @@ func (b *Backend) retrieveObject(ctx context.Context, object string) (string, er
 	return string(payload), nil
 }
 
-func sigName(tr *v1beta1.TaskRun, opts config.StorageOpts) string {
-	return fmt.Sprintf(SignatureNameFormat, tr.Namespace, tr.Name, opts.ShortKey)
+// retrievedName returns the name of the object with extension ext of the payload of obj.
+// Names with the subject digest of payloads can't be told without the payloads.
+func (b *Backend) retrievedName(obj objects.TektonObject, opts config.StorageOpts, ext string) (string, error) {
+	template := nameTemplate(b.cfg.Storage.GCS.NameTemplate)
+	if naming.Uses(template, naming.SubjectDigest) {
+		return "", fmt.Errorf("payloads named with %q can't be retrieved without their subject digest", template)
+	}
+	prefix, err := naming.Render(template, naming.Values(obj, opts.ShortKey, string(opts.PayloadFormat), nil), nil)
+	if err != nil {
+		return "", err
+	}
+	return prefix + ext, nil
 }
 
-func payloadName(tr *v1beta1.TaskRun, opts config.StorageOpts) string {
-	return fmt.Sprintf(PayloadNameFormat, tr.Namespace, tr.Name, opts.ShortKey)
+func nameTemplate(template string) string {
+	if template == "" {
+		return DefaultNameTemplate
+	}
+	return template
 }
 
 var (
@@ -183,6 +228,10 @@ type TaskRunStorer struct {
 	// Optional key to store objects as. If not set, the object UID will be used.
 	// The resulting name will look like: $bucket/taskrun-$namespace-$name/$key.signature
 	key string
+	// nameTemplate is the template of the names of the objects, DefaultNameTemplate if
+	// it is empty.
+	nameTemplate  string
+	payloadFormat string
 }
 
 // Store stores the
@@ -191,14 +240,21 @@ func (s *TaskRunStorer) Store(ctx context.Context, req *api.StoreRequest[*v1beta
 
 	tr := req.Artifact
 	// We need multiple objects: the signature and the payload. We want to make these unique to the UID, but easy to find based on the
-	// name/namespace as well.
+	// name/namespace as well, with the default template.
 	// $bucket/taskrun-$namespace-$name/$key.signature
 	// $bucket/taskrun-$namespace-$name/$key.payload
 	key := s.key
 	if key == "" {
 		key = string(tr.GetUID())
 	}
-	prefix := fmt.Sprintf("taskrun-%s-%s/%s", tr.GetNamespace(), tr.GetName(), key)
+	obj := req.Object
+	if obj == nil {
+		obj = objects.NewTaskRunObject(tr)
+	}
+	prefix, err := naming.Render(nameTemplate(s.nameTemplate), naming.Values(obj, key, s.payloadFormat, req.Bundle.Content), nil)
+	if err != nil {
+		return nil, err
+	}
 
 	// Write signature
 	sigName := prefix + ".signature"
@@ -208,13 +264,15 @@ func (s *TaskRunStorer) Store(ctx context.Context, req *api.StoreRequest[*v1beta
 	}
 
 	// Write payload
-	if _, err := write(ctx, s.writer, prefix+".payload", req.Bundle.Content); err != nil {
+	payloadName := prefix + ".payload"
+	if _, err := write(ctx, s.writer, payloadName, req.Bundle.Content); err != nil {
 		return nil, err
 	}
+	resp := &api.StoreResponse{Locations: []string{payloadName, sigName}}
 
 	// Only write cert+chain if it is present.
 	if req.Bundle.Cert == nil {
-		return nil, nil
+		return resp, nil
 	}
 	if _, err := write(ctx, s.writer, prefix+".cert", req.Bundle.Cert); err != nil {
 		return nil, err
@@ -223,7 +281,7 @@ func (s *TaskRunStorer) Store(ctx context.Context, req *api.StoreRequest[*v1beta
 		return nil, err
 	}
 
-	return &api.StoreResponse{}, nil
+	return resp, nil
 }
 
 func write(ctx context.Context, client gcsWriter, name string, content []byte) (int, error) {
@@ -16,11 +16,16 @@ package gcs
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/tektoncd/chains/pkg/chains/formats"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage/api"
 
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
@@ -84,12 +89,18 @@ func TestBackend_StorePayload(t *testing.T) {
 				cfg:    config.Config{Storage: config.StorageConfigs{GCS: config.GCSStorageConfig{Bucket: "foo"}}},
 			}
 			trObj := objects.NewTaskRunObject(tt.args.tr)
-			if err := b.StorePayload(ctx, trObj, tt.args.signed, tt.args.signature, tt.args.opts); (err != nil) != tt.wantErr {
+			lctx, locations := api.WithLocations(ctx)
+			if err := b.StorePayload(lctx, trObj, tt.args.signed, tt.args.signature, tt.args.opts); (err != nil) != tt.wantErr {
 				t.Errorf("Backend.StorePayload() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
-			objectSig := sigName(tt.args.tr, tt.args.opts)
-			objectPayload := payloadName(tt.args.tr, tt.args.opts)
+			// The default template names objects like before templates.
+			objectSig := fmt.Sprintf(SignatureNameFormat, tt.args.tr.Namespace, tt.args.tr.Name, tt.args.opts.ShortKey)
+			objectPayload := fmt.Sprintf(PayloadNameFormat, tt.args.tr.Namespace, tt.args.tr.Name, tt.args.opts.ShortKey)
+			wantLocations := []string{"gs://foo/" + objectPayload, "gs://foo/" + objectSig}
+			if diff := cmp.Diff(wantLocations, locations()); diff != "" {
+				t.Errorf("locations diff (-want +got): %s", diff)
+			}
 			got, err := b.RetrieveSignatures(ctx, trObj, tt.args.opts)
 			if err != nil {
 				t.Fatal(err)
@@ -109,6 +120,74 @@ func TestBackend_StorePayload(t *testing.T) {
 	}
 }
 
+func TestBackend_StorePayload_NameTemplate(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "team-a",
+			Name:              "bar",
+			UID:               types.UID("uid"),
+			Labels:            map[string]string{"tekton.dev/pipeline": "release"},
+			CreationTimestamp: metav1.Date(2023, 9, 4, 23, 0, 0, 0, time.UTC),
+		},
+	}
+	opts := config.StorageOpts{ShortKey: "taskrun-uid", PayloadFormat: formats.PayloadTypeSlsav1}
+	statement := []byte(`{"subject":[{"name":"registry/image","digest":{"sha256":"abc"}}]}`)
+
+	tests := []struct {
+		name         string
+		template     string
+		wantPayload  string
+		retrieveErrs bool
+	}{{
+		name:        "namespace, pipeline and date",
+		template:    "$(run.namespace)/$(pipeline.name)/$(year)/$(month)/$(day)/$(key)",
+		wantPayload: "team-a/release/2023/09/04/taskrun-uid.payload",
+	}, {
+		name:         "subject digest",
+		template:     "$(run.namespace)/$(subject.digest)/$(key)",
+		wantPayload:  "team-a/sha256:abc/taskrun-uid.payload",
+		retrieveErrs: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGcsWrite := &mockGcsWriter{objects: map[string]*bytes.Buffer{}}
+			b := &Backend{
+				writer: mockGcsWrite,
+				reader: &mockGcsReader{objects: mockGcsWrite.objects},
+				cfg:    config.Config{Storage: config.StorageConfigs{GCS: config.GCSStorageConfig{Bucket: "foo", NameTemplate: tt.template}}},
+			}
+			obj := objects.NewTaskRunObject(tr)
+			if err := b.StorePayload(ctx, obj, statement, "signature", opts); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := mockGcsWrite.objects[tt.wantPayload]; !ok {
+				t.Errorf("expected object %s, got %v", tt.wantPayload, mockGcsWrite.objects)
+			}
+			got, err := b.RetrievePayloads(ctx, obj, opts)
+			if tt.retrieveErrs {
+				if err == nil {
+					t.Error("expected an error retrieving payloads named with their subject digest")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got[tt.wantPayload] != string(statement) {
+				t.Errorf("wrong payload, expected %s, got %v", statement, got)
+			}
+			sigs, err := b.RetrieveSignatures(ctx, obj, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sig := strings.TrimSuffix(tt.wantPayload, ".payload") + ".signature"; sigs[sig][0] != "signature" {
+				t.Errorf("wrong signatures, expected %s, got %v", sig, sigs)
+			}
+		})
+	}
+}
+
 type mockGcsWriter struct {
 	objects map[string]*bytes.Buffer
 }
@@ -145,3 +224,14 @@ func (rc *ReaderCloser) Close() error {
 	// Noop
 	return nil
 }
+
+func TestBackend_CheckHealth(t *testing.T) {
+	mockGcsWrite := &mockGcsWriter{objects: map[string]*bytes.Buffer{}}
+	b := &Backend{writer: mockGcsWrite}
+	if err := b.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("CheckHealth() = %v", err)
+	}
+	if _, ok := mockGcsWrite.objects[healthCheckObject]; !ok {
+		t.Errorf("expected object %s, got %v", healthCheckObject, mockGcsWrite.objects)
+	}
+}
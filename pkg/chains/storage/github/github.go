@@ -0,0 +1,452 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/patch"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	StorageBackendGitHub = "github"
+	// AttestationAnnotationFormat is the annotation that records the attestation a payload was uploaded as.
+	AttestationAnnotationFormat = "chains.tekton.dev/github-attestation-%s"
+
+	// BundleMediaType is the media type of the Sigstore bundles that are uploaded.
+	BundleMediaType = "application/vnd.dev.sigstore.bundle+json;version=0.2"
+
+	defaultURL = "https://api.github.com"
+	apiVersion = "2022-11-28"
+	// tokenExpiryMargin is how long before it expires an installation token is renewed.
+	tokenExpiryMargin = 5 * time.Minute
+)
+
+// Bundle is the Sigstore bundle uploaded for every signed payload.
+type Bundle struct {
+	MediaType            string               `json:"mediaType"`
+	VerificationMaterial VerificationMaterial `json:"verificationMaterial"`
+	DSSEEnvelope         json.RawMessage      `json:"dsseEnvelope"`
+}
+
+// VerificationMaterial holds the certificate chain or the hint of the key a payload was signed with.
+type VerificationMaterial struct {
+	X509CertificateChain *CertificateChain `json:"x509CertificateChain,omitempty"`
+	PublicKey            *PublicKey        `json:"publicKey,omitempty"`
+}
+
+type CertificateChain struct {
+	Certificates []Certificate `json:"certificates"`
+}
+
+type Certificate struct {
+	// RawBytes is the base64 encoded DER of the certificate.
+	RawBytes string `json:"rawBytes"`
+}
+
+type PublicKey struct {
+	Hint string `json:"hint,omitempty"`
+}
+
+// record is the content of the attestation annotation.
+type record struct {
+	ID      int64  `json:"id"`
+	Subject string `json:"subject"`
+	// Payload is the hex encoded sha256 of the payload, used to find the
+	// attestation among the ones GitHub returns for the subject.
+	Payload string `json:"payload"`
+}
+
+// envelope is the subset of a DSSE envelope the backend reads.
+type envelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		KeyID string `json:"keyid"`
+	} `json:"signatures"`
+}
+
+// statement is the subset of an in-toto statement the backend reads.
+type statement struct {
+	Subject []struct {
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+}
+
+// Backend is a storage backend that uploads signed payloads to the artifact
+// attestations API of a GitHub repository, authenticated as a GitHub App installation.
+type Backend struct {
+	client            *http.Client
+	url               string
+	repository        string
+	appID             int64
+	installationID    int64
+	key               *rsa.PrivateKey
+	pipelineclientset versioned.Interface
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewStorageBackend returns a new GitHub StorageBackend for the repository and GitHub App configured in cfg.
+func NewStorageBackend(ps versioned.Interface, cfg config.Config) (*Backend, error) {
+	c := cfg.Storage.GitHub
+	if len(strings.Split(c.Repository, "/")) != 2 {
+		return nil, errors.New("storage.github.repository must be configured as owner/name to use the github storage backend")
+	}
+	if c.AppID == 0 || c.InstallationID == 0 || c.PrivateKeyPath == "" {
+		return nil, errors.New("storage.github.app-id, storage.github.installation-id and storage.github.private-key-path must be configured to use the github storage backend")
+	}
+	key, err := loadKey(c.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading GitHub App private key: %w", err)
+	}
+	url := c.URL
+	if url == "" {
+		url = defaultURL
+	}
+	return &Backend{
+		client:            http.DefaultClient,
+		url:               strings.TrimSuffix(url, "/"),
+		repository:        c.Repository,
+		appID:             c.AppID,
+		installationID:    c.InstallationID,
+		key:               key,
+		pipelineclientset: ps,
+		now:               time.Now,
+	}, nil
+}
+
+func (b *Backend) Type() string {
+	return StorageBackendGitHub
+}
+
+// StorePayload implements the storage.Backend interface. Only in-toto statements
+// signed in a DSSE envelope can be uploaded; other payloads are skipped.
+func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
+	logger := logging.FromContext(ctx)
+
+	var env envelope
+	if err := json.Unmarshal([]byte(signature), &env); err != nil || env.PayloadType == "" {
+		logger.Infof("Not uploading payload for %s %s/%s to GitHub: signature is not a DSSE envelope", obj.GetGVK(), obj.GetNamespace(), obj.GetName())
+		return nil
+	}
+	subject := firstSubject(rawPayload)
+	if subject == "" {
+		logger.Infof("Not uploading payload for %s %s/%s to GitHub: it has no sha256 subject", obj.GetGVK(), obj.GetNamespace(), obj.GetName())
+		return nil
+	}
+
+	material, err := verificationMaterial(env, opts)
+	if err != nil {
+		return err
+	}
+	bundle := Bundle{
+		MediaType:            BundleMediaType,
+		VerificationMaterial: material,
+		DSSEEnvelope:         json.RawMessage(signature),
+	}
+	body, err := json.Marshal(map[string]Bundle{"bundle": bundle})
+	if err != nil {
+		return err
+	}
+	resp, err := b.do(ctx, http.MethodPost, "/repos/"+b.repository+"/attestations", body, http.StatusCreated)
+	if err != nil {
+		return fmt.Errorf("uploading attestation to GitHub: %w", err)
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(resp, &created); err != nil {
+		return err
+	}
+	logger.Infof("Uploaded attestation for %s %s/%s to GitHub repository %s with ID %d", obj.GetGVK(), obj.GetNamespace(), obj.GetName(), b.repository, created.ID)
+
+	sum := sha256.Sum256(rawPayload)
+	rec, err := json.Marshal(record{ID: created.ID, Subject: subject, Payload: hex.EncodeToString(sum[:])})
+	if err != nil {
+		return err
+	}
+	patchBytes, err := patch.GetAnnotationsPatch(map[string]string{
+		annotationName(opts): string(rec),
+	})
+	if err != nil {
+		return err
+	}
+	return obj.Patch(ctx, b.pipelineclientset, patchBytes)
+}
+
+// RetrievePayloads fetches the attestation referenced by the annotation of obj and returns its payload.
+func (b *Backend) RetrievePayloads(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string]string, error) {
+	key, env, err := b.retrieveEnvelope(ctx, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{key: string(payload)}, nil
+}
+
+// RetrieveSignatures fetches the attestation referenced by the annotation of obj and returns its DSSE envelope.
+func (b *Backend) RetrieveSignatures(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string][]string, error) {
+	key, env, err := b.retrieveEnvelope(ctx, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]string{key: {string(raw)}}, nil
+}
+
+// retrievedEnvelope is a DSSE envelope as returned by GitHub, kept whole so that
+// its signatures are returned unchanged.
+type retrievedEnvelope struct {
+	PayloadType string            `json:"payloadType"`
+	Payload     string            `json:"payload"`
+	Signatures  []json.RawMessage `json:"signatures"`
+}
+
+func (b *Backend) retrieveEnvelope(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (string, *retrievedEnvelope, error) {
+	annotations, err := obj.GetLatestAnnotations(ctx, b.pipelineclientset)
+	if err != nil {
+		return "", nil, err
+	}
+	raw, ok := annotations[annotationName(opts)]
+	if !ok {
+		return "", nil, fmt.Errorf("no GitHub attestation recorded for %s/%s", obj.GetNamespace(), obj.GetName())
+	}
+	var rec record
+	if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+		return "", nil, fmt.Errorf("invalid GitHub attestation annotation: %w", err)
+	}
+
+	resp, err := b.do(ctx, http.MethodGet, "/repos/"+b.repository+"/attestations/"+rec.Subject, nil, http.StatusOK)
+	if err != nil {
+		return "", nil, fmt.Errorf("fetching attestations of %s from GitHub: %w", rec.Subject, err)
+	}
+	var list struct {
+		Attestations []struct {
+			Bundle struct {
+				DSSEEnvelope retrievedEnvelope `json:"dsseEnvelope"`
+			} `json:"bundle"`
+		} `json:"attestations"`
+	}
+	if err := json.Unmarshal(resp, &list); err != nil {
+		return "", nil, err
+	}
+	for _, a := range list.Attestations {
+		env := a.Bundle.DSSEEnvelope
+		payload, err := base64.StdEncoding.DecodeString(env.Payload)
+		if err != nil {
+			continue
+		}
+		if sum := sha256.Sum256(payload); hex.EncodeToString(sum[:]) == rec.Payload {
+			return b.repository + "#" + strconv.FormatInt(rec.ID, 10), &env, nil
+		}
+	}
+	return "", nil, fmt.Errorf("attestation %d not found among the attestations of %s in %s", rec.ID, rec.Subject, b.repository)
+}
+
+// do issues an authenticated request against the REST API and returns the response
+// body if it has the expected status.
+func (b *Backend) do(ctx context.Context, method, path string, body []byte, want int) ([]byte, error) {
+	token, err := b.installationToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.request(ctx, method, path, body, "token "+token, want)
+}
+
+func (b *Backend) request(ctx context.Context, method, path string, body []byte, authorization string, want int) ([]byte, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.url+path, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	req.Header.Set("Authorization", authorization)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != want {
+		return nil, fmt.Errorf("unexpected status %d from %s %s: %s", resp.StatusCode, method, path, strings.TrimSpace(string(content)))
+	}
+	return content, nil
+}
+
+// installationToken returns a token of the GitHub App installation, creating a new
+// one when the cached token is about to expire.
+func (b *Backend) installationToken(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if b.token != "" && now.Add(tokenExpiryMargin).Before(b.expires) {
+		return b.token, nil
+	}
+	jwt, err := b.appJWT(now)
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("/app/installations/%d/access_tokens", b.installationID)
+	resp, err := b.request(ctx, http.MethodPost, path, nil, "Bearer "+jwt, http.StatusCreated)
+	if err != nil {
+		return "", fmt.Errorf("creating GitHub App installation token: %w", err)
+	}
+	var t struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(resp, &t); err != nil {
+		return "", err
+	}
+	if t.Token == "" {
+		return "", errors.New("no installation token returned from GitHub")
+	}
+	b.token, b.expires = t.Token, t.ExpiresAt
+	return b.token, nil
+}
+
+// appJWT returns the RS256 signed JWT that authenticates as the GitHub App.
+// GitHub accepts JWTs valid for at most 10 minutes; the issue time is set in the
+// past to allow for clock drift.
+func (b *Backend) appJWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(b.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, b.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// verificationMaterial returns the certificate chain the payload was signed with,
+// or the key ID of the signature if it was signed with a key.
+func verificationMaterial(env envelope, opts config.StorageOpts) (VerificationMaterial, error) {
+	if opts.Cert == "" {
+		var hint string
+		if len(env.Signatures) > 0 {
+			hint = env.Signatures[0].KeyID
+		}
+		return VerificationMaterial{PublicKey: &PublicKey{Hint: hint}}, nil
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(opts.Cert + "\n" + opts.Chain))
+	if err != nil {
+		return VerificationMaterial{}, fmt.Errorf("parsing signing certificate: %w", err)
+	}
+	chain := &CertificateChain{}
+	for _, c := range certs {
+		chain.Certificates = append(chain.Certificates, Certificate{RawBytes: base64.StdEncoding.EncodeToString(c.Raw)})
+	}
+	return VerificationMaterial{X509CertificateChain: chain}, nil
+}
+
+// firstSubject returns the sha256 digest of the first subject of the in-toto
+// statement in payload, as sha256:<hex>.
+func firstSubject(payload []byte) string {
+	var st statement
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return ""
+	}
+	for _, s := range st.Subject {
+		if d := s.Digest["sha256"]; d != "" {
+			return "sha256:" + d
+		}
+	}
+	return ""
+}
+
+func loadKey(path string) (*rsa.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+func annotationName(opts config.StorageOpts) string {
+	return fmt.Sprintf(AttestationAnnotationFormat, opts.ShortKey)
+}
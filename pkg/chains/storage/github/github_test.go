@@ -0,0 +1,309 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+const (
+	repository     = "acme/widgets"
+	installationID = 42
+	statementJSON  = `{"_type":"https://in-toto.io/Statement/v0.1","subject":[{"name":"gcr.io/foo/bar","digest":{"sha256":"abc"}}]}`
+)
+
+// fakeGitHub emulates the subset of the GitHub REST API used by the backend.
+type fakeGitHub struct {
+	mu           sync.Mutex
+	key          *rsa.PublicKey
+	tokens       int
+	attestations map[string][]json.RawMessage
+}
+
+func (g *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if r.Header.Get("X-GitHub-Api-Version") != apiVersion {
+		http.Error(w, "missing API version", http.StatusBadRequest)
+		return
+	}
+	if r.URL.Path == fmt.Sprintf("/app/installations/%d/access_tokens", installationID) {
+		if r.Method != http.MethodPost || !g.validJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		g.tokens++
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      fmt.Sprintf("token-%d", g.tokens),
+			"expires_at": time.Now().Add(time.Hour),
+		})
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "token token-") {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+		return
+	}
+	prefix := "/repos/" + repository + "/attestations"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == prefix:
+		var req struct {
+			Bundle struct {
+				MediaType    string `json:"mediaType"`
+				DSSEEnvelope struct {
+					Payload string `json:"payload"`
+				} `json:"dsseEnvelope"`
+			} `json:"bundle"`
+		}
+		raw := json.RawMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(raw, &req); err != nil || req.Bundle.MediaType != BundleMediaType {
+			http.Error(w, "invalid bundle", http.StatusUnprocessableEntity)
+			return
+		}
+		payload, _ := base64.StdEncoding.DecodeString(req.Bundle.DSSEEnvelope.Payload)
+		subject := firstSubject(payload)
+		g.attestations[subject] = append(g.attestations[subject], raw)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": len(g.attestations[subject])})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, prefix+"/"):
+		list := g.attestations[strings.TrimPrefix(r.URL.Path, prefix+"/")]
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"attestations": list})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (g *fakeGitHub) validJWT(jwt string) bool {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(g.key, crypto.SHA256, digest[:], sig) != nil {
+		return false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+		Exp int64  `json:"exp"`
+	}
+	return json.Unmarshal(raw, &claims) == nil && claims.Iss == "7" && claims.Exp > time.Now().Unix()
+}
+
+// writeKey writes a new GitHub App private key to a temporary file.
+func writeKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return key, path
+}
+
+func newConfig(url, keyPath string) config.Config {
+	return config.Config{
+		Storage: config.StorageConfigs{
+			GitHub: config.GitHubStorageConfig{
+				URL:            url,
+				Repository:     repository,
+				AppID:          7,
+				InstallationID: installationID,
+				PrivateKeyPath: keyPath,
+			},
+		},
+	}
+}
+
+func TestBackend_StorePayload(t *testing.T) {
+	key, keyPath := writeKey(t)
+	envelope := fmt.Sprintf(`{"payloadType":"application/vnd.in-toto+json","payload":%q,"signatures":[{"keyid":"SHA256:key","sig":"c2ln"}]}`,
+		base64.StdEncoding.EncodeToString([]byte(statementJSON)))
+
+	for _, obj := range []objects.TektonObject{
+		objects.NewTaskRunObject(&v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"},
+		}),
+		objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"},
+		}),
+	} {
+		t.Run(obj.GetKindName(), func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			tekton.CreateObject(t, ctx, ps, obj)
+
+			gh := &fakeGitHub{key: &key.PublicKey, attestations: map[string][]json.RawMessage{}}
+			server := httptest.NewServer(gh)
+			defer server.Close()
+
+			b, err := NewStorageBackend(ps, newConfig(server.URL+"/", keyPath))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := config.StorageOpts{ShortKey: "taskrun-uid"}
+			if err := b.StorePayload(ctx, obj, []byte(statementJSON), envelope, opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(gh.attestations["sha256:abc"]); got != 1 {
+				t.Fatalf("expected 1 attestation uploaded for the subject, got %d", got)
+			}
+
+			updated, err := tekton.GetObject(t, ctx, ps, obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := updated.GetAnnotations()[fmt.Sprintf(AttestationAnnotationFormat, opts.ShortKey)]; !ok {
+				t.Fatalf("expected attestation annotation, got %v", updated.GetAnnotations())
+			}
+
+			gotPayloads, err := b.RetrievePayloads(ctx, obj, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(map[string]string{repository + "#1": statementJSON}, gotPayloads); diff != "" {
+				t.Errorf("RetrievePayloads() -want +got: %s", diff)
+			}
+
+			gotSignatures, err := b.RetrieveSignatures(ctx, obj, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(gotSignatures[repository+"#1"][0]), &got); err != nil {
+				t.Fatal(err)
+			}
+			var want map[string]interface{}
+			if err := json.Unmarshal([]byte(envelope), &want); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("RetrieveSignatures() -want +got: %s", diff)
+			}
+
+			if gh.tokens != 1 {
+				t.Errorf("expected the installation token to be reused, %d were created", gh.tokens)
+			}
+		})
+	}
+}
+
+func TestBackend_StorePayload_Skipped(t *testing.T) {
+	key, keyPath := writeKey(t)
+	tests := []struct {
+		name      string
+		payload   string
+		signature string
+	}{{
+		name:      "raw signature",
+		payload:   statementJSON,
+		signature: "c2ln",
+	}, {
+		name:      "no subjects",
+		payload:   `{"_type":"https://in-toto.io/Statement/v0.1","subject":[]}`,
+		signature: `{"payloadType":"application/vnd.in-toto+json","payload":"e30=","signatures":[]}`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"},
+			})
+			tekton.CreateObject(t, ctx, ps, obj)
+
+			gh := &fakeGitHub{key: &key.PublicKey, attestations: map[string][]json.RawMessage{}}
+			server := httptest.NewServer(gh)
+			defer server.Close()
+
+			b, err := NewStorageBackend(ps, newConfig(server.URL, keyPath))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := b.StorePayload(ctx, obj, []byte(tt.payload), tt.signature, config.StorageOpts{ShortKey: "taskrun-uid"}); err != nil {
+				t.Fatal(err)
+			}
+			if len(gh.attestations) != 0 || gh.tokens != 0 {
+				t.Errorf("expected no requests to GitHub, got %d attestations and %d tokens", len(gh.attestations), gh.tokens)
+			}
+		})
+	}
+}
+
+func TestNewStorageBackend_MissingConfig(t *testing.T) {
+	_, keyPath := writeKey(t)
+	tests := []struct {
+		name   string
+		modify func(*config.GitHubStorageConfig)
+	}{
+		{name: "repository", modify: func(c *config.GitHubStorageConfig) { c.Repository = "" }},
+		{name: "repository without owner", modify: func(c *config.GitHubStorageConfig) { c.Repository = "widgets" }},
+		{name: "app id", modify: func(c *config.GitHubStorageConfig) { c.AppID = 0 }},
+		{name: "installation id", modify: func(c *config.GitHubStorageConfig) { c.InstallationID = 0 }},
+		{name: "private key", modify: func(c *config.GitHubStorageConfig) { c.PrivateKeyPath = filepath.Join(t.TempDir(), "missing.pem") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			cfg := newConfig("", keyPath)
+			tt.modify(&cfg.Storage.GitHub)
+			if _, err := NewStorageBackend(fakepipelineclient.Get(ctx), cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gitlab implements a storage backend uploading signed payloads to the generic
+// package registry of a GitLab project. It doesn't publish dependency or security
+// reports, which GitLab only ingests as report artifacts of its CI jobs.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	StorageBackendGitLab = "gitlab"
+
+	defaultURL     = "https://gitlab.com"
+	defaultPackage = "tekton-chains"
+)
+
+// Document is the content that is uploaded to the package registry for every signed payload.
+type Document struct {
+	Payload   []byte `json:"payload"`
+	Signature string `json:"signature"`
+	Cert      string `json:"cert,omitempty"`
+	Chain     string `json:"chain,omitempty"`
+}
+
+// Backend is a storage backend that uploads signed payloads to the generic package
+// registry of a GitLab project. The payloads of a run are uploaded as files of the
+// package version named after the UID of the run.
+type Backend struct {
+	client  *http.Client
+	url     string
+	project string
+	pkg     string
+	token   string
+}
+
+// NewStorageBackend returns a new GitLab StorageBackend for the project configured in cfg.
+func NewStorageBackend(cfg config.Config) (*Backend, error) {
+	c := cfg.Storage.GitLab
+	if c.Project == "" || c.TokenPath == "" {
+		return nil, errors.New("storage.gitlab.project and storage.gitlab.token-path must be configured to use the gitlab storage backend")
+	}
+	token, err := os.ReadFile(c.TokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading GitLab token: %w", err)
+	}
+	b := &Backend{
+		client:  http.DefaultClient,
+		url:     strings.TrimSuffix(c.URL, "/"),
+		project: c.Project,
+		pkg:     c.Package,
+		token:   strings.TrimSpace(string(token)),
+	}
+	if b.url == "" {
+		b.url = defaultURL
+	}
+	if b.pkg == "" {
+		b.pkg = defaultPackage
+	}
+	return b, nil
+}
+
+func (b *Backend) Type() string {
+	return StorageBackendGitLab
+}
+
+// StorePayload implements the storage.Backend interface.
+func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
+	logger := logging.FromContext(ctx)
+
+	doc, err := json.Marshal(Document{
+		Payload:   rawPayload,
+		Signature: signature,
+		Cert:      opts.Cert,
+		Chain:     opts.Chain,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := b.do(ctx, http.MethodPut, b.filePath(obj, opts), doc, http.StatusCreated); err != nil {
+		return fmt.Errorf("uploading payload to GitLab: %w", err)
+	}
+	logger.Infof("Uploaded payload for %s %s/%s to GitLab package %s", obj.GetGVK(), obj.GetNamespace(), obj.GetName(), b.fileName(obj, opts))
+	return nil
+}
+
+// RetrievePayloads downloads the package file of obj and returns its payload.
+func (b *Backend) RetrievePayloads(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string]string, error) {
+	doc, err := b.retrieveDocument(ctx, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{b.fileName(obj, opts): string(doc.Payload)}, nil
+}
+
+// RetrieveSignatures downloads the package file of obj and returns its signature.
+func (b *Backend) RetrieveSignatures(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string][]string, error) {
+	doc, err := b.retrieveDocument(ctx, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]string{b.fileName(obj, opts): {doc.Signature}}, nil
+}
+
+func (b *Backend) retrieveDocument(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (*Document, error) {
+	content, err := b.do(ctx, http.MethodGet, b.filePath(obj, opts), nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s from GitLab: %w", b.fileName(obj, opts), err)
+	}
+	doc := &Document{}
+	if err := json.Unmarshal(content, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// fileName returns the package, version and file name of the payload of obj.
+func (b *Backend) fileName(obj objects.TektonObject, opts config.StorageOpts) string {
+	return fmt.Sprintf("%s/%s/%s.json", b.pkg, obj.GetUID(), opts.ShortKey)
+}
+
+// filePath returns the path of the package file of the payload of obj in the
+// generic packages API.
+func (b *Backend) filePath(obj objects.TektonObject, opts config.StorageOpts) string {
+	return fmt.Sprintf("/api/v4/projects/%s/packages/generic/%s", url.PathEscape(b.project), b.fileName(obj, opts))
+}
+
+func (b *Backend) do(ctx context.Context, method, path string, body []byte, want int) ([]byte, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.url+path, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", b.token)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != want {
+		return nil, fmt.Errorf("unexpected status %d from %s %s: %s", resp.StatusCode, method, path, strings.TrimSpace(string(content)))
+	}
+	return content, nil
+}
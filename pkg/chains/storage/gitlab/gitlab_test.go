@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlab
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const packagesPrefix = "/api/v4/projects/acme%2Fwidgets/packages/generic/"
+
+// fakeRegistry emulates the generic packages API of a GitLab project.
+type fakeRegistry struct {
+	mu    sync.Mutex
+	token string
+	files map[string][]byte
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("PRIVATE-TOKEN") != f.token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, packagesPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	name := strings.TrimPrefix(path, packagesPrefix)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.files[name] = content
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		content, ok := f.files[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeToken(t *testing.T, token string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBackend_StorePayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		registryToken string
+		pkg           string
+		wantFile      string
+		wantErr       bool
+	}{{
+		name:          "default package",
+		token:         "secret",
+		registryToken: "secret",
+		wantFile:      "tekton-chains/uid/taskrun-uid.json",
+	}, {
+		name:          "custom package",
+		token:         "secret",
+		registryToken: "secret",
+		pkg:           "provenance",
+		wantFile:      "provenance/uid/taskrun-uid.json",
+	}, {
+		name:          "wrong token",
+		token:         "wrong",
+		registryToken: "secret",
+		wantErr:       true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := &fakeRegistry{token: tt.registryToken, files: map[string][]byte{}}
+			server := httptest.NewServer(registry)
+			defer server.Close()
+
+			b, err := NewStorageBackend(config.Config{
+				Storage: config.StorageConfigs{
+					GitLab: config.GitLabStorageConfig{
+						URL:       server.URL + "/",
+						Project:   "acme/widgets",
+						TokenPath: writeToken(t, tt.token),
+						Package:   tt.pkg,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"},
+			})
+			payload := []byte(`{"foo":"bar"}`)
+			opts := config.StorageOpts{ShortKey: "taskrun-uid", Cert: "cert", Chain: "chain"}
+			if err := b.StorePayload(context.Background(), obj, payload, "signature", opts); (err != nil) != tt.wantErr {
+				t.Fatalf("StorePayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, ok := registry.files[tt.wantFile]; !ok {
+				t.Fatalf("expected %s to be uploaded, got %v", tt.wantFile, registry.files)
+			}
+
+			gotPayloads, err := b.RetrievePayloads(context.Background(), obj, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(map[string]string{tt.wantFile: string(payload)}, gotPayloads); diff != "" {
+				t.Errorf("RetrievePayloads() -want +got: %s", diff)
+			}
+
+			gotSignatures, err := b.RetrieveSignatures(context.Background(), obj, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(map[string][]string{tt.wantFile: {"signature"}}, gotSignatures); diff != "" {
+				t.Errorf("RetrieveSignatures() -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewStorageBackend_MissingConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.GitLabStorageConfig
+	}{
+		{name: "project", cfg: config.GitLabStorageConfig{TokenPath: writeToken(t, "secret")}},
+		{name: "token path", cfg: config.GitLabStorageConfig{Project: "acme/widgets"}},
+		{name: "missing token", cfg: config.GitLabStorageConfig{Project: "acme/widgets", TokenPath: filepath.Join(t.TempDir(), "missing")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStorageBackend(config.Config{Storage: config.StorageConfigs{GitLab: tt.cfg}}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
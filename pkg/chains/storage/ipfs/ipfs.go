@@ -0,0 +1,232 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/patch"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	StorageBackendIPFS = "ipfs"
+	// CIDAnnotationFormat is the annotation that records the CID a payload was pinned as.
+	CIDAnnotationFormat = "chains.tekton.dev/ipfs-cid-%s"
+
+	addPath = "/api/v0/add"
+	catPath = "/api/v0/cat"
+)
+
+// Document is the content that is pinned to IPFS for every signed payload.
+type Document struct {
+	Payload   []byte `json:"payload"`
+	Signature string `json:"signature"`
+	Cert      string `json:"cert,omitempty"`
+	Chain     string `json:"chain,omitempty"`
+}
+
+// addResponse is the relevant subset of the response returned by the add endpoint.
+type addResponse struct {
+	Name string `json:"Name"`
+	Hash string `json:"Hash"`
+	Size string `json:"Size"`
+}
+
+// Backend is a storage backend that pins signed payloads to IPFS and records
+// the resulting CID as an annotation on the Tekton object.
+type Backend struct {
+	client            *http.Client
+	url               string
+	token             string
+	pipelineclientset versioned.Interface
+}
+
+// NewStorageBackend returns a new IPFS StorageBackend that talks to the node configured in cfg.
+func NewStorageBackend(ps versioned.Interface, cfg config.Config) (*Backend, error) {
+	if cfg.Storage.IPFS.URL == "" {
+		return nil, errors.New("storage.ipfs.url must be configured to use the ipfs storage backend")
+	}
+	var token []byte
+	if p := cfg.Storage.IPFS.TokenPath; p != "" {
+		var err error
+		if token, err = os.ReadFile(p); err != nil {
+			return nil, fmt.Errorf("reading IPFS token: %w", err)
+		}
+	}
+	return &Backend{
+		client:            http.DefaultClient,
+		url:               strings.TrimSuffix(cfg.Storage.IPFS.URL, "/"),
+		token:             strings.TrimSpace(string(token)),
+		pipelineclientset: ps,
+	}, nil
+}
+
+func (b *Backend) Type() string {
+	return StorageBackendIPFS
+}
+
+// StorePayload implements the storage.Backend interface.
+func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
+	logger := logging.FromContext(ctx)
+
+	doc, err := json.Marshal(Document{
+		Payload:   rawPayload,
+		Signature: signature,
+		Cert:      opts.Cert,
+		Chain:     opts.Chain,
+	})
+	if err != nil {
+		return err
+	}
+
+	cid, err := b.add(ctx, opts.ShortKey+".json", doc)
+	if err != nil {
+		return fmt.Errorf("pinning payload to IPFS: %w", err)
+	}
+	logger.Infof("Pinned payload for %s %s/%s to IPFS with CID %s", obj.GetGVK(), obj.GetNamespace(), obj.GetName(), cid)
+
+	patchBytes, err := patch.GetAnnotationsPatch(map[string]string{
+		cidName(opts): cid,
+	})
+	if err != nil {
+		return err
+	}
+	return obj.Patch(ctx, b.pipelineclientset, patchBytes)
+}
+
+// RetrievePayloads fetches the pinned document referenced by the CID annotation and returns its payload.
+func (b *Backend) RetrievePayloads(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string]string, error) {
+	cid, doc, err := b.retrieveDocument(ctx, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{cid: string(doc.Payload)}, nil
+}
+
+// RetrieveSignatures fetches the pinned document referenced by the CID annotation and returns its signature.
+func (b *Backend) RetrieveSignatures(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (map[string][]string, error) {
+	cid, doc, err := b.retrieveDocument(ctx, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]string{cid: {doc.Signature}}, nil
+}
+
+func (b *Backend) retrieveDocument(ctx context.Context, obj objects.TektonObject, opts config.StorageOpts) (string, *Document, error) {
+	annotations, err := obj.GetLatestAnnotations(ctx, b.pipelineclientset)
+	if err != nil {
+		return "", nil, err
+	}
+	cid, ok := annotations[cidName(opts)]
+	if !ok {
+		return "", nil, fmt.Errorf("no IPFS CID recorded for %s/%s", obj.GetNamespace(), obj.GetName())
+	}
+
+	content, err := b.cat(ctx, cid)
+	if err != nil {
+		return "", nil, fmt.Errorf("fetching %s from IPFS: %w", cid, err)
+	}
+	doc := &Document{}
+	if err := json.Unmarshal(content, doc); err != nil {
+		return "", nil, err
+	}
+	return cid, doc, nil
+}
+
+// add uploads content to the node and pins it, returning the CID.
+func (b *Backend) add(ctx context.Context, filename string, content []byte) (string, error) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write(content); err != nil {
+		return "", err
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("pin", "true")
+	q.Set("cid-version", "1")
+	resp, err := b.do(ctx, addPath, q, body, mw.FormDataContentType())
+	if err != nil {
+		return "", err
+	}
+
+	var ar addResponse
+	if err := json.Unmarshal(resp, &ar); err != nil {
+		return "", err
+	}
+	if ar.Hash == "" {
+		return "", errors.New("no CID returned from IPFS node")
+	}
+	return ar.Hash, nil
+}
+
+// cat returns the content stored under the given CID.
+func (b *Backend) cat(ctx context.Context, cid string) ([]byte, error) {
+	q := url.Values{}
+	q.Set("arg", cid)
+	return b.do(ctx, catPath, q, nil, "")
+}
+
+// do issues a request against the RPC API. The API only accepts POST requests.
+func (b *Backend) do(ctx context.Context, path string, query url.Values, body io.Reader, contentType string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url+path+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s: %s", resp.StatusCode, path, strings.TrimSpace(string(content)))
+	}
+	return content, nil
+}
+
+func cidName(opts config.StorageOpts) string {
+	return fmt.Sprintf(CIDAnnotationFormat, opts.ShortKey)
+}
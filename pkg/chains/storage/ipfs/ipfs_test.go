@@ -0,0 +1,209 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+// fakeNode emulates the subset of the IPFS RPC API used by the backend.
+type fakeNode struct {
+	mu      sync.Mutex
+	token   string
+	objects map[string][]byte
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if n.token != "" && r.Header.Get("Authorization") != "Bearer "+n.token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch r.URL.Path {
+	case addPath:
+		if r.URL.Query().Get("pin") != "true" {
+			http.Error(w, "expected pin=true", http.StatusBadRequest)
+			return
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		content, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sum := sha256.Sum256(content)
+		cid := "bafy" + hex.EncodeToString(sum[:8])
+		n.objects[cid] = content
+		_ = json.NewEncoder(w).Encode(addResponse{Name: h.Filename, Hash: cid, Size: fmt.Sprint(len(content))})
+	case catPath:
+		content, ok := n.objects[r.URL.Query().Get("arg")]
+		if !ok {
+			http.Error(w, "not found", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(content)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestBackend_StorePayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		nodeToken string
+		object    objects.TektonObject
+		wantErr   bool
+	}{
+		{
+			name: "taskrun",
+			object: objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"},
+			}),
+		},
+		{
+			name: "pipelinerun",
+			object: objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"},
+			}),
+		},
+		{
+			name:      "authenticated",
+			token:     "secret",
+			nodeToken: "secret",
+			object: objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"},
+			}),
+		},
+		{
+			name:      "unauthenticated",
+			nodeToken: "secret",
+			object: objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"},
+			}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			tekton.CreateObject(t, ctx, ps, tt.object)
+
+			server := httptest.NewServer(&fakeNode{token: tt.nodeToken, objects: map[string][]byte{}})
+			defer server.Close()
+
+			c := config.IPFSStorageConfig{URL: server.URL + "/"}
+			if tt.token != "" {
+				c.TokenPath = filepath.Join(t.TempDir(), "token")
+				if err := os.WriteFile(c.TokenPath, []byte(tt.token+"\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			b, err := NewStorageBackend(ps, config.Config{Storage: config.StorageConfigs{IPFS: c}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payload := []byte(`{"foo":"bar"}`)
+			opts := config.StorageOpts{ShortKey: "taskrun-uid", Cert: "cert", Chain: "chain"}
+			if err := b.StorePayload(ctx, tt.object, payload, "signature", opts); (err != nil) != tt.wantErr {
+				t.Fatalf("StorePayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			updated, err := tekton.GetObject(t, ctx, ps, tt.object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cid, ok := updated.GetAnnotations()[fmt.Sprintf(CIDAnnotationFormat, opts.ShortKey)]
+			if !ok {
+				t.Fatalf("expected CID annotation, got %v", updated.GetAnnotations())
+			}
+
+			gotPayloads, err := b.RetrievePayloads(ctx, tt.object, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(map[string]string{cid: string(payload)}, gotPayloads); diff != "" {
+				t.Errorf("RetrievePayloads() -want +got: %s", diff)
+			}
+
+			gotSignatures, err := b.RetrieveSignatures(ctx, tt.object, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(map[string][]string{cid: {"signature"}}, gotSignatures); diff != "" {
+				t.Errorf("RetrieveSignatures() -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewStorageBackend_MissingURL(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	if _, err := NewStorageBackend(fakepipelineclient.Get(ctx), config.Config{}); err == nil {
+		t.Error("expected error when storage.ipfs.url is not set")
+	}
+}
+
+func TestBackend_RetrieveWithoutCID(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+	})
+	tekton.CreateObject(t, ctx, ps, obj)
+
+	b, err := NewStorageBackend(ps, config.Config{
+		Storage: config.StorageConfigs{IPFS: config.IPFSStorageConfig{URL: "http://unused"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.RetrievePayloads(ctx, obj, config.StorageOpts{ShortKey: "missing"}); err == nil {
+		t.Error("expected error when no CID annotation is present")
+	}
+}
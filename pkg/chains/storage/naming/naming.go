@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package naming renders the templates of the names storage backends store payloads
+// under, like the objects of GCS buckets, so that stores can be partitioned by team,
+// pipeline or date.
+package naming
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	pipelineLabel = "tekton.dev/pipeline"
+	taskLabel     = "tekton.dev/task"
+)
+
+// SubjectDigest is the variable of the digest of the first subject of in-toto payloads.
+// It has no value when payloads are retrieved.
+const SubjectDigest = "subject.digest"
+
+// variable matches the variables of templates, like $(run.namespace).
+var variable = regexp.MustCompile(`\$\(([^()]+)\)`)
+
+// variables are the variables of templates.
+var variables = sets.New[string](
+	"run.kind", "run.namespace", "run.name", "run.uid", "key",
+	"pipeline.name", "task.name", "payload.format", SubjectDigest,
+	"date", "year", "month", "day",
+)
+
+// Values returns the values of the variables of templates for the payload of obj stored
+// under key. The date is the date obj was created, in UTC, so that the names of the
+// payloads of a run don't change. payload may be nil, like when payloads are retrieved.
+func Values(obj objects.TektonObject, key, payloadFormat string, payload []byte) map[string]string {
+	created := obj.GetCreationTimestamp().UTC()
+	values := map[string]string{
+		"run.kind":       obj.GetKindName(),
+		"run.namespace":  obj.GetNamespace(),
+		"run.name":       obj.GetName(),
+		"run.uid":        string(obj.GetUID()),
+		"key":            key,
+		"pipeline.name":  obj.GetLabels()[pipelineLabel],
+		"task.name":      obj.GetLabels()[taskLabel],
+		"payload.format": payloadFormat,
+		"date":           created.Format("2006-01-02"),
+		"year":           created.Format("2006"),
+		"month":          created.Format("01"),
+		"day":            created.Format("02"),
+	}
+	var statement struct {
+		Subject []struct {
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(payload, &statement); err == nil && len(statement.Subject) > 0 {
+		// Subjects usually have a single digest, the algorithms are sorted otherwise.
+		digest := statement.Subject[0].Digest
+		algs := make([]string, 0, len(digest))
+		for alg := range digest {
+			algs = append(algs, alg)
+		}
+		sort.Strings(algs)
+		if len(algs) > 0 {
+			values[SubjectDigest] = algs[0] + ":" + digest[algs[0]]
+		}
+	}
+	return values
+}
+
+// Validate returns an error if template has variables that aren't known.
+func Validate(template string) error {
+	var unknown []string
+	for _, m := range variable.FindAllStringSubmatch(template, -1) {
+		if key := strings.TrimSpace(m[1]); !variables.Has(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown variables %s in template %q", strings.Join(unknown, ", "), template)
+	}
+	return nil
+}
+
+// Uses returns whether template has the variable key.
+func Uses(template, key string) bool {
+	for _, m := range variable.FindAllStringSubmatch(template, -1) {
+		if strings.TrimSpace(m[1]) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns template with its variables substituted with values, escaped with
+// escape if it isn't nil. Variables without a value are substituted with "".
+func Render(template string, values map[string]string, escape func(string) string) (string, error) {
+	if err := Validate(template); err != nil {
+		return "", err
+	}
+	return variable.ReplaceAllStringFunc(template, func(v string) string {
+		value := values[strings.TrimSpace(variable.FindStringSubmatch(v)[1])]
+		if escape != nil {
+			return escape(value)
+		}
+		return value
+	}), nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package naming
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRender(t *testing.T) {
+	obj := objects.NewPipelineRunObject(&v1beta1.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+		Name:              "build-1",
+		Namespace:         "team-a",
+		UID:               "uid",
+		Labels:            map[string]string{"tekton.dev/pipeline": "build"},
+		CreationTimestamp: metav1.NewTime(time.Date(2023, 4, 5, 23, 30, 0, 0, time.FixedZone("", -2*60*60))),
+	}})
+	payload := []byte(`{"subject":[{"name":"img","digest":{"sha256":"abc"}}]}`)
+	tests := []struct {
+		template string
+		payload  []byte
+		escape   func(string) string
+		want     string
+		wantErr  bool
+	}{
+		{template: "$(key)", want: "pipelinerun-uid"},
+		{template: "$(run.namespace)/$(pipeline.name)/$(run.name)/$(key)", want: "team-a/build/build-1/pipelinerun-uid"},
+		// The date is in UTC.
+		{template: "$(year)/$(month)/$(day)/$(date)", want: "2023/04/06/2023-04-06"},
+		{template: "$(subject.digest)/$(key)", payload: payload, want: "sha256:abc/pipelinerun-uid"},
+		// Variables without a value render empty.
+		{template: "$(task.name)-$( subject.digest )", want: "-"},
+		{template: "$(run.kind)/$(payload.format)", escape: strings.ToUpper, want: "PIPELINERUN/SLSA/V1"},
+		{template: "$(params.version)/$(key)", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.template, Values(obj, "pipelinerun-uid", "slsa/v1", tt.payload), tt.escape)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Render(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestUses(t *testing.T) {
+	if !Uses("$(run.namespace)/$(subject.digest)", SubjectDigest) {
+		t.Error("expected the template to use the subject digest")
+	}
+	if Uses("$(run.namespace)/$(key)", SubjectDigest) {
+		t.Error("expected the template not to use the subject digest")
+	}
+}
@@ -40,6 +40,11 @@ type AttestationStorer struct {
 	repo *name.Repository
 	// remoteOpts are additional remote options (i.e. auth) to use for client operations.
 	remoteOpts []remote.Option
+	// tags are applied to the stored attestations in repo.
+	tags []string
+	// platforms configures whether the attestations of image indexes are also attached
+	// to the platform-specific manifests of the index.
+	platforms bool
 }
 
 func NewAttestationStorer(opts ...AttestationStorerOption) (*AttestationStorer, error) {
@@ -59,6 +64,12 @@ func (s *AttestationStorer) Store(ctx context.Context, req *api.StoreRequest[nam
 	if s.repo != nil {
 		repo = *s.repo
 	}
+	desc, descErr := remote.Get(req.Artifact, s.remoteOpts...)
+	// OCI artifacts that aren't container images, like WASM modules or files pushed
+	// with ORAS, are attached attestations as referrers.
+	if descErr == nil && artifactType(desc) != "" {
+		return s.storeReferrer(ctx, repo, desc, req)
+	}
 	se, err := ociremote.SignedEntity(req.Artifact, ociremote.WithRemoteOptions(s.remoteOpts...))
 	if err != nil {
 		return nil, errors.Wrap(err, "getting signed image")
@@ -84,5 +95,81 @@ func (s *AttestationStorer) Store(ctx context.Context, req *api.StoreRequest[nam
 	}
 	logger.Infof("Successfully uploaded attestation for %s", req.Artifact.String())
 
-	return &api.StoreResponse{}, nil
+	attTag, err := ociremote.AttestationTag(req.Artifact, ociremote.WithTargetRepository(repo))
+	if err != nil {
+		return nil, err
+	}
+	resp := &api.StoreResponse{Locations: []string{attTag.String()}}
+	if len(s.tags) > 0 {
+		desc, err := remote.Get(attTag, s.remoteOpts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting uploaded attestations")
+		}
+		resp.Locations = append(resp.Locations, s.tag(ctx, repo, desc)...)
+	}
+
+	if s.platforms && descErr == nil && desc.MediaType.IsIndex() {
+		locations, err := s.storePlatforms(ctx, desc, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Locations = append(resp.Locations, locations...)
+	}
+	return resp, nil
+}
+
+// storePlatforms attaches the attestation in req to each platform-specific image of the
+// index desc, so that pulls of a single platform can be verified without resolving the
+// index first. Manifests without a known platform, like the attestation manifests
+// BuildKit adds to indexes, are skipped.
+func (s *AttestationStorer) storePlatforms(ctx context.Context, desc *remote.Descriptor, req *api.StoreRequest[name.Digest, in_toto.Statement]) ([]string, error) {
+	logger := logging.FromContext(ctx)
+
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting image index")
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting index manifest")
+	}
+
+	// The templated tags name the attestations of the index only, and child manifests
+	// aren't indexes themselves.
+	child := *s
+	child.tags = nil
+	child.platforms = false
+	var locations []string
+	for _, m := range manifest.Manifests {
+		if !m.MediaType.IsImage() || m.Platform == nil || m.Platform.OS == "" || m.Platform.OS == "unknown" {
+			continue
+		}
+		creq := *req
+		creq.Artifact = req.Artifact.Context().Digest(m.Digest.String())
+		resp, err := child.Store(ctx, &creq)
+		if err != nil {
+			return nil, errors.Wrapf(err, "attaching attestation to %s image %s", m.Platform, creq.Artifact)
+		}
+		logger.Infof("Attached attestation of %s to its %s image %s", req.Artifact, m.Platform, creq.Artifact)
+		locations = append(locations, resp.Locations...)
+	}
+	return locations, nil
+}
+
+// tag applies the tags of s to the attestation manifest t in repo, and returns the
+// references of the applied tags. The attestations remain reachable through the tags
+// derived from the digest of their artifact, so failures are only logged.
+func (s *AttestationStorer) tag(ctx context.Context, repo name.Repository, t remote.Taggable) []string {
+	logger := logging.FromContext(ctx)
+	var tagged []string
+	for _, tag := range s.tags {
+		ref := repo.Tag(tag)
+		if err := remote.Tag(ref, t, s.remoteOpts...); err != nil {
+			logger.Warnf("error tagging attestations as %s: %v", ref, err)
+			continue
+		}
+		logger.Infof("Tagged attestations as %s", ref)
+		tagged = append(tagged, ref.String())
+	}
+	return tagged
 }
@@ -18,7 +18,10 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 
+	"github.com/tektoncd/chains/pkg/chains/chaining"
 	"github.com/tektoncd/chains/pkg/chains/formats"
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/chains/signing"
@@ -43,6 +46,8 @@ import (
 
 const StorageBackendOCI = "oci"
 
+var _ chaining.Finder = &Backend{}
+
 // Backend implements a storage backend for OCI artifacts.
 // Deprecated: Use SimpleStorer and AttestationStorer instead.
 type Backend struct {
@@ -105,7 +110,7 @@ func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, ra
 			return nil
 		}
 
-		return b.uploadAttestation(ctx, attestation, signature, storageOpts, auth)
+		return b.uploadAttestation(ctx, attestation, signature, storageOpts, renderTags(ctx, obj, b.cfg.Storage.OCI.Tags), auth)
 	}
 
 	// Fallback in case unsupported payload format is used or the deprecated "tekton" format
@@ -130,7 +135,7 @@ func (b *Backend) uploadSignature(ctx context.Context, format simple.SimpleConta
 	}
 	// TODO: make these creation opts.
 	store.remoteOpts = remoteOpts
-	if _, err := store.Store(ctx, &api.StoreRequest[name.Digest, simple.SimpleContainerImage]{
+	resp, err := store.Store(ctx, &api.StoreRequest[name.Digest, simple.SimpleContainerImage]{
 		Object:   nil,
 		Artifact: ref,
 		Payload:  format,
@@ -140,18 +145,25 @@ func (b *Backend) uploadSignature(ctx context.Context, format simple.SimpleConta
 			Cert:      []byte(storageOpts.Cert),
 			Chain:     []byte(storageOpts.Chain),
 		},
-	}); err != nil {
+	})
+	if err != nil {
 		return err
 	}
+	api.RecordLocations(ctx, resp.Locations...)
 	return nil
 }
 
-func (b *Backend) uploadAttestation(ctx context.Context, attestation in_toto.Statement, signature string, storageOpts config.StorageOpts, remoteOpts ...remote.Option) error {
+func (b *Backend) uploadAttestation(ctx context.Context, attestation in_toto.Statement, signature string, storageOpts config.StorageOpts, tags []string, remoteOpts ...remote.Option) error {
 	logger := logging.FromContext(ctx)
 	// upload an attestation for each subject
 	logger.Info("Starting to upload attestations to OCI ...")
 	for _, subj := range attestation.Subject {
-		imageName := fmt.Sprintf("%s@sha256:%s", subj.Name, subj.Digest["sha256"])
+		// Subjects from ARTIFACT_OUTPUTS may not be stored in a registry.
+		if subj.Digest["sha256"] == "" {
+			logger.Infof("Skipping attestation upload to OCI for %s, which has no sha256 digest", subj.Name)
+			continue
+		}
+		imageName := fmt.Sprintf("%s@sha256:%s", strings.TrimPrefix(subj.Name, artifacts.OCIScheme), subj.Digest["sha256"])
 		logger.Infof("Starting attestation upload to OCI for %s...", imageName)
 
 		ref, err := newDigest(b.cfg, imageName)
@@ -159,13 +171,13 @@ func (b *Backend) uploadAttestation(ctx context.Context, attestation in_toto.Sta
 			return errors.Wrapf(err, "getting digest for subj %s", imageName)
 		}
 
-		store, err := NewAttestationStorer(WithTargetRepository(ref.Repository))
+		store, err := NewAttestationStorer(WithTargetRepository(ref.Repository), WithTags(tags...), WithPlatforms(b.cfg.Storage.OCI.AttachToPlatforms))
 		if err != nil {
 			return err
 		}
 		// TODO: make these creation opts.
 		store.remoteOpts = remoteOpts
-		if _, err := store.Store(ctx, &api.StoreRequest[name.Digest, in_toto.Statement]{
+		resp, err := store.Store(ctx, &api.StoreRequest[name.Digest, in_toto.Statement]{
 			Object:   nil,
 			Artifact: ref,
 			Payload:  attestation,
@@ -175,9 +187,11 @@ func (b *Backend) uploadAttestation(ctx context.Context, attestation in_toto.Sta
 				Cert:      []byte(storageOpts.Cert),
 				Chain:     []byte(storageOpts.Chain),
 			},
-		}); err != nil {
+		})
+		if err != nil {
 			return err
 		}
+		api.RecordLocations(ctx, resp.Locations...)
 	}
 	return nil
 }
@@ -278,6 +292,100 @@ func (b *Backend) RetrieveArtifact(ctx context.Context, obj objects.TektonObject
 	return m, nil
 }
 
+// FindAttestations implements chaining.Finder. It returns the attestations attached to
+// the image with the given uri and sha256 digest in its registry, or in the configured
+// repository if one is set.
+func (b *Backend) FindAttestations(ctx context.Context, obj objects.TektonObject, uri string, digest map[string]string) ([]chaining.Attestation, error) {
+	hex, ok := digest["sha256"]
+	if !ok {
+		return nil, nil
+	}
+	var nameOpts []name.Option
+	if b.cfg.Storage.OCI.Insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	ref, err := name.NewDigest(strings.TrimPrefix(uri, artifacts.OCIScheme)+"@sha256:"+hex, nameOpts...)
+	if err != nil {
+		// Not an image.
+		return nil, nil
+	}
+	repo := ref.Repository
+	if r := b.cfg.Storage.OCI.Repository; r != "" {
+		if repo, err = name.NewRepository(r, nameOpts...); err != nil {
+			return nil, err
+		}
+	}
+
+	auth, err := b.getAuthenticator(ctx, obj, b.client)
+	if err != nil {
+		return nil, err
+	}
+	opts := []ociremote.Option{ociremote.WithRemoteOptions(auth), ociremote.WithTargetRepository(repo)}
+	se, err := ociremote.SignedEntity(ref, opts...)
+	if err != nil {
+		return nil, err
+	}
+	attImage, err := se.Attestations()
+	if err != nil {
+		return nil, err
+	}
+	atts, err := attImage.Get()
+	if err != nil {
+		return nil, err
+	}
+	tag, err := ociremote.AttestationTag(ref, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]chaining.Attestation, 0, len(atts))
+	for _, att := range atts {
+		h, err := att.Digest()
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, chaining.Attestation{
+			URI:    artifacts.OCIScheme + tag.String(),
+			Digest: map[string]string{h.Algorithm: h.Hex},
+		})
+	}
+	return found, nil
+}
+
+// CheckHealth checks that the registry of storage.oci.repository is reachable, if it is
+// set. Attestations are stored with the credentials of runs, so pushing them isn't
+// checked.
+func (b *Backend) CheckHealth(ctx context.Context) error {
+	r := b.cfg.Storage.OCI.Repository
+	if r == "" {
+		return nil
+	}
+	var nameOpts []name.Option
+	if b.cfg.Storage.OCI.Insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	repo, err := name.NewRepository(r, nameOpts...)
+	if err != nil {
+		return err
+	}
+	// The registry responds to anonymous requests for the API version with 401 if
+	// it requires authentication.
+	u := fmt.Sprintf("%s://%s/v2/", repo.Registry.Scheme(), repo.RegistryStr())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: remote.DefaultTransport}).Do(req)
+	if err != nil {
+		return fmt.Errorf("registry %s is not reachable: %w", repo.RegistryStr(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("unexpected status %d from registry %s", resp.StatusCode, repo.RegistryStr())
+	}
+	return nil
+}
+
 func newDigest(cfg config.Config, imageName string) (name.Digest, error) {
 	// Override image name from config if set.
 	if r := cfg.Storage.OCI.Repository; r != "" {
@@ -24,18 +24,29 @@ import (
 	"github.com/tektoncd/chains/pkg/chains/formats"
 	"github.com/tektoncd/chains/pkg/chains/formats/simple"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage/api"
 	"github.com/tektoncd/chains/pkg/config"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	ggcrmutate "github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	ggcrstatic "github.com/google/go-containerregistry/pkg/v1/static"
+	ggcrtypes "github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	ociremote "github.com/sigstore/cosign/v2/pkg/oci/remote"
+	cosigntypes "github.com/sigstore/cosign/v2/pkg/types"
 	"github.com/sigstore/sigstore/pkg/signature/payload"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	remotetest "github.com/tektoncd/pipeline/test"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	logtesting "knative.dev/pkg/logging/testing"
 )
@@ -238,3 +249,322 @@ func TestBackend_StorePayload(t *testing.T) {
 		})
 	}
 }
+
+func TestBackend_StorePayload_OCIArtifact(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	// Push a WASM module, an OCI artifact that isn't a container image.
+	const wasmConfigType = "application/vnd.wasm.config.v1+json"
+	module, err := ggcrmutate.Append(empty.Image, ggcrmutate.Addendum{
+		Layer: ggcrstatic.NewLayer([]byte("\x00asm"), "application/vnd.wasm.content.layer.v1+wasm"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	module = ggcrmutate.ConfigMediaType(ggcrmutate.MediaType(module, ggcrtypes.OCIManifestSchema1), wasmConfigType)
+	h, err := module.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.NewDigest(u.Host + "/wasm/module@" + h.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, module); err != nil {
+		t.Fatal(err)
+	}
+
+	statement := in_toto.ProvenanceStatement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: slsa.PredicateSLSAProvenance,
+			Subject: []in_toto.Subject{
+				{Name: "oci://" + u.Host + "/wasm/module", Digest: common.DigestSet{"sha256": h.Hex}},
+				{Name: "https://example.com/module.tar", Digest: common.DigestSet{"sha512": "ab"}},
+			},
+		},
+		Predicate: slsa.ProvenancePredicate{},
+	}
+	rawPayload, err := json.Marshal(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := logtesting.TestContextWithLogger(t)
+	b := &Backend{
+		getAuthenticator: func(context.Context, objects.TektonObject, kubernetes.Interface) (remote.Option, error) {
+			return remote.WithAuthFromKeychain(authn.DefaultKeychain), nil
+		},
+	}
+	if err := b.StorePayload(ctx, objects.NewTaskRunObject(tr), rawPayload, "envelope", config.StorageOpts{PayloadFormat: formats.PayloadTypeSlsav1}); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := remote.Referrers(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Manifests) != 1 || m.Manifests[0].ArtifactType != cosigntypes.DssePayloadType {
+		t.Fatalf("referrers of %s = %+v, want a single %s", ref, m.Manifests, cosigntypes.DssePayloadType)
+	}
+	att, err := remote.Image(ref.Context().Digest(m.Manifests[0].Digest.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	am, err := att.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if am.Subject == nil || am.Subject.ArtifactType != wasmConfigType {
+		t.Errorf("subject of the attestation = %+v, want a %s artifact", am.Subject, wasmConfigType)
+	}
+	if got := am.Layers[0].Annotations[PredicateTypeAnnotation]; got != slsa.PredicateSLSAProvenance {
+		t.Errorf("predicate type of the attestation = %q, want %q", got, slsa.PredicateSLSAProvenance)
+	}
+}
+
+func TestBackend_StorePayload_Tags(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	ref, err := remotetest.CreateImage(u.Host+"/task/"+tr.Name, tr)
+	if err != nil {
+		t.Fatalf("failed to push img: %v", err)
+	}
+	digest, err := name.NewDigest(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := in_toto.ProvenanceStatement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: slsa.PredicateSLSAProvenance,
+			Subject: []in_toto.Subject{{
+				Name:   u.Host + "/task/" + tr.Name,
+				Digest: common.DigestSet{"sha256": strings.TrimPrefix(digest.DigestStr(), "sha256:")},
+			}},
+		},
+		Predicate: slsa.ProvenancePredicate{},
+	}
+	rawPayload, err := json.Marshal(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := logtesting.TestContextWithLogger(t)
+	b := &Backend{
+		cfg: config.Config{Storage: config.StorageConfigs{OCI: config.OCIStorageConfig{
+			Tags: sets.New[string]("$(run.name)-provenance", "$(params.missing)"),
+		}}},
+		getAuthenticator: func(context.Context, objects.TektonObject, kubernetes.Interface) (remote.Option, error) {
+			return remote.WithAuthFromKeychain(authn.DefaultKeychain), nil
+		},
+	}
+	lctx, locations := api.WithLocations(ctx)
+	if err := b.StorePayload(lctx, objects.NewTaskRunObject(tr), rawPayload, "envelope", config.StorageOpts{PayloadFormat: formats.PayloadTypeSlsav1}); err != nil {
+		t.Fatal(err)
+	}
+
+	attTag, err := ociremote.AttestationTag(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLocations := []string{attTag.String(), digest.Context().Tag(tr.Name + "-provenance").String()}
+	if diff := cmp.Diff(wantLocations, locations()); diff != "" {
+		t.Errorf("locations diff (-want +got): %s", diff)
+	}
+	want, err := remote.Head(attTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := remote.Head(digest.Context().Tag(tr.Name + "-provenance"))
+	if err != nil {
+		t.Fatalf("attestations weren't tagged: %v", err)
+	}
+	if got.Digest != want.Digest {
+		t.Errorf("tag points to %s, want the attestations %s", got.Digest, want.Digest)
+	}
+	tags, err := remote.List(digest.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 3 {
+		t.Errorf("tags = %v, want the image, attestation and semantic tags", tags)
+	}
+}
+
+func TestBackend_StorePayload_Platforms(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	repo, err := name.NewRepository(u.Host + "/task/" + tr.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	platforms := []v1.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64"},
+		// Like the attestation manifests BuildKit adds to indexes.
+		{OS: "unknown", Architecture: "unknown"},
+	}
+	var manifests []ggcrmutate.IndexAddendum
+	for _, p := range platforms {
+		p := p
+		img, err := ggcrmutate.ConfigFile(empty.Image, &v1.ConfigFile{OS: p.OS, Architecture: p.Architecture})
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifests = append(manifests, ggcrmutate.IndexAddendum{Add: img, Descriptor: v1.Descriptor{Platform: &p}})
+	}
+	idx := ggcrmutate.AppendManifests(empty.Index, manifests...)
+	h, err := idx.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := repo.Digest(h.String())
+	if err := remote.WriteIndex(digest, idx); err != nil {
+		t.Fatalf("failed to push index: %v", err)
+	}
+	statement := in_toto.ProvenanceStatement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: slsa.PredicateSLSAProvenance,
+			Subject: []in_toto.Subject{{
+				Name:   repo.String(),
+				Digest: common.DigestSet{"sha256": h.Hex},
+			}},
+		},
+		Predicate: slsa.ProvenancePredicate{},
+	}
+	rawPayload, err := json.Marshal(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := logtesting.TestContextWithLogger(t)
+	b := &Backend{
+		cfg: config.Config{Storage: config.StorageConfigs{OCI: config.OCIStorageConfig{AttachToPlatforms: true}}},
+		getAuthenticator: func(context.Context, objects.TektonObject, kubernetes.Interface) (remote.Option, error) {
+			return remote.WithAuthFromKeychain(authn.DefaultKeychain), nil
+		},
+	}
+	lctx, locations := api.WithLocations(ctx)
+	if err := b.StorePayload(lctx, objects.NewTaskRunObject(tr), rawPayload, "envelope", config.StorageOpts{PayloadFormat: formats.PayloadTypeSlsav1}); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	attached := []name.Digest{digest}
+	for _, m := range manifest.Manifests {
+		if m.Platform.OS != "unknown" {
+			attached = append(attached, repo.Digest(m.Digest.String()))
+		}
+	}
+	var wantLocations []string
+	for _, d := range attached {
+		attTag, err := ociremote.AttestationTag(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := remote.Head(attTag); err != nil {
+			t.Errorf("attestation wasn't attached to %s: %v", d, err)
+		}
+		wantLocations = append(wantLocations, attTag.String())
+	}
+	if diff := cmp.Diff(wantLocations, locations()); diff != "" {
+		t.Errorf("locations diff (-want +got): %s", diff)
+	}
+}
+
+func TestBackend_FindAttestations(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	ref, err := remotetest.CreateImage(u.Host+"/task/"+tr.Name, tr)
+	if err != nil {
+		t.Fatalf("failed to push img: %v", err)
+	}
+	hex := strings.TrimPrefix(strings.Split(ref, "@")[1], "sha256:")
+	b := &Backend{
+		getAuthenticator: func(context.Context, objects.TektonObject, kubernetes.Interface) (remote.Option, error) {
+			return remote.WithAuthFromKeychain(authn.DefaultKeychain), nil
+		},
+	}
+	obj := objects.NewTaskRunObject(tr)
+
+	got, err := b.FindAttestations(ctx, obj, u.Host+"/task/"+tr.Name, map[string]string{"sha256": hex})
+	if err != nil {
+		t.Fatalf("FindAttestations() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("FindAttestations() = %v before an attestation was stored, want none", got)
+	}
+
+	statement := in_toto.ProvenanceStatement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          in_toto.StatementInTotoV01,
+			PredicateType: slsa.PredicateSLSAProvenance,
+			Subject:       []in_toto.Subject{{Name: u.Host + "/task/" + tr.Name, Digest: common.DigestSet{"sha256": hex}}},
+		},
+	}
+	rawPayload, err := json.Marshal(statement)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if err := b.StorePayload(ctx, obj, rawPayload, "attestation", config.StorageOpts{PayloadFormat: formats.PayloadTypeSlsav1}); err != nil {
+		t.Fatalf("StorePayload() error = %v", err)
+	}
+
+	got, err = b.FindAttestations(ctx, obj, "oci://"+u.Host+"/task/"+tr.Name, map[string]string{"sha256": hex})
+	if err != nil {
+		t.Fatalf("FindAttestations() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("FindAttestations() = %v, want one attestation", got)
+	}
+	wantURI := "oci://" + u.Host + "/task/" + tr.Name + ":sha256-" + hex + ".att"
+	if got[0].URI != wantURI {
+		t.Errorf("FindAttestations() URI = %s, want %s", got[0].URI, wantURI)
+	}
+	if len(got[0].Digest["sha256"]) != 64 {
+		t.Errorf("FindAttestations() digest = %v, want a sha256 digest", got[0].Digest)
+	}
+
+	for _, uri := range []string{"git+https://github.com/tektoncd/chains.git", u.Host + "/task/" + tr.Name} {
+		got, err := b.FindAttestations(ctx, obj, uri, map[string]string{"sha1": "04b1a67ab2ec1ee7e2df1f1b4f4b7ad6ced2c0c6"})
+		if err != nil || len(got) != 0 {
+			t.Errorf("FindAttestations(%s) = %v, %v, want none", uri, got, err)
+		}
+	}
+}
+
+func TestBackend_CheckHealth(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	u, _ := url.Parse(s.URL)
+	b := &Backend{cfg: config.Config{Storage: config.StorageConfigs{OCI: config.OCIStorageConfig{Repository: u.Host + "/attestations"}}}}
+	if err := b.CheckHealth(context.Background()); err != nil {
+		t.Errorf("CheckHealth() = %v", err)
+	}
+
+	s.Close()
+	if err := b.CheckHealth(context.Background()); err == nil || !strings.Contains(err.Error(), "not reachable") {
+		t.Errorf("expected the registry not to be reachable, got %v", err)
+	}
+
+	// Attestations stored alongside images aren't checked.
+	if err := (&Backend{}).CheckHealth(context.Background()); err != nil {
+		t.Errorf("CheckHealth() = %v", err)
+	}
+}
@@ -52,3 +52,37 @@ func (o *targetRepoOption) applySimpleStorer(s *SimpleStorer) error {
 	s.repo = &o.repo
 	return nil
 }
+
+// WithTags configures tags to apply to the stored attestations, in addition to the
+// tags derived from the digest of their artifact.
+func WithTags(tags ...string) AttestationStorerOption {
+	return &tagsOption{
+		tags: tags,
+	}
+}
+
+type tagsOption struct {
+	tags []string
+}
+
+func (o *tagsOption) applyAttestationStorer(s *AttestationStorer) error {
+	s.tags = o.tags
+	return nil
+}
+
+// WithPlatforms configures whether the attestations of image indexes are also attached
+// to each platform-specific image of the index.
+func WithPlatforms(enabled bool) AttestationStorerOption {
+	return &platformsOption{
+		enabled: enabled,
+	}
+}
+
+type platformsOption struct {
+	enabled bool
+}
+
+func (o *platformsOption) applyAttestationStorer(s *AttestationStorer) error {
+	s.platforms = o.enabled
+	return nil
+}
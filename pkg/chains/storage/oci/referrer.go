@@ -0,0 +1,116 @@
+// Copyright 2023 The Tekton Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/pkg/errors"
+	"github.com/sigstore/cosign/v2/pkg/oci/static"
+	cosigntypes "github.com/sigstore/cosign/v2/pkg/types"
+	"github.com/tektoncd/chains/pkg/chains/storage/api"
+	"knative.dev/pkg/logging"
+)
+
+// PredicateTypeAnnotation is the annotation of the attestations attached to OCI
+// artifacts as referrers that holds the predicate type of the attestation.
+const PredicateTypeAnnotation = "in-toto.io/predicate-type"
+
+// artifactType returns the artifact type of the manifest desc, or "" if desc is a
+// container image or an index. The artifact type is the artifactType of the manifest
+// or, like for the Referrers API, the media type of its config.
+func artifactType(desc *remote.Descriptor) string {
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList, types.DockerManifestSchema1, types.DockerManifestSchema1Signed, types.DockerManifestSchema2:
+		return ""
+	}
+	var m struct {
+		ArtifactType string `json:"artifactType"`
+		Config       struct {
+			MediaType types.MediaType `json:"mediaType"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(desc.Manifest, &m); err != nil {
+		return ""
+	}
+	switch {
+	case m.ArtifactType != "":
+		return m.ArtifactType
+	case desc.MediaType != types.OCIManifestSchema1:
+		// Manifests of unknown media types, like the OCI artifact manifests of
+		// registries predating OCI 1.1, are typed by their media type.
+		return string(desc.MediaType)
+	case m.Config.MediaType == types.OCIConfigJSON || m.Config.MediaType == "":
+		return ""
+	default:
+		return string(m.Config.MediaType)
+	}
+}
+
+// storeReferrer attaches the attestation in req to the OCI artifact desc as a referrer,
+// an OCI manifest whose subject is the artifact, in repo. Unlike the attestations of
+// images, which cosign looks up by tag, the attestations of other OCI artifacts are
+// found with the Referrers API, or its fallback tag on registries that don't support
+// it. The artifact type of the referrer is the DSSE envelope media type.
+func (s *AttestationStorer) storeReferrer(ctx context.Context, repo name.Repository, desc *remote.Descriptor, req *api.StoreRequest[name.Digest, in_toto.Statement]) (*api.StoreResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	attOpts := []static.Option{static.WithLayerMediaType(cosigntypes.DssePayloadType)}
+	if req.Bundle.Cert != nil {
+		attOpts = append(attOpts, static.WithCertChain(req.Bundle.Cert, req.Bundle.Chain))
+	}
+	att, err := static.NewAttestation(req.Bundle.Signature, attOpts...)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := att.Annotations()
+	if err != nil {
+		return nil, err
+	}
+	annotations[PredicateTypeAnnotation] = req.Payload.PredicateType
+
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: att, Annotations: annotations})
+	if err != nil {
+		return nil, err
+	}
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, cosigntypes.DssePayloadType)
+	img = mutate.Subject(img, v1.Descriptor{
+		MediaType:    desc.MediaType,
+		Size:         desc.Size,
+		Digest:       desc.Digest,
+		ArtifactType: artifactType(desc),
+	}).(v1.Image)
+
+	h, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+	ref := repo.Digest(h.String())
+	if err := remote.Write(ref, img, s.remoteOpts...); err != nil {
+		return nil, errors.Wrap(err, "writing referrer")
+	}
+	logger.Infof("Successfully uploaded attestation for %s artifact %s", artifactType(desc), req.Artifact.String())
+
+	return &api.StoreResponse{Locations: append([]string{ref.String()}, s.tag(ctx, repo, img)...)}, nil
+}
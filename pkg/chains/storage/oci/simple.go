@@ -86,5 +86,10 @@ func (s *SimpleStorer) Store(ctx context.Context, req *api.StoreRequest[name.Dig
 		return nil, err
 	}
 	logger.Info("Successfully uploaded signature")
-	return &api.StoreResponse{}, nil
+
+	sigTag, err := ociremote.SignatureTag(req.Artifact, ociremote.WithTargetRepository(repo))
+	if err != nil {
+		return nil, err
+	}
+	return &api.StoreResponse{Locations: []string{sigTag.String()}}, nil
 }
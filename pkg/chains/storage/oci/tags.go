@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// maxTagLength is the maximum length of OCI tags.
+	maxTagLength = 128
+
+	pipelineLabel = "tekton.dev/pipeline"
+	taskLabel     = "tekton.dev/task"
+)
+
+var (
+	// tagVariable matches the variables of tag templates, like $(run.uid).
+	tagVariable = regexp.MustCompile(`\$\(([^()]+)\)`)
+	// invalidTagChars matches the characters that can't be used in OCI tags.
+	invalidTagChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+)
+
+// renderTags returns the tags of templates for the attestations of obj. Variables are
+// substituted with the values of obj, and characters OCI tags can't contain are
+// replaced with "-". Templates with variables obj has no value for are skipped.
+func renderTags(ctx context.Context, obj objects.TektonObject, templates sets.Set[string]) []string {
+	logger := logging.FromContext(ctx)
+	values := tagValues(obj)
+
+	tags := sets.New[string]()
+	for _, template := range sets.List(templates) {
+		var missing []string
+		tag := tagVariable.ReplaceAllStringFunc(template, func(v string) string {
+			key := strings.TrimSpace(tagVariable.FindStringSubmatch(v)[1])
+			value, ok := values[key]
+			if !ok || value == "" {
+				missing = append(missing, key)
+			}
+			return value
+		})
+		if len(missing) > 0 {
+			logger.Warnf("Not tagging the attestations of %s %s/%s with %q: no value for %s", obj.GetKindName(), obj.GetNamespace(), obj.GetName(), template, strings.Join(missing, ", "))
+			continue
+		}
+		tag = strings.TrimLeft(invalidTagChars.ReplaceAllString(tag, "-"), ".-")
+		if len(tag) > maxTagLength {
+			tag = tag[:maxTagLength]
+		}
+		if tag != "" {
+			tags.Insert(tag)
+		}
+	}
+	return sets.List(tags)
+}
+
+// tagValues returns the values of the variables of tag templates for obj.
+func tagValues(obj objects.TektonObject) map[string]string {
+	values := map[string]string{
+		"run.kind":      obj.GetKindName(),
+		"run.namespace": obj.GetNamespace(),
+		"run.name":      obj.GetName(),
+		"run.uid":       string(obj.GetUID()),
+	}
+	if p := obj.GetLabels()[pipelineLabel]; p != "" {
+		values["pipeline.name"] = p
+	}
+	if t := obj.GetLabels()[taskLabel]; t != "" {
+		values["task.name"] = t
+	}
+
+	var params []v1beta1.Param
+	switch o := obj.GetObject().(type) {
+	case *v1beta1.TaskRun:
+		params = o.Spec.Params
+	case *v1beta1.PipelineRun:
+		params = o.Spec.Params
+	case *v1beta1.CustomRun:
+		params = o.Spec.Params
+	}
+	for _, p := range params {
+		if p.Value.Type == v1beta1.ParamTypeString {
+			values["params."+p.Name] = p.Value.StringVal
+		}
+	}
+	for _, r := range obj.GetResults() {
+		if r.Value.Type == v1beta1.ParamTypeString {
+			values["results."+r.Name] = r.Value.StringVal
+		}
+	}
+	// Like in provenance, the commit of results takes precedence over params.
+	for _, key := range []string{"results." + artifacts.GitCommitHint, "params." + artifacts.GitCommitHint} {
+		if c := values[key]; c != "" {
+			values["git.commit"] = c
+			break
+		}
+	}
+	return values
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestRenderTags(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	pr := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "release-abcde",
+			Namespace: "ci",
+			UID:       "8c5b2c8e-5c2a-4a55-9b0a-6e3b8f1b4a1d",
+			Labels:    map[string]string{pipelineLabel: "release"},
+		},
+		Spec: v1beta1.PipelineRunSpec{Params: []v1beta1.Param{
+			{Name: "version", Value: *v1beta1.NewStructuredValues("v1.2.0")},
+			{Name: "CHAINS-GIT_COMMIT", Value: *v1beta1.NewStructuredValues("50c56a48cfb3a5a80fa36ed91c739bdac8381cbe")},
+		}},
+		Status: v1beta1.PipelineRunStatus{PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+			PipelineResults: []v1beta1.PipelineRunResult{{Name: "GIT_TAG", Value: *v1beta1.NewStructuredValues("refs/tags/v1.2.0")}},
+		}},
+	})
+
+	tests := []struct {
+		name      string
+		templates sets.Set[string]
+		want      []string
+	}{
+		{name: "none", want: []string{}},
+		{
+			name:      "variables",
+			templates: sets.New[string]("$(pipeline.name)-$(params.version)", "run-$(run.uid)", "$(git.commit)"),
+			want: []string{
+				"50c56a48cfb3a5a80fa36ed91c739bdac8381cbe",
+				"release-v1.2.0",
+				"run-8c5b2c8e-5c2a-4a55-9b0a-6e3b8f1b4a1d",
+			},
+		},
+		{name: "invalid characters", templates: sets.New[string]("$(results.GIT_TAG)"), want: []string{"refs-tags-v1.2.0"}},
+		{name: "missing value", templates: sets.New[string]("$(task.name)", "$(params.missing)-x"), want: []string{}},
+		{name: "leading separators", templates: sets.New[string](".$(params.version)"), want: []string{"v1.2.0"}},
+		{name: "duplicates", templates: sets.New[string]("$(params.version)", "$( params.version )"), want: []string{"v1.2.0"}},
+		{name: "too long", templates: sets.New[string](strings.Repeat("a", 200)), want: []string{strings.Repeat("a", maxTagLength)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, renderTags(ctx, pr, tt.templates)); diff != "" {
+				t.Errorf("renderTags() -want +got: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"gocloud.dev/gcerrors"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/batcher"
+	"gocloud.dev/pubsub/driver"
+)
+
+const (
+	PubSubProviderSNS = "sns"
+	PubSubProviderSQS = "sqs"
+
+	// fifoMessageGroup is the message group of the messages published to FIFO topics
+	// and queues, so that they are delivered in the order they were published.
+	fifoMessageGroup = "tekton-chains"
+)
+
+// snsEndpoint returns the SNS endpoint of region. It is overridden in tests.
+var snsEndpoint = func(region string) string {
+	return fmt.Sprintf("https://sns.%s.amazonaws.com", region)
+}
+
+// awsTopic is a gocloud pubsub driver publishing messages to an SNS topic or an SQS
+// queue, with the default AWS credentials of the controller, like those of IAM roles
+// for service accounts. The metadata of messages is published as message attributes.
+type awsTopic struct {
+	provider string
+	// target is the ARN of the SNS topic or the URL of the SQS queue.
+	target string
+	region string
+	creds  aws.CredentialsProvider
+}
+
+// openAWSTopic opens the SNS topic with ARN target, or the SQS queue with URL target.
+// The region is the one of target, or the default region if it can't be told.
+func openAWSTopic(ctx context.Context, provider, target string) (*pubsub.Topic, error) {
+	if target == "" {
+		return nil, fmt.Errorf("no %s topic configured", provider)
+	}
+	var opts []func(*awsconfig.LoadOptions) error
+	if region := awsRegion(provider, target); region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region to publish to %s in", target)
+	}
+	t := &awsTopic{provider: provider, target: target, region: awsCfg.Region, creds: awsCfg.Credentials}
+	// Messages are published one at a time, like by the SNS and SQS drivers of gocloud.
+	return pubsub.NewTopic(t, &batcher.Options{MaxBatchSize: 1, MaxHandlers: 2}), nil
+}
+
+// awsRegion returns the region of the SNS topic ARN arn:<partition>:sns:<region>:..., or
+// of the SQS queue URL https://sqs.<region>.amazonaws.com/..., or "".
+func awsRegion(provider, target string) string {
+	if provider == PubSubProviderSNS {
+		if parts := strings.SplitN(target, ":", 5); len(parts) == 5 && parts[0] == "arn" {
+			return parts[3]
+		}
+		return ""
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	if parts := strings.Split(u.Hostname(), "."); len(parts) > 2 && parts[0] == "sqs" {
+		return parts[1]
+	}
+	return ""
+}
+
+// SendBatch implements driver.Topic.
+func (t *awsTopic) SendBatch(ctx context.Context, ms []*driver.Message) error {
+	for _, m := range ms {
+		if err := t.send(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send publishes m with the Publish action of SNS or the SendMessage action of SQS.
+func (t *awsTopic) send(ctx context.Context, m *driver.Message) error {
+	form := url.Values{}
+	endpoint, service := t.target, PubSubProviderSQS
+	attribute := "MessageAttribute.%d.%s"
+	if t.provider == PubSubProviderSNS {
+		endpoint, service = snsEndpoint(t.region), PubSubProviderSNS
+		attribute = "MessageAttributes.entry.%d.%s"
+		form.Set("Action", "Publish")
+		form.Set("Version", "2010-03-31")
+		form.Set("TopicArn", t.target)
+		form.Set("Message", string(m.Body))
+	} else {
+		form.Set("Action", "SendMessage")
+		form.Set("Version", "2012-11-05")
+		form.Set("QueueUrl", t.target)
+		form.Set("MessageBody", string(m.Body))
+	}
+	keys := make([]string, 0, len(m.Metadata))
+	for k := range m.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		form.Set(fmt.Sprintf(attribute, i+1, "Name"), k)
+		form.Set(fmt.Sprintf(attribute, i+1, "Value.DataType"), "String")
+		form.Set(fmt.Sprintf(attribute, i+1, "Value.StringValue"), m.Metadata[k])
+	}
+	if strings.HasSuffix(t.target, ".fifo") {
+		sum := sha256.Sum256(m.Body)
+		form.Set("MessageGroupId", fifoMessageGroup)
+		form.Set("MessageDeduplicationId", hex.EncodeToString(sum[:]))
+	}
+
+	body := form.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	creds, err := t.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving AWS credentials: %w", err)
+	}
+	sum := sha256.Sum256([]byte(body))
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), service, t.region, time.Now()); err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d publishing to %s: %s", resp.StatusCode, t.target, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// IsRetryable implements driver.Topic.
+func (t *awsTopic) IsRetryable(error) bool { return false }
+
+// As implements driver.Topic.
+func (t *awsTopic) As(interface{}) bool { return false }
+
+// ErrorAs implements driver.Topic.
+func (t *awsTopic) ErrorAs(error, interface{}) bool { return false }
+
+// ErrorCode implements driver.Topic.
+func (t *awsTopic) ErrorCode(error) gcerrors.ErrorCode { return gcerrors.Unknown }
+
+// Close implements driver.Topic.
+func (t *awsTopic) Close() error { return nil }
@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+const statement = `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2",` +
+	`"subject":[{"name":"registry/image","digest":{"sha256":"bbb"}},{"name":"registry/other","digest":{"sha256":"aaa"}}]}`
+
+// startAWS serves an SNS or SQS endpoint, returning the forms of the requests it received
+// and the Authorization headers they were signed with.
+func startAWS(t *testing.T, status int) (*httptest.Server, *[]url.Values, *[]string) {
+	t.Helper()
+	var forms []url.Values
+	var auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		forms = append(forms, r.PostForm)
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("<ErrorResponse/>"))
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+	return srv, &forms, &auths
+}
+
+func storeAWS(t *testing.T, provider, target string) error {
+	t.Helper()
+	ctx := logtesting.TestContextWithLogger(t)
+	b, err := NewStorageBackend(ctx, config.Config{Storage: config.StorageConfigs{PubSub: config.PubSubStorageConfig{
+		Provider: provider,
+		Topic:    target,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"}})
+	opts := config.StorageOpts{ShortKey: "taskrun-uid", PayloadFormat: formats.PayloadTypeSlsav1}
+	return b.StorePayload(ctx, obj, []byte(statement), "signature", opts)
+}
+
+func TestBackend_StorePayload_SNS(t *testing.T) {
+	srv, forms, auths := startAWS(t, http.StatusOK)
+	defer func(f func(string) string) { snsEndpoint = f }(snsEndpoint)
+	snsEndpoint = func(string) string { return srv.URL }
+
+	arn := "arn:aws:sns:eu-west-1:123456789012:attestations"
+	if err := storeAWS(t, PubSubProviderSNS, arn); err != nil {
+		t.Fatal(err)
+	}
+	want := []url.Values{{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {arn},
+		"Message":  {"signature"},
+
+		"MessageAttributes.entry.1.Name":              {PayloadFormatAttribute},
+		"MessageAttributes.entry.1.Value.DataType":    {"String"},
+		"MessageAttributes.entry.1.Value.StringValue": {string(formats.PayloadTypeSlsav1)},
+		"MessageAttributes.entry.2.Name":              {PredicateTypeAttribute},
+		"MessageAttributes.entry.2.Value.DataType":    {"String"},
+		"MessageAttributes.entry.2.Value.StringValue": {"https://slsa.dev/provenance/v0.2"},
+		"MessageAttributes.entry.3.Name":              {RunKindAttribute},
+		"MessageAttributes.entry.3.Value.DataType":    {"String"},
+		"MessageAttributes.entry.3.Value.StringValue": {"taskrun"},
+		"MessageAttributes.entry.4.Name":              {RunNameAttribute},
+		"MessageAttributes.entry.4.Value.DataType":    {"String"},
+		"MessageAttributes.entry.4.Value.StringValue": {"foo"},
+		"MessageAttributes.entry.5.Name":              {RunNamespaceAttribute},
+		"MessageAttributes.entry.5.Value.DataType":    {"String"},
+		"MessageAttributes.entry.5.Value.StringValue": {"bar"},
+		"MessageAttributes.entry.6.Name":              {SubjectDigestAttribute},
+		"MessageAttributes.entry.6.Value.DataType":    {"String"},
+		"MessageAttributes.entry.6.Value.StringValue": {"sha256:aaa,sha256:bbb"},
+	}}
+	if diff := cmp.Diff(want, *forms); diff != "" {
+		t.Errorf("-want +got: %s", diff)
+	}
+	// The request is signed for the region of the topic.
+	if auth := (*auths)[0]; !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") || !strings.Contains(auth, "/eu-west-1/sns/") {
+		t.Errorf("Authorization = %q, want a signature for sns in eu-west-1", auth)
+	}
+}
+
+func TestBackend_StorePayload_SQSFIFO(t *testing.T) {
+	srv, forms, auths := startAWS(t, http.StatusOK)
+
+	queue := srv.URL + "/123456789012/attestations.fifo"
+	if err := storeAWS(t, PubSubProviderSQS, queue); err != nil {
+		t.Fatal(err)
+	}
+	if len(*forms) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(*forms))
+	}
+	form := (*forms)[0]
+	sum := sha256.Sum256([]byte("signature"))
+	for k, want := range map[string]string{
+		"Action":                            "SendMessage",
+		"QueueUrl":                          queue,
+		"MessageBody":                       "signature",
+		"MessageGroupId":                    fifoMessageGroup,
+		"MessageDeduplicationId":            hex.EncodeToString(sum[:]),
+		"MessageAttribute.6.Name":           SubjectDigestAttribute,
+		"MessageAttribute.6.Value.DataType": "String",
+	} {
+		if got := form.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	// The region of the queue can't be told from its URL, so the default region is used.
+	if auth := (*auths)[0]; !strings.Contains(auth, "/us-east-1/sqs/") {
+		t.Errorf("Authorization = %q, want a signature for sqs in us-east-1", auth)
+	}
+}
+
+func TestBackend_StorePayload_AWSError(t *testing.T) {
+	srv, _, _ := startAWS(t, http.StatusForbidden)
+
+	err := storeAWS(t, PubSubProviderSQS, srv.URL+"/123456789012/attestations")
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 403") {
+		t.Errorf("expected the status to be returned, got %v", err)
+	}
+}
+
+func TestAWSRegion(t *testing.T) {
+	tests := []struct {
+		provider, target, want string
+	}{
+		{PubSubProviderSNS, "arn:aws:sns:us-west-2:123456789012:attestations", "us-west-2"},
+		{PubSubProviderSNS, "attestations", ""},
+		{PubSubProviderSQS, "https://sqs.ap-south-1.amazonaws.com/123456789012/attestations", "ap-south-1"},
+		{PubSubProviderSQS, "http://localhost:4566/000000000000/attestations", ""},
+	}
+	for _, tt := range tests {
+		if got := awsRegion(tt.provider, tt.target); got != tt.want {
+			t.Errorf("awsRegion(%q, %q) = %q, want %q", tt.provider, tt.target, got, tt.want)
+		}
+	}
+}
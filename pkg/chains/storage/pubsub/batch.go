@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"gocloud.dev/pubsub"
+)
+
+const (
+	// BatchSizeMetadataKey is the metadata key of the number of payloads in a batch.
+	// The body of batches is a JSON array of their payloads and signatures.
+	BatchSizeMetadataKey = "batch-size"
+
+	// DefaultBatchLinger is how long a batch waits for more payloads when the config
+	// doesn't say.
+	DefaultBatchLinger = 100 * time.Millisecond
+)
+
+// BatchEntry is a payload published in a batch. Payload is base64 encoded, like in the
+// metadata of the messages of single payloads.
+type BatchEntry struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// errClosed is returned when adding a payload to a publisher that was replaced.
+var errClosed = errors.New("pubsub publisher closed")
+
+type pendingEntry struct {
+	entry BatchEntry
+	done  chan error
+}
+
+// publisher publishes the payloads sent to a topic in batches, one batch at a time
+// and in the order they were filled.
+type publisher struct {
+	cfg   config.PubSubStorageConfig
+	topic *pubsub.Topic
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// pending is the batch being filled.
+	pending []pendingEntry
+	// timer publishes pending once the linger elapsed.
+	timer *time.Timer
+	// ready are the filled batches waiting to be published.
+	ready [][]pendingEntry
+	// draining is set once the controller shuts down, after which payloads are
+	// published as soon as they are added.
+	draining bool
+	closed   bool
+	stopped  chan struct{}
+}
+
+var (
+	publishersMu sync.Mutex
+	current      *publisher
+)
+
+// getPublisher returns the publisher for cfg, opening its topic with open if the
+// current publisher is for another config. The batches of the replaced publisher are
+// published before its topic is shut down. Publishers flush their batches once ctx,
+// the context the controller runs with, is done.
+func getPublisher(ctx context.Context, cfg config.PubSubStorageConfig, open func(context.Context) (*pubsub.Topic, error)) (*publisher, error) {
+	publishersMu.Lock()
+	defer publishersMu.Unlock()
+	if current != nil && current.cfg == cfg {
+		return current, nil
+	}
+	topic, err := open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		go current.close()
+	}
+	current = newPublisher(ctx, cfg, topic)
+	return current, nil
+}
+
+func newPublisher(ctx context.Context, cfg config.PubSubStorageConfig, topic *pubsub.Topic) *publisher {
+	p := &publisher{cfg: cfg, topic: topic, stopped: make(chan struct{})}
+	p.cond = sync.NewCond(&p.mu)
+	go p.run()
+	if done := ctx.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				p.drain()
+			case <-p.stopped:
+			}
+		}()
+	}
+	return p
+}
+
+// add adds entry to the batch being filled, and waits until the batch was published.
+func (p *publisher) add(ctx context.Context, entry BatchEntry) error {
+	done := make(chan error, 1)
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return errClosed
+	}
+	p.pending = append(p.pending, pendingEntry{entry: entry, done: done})
+	switch {
+	case p.draining || len(p.pending) >= p.cfg.BatchSize:
+		p.flushLocked()
+	case p.timer == nil:
+		linger := p.cfg.BatchLinger
+		if linger <= 0 {
+			linger = DefaultBatchLinger
+		}
+		p.timer = time.AfterFunc(linger, p.flush)
+	}
+	p.mu.Unlock()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *publisher) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flushLocked()
+}
+
+// flushLocked queues the batch being filled to be published. p.mu must be held.
+func (p *publisher) flushLocked() {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if len(p.pending) == 0 {
+		return
+	}
+	p.ready = append(p.ready, p.pending)
+	p.pending = nil
+	p.cond.Signal()
+}
+
+// drain publishes the batch being filled, and the payloads added later right away.
+func (p *publisher) drain() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = true
+	p.flushLocked()
+}
+
+// close publishes the batches left and shuts the topic down.
+func (p *publisher) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.flushLocked()
+	p.cond.Signal()
+	p.mu.Unlock()
+	<-p.stopped
+	_ = p.topic.Shutdown(context.Background())
+}
+
+// run publishes the filled batches in order until the publisher is closed.
+func (p *publisher) run() {
+	defer close(p.stopped)
+	for {
+		p.mu.Lock()
+		for len(p.ready) == 0 && !p.closed {
+			p.cond.Wait()
+		}
+		if len(p.ready) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		batch := p.ready[0]
+		p.ready = p.ready[1:]
+		p.mu.Unlock()
+
+		err := p.publish(batch)
+		for _, e := range batch {
+			e.done <- err
+		}
+	}
+}
+
+// publish sends batch as a single message. Payloads whose runs stopped waiting are
+// sent anyway, so that batches are published in order.
+func (p *publisher) publish(batch []pendingEntry) error {
+	entries := make([]BatchEntry, 0, len(batch))
+	for _, e := range batch {
+		entries = append(entries, e.entry)
+	}
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return p.topic.Send(context.Background(), &pubsub.Message{
+		Body:     body,
+		Metadata: map[string]string{BatchSizeMetadataKey: strconv.Itoa(len(entries))},
+	})
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"gocloud.dev/pubsub"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+var topics int
+
+// topicName returns a new in-memory topic name, as in-memory topics can't be opened
+// again once shut down. The publisher of the previous test is closed.
+func topicName(t *testing.T, name string) string {
+	t.Helper()
+	publishersMu.Lock()
+	if current != nil {
+		current.close()
+		current = nil
+	}
+	publishersMu.Unlock()
+	topics++
+	return fmt.Sprintf("%s-%d", name, topics)
+}
+
+// waitPending waits until a payload is pending in the current publisher.
+func waitPending(t *testing.T) {
+	t.Helper()
+	for {
+		publishersMu.Lock()
+		p := current
+		publishersMu.Unlock()
+		if p != nil {
+			p.mu.Lock()
+			n := len(p.pending)
+			p.mu.Unlock()
+			if n > 0 {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// subscribe opens a subscription to the in-memory topic, which must exist before
+// messages are sent to it.
+func subscribe(t *testing.T, ctx context.Context, name string) *pubsub.Subscription {
+	t.Helper()
+	addr := fmt.Sprintf("mem://%s", name)
+	topic, err := pubsub.OpenTopic(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = topic.Shutdown(context.Background()) })
+	sub, err := pubsub.OpenSubscription(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sub.Shutdown(context.Background()) })
+	return sub
+}
+
+// receiveBatch receives a message and returns the signatures of its batch.
+func receiveBatch(t *testing.T, ctx context.Context, sub *pubsub.Subscription) []string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	msg, err := sub.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Ack()
+	var entries []BatchEntry
+	if err := json.Unmarshal(msg.Body, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Metadata[BatchSizeMetadataKey]; got != fmt.Sprint(len(entries)) {
+		t.Errorf("expected %s %d, got %q", BatchSizeMetadataKey, len(entries), got)
+	}
+	var signatures []string
+	for _, e := range entries {
+		payload, err := base64.StdEncoding.DecodeString(e.Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(payload) != "payload-"+e.Signature {
+			t.Errorf("unexpected payload %q for signature %q", payload, e.Signature)
+		}
+		signatures = append(signatures, e.Signature)
+	}
+	return signatures
+}
+
+func batchConfig(topic string, size int, linger time.Duration) config.Config {
+	return config.Config{Storage: config.StorageConfigs{PubSub: config.PubSubStorageConfig{
+		Provider:    PubSubProviderInMemory,
+		Topic:       topic,
+		BatchSize:   size,
+		BatchLinger: linger,
+	}}}
+}
+
+func store(ctx context.Context, b *Backend, signature string) error {
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "bar"}})
+	return b.StorePayload(ctx, obj, []byte("payload-"+signature), signature, config.StorageOpts{})
+}
+
+func TestBackend_StorePayload_Batched(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	name := topicName(t, "batched")
+	sub := subscribe(t, ctx, name)
+	b, err := NewStorageBackend(ctx, batchConfig(name, 3, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func(i int) { errs <- store(ctx, b, fmt.Sprint(i)) }(i)
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := receiveBatch(t, ctx, sub); len(got) != 3 {
+		t.Errorf("expected a batch of 3 payloads, got %v", got)
+	}
+}
+
+func TestBackend_StorePayload_Linger(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	name := topicName(t, "linger")
+	sub := subscribe(t, ctx, name)
+	b, err := NewStorageBackend(ctx, batchConfig(name, 10, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store(ctx, b, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"0"}, receiveBatch(t, ctx, sub)); diff != "" {
+		t.Errorf("expected the batch to be published after the linger, -want +got: %s", diff)
+	}
+}
+
+func TestBackend_StorePayload_Drain(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	name := topicName(t, "drain")
+	sub := subscribe(t, ctx, name)
+	controllerCtx, shutdown := context.WithCancel(ctx)
+	b, err := NewStorageBackend(controllerCtx, batchConfig(name, 10, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- store(ctx, b, "0") }()
+	// Shut down once the payload is pending.
+	waitPending(t)
+	shutdown()
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"0"}, receiveBatch(t, ctx, sub)); diff != "" {
+		t.Errorf("expected the pending batch to be flushed on shutdown, -want +got: %s", diff)
+	}
+
+	// Payloads stored while draining are published right away.
+	if err := store(ctx, b, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"1"}, receiveBatch(t, ctx, sub)); diff != "" {
+		t.Errorf("-want +got: %s", diff)
+	}
+}
+
+func TestBackend_StorePayload_ConfigChange(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	oldName, newName := topicName(t, "old"), topicName(t, "new")
+	oldSub := subscribe(t, ctx, oldName)
+	newSub := subscribe(t, ctx, newName)
+	old, err := NewStorageBackend(ctx, batchConfig(oldName, 10, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- store(ctx, old, "0") }()
+	waitPending(t)
+
+	// The batch of the replaced publisher is published when the config changes.
+	updated, err := NewStorageBackend(ctx, batchConfig(newName, 1000, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store(ctx, updated, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"0"}, receiveBatch(t, ctx, oldSub)); diff != "" {
+		t.Errorf("-want +got: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"1"}, receiveBatch(t, ctx, newSub)); diff != "" {
+		t.Errorf("-want +got: %s", diff)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"gocloud.dev/gcerrors"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/batcher"
+	"gocloud.dev/pubsub/driver"
+)
+
+const (
+	PubSubProviderEventHubs = "eventhubs"
+
+	// eventHubsScope is the scope of the AAD tokens events are sent with.
+	eventHubsScope = "https://eventhubs.azure.net/.default"
+
+	// maxPartitionKey is the length of the longest partition key Event Hubs accepts.
+	maxPartitionKey = 128
+)
+
+var (
+	// eventHubsEndpoint returns the endpoint of the Event Hubs namespace. It is
+	// overridden in tests.
+	eventHubsEndpoint = func(namespace string) string {
+		return "https://" + namespace
+	}
+	// azureCredential returns the credential of the controller, the AAD workload
+	// identity of its service account, or its managed identity, like the other
+	// credentials of the Azure SDK. It is overridden in tests.
+	azureCredential = func() (azcore.TokenCredential, error) {
+		return azidentity.NewDefaultAzureCredential(nil)
+	}
+)
+
+// eventHub is a gocloud pubsub driver sending events to an event hub with its REST API.
+// The metadata of messages is sent as properties of the events, and the subject digests
+// of payloads select their partition.
+type eventHub struct {
+	// url is the URL the events of the event hub are sent to.
+	url   string
+	creds azcore.TokenCredential
+}
+
+// openEventHub opens the event hub named hub in the Event Hubs namespace, e.g.
+// chains.servicebus.windows.net.
+func openEventHub(namespace, hub string) (*pubsub.Topic, error) {
+	if namespace == "" || hub == "" {
+		return nil, fmt.Errorf("no event hub configured: namespace %q, topic %q", namespace, hub)
+	}
+	creds, err := azureCredential()
+	if err != nil {
+		return nil, fmt.Errorf("getting Azure credentials: %w", err)
+	}
+	h := &eventHub{
+		url:   strings.TrimSuffix(eventHubsEndpoint(namespace), "/") + "/" + url.PathEscape(hub) + "/messages",
+		creds: creds,
+	}
+	return pubsub.NewTopic(h, &batcher.Options{MaxBatchSize: 1, MaxHandlers: 2}), nil
+}
+
+// partitionKey returns the partition key of the events with the subject digests of their
+// payloads, so that the attestations of an artifact are consumed in order. Keys longer
+// than Event Hubs accepts, for payloads with several subjects, are hashed. Events
+// without subjects are spread over the partitions.
+func partitionKey(subjectDigest string) string {
+	if len(subjectDigest) <= maxPartitionKey {
+		return subjectDigest
+	}
+	sum := sha256.Sum256([]byte(subjectDigest))
+	return hex.EncodeToString(sum[:])
+}
+
+// SendBatch implements driver.Topic.
+func (h *eventHub) SendBatch(ctx context.Context, ms []*driver.Message) error {
+	for _, m := range ms {
+		if err := h.send(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send sends m as an event, holding the body of m, with the properties of its metadata.
+func (h *eventHub) send(ctx context.Context, m *driver.Message) error {
+	token, err := h.creds.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{eventHubsScope}})
+	if err != nil {
+		return fmt.Errorf("getting AAD token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, strings.NewReader(string(m.Body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	req.Header.Set("Content-Type", "application/atom+xml;type=entry;charset=utf-8")
+	if key := partitionKey(m.Metadata[SubjectDigestAttribute]); key != "" {
+		brokerProperties, err := json.Marshal(map[string]string{"PartitionKey": key})
+		if err != nil {
+			return err
+		}
+		req.Header.Set("BrokerProperties", string(brokerProperties))
+	}
+	// Properties are sent as headers named like them, with their values quoted.
+	for k, v := range m.Metadata {
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		req.Header[k] = []string{string(value)}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d sending to event hub %s: %s", resp.StatusCode, h.url, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// IsRetryable implements driver.Topic.
+func (h *eventHub) IsRetryable(error) bool { return false }
+
+// As implements driver.Topic.
+func (h *eventHub) As(interface{}) bool { return false }
+
+// ErrorAs implements driver.Topic.
+func (h *eventHub) ErrorAs(error, interface{}) bool { return false }
+
+// ErrorCode implements driver.Topic.
+func (h *eventHub) ErrorCode(error) gcerrors.ErrorCode { return gcerrors.Unknown }
+
+// Close implements driver.Topic.
+func (h *eventHub) Close() error { return nil }
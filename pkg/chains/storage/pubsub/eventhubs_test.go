@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+// staticToken is a credential returning the token "token" for the Event Hubs scope.
+type staticToken struct {
+	t *testing.T
+}
+
+func (s staticToken) GetToken(_ context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if diff := cmp.Diff([]string{eventHubsScope}, opts.Scopes); diff != "" {
+		s.t.Errorf("-want +got: %s", diff)
+	}
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+type event struct {
+	Path    string
+	Body    string
+	Headers map[string]string
+}
+
+// startEventHubs serves an Event Hubs namespace responding with status.
+func startEventHubs(t *testing.T, status int) *[]event {
+	t.Helper()
+	var events []event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		headers := map[string]string{}
+		for _, k := range []string{"Authorization", "BrokerProperties", PredicateTypeAttribute, SubjectDigestAttribute, PayloadFormatAttribute, RunKindAttribute, RunNamespaceAttribute, RunNameAttribute} {
+			if v := r.Header.Get(k); v != "" {
+				headers[k] = v
+			}
+		}
+		events = append(events, event{Path: r.URL.Path, Body: string(body), Headers: headers})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	endpoint, creds := eventHubsEndpoint, azureCredential
+	t.Cleanup(func() { eventHubsEndpoint, azureCredential = endpoint, creds })
+	eventHubsEndpoint = func(string) string { return srv.URL }
+	azureCredential = func() (azcore.TokenCredential, error) { return staticToken{t: t}, nil }
+	return &events
+}
+
+func storeEventHub(t *testing.T, payload string) error {
+	t.Helper()
+	ctx := logtesting.TestContextWithLogger(t)
+	b, err := NewStorageBackend(ctx, config.Config{Storage: config.StorageConfigs{PubSub: config.PubSubStorageConfig{
+		Provider:  PubSubProviderEventHubs,
+		Topic:     "attestations",
+		EventHubs: config.EventHubsStorageConfig{Namespace: "chains.servicebus.windows.net"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"}})
+	opts := config.StorageOpts{ShortKey: "taskrun-uid", PayloadFormat: formats.PayloadTypeSlsav1}
+	return b.StorePayload(ctx, obj, []byte(payload), "signature", opts)
+}
+
+func TestBackend_StorePayload_EventHubs(t *testing.T) {
+	events := startEventHubs(t, http.StatusCreated)
+
+	if err := storeEventHub(t, statement); err != nil {
+		t.Fatal(err)
+	}
+	want := []event{{
+		Path: "/attestations/messages",
+		Body: "signature",
+		Headers: map[string]string{
+			"Authorization":        "Bearer token",
+			"BrokerProperties":     `{"PartitionKey":"sha256:aaa,sha256:bbb"}`,
+			PredicateTypeAttribute: `"https://slsa.dev/provenance/v0.2"`,
+			SubjectDigestAttribute: `"sha256:aaa,sha256:bbb"`,
+			PayloadFormatAttribute: `"` + string(formats.PayloadTypeSlsav1) + `"`,
+			RunKindAttribute:       `"taskrun"`,
+			RunNamespaceAttribute:  `"bar"`,
+			RunNameAttribute:       `"foo"`,
+		},
+	}}
+	if diff := cmp.Diff(want, *events); diff != "" {
+		t.Errorf("-want +got: %s", diff)
+	}
+}
+
+func TestBackend_StorePayload_EventHubsNoSubjects(t *testing.T) {
+	events := startEventHubs(t, http.StatusCreated)
+
+	// Events of payloads without subjects have no partition key.
+	if err := storeEventHub(t, "payload"); err != nil {
+		t.Fatal(err)
+	}
+	if got := (*events)[0].Headers["BrokerProperties"]; got != "" {
+		t.Errorf("expected no partition key, got %s", got)
+	}
+}
+
+func TestBackend_StorePayload_EventHubsError(t *testing.T) {
+	startEventHubs(t, http.StatusUnauthorized)
+
+	if err := storeEventHub(t, statement); err == nil || !strings.Contains(err.Error(), "unexpected status 401") {
+		t.Errorf("expected the status to be returned, got %v", err)
+	}
+}
+
+func TestPartitionKey(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	if got := partitionKey(digest); got != digest {
+		t.Errorf("expected the digest as partition key, got %q", got)
+	}
+	// The digests of several subjects are too long for a partition key.
+	digests := digest + "," + digest
+	got := partitionKey(digests)
+	if len(got) > maxPartitionKey || got != partitionKey(digests) {
+		t.Errorf("expected a stable partition key of at most %d characters, got %q", maxPartitionKey, got)
+	}
+}
@@ -0,0 +1,389 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage/pubsub/collectorpb"
+	"github.com/tektoncd/chains/pkg/config"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	PubSubProviderGRPC = "grpc"
+
+	// DefaultMaxInFlight is the number of payloads the collector may not have
+	// acknowledged when the config doesn't say.
+	DefaultMaxInFlight = 1000
+
+	// DefaultStreamTimeout is how long payloads wait for the collector to acknowledge
+	// them when the config doesn't say.
+	DefaultStreamTimeout = time.Minute
+
+	// minReconnectDelay and maxReconnectDelay bound the delay before a broken stream
+	// is opened again, which doubles with each stream that breaks in a row.
+	minReconnectDelay = 100 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+)
+
+// errStreamClosed is returned when the collector ends the stream.
+var errStreamClosed = errors.New("stream closed by the collector")
+
+type streamEntry struct {
+	// ctx is the context of the caller, once done the payload is no longer sent.
+	ctx  context.Context
+	req  *collectorpb.PublishRequest
+	done chan error
+}
+
+// streamer streams the payloads sent to a collector over a long-lived stream, opening
+// a new stream when it breaks.
+type streamer struct {
+	cfg    config.GRPCStorageConfig
+	conn   *grpc.ClientConn
+	client collectorpb.CollectorClient
+	logger *zap.SugaredLogger
+
+	// entries are the payloads to send. They are only taken while fewer than
+	// maxInFlight payloads are waiting to be acknowledged, which holds back callers.
+	entries     chan *streamEntry
+	maxInFlight int
+	nextID      atomic.Uint64
+
+	// mu is held for reading while payloads are added, and for writing once closing,
+	// after which no payloads are added.
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}
+	stopped chan struct{}
+}
+
+var (
+	streamersMu     sync.Mutex
+	currentStreamer *streamer
+)
+
+// getStreamer returns the streamer for cfg, connecting to its collector if the current
+// streamer is for another config. The replaced streamer sends the payloads it was
+// given before its connection is closed.
+func getStreamer(ctx context.Context, cfg config.GRPCStorageConfig) (*streamer, error) {
+	streamersMu.Lock()
+	defer streamersMu.Unlock()
+	if currentStreamer != nil && currentStreamer.cfg == cfg {
+		return currentStreamer, nil
+	}
+	conn, err := dialCollector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if currentStreamer != nil {
+		go currentStreamer.close()
+	}
+	currentStreamer = newStreamer(ctx, cfg, conn)
+	return currentStreamer, nil
+}
+
+func dialCollector(cfg config.GRPCStorageConfig) (*grpc.ClientConn, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("no collector address configured for the grpc pubsub provider")
+	}
+	creds := insecure.NewCredentials()
+	if !cfg.Insecure {
+		tlsConfig, err := clientTLSConfig("collector", cfg.CAPath)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	return grpc.Dial(cfg.Address, grpc.WithTransportCredentials(creds))
+}
+
+// clientTLSConfig returns the TLS config of connections to server, verifying its
+// certificate with the PEM bundle at caPath, or the system roots if it is empty.
+func clientTLSConfig(server, caPath string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caPath != "" {
+		pem, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s CA bundle: %w", server, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s CA bundle %s", server, caPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+func newStreamer(ctx context.Context, cfg config.GRPCStorageConfig, conn *grpc.ClientConn) *streamer {
+	s := &streamer{
+		cfg:         cfg,
+		conn:        conn,
+		client:      collectorpb.NewCollectorClient(conn),
+		logger:      logging.FromContext(ctx),
+		entries:     make(chan *streamEntry),
+		maxInFlight: cfg.MaxInFlight,
+		closing:     make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	if s.maxInFlight <= 0 {
+		s.maxInFlight = DefaultMaxInFlight
+	}
+	go s.run()
+	return s
+}
+
+// add sends req to the collector, and waits until the collector acknowledged it.
+func (s *streamer) add(ctx context.Context, req *collectorpb.PublishRequest) error {
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		return errClosed
+	}
+	req.Id = s.nextID.Add(1)
+	e := &streamEntry{ctx: ctx, req: req, done: make(chan error, 1)}
+	select {
+	case s.entries <- e:
+	case <-s.closing:
+		s.mu.RUnlock()
+		return errClosed
+	case <-ctx.Done():
+		s.mu.RUnlock()
+		return ctx.Err()
+	}
+	s.mu.RUnlock()
+
+	select {
+	case err := <-e.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close sends the payloads left and closes the connection to the collector.
+func (s *streamer) close() {
+	close(s.closing)
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	<-s.stopped
+	_ = s.conn.Close()
+}
+
+// run streams the payloads until the streamer is closed. Streams are only opened once
+// there are payloads to send, and opened again after a delay when they break, sending
+// the payloads that weren't acknowledged first.
+func (s *streamer) run() {
+	defer close(s.stopped)
+	var unacked []*streamEntry
+	delay := minReconnectDelay
+	for {
+		unacked = waiting(unacked)
+		if len(unacked) == 0 {
+			select {
+			case e := <-s.entries:
+				unacked = append(unacked, e)
+			case <-s.closing:
+				return
+			}
+		}
+
+		left, acked, err := s.serve(unacked)
+		unacked = left
+		if err == nil {
+			return
+		}
+		if acked {
+			delay = minReconnectDelay
+		}
+		// Once closing, the payloads left are still sent until their callers stop waiting.
+		select {
+		case <-s.closing:
+			if len(waiting(unacked)) == 0 {
+				return
+			}
+		default:
+		}
+		s.logger.Warnf("Stream to collector %s broke with %d payloads not acknowledged, reconnecting in %s: %v", s.cfg.Address, len(unacked), delay, err)
+		time.Sleep(delay)
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// serve sends unacked and then the payloads added over one stream, until it breaks or
+// the streamer is closed and all the payloads were acknowledged. It returns the
+// payloads that weren't acknowledged, whether any payload was, and why the stream
+// broke, or nil once the streamer was closed.
+func (s *streamer) serve(unacked []*streamEntry) ([]*streamEntry, bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := s.client.Publish(ctx)
+	if err != nil {
+		return unacked, false, err
+	}
+	// There are never more acknowledgements than payloads in flight, so receiving
+	// them never blocks, even while sending is held back by flow control.
+	acks := make(chan uint64, s.maxInFlight)
+	broken := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				err = errStreamClosed
+			}
+			if err != nil {
+				broken <- err
+				return
+			}
+			select {
+			case acks <- resp.GetId():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	inFlight := map[uint64]*streamEntry{}
+	pending := unacked
+	acked := false
+	closing := s.closing
+	// Once closing, the stream is closed when no caller waits for its payloads anymore.
+	var abandoned <-chan time.Time
+	// left returns the payloads that weren't acknowledged, in the order they were added.
+	left := func() []*streamEntry {
+		entries := make([]*streamEntry, 0, len(inFlight)+len(pending))
+		for _, e := range inFlight {
+			entries = append(entries, e)
+		}
+		entries = append(entries, pending...)
+		sort.Slice(entries, func(i, j int) bool { return entries[i].req.Id < entries[j].req.Id })
+		return entries
+	}
+	for {
+		for len(pending) > 0 && len(inFlight) < s.maxInFlight {
+			e := pending[0]
+			pending = pending[1:]
+			if e.ctx.Err() != nil {
+				continue
+			}
+			inFlight[e.req.Id] = e
+			if err := stream.Send(e.req); err != nil {
+				return left(), acked, err
+			}
+		}
+
+		var entries <-chan *streamEntry
+		if closing != nil && len(inFlight) < s.maxInFlight {
+			entries = s.entries
+		}
+		if closing == nil && len(inFlight) == 0 && len(pending) == 0 {
+			return nil, acked, stream.CloseSend()
+		}
+
+		select {
+		case e := <-entries:
+			pending = append(pending, e)
+		case id := <-acks:
+			if e, ok := inFlight[id]; ok {
+				delete(inFlight, id)
+				e.done <- nil
+				acked = true
+			}
+		case err := <-broken:
+			return left(), acked, err
+		case <-closing:
+			closing = nil
+			ticker := time.NewTicker(minReconnectDelay)
+			defer ticker.Stop()
+			abandoned = ticker.C
+		case <-abandoned:
+			if len(waiting(left())) == 0 {
+				return nil, acked, nil
+			}
+		}
+	}
+}
+
+// waiting returns the entries whose callers still wait for them to be acknowledged.
+func waiting(entries []*streamEntry) []*streamEntry {
+	var w []*streamEntry
+	for _, e := range entries {
+		if e.ctx.Err() == nil {
+			w = append(w, e)
+		}
+	}
+	return w
+}
+
+// storeStreamed streams the payload to the collector of the config, and waits until
+// the collector acknowledged it.
+func (b *Backend) storeStreamed(ctx context.Context, obj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
+	pctx := b.ctx
+	if pctx == nil {
+		pctx = context.Background()
+	}
+	cfg := b.cfg.Storage.PubSub.GRPC
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultStreamTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		s, err := getStreamer(pctx, cfg)
+		if err != nil {
+			return err
+		}
+		req := &collectorpb.PublishRequest{
+			Payload:   rawPayload,
+			Signature: []byte(signature),
+			Kind:      obj.GetKindName(),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Uid:       string(obj.GetUID()),
+			Key:       opts.ShortKey,
+			Format:    string(opts.PayloadFormat),
+		}
+		// The streamer is closed if the config changed since it was returned.
+		err = s.add(ctx, req)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("collector %s didn't acknowledge the payload within %s: %w", cfg.Address, timeout, err)
+		}
+		if !errors.Is(err, errClosed) {
+			return err
+		}
+	}
+}
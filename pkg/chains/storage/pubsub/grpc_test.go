@@ -0,0 +1,239 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage/pubsub/collectorpb"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+// collector acknowledges the payloads it receives, unless handle returns an error, which
+// breaks the stream. handle is called with the number of payloads received so far.
+type collector struct {
+	collectorpb.UnimplementedCollectorServer
+
+	handle func(ctx context.Context, n int) error
+
+	mu       sync.Mutex
+	received []*collectorpb.PublishRequest
+}
+
+func (c *collector) Publish(stream collectorpb.Collector_PublishServer) error {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		c.mu.Lock()
+		c.received = append(c.received, req)
+		n := len(c.received)
+		c.mu.Unlock()
+		if c.handle != nil {
+			if err := c.handle(stream.Context(), n); err != nil {
+				return err
+			}
+		}
+		if err := stream.Send(&collectorpb.PublishResponse{Id: req.GetId()}); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *collector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.received)
+}
+
+// startCollector serves c and returns its address. The streamer of the test is closed
+// before c is stopped.
+func startCollector(t *testing.T, c *collector) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	collectorpb.RegisterCollectorServer(srv, c)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	t.Cleanup(func() {
+		streamersMu.Lock()
+		defer streamersMu.Unlock()
+		if currentStreamer != nil {
+			currentStreamer.close()
+			currentStreamer = nil
+		}
+	})
+	return ln.Addr().String()
+}
+
+func grpcConfig(address string, maxInFlight int, timeout time.Duration) config.Config {
+	return config.Config{Storage: config.StorageConfigs{PubSub: config.PubSubStorageConfig{
+		Provider: PubSubProviderGRPC,
+		GRPC: config.GRPCStorageConfig{
+			Address:     address,
+			Insecure:    true,
+			MaxInFlight: maxInFlight,
+			Timeout:     timeout,
+		},
+	}}}
+}
+
+// waitReceived waits until c received n payloads.
+func waitReceived(t *testing.T, c *collector, n int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for c.count() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d payloads, got %d", n, c.count())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBackend_StorePayload_GRPC(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	c := &collector{}
+	b, err := NewStorageBackend(ctx, grpcConfig(startCollector(t, c), 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"}})
+	opts := config.StorageOpts{ShortKey: "taskrun-uid", PayloadFormat: formats.PayloadTypeSlsav1}
+	if err := b.StorePayload(ctx, obj, []byte("payload"), "signature", opts); err != nil {
+		t.Fatal(err)
+	}
+	want := []*collectorpb.PublishRequest{{
+		Id:        1,
+		Payload:   []byte("payload"),
+		Signature: []byte("signature"),
+		Kind:      "taskrun",
+		Namespace: "bar",
+		Name:      "foo",
+		Uid:       "uid",
+		Key:       "taskrun-uid",
+		Format:    string(formats.PayloadTypeSlsav1),
+	}}
+	if diff := cmp.Diff(want, c.received, protocmp.Transform()); diff != "" {
+		t.Errorf("-want +got: %s", diff)
+	}
+
+	// Payloads are sent over the same stream.
+	if err := store(ctx, b, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.count(); got != 2 {
+		t.Errorf("expected 2 payloads, got %d", got)
+	}
+}
+
+func TestBackend_StorePayload_GRPCReconnect(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	// The stream breaks before the first payload is acknowledged.
+	c := &collector{handle: func(_ context.Context, n int) error {
+		if n == 1 {
+			return status.Error(codes.Unavailable, "restarting")
+		}
+		return nil
+	}}
+	b, err := NewStorageBackend(ctx, grpcConfig(startCollector(t, c), 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store(ctx, b, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.count(); got != 2 {
+		t.Fatalf("expected the payload to be sent again, got %d payloads", got)
+	}
+	if first, second := c.received[0], c.received[1]; first.GetId() != second.GetId() {
+		t.Errorf("expected the payload to be sent again with id %d, got %d", first.GetId(), second.GetId())
+	}
+}
+
+func TestBackend_StorePayload_GRPCBackpressure(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	// The first payload is only acknowledged once released.
+	release := make(chan struct{})
+	c := &collector{handle: func(ctx context.Context, n int) error {
+		if n == 1 {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+		}
+		return nil
+	}}
+	b, err := NewStorageBackend(ctx, grpcConfig(startCollector(t, c), 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- store(ctx, b, "0") }()
+	waitReceived(t, c, 1)
+	go func() { errs <- store(ctx, b, "1") }()
+
+	// The second payload waits until the first was acknowledged.
+	time.Sleep(50 * time.Millisecond)
+	if got := c.count(); got != 1 {
+		t.Errorf("expected the second payload to wait, got %d payloads", got)
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.count(); got != 2 {
+		t.Errorf("expected 2 payloads, got %d", got)
+	}
+}
+
+func TestBackend_StorePayload_GRPCTimeout(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	// The payloads are never acknowledged.
+	c := &collector{handle: func(ctx context.Context, _ int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	b, err := NewStorageBackend(ctx, grpcConfig(startCollector(t, c), 0, 50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store(ctx, b, "0"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the payload to time out, got %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+const (
+	PubSubProviderMQTT = "mqtt"
+
+	// DefaultMQTTQoS is the quality of service payloads are published with when the
+	// config doesn't say, at least once.
+	DefaultMQTTQoS = 1
+
+	// mqttKeepAlive is the keep alive of connections to the broker, which the client
+	// pings when they are idle.
+	mqttKeepAlive = time.Minute
+
+	// mqttTimeout is how long publishing a payload waits for the broker when the
+	// context has no deadline.
+	mqttTimeout = 30 * time.Second
+)
+
+var (
+	// topicVariable matches the variables of topic templates, like $(run.namespace).
+	topicVariable = regexp.MustCompile(`\$\(([^()]+)\)`)
+	// invalidTopicChars matches the characters values can't contain in a topic level.
+	invalidTopicChars = regexp.MustCompile(`[/+#\x00]`)
+)
+
+// renderTopic returns the topic of the payloads of obj for template, in which the
+// variables $(run.kind), $(run.namespace), $(run.name), $(run.uid) and
+// $(payload.format) are substituted. The separators and wildcards of topics are
+// replaced with "-" in values.
+func renderTopic(template string, obj objects.TektonObject, opts config.StorageOpts) (string, error) {
+	values := map[string]string{
+		"run.kind":       obj.GetKindName(),
+		"run.namespace":  obj.GetNamespace(),
+		"run.name":       obj.GetName(),
+		"run.uid":        string(obj.GetUID()),
+		"payload.format": string(opts.PayloadFormat),
+	}
+	var missing []string
+	topic := topicVariable.ReplaceAllStringFunc(template, func(v string) string {
+		key := strings.TrimSpace(topicVariable.FindStringSubmatch(v)[1])
+		value := values[key]
+		if value == "" {
+			missing = append(missing, key)
+		}
+		return invalidTopicChars.ReplaceAllString(value, "-")
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("no value for %s in topic %q", strings.Join(missing, ", "), template)
+	}
+	if topic == "" {
+		return "", errors.New("no topic configured for the mqtt pubsub provider")
+	}
+	return topic, nil
+}
+
+// mqttClient publishes to a broker with a paho client, which keeps its connection
+// alive and connects again once it broke. Its session isn't clean, so the payloads of
+// qos 1 and 2 that weren't acknowledged when the connection broke are kept by the
+// client and published again once it is back, flagged as duplicates.
+type mqttClient struct {
+	cfg    config.MQTTStorageConfig
+	client mqtt.Client
+
+	// connectMu serializes the first connection, and the ones after it failed.
+	connectMu sync.Mutex
+}
+
+var (
+	mqttClientsMu     sync.Mutex
+	currentMQTTClient *mqttClient
+)
+
+// getMQTTClient returns the client for cfg, replacing the current client if it is for
+// another config.
+func getMQTTClient(cfg config.MQTTStorageConfig) (*mqttClient, error) {
+	mqttClientsMu.Lock()
+	defer mqttClientsMu.Unlock()
+	if currentMQTTClient != nil && currentMQTTClient.cfg == cfg {
+		return currentMQTTClient, nil
+	}
+	c, err := newMQTTClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if currentMQTTClient != nil {
+		go currentMQTTClient.close()
+	}
+	currentMQTTClient = c
+	return c, nil
+}
+
+// newMQTTClient returns a client of the broker of cfg, which connects when payloads are
+// first published.
+func newMQTTClient(cfg config.MQTTStorageConfig) (*mqttClient, error) {
+	broker, secure, err := mqttBroker(cfg.Broker)
+	if err != nil {
+		return nil, err
+	}
+	clientID := cfg.ClientID
+	if clientID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("no MQTT client id configured: %w", err)
+		}
+		clientID = hostname
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientID).
+		SetProtocolVersion(4).
+		SetCleanSession(false).
+		SetOrderMatters(false).
+		SetKeepAlive(mqttKeepAlive).
+		SetConnectTimeout(mqttTimeout).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(time.Minute)
+	if secure {
+		tlsConfig, err := clientTLSConfig("MQTT broker", cfg.CAPath)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+	if cfg.CredentialsPath != "" {
+		// The credentials are read again for each connection, so they can be rotated.
+		user, password, err := mqttCredentials(cfg.CredentialsPath)
+		if err != nil {
+			return nil, err
+		}
+		var mu sync.Mutex
+		opts.SetCredentialsProvider(func() (string, string) {
+			mu.Lock()
+			defer mu.Unlock()
+			if u, p, err := mqttCredentials(cfg.CredentialsPath); err == nil {
+				user, password = u, p
+			}
+			return user, password
+		})
+	}
+	return &mqttClient{cfg: cfg, client: mqtt.NewClient(opts)}, nil
+}
+
+// mqttBroker returns the URL of broker with the default port of its scheme if it has
+// none, and whether it is connected to with TLS.
+func mqttBroker(broker string) (string, bool, error) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return "", false, fmt.Errorf("invalid MQTT broker %q, expected e.g. tcp://broker:1883", broker)
+	}
+	secure := false
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		secure = true
+	default:
+		return "", false, fmt.Errorf("unsupported scheme %q of MQTT broker %s", u.Scheme, broker)
+	}
+	if u.Port() == "" {
+		port := "1883"
+		if secure {
+			port = "8883"
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u.String(), secure, nil
+}
+
+// mqttCredentials reads the user and password of the file at path, as user:password.
+func mqttCredentials(path string) (string, string, error) {
+	userInfo, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("reading MQTT credentials: %w", err)
+	}
+	user, password, _ := strings.Cut(strings.TrimSpace(string(userInfo)), ":")
+	return user, password, nil
+}
+
+// publish publishes payload on topic with qos, waiting for the acknowledgements of
+// qos. It connects to the broker first if the client isn't connected, or connecting
+// again after its connection broke.
+func (c *mqttClient) publish(ctx context.Context, topic string, payload []byte, qos byte) error {
+	if err := c.connect(ctx); err != nil {
+		return fmt.Errorf("connecting to MQTT broker %s: %w", c.cfg.Broker, err)
+	}
+	if err := waitToken(ctx, c.client.Publish(topic, qos, false, payload)); err != nil {
+		return fmt.Errorf("publishing to MQTT broker %s: %w", c.cfg.Broker, err)
+	}
+	return nil
+}
+
+func (c *mqttClient) connect(ctx context.Context) error {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+	if c.client.IsConnected() {
+		return nil
+	}
+	return waitToken(ctx, c.client.Connect())
+}
+
+func (c *mqttClient) close() {
+	c.client.Disconnect(uint(time.Second / time.Millisecond))
+}
+
+// waitToken waits for t to complete, until ctx is done or for mqttTimeout if ctx has no
+// deadline.
+func waitToken(ctx context.Context, t mqtt.Token) error {
+	timeout := mqttTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-t.Done():
+		return t.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return errors.New("timed out waiting for the broker")
+	}
+}
+
+// storeMQTT publishes the payload and its signature to the broker of the config, on
+// the topic rendered for obj. The message is a JSON object like the entries of
+// batches, as MQTT 3.1.1 messages have no metadata.
+func (b *Backend) storeMQTT(ctx context.Context, obj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) error {
+	cfg := b.cfg.Storage.PubSub.MQTT
+	topic, err := renderTopic(b.cfg.Storage.PubSub.Topic, obj, opts)
+	if err != nil {
+		return err
+	}
+	qos := byte(DefaultMQTTQoS)
+	if cfg.QoS != "" {
+		qos = cfg.QoS[0] - '0'
+	}
+	body, err := json.Marshal(BatchEntry{
+		Payload:   base64.StdEncoding.EncodeToString(rawPayload),
+		Signature: signature,
+	})
+	if err != nil {
+		return err
+	}
+	client, err := getMQTTClient(cfg)
+	if err != nil {
+		return err
+	}
+	return client.publish(ctx, topic, body, qos)
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+// The types of the MQTT 3.1.1 control packets, in the high bits of their first byte.
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttPubrec     = 5
+	mqttPubrel     = 6
+	mqttPubcomp    = 7
+	mqttPingreq    = 12
+	mqttPingresp   = 13
+	mqttDisconnect = 14
+)
+
+// packet returns the control packet with the first byte header and body.
+func packet(header byte, body []byte) []byte {
+	b := []byte{header}
+	// The remaining length is encoded 7 bits at a time, least significant first.
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(b, body...)
+}
+
+// readPacket reads a control packet, returning its type and its body.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		n += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+type mqttMessage struct {
+	ClientID string
+	User     string
+	Topic    string
+	QoS      byte
+	Dup      bool
+	Payload  string
+}
+
+// broker accepts MQTT connections, refusing them with connackCode if it isn't 0, and
+// acknowledges the messages it receives, unless drop returns true for the number of
+// messages received so far, which closes the connection.
+type broker struct {
+	connackCode byte
+	drop        func(n int) bool
+
+	mu        sync.Mutex
+	published []mqttMessage
+	conns     int
+}
+
+func (b *broker) messages() []mqttMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]mqttMessage(nil), b.published...)
+}
+
+func (b *broker) connections() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conns
+}
+
+// waitMessages waits until b received n messages, as messages published with qos 0
+// aren't acknowledged.
+func waitMessages(t *testing.T, b *broker, n int) []mqttMessage {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for len(b.messages()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d messages, got %d", n, len(b.messages()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return b.messages()
+}
+
+// startBroker serves b and returns its URL. The client of the test is closed before b
+// is stopped.
+func startBroker(t *testing.T, b *broker) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	t.Cleanup(func() {
+		mqttClientsMu.Lock()
+		defer mqttClientsMu.Unlock()
+		if currentMQTTClient != nil {
+			currentMQTTClient.close()
+			currentMQTTClient = nil
+		}
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return "tcp://" + ln.Addr().String()
+}
+
+func (b *broker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	typ, body, err := readPacket(r)
+	if err != nil || typ != mqttConnect {
+		return
+	}
+	// The protocol name, level, flags and keep alive precede the client id.
+	flags := body[7]
+	rest := body[10:]
+	next := func() string {
+		n := binary.BigEndian.Uint16(rest)
+		s := string(rest[2 : 2+n])
+		rest = rest[2+n:]
+		return s
+	}
+	clientID, user := next(), ""
+	if flags&0x80 != 0 {
+		user = next()
+	}
+	b.mu.Lock()
+	b.conns++
+	b.mu.Unlock()
+	if _, err := conn.Write(packet(mqttConnack<<4, []byte{0, b.connackCode})); err != nil || b.connackCode != 0 {
+		return
+	}
+
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		_ = r.UnreadByte()
+		typ, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch typ {
+		case mqttPublish:
+			qos := (header >> 1) & 0x03
+			n := binary.BigEndian.Uint16(body)
+			topic, body := string(body[2:2+n]), body[2+n:]
+			var id []byte
+			if qos > 0 {
+				id, body = body[:2], body[2:]
+			}
+			b.mu.Lock()
+			b.published = append(b.published, mqttMessage{ClientID: clientID, User: user, Topic: topic, QoS: qos, Dup: header&0x08 != 0, Payload: string(body)})
+			count := len(b.published)
+			b.mu.Unlock()
+			if b.drop != nil && b.drop(count) {
+				return
+			}
+			switch qos {
+			case 1:
+				_, _ = conn.Write(packet(mqttPuback<<4, id))
+			case 2:
+				_, _ = conn.Write(packet(mqttPubrec<<4, id))
+			}
+		case mqttPubrel:
+			_, _ = conn.Write(packet(mqttPubcomp<<4, body))
+		case mqttPingreq:
+			_, _ = conn.Write(packet(mqttPingresp<<4, nil))
+		case mqttDisconnect:
+			return
+		}
+	}
+}
+
+func storeMQTT(t *testing.T, cfg config.PubSubStorageConfig) error {
+	t.Helper()
+	ctx := logtesting.TestContextWithLogger(t)
+	cfg.Provider = PubSubProviderMQTT
+	b, err := NewStorageBackend(ctx, config.Config{Storage: config.StorageConfigs{PubSub: cfg}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"}})
+	opts := config.StorageOpts{ShortKey: "taskrun-uid", PayloadFormat: formats.PayloadTypeSlsav1}
+	return b.StorePayload(ctx, obj, []byte("payload"), "signature", opts)
+}
+
+func TestBackend_StorePayload_MQTT(t *testing.T) {
+	body, err := json.Marshal(BatchEntry{Payload: base64.StdEncoding.EncodeToString([]byte("payload")), Signature: "signature"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		qos     string
+		wantQoS byte
+	}{
+		{qos: "", wantQoS: DefaultMQTTQoS},
+		{qos: "0", wantQoS: 0},
+		{qos: "2", wantQoS: 2},
+	} {
+		t.Run("qos "+tc.qos, func(t *testing.T) {
+			b := &broker{}
+			credentials := filepath.Join(t.TempDir(), "credentials")
+			if err := os.WriteFile(credentials, []byte("edge:secret\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg := config.PubSubStorageConfig{
+				Topic: "chains/$(run.namespace)/$(run.kind)",
+				MQTT: config.MQTTStorageConfig{
+					Broker:          startBroker(t, b),
+					QoS:             tc.qos,
+					ClientID:        "edge-1",
+					CredentialsPath: credentials,
+				},
+			}
+			if err := storeMQTT(t, cfg); err != nil {
+				t.Fatal(err)
+			}
+			// Payloads are published over the same connection.
+			if err := storeMQTT(t, cfg); err != nil {
+				t.Fatal(err)
+			}
+			want := mqttMessage{ClientID: "edge-1", User: "edge", Topic: "chains/bar/taskrun", QoS: tc.wantQoS, Payload: string(body)}
+			if diff := cmp.Diff([]mqttMessage{want, want}, waitMessages(t, b, 2)); diff != "" {
+				t.Errorf("-want +got: %s", diff)
+			}
+			if got := b.connections(); got != 1 {
+				t.Errorf("expected 1 connection, got %d", got)
+			}
+		})
+	}
+}
+
+func TestBackend_StorePayload_MQTTReconnect(t *testing.T) {
+	// The connection breaks before the second message is acknowledged.
+	b := &broker{drop: func(n int) bool { return n == 2 }}
+	cfg := config.PubSubStorageConfig{Topic: "chains", MQTT: config.MQTTStorageConfig{Broker: startBroker(t, b), ClientID: "edge-1"}}
+	for i := 0; i < 2; i++ {
+		if err := storeMQTT(t, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The client publishes the message again once it is connected again.
+	got := waitMessages(t, b, 3)
+	if len(got) != 3 {
+		t.Fatalf("expected the second message to be published again, got %d messages", len(got))
+	}
+	if got[1].Dup || !got[2].Dup {
+		t.Errorf("expected the message published again to be a duplicate, got %+v", got)
+	}
+}
+
+func TestBackend_StorePayload_MQTTRefused(t *testing.T) {
+	b := &broker{connackCode: 5}
+	cfg := config.PubSubStorageConfig{Topic: "chains", MQTT: config.MQTTStorageConfig{Broker: startBroker(t, b), ClientID: "edge-1"}}
+	if err := storeMQTT(t, cfg); err == nil || !strings.Contains(strings.ToLower(err.Error()), "not authorized") {
+		t.Errorf("expected the connection to be refused, got %v", err)
+	}
+}
+
+func TestRenderTopic(t *testing.T) {
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"}})
+	opts := config.StorageOpts{PayloadFormat: formats.PayloadTypeSlsav1}
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{template: "chains", want: "chains"},
+		{template: "chains/$(run.namespace)/$(run.name)/$(run.uid)", want: "chains/bar/foo/uid"},
+		// The separators of levels in values are replaced.
+		{template: "chains/$(payload.format)", want: "chains/slsa-v1"},
+		{template: "chains/$(params.version)", wantErr: true},
+		{template: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := renderTopic(tt.template, obj, opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("renderTopic(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("renderTopic(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
@@ -16,11 +16,15 @@ package pubsub
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/tektoncd/chains/pkg/chains/objects"
 	"github.com/tektoncd/chains/pkg/config"
 	"gocloud.dev/pubsub/kafkapubsub"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/logging"
 
 	"gocloud.dev/pubsub"
@@ -31,18 +35,29 @@ const (
 	StorageBackendPubSub   = "pubsub"
 	PubSubProviderInMemory = "inmemory"
 	PubSubProviderKafka    = "kafka"
+
+	// The attributes of the messages published to SNS topics, SQS queues and event hubs.
+	PredicateTypeAttribute = "predicateType"
+	SubjectDigestAttribute = "subjectDigest"
+	PayloadFormatAttribute = "payloadFormat"
+	RunKindAttribute       = "runKind"
+	RunNamespaceAttribute  = "runNamespace"
+	RunNameAttribute       = "runName"
 )
 
 // Backend is a storage backend that stores signed payloads in the TaskRun metadata as an annotation.
 // It is stored as base64 encoded JSON.
 type Backend struct {
 	cfg config.Config
+	// ctx is the context the controller runs with, once done batches are flushed.
+	ctx context.Context
 }
 
 // NewStorageBackend returns a new Tekton StorageBackend that stores signatures on a TaskRun
 func NewStorageBackend(ctx context.Context, cfg config.Config) (*Backend, error) {
 	return &Backend{
 		cfg: cfg,
+		ctx: ctx,
 	}, nil
 }
 
@@ -54,6 +69,16 @@ func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, ra
 	logger := logging.FromContext(ctx)
 	logger.Infof("Storing payload on Object %s/%s", obj.GetNamespace(), obj.GetName())
 
+	switch b.cfg.Storage.PubSub.Provider {
+	case PubSubProviderGRPC:
+		return b.storeStreamed(ctx, obj, rawPayload, signature, opts)
+	case PubSubProviderMQTT:
+		return b.storeMQTT(ctx, obj, rawPayload, signature, opts)
+	}
+	if b.cfg.Storage.PubSub.BatchSize > 1 {
+		return b.storeBatched(ctx, rawPayload, signature)
+	}
+
 	// Construct a *pubsub.Topic.
 	topic, err := b.NewTopic(ctx)
 	if err != nil {
@@ -65,19 +90,104 @@ func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, ra
 		}
 	}()
 
-	// Send the message with the DSSE signature.
-	err = topic.Send(ctx, &pubsub.Message{
-		Body: []byte(signature),
-		Metadata: map[string]string{
-			"payload":   base64.StdEncoding.EncodeToString(rawPayload),
-			"signature": signature,
-		},
-	})
+	msg, err := b.message(ctx, obj, rawPayload, signature, opts)
 	if err != nil {
 		return err
 	}
+	return topic.Send(ctx, msg)
+}
+
+// message returns the message of the payload. SNS topics, SQS queues and event hubs get
+// the DSSE signature with the attributes of the payload, which isn't duplicated in the
+// attributes as their messages are small. Other topics get the envelope of the payload
+// encoded with the schemas of the schema registry when one is configured, and otherwise
+// the DSSE signature, with the payload and signature in its metadata.
+func (b *Backend) message(ctx context.Context, obj objects.TektonObject, rawPayload []byte, signature string, opts config.StorageOpts) (*pubsub.Message, error) {
+	switch b.cfg.Storage.PubSub.Provider {
+	case PubSubProviderSNS, PubSubProviderSQS, PubSubProviderEventHubs:
+		return &pubsub.Message{Body: []byte(signature), Metadata: attributes(obj, rawPayload, opts)}, nil
+	}
+	if b.cfg.Storage.PubSub.SchemaRegistry.URL == "" {
+		return &pubsub.Message{
+			Body: []byte(signature),
+			Metadata: map[string]string{
+				"payload":   base64.StdEncoding.EncodeToString(rawPayload),
+				"signature": signature,
+			},
+		}, nil
+	}
+	body, err := encodeEnvelope(ctx, b.cfg.Storage.PubSub, Envelope{
+		Payload:   rawPayload,
+		Signature: []byte(signature),
+		Kind:      obj.GetKindName(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		UID:       string(obj.GetUID()),
+		Key:       opts.ShortKey,
+		Format:    string(opts.PayloadFormat),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pubsub.Message{Body: body}, nil
+}
+
+// attributes returns the attributes of the payload of obj, for subscriptions to filter
+// on. In-toto statements have the predicate type and the digests of their subjects,
+// sorted and comma separated like sha256:<hex>. Empty attributes are omitted, as SNS and
+// SQS reject them.
+func attributes(obj objects.TektonObject, rawPayload []byte, opts config.StorageOpts) map[string]string {
+	attributes := map[string]string{
+		PayloadFormatAttribute: string(opts.PayloadFormat),
+		RunKindAttribute:       obj.GetKindName(),
+		RunNamespaceAttribute:  obj.GetNamespace(),
+		RunNameAttribute:       obj.GetName(),
+	}
+	var statement struct {
+		PredicateType string `json:"predicateType"`
+		Subject       []struct {
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(rawPayload, &statement); err == nil {
+		attributes[PredicateTypeAttribute] = statement.PredicateType
+		digests := sets.New[string]()
+		for _, s := range statement.Subject {
+			for alg, digest := range s.Digest {
+				digests.Insert(alg + ":" + digest)
+			}
+		}
+		attributes[SubjectDigestAttribute] = strings.Join(sets.List(digests), ",")
+	}
+	for k, v := range attributes {
+		if v == "" {
+			delete(attributes, k)
+		}
+	}
+	return attributes
+}
 
-	return nil
+// storeBatched adds the payload to the batch being filled for the configured topic,
+// and waits until the batch was published.
+func (b *Backend) storeBatched(ctx context.Context, rawPayload []byte, signature string) error {
+	pctx := b.ctx
+	if pctx == nil {
+		pctx = context.Background()
+	}
+	entry := BatchEntry{
+		Payload:   base64.StdEncoding.EncodeToString(rawPayload),
+		Signature: signature,
+	}
+	for {
+		p, err := getPublisher(pctx, b.cfg.Storage.PubSub, b.NewTopic)
+		if err != nil {
+			return err
+		}
+		// The publisher is closed if the config changed since it was returned.
+		if err := p.add(ctx, entry); !errors.Is(err, errClosed) {
+			return err
+		}
+	}
 }
 
 func (b *Backend) RetrievePayloads(ctx context.Context, _ objects.TektonObject, opts config.StorageOpts) (map[string]string, error) {
@@ -105,6 +215,10 @@ func (b *Backend) NewTopic(ctx context.Context) (*pubsub.Topic, error) {
 		addr := fmt.Sprintf("mem://%s", b.cfg.Storage.PubSub.Topic)
 		logger.Infof("Configuring in-memory producer: %s", addr)
 		return pubsub.OpenTopic(context.TODO(), addr)
+	case PubSubProviderSNS, PubSubProviderSQS:
+		return openAWSTopic(ctx, provider, topic)
+	case PubSubProviderEventHubs:
+		return openEventHub(b.cfg.Storage.PubSub.EventHubs.Namespace, topic)
 	default:
 		return nil, fmt.Errorf("invalid provider: %q", provider)
 	}
@@ -0,0 +1,224 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	SchemaFormatAvro     = "avro"
+	SchemaFormatProtobuf = "protobuf"
+
+	SubjectNameStrategyTopicName       = "topic-name"
+	SubjectNameStrategyRecordName      = "record-name"
+	SubjectNameStrategyTopicRecordName = "topic-record-name"
+
+	// AvroEnvelopeName and ProtobufEnvelopeName are the fully qualified names of the
+	// envelope records, which name the subjects of the record-name strategies.
+	AvroEnvelopeName     = "dev.tekton.chains.Envelope"
+	ProtobufEnvelopeName = "tekton.chains.v1alpha1.Envelope"
+
+	// wireMagicByte starts the messages in the wire format of the schema registry,
+	// before the ID of the schema.
+	wireMagicByte = 0
+)
+
+// AvroEnvelopeSchema is the Avro schema of envelopes.
+const AvroEnvelopeSchema = `{"type":"record","name":"Envelope","namespace":"dev.tekton.chains","fields":[` +
+	`{"name":"payload","type":"bytes"},` +
+	`{"name":"signature","type":"bytes"},` +
+	`{"name":"kind","type":"string"},` +
+	`{"name":"namespace","type":"string"},` +
+	`{"name":"name","type":"string"},` +
+	`{"name":"uid","type":"string"},` +
+	`{"name":"key","type":"string"},` +
+	`{"name":"format","type":"string"}]}`
+
+// ProtobufEnvelopeSchema is the Protobuf schema of envelopes.
+const ProtobufEnvelopeSchema = `syntax = "proto3";
+
+package tekton.chains.v1alpha1;
+
+// Envelope is a payload signed by Tekton Chains.
+message Envelope {
+  // payload is the signed payload.
+  bytes payload = 1;
+  // signature is the signature of the payload, the DSSE envelope for in-toto formats.
+  bytes signature = 2;
+  // kind, namespace, name and uid identify the signed run.
+  string kind = 3;
+  string namespace = 4;
+  string name = 5;
+  string uid = 6;
+  // key is the key of the artifact, e.g. taskrun-<uid>.
+  string key = 7;
+  // format is the payload format, e.g. in-toto.
+  string format = 8;
+}
+`
+
+// Envelope is a signed payload and what it was signed for, the message published to
+// topics when a schema registry is configured.
+type Envelope struct {
+	Payload   []byte
+	Signature []byte
+	Kind      string
+	Namespace string
+	Name      string
+	UID       string
+	Key       string
+	Format    string
+}
+
+// fields returns the fields of e, in the order of the schemas. All of them are
+// serialized as bytes, which Avro and Protobuf encode like strings.
+func (e Envelope) fields() [][]byte {
+	return [][]byte{e.Payload, e.Signature, []byte(e.Kind), []byte(e.Namespace), []byte(e.Name), []byte(e.UID), []byte(e.Key), []byte(e.Format)}
+}
+
+// avro returns the Avro binary encoding of e.
+func (e Envelope) avro() []byte {
+	var b []byte
+	for _, v := range e.fields() {
+		b = binary.AppendVarint(b, int64(len(v)))
+		b = append(b, v...)
+	}
+	return b
+}
+
+// protobuf returns the Protobuf encoding of e. Like in proto3, empty fields are omitted.
+func (e Envelope) protobuf() []byte {
+	var b []byte
+	for i, v := range e.fields() {
+		if len(v) == 0 {
+			continue
+		}
+		b = protowire.AppendTag(b, protowire.Number(i+1), protowire.BytesType)
+		b = protowire.AppendBytes(b, v)
+	}
+	return b
+}
+
+// subject returns the subject of the schema of the envelopes published to topic.
+func subject(cfg config.PubSubStorageConfig) string {
+	record := AvroEnvelopeName
+	if cfg.SchemaRegistry.Format == SchemaFormatProtobuf {
+		record = ProtobufEnvelopeName
+	}
+	switch cfg.SchemaRegistry.SubjectNameStrategy {
+	case SubjectNameStrategyRecordName:
+		return record
+	case SubjectNameStrategyTopicRecordName:
+		return cfg.Topic + "-" + record
+	default:
+		return cfg.Topic + "-value"
+	}
+}
+
+var (
+	schemaIDsMu sync.Mutex
+	// schemaIDs are the IDs of the schemas registered, by registry, subject and format.
+	schemaIDs = map[string]uint32{}
+)
+
+// encodeEnvelope returns e in the wire format of the schema registry of cfg: a zero
+// byte, the ID of the schema of envelopes as 4 big-endian bytes, and the serialized
+// envelope. The schema is registered under its subject the first time.
+func encodeEnvelope(ctx context.Context, cfg config.PubSubStorageConfig, e Envelope) ([]byte, error) {
+	id, err := schemaID(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	b := []byte{wireMagicByte}
+	b = binary.BigEndian.AppendUint32(b, id)
+	if cfg.SchemaRegistry.Format == SchemaFormatProtobuf {
+		// The message indexes of the envelope, the first message of its schema,
+		// encoded as a single zero.
+		b = append(b, 0)
+		return append(b, e.protobuf()...), nil
+	}
+	return append(b, e.avro()...), nil
+}
+
+// schemaID returns the ID of the schema of envelopes in the registry of cfg, registering
+// it if it wasn't. Registering a schema that was registered returns its ID.
+func schemaID(ctx context.Context, cfg config.PubSubStorageConfig) (uint32, error) {
+	subject := subject(cfg)
+	cacheKey := strings.Join([]string{cfg.SchemaRegistry.URL, subject, cfg.SchemaRegistry.Format}, "\x00")
+	schemaIDsMu.Lock()
+	defer schemaIDsMu.Unlock()
+	if id, ok := schemaIDs[cacheKey]; ok {
+		return id, nil
+	}
+
+	request := struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType,omitempty"`
+	}{Schema: AvroEnvelopeSchema}
+	if cfg.SchemaRegistry.Format == SchemaFormatProtobuf {
+		request.Schema, request.SchemaType = ProtobufEnvelopeSchema, "PROTOBUF"
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+	u := strings.TrimSuffix(cfg.SchemaRegistry.URL, "/") + "/subjects/" + url.PathEscape(subject) + "/versions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	if cfg.SchemaRegistry.BasicAuthPath != "" {
+		userInfo, err := os.ReadFile(cfg.SchemaRegistry.BasicAuthPath)
+		if err != nil {
+			return 0, fmt.Errorf("reading schema registry credentials: %w", err)
+		}
+		user, password, _ := strings.Cut(strings.TrimSpace(string(userInfo)), ":")
+		req.SetBasicAuth(user, password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("registering the schema of subject %s: %w", subject, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %d registering the schema of subject %s: %s", resp.StatusCode, subject, strings.TrimSpace(string(respBody)))
+	}
+	var registered struct {
+		ID uint32 `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &registered); err != nil {
+		return 0, err
+	}
+	schemaIDs[cacheKey] = registered.ID
+	return registered.ID, nil
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"google.golang.org/protobuf/encoding/protowire"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+type registration struct {
+	Path       string
+	Schema     string
+	SchemaType string
+	User       string
+}
+
+// startRegistry serves a schema registry registering schemas with ID 7.
+func startRegistry(t *testing.T) (string, func() []registration) {
+	t.Helper()
+	var mu sync.Mutex
+	var registrations []registration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Schema     string `json:"schema"`
+			SchemaType string `json:"schemaType"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		user, _, _ := r.BasicAuth()
+		mu.Lock()
+		registrations = append(registrations, registration{Path: r.URL.Path, Schema: req.Schema, SchemaType: req.SchemaType, User: user})
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"id":7}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, func() []registration {
+		mu.Lock()
+		defer mu.Unlock()
+		return registrations
+	}
+}
+
+func decodeAvro(t *testing.T, b []byte) Envelope {
+	t.Helper()
+	var fields [][]byte
+	for len(b) > 0 {
+		n, read := binary.Varint(b)
+		if read <= 0 || int64(len(b)-read) < n {
+			t.Fatalf("invalid Avro field at %v", b)
+		}
+		fields = append(fields, b[read:read+int(n)])
+		b = b[read+int(n):]
+	}
+	return envelopeOf(t, fields)
+}
+
+func decodeProtobuf(t *testing.T, b []byte) Envelope {
+	t.Helper()
+	fields := make([][]byte, 8)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 || typ != protowire.BytesType || num < 1 || num > 8 {
+			t.Fatalf("invalid Protobuf field at %v", b)
+		}
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			t.Fatalf("invalid Protobuf field at %v", b)
+		}
+		fields[num-1] = v
+		b = b[n:]
+	}
+	return envelopeOf(t, fields)
+}
+
+func envelopeOf(t *testing.T, fields [][]byte) Envelope {
+	t.Helper()
+	if len(fields) != 8 {
+		t.Fatalf("expected 8 fields, got %d", len(fields))
+	}
+	return Envelope{
+		Payload:   fields[0],
+		Signature: fields[1],
+		Kind:      string(fields[2]),
+		Namespace: string(fields[3]),
+		Name:      string(fields[4]),
+		UID:       string(fields[5]),
+		Key:       string(fields[6]),
+		Format:    string(fields[7]),
+	}
+}
+
+func TestBackend_StorePayload_SchemaRegistry(t *testing.T) {
+	want := Envelope{
+		Payload:   []byte("payload"),
+		Signature: []byte("signature"),
+		Kind:      "taskrun",
+		Namespace: "bar",
+		Name:      "foo",
+		UID:       "uid",
+		Key:       "taskrun-uid",
+		Format:    string(formats.PayloadTypeSlsav1),
+	}
+	for _, format := range []string{SchemaFormatAvro, SchemaFormatProtobuf} {
+		t.Run(format, func(t *testing.T) {
+			ctx := logtesting.TestContextWithLogger(t)
+			registryURL, registrations := startRegistry(t)
+			credentials := filepath.Join(t.TempDir(), "user-info")
+			if err := os.WriteFile(credentials, []byte("chains:secret\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			name := topicName(t, "registry-"+format)
+			sub := subscribe(t, ctx, name)
+			b, err := NewStorageBackend(ctx, config.Config{Storage: config.StorageConfigs{PubSub: config.PubSubStorageConfig{
+				Provider: PubSubProviderInMemory,
+				Topic:    name,
+				SchemaRegistry: config.SchemaRegistryConfig{
+					URL:           registryURL,
+					Format:        format,
+					BasicAuthPath: credentials,
+				},
+			}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			obj := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"}})
+			opts := config.StorageOpts{ShortKey: "taskrun-uid", PayloadFormat: formats.PayloadTypeSlsav1}
+			if err := b.StorePayload(ctx, obj, []byte("payload"), "signature", opts); err != nil {
+				t.Fatal(err)
+			}
+			rctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			msg, err := sub.Receive(rctx)
+			cancel()
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg.Ack()
+
+			body := msg.Body
+			if len(body) < 5 || body[0] != wireMagicByte || binary.BigEndian.Uint32(body[1:5]) != 7 {
+				t.Fatalf("expected the body to start with the magic byte and schema ID 7, got %v", body)
+			}
+			var got Envelope
+			if format == SchemaFormatProtobuf {
+				if body[5] != 0 {
+					t.Fatalf("expected the message indexes of the first message, got %v", body[5])
+				}
+				got = decodeProtobuf(t, body[6:])
+			} else {
+				got = decodeAvro(t, body[5:])
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("-want +got: %s", diff)
+			}
+
+			// The ID of the schema is only looked up once.
+			if id, err := schemaID(ctx, b.cfg.Storage.PubSub); err != nil || id != 7 {
+				t.Errorf("expected schema ID 7, got %d, %v", id, err)
+			}
+			wantSchema, wantType := AvroEnvelopeSchema, ""
+			if format == SchemaFormatProtobuf {
+				wantSchema, wantType = ProtobufEnvelopeSchema, "PROTOBUF"
+			}
+			wantRegistrations := []registration{{Path: "/subjects/" + name + "-value/versions", Schema: wantSchema, SchemaType: wantType, User: "chains"}}
+			if diff := cmp.Diff(wantRegistrations, registrations()); diff != "" {
+				t.Errorf("-want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSubject(t *testing.T) {
+	tests := []struct {
+		format, strategy string
+		want             string
+	}{
+		{strategy: "", want: "chains-value"},
+		{strategy: SubjectNameStrategyTopicName, want: "chains-value"},
+		{strategy: SubjectNameStrategyRecordName, want: AvroEnvelopeName},
+		{format: SchemaFormatProtobuf, strategy: SubjectNameStrategyRecordName, want: ProtobufEnvelopeName},
+		{strategy: SubjectNameStrategyTopicRecordName, want: "chains-" + AvroEnvelopeName},
+		{format: SchemaFormatProtobuf, strategy: SubjectNameStrategyTopicRecordName, want: "chains-" + ProtobufEnvelopeName},
+	}
+	for _, tc := range tests {
+		cfg := config.PubSubStorageConfig{Topic: "chains", SchemaRegistry: config.SchemaRegistryConfig{Format: tc.format, SubjectNameStrategy: tc.strategy}}
+		if got := subject(cfg); got != tc.want {
+			t.Errorf("subject(%q, %q) = %q, want %q", tc.format, tc.strategy, got, tc.want)
+		}
+	}
+}
@@ -17,9 +17,13 @@ import (
 	"context"
 
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage/attestation"
 	"github.com/tektoncd/chains/pkg/chains/storage/docdb"
 	"github.com/tektoncd/chains/pkg/chains/storage/gcs"
+	"github.com/tektoncd/chains/pkg/chains/storage/github"
+	"github.com/tektoncd/chains/pkg/chains/storage/gitlab"
 	"github.com/tektoncd/chains/pkg/chains/storage/grafeas"
+	"github.com/tektoncd/chains/pkg/chains/storage/ipfs"
 	"github.com/tektoncd/chains/pkg/chains/storage/oci"
 	"github.com/tektoncd/chains/pkg/chains/storage/pubsub"
 	"github.com/tektoncd/chains/pkg/chains/storage/tekton"
@@ -27,6 +31,7 @@ import (
 	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 )
 
 // Backend is an interface to store a chains Payload
@@ -42,17 +47,7 @@ type Backend interface {
 
 // InitializeBackends creates and initializes every configured storage backend.
 func InitializeBackends(ctx context.Context, ps versioned.Interface, kc kubernetes.Interface, cfg config.Config) (map[string]Backend, error) {
-	// Add an entry here for every configured backend
-	configuredBackends := []string{}
-	if cfg.Artifacts.TaskRuns.Enabled() {
-		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.TaskRuns.StorageBackend)...)
-	}
-	if cfg.Artifacts.OCI.Enabled() {
-		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.OCI.StorageBackend)...)
-	}
-	if cfg.Artifacts.PipelineRuns.Enabled() {
-		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.PipelineRuns.StorageBackend)...)
-	}
+	configuredBackends := ConfiguredBackends(cfg)
 
 	// Now only initialize and return the configured ones.
 	backends := map[string]Backend{}
@@ -64,8 +59,10 @@ func InitializeBackends(ctx context.Context, ps versioned.Interface, kc kubernet
 				return nil, err
 			}
 			backends[backendType] = gcsBackend
+		case attestation.StorageBackendAttestation:
+			backends[backendType] = attestation.NewStorageBackend(dynamicclient.Get(ctx))
 		case tekton.StorageBackendTekton:
-			backends[backendType] = tekton.NewStorageBackend(ps)
+			backends[backendType] = tekton.NewStorageBackend(ps, cfg)
 		case oci.StorageBackendOCI:
 			ociBackend := oci.NewStorageBackend(ctx, kc, cfg)
 			backends[backendType] = ociBackend
@@ -87,8 +84,59 @@ func InitializeBackends(ctx context.Context, ps versioned.Interface, kc kubernet
 				return nil, err
 			}
 			backends[backendType] = pubsubBackend
+		case ipfs.StorageBackendIPFS:
+			ipfsBackend, err := ipfs.NewStorageBackend(ps, cfg)
+			if err != nil {
+				return nil, err
+			}
+			backends[backendType] = ipfsBackend
+		case github.StorageBackendGitHub:
+			githubBackend, err := github.NewStorageBackend(ps, cfg)
+			if err != nil {
+				return nil, err
+			}
+			backends[backendType] = githubBackend
+		case gitlab.StorageBackendGitLab:
+			gitlabBackend, err := gitlab.NewStorageBackend(cfg)
+			if err != nil {
+				return nil, err
+			}
+			backends[backendType] = gitlabBackend
 		}
 
 	}
 	return backends, nil
 }
+
+// ConfiguredBackends returns the storage backends cfg stores the payloads of any
+// artifact in, with duplicates.
+func ConfiguredBackends(cfg config.Config) []string {
+	// Add an entry here for every configured backend
+	configuredBackends := []string{}
+	if cfg.Artifacts.TaskRuns.Enabled() {
+		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.TaskRuns.StorageBackend)...)
+	}
+	if cfg.Artifacts.OCI.Enabled() {
+		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.OCI.StorageBackend)...)
+	}
+	if cfg.Artifacts.PipelineRuns.Enabled() {
+		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.PipelineRuns.StorageBackend)...)
+	}
+	if cfg.Artifacts.PipelineRuns.BundleEnabled() {
+		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.PipelineRuns.BundleStorageBackend)...)
+	}
+	if cfg.Artifacts.VEX.Enabled() {
+		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.VEX.StorageBackend)...)
+	}
+	if cfg.Artifacts.CustomRuns.Enabled() {
+		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.CustomRuns.StorageBackend)...)
+	}
+	if cfg.Artifacts.TektonBundles.Enabled() {
+		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.TektonBundles.StorageBackend)...)
+	}
+	// Source commits are only attested with keyless signing.
+	if cfg.Artifacts.Source.Enabled() && cfg.Signers.X509.FulcioEnabled {
+		configuredBackends = append(configuredBackends, sets.List[string](cfg.Artifacts.Source.StorageBackend)...)
+	}
+	return configuredBackends
+}
@@ -22,6 +22,7 @@ import (
 	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
 	"k8s.io/apimachinery/pkg/util/sets"
 	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	_ "knative.dev/pkg/injection/clients/dynamicclient/fake"
 	"knative.dev/pkg/logging"
 	logtesting "knative.dev/pkg/logging/testing"
 	rtesting "knative.dev/pkg/reconciler/testing"
@@ -69,6 +70,19 @@ func TestInitializeBackends(t *testing.T) {
 			name: "pubsub",
 			want: []string{"pubsub"},
 			cfg:  config.Config{Artifacts: config.ArtifactConfigs{TaskRuns: config.Artifact{StorageBackend: sets.New[string]("pubsub")}}}},
+		{
+			name: "attestation",
+			want: []string{"attestation"},
+			cfg:  config.Config{Artifacts: config.ArtifactConfigs{PipelineRuns: config.Artifact{StorageBackend: sets.New[string]("attestation")}}},
+		},
+		{
+			name: "ipfs",
+			want: []string{"ipfs"},
+			cfg: config.Config{
+				Artifacts: config.ArtifactConfigs{TaskRuns: config.Artifact{StorageBackend: sets.New[string]("ipfs")}},
+				Storage:   config.StorageConfigs{IPFS: config.IPFSStorageConfig{URL: "http://ipfs:5001"}},
+			},
+		},
 	}
 	ctx, _ := rtesting.SetupFakeContext(t)
 	ps := fakepipelineclient.Get(ctx)
@@ -94,3 +108,49 @@ func TestInitializeBackends(t *testing.T) {
 		})
 	}
 }
+
+func TestConfiguredBackends(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]string
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"oci", "tekton"},
+		},
+		{
+			name: "pipelinerun bundles",
+			data: map[string]string{"artifacts.pipelinerun.bundle.storage": "ipfs"},
+			want: []string{"ipfs", "oci", "tekton"},
+		},
+		{
+			name: "tekton bundles",
+			data: map[string]string{"artifacts.tekton-bundle.storage": "ipfs"},
+			want: []string{"ipfs", "oci", "tekton"},
+		},
+		{
+			name: "source",
+			data: map[string]string{"artifacts.source.storage": "gitlab", "signers.x509.fulcio.enabled": "true"},
+			want: []string{"gitlab", "oci", "tekton"},
+		},
+		{
+			// Source commits are only attested with keyless signing.
+			name: "source without fulcio",
+			data: map[string]string{"artifacts.source.storage": "gitlab"},
+			want: []string{"oci", "tekton"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.NewConfigFromMap(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := sets.List(sets.New(ConfiguredBackends(*cfg)...))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConfiguredBackends() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/tektoncd/chains/pkg/chains/objects"
@@ -35,6 +37,15 @@ const (
 	SignatureAnnotationFormat = "chains.tekton.dev/signature-%s"
 	CertAnnotationsFormat     = "chains.tekton.dev/cert-%s"
 	ChainAnnotationFormat     = "chains.tekton.dev/chain-%s"
+
+	// ChunkAnnotationFormat is the format of the annotations the chunks of a value
+	// split across several annotations are stored in, formatted with the annotation
+	// the value is stored in otherwise and the index of the chunk. The suffixes are
+	// short since the names of annotation keys are limited to 63 characters.
+	ChunkAnnotationFormat = "%s.%d"
+	// ChunksAnnotationSuffix is appended to the annotation a value is stored in
+	// otherwise for the index annotation holding the number of chunks it was split into.
+	ChunksAnnotationSuffix = ".n"
 )
 
 // Backend is a storage backend that stores signed payloads in the TaskRun metadata as an annotation.
@@ -42,12 +53,14 @@ const (
 // Deprecated: use Storer instead.
 type Backend struct {
 	pipelineclientset versioned.Interface
+	cfg               config.Config
 }
 
 // NewStorageBackend returns a new Tekton StorageBackend that stores signatures on a TaskRun
-func NewStorageBackend(ps versioned.Interface) *Backend {
+func NewStorageBackend(ps versioned.Interface, cfg config.Config) *Backend {
 	return &Backend{
 		pipelineclientset: ps,
+		cfg:               cfg,
 	}
 }
 
@@ -56,10 +69,11 @@ func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, ra
 	logger := logging.FromContext(ctx)
 
 	store := &Storer{
-		client: b.pipelineclientset,
-		key:    opts.ShortKey,
+		client:            b.pipelineclientset,
+		key:               opts.ShortKey,
+		maxAnnotationSize: b.cfg.Storage.Tekton.MaxAnnotationSize,
 	}
-	if _, err := store.Store(ctx, &api.StoreRequest[objects.TektonObject, *in_toto.Statement]{
+	resp, err := store.Store(ctx, &api.StoreRequest[objects.TektonObject, *in_toto.Statement]{
 		Object:   obj,
 		Artifact: obj,
 		// We don't actually use payload - we store the raw bundle values directly.
@@ -70,10 +84,12 @@ func (b *Backend) StorePayload(ctx context.Context, obj objects.TektonObject, ra
 			Cert:      []byte(opts.Cert),
 			Chain:     []byte(opts.Chain),
 		},
-	}); err != nil {
+	})
+	if err != nil {
 		logger.Errorf("error writing to Tekton object: %w", err)
 		return err
 	}
+	api.RecordLocations(ctx, resp.Locations...)
 	return nil
 }
 
@@ -91,7 +107,10 @@ func (b *Backend) retrieveAnnotationValue(ctx context.Context, obj objects.Tekto
 	if err != nil {
 		return "", fmt.Errorf("error retrieving the annotation value for the key %q: %s", annotationKey, err)
 	}
-	val, ok := annotations[annotationKey]
+	val, ok, err := AnnotationValue(annotations, annotationKey)
+	if err != nil {
+		return "", err
+	}
 
 	// Ensure it exists.
 	if ok {
@@ -138,6 +157,68 @@ func (b *Backend) RetrievePayloads(ctx context.Context, obj objects.TektonObject
 	return m, nil
 }
 
+// AnnotationValue returns the value stored in the annotation key of annotations, and
+// whether it was found. Values split into chunks are reassembled.
+func AnnotationValue(annotations map[string]string, key string) (string, bool, error) {
+	if val, ok := annotations[key]; ok {
+		return val, true, nil
+	}
+	index, ok := annotations[key+ChunksAnnotationSuffix]
+	if !ok {
+		return "", false, nil
+	}
+	chunks, err := strconv.Atoi(index)
+	if err != nil || chunks < 1 {
+		return "", false, fmt.Errorf("invalid number of chunks %q of the annotation %q", index, key)
+	}
+	var val strings.Builder
+	for i := 0; i < chunks; i++ {
+		chunk, ok := annotations[fmt.Sprintf(ChunkAnnotationFormat, key, i)]
+		if !ok {
+			return "", false, fmt.Errorf("missing chunk %d of %d of the annotation %q", i, chunks, key)
+		}
+		val.WriteString(chunk)
+	}
+	return val.String(), true, nil
+}
+
+// setAnnotation sets the annotation key to val in annotations, split into chunks of at
+// most maxSize bytes and an index annotation if it is larger. It returns the keys of
+// existing that held a previous value of key and aren't set again: the plain annotation
+// when val is split and the index and chunks when it isn't, or the chunks past the new
+// number of chunks, since AnnotationValue prefers the plain annotation.
+func setAnnotation(annotations, existing map[string]string, key, val string, maxSize int) []string {
+	if maxSize <= 0 || len(val) <= maxSize {
+		annotations[key] = val
+	} else {
+		chunks := 0
+		for ; len(val) > 0; chunks++ {
+			n := maxSize
+			if n > len(val) {
+				n = len(val)
+			}
+			annotations[fmt.Sprintf(ChunkAnnotationFormat, key, chunks)] = val[:n]
+			val = val[n:]
+		}
+		annotations[key+ChunksAnnotationSuffix] = strconv.Itoa(chunks)
+	}
+
+	var stale []string
+	for k := range existing {
+		if _, ok := annotations[k]; ok {
+			continue
+		}
+		if k == key || k == key+ChunksAnnotationSuffix {
+			stale = append(stale, k)
+			continue
+		}
+		if i, err := strconv.Atoi(strings.TrimPrefix(k, key+".")); err == nil && k == fmt.Sprintf(ChunkAnnotationFormat, key, i) {
+			stale = append(stale, k)
+		}
+	}
+	return stale
+}
+
 func sigName(opts config.StorageOpts) string {
 	return fmt.Sprintf(SignatureAnnotationFormat, opts.ShortKey)
 }
@@ -150,6 +231,9 @@ type Storer struct {
 	client versioned.Interface
 	// optional key override. If not specified, the UID of the object is used.
 	key string
+	// maxAnnotationSize is the size above which values are split into chunks. They
+	// are not split if it is 0.
+	maxAnnotationSize int
 }
 
 var (
@@ -168,13 +252,24 @@ func (s *Storer) Store(ctx context.Context, req *api.StoreRequest[objects.Tekton
 	if key == "" {
 		key = string(obj.GetUID())
 	}
-	patchBytes, err := patch.GetAnnotationsPatch(map[string]string{
+	// The values previously stored for the key are replaced, removing the annotations
+	// that held them and aren't set again.
+	existing, err := obj.GetLatestAnnotations(ctx, s.client)
+	if err != nil {
+		return nil, err
+	}
+	annotations := map[string]string{}
+	var stale []string
+	for format, val := range map[string][]byte{
 		// Base64 encode both the signature and the payload
-		fmt.Sprintf(PayloadAnnotationFormat, key):   base64.StdEncoding.EncodeToString(req.Bundle.Content),
-		fmt.Sprintf(SignatureAnnotationFormat, key): base64.StdEncoding.EncodeToString(req.Bundle.Signature),
-		fmt.Sprintf(CertAnnotationsFormat, key):     base64.StdEncoding.EncodeToString(req.Bundle.Cert),
-		fmt.Sprintf(ChainAnnotationFormat, key):     base64.StdEncoding.EncodeToString(req.Bundle.Chain),
-	})
+		PayloadAnnotationFormat:   req.Bundle.Content,
+		SignatureAnnotationFormat: req.Bundle.Signature,
+		CertAnnotationsFormat:     req.Bundle.Cert,
+		ChainAnnotationFormat:     req.Bundle.Chain,
+	} {
+		stale = append(stale, setAnnotation(annotations, existing, fmt.Sprintf(format, key), base64.StdEncoding.EncodeToString(val), s.maxAnnotationSize)...)
+	}
+	patchBytes, err := patch.GetAnnotationsReplacePatch(annotations, stale)
 	if err != nil {
 		return nil, err
 	}
@@ -183,5 +278,9 @@ func (s *Storer) Store(ctx context.Context, req *api.StoreRequest[objects.Tekton
 	if patchErr != nil {
 		return nil, patchErr
 	}
-	return &api.StoreResponse{}, nil
+	// The payload is stored in the annotations of the object itself.
+	return &api.StoreResponse{Locations: []string{
+		fmt.Sprintf(PayloadAnnotationFormat, key),
+		fmt.Sprintf(SignatureAnnotationFormat, key),
+	}}, nil
 }
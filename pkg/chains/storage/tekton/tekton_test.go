@@ -15,6 +15,9 @@ package tekton
 
 import (
 	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -137,3 +140,163 @@ type mockPayload struct {
 	A string
 	B int
 }
+
+func TestBackend_StorePayload_Chunks(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	obj := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "bar",
+		},
+	})
+	tekton.CreateObject(t, ctx, c, obj)
+
+	b := NewStorageBackend(c, config.Config{Storage: config.StorageConfigs{
+		Tekton: config.TektonStorageConfig{MaxAnnotationSize: 12},
+	}})
+	// The payload is 36 bytes once base64 encoded, the signature 12.
+	payload := []byte(`{"A":"foo","B":3,"C":"b"}`)
+	opts := config.StorageOpts{ShortKey: "mockpayload"}
+	if err := b.StorePayload(ctx, obj, payload, "mocksign", opts); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := tekton.GetObject(t, ctx, c, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := updated.GetAnnotations()
+	payloadAnnotation := payloadName(opts)
+	if _, ok := annotations[payloadAnnotation]; ok {
+		t.Errorf("annotation %q should be split into chunks", payloadAnnotation)
+	}
+	if got := annotations[payloadAnnotation+ChunksAnnotationSuffix]; got != "3" {
+		t.Errorf("got %q chunks, want 3", got)
+	}
+	for i := 0; i < 3; i++ {
+		chunk := annotations[payloadAnnotation+"."+strconv.Itoa(i)]
+		if len(chunk) == 0 || len(chunk) > 12 {
+			t.Errorf("chunk %d has %d bytes, want between 1 and 12", i, len(chunk))
+		}
+	}
+	// Values that fit are not split.
+	if _, ok := annotations[sigName(opts)]; !ok {
+		t.Errorf("annotation %q should not be split", sigName(opts))
+	}
+
+	payloads, err := b.RetrievePayloads(ctx, obj, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(payload), payloads[payloadAnnotation]); diff != "" {
+		t.Errorf("unexpected payload: (-want, +got): %s", diff)
+	}
+}
+
+func TestBackend_StorePayload_Restore(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	obj := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "bar",
+		},
+	})
+	tekton.CreateObject(t, ctx, c, obj)
+	opts := config.StorageOpts{ShortKey: "mockpayload"}
+	payloadAnnotation := payloadName(opts)
+
+	// Each payload is stored over the previous one, with max-annotation-size enabled,
+	// changed and disabled.
+	for _, tc := range []struct {
+		maxSize    int
+		payload    string
+		wantChunks int
+	}{
+		{payload: `{"A":"foo"}`},
+		// 36 bytes once base64 encoded.
+		{maxSize: 12, payload: `{"A":"foo","B":3,"C":"b"}`, wantChunks: 3},
+		{maxSize: 12, payload: `{"A":"bar"}`, wantChunks: 2},
+		{payload: `{"A":"baz"}`},
+	} {
+		b := NewStorageBackend(c, config.Config{Storage: config.StorageConfigs{
+			Tekton: config.TektonStorageConfig{MaxAnnotationSize: tc.maxSize},
+		}})
+		if err := b.StorePayload(ctx, obj, []byte(tc.payload), "mocksign", opts); err != nil {
+			t.Fatal(err)
+		}
+		payloads, err := b.RetrievePayloads(ctx, obj, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tc.payload, payloads[payloadAnnotation]); diff != "" {
+			t.Errorf("unexpected payload: (-want, +got): %s", diff)
+		}
+
+		updated, err := tekton.GetObject(t, ctx, c, obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for k := range updated.GetAnnotations() {
+			if strings.HasPrefix(k, payloadAnnotation) {
+				got = append(got, k)
+			}
+		}
+		sort.Strings(got)
+		want := []string{payloadAnnotation}
+		if tc.wantChunks > 0 {
+			want = []string{payloadAnnotation + ChunksAnnotationSuffix}
+			for i := 0; i < tc.wantChunks; i++ {
+				want = append(want, payloadAnnotation+"."+strconv.Itoa(i))
+			}
+			sort.Strings(want)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected payload annotations with max size %d: (-want, +got): %s", tc.maxSize, diff)
+		}
+	}
+}
+
+func TestAnnotationValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        string
+		wantOK      bool
+		wantErr     bool
+	}{{
+		name:        "not chunked",
+		annotations: map[string]string{"key": "value"},
+		want:        "value",
+		wantOK:      true,
+	}, {
+		name:        "chunked",
+		annotations: map[string]string{"key.n": "2", "key.0": "val", "key.1": "ue"},
+		want:        "value",
+		wantOK:      true,
+	}, {
+		name:        "missing",
+		annotations: map[string]string{"other": "value"},
+	}, {
+		name:        "missing chunk",
+		annotations: map[string]string{"key.n": "2", "key.0": "val"},
+		wantErr:     true,
+	}, {
+		name:        "invalid index",
+		annotations: map[string]string{"key.n": "two"},
+		wantErr:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := AnnotationValue(tt.annotations, "key")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AnnotationValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("AnnotationValue() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tektonbundles reads the Tasks and Pipelines packaged in Tekton bundles, so
+// that the bundles built by runs can be attested.
+package tektonbundles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/authn/k8schain"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Annotations of the layers of Tekton bundles identifying the resource each of them
+// packages.
+const (
+	APIVersionAnnotation = "dev.tekton.image.apiVersion"
+	KindAnnotation       = "dev.tekton.image.kind"
+	NameAnnotation       = "dev.tekton.image.name"
+)
+
+// Resource is a Task or Pipeline packaged in a Tekton bundle.
+type Resource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	// Digest is the digest of the layer the resource is packaged in.
+	Digest map[string]string `json:"digest"`
+}
+
+// Fetcher reads the resources packaged in Tekton bundles.
+type Fetcher interface {
+	// Resources returns the resources packaged in the bundle d, in the order of its
+	// layers.
+	Resources(ctx context.Context, d name.Digest) ([]Resource, error)
+}
+
+type fetcherKey struct{}
+
+// WithFetcher returns a context carrying f.
+func WithFetcher(ctx context.Context, f Fetcher) context.Context {
+	return context.WithValue(ctx, fetcherKey{}, f)
+}
+
+// FromContext returns the Fetcher carried by ctx, or nil.
+func FromContext(ctx context.Context) Fetcher {
+	f, _ := ctx.Value(fetcherKey{}).(Fetcher)
+	return f
+}
+
+// RegistryFetcher reads Tekton bundles from their registry, authenticating like the
+// run that built them: with the image pull secrets of the run and of its service
+// account. Without a Client, bundles are read anonymously.
+type RegistryFetcher struct {
+	Client             kubernetes.Interface
+	Namespace          string
+	ServiceAccountName string
+	ImagePullSecrets   []string
+
+	// Options are extra options of the requests to the registry.
+	Options []remote.Option
+}
+
+// Resources implements Fetcher.
+func (f *RegistryFetcher) Resources(ctx context.Context, d name.Digest) ([]Resource, error) {
+	var kc authn.Keychain = authn.DefaultKeychain
+	if f.Client != nil {
+		var err error
+		kc, err = k8schain.New(ctx, f.Client, k8schain.Options{
+			Namespace:          f.Namespace,
+			ServiceAccountName: f.ServiceAccountName,
+			ImagePullSecrets:   f.ImagePullSecrets,
+			UseMountSecrets:    true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating keychain: %w", err)
+		}
+	}
+	opts := append([]remote.Option{remote.WithContext(ctx), remote.WithAuthFromKeychain(kc)}, f.Options...)
+	desc, err := remote.Get(d, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("fetching Tekton bundle %s: %w", d, err)
+	}
+	m, err := v1.ParseManifest(bytes.NewReader(desc.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest of Tekton bundle %s: %w", d, err)
+	}
+	return ResourcesOf(d, m)
+}
+
+// ResourcesOf returns the resources packaged in the layers of the manifest m of the
+// Tekton bundle d. It returns an error if a layer doesn't identify its resource.
+func ResourcesOf(d name.Digest, m *v1.Manifest) ([]Resource, error) {
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("%s is not a Tekton bundle: it has no layers", d)
+	}
+	resources := make([]Resource, 0, len(m.Layers))
+	for _, l := range m.Layers {
+		r := Resource{
+			APIVersion: l.Annotations[APIVersionAnnotation],
+			Kind:       l.Annotations[KindAnnotation],
+			Name:       l.Annotations[NameAnnotation],
+			Digest:     map[string]string{l.Digest.Algorithm: l.Digest.Hex},
+		}
+		if r.APIVersion == "" || r.Kind == "" || r.Name == "" {
+			return nil, fmt.Errorf("%s is not a Tekton bundle: layer %s doesn't have the %s annotations", d, l.Digest, strings.Join([]string{APIVersionAnnotation, KindAnnotation, NameAnnotation}, ", "))
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
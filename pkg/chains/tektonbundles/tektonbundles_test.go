@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tektonbundles
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func TestRegistryFetcher(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := []Resource{
+		{APIVersion: "tekton.dev/v1beta1", Kind: "task", Name: "build"},
+		{APIVersion: "tekton.dev/v1beta1", Kind: "pipeline", Name: "release"},
+	}
+	img := empty.Image
+	for i, r := range resources {
+		l := static.NewLayer([]byte(r.Kind+"/"+r.Name), types.DockerLayer)
+		img, err = mutate.Append(img, mutate.Addendum{Layer: l, Annotations: map[string]string{
+			APIVersionAnnotation: r.APIVersion,
+			KindAnnotation:       r.Kind,
+			NameAnnotation:       r.Name,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := l.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resources[i].Digest = map[string]string{h.Algorithm: h.Hex}
+	}
+	tag, err := name.NewTag(u.Host + "/tekton/bundle:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatal(err)
+	}
+	h, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &RegistryFetcher{}
+	got, err := f.Resources(context.Background(), tag.Context().Digest(h.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(resources, got); diff != "" {
+		t.Errorf("Resources() -want +got: %s", diff)
+	}
+}
+
+func TestResourcesOf_NotABundle(t *testing.T) {
+	d, err := name.NewDigest("gcr.io/foo/bar@sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer := v1.Descriptor{Digest: v1.Hash{Algorithm: "sha256", Hex: "05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5"}}
+	for _, m := range []*v1.Manifest{
+		{},
+		{Layers: []v1.Descriptor{layer}},
+	} {
+		if _, err := ResourcesOf(d, m); err == nil {
+			t.Errorf("expected an error for manifest %+v", m)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// withTimeout returns ctx bounded by timeout, or a ctx that is only cancelled with its
+// parent if timeout is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timedOut wraps err, returned by what, if it failed because ctx, bounded by timeout,
+// expired rather than because its parent was cancelled.
+func timedOut(ctx context.Context, timeout time.Duration, what string, err error) error {
+	if err == nil || timeout == 0 || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%s timed out after %s: %w", what, timeout, err)
+}
+
+// storePayload calls store with ctx bounded by the timeout of backend.
+func storePayload(ctx context.Context, cfg config.Config, backend string, store func(ctx context.Context) error) error {
+	timeout := cfg.Concurrency.BackendTimeouts[backend]
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	return timedOut(ctx, timeout, "storing payload in "+backend, store(ctx))
+}
+
+// signMessage signs payload with signer, of type signerType, within its timeout.
+func signMessage(ctx context.Context, cfg config.Config, signerType string, signer signing.Signer, payload []byte) ([]byte, error) {
+	timeout := cfg.Concurrency.SignerTimeouts[signerType]
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	signature, err := signer.SignMessage(bytes.NewReader(payload), options.WithContext(ctx))
+	return signature, timedOut(ctx, timeout, "signing with "+signerType, err)
+}
+
+// tlogUpload calls upload with ctx bounded by the timeout of the transparency log of cfg.
+func tlogUpload[T any](ctx context.Context, cfg config.TransparencyConfig, upload func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := withTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	entry, err := upload(ctx)
+	return entry, timedOut(ctx, cfg.Timeout, "uploading entry to "+cfg.URL, err)
+}
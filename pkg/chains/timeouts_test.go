@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// wait waits for ctx to be done.
+func wait(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestStorePayload_Timeout(t *testing.T) {
+	cfg := config.Config{Concurrency: config.ConcurrencyConfig{BackendTimeouts: map[string]time.Duration{"gcs": 10 * time.Millisecond}}}
+
+	err := storePayload(context.Background(), cfg, "gcs", wait)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "storing payload in gcs timed out after 10ms") {
+		t.Errorf("expected storing in gcs to time out, got %v", err)
+	}
+
+	// Backends without a timeout are only bounded by their parent context.
+	err = storePayload(context.Background(), cfg, "oci", func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			return errors.New("unexpected deadline")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Cancelling the parent context isn't a timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := storePayload(ctx, cfg, "gcs", wait); !errors.Is(err, context.Canceled) || strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected storing in gcs to be cancelled, got %v", err)
+	}
+}
+
+func TestTlogUpload_Timeout(t *testing.T) {
+	cfg := config.TransparencyConfig{URL: "https://rekor.sigstore.dev", Timeout: 10 * time.Millisecond}
+
+	_, err := tlogUpload(context.Background(), cfg, func(ctx context.Context) (string, error) {
+		return "", wait(ctx)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "uploading entry to https://rekor.sigstore.dev timed out after 10ms") {
+		t.Errorf("expected the upload to time out, got %v", err)
+	}
+	if !rekorUnreachable(err) {
+		t.Error("expected timed out uploads to be queued")
+	}
+}
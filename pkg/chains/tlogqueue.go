@@ -0,0 +1,357 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	sigsig "github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/patch"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// TransparencyPendingAnnotation is set on runs signed while the transparency log was
+	// unreachable, whose entries are queued in TransparencyEntries. It is removed once
+	// all of them are uploaded and ChainsTransparencyAnnotation is set.
+	TransparencyPendingAnnotation = "chains.tekton.dev/transparency-pending"
+
+	// transparencyRunLabel labels TransparencyEntries with the UID of their run.
+	transparencyRunLabel = "chains.tekton.dev/run-uid"
+
+	defaultQueueInterval = time.Minute
+)
+
+// rekorUnreachable returns whether err means that the transparency log couldn't be
+// reached, didn't respond within transparency.timeout or failed to handle the request,
+// rather than that it rejected the entry.
+func rekorUnreachable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var codeErr interface{ Code() int }
+	if errors.As(err, &codeErr) {
+		return codeErr.Code() >= 500
+	}
+	return false
+}
+
+// TransparencyEntryName returns the name of the TransparencyEntry of the artifact with
+// the given key of the run of the given kind and name.
+func TransparencyEntryName(kind, name, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return kmeta.ChildName(name, fmt.Sprintf("-%s-%s", kind, hex.EncodeToString(sum[:4])))
+}
+
+// queueTransparencyEntry queues the entry of the artifact with the given key of obj
+// in a TransparencyEntry, to be uploaded by the TransparencyQueue once the transparency
+// log of cfg is reachable again.
+func (o *ObjectSigner) queueTransparencyEntry(ctx context.Context, obj objects.TektonObject, cfg config.TransparencyConfig, key string, format config.PayloadType, signature, rawPayload []byte, signer signing.Signer) error {
+	entry := &v1alpha1.TransparencyEntry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TransparencyEntryName(obj.GetKindName(), obj.GetName(), key),
+			Namespace: obj.GetNamespace(),
+			Labels:    map[string]string{transparencyRunLabel: string(obj.GetUID())},
+		},
+		Spec: v1alpha1.TransparencyEntrySpec{
+			Run:       v1alpha1.RunReference{Kind: ownerKind(obj), Name: obj.GetName(), UID: string(obj.GetUID())},
+			Key:       key,
+			Format:    string(format),
+			URL:       cfg.URL,
+			Version:   cfg.Version,
+			Signature: signature,
+			Cert:      signer.Cert(),
+		},
+	}
+	if _, ok := formats.IntotoAttestationSet[format]; !ok {
+		entry.Spec.Payload = rawPayload
+	}
+	if entry.Spec.Cert == "" {
+		pub, err := signer.PublicKey()
+		if err != nil {
+			return errors.Wrap(err, "getting public key")
+		}
+		pem, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+		if err != nil {
+			return errors.Wrap(err, "key to pem")
+		}
+		entry.Spec.PublicKey = string(pem)
+	}
+	if obj.GetUID() != "" {
+		entry.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: v1beta1.SchemeGroupVersion.String(),
+			Kind:       ownerKind(obj),
+			Name:       obj.GetName(),
+			UID:        obj.GetUID(),
+		}}
+	}
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(entry)
+	if err != nil {
+		return err
+	}
+	object := &unstructured.Unstructured{Object: u}
+	object.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("TransparencyEntry"))
+	client := o.DynamicClient.Resource(v1alpha1.TransparencyEntryResource).Namespace(obj.GetNamespace())
+	_, err = client.Create(ctx, object, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// The entry is left over from an earlier signing of the run, or of a deleted run
+		// with the same name: it is replaced.
+		existing, getErr := client.Get(ctx, entry.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		object.SetResourceVersion(existing.GetResourceVersion())
+		_, err = client.Update(ctx, object, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// TransparencyQueue uploads the TransparencyEntries queued while the transparency log
+// was unreachable, and records the entries on their runs once they are uploaded.
+type TransparencyQueue struct {
+	DynamicClient     dynamic.Interface
+	Pipelineclientset versioned.Interface
+
+	mu  sync.Mutex
+	cfg config.TransparencyConfig
+	// running is the interval of the upload loop, and stop stops it, if it runs.
+	running time.Duration
+	stop    context.CancelFunc
+}
+
+// Setup uploads the queued entries every transparency.queue.interval, if cfg enables the
+// queue. Like Prober.Setup, it is safe to call on every config update: the upload loop
+// is only restarted when its interval changed.
+func (q *TransparencyQueue) Setup(ctx context.Context, cfg config.Config) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.cfg = cfg.Transparency
+	interval := cfg.Transparency.QueueInterval
+	if interval == 0 {
+		interval = defaultQueueInterval
+	}
+	if !cfg.Transparency.QueueEnabled {
+		interval = 0
+	}
+	if interval == q.running {
+		return
+	}
+	if q.stop != nil {
+		q.stop()
+		q.stop = nil
+	}
+	q.running = interval
+	if interval == 0 {
+		return
+	}
+
+	ctx, q.stop = context.WithCancel(ctx)
+	go func() {
+		logger := logging.FromContext(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			if err := q.Flush(ctx, q.state()); err != nil && ctx.Err() == nil {
+				logger.Warnf("error uploading queued transparency log entries: %v", err)
+			}
+		}
+	}()
+}
+
+// state returns the transparency config of the last Setup.
+func (q *TransparencyQueue) state() config.TransparencyConfig {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.cfg
+}
+
+// Flush attempts to upload all queued entries to their transparency logs, connecting to
+// them with the TLS and proxy settings of cfg. Uploaded entries are recorded on their
+// runs and deleted; the others record the failed attempt and are retried by the next
+// Flush.
+func (q *TransparencyQueue) Flush(ctx context.Context, cfg config.TransparencyConfig) error {
+	list, err := q.DynamicClient.Resource(v1alpha1.TransparencyEntryResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing TransparencyEntries")
+	}
+	var merr *multierror.Error
+	for _, item := range list.Items {
+		entry := &v1alpha1.TransparencyEntry{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, entry); err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		if err := q.upload(ctx, cfg, entry); err != nil {
+			merr = multierror.Append(merr, errors.Wrapf(err, "%s/%s", entry.Namespace, entry.Name))
+		}
+	}
+	return merr.ErrorOrNil()
+}
+
+// upload uploads entry, and records the outcome on its run if it was uploaded and on
+// entry otherwise.
+func (q *TransparencyQueue) upload(ctx context.Context, cfg config.TransparencyConfig, entry *v1alpha1.TransparencyEntry) error {
+	logger := logging.FromContext(ctx)
+	obj, err := q.run(ctx, entry)
+	if apierrors.IsNotFound(err) || (err == nil && string(obj.GetUID()) != entry.Spec.Run.UID) {
+		// The run was deleted: there is nothing to record the entry on.
+		return q.delete(ctx, entry)
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg.URL, cfg.Version = entry.Spec.URL, entry.Spec.Version
+	logEntry, uploadErr := uploadQueuedEntry(ctx, cfg, entry)
+	if uploadErr != nil {
+		now := metav1.Now()
+		entry.Status.Attempts++
+		entry.Status.LastAttempt = &now
+		entry.Status.LastError = uploadErr.Error()
+		return q.update(ctx, entry)
+	}
+	logger.Infof("Uploaded queued entry of %s/%s to %s with index %d", entry.Namespace, entry.Spec.Run.Name, cfg.URL, *logEntry.LogIndex)
+
+	if err := AddAnnotation(ctx, obj, q.Pipelineclientset, ChainsTransparencyAnnotation, transparencyEntryURL(cfg, *logEntry.LogIndex), nil); err != nil {
+		return err
+	}
+	if err := q.delete(ctx, entry); err != nil {
+		return err
+	}
+	remaining, err := q.DynamicClient.Resource(v1alpha1.TransparencyEntryResource).Namespace(entry.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", transparencyRunLabel, entry.Spec.Run.UID),
+	})
+	if err != nil {
+		return err
+	}
+	if len(remaining.Items) > 0 {
+		return nil
+	}
+	patchBytes, err := patch.GetAnnotationsRemovalPatch([]string{TransparencyPendingAnnotation})
+	if err != nil {
+		return err
+	}
+	return obj.Patch(ctx, q.Pipelineclientset, patchBytes)
+}
+
+// uploadQueuedEntry uploads entry to the transparency log of cfg.
+func uploadQueuedEntry(ctx context.Context, cfg config.TransparencyConfig, entry *v1alpha1.TransparencyEntry) (*models.LogEntryAnon, error) {
+	signer := queuedSigner{cert: entry.Spec.Cert}
+	if entry.Spec.Cert == "" {
+		pub, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(entry.Spec.PublicKey))
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing public key")
+		}
+		signer.pub = pub
+	}
+	client, err := transparencyClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return tlogUpload(ctx, cfg, func(ctx context.Context) (*models.LogEntryAnon, error) {
+		return client.UploadTlog(ctx, signer, entry.Spec.Signature, entry.Spec.Payload, entry.Spec.Cert, entry.Spec.Format)
+	})
+}
+
+// run returns the run of entry.
+func (q *TransparencyQueue) run(ctx context.Context, entry *v1alpha1.TransparencyEntry) (objects.TektonObject, error) {
+	if entry.Spec.Run.Kind == "PipelineRun" {
+		pr, err := q.Pipelineclientset.TektonV1beta1().PipelineRuns(entry.Namespace).Get(ctx, entry.Spec.Run.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return objects.NewPipelineRunObject(pr), nil
+	}
+	tr, err := q.Pipelineclientset.TektonV1beta1().TaskRuns(entry.Namespace).Get(ctx, entry.Spec.Run.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return objects.NewTaskRunObject(tr), nil
+}
+
+func (q *TransparencyQueue) update(ctx context.Context, entry *v1alpha1.TransparencyEntry) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(entry)
+	if err != nil {
+		return err
+	}
+	object := &unstructured.Unstructured{Object: u}
+	object.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("TransparencyEntry"))
+	_, err = q.DynamicClient.Resource(v1alpha1.TransparencyEntryResource).Namespace(entry.Namespace).Update(ctx, object, metav1.UpdateOptions{})
+	return err
+}
+
+func (q *TransparencyQueue) delete(ctx context.Context, entry *v1alpha1.TransparencyEntry) error {
+	err := q.DynamicClient.Resource(v1alpha1.TransparencyEntryResource).Namespace(entry.Namespace).Delete(ctx, entry.Name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// queuedSigner is the signer a queued entry was signed by. Only its public key and
+// certificate are known, which is all uploading the entry needs.
+type queuedSigner struct {
+	signing.Signer
+	pub  crypto.PublicKey
+	cert string
+}
+
+func (s queuedSigner) PublicKey(...sigsig.PublicKeyOption) (crypto.PublicKey, error) {
+	if s.pub == nil {
+		return nil, errors.New("no public key")
+	}
+	return s.pub, nil
+}
+
+func (s queuedSigner) Cert() string {
+	return s.cert
+}
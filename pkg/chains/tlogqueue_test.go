@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestRekorUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", &url.Error{Op: "Post", URL: "https://rekor.sigstore.dev", Err: errors.New("connection refused")}, true},
+		{"wrapped", fmt.Errorf("uploading entry: %w", &url.Error{Op: "Post", Err: errors.New("timeout")}), true},
+		{"server error", &rekorV2StatusError{status: "503 Service Unavailable", code: 503}, true},
+		{"timed out", fmt.Errorf("uploading entry to https://rekor.sigstore.dev timed out after 5s: %w", context.DeadlineExceeded), true},
+		{"rejected", &rekorV2StatusError{status: "400 Bad Request", code: 400}, false},
+		{"other", errors.New("verifying inclusion of entry 1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rekorUnreachable(tt.err); got != tt.want {
+				t.Errorf("rekorUnreachable() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+type unreachableRekor struct{}
+
+func (unreachableRekor) UploadTlog(context.Context, signing.Signer, []byte, []byte, string, string) (*models.LogEntryAnon, error) {
+	return nil, &url.Error{Op: "Post", URL: "https://rekor.sigstore.dev", Err: errors.New("connection refused")}
+}
+
+func TestTransparencyQueue(t *testing.T) {
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.New[string]("mock"),
+				Signer:         "x509",
+			},
+		},
+		Transparency: config.TransparencyConfig{Enabled: true, URL: "https://rekor.sigstore.dev", QueueEnabled: true},
+	}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "ns", UID: "uid"}})
+
+	oldRekor := getRekor
+	defer func() { getRekor = oldRekor }()
+	getRekor = func(config.TransparencyConfig) (rekorClient, error) {
+		return unreachableRekor{}, nil
+	}
+
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	dc := dynamicfake.NewSimpleDynamicClient(scheme)
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, cfg)
+	ts := &ObjectSigner{
+		Backends:          map[string]storage.Backend{"mock": &mockBackend{backendType: "mock"}},
+		SecretPath:        "./signing/x509/testdata/",
+		Pipelineclientset: ps,
+		DynamicClient:     dc,
+	}
+	tekton.CreateObject(t, ctx, ps, tro)
+
+	if err := ts.Sign(ctx, tro); err != nil {
+		t.Fatalf("Signer.Sign() = %v", err)
+	}
+	signed, err := tekton.GetObject(t, ctx, ps, tro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := signed.GetAnnotations()
+	if annotations[ChainsAnnotation] != "true" || annotations[TransparencyPendingAnnotation] != "true" {
+		t.Errorf("annotations = %v, want the run signed with its entry pending", annotations)
+	}
+	entries := func() []v1alpha1.TransparencyEntry {
+		t.Helper()
+		list, err := dc.Resource(v1alpha1.TransparencyEntryResource).Namespace("ns").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries []v1alpha1.TransparencyEntry
+		for _, item := range list.Items {
+			var entry v1alpha1.TransparencyEntry
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &entry); err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	queued := entries()
+	if len(queued) != 1 || queued[0].Spec.Run.UID != "uid" || queued[0].Spec.PublicKey == "" || len(queued[0].Spec.Signature) == 0 {
+		t.Fatalf("queued entries = %+v", queued)
+	}
+
+	q := &TransparencyQueue{DynamicClient: dc, Pipelineclientset: ps}
+	if err := q.Flush(ctx, cfg.Transparency); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	if got := entries(); len(got) != 1 || got[0].Status.Attempts != 1 || got[0].Status.LastError == "" {
+		t.Errorf("entries after a failed attempt = %+v", got)
+	}
+
+	rekor := &mockRekor{}
+	getRekor = func(config.TransparencyConfig) (rekorClient, error) {
+		return rekor, nil
+	}
+	if err := q.Flush(ctx, cfg.Transparency); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+	if len(rekor.entries) != 1 {
+		t.Errorf("uploaded %d entries, want 1", len(rekor.entries))
+	}
+	if got := entries(); len(got) != 0 {
+		t.Errorf("entries after the upload = %+v, want none", got)
+	}
+	uploaded, err := tekton.GetObject(t, ctx, ps, tro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations = uploaded.GetAnnotations()
+	if _, ok := annotations[TransparencyPendingAnnotation]; ok {
+		t.Errorf("expected %s to be removed", TransparencyPendingAnnotation)
+	}
+	if want := "https://rekor.sigstore.dev/api/v1/log/entries?logIndex=0"; annotations[ChainsTransparencyAnnotation] != want {
+		t.Errorf("%s = %q, want %q", ChainsTransparencyAnnotation, annotations[ChainsTransparencyAnnotation], want)
+	}
+}
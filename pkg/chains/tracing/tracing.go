@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing instruments the signing pipeline with OpenTelemetry spans
+// and configures exporting them over OTLP.
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// TracerName is the name of the tracer used for all Chains spans.
+	TracerName  = "github.com/tektoncd/chains"
+	serviceName = "tekton-chains"
+)
+
+// Attribute keys attached to Chains spans.
+const (
+	ObjectKeyAttr = attribute.Key("chains.object.key")
+	KindAttr      = attribute.Key("chains.object.kind")
+	FormatAttr    = attribute.Key("chains.format")
+	SignerAttr    = attribute.Key("chains.signer")
+	BackendAttr   = attribute.Key("chains.storage.backend")
+)
+
+var (
+	mu       sync.Mutex
+	current  config.TracingConfig
+	provider *sdktrace.TracerProvider
+)
+
+// Start starts a span from the Chains tracer.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Setup installs the global tracer provider according to cfg. It is safe to call on
+// every config update: the provider is only replaced when the tracing config changed,
+// and spans are dropped when no endpoint is configured.
+func Setup(ctx context.Context, cfg config.TracingConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if provider != nil && cfg == current {
+		return nil
+	}
+	if provider != nil {
+		if err := provider.Shutdown(ctx); err != nil {
+			logging.FromContext(ctx).Warnf("error shutting down tracer provider: %v", err)
+		}
+		provider = nil
+	}
+	current = cfg
+	if cfg.Endpoint == "" {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		return nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return nil
+}
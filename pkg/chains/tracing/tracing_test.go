@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	old := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(old)
+
+	ctx, parent := Start(context.Background(), "parent", ObjectKeyAttr.String("ns/name"))
+	_, child := Start(ctx, "child", BackendAttr.String("oci"))
+	End(child, errors.New("boom"))
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.Parent().SpanID() != p.SpanContext().SpanID() {
+		t.Error("expected child span to be parented to the parent span")
+	}
+	if c.Status().Code != codes.Error || c.Status().Description != "boom" {
+		t.Errorf("unexpected child status %v", c.Status())
+	}
+	if p.Status().Code != codes.Unset {
+		t.Errorf("unexpected parent status %v", p.Status())
+	}
+	if got := p.Attributes(); len(got) != 1 || got[0].Value.AsString() != "ns/name" {
+		t.Errorf("unexpected parent attributes %v", got)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+	if err := Setup(ctx, config.TracingConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if provider != nil {
+		t.Error("expected no provider without an endpoint")
+	}
+
+	cfg := config.TracingConfig{Endpoint: "localhost:4318", Insecure: true}
+	if err := Setup(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	first := provider
+	if first == nil {
+		t.Fatal("expected provider to be configured")
+	}
+	if err := Setup(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if provider != first {
+		t.Error("expected provider to be reused for an unchanged config")
+	}
+
+	if err := Setup(ctx, config.TracingConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if provider != nil {
+		t.Error("expected provider to be removed once the endpoint is unset")
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// TruncatedAnnotation lists the keys of the artifacts of a run whose attestations were
+// truncated to fit in provenance.max-attestation-kb.
+const TruncatedAnnotation = "chains.tekton.dev/truncated"
+
+// truncationRule drops a part of a decoded attestation, and returns whether there was
+// anything to drop.
+type truncationRule struct {
+	name string
+	drop func(statement map[string]interface{}) bool
+}
+
+// truncationRules are applied in order to attestations that are too large, until
+// they fit.
+var truncationRules = []truncationRule{
+	{name: "byproduct-content", drop: dropByproductContent},
+	{name: "byproducts", drop: dropByproducts},
+	{name: "annotations", drop: dropAnnotations},
+}
+
+// untruncatedFields hold param and result values, which are recorded as they are even
+// if they have an annotations field.
+var untruncatedFields = map[string]bool{
+	"parameters":         true,
+	"externalParameters": true,
+	"internalParameters": true,
+	"results":            true,
+}
+
+// truncate returns raw, if it is at most maxKB kilobytes or maxKB is zero, or raw
+// re-encoded after applying truncationRules until it fits, along with the names of the
+// rules that dropped something. An error is returned if raw doesn't fit once all rules
+// were applied.
+func truncate(raw []byte, maxKB int) ([]byte, []string, error) {
+	limit := maxKB * 1024
+	if limit <= 0 || len(raw) <= limit {
+		return raw, nil, nil
+	}
+	tooLarge := fmt.Errorf("attestation of %d bytes exceeds the maximum of %d KB", len(raw), maxKB)
+
+	var statement map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&statement); err != nil {
+		return nil, nil, tooLarge
+	}
+	var applied []string
+	for _, rule := range truncationRules {
+		if !rule.drop(statement) {
+			continue
+		}
+		applied = append(applied, rule.name)
+		truncated, err := json.Marshal(statement)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(truncated) <= limit {
+			return truncated, applied, nil
+		}
+	}
+	return nil, nil, tooLarge
+}
+
+// dropByproductContent drops the content of the byproducts of SLSA v1.0 predicates that
+// have a digest, which still identifies them.
+func dropByproductContent(statement map[string]interface{}) bool {
+	predicate, _ := statement["predicate"].(map[string]interface{})
+	runDetails, _ := predicate["runDetails"].(map[string]interface{})
+	byproducts, _ := runDetails["byproducts"].([]interface{})
+	dropped := false
+	for _, bp := range byproducts {
+		bp, _ := bp.(map[string]interface{})
+		if _, ok := bp["digest"]; !ok {
+			continue
+		}
+		if _, ok := bp["content"]; ok {
+			delete(bp, "content")
+			delete(bp, "mediaType")
+			dropped = true
+		}
+	}
+	return dropped
+}
+
+// dropByproducts drops the byproducts of SLSA v1.0 predicates.
+func dropByproducts(statement map[string]interface{}) bool {
+	predicate, _ := statement["predicate"].(map[string]interface{})
+	runDetails, _ := predicate["runDetails"].(map[string]interface{})
+	if _, ok := runDetails["byproducts"]; !ok {
+		return false
+	}
+	delete(runDetails, "byproducts")
+	return true
+}
+
+// dropAnnotations drops the annotations of the runs and steps recorded in the
+// predicate.
+func dropAnnotations(statement map[string]interface{}) bool {
+	return dropAnnotationsFrom(statement["predicate"])
+}
+
+func dropAnnotationsFrom(v interface{}) bool {
+	dropped := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if annotations, ok := v["annotations"]; ok {
+			delete(v, "annotations")
+			// Steps record their annotations as null when they have none.
+			dropped = annotations != nil
+		}
+		for key, field := range v {
+			if untruncatedFields[key] {
+				continue
+			}
+			dropped = dropAnnotationsFrom(field) || dropped
+		}
+	case []interface{}:
+		for _, item := range v {
+			dropped = dropAnnotationsFrom(item) || dropped
+		}
+	}
+	return dropped
+}
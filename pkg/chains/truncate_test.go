@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestTruncate(t *testing.T) {
+	big := strings.Repeat("x", 600)
+	statement := func(byproducts, annotations string) string {
+		return `{"_type":"https://in-toto.io/Statement/v0.1","predicate":{` +
+			`"buildDefinition":{"externalParameters":{"runSpec":{"annotations":{"keep":"` + big + `"}}}},` +
+			`"invocation":{"environment":{"annotations":{"a":"` + annotations + `"}}},` +
+			`"buildConfig":{"steps":[{"entryPoint":"build","annotations":null}]},` +
+			`"runDetails":{"builder":{"id":"chains"},"byproducts":[{"content":"` + byproducts + `"}]}}}`
+	}
+
+	tests := []struct {
+		name      string
+		raw       string
+		maxKB     int
+		truncated []string
+		dropped   []string
+		kept      []string
+		wantErr   bool
+	}{{
+		name:  "no cap",
+		raw:   statement(big, big),
+		maxKB: 0,
+	}, {
+		name:  "fits",
+		raw:   statement("", ""),
+		maxKB: 1,
+	}, {
+		name:      "byproducts dropped",
+		raw:       statement(big, ""),
+		maxKB:     1,
+		truncated: []string{"byproducts"},
+		dropped:   []string{`"byproducts"`},
+	}, {
+		name:      "content of byproducts with digests dropped",
+		raw:       strings.Replace(statement(big, ""), `[{"content":`, `[{"name":"pipelineRunResults/report","digest":{"sha256":"abc"},"content":`, 1),
+		maxKB:     1,
+		truncated: []string{"byproduct-content"},
+		dropped:   []string{`"content"`},
+		kept:      []string{`"byproducts":[{"digest":{"sha256":"abc"},"name":"pipelineRunResults/report"}]`},
+	}, {
+		name:      "annotations dropped",
+		raw:       statement(big, big),
+		maxKB:     1,
+		truncated: []string{"byproducts", "annotations"},
+		dropped:   []string{`"byproducts"`, `"a":`, `"entryPoint":"build","annotations"`},
+	}, {
+		name:    "still too large",
+		raw:     `{"predicate":{"invocation":{"parameters":{"p":"` + strings.Repeat("x", 2048) + `"}}}}`,
+		maxKB:   1,
+		wantErr: true,
+	}, {
+		name:    "not an object",
+		raw:     `"` + strings.Repeat("x", 2048) + `"`,
+		maxKB:   1,
+		wantErr: true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, truncated, err := truncate([]byte(tc.raw), tc.maxKB)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.truncated, truncated); diff != "" {
+				t.Errorf("truncate() truncated -want +got: %s", diff)
+			}
+			if len(tc.truncated) == 0 && string(got) != tc.raw {
+				t.Errorf("expected the payload to be unchanged, got %s", got)
+			}
+			if tc.maxKB > 0 && len(got) > tc.maxKB*1024 {
+				t.Errorf("expected at most %d KB, got %d bytes", tc.maxKB, len(got))
+			}
+			if !json.Valid(got) {
+				t.Errorf("expected valid JSON, got %s", got)
+			}
+			for _, d := range tc.dropped {
+				if strings.Contains(string(got), d) {
+					t.Errorf("expected %s to be dropped, got %s", d, got)
+				}
+			}
+			for _, k := range tc.kept {
+				if !strings.Contains(string(got), k) {
+					t.Errorf("expected %s to be kept, got %s", k, got)
+				}
+			}
+			// Param values are never truncated.
+			if !strings.Contains(string(got), `"keep":"`+big) {
+				t.Errorf("expected the parameters to be kept, got %s", got)
+			}
+		})
+	}
+}
+
+func TestPreview_Truncated(t *testing.T) {
+	cfg := config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")},
+			OCI:      config.Artifact{Format: "simplesigning", StorageBackend: sets.New[string]("oci")},
+		},
+		Provenance: config.ProvenanceConfig{MaxAttestationKB: 1},
+	}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "default",
+			UID:         "uid",
+			Annotations: map[string]string{"description": strings.Repeat("x", 2048)},
+		},
+	})
+	got, err := Preview(context.Background(), tro, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Error != "" {
+		t.Fatalf("unexpected previews %+v", got)
+	}
+	if diff := cmp.Diff([]string{"annotations"}, got[0].Truncated); diff != "" {
+		t.Errorf("Truncated -want +got: %s", diff)
+	}
+	if strings.Contains(string(got[0].Payload), "description") {
+		t.Errorf("expected the annotations to be dropped, got %s", got[0].Payload)
+	}
+}
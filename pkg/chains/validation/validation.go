@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation validates in-toto statements against a schema for their predicate
+// type, to catch formatter regressions and malformed type-hint data before payloads are
+// signed, and to sanity check them when they are verified.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	slsa02 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	slsa1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+)
+
+// statementInTotoV1 is the type of in-toto v1 statements.
+const statementInTotoV1 = "https://in-toto.io/Statement/v1"
+
+var (
+	// ErrNotStatement is returned for payloads that aren't in-toto statements.
+	ErrNotStatement = errors.New("payload is not an in-toto statement")
+	// ErrUnknownPredicateType is returned for statements with a predicate type that has
+	// no schema.
+	ErrUnknownPredicateType = errors.New("predicate type has no schema")
+)
+
+// Schema validates the predicate of a statement.
+type Schema func(predicate json.RawMessage) error
+
+// schemas are the schemas of the predicate types that are validated.
+var schemas = map[string]Schema{
+	slsa02.PredicateSLSAProvenance: validateSLSA02,
+	slsa1.PredicateSLSAProvenance:  validateSLSA1,
+}
+
+// statement is an in-toto statement with a predicate of any type.
+type statement struct {
+	Type          string `json:"_type"`
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate json.RawMessage `json:"predicate"`
+}
+
+// Validate validates the in-toto statement in payload, and its predicate against the
+// schema of its predicate type, which it returns. It returns ErrNotStatement or
+// ErrUnknownPredicateType if the payload can't be validated. It doesn't check the build
+// against any policy.
+func Validate(payload []byte) (string, error) {
+	var st statement
+	if err := json.Unmarshal(payload, &st); err != nil || st.Type == "" {
+		return "", ErrNotStatement
+	}
+	schema, ok := schemas[st.PredicateType]
+	if !ok {
+		return st.PredicateType, fmt.Errorf("%w: %q", ErrUnknownPredicateType, st.PredicateType)
+	}
+	if err := schema(st.Predicate); err != nil {
+		return st.PredicateType, err
+	}
+	return st.PredicateType, validateStatement(st)
+}
+
+// validateStatement validates the type and subjects of st.
+func validateStatement(st statement) error {
+	if st.Type != in_toto.StatementInTotoV01 && st.Type != statementInTotoV1 {
+		return fmt.Errorf("unknown statement type %q", st.Type)
+	}
+	for i, s := range st.Subject {
+		if s.Name == "" {
+			return fmt.Errorf("subject %d has no name", i)
+		}
+		if len(s.Digest) == 0 {
+			return fmt.Errorf("subject %s has no digest", s.Name)
+		}
+		for alg, d := range s.Digest {
+			if d == "" || strings.Trim(d, "0123456789abcdef") != "" {
+				return fmt.Errorf("subject %s has an invalid %s digest %q", s.Name, alg, d)
+			}
+		}
+	}
+	return nil
+}
+
+func validateSLSA02(predicate json.RawMessage) error {
+	var p slsa02.ProvenancePredicate
+	if err := json.Unmarshal(predicate, &p); err != nil {
+		return fmt.Errorf("invalid predicate: %w", err)
+	}
+	if p.Builder.ID == "" {
+		return errors.New("predicate has no builder.id")
+	}
+	if p.BuildType == "" {
+		return errors.New("predicate has no buildType")
+	}
+	if p.Metadata != nil {
+		return validateTimes(p.Metadata.BuildStartedOn, p.Metadata.BuildFinishedOn)
+	}
+	return nil
+}
+
+func validateSLSA1(predicate json.RawMessage) error {
+	var p slsa1.ProvenancePredicate
+	if err := json.Unmarshal(predicate, &p); err != nil {
+		return fmt.Errorf("invalid predicate: %w", err)
+	}
+	if p.RunDetails.Builder.ID == "" {
+		return errors.New("predicate has no runDetails.builder.id")
+	}
+	if p.BuildDefinition.BuildType == "" {
+		return errors.New("predicate has no buildDefinition.buildType")
+	}
+	return validateTimes(p.RunDetails.BuildMetadata.StartedOn, p.RunDetails.BuildMetadata.FinishedOn)
+}
+
+// validateTimes validates that a build didn't finish before it started.
+func validateTimes(started, finished *time.Time) error {
+	if started != nil && finished != nil && finished.Before(*started) {
+		return fmt.Errorf("build finished on %s before it started on %s", finished.Format(time.RFC3339), started.Format(time.RFC3339))
+	}
+	return nil
+}
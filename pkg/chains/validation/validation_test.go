@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr error
+		invalid bool
+	}{{
+		name: "slsa v0.2",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://slsa.dev/provenance/v0.2",
+			"subject": [{"name": "gcr.io/foo/bar", "digest": {"sha256": "05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5"}}],
+			"predicate": {
+				"builder": {"id": "https://tekton.dev/chains/v2"},
+				"buildType": "tekton.dev/v1beta1/TaskRun"
+			}
+		}`,
+	}, {
+		name: "slsa v1 without build type",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v1",
+			"predicateType": "https://slsa.dev/provenance/v1",
+			"predicate": {"runDetails": {"builder": {"id": "https://tekton.dev/chains/v2"}}}
+		}`,
+		invalid: true,
+	}, {
+		name: "malformed digest",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://slsa.dev/provenance/v0.2",
+			"subject": [{"name": "gcr.io/foo/bar", "digest": {"sha256": "sha256:05f95b26"}}],
+			"predicate": {
+				"builder": {"id": "https://tekton.dev/chains/v2"},
+				"buildType": "tekton.dev/v1beta1/TaskRun"
+			}
+		}`,
+		invalid: true,
+	}, {
+		name: "unknown predicate type",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://example.com/custom",
+			"predicate": {}
+		}`,
+		wantErr: ErrUnknownPredicateType,
+	}, {
+		name:    "not a statement",
+		payload: `{"status": "Succeeded"}`,
+		wantErr: ErrNotStatement,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Validate([]byte(tt.payload))
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+				}
+			case tt.invalid:
+				if err == nil || errors.Is(err, ErrNotStatement) || errors.Is(err, ErrUnknownPredicateType) {
+					t.Errorf("Validate() = %v, want a validation error", err)
+				}
+			case err != nil:
+				t.Errorf("Validate() = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// typeHintSuffixes are the suffixes of the names of the params and results Chains reads
+// artifacts from, which are never capped.
+var typeHintSuffixes = []string{
+	"IMAGE_URL", "IMAGE_DIGEST", "ARTIFACT_URI", "ARTIFACT_DIGEST",
+	artifacts.ArtifactsInputsResultName, artifacts.ArtifactsOutputsResultName,
+	"_" + artifacts.VEXResultName,
+	artifacts.TektonBundleURLSuffix, artifacts.TektonBundleDigestSuffix,
+}
+
+// typeHinted returns whether name is the name of a param or result Chains reads
+// artifacts from.
+func typeHinted(name string) bool {
+	switch name {
+	case "IMAGES", artifacts.VEXResultName, attest.CommitParam, attest.URLParam:
+		return true
+	}
+	for _, suffix := range typeHintSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadObject returns the object the payloads of obj are generated from, without the
+// values and env vars cfg leaves out of provenance.
+func payloadObject(obj objects.TektonObject, cfg config.ProvenanceConfig) objects.TektonObject {
+	return filterStepEnv(capValues(obj, cfg), cfg)
+}
+
+// capValues returns a copy of obj, and for PipelineRuns of their TaskRuns, whose param
+// and result values larger than cfg.MaxValueKB, or redacted by attest.RedactAnnotation, are
+// replaced with their digest or left out, for payloads to be generated from. Type hinted
+// params and results are kept, as the artifacts they describe are read from them. obj
+// itself is returned if values aren't capped.
+func capValues(obj objects.TektonObject, cfg config.ProvenanceConfig) objects.TektonObject {
+	limit := cfg.MaxValueKB * 1024
+	skip := cfg.OversizedValues == config.OversizedSkip
+
+	switch o := obj.(type) {
+	case *objects.TaskRunObject:
+		redact := redacted(o.Annotations)
+		if limit <= 0 && redact.Len() == 0 {
+			return obj
+		}
+		return objects.NewTaskRunObject(capTaskRun(o.TaskRun, limit, skip, redact))
+	case *objects.PipelineRunObject:
+		redact := redacted(o.Annotations)
+		if limit <= 0 && redact.Len() == 0 && !anyRedacted(o.GetTaskRuns()) {
+			return obj
+		}
+		pr := o.PipelineRun.DeepCopy()
+		pr.Spec.Params = capParams(pr.Spec.Params, limit, skip, redact)
+		var results []v1beta1.PipelineRunResult
+		for _, r := range pr.Status.PipelineResults {
+			if value, ok := capValue(r.Name, r.Value, limit, skip, redact); ok {
+				r.Value = value
+				results = append(results, r)
+			}
+		}
+		pr.Status.PipelineResults = results
+		capped := objects.NewPipelineRunObject(pr)
+		for _, tr := range o.GetTaskRuns() {
+			// The values the PipelineRun redacts are redacted in its TaskRuns too.
+			capped.AppendTaskRun(capTaskRun(tr, limit, skip, redact.Union(redacted(tr.Annotations))))
+		}
+		return capped
+	}
+	return obj
+}
+
+// redacted returns the names of the params and results the attest.RedactAnnotation of a run
+// with annotations redacts.
+func redacted(annotations map[string]string) sets.Set[string] {
+	names := sets.New[string]()
+	for _, name := range strings.Split(annotations[attest.RedactAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names.Insert(name)
+		}
+	}
+	return names
+}
+
+// anyRedacted returns whether any of trs redacts values.
+func anyRedacted(trs []*v1beta1.TaskRun) bool {
+	for _, tr := range trs {
+		if redacted(tr.Annotations).Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// capTaskRun returns a copy of tr whose oversized and redacted param and result values
+// are capped.
+func capTaskRun(tr *v1beta1.TaskRun, limit int, skip bool, redact sets.Set[string]) *v1beta1.TaskRun {
+	tr = tr.DeepCopy()
+	tr.Spec.Params = capParams(tr.Spec.Params, limit, skip, redact)
+	var results []v1beta1.TaskRunResult
+	for _, r := range tr.Status.TaskRunResults {
+		if value, ok := capValue(r.Name, r.Value, limit, skip, redact); ok {
+			r.Value = value
+			results = append(results, r)
+		}
+	}
+	tr.Status.TaskRunResults = results
+	return tr
+}
+
+func capParams(params v1beta1.Params, limit int, skip bool, redact sets.Set[string]) v1beta1.Params {
+	var capped v1beta1.Params
+	for _, p := range params {
+		if value, ok := capValue(p.Name, p.Value, limit, skip, redact); ok {
+			p.Value = value
+			capped = append(capped, p)
+		}
+	}
+	return capped
+}
+
+// capValue returns the value to record for the param or result name with value, and
+// false if it must be left out. Values are measured by the size of their string, or of
+// their JSON encoding for arrays and objects, which is also what their digest is of.
+// Redacted values are always replaced with their digest, and values are not capped if
+// limit isn't positive.
+func capValue(name string, value v1beta1.ParamValue, limit int, skip bool, redact sets.Set[string]) (v1beta1.ParamValue, bool) {
+	if typeHinted(name) {
+		return value, true
+	}
+	raw := []byte(value.StringVal)
+	if value.Type != v1beta1.ParamTypeString {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return value, true
+		}
+	}
+	if !redact.Has(name) {
+		if limit <= 0 || len(raw) <= limit {
+			return value, true
+		}
+		if skip {
+			return v1beta1.ParamValue{}, false
+		}
+	}
+	sum := sha256.Sum256(raw)
+	return *v1beta1.NewStructuredValues("sha256:" + hex.EncodeToString(sum[:])), true
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestCapValues(t *testing.T) {
+	huge := strings.Repeat("x", 2048)
+	sum := sha256.Sum256([]byte(huge))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	list := *v1beta1.NewStructuredValues("a", huge)
+	raw, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum = sha256.Sum256(raw)
+	listDigest := "sha256:" + hex.EncodeToString(sum[:])
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Spec: v1beta1.TaskRunSpec{Params: []v1beta1.Param{
+			{Name: "small", Value: *v1beta1.NewStructuredValues("foo")},
+			{Name: "manifest", Value: *v1beta1.NewStructuredValues(huge)},
+		}},
+		Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+			TaskRunResults: []v1beta1.TaskRunResult{
+				{Name: "LOGS", Value: *v1beta1.NewStructuredValues(huge)},
+				{Name: "IMAGES", Value: *v1beta1.NewStructuredValues(huge)},
+				{Name: "LIST", Value: list},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name        string
+		cfg         config.ProvenanceConfig
+		wantParams  v1beta1.Params
+		wantResults []v1beta1.TaskRunResult
+	}{{
+		name:        "not capped",
+		wantParams:  tr.Spec.Params,
+		wantResults: tr.Status.TaskRunResults,
+	}, {
+		name: "digest",
+		cfg:  config.ProvenanceConfig{MaxValueKB: 1},
+		wantParams: v1beta1.Params{
+			{Name: "small", Value: *v1beta1.NewStructuredValues("foo")},
+			{Name: "manifest", Value: *v1beta1.NewStructuredValues(digest)},
+		},
+		wantResults: []v1beta1.TaskRunResult{
+			{Name: "LOGS", Value: *v1beta1.NewStructuredValues(digest)},
+			{Name: "IMAGES", Value: *v1beta1.NewStructuredValues(huge)},
+			{Name: "LIST", Value: *v1beta1.NewStructuredValues(listDigest)},
+		},
+	}, {
+		name: "skip",
+		cfg:  config.ProvenanceConfig{MaxValueKB: 1, OversizedValues: config.OversizedSkip},
+		wantParams: v1beta1.Params{
+			{Name: "small", Value: *v1beta1.NewStructuredValues("foo")},
+		},
+		wantResults: []v1beta1.TaskRunResult{
+			{Name: "IMAGES", Value: *v1beta1.NewStructuredValues(huge)},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := capValues(objects.NewTaskRunObject(tr), tt.cfg).(*objects.TaskRunObject)
+			if diff := cmp.Diff(tt.wantParams, got.Spec.Params); diff != "" {
+				t.Errorf("params -want +got: %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantResults, got.Status.TaskRunResults); diff != "" {
+				t.Errorf("results -want +got: %s", diff)
+			}
+		})
+	}
+	if len(tr.Status.TaskRunResults) != 3 || tr.Status.TaskRunResults[0].Value.StringVal != huge {
+		t.Error("expected the run not to be modified")
+	}
+}
+
+func TestCapValues_PipelineRun(t *testing.T) {
+	huge := strings.Repeat("x", 2048)
+	pro := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Spec:       v1beta1.PipelineRunSpec{Params: []v1beta1.Param{{Name: "manifest", Value: *v1beta1.NewStructuredValues(huge)}}},
+		Status: v1beta1.PipelineRunStatus{PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+			PipelineResults: []v1beta1.PipelineRunResult{{Name: "LOGS", Value: *v1beta1.NewStructuredValues(huge)}},
+		}},
+	})
+	pro.AppendTaskRun(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-build"},
+		Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+			TaskRunResults: []v1beta1.TaskRunResult{{Name: "LOGS", Value: *v1beta1.NewStructuredValues(huge)}},
+		}},
+	})
+
+	got := capValues(pro, config.ProvenanceConfig{MaxValueKB: 1, OversizedValues: config.OversizedSkip}).(*objects.PipelineRunObject)
+	if len(got.Spec.Params) != 0 || len(got.Status.PipelineResults) != 0 {
+		t.Errorf("expected the oversized values of the pipelinerun to be skipped, got %v and %v", got.Spec.Params, got.Status.PipelineResults)
+	}
+	trs := got.GetTaskRuns()
+	if len(trs) != 1 || len(trs[0].Status.TaskRunResults) != 0 {
+		t.Errorf("expected the oversized values of the taskruns to be skipped, got %v", trs)
+	}
+	if len(pro.GetTaskRuns()[0].Status.TaskRunResults) != 1 {
+		t.Error("expected the taskruns not to be modified")
+	}
+}
+
+func TestPreview_CappedValues(t *testing.T) {
+	huge := strings.Repeat("x", 2048)
+	cfg := config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")},
+			OCI:      config.Artifact{Format: "simplesigning", StorageBackend: sets.New[string]("oci")},
+		},
+		Provenance: config.ProvenanceConfig{MaxValueKB: 1},
+	}
+	tro := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", UID: "uid"},
+		Spec:       v1beta1.TaskRunSpec{Params: []v1beta1.Param{{Name: "manifest", Value: *v1beta1.NewStructuredValues(huge)}}},
+	})
+	got, err := Preview(context.Background(), tro, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Error != "" {
+		t.Fatalf("unexpected previews %+v", got)
+	}
+	if strings.Contains(string(got[0].Payload), huge) {
+		t.Error("expected the oversized param not to be in the payload")
+	}
+	if !strings.Contains(string(got[0].Payload), `"manifest":"sha256:`) {
+		t.Errorf("expected the digest of the oversized param in the payload, got %s", got[0].Payload)
+	}
+}
+
+func TestCapValues_Redact(t *testing.T) {
+	sum := sha256.Sum256([]byte("s3cr3t"))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	pro := objects.NewPipelineRunObject(&v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: map[string]string{attest.RedactAnnotation: "token, IMAGE_URL"}},
+		Spec: v1beta1.PipelineRunSpec{Params: []v1beta1.Param{
+			{Name: "token", Value: *v1beta1.NewStructuredValues("s3cr3t")},
+			{Name: "revision", Value: *v1beta1.NewStructuredValues("main")},
+		}},
+	})
+	pro.AppendTaskRun(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-build", Annotations: map[string]string{attest.RedactAnnotation: "license"}},
+		Spec: v1beta1.TaskRunSpec{Params: []v1beta1.Param{
+			{Name: "token", Value: *v1beta1.NewStructuredValues("s3cr3t")},
+			{Name: "license", Value: *v1beta1.NewStructuredValues("s3cr3t")},
+		}},
+		Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+			// Type hinted results are kept, even when redacted.
+			TaskRunResults: []v1beta1.TaskRunResult{{Name: "IMAGE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/bar")}},
+		}},
+	})
+
+	// Redacted values are replaced with their digest even when they are small, and
+	// values aren't capped otherwise.
+	got := capValues(pro, config.ProvenanceConfig{OversizedValues: config.OversizedSkip}).(*objects.PipelineRunObject)
+	wantParams := v1beta1.Params{
+		{Name: "token", Value: *v1beta1.NewStructuredValues(digest)},
+		{Name: "revision", Value: *v1beta1.NewStructuredValues("main")},
+	}
+	if diff := cmp.Diff(wantParams, got.Spec.Params); diff != "" {
+		t.Errorf("pipelinerun params -want +got: %s", diff)
+	}
+	tr := got.GetTaskRuns()[0]
+	wantParams = v1beta1.Params{
+		{Name: "token", Value: *v1beta1.NewStructuredValues(digest)},
+		{Name: "license", Value: *v1beta1.NewStructuredValues(digest)},
+	}
+	if diff := cmp.Diff(wantParams, tr.Spec.Params); diff != "" {
+		t.Errorf("taskrun params -want +got: %s", diff)
+	}
+	if diff := cmp.Diff(pro.GetTaskRuns()[0].Status.TaskRunResults, tr.Status.TaskRunResults); diff != "" {
+		t.Errorf("taskrun results -want +got: %s", diff)
+	}
+	if pro.Spec.Params[0].Value.StringVal != "s3cr3t" {
+		t.Error("expected the run not to be modified")
+	}
+
+	tro := objects.NewTaskRunObject(pro.GetTaskRuns()[0])
+	if capped := capValues(tro, config.ProvenanceConfig{}).(*objects.TaskRunObject); capped.Spec.Params[0].Value.StringVal != "s3cr3t" || capped.Spec.Params[1].Value.StringVal != digest {
+		t.Errorf("expected only the values the taskrun redacts to be redacted, got %v", capped.Spec.Params)
+	}
+}
@@ -57,7 +57,11 @@ func (tv *TaskRunVerifier) VerifyTaskRun(ctx context.Context, tr *v1beta1.TaskRu
 	if err != nil {
 		return err
 	}
-	signers := allSigners(ctx, tv.SecretPath, cfg)
+	sp, err := SigningSecretPath(ctx, tv.KubeClient, tv.SecretPath, cfg)
+	if err != nil {
+		return err
+	}
+	signers := AllSigners(ctx, sp, cfg)
 
 	for _, signableType := range enabledSignableTypes {
 		if !signableType.Enabled(cfg) {
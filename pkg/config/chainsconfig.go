@@ -0,0 +1,606 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// DataFromSpec renders a ChainsConfig spec into chains-config ConfigMap data. Unset
+// fields are left out, so that they keep their defaults when the data is parsed.
+func DataFromSpec(spec *v1alpha1.ChainsConfigSpec) map[string]string {
+	data := map[string]string{}
+	set := func(key, value string) {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	setBool := func(key string, value bool) {
+		if value {
+			data[key] = "true"
+		}
+	}
+	setList := func(key string, values []string) {
+		set(key, strings.Join(values, ","))
+	}
+	setDuration := func(key string, value *metav1.Duration) {
+		if value != nil {
+			data[key] = value.Duration.String()
+		}
+	}
+	setInt := func(key string, value int) {
+		if value != 0 {
+			data[key] = strconv.Itoa(value)
+		}
+	}
+	setInt64 := func(key string, value int64) {
+		if value != 0 {
+			data[key] = strconv.FormatInt(value, 10)
+		}
+	}
+	setFloat := func(key string, value float64) {
+		if value != 0 {
+			data[key] = strconv.FormatFloat(value, 'g', -1, 64)
+		}
+	}
+	setArtifact := func(formatKey, storageKey, signerKey string, artifact v1alpha1.ArtifactSpec) {
+		set(formatKey, artifact.Format)
+		setList(storageKey, artifact.Storage)
+		if artifact.Disabled {
+			data[storageKey] = ""
+		}
+		set(signerKey, artifact.Signer)
+	}
+
+	a := spec.Artifacts
+	setArtifact(taskrunFormatKey, taskrunStorageKey, taskrunSignerKey, a.TaskRuns)
+	setArtifact(pipelinerunFormatKey, pipelinerunStorageKey, pipelinerunSignerKey, a.PipelineRuns)
+	setBool(pipelinerunEnableDeepInspectionKey, a.PipelineRuns.EnableDeepInspection)
+	setList(pipelinerunBundleStorageKey, a.PipelineRuns.BundleStorage)
+	setBool(pipelinerunResultDigestsKey, a.PipelineRuns.ResultDigests)
+	setArtifact(ociFormatKey, ociStorageKey, ociSignerKey, a.OCI)
+	setList(vexStorageKey, a.VEX.Storage)
+	if a.VEX.Disabled {
+		data[vexStorageKey] = ""
+	}
+	set(vexSignerKey, a.VEX.Signer)
+	setList(tektonBundleStorageKey, a.TektonBundles.Storage)
+	if a.TektonBundles.Disabled {
+		data[tektonBundleStorageKey] = ""
+	}
+	set(tektonBundleSignerKey, a.TektonBundles.Signer)
+	setList(sourceStorageKey, a.Source.Storage)
+	if a.Source.Disabled {
+		data[sourceStorageKey] = ""
+	}
+	setBool(sourceFetchRunsKey, a.Source.FetchRuns)
+	setArtifact(customrunFormatKey, customrunStorageKey, customrunSignerKey, a.CustomRuns)
+
+	if s := spec.Storage.GCS; s != nil {
+		set(gcsBucketKey, s.Bucket)
+		set(gcsNameTemplateKey, s.NameTemplate)
+	}
+	if s := spec.Storage.OCI; s != nil {
+		set(ociRepositoryKey, s.Repository)
+		setBool(ociRepositoryInsecureKey, s.Insecure)
+		setList(ociTagsKey, s.Tags)
+		setBool(ociAttachToPlatformsKey, s.AttachToPlatforms)
+	}
+	if s := spec.Storage.Tekton; s != nil {
+		setInt(tektonMaxAnnotationKey, s.MaxAnnotationSize)
+	}
+	if s := spec.Storage.DocDB; s != nil {
+		set(docDBUrlKey, s.URL)
+		set(docDBNameTemplateKey, s.NameTemplate)
+	}
+	if s := spec.Storage.Grafeas; s != nil {
+		set(grafeasProjectIDKey, s.ProjectID)
+		set(grafeasNoteIDKey, s.NoteID)
+		set(grafeasNoteHint, s.NoteHint)
+	}
+	if s := spec.Storage.PubSub; s != nil {
+		set(pubsubProvider, s.Provider)
+		set(pubsubTopic, s.Topic)
+		set(pubsubKafkaBootstrapServer, s.KafkaBootstrapServers)
+		set(pubsubEventHubsNamespaceKey, s.EventHubsNamespace)
+		setInt(pubsubBatchSizeKey, s.BatchSize)
+		setDuration(pubsubBatchLingerKey, s.BatchLinger)
+		set(pubsubGRPCAddressKey, s.GRPCAddress)
+		setBool(pubsubGRPCInsecureKey, s.GRPCInsecure)
+		set(pubsubGRPCCAPathKey, s.GRPCCAPath)
+		setInt(pubsubGRPCMaxInFlightKey, s.GRPCMaxInFlight)
+		setDuration(pubsubGRPCTimeoutKey, s.GRPCTimeout)
+		set(pubsubMQTTBrokerKey, s.MQTTBroker)
+		set(pubsubMQTTQoSKey, s.MQTTQoS)
+		set(pubsubMQTTClientIDKey, s.MQTTClientID)
+		set(pubsubMQTTCredentialsPathKey, s.MQTTCredentialsPath)
+		set(pubsubMQTTCAPathKey, s.MQTTCAPath)
+		set(pubsubSchemaRegistryURLKey, s.SchemaRegistryURL)
+		set(pubsubSchemaRegistryFormatKey, s.SchemaRegistryFormat)
+		set(pubsubSchemaRegistrySubjectNameKey, s.SchemaRegistrySubjectNameStrategy)
+		set(pubsubSchemaRegistryBasicAuthPathKey, s.SchemaRegistryBasicAuthPath)
+	}
+	if s := spec.Storage.IPFS; s != nil {
+		set(ipfsURLKey, s.URL)
+		set(ipfsTokenPathKey, s.TokenPath)
+	}
+	if s := spec.Storage.GitHub; s != nil {
+		set(githubURLKey, s.URL)
+		set(githubRepositoryKey, s.Repository)
+		setInt64(githubAppIDKey, s.AppID)
+		setInt64(githubInstallationIDKey, s.InstallationID)
+		set(githubPrivateKeyPathKey, s.PrivateKeyPath)
+	}
+	if s := spec.Storage.GitLab; s != nil {
+		set(gitlabURLKey, s.URL)
+		set(gitlabProjectKey, s.Project)
+		set(gitlabTokenPathKey, s.TokenPath)
+		set(gitlabPackageKey, s.Package)
+	}
+
+	if s := spec.Signers.X509; s != nil {
+		if f := s.Fulcio; f != nil {
+			setBool(x509SignerFulcioEnabled, f.Enabled)
+			set(x509SignerFulcioAddr, f.Address)
+			set(x509SignerFulcioOIDCIssuer, f.Issuer)
+			set(x509SignerFulcioProvider, f.Provider)
+		}
+		set(x509SignerIdentityTokenFile, s.IdentityTokenFile)
+		set(x509SignerIdentityTokenCmd, s.IdentityTokenCommand)
+		set(x509SignerIdentityTokenAud, s.IdentityTokenAudience)
+		set(x509SignerTUFMirrorURL, s.TUFMirrorURL)
+	}
+	if s := spec.Signers.KMS; s != nil {
+		set(kmsSignerKMSRef, s.KMSRef)
+		if auth := s.Auth; auth != nil {
+			set(kmsAuthAddress, auth.Address)
+			set(kmsAuthToken, auth.Token)
+			set(kmsAuthOIDCPath, auth.OIDCPath)
+			set(kmsAuthOIDCRole, auth.OIDCRole)
+			set(kmsAuthSpireSock, auth.SpireSock)
+			set(kmsAuthSpireAudience, auth.SpireAudience)
+		}
+	}
+	if s := spec.Signers.Secret; s != nil {
+		set(signingSecretNamespaceKey, s.Namespace)
+		set(signingSecretNameKey, s.Name)
+		setList(signingSecretAllowedNamespacesKey, s.AllowedNamespaces)
+	}
+	if s := spec.Signers.External; s != nil {
+		for file, ref := range s.Refs {
+			set(externalSecretsPrefix+file, ref)
+		}
+		setDuration(externalSecretsRefreshIntervalKey, s.RefreshInterval)
+	}
+
+	set(builderIDKey, spec.Builder.ID)
+	set(builderClusterKey, spec.Builder.Cluster)
+	set(builderBuildTypeKey, spec.Builder.BuildType)
+
+	switch {
+	case spec.Transparency.VerifyAnnotation:
+		data[transparencyEnabledKey] = "manual"
+	case spec.Transparency.Enabled:
+		data[transparencyEnabledKey] = "true"
+	}
+	set(transparencyURLKey, spec.Transparency.URL)
+	set(transparencyVersionKey, spec.Transparency.Version)
+	set(transparencyCAPathKey, spec.Transparency.CAPath)
+	set(transparencyCertPathKey, spec.Transparency.CertPath)
+	set(transparencyKeyPathKey, spec.Transparency.KeyPath)
+	set(transparencyProxyURLKey, spec.Transparency.ProxyURL)
+	setBool(transparencyQueueKey, spec.Transparency.Queue.Enabled)
+	setDuration(transparencyQueueIntervalKey, spec.Transparency.Queue.Interval)
+	setDuration(transparencyTimeoutKey, spec.Transparency.Timeout)
+
+	setList(watchedNamespacesKey, spec.Namespaces.Watched)
+	setList(excludedNamespacesKey, spec.Namespaces.Excluded)
+	set(labelSelectorKey, spec.LabelSelector)
+
+	if spec.Retry.MaxRetries != 0 {
+		data[retryMaxRetriesKey] = strconv.Itoa(spec.Retry.MaxRetries)
+	}
+	setDuration(retryInitialBackoffKey, spec.Retry.InitialBackoff)
+	setFloat(retryBackoffMultiplierKey, spec.Retry.BackoffMultiplier)
+	setDuration(retryMaxBackoffKey, spec.Retry.MaxBackoff)
+	setFloat(retryJitterKey, spec.Retry.Jitter)
+	setList(retryNoRetryErrorsKey, spec.Retry.NoRetryErrors)
+
+	setBool(finalizerBlockDeletionKey, spec.Finalizer.BlockDeletion)
+	setDuration(finalizerTimeoutKey, spec.Finalizer.Timeout)
+
+	setBool(dryRunEnabledKey, spec.DryRun.Enabled)
+	setList(dryRunNamespacesKey, spec.DryRun.Namespaces)
+	set(dryRunDirectoryKey, spec.DryRun.Directory)
+
+	set(auditSinkKey, spec.Audit.Sink)
+	set(auditFilePathKey, spec.Audit.FilePath)
+	setBool(auditKeyUsageKey, spec.Audit.KeyUsage)
+
+	setBool(webhookRejectInvalidKey, spec.Webhook.RejectInvalid)
+
+	setList(overridesAllowedKeysKey, spec.Overrides.AllowedKeys)
+
+	setInt(taskrunWorkersKey, spec.Concurrency.TaskRunWorkers)
+	setInt(pipelinerunWorkersKey, spec.Concurrency.PipelineRunWorkers)
+	setFloat(signingRateKey, spec.Concurrency.SigningRate)
+	setInt(signingBurstKey, spec.Concurrency.SigningBurst)
+	for backend, limit := range spec.Concurrency.BackendLimits {
+		data["storage."+backend+backendMaxConcurrencySuffix] = strconv.Itoa(limit)
+	}
+	for backend, timeout := range spec.Concurrency.BackendTimeouts {
+		data["storage."+backend+timeoutSuffix] = timeout.Duration.String()
+	}
+	for signer, timeout := range spec.Concurrency.SignerTimeouts {
+		data["signers."+signer+timeoutSuffix] = timeout.Duration.String()
+	}
+	setInt(namespaceLimitKey, spec.Concurrency.NamespaceLimit)
+	for namespace, limit := range spec.Concurrency.NamespaceLimits {
+		data[namespaceLimitPrefix+namespace+namespaceLimitSuffix] = strconv.Itoa(limit)
+	}
+	setInt(uploadParallelismKey, spec.Concurrency.UploadParallelism)
+
+	setBool(signingLeaseEnabledKey, spec.Lease.Enabled)
+	setDuration(signingLeaseDurationKey, spec.Lease.Duration)
+	setDuration(drainTimeoutKey, spec.Shutdown.DrainTimeout)
+	setBool(signingStatusEnabledKey, spec.SigningStatus.Enabled)
+	set(eventsSinkKey, spec.Events.Sink)
+	set(policyOPAURLKey, spec.Policy.OPAURL)
+	set(policyOPAPathKey, spec.Policy.OPAPath)
+	set(queryAddressKey, spec.Query.Address)
+	set(queryCertPathKey, spec.Query.CertPath)
+	set(queryKeyPathKey, spec.Query.KeyPath)
+	setInt(queryMaxResultsKey, spec.Query.MaxResults)
+	setInt(queryMaxRunsKey, spec.Query.MaxRuns)
+	set(grpcAddressKey, spec.GRPC.Address)
+	set(grpcCertPathKey, spec.GRPC.CertPath)
+	set(grpcKeyPathKey, spec.GRPC.KeyPath)
+	set(grpcClientCAPathKey, spec.GRPC.ClientCAPath)
+	setBool(publicKeysEnabledKey, spec.PublicKeys.Enabled)
+	setBool(conformanceEnabledKey, spec.Conformance.Enabled)
+	setDuration(conformanceIntervalKey, spec.Conformance.Interval)
+	setDuration(conformanceTimeoutKey, spec.Conformance.Timeout)
+	set(conformanceNamespaceKey, spec.Conformance.Namespace)
+	set(conformanceImageKey, spec.Conformance.Image)
+	setBool(healthEnabledKey, spec.Health.Enabled)
+	setDuration(healthIntervalKey, spec.Health.Interval)
+	setDuration(healthTimeoutKey, spec.Health.Timeout)
+	set(healthAddressKey, spec.Health.Address)
+	setBool(metricsNamespaceLabelKey, spec.Metrics.NamespaceLabel)
+	setBool(metricsPipelineLabelKey, spec.Metrics.PipelineLabel)
+	setList(metricsNamespacesKey, spec.Metrics.Namespaces)
+	setInt(metricsMaxLabelValuesKey, spec.Metrics.MaxLabelValues)
+	setInt(provenanceMaxValueKBKey, spec.Provenance.MaxValueKB)
+	set(provenanceOversizedValuesKey, spec.Provenance.OversizedValues)
+	setInt(provenanceMaxAttestationKBKey, spec.Provenance.MaxAttestationKB)
+	setBool(provenanceChainInputsKey, spec.Provenance.ChainInputAttestations)
+	setBool(provenanceRecordEnvironmentKey, spec.Provenance.RecordEnvironment)
+	set(provenanceConfigSnapshotKey, spec.Provenance.ConfigSnapshot)
+	setBool(provenanceValidatePayloadsKey, spec.Provenance.ValidatePayloads)
+	setList(provenanceDigestAlgorithmsKey, spec.Provenance.DigestAlgorithms)
+	setInt(provenanceMaxInlineContentKey, spec.Provenance.MaxInlineContentBytes)
+	setBool(provenanceCompletenessKey, spec.Provenance.ComputeCompleteness)
+	set(provenanceStepEnvKey, spec.Provenance.StepEnv)
+	setList(provenanceStepEnvAllowKey, spec.Provenance.StepEnvAllow)
+	setList(provenanceStepEnvDenyKey, spec.Provenance.StepEnvDeny)
+	setList(isolationSandboxRuntimeClassesKey, spec.Isolation.SandboxRuntimeClasses)
+	setList(isolationNetworkLabelsKey, spec.Isolation.NetworkLabels)
+	setList(materialsAllowedPrefixesKey, spec.Materials.AllowedPrefixes)
+	set(materialsEnforcementKey, spec.Materials.Enforcement)
+	for cluster, id := range spec.Clusters.BuilderIDs {
+		data[clustersPrefix+cluster+clusterBuilderIDSuffix] = id
+	}
+	for name, stack := range spec.Sigstore.Stacks {
+		setList(sigstorePrefix+name+sigstoreNamespacesSuffix, stack.Namespaces)
+		set(sigstorePrefix+name+sigstoreTUFMirrorURLSuffix, stack.TUFMirrorURL)
+		set(sigstorePrefix+name+sigstoreFulcioAddrSuffix, stack.FulcioAddress)
+		set(sigstorePrefix+name+sigstoreFulcioIssuerSuffix, stack.FulcioIssuer)
+		set(sigstorePrefix+name+sigstoreRekorURLSuffix, stack.RekorURL)
+	}
+	set(profilingAddressKey, spec.Profiling.Address)
+	setDuration(profilingHeapIntervalKey, spec.Profiling.HeapInterval)
+	set(profilingHeapDirKey, spec.Profiling.HeapDir)
+	setInt(profilingHeapKeepKey, spec.Profiling.HeapKeep)
+
+	set(tracingOTLPEndpointKey, spec.Tracing.OTLPEndpoint)
+	setBool(tracingOTLPInsecureKey, spec.Tracing.OTLPInsecure)
+
+	return data
+}
+
+// SpecFromConfig returns the ChainsConfig spec equivalent to cfg. It is used to migrate
+// an existing chains-config ConfigMap to a ChainsConfig.
+func SpecFromConfig(cfg *Config) v1alpha1.ChainsConfigSpec {
+	list := func(s sets.Set[string]) []string {
+		values := sets.List(s.Clone().Delete(""))
+		if len(values) == 0 {
+			return nil
+		}
+		return values
+	}
+	duration := func(d time.Duration) *metav1.Duration {
+		if d == 0 {
+			return nil
+		}
+		return &metav1.Duration{Duration: d}
+	}
+	durations := func(m map[string]time.Duration) map[string]metav1.Duration {
+		if len(m) == 0 {
+			return nil
+		}
+		out := make(map[string]metav1.Duration, len(m))
+		for k, d := range m {
+			out[k] = metav1.Duration{Duration: d}
+		}
+		return out
+	}
+	artifact := func(a Artifact) v1alpha1.ArtifactSpec {
+		return v1alpha1.ArtifactSpec{
+			Format:   a.Format,
+			Storage:  list(a.StorageBackend),
+			Signer:   a.Signer,
+			Disabled: !a.Enabled(),
+		}
+	}
+
+	var stacks map[string]v1alpha1.SigstoreStackSpec
+	for name, stack := range cfg.Sigstore.Stacks {
+		if stacks == nil {
+			stacks = map[string]v1alpha1.SigstoreStackSpec{}
+		}
+		stacks[name] = v1alpha1.SigstoreStackSpec{
+			Namespaces:    list(stack.Namespaces),
+			TUFMirrorURL:  stack.TUFMirrorURL,
+			FulcioAddress: stack.FulcioAddr,
+			FulcioIssuer:  stack.FulcioOIDCIssuer,
+			RekorURL:      stack.RekorURL,
+		}
+	}
+
+	pipelineRuns := artifact(cfg.Artifacts.PipelineRuns)
+	pipelineRuns.EnableDeepInspection = cfg.Artifacts.PipelineRuns.DeepInspectionEnabled
+	pipelineRuns.BundleStorage = list(cfg.Artifacts.PipelineRuns.BundleStorageBackend)
+	pipelineRuns.ResultDigests = cfg.Artifacts.PipelineRuns.ResultDigests
+	source := artifact(cfg.Artifacts.Source)
+	source.FetchRuns = cfg.Artifacts.Source.FetchRuns
+
+	s := cfg.Storage
+	x := cfg.Signers.X509
+	k := cfg.Signers.KMS
+	return v1alpha1.ChainsConfigSpec{
+		Artifacts: v1alpha1.ArtifactsSpec{
+			TaskRuns:      artifact(cfg.Artifacts.TaskRuns),
+			PipelineRuns:  pipelineRuns,
+			OCI:           artifact(cfg.Artifacts.OCI),
+			VEX:           artifact(cfg.Artifacts.VEX),
+			TektonBundles: artifact(cfg.Artifacts.TektonBundles),
+			Source:        source,
+			CustomRuns:    artifact(cfg.Artifacts.CustomRuns),
+		},
+		Storage: v1alpha1.StorageSpec{
+			GCS:     &v1alpha1.GCSStorageSpec{Bucket: s.GCS.Bucket, NameTemplate: s.GCS.NameTemplate},
+			OCI:     &v1alpha1.OCIStorageSpec{Repository: s.OCI.Repository, Insecure: s.OCI.Insecure, Tags: list(s.OCI.Tags), AttachToPlatforms: s.OCI.AttachToPlatforms},
+			Tekton:  &v1alpha1.TektonStorageSpec{MaxAnnotationSize: s.Tekton.MaxAnnotationSize},
+			DocDB:   &v1alpha1.DocDBStorageSpec{URL: s.DocDB.URL, NameTemplate: s.DocDB.NameTemplate},
+			Grafeas: &v1alpha1.GrafeasStorageSpec{ProjectID: s.Grafeas.ProjectID, NoteID: s.Grafeas.NoteID, NoteHint: s.Grafeas.NoteHint},
+			PubSub: &v1alpha1.PubSubStorageSpec{
+				Provider:                          s.PubSub.Provider,
+				Topic:                             s.PubSub.Topic,
+				KafkaBootstrapServers:             s.PubSub.Kafka.BootstrapServers,
+				EventHubsNamespace:                s.PubSub.EventHubs.Namespace,
+				BatchSize:                         s.PubSub.BatchSize,
+				BatchLinger:                       duration(s.PubSub.BatchLinger),
+				GRPCAddress:                       s.PubSub.GRPC.Address,
+				GRPCInsecure:                      s.PubSub.GRPC.Insecure,
+				GRPCCAPath:                        s.PubSub.GRPC.CAPath,
+				GRPCMaxInFlight:                   s.PubSub.GRPC.MaxInFlight,
+				GRPCTimeout:                       duration(s.PubSub.GRPC.Timeout),
+				MQTTBroker:                        s.PubSub.MQTT.Broker,
+				MQTTQoS:                           s.PubSub.MQTT.QoS,
+				MQTTClientID:                      s.PubSub.MQTT.ClientID,
+				MQTTCredentialsPath:               s.PubSub.MQTT.CredentialsPath,
+				MQTTCAPath:                        s.PubSub.MQTT.CAPath,
+				SchemaRegistryURL:                 s.PubSub.SchemaRegistry.URL,
+				SchemaRegistryFormat:              s.PubSub.SchemaRegistry.Format,
+				SchemaRegistrySubjectNameStrategy: s.PubSub.SchemaRegistry.SubjectNameStrategy,
+				SchemaRegistryBasicAuthPath:       s.PubSub.SchemaRegistry.BasicAuthPath,
+			},
+			IPFS: &v1alpha1.IPFSStorageSpec{URL: s.IPFS.URL, TokenPath: s.IPFS.TokenPath},
+			GitHub: &v1alpha1.GitHubStorageSpec{
+				URL:            s.GitHub.URL,
+				Repository:     s.GitHub.Repository,
+				AppID:          s.GitHub.AppID,
+				InstallationID: s.GitHub.InstallationID,
+				PrivateKeyPath: s.GitHub.PrivateKeyPath,
+			},
+			GitLab: &v1alpha1.GitLabStorageSpec{
+				URL:       s.GitLab.URL,
+				Project:   s.GitLab.Project,
+				TokenPath: s.GitLab.TokenPath,
+				Package:   s.GitLab.Package,
+			},
+		},
+		Signers: v1alpha1.SignersSpec{
+			X509: &v1alpha1.X509SignerSpec{
+				Fulcio: &v1alpha1.FulcioSpec{
+					Enabled:  x.FulcioEnabled,
+					Address:  x.FulcioAddr,
+					Issuer:   x.FulcioOIDCIssuer,
+					Provider: x.FulcioProvider,
+				},
+				IdentityTokenFile:     x.IdentityTokenFile,
+				IdentityTokenCommand:  x.IdentityTokenCommand,
+				IdentityTokenAudience: x.IdentityTokenAudience,
+				TUFMirrorURL:          x.TUFMirrorURL,
+			},
+			KMS: &v1alpha1.KMSSignerSpec{
+				KMSRef: k.KMSRef,
+				Auth: &v1alpha1.KMSAuthSpec{
+					Address:       k.Auth.Address,
+					Token:         k.Auth.Token,
+					OIDCPath:      k.Auth.OIDC.Path,
+					OIDCRole:      k.Auth.OIDC.Role,
+					SpireSock:     k.Auth.Spire.Sock,
+					SpireAudience: k.Auth.Spire.Audience,
+				},
+			},
+			Secret: &v1alpha1.SigningSecretSpec{
+				Namespace:         cfg.Signers.Secret.Namespace,
+				Name:              cfg.Signers.Secret.Name,
+				AllowedNamespaces: list(cfg.Signers.Secret.AllowedNamespaces),
+			},
+			External: &v1alpha1.ExternalSecretsSpec{
+				Refs:            cfg.Signers.External.Refs,
+				RefreshInterval: duration(cfg.Signers.External.RefreshInterval),
+			},
+		},
+		Builder: v1alpha1.BuilderSpec{ID: cfg.Builder.ID, Cluster: cfg.Builder.Cluster, BuildType: cfg.Builder.BuildType},
+		Transparency: v1alpha1.TransparencySpec{
+			Enabled:          cfg.Transparency.Enabled,
+			VerifyAnnotation: cfg.Transparency.VerifyAnnotation,
+			URL:              cfg.Transparency.URL,
+			Version:          cfg.Transparency.Version,
+			CAPath:           cfg.Transparency.CAPath,
+			CertPath:         cfg.Transparency.CertPath,
+			KeyPath:          cfg.Transparency.KeyPath,
+			ProxyURL:         cfg.Transparency.ProxyURL,
+			Queue: v1alpha1.TransparencyQueueSpec{
+				Enabled:  cfg.Transparency.QueueEnabled,
+				Interval: duration(cfg.Transparency.QueueInterval),
+			},
+			Timeout: duration(cfg.Transparency.Timeout),
+		},
+		Namespaces: v1alpha1.NamespacesSpec{
+			Watched:  list(cfg.Namespaces.Watched),
+			Excluded: list(cfg.Namespaces.Excluded),
+		},
+		LabelSelector: cfg.Selector.LabelSelector,
+		Retry: v1alpha1.RetrySpec{
+			MaxRetries:        cfg.Retry.MaxRetries,
+			InitialBackoff:    duration(cfg.Retry.InitialBackoff),
+			BackoffMultiplier: cfg.Retry.BackoffMultiplier,
+			MaxBackoff:        duration(cfg.Retry.MaxBackoff),
+			Jitter:            cfg.Retry.Jitter,
+			NoRetryErrors:     list(cfg.Retry.NoRetryErrors),
+		},
+		Finalizer: v1alpha1.FinalizerSpec{
+			BlockDeletion: cfg.Finalizer.BlockDeletion,
+			Timeout:       duration(cfg.Finalizer.Timeout),
+		},
+		DryRun: v1alpha1.DryRunSpec{
+			Enabled:    cfg.DryRun.Enabled,
+			Namespaces: list(cfg.DryRun.Namespaces),
+			Directory:  cfg.DryRun.Directory,
+		},
+		Audit:     v1alpha1.AuditSpec{Sink: cfg.Audit.Sink, FilePath: cfg.Audit.FilePath, KeyUsage: cfg.Audit.KeyUsage},
+		Webhook:   v1alpha1.WebhookSpec{RejectInvalid: cfg.Webhook.RejectInvalid},
+		Overrides: v1alpha1.OverridesSpec{AllowedKeys: list(cfg.Overrides.Allowed)},
+		Concurrency: v1alpha1.ConcurrencySpec{
+			TaskRunWorkers:     cfg.Concurrency.TaskRunWorkers,
+			PipelineRunWorkers: cfg.Concurrency.PipelineRunWorkers,
+			SigningRate:        cfg.Concurrency.SigningRate,
+			SigningBurst:       cfg.Concurrency.SigningBurst,
+			BackendLimits:      cfg.Concurrency.BackendLimits,
+			UploadParallelism:  cfg.Concurrency.UploadParallelism,
+			BackendTimeouts:    durations(cfg.Concurrency.BackendTimeouts),
+			SignerTimeouts:     durations(cfg.Concurrency.SignerTimeouts),
+			NamespaceLimit:     cfg.Concurrency.NamespaceLimit,
+			NamespaceLimits:    cfg.Concurrency.NamespaceLimits,
+		},
+		Lease: v1alpha1.LeaseSpec{
+			Enabled:  cfg.Lease.Enabled,
+			Duration: duration(cfg.Lease.Duration),
+		},
+		Shutdown:      v1alpha1.ShutdownSpec{DrainTimeout: duration(cfg.Shutdown.DrainTimeout)},
+		SigningStatus: v1alpha1.SigningStatusConfigSpec{Enabled: cfg.SigningStatus.Enabled},
+		Events:        v1alpha1.EventsSpec{Sink: cfg.Events.Sink},
+		Query:         v1alpha1.QuerySpec{Address: cfg.Query.Address, CertPath: cfg.Query.CertPath, KeyPath: cfg.Query.KeyPath, MaxResults: cfg.Query.MaxResults, MaxRuns: cfg.Query.MaxRuns},
+		GRPC:          v1alpha1.GRPCSpec{Address: cfg.GRPC.Address, CertPath: cfg.GRPC.CertPath, KeyPath: cfg.GRPC.KeyPath, ClientCAPath: cfg.GRPC.ClientCAPath},
+		PublicKeys:    v1alpha1.PublicKeysSpec{Enabled: cfg.PublicKeys.Enabled},
+		Tracing:       v1alpha1.TracingSpec{OTLPEndpoint: cfg.Tracing.Endpoint, OTLPInsecure: cfg.Tracing.Insecure},
+		Conformance: v1alpha1.ConformanceSpec{
+			Enabled:   cfg.Conformance.Enabled,
+			Interval:  duration(cfg.Conformance.Interval),
+			Timeout:   duration(cfg.Conformance.Timeout),
+			Namespace: cfg.Conformance.Namespace,
+			Image:     cfg.Conformance.Image,
+		},
+		Health: v1alpha1.HealthSpec{
+			Enabled:  cfg.Health.Enabled,
+			Interval: duration(cfg.Health.Interval),
+			Timeout:  duration(cfg.Health.Timeout),
+			Address:  cfg.Health.Address,
+		},
+		Metrics: v1alpha1.MetricsSpec{
+			NamespaceLabel: cfg.Metrics.NamespaceLabel,
+			PipelineLabel:  cfg.Metrics.PipelineLabel,
+			Namespaces:     list(cfg.Metrics.Namespaces),
+			MaxLabelValues: cfg.Metrics.MaxLabelValues,
+		},
+		Provenance: v1alpha1.ProvenanceSpec{
+			MaxValueKB:             cfg.Provenance.MaxValueKB,
+			OversizedValues:        cfg.Provenance.OversizedValues,
+			MaxAttestationKB:       cfg.Provenance.MaxAttestationKB,
+			ChainInputAttestations: cfg.Provenance.ChainInputAttestations,
+			RecordEnvironment:      cfg.Provenance.RecordEnvironment,
+			ConfigSnapshot:         cfg.Provenance.ConfigSnapshot,
+			ValidatePayloads:       cfg.Provenance.ValidatePayloads,
+			DigestAlgorithms:       list(cfg.Provenance.DigestAlgorithms),
+			MaxInlineContentBytes:  cfg.Provenance.MaxInlineContentBytes,
+			ComputeCompleteness:    cfg.Provenance.ComputeCompleteness,
+			StepEnv:                cfg.Provenance.StepEnv,
+			StepEnvAllow:           list(cfg.Provenance.StepEnvAllow),
+			StepEnvDeny:            list(cfg.Provenance.StepEnvDeny),
+		},
+		Isolation: v1alpha1.IsolationSpec{
+			SandboxRuntimeClasses: list(cfg.Isolation.SandboxRuntimeClasses),
+			NetworkLabels:         list(cfg.Isolation.NetworkLabels),
+		},
+		Policy: v1alpha1.PolicySpec{OPAURL: cfg.Policy.URL, OPAPath: cfg.Policy.Path},
+		Materials: v1alpha1.MaterialsSpec{
+			AllowedPrefixes: list(cfg.Materials.AllowedPrefixes),
+			Enforcement:     cfg.Materials.Enforcement,
+		},
+		Clusters: v1alpha1.ClustersSpec{BuilderIDs: cfg.Clusters.BuilderIDs},
+		Profiling: v1alpha1.ProfilingSpec{
+			Address:      cfg.Profiling.Address,
+			HeapInterval: duration(cfg.Profiling.HeapInterval),
+			HeapDir:      cfg.Profiling.HeapDir,
+			HeapKeep:     cfg.Profiling.HeapKeep,
+		},
+		Sigstore: v1alpha1.SigstoreSpec{Stacks: stacks},
+	}
+}
+
+// secretKeys are the keys whose values are credentials, which are left out of Snapshot.
+var secretKeys = []string{kmsAuthToken}
+
+// Snapshot renders cfg into chains-config ConfigMap data, without the values of
+// credentials, so that the configuration in force when a run was signed can be
+// recorded in its provenance. The JSON encoding of the data is stable, since its keys
+// are sorted.
+func Snapshot(cfg *Config) map[string]string {
+	spec := SpecFromConfig(cfg)
+	data := DataFromSpec(&spec)
+	for _, key := range secretKeys {
+		delete(data, key)
+	}
+	return data
+}
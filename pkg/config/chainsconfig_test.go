@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDataFromSpec(t *testing.T) {
+	spec := &v1alpha1.ChainsConfigSpec{
+		Artifacts: v1alpha1.ArtifactsSpec{
+			TaskRuns:     v1alpha1.ArtifactSpec{Format: "slsa/v1", Storage: []string{"oci", "tekton"}},
+			PipelineRuns: v1alpha1.ArtifactSpec{Disabled: true},
+		},
+		Storage:       v1alpha1.StorageSpec{OCI: &v1alpha1.OCIStorageSpec{Repository: "gcr.io/foo", Insecure: true, Tags: []string{"$(pipeline.name)-$(params.version)"}, AttachToPlatforms: true}},
+		Transparency:  v1alpha1.TransparencySpec{VerifyAnnotation: true},
+		LabelSelector: "team=a",
+		Retry: v1alpha1.RetrySpec{
+			MaxRetries:        5,
+			InitialBackoff:    &metav1.Duration{Duration: 10 * time.Second},
+			BackoffMultiplier: 1.5,
+		},
+	}
+	want := map[string]string{
+		"artifacts.taskrun.format":        "slsa/v1",
+		"artifacts.taskrun.storage":       "oci,tekton",
+		"artifacts.pipelinerun.storage":   "",
+		"storage.oci.repository":          "gcr.io/foo",
+		"storage.oci.repository.insecure": "true",
+		"storage.oci.tags":                "$(pipeline.name)-$(params.version)",
+		"storage.oci.attach-to-platforms": "true",
+		"transparency.enabled":            "manual",
+		"label-selector":                  "team=a",
+		"retry.max-retries":               "5",
+		"retry.backoff.initial":           "10s",
+		"retry.backoff.multiplier":        "1.5",
+	}
+	if diff := cmp.Diff(want, DataFromSpec(spec)); diff != "" {
+		t.Errorf("DataFromSpec() -want +got: %s", diff)
+	}
+}
+
+func TestSpecFromConfig_RoundTrip(t *testing.T) {
+	data := map[string]string{
+		"artifacts.taskrun.format":                     "slsa/v1",
+		"artifacts.taskrun.storage":                    "oci,tekton",
+		"artifacts.oci.storage":                        "",
+		"artifacts.pipelinerun.enable-deep-inspection": "true",
+		"artifacts.pipelinerun.result-digests":         "true",
+		"artifacts.pipelinerun.bundle.storage":         "ipfs",
+		"storage.ipfs.url":                             "http://ipfs:5001",
+		"storage.ipfs.token-path":                      "/etc/ipfs/token",
+		"storage.gcs.name-template":                    "$(run.namespace)/$(year)/$(month)/$(key)",
+		"storage.docdb.name-template":                  "$(run.namespace)-$(key)",
+		"storage.pubsub.batch-size":                    "50",
+		"storage.pubsub.batch-linger":                  "250ms",
+		"storage.pubsub.grpc.address":                  "collector:9090",
+		"storage.pubsub.grpc.insecure":                 "true",
+		"storage.pubsub.grpc.max-in-flight":            "100",
+		"storage.pubsub.grpc.timeout":                  "30s",
+		"storage.pubsub.eventhubs.namespace":           "chains.servicebus.windows.net",
+		"storage.pubsub.mqtt.broker":                   "ssl://broker:8883",
+		"storage.pubsub.mqtt.qos":                      "2",
+		"storage.pubsub.mqtt.client-id":                "edge-1",
+		"storage.pubsub.mqtt.credentials-path":         "/etc/chains/mqtt",
+		"storage.pubsub.mqtt.ca-path":                  "/etc/chains/mqtt-ca.pem",
+		"storage.pubsub.schema-registry.format":        "protobuf",
+		"storage.github.repository":                    "acme/widgets",
+		"storage.github.app-id":                        "7",
+		"storage.gitlab.project":                       "acme/widgets",
+		"artifacts.vex.storage":                        "oci",
+		"artifacts.tekton-bundle.storage":              "oci",
+		"artifacts.source.storage":                     "tekton",
+		"artifacts.source.fetch-runs":                  "true",
+		"storage.oci.tags":                             "$(run.uid)",
+		"storage.oci.attach-to-platforms":              "true",
+		"storage.tekton.max-annotation-size":           "65536",
+		"artifacts.customrun.format":                   "slsa/v1",
+		"artifacts.customrun.storage":                  "tekton",
+		"signers.x509.fulcio.enabled":                  "true",
+		"signers.x509.identity.token.command":          "/usr/local/bin/get-token --cluster prod",
+		"signers.x509.identity.token.audience":         "chains",
+		"signers.kms.kmsref":                           "gcpkms://foo",
+		"signers.secret.namespace":                     "signing-keys",
+		"signers.secret.name":                          "chains-signing-secrets",
+		"signers.secret.allowed-namespaces":            "signing-keys",
+		"signers.external.cosign.password":             "awssm://chains/cosign-password",
+		"signers.external.refresh-interval":            "10m0s",
+		"builder.cluster":                              "prod-east",
+		"builder.build-type":                           "https://example.com/tekton/build/v1",
+		"transparency.enabled":                         "true",
+		"transparency.version":                         "v2",
+		"transparency.tls.ca-path":                     "/etc/rekor/ca.crt",
+		"transparency.tls.cert-path":                   "/etc/rekor/tls.crt",
+		"transparency.tls.key-path":                    "/etc/rekor/tls.key",
+		"transparency.proxy-url":                       "http://proxy.corp:3128",
+		"transparency.queue.enabled":                   "true",
+		"transparency.queue.interval":                  "5m0s",
+		"excluded-namespaces":                          "kube-system",
+		"retry.backoff.jitter":                         "0.2",
+		"finalizer.timeout":                            "30m",
+		"dryrun.namespaces":                            "staging",
+		"audit.sink":                                   "stdout",
+		"audit.key-usage":                              "true",
+		"overrides.allowed-keys":                       "format,storage",
+		"signing.rate":                                 "0.5",
+		"storage.oci.max-concurrency":                  "2",
+		"storage.gcs.timeout":                          "30s",
+		"signers.kms.timeout":                          "5s",
+		"signing.namespace.max-in-flight":              "4",
+		"signing.namespace.release.max-in-flight":      "8",
+		"transparency.timeout":                         "5s",
+		"storage.parallelism":                          "2",
+		"signing.lease.enabled":                        "true",
+		"signing.lease.duration":                       "2m0s",
+		"controller.drain-timeout":                     "45s",
+		"signingstatus.enabled":                        "true",
+		"events.sink":                                  "http://broker-ingress.knative-eventing.svc/default/default",
+		"query.address":                                ":8081",
+		"query.tls.cert-path":                          "/etc/chains-query/tls.crt",
+		"query.tls.key-path":                           "/etc/chains-query/tls.key",
+		"query.max-results":                            "50",
+		"query.max-runs":                               "200",
+		"grpc.address":                                 ":9090",
+		"grpc.tls.cert-path":                           "/etc/chains-grpc/tls.crt",
+		"grpc.tls.key-path":                            "/etc/chains-grpc/tls.key",
+		"grpc.tls.client-ca-path":                      "/etc/chains-grpc/ca.crt",
+		"publickeys.enabled":                           "true",
+		"tracing.otlp.endpoint":                        "collector:4318",
+		"conformance.enabled":                          "true",
+		"conformance.interval":                         "30m0s",
+		"conformance.namespace":                        "chains-conformance",
+		"health.enabled":                               "true",
+		"health.interval":                              "1m0s",
+		"health.address":                               ":8082",
+		"metrics.labels.namespace":                     "true",
+		"metrics.labels.namespaces":                    "team-a,team-b",
+		"metrics.labels.max-values":                    "50",
+		"provenance.max-value-kb":                      "64",
+		"provenance.oversized-values":                  "digest",
+		"provenance.max-attestation-kb":                "512",
+		"provenance.chain-input-attestations":          "true",
+		"provenance.record-environment":                "true",
+		"provenance.config-snapshot":                   "content",
+		"provenance.validate-payloads":                 "true",
+		"provenance.digest-algorithms":                 "sha256,sha512",
+		"provenance.max-inline-content-bytes":          "4096",
+		"provenance.compute-completeness":              "true",
+		"provenance.step-env":                          "exclude",
+		"provenance.step-env.allow":                    "GOFLAGS,NPM_CONFIG_*",
+		"provenance.step-env.deny":                     "AWS_*",
+		"policy.opa.url":                               "http://opa.opa-system:8181",
+		"policy.opa.path":                              "chains/deny",
+		"isolation.sandbox-runtime-classes":            "gvisor,kata",
+		"isolation.network-labels":                     "network.example.com/egress=deny",
+		"materials.allowed-prefixes":                   "gcr.io/trusted/,oci://registry.example.com/",
+		"materials.enforcement":                        "block",
+		"clusters.edge-1.builder-id":                   "https://chains.example.com/edge-1",
+		"sigstore.private.namespaces":                  "team-a,team-b",
+		"sigstore.private.tuf-mirror-url":              "https://tuf.sigstore.example.com",
+		"sigstore.private.fulcio-address":              "https://fulcio.sigstore.example.com",
+		"sigstore.private.fulcio-issuer":               "https://dex.example.com",
+		"sigstore.private.rekor-url":                   "https://rekor.sigstore.example.com",
+		"profiling.address":                            "localhost:6060",
+		"profiling.heap-interval":                      "10m",
+		"profiling.heap-dir":                           "/var/run/chains/profiles",
+		"profiling.heap-keep":                          "5",
+	}
+	want, err := NewConfigFromMap(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := SpecFromConfig(want)
+	got, err := NewConfigFromMap(DataFromSpec(&spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("round trip -want +got: %s", diff)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	cfg, err := NewConfigFromMap(map[string]string{
+		"artifacts.taskrun.format":  "in-toto",
+		"artifacts.taskrun.storage": "oci",
+		"storage.ipfs.url":          "http://ipfs:5001",
+		"signers.kms.kmsref":        "hashivault://chains",
+		"signers.kms.auth.token":    "kms-token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Snapshot(cfg)
+	for _, key := range []string{"signers.kms.auth.token"} {
+		if v, ok := got[key]; ok {
+			t.Errorf("Snapshot()[%q] = %q, want credentials left out", key, v)
+		}
+	}
+	for key, want := range map[string]string{
+		"artifacts.taskrun.format":  "in-toto",
+		"artifacts.taskrun.storage": "oci",
+		"storage.ipfs.url":          "http://ipfs:5001",
+		"signers.kms.kmsref":        "hashivault://chains",
+	} {
+		if got[key] != want {
+			t.Errorf("Snapshot()[%q] = %q, want %q", key, got[key], want)
+		}
+	}
+}
@@ -20,19 +20,49 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sigstore/sigstore/pkg/tuf"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	cm "knative.dev/pkg/configmap"
 )
 
 type Config struct {
-	Artifacts    ArtifactConfigs
-	Storage      StorageConfigs
-	Signers      SignerConfigs
-	Builder      BuilderConfig
-	Transparency TransparencyConfig
+	Artifacts     ArtifactConfigs
+	Storage       StorageConfigs
+	Signers       SignerConfigs
+	Builder       BuilderConfig
+	Transparency  TransparencyConfig
+	Tracing       TracingConfig
+	Retry         RetryConfig
+	Namespaces    NamespaceConfig
+	Selector      SelectorConfig
+	Finalizer     FinalizerConfig
+	DryRun        DryRunConfig
+	Audit         AuditConfig
+	Webhook       WebhookConfig
+	Overrides     OverridesConfig
+	Concurrency   ConcurrencyConfig
+	Lease         LeaseConfig
+	Shutdown      ShutdownConfig
+	SigningStatus SigningStatusConfig
+	Events        EventsConfig
+	Query         QueryConfig
+	GRPC          GRPCConfig
+	PublicKeys    PublicKeysConfig
+	Conformance   ConformanceConfig
+	Health        HealthConfig
+	Metrics       MetricsConfig
+	Provenance    ProvenanceConfig
+	Isolation     IsolationConfig
+	Policy        PolicyConfig
+	Materials     MaterialsConfig
+	Clusters      ClustersConfig
+	Profiling     ProfilingConfig
+	Sigstore      SigstoreConfig
 }
 
 // ArtifactConfigs contains the configuration for how to sign/store/format the signatures for each artifact type
@@ -40,6 +70,19 @@ type ArtifactConfigs struct {
 	OCI          Artifact
 	PipelineRuns Artifact
 	TaskRuns     Artifact
+	// VEX configures signing the OpenVEX documents produced by runs. They are
+	// only signed when storage backends are configured.
+	VEX Artifact
+	// TektonBundles configures attesting the Tekton bundles built by runs. They are
+	// only attested when storage backends are configured.
+	TektonBundles Artifact
+	// Source configures attesting the source commits runs built from, identified by
+	// CHAINS-GIT_URL and CHAINS-GIT_COMMIT hints. They are only attested when storage
+	// backends are configured and signing is keyless.
+	Source Artifact
+	// CustomRuns configures signing provenance for CustomRuns. They are only
+	// signed when storage backends are configured.
+	CustomRuns Artifact
 }
 
 // Artifact contains the configuration for how to sign/store/format the signatures for a single artifact
@@ -48,6 +91,16 @@ type Artifact struct {
 	StorageBackend        sets.Set[string]
 	Signer                string
 	DeepInspectionEnabled bool
+	// BundleStorageBackend is the set of backends an aggregated bundle of
+	// envelopes is stored in. Only used for PipelineRuns; empty disables it.
+	BundleStorageBackend sets.Set[string]
+	// ResultDigests records the digest of every result in its byproduct, so that the
+	// results are still identified if their content is truncated. Only used for
+	// PipelineRuns.
+	ResultDigests bool
+	// FetchRuns records how TaskRuns that only fetch source fetched the commit in their
+	// source attestations. Only used for source commits.
+	FetchRuns bool
 }
 
 // StorageConfigs contains the configuration to instantiate different storage providers
@@ -58,16 +111,55 @@ type StorageConfigs struct {
 	DocDB   DocDBStorageConfig
 	Grafeas GrafeasConfig
 	PubSub  PubSubStorageConfig
+	IPFS    IPFSStorageConfig
+	GitHub  GitHubStorageConfig
+	GitLab  GitLabStorageConfig
 }
 
 // SignerConfigs contains the configuration to instantiate different signers
 type SignerConfigs struct {
 	X509 X509Signer
 	KMS  KMSSigner
+	// Secret is the secret the signing keys are read from instead of the signing secrets
+	// mounted in the controller.
+	Secret SigningSecretConfig
+	// External are the files of the signing secrets fetched from external secret
+	// managers instead.
+	External ExternalSecretsConfig
+}
+
+// ExternalSecretsConfig configures the files of the signing secrets that are fetched
+// from AWS Secrets Manager, GCP Secret Manager or Vault at runtime.
+type ExternalSecretsConfig struct {
+	// Refs are the references of the secrets, by the file of the signing secrets they
+	// replace, e.g. cosign.password.
+	Refs map[string]string
+	// RefreshInterval is how long fetched secrets are used before they are fetched
+	// again, to pick up rotated secrets.
+	RefreshInterval time.Duration
+}
+
+// SigningSecretConfig references the secret holding the signing keys, e.g. in a
+// namespace in which a platform team manages them.
+type SigningSecretConfig struct {
+	// Namespace of the secret, the namespace Chains is installed in if empty.
+	Namespace string
+	// Name of the secret. The mounted signing secrets are used if it is empty.
+	Name string
+	// AllowedNamespaces are the namespaces other than the one Chains is installed in
+	// that the secret may be read from.
+	AllowedNamespaces sets.Set[string]
 }
 
 type BuilderConfig struct {
 	ID string
+	// Cluster is the name of the cluster in which Tasks and Pipelines are fetched by
+	// the cluster resolver, recorded in the provenance of remote pipelines.
+	Cluster string
+	// BuildType overrides the buildType of SLSA provenance, e.g. with an organization
+	// specific buildType with its own schema. The buildType of the format is used when
+	// it is empty.
+	BuildType string
 }
 
 type X509Signer struct {
@@ -76,7 +168,14 @@ type X509Signer struct {
 	FulcioOIDCIssuer  string
 	FulcioProvider    string
 	IdentityTokenFile string
-	TUFMirrorURL      string
+	// IdentityTokenCommand is a command printing an ID token, run with the audience in
+	// the CHAINS_OIDC_AUDIENCE environment variable. Its arguments are separated by
+	// spaces.
+	IdentityTokenCommand string
+	// IdentityTokenAudience is the audience of the ID token requested from providers. A
+	// default of "sigstore" is used when it is empty.
+	IdentityTokenAudience string
+	TUFMirrorURL          string
 }
 
 type KMSSigner struct {
@@ -106,18 +205,35 @@ type KMSAuthSpire struct {
 
 type GCSStorageConfig struct {
 	Bucket string
+	// NameTemplate is the template of the names of the objects payloads are stored in,
+	// without their extensions, like $(run.namespace)/$(year)/$(key). Objects are named
+	// taskrun-$(run.namespace)-$(run.name)/$(key) when it is empty.
+	NameTemplate string
 }
 
 type OCIStorageConfig struct {
 	Repository string
 	Insecure   bool
+	// Tags are templates of the tags to apply to the attestations stored in OCI
+	// registries, in addition to the tags derived from the digest of their artifact.
+	Tags sets.Set[string]
+	// AttachToPlatforms configures whether the attestations of image indexes are also
+	// attached to each platform-specific image of the index.
+	AttachToPlatforms bool
 }
 
 type TektonStorageConfig struct {
+	// MaxAnnotationSize is the size in bytes above which the base64 values stored in
+	// annotations are split into chunks across several annotations. Values are not
+	// split if it is 0.
+	MaxAnnotationSize int
 }
 
 type DocDBStorageConfig struct {
 	URL string
+	// NameTemplate is the template of the names documents are keyed by, like
+	// $(run.namespace)-$(key). Documents are keyed by $(key) when it is empty.
+	NameTemplate string
 }
 
 type GrafeasConfig struct {
@@ -130,20 +246,603 @@ type GrafeasConfig struct {
 	NoteHint string
 }
 
+type IPFSStorageConfig struct {
+	// URL of the IPFS node or pinning service RPC API that payloads are added to.
+	URL string
+	// TokenPath is the path of a file holding an optional bearer token used to
+	// authenticate with the API.
+	TokenPath string
+}
+
+// GitHubStorageConfig configures uploading attestations to the GitHub artifact
+// attestations API, authenticated as a GitHub App installation.
+type GitHubStorageConfig struct {
+	// URL of the GitHub REST API. A default of https://api.github.com is used when it is empty.
+	URL string
+	// Repository is the owner/name of the repository attestations are uploaded to.
+	Repository string
+	// AppID and InstallationID identify the GitHub App installation to authenticate as.
+	AppID          int64
+	InstallationID int64
+	// PrivateKeyPath is the path of the PEM encoded private key of the GitHub App.
+	PrivateKeyPath string
+}
+
+// GitLabStorageConfig configures uploading signed payloads to the generic package
+// registry of a GitLab project.
+type GitLabStorageConfig struct {
+	// URL of the GitLab instance. A default of https://gitlab.com is used when it is empty.
+	URL string
+	// Project is the ID or the full path of the project payloads are uploaded to.
+	Project string
+	// TokenPath is the path of a file holding an access token with the api scope.
+	TokenPath string
+	// Package is the name of the generic package payloads are uploaded to. A default
+	// of tekton-chains is used when it is empty.
+	Package string
+}
+
 type PubSubStorageConfig struct {
 	Provider string
 	Topic    string
 	Kafka    KafkaStorageConfig
+	// EventHubs configures the eventhubs provider, the event hub of which is the topic.
+	EventHubs EventHubsStorageConfig
+	// BatchSize is the number of payloads published together in a single message.
+	// Payloads are published one at a time when it is zero or one.
+	BatchSize int
+	// BatchLinger is how long a batch waits for more payloads before it is published
+	// anyway. A default of 100ms is used when it is zero.
+	BatchLinger time.Duration
+	GRPC        GRPCStorageConfig
+	MQTT        MQTTStorageConfig
+	// SchemaRegistry encodes the messages of the inmemory and kafka providers with the
+	// schemas of a schema registry when its URL is set.
+	SchemaRegistry SchemaRegistryConfig
+}
+
+// SchemaRegistryConfig configures the Confluent compatible schema registry the schemas
+// of the envelopes of payloads are registered in.
+type SchemaRegistryConfig struct {
+	URL string
+	// Format is the format envelopes are serialized with, avro or protobuf. Avro is
+	// used when it is empty.
+	Format string
+	// SubjectNameStrategy names the subject of the schema, one of topic-name,
+	// record-name or topic-record-name. topic-name is used when it is empty.
+	SubjectNameStrategy string
+	// BasicAuthPath is the path of a file holding the user:password the registry is
+	// called with, if any.
+	BasicAuthPath string
 }
 
 type KafkaStorageConfig struct {
 	BootstrapServers string
 }
 
+type EventHubsStorageConfig struct {
+	// Namespace is the fully qualified Event Hubs namespace, e.g.
+	// chains.servicebus.windows.net.
+	Namespace string
+}
+
+// GRPCStorageConfig configures the grpc provider, which streams payloads to a collector.
+type GRPCStorageConfig struct {
+	// Address is the address of the collector, e.g. collector.observability:9090.
+	Address string
+	// Insecure connects to the collector without TLS.
+	Insecure bool
+	// CAPath is the path of a PEM bundle of the CAs the certificate of the collector is
+	// verified with, instead of the system roots.
+	CAPath string
+	// MaxInFlight is the number of payloads sent to the collector that it may not have
+	// acknowledged yet, after which storing payloads waits. A default of 1000 is used
+	// when it is zero.
+	MaxInFlight int
+	// Timeout is how long storing a payload waits for the collector to acknowledge it,
+	// including while reconnecting. A default of 1m is used when it is zero.
+	Timeout time.Duration
+}
+
+// MQTTStorageConfig configures the mqtt provider, which publishes payloads to an MQTT
+// broker, on the topics rendered from the template of the pubsub topic.
+type MQTTStorageConfig struct {
+	// Broker is the URL of the broker, e.g. tcp://broker:1883 or ssl://broker:8883.
+	Broker string
+	// QoS is the quality of service payloads are published with, 0, 1 or 2. 1 is used
+	// when it is empty.
+	QoS string
+	// ClientID identifies the controller to the broker. The name of its host, the pod,
+	// is used when it is empty.
+	ClientID string
+	// CredentialsPath is the path of a file holding the user:password the controller
+	// connects with, if any.
+	CredentialsPath string
+	// CAPath is the path of a PEM bundle of the CAs the certificate of the broker is
+	// verified with, instead of the system roots.
+	CAPath string
+}
+
 type TransparencyConfig struct {
 	Enabled          bool
 	VerifyAnnotation bool
 	URL              string
+	// Version is the Rekor API entries are uploaded with, one of TransparencyV1 or
+	// TransparencyV2. TransparencyV1 is used when it is empty.
+	Version string
+	// CAPath is the path of a PEM bundle of the CAs the certificate of a private Rekor
+	// instance is verified with, in addition to the system roots.
+	CAPath string
+	// CertPath and KeyPath are the paths of the PEM client certificate and key
+	// presented to Rekor instances requiring mTLS.
+	CertPath, KeyPath string
+	// ProxyURL is the URL of the proxy Rekor is connected to through. The proxy of the
+	// environment is used when it is empty.
+	ProxyURL string
+	// QueueEnabled queues the entries that can't be uploaded because the transparency
+	// log is unreachable in TransparencyEntries, which are uploaded every QueueInterval
+	// until they are. A default of one minute is used when QueueInterval is zero.
+	QueueEnabled  bool
+	QueueInterval time.Duration
+	// Timeout bounds how long uploading an entry may take. Uploads aren't bounded when
+	// it is zero.
+	Timeout time.Duration
+}
+
+// CustomTransport returns whether the transparency log is connected to with a custom
+// CA, client certificate or proxy.
+func (t TransparencyConfig) CustomTransport() bool {
+	return t.CAPath != "" || t.CertPath != "" || t.ProxyURL != ""
+}
+
+// NamespaceConfig restricts the namespaces Chains reconciles runs in.
+type NamespaceConfig struct {
+	// Watched are the only namespaces runs are reconciled in. All namespaces are watched when empty.
+	Watched sets.Set[string]
+	// Excluded are the namespaces runs are never reconciled in. It takes precedence over Watched.
+	Excluded sets.Set[string]
+}
+
+// Allowed returns whether runs in the given namespace should be reconciled.
+func (n *NamespaceConfig) Allowed(namespace string) bool {
+	if n.Excluded.Has(namespace) {
+		return false
+	}
+	watched := n.Watched.Clone().Delete("")
+	return watched.Len() == 0 || watched.Has(namespace)
+}
+
+// SelectorConfig restricts the runs Chains reconciles by their labels.
+type SelectorConfig struct {
+	// LabelSelector is a label selector runs must match to be reconciled. All runs match when empty.
+	LabelSelector string
+}
+
+// Selects returns whether runs with the given metadata should be reconciled.
+func (cfg *Config) Selects(obj metav1.Object) bool {
+	if !cfg.Namespaces.Allowed(obj.GetNamespace()) {
+		return false
+	}
+	if cfg.Selector.LabelSelector == "" {
+		return true
+	}
+	selector, err := labels.Parse(cfg.Selector.LabelSelector)
+	if err != nil {
+		// The selector is validated when the config is parsed.
+		return false
+	}
+	return selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// RetryConfig configures how failures to sign or store provenance are retried.
+type RetryConfig struct {
+	// MaxRetries is the number of retries before an object is marked as failed.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry. When zero, retries are
+	// scheduled by the controller's rate limiter instead.
+	InitialBackoff time.Duration
+	// BackoffMultiplier is the factor the delay grows by on every retry.
+	BackoffMultiplier float64
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the delay that is randomly added to it.
+	Jitter float64
+	// NoRetryErrors are the classes of errors that mark an object as failed without retrying.
+	NoRetryErrors sets.Set[string]
+}
+
+// FinalizerConfig configures how long the Chains finalizer holds runs that are being deleted.
+type FinalizerConfig struct {
+	// BlockDeletion holds the finalizer of a deleted run until it is signed and stored,
+	// instead of releasing it once retries are exhausted.
+	BlockDeletion bool
+	// Timeout is how long after the deletion was requested the finalizer is released
+	// regardless of whether the run was signed.
+	Timeout time.Duration
+}
+
+// DryRunConfig configures generating payloads without signing or storing them.
+type DryRunConfig struct {
+	// Enabled turns on dry-run mode for all namespaces.
+	Enabled bool
+	// Namespaces turns on dry-run mode for runs in the given namespaces only.
+	Namespaces sets.Set[string]
+	// Directory is the scratch directory unsigned payloads are written to. They are
+	// only logged when it is empty.
+	Directory string
+}
+
+// Applies returns whether runs in the given namespace are handled in dry-run mode.
+func (d *DryRunConfig) Applies(namespace string) bool {
+	return d.Enabled || d.Namespaces.Has(namespace)
+}
+
+// AuditConfig configures the structured audit log of signing decisions.
+type AuditConfig struct {
+	// Sink is where audit events are written to: "stdout" or "file". The audit log
+	// is disabled when it is empty.
+	Sink string
+	// FilePath is the file audit events are appended to when Sink is "file".
+	FilePath string
+	// KeyUsage is whether every use of a signing key is recorded in a Kubernetes Event
+	// on the run and a structured log line.
+	KeyUsage bool
+}
+
+// WebhookConfig configures the optional validating admission webhook.
+type WebhookConfig struct {
+	// RejectInvalid rejects resources with invalid type hints or Chains annotations.
+	// They are admitted with warnings otherwise.
+	RejectInvalid bool
+}
+
+// OverridesConfig configures which keys runs may override with annotations.
+type OverridesConfig struct {
+	// Allowed are the keys that may be overridden. Overrides are ignored when it is empty.
+	Allowed sets.Set[string]
+}
+
+// ConcurrencyConfig tunes signing throughput against KMS and registry quotas.
+type ConcurrencyConfig struct {
+	// TaskRunWorkers and PipelineRunWorkers are the number of runs of each kind that are
+	// reconciled concurrently. The controller default is used when they are zero. Changes
+	// take effect when the controller restarts.
+	TaskRunWorkers     int
+	PipelineRunWorkers int
+	// SigningRate is the number of payloads signed per second across all runs. Signing
+	// is not rate limited when it is zero.
+	SigningRate float64
+	// SigningBurst is the number of payloads that may be signed at once above SigningRate.
+	SigningBurst int
+	// BackendLimits caps the number of concurrent uploads to each storage backend.
+	BackendLimits map[string]int
+	// BackendTimeouts bounds how long storing a payload in each storage backend may take,
+	// and SignerTimeouts how long signing a payload with each signer may take, so that a
+	// slow dependency doesn't use up the time runs are signed in. Backends and signers
+	// without a timeout aren't bounded. Only kms signers have a timeout.
+	BackendTimeouts map[string]time.Duration
+	SignerTimeouts  map[string]time.Duration
+	// NamespaceLimit caps the number of runs of each namespace a controller replica signs
+	// at once, so that a namespace creating many runs can't keep the workers from signing
+	// the runs of the others. NamespaceLimits overrides it for some namespaces. Runs are
+	// not limited when the limit of their namespace is zero.
+	NamespaceLimit  int
+	NamespaceLimits map[string]int
+	// UploadParallelism is the number of uploads of a payload, to its storage backends
+	// and the transparency log, that run at once. A default of 4 is used when it is zero.
+	UploadParallelism int
+}
+
+// LeaseConfig configures the Lease a controller replica holds on a run while signing it,
+// so that replicas can't sign the same run at the same time.
+type LeaseConfig struct {
+	// Enabled turns on taking a Lease per run. Concurrent signing of the same run by a
+	// single replica is always prevented.
+	Enabled bool
+	// Duration is how long a Lease is held before another replica may take it over, in
+	// case the holder died while signing. A default of one minute is used when it is zero.
+	Duration time.Duration
+}
+
+// ShutdownConfig configures how the controller shuts down.
+type ShutdownConfig struct {
+	// DrainTimeout is how long runs that are being signed may take to finish once the
+	// controller started shutting down. A default of 25 seconds is used when it is zero.
+	DrainTimeout time.Duration
+}
+
+// SigningStatusConfig configures reporting the outcome of signing each run in a SigningStatus.
+type SigningStatusConfig struct {
+	// Enabled creates a SigningStatus in the namespace of every run that is signed.
+	Enabled bool
+}
+
+// EventsConfig configures emitting CloudEvents about attestations.
+type EventsConfig struct {
+	// Sink is the URL events are sent to. No events are emitted when it is empty.
+	Sink string
+}
+
+// PolicyConfig configures the policies payloads are evaluated against before they are
+// signed. Payloads that violate them are not signed.
+type PolicyConfig struct {
+	// URL is the URL of the OPA server the policies are loaded in. Payloads are not
+	// evaluated when it is empty.
+	URL string
+	// Path is the path of the rule that evaluates to the set of policy violations of a
+	// payload, e.g. chains/deny. DefaultPolicyPath is used when it is empty.
+	Path string
+}
+
+// DefaultPolicyPath is the path of the rule payloads are evaluated with by default.
+const DefaultPolicyPath = "chains/deny"
+
+// PublicKeysConfig configures publishing the verification material of the signers.
+type PublicKeysConfig struct {
+	// Enabled publishes the public keys of the signers and their rotation history in the
+	// chains-public-keys ConfigMap.
+	Enabled bool
+}
+
+// QueryConfig configures the read-only HTTP API serving attestations.
+type QueryConfig struct {
+	// Address is the address the API listens on, e.g. ":8081". It is disabled when empty.
+	Address string
+	// CertPath and KeyPath are the paths of the PEM certificate and key the API is served
+	// with. The API isn't served without them.
+	CertPath, KeyPath string
+	// MaxResults bounds the number of attestations returned for a query. A default of
+	// 100 is used when it is zero.
+	MaxResults int
+	// MaxRuns bounds the number of runs whose attestations are read from the storage
+	// backends for a query. A default of 500 is used when it is zero.
+	MaxRuns int
+}
+
+// GRPCConfig configures the gRPC API generating attestations on demand.
+type GRPCConfig struct {
+	// Address is the address the API listens on, e.g. ":9090". It is disabled when empty.
+	Address string
+	// CertPath and KeyPath are the paths of the PEM certificate and key the API is served
+	// with. The API isn't served without them.
+	CertPath, KeyPath string
+	// ClientCAPath is the path of a PEM bundle of the CAs client certificates are
+	// verified with. Clients must present a certificate when it is set.
+	ClientCAPath string
+}
+
+// ProvenanceConfig caps the size of the param and result values recorded in provenance,
+// so that a run with a huge result doesn't bloat every attestation about it, and of
+// the attestations themselves, so that storage backends don't reject them.
+type ProvenanceConfig struct {
+	// MaxValueKB is the size in kilobytes above which values are not recorded as they
+	// are. Values aren't capped when it is zero.
+	MaxValueKB int
+	// OversizedValues is how values larger than MaxValueKB are recorded, one of
+	// OversizedDigest or OversizedSkip. OversizedDigest is used when it is empty.
+	OversizedValues string
+	// MaxAttestationKB is the size in kilobytes above which attestations are truncated
+	// before they are signed. Attestations aren't truncated when it is zero.
+	MaxAttestationKB int
+	// ChainInputAttestations looks up the attestations of type-hinted input artifacts
+	// in the storage backends and records them as resolved dependencies.
+	ChainInputAttestations bool
+	// RecordEnvironment records the Kubernetes version of the cluster, and the OS,
+	// architecture and container runtime of the nodes the pods of a run executed on.
+	RecordEnvironment bool
+	// ConfigSnapshot records the configuration in force when a run is signed, one of
+	// ConfigSnapshotDigest or ConfigSnapshotContent. It isn't recorded when it is empty.
+	ConfigSnapshot string
+	// ValidatePayloads validates payloads against the schema of their predicate type
+	// before they are signed, and records the artifacts whose payloads are invalid.
+	ValidatePayloads bool
+	// DigestAlgorithms are the algorithms recorded in the digest sets of subjects and
+	// materials, among those the type hints and step images provide. All of them are
+	// recorded when it is empty.
+	DigestAlgorithms sets.Set[string]
+	// MaxInlineContentBytes is the size in bytes up to which the content type-hinted
+	// input artifacts provide is embedded in their resolved dependencies. Content isn't
+	// embedded when it is zero.
+	MaxInlineContentBytes int
+	// ComputeCompleteness sets the completeness flags of SLSA v0.2 predicates from the
+	// capture features that are enabled, instead of the static values of the formats.
+	ComputeCompleteness bool
+	// StepEnv is whether the env vars of steps are recorded, one of StepEnvInclude or
+	// StepEnvExclude. StepEnvInclude is used when it is empty. Tasks can override it
+	// with the chains.tekton.dev/step-env annotation.
+	StepEnv string
+	// StepEnvAllow are the names, or NAME_* prefixes, of the env vars of steps that are
+	// recorded. All of them are recorded when it is empty.
+	StepEnvAllow sets.Set[string]
+	// StepEnvDeny are the names, or NAME_* prefixes, of the env vars of steps that are
+	// never recorded, even by the tasks that opt into recording them.
+	StepEnvDeny sets.Set[string]
+}
+
+// IsolationConfig configures the signals that show the pods of a run were isolated,
+// which are recorded in provenance so that hermeticity can be assessed.
+type IsolationConfig struct {
+	// SandboxRuntimeClasses are the RuntimeClasses that run pods in a sandbox, such as gVisor or Kata.
+	SandboxRuntimeClasses sets.Set[string]
+	// NetworkLabels are the labels and annotations, as key or key=value, that show the
+	// pods of a run were cut off from the network or could only egress through a proxy,
+	// for example the label a NetworkPolicy denying egress selects pods with.
+	NetworkLabels sets.Set[string]
+}
+
+// MaterialsConfig configures the registries and URI prefixes the step images and
+// materials of runs are allowed to come from, to support trusted base image policies.
+type MaterialsConfig struct {
+	// AllowedPrefixes are the prefixes the URIs of materials must start with, with or
+	// without their scheme. Materials are not checked when it is empty.
+	AllowedPrefixes sets.Set[string]
+	// Enforcement is what happens to runs with materials that aren't allowed, one of
+	// MaterialsAnnotate, MaterialsBlock or MaterialsProvenance. MaterialsAnnotate is
+	// used when it is empty.
+	Enforcement string
+}
+
+// ClustersConfig configures the workload clusters Chains watches runs in, in addition to
+// the cluster it runs in. Their kubeconfigs are passed to the controller with the
+// -cluster-kubeconfigs flag.
+type ClustersConfig struct {
+	// BuilderIDs are the builder IDs recorded in the provenance of the runs of each
+	// cluster, keyed by cluster name. Builder.ID is used for clusters without one.
+	BuilderIDs map[string]string
+}
+
+// SigstoreConfig maps namespaces to the Sigstore stacks their runs are signed with, so
+// that tenants with private Sigstore deployments and tenants using the public good
+// instance can share a cluster.
+type SigstoreConfig struct {
+	// Stacks are the Sigstore stacks, keyed by name.
+	Stacks map[string]SigstoreStack
+}
+
+// SigstoreStack is a Sigstore deployment. The values of the signers and transparency
+// configuration are used for the fields that are empty.
+type SigstoreStack struct {
+	// Namespaces are the namespaces whose runs are signed with the stack. A namespace
+	// may only belong to one stack.
+	Namespaces sets.Set[string]
+	// TUFMirrorURL is the URL of the TUF repository holding the trust root of the stack.
+	TUFMirrorURL string
+	// FulcioAddr is the address of the Fulcio instance of the stack.
+	FulcioAddr string
+	// FulcioOIDCIssuer is the OIDC issuer trusted by the Fulcio instance of the stack.
+	FulcioOIDCIssuer string
+	// RekorURL is the URL of the Rekor instance of the stack.
+	RekorURL string
+}
+
+// ApplySigstoreStack replaces the Fulcio, TUF and Rekor configuration of cfg with that
+// of the Sigstore stack of namespace, and returns its name. It returns "" and leaves
+// cfg unchanged if the namespace doesn't belong to a stack.
+func (cfg *Config) ApplySigstoreStack(namespace string) string {
+	for name, stack := range cfg.Sigstore.Stacks {
+		if !stack.Namespaces.Has(namespace) {
+			continue
+		}
+		if stack.TUFMirrorURL != "" {
+			cfg.Signers.X509.TUFMirrorURL = stack.TUFMirrorURL
+		}
+		if stack.FulcioAddr != "" {
+			cfg.Signers.X509.FulcioAddr = stack.FulcioAddr
+		}
+		if stack.FulcioOIDCIssuer != "" {
+			cfg.Signers.X509.FulcioOIDCIssuer = stack.FulcioOIDCIssuer
+		}
+		if stack.RekorURL != "" {
+			cfg.Transparency.URL = stack.RekorURL
+		}
+		return name
+	}
+	return ""
+}
+
+// ProfilingConfig configures the runtime profiling of the controller, to diagnose memory
+// growth in the informer caches and payload generation under production load.
+type ProfilingConfig struct {
+	// Address is the address the pprof endpoints are served on, e.g. "localhost:6060".
+	// They are disabled when it is empty.
+	Address string
+	// HeapInterval is how long to wait between heap profiles written to HeapDir. Heap
+	// profiles aren't written when it is zero.
+	HeapInterval time.Duration
+	// HeapDir is the directory heap profiles are written to. A default of
+	// /tmp/chains-profiles is used when it is empty.
+	HeapDir string
+	// HeapKeep is the number of heap profiles kept in HeapDir, the oldest being removed
+	// first. A default of 10 is used when it is zero.
+	HeapKeep int
+}
+
+const (
+	// TransparencyV1 uploads entries with the Rekor v1 API.
+	TransparencyV1 = "v1"
+	// TransparencyV2 uploads entries with the API of the tile-backed Rekor v2 logs.
+	TransparencyV2 = "v2"
+
+	// OversizedDigest replaces oversized values with their sha256 digest.
+	OversizedDigest = "digest"
+	// OversizedSkip leaves oversized values out.
+	OversizedSkip = "skip"
+
+	// StepEnvInclude records the env vars of steps.
+	StepEnvInclude = "include"
+	// StepEnvExclude leaves the env vars of steps out.
+	StepEnvExclude = "exclude"
+
+	// MaterialsAnnotate lists the materials that aren't allowed in an annotation of the run.
+	MaterialsAnnotate = "annotate"
+	// MaterialsBlock doesn't sign payloads with materials that aren't allowed.
+	MaterialsBlock = "block"
+	// MaterialsProvenance records the materials that aren't allowed in the provenance.
+	MaterialsProvenance = "provenance"
+
+	// ConfigSnapshotDigest records the sha256 digest of the configuration.
+	ConfigSnapshotDigest = "digest"
+	// ConfigSnapshotContent records the configuration along with its digest.
+	ConfigSnapshotContent = "content"
+)
+
+// ConformanceConfig configures the conformance probe, which periodically runs a canary
+// TaskRun and verifies the provenance Chains generated for it.
+type ConformanceConfig struct {
+	// Enabled turns on the probe.
+	Enabled bool
+	// Interval is how long to wait between probes. A default of one hour is used when it
+	// is zero.
+	Interval time.Duration
+	// Timeout is how long the canary TaskRun may take to complete and be signed. A
+	// default of ten minutes is used when it is zero.
+	Timeout time.Duration
+	// Namespace is the namespace the canary TaskRuns are created in. The controller
+	// namespace is used when it is empty.
+	Namespace string
+	// Image is the image of the step of the canary TaskRuns. A default of
+	// cgr.dev/chainguard/busybox is used when it is empty.
+	Image string
+}
+
+// HealthConfig configures the health checks of the storage backends and signers, which
+// run when the config is loaded and then periodically.
+type HealthConfig struct {
+	// Enabled turns on the checks.
+	Enabled bool
+	// Interval is how long to wait between checks. A default of five minutes is used
+	// when it is zero.
+	Interval time.Duration
+	// Timeout is how long each check may take. A default of thirty seconds is used when
+	// it is zero.
+	Timeout time.Duration
+	// Address is the address the readiness endpoint is served on, if any.
+	Address string
+}
+
+// MetricsConfig configures the namespace and pipeline labels of the signing and storage
+// metrics, whose number of values is capped so that multi-tenant clusters don't create
+// a series per namespace or pipeline.
+type MetricsConfig struct {
+	// NamespaceLabel and PipelineLabel add the namespace of runs, and the pipeline of
+	// PipelineRuns and of the TaskRuns of pipelines, to the labels of the metrics.
+	NamespaceLabel bool
+	PipelineLabel  bool
+	// Namespaces are the namespaces recorded in the namespace label, if any. The others
+	// are recorded as "other".
+	Namespaces sets.Set[string]
+	// MaxLabelValues is the number of values each label records before the others are
+	// recorded as "other". A default of 100 is used when it is zero.
+	MaxLabelValues int
+}
+
+// TracingConfig configures exporting OpenTelemetry traces for the signing pipeline.
+type TracingConfig struct {
+	// Endpoint is the host:port of the OTLP/HTTP collector spans are exported to.
+	// Tracing is disabled when it is empty.
+	Endpoint string
+	// Insecure disables TLS when talking to the collector.
+	Insecure bool
 }
 
 const (
@@ -155,18 +854,49 @@ const (
 	pipelinerunStorageKey              = "artifacts.pipelinerun.storage"
 	pipelinerunSignerKey               = "artifacts.pipelinerun.signer"
 	pipelinerunEnableDeepInspectionKey = "artifacts.pipelinerun.enable-deep-inspection"
+	pipelinerunBundleStorageKey        = "artifacts.pipelinerun.bundle.storage"
+	pipelinerunResultDigestsKey        = "artifacts.pipelinerun.result-digests"
 
 	ociFormatKey  = "artifacts.oci.format"
 	ociStorageKey = "artifacts.oci.storage"
 	ociSignerKey  = "artifacts.oci.signer"
 
+	vexStorageKey = "artifacts.vex.storage"
+	vexSignerKey  = "artifacts.vex.signer"
+
+	tektonBundleStorageKey = "artifacts.tekton-bundle.storage"
+	tektonBundleSignerKey  = "artifacts.tekton-bundle.signer"
+
+	sourceStorageKey   = "artifacts.source.storage"
+	sourceFetchRunsKey = "artifacts.source.fetch-runs"
+
+	customrunFormatKey  = "artifacts.customrun.format"
+	customrunStorageKey = "artifacts.customrun.storage"
+	customrunSignerKey  = "artifacts.customrun.signer"
+
 	gcsBucketKey             = "storage.gcs.bucket"
+	gcsNameTemplateKey       = "storage.gcs.name-template"
 	ociRepositoryKey         = "storage.oci.repository"
 	ociRepositoryInsecureKey = "storage.oci.repository.insecure"
+	ociTagsKey               = "storage.oci.tags"
+	ociAttachToPlatformsKey  = "storage.oci.attach-to-platforms"
+	tektonMaxAnnotationKey   = "storage.tekton.max-annotation-size"
 	docDBUrlKey              = "storage.docdb.url"
+	docDBNameTemplateKey     = "storage.docdb.name-template"
 	grafeasProjectIDKey      = "storage.grafeas.projectid"
 	grafeasNoteIDKey         = "storage.grafeas.noteid"
 	grafeasNoteHint          = "storage.grafeas.notehint"
+	ipfsURLKey               = "storage.ipfs.url"
+	ipfsTokenPathKey         = "storage.ipfs.token-path"
+	githubURLKey             = "storage.github.url"
+	githubRepositoryKey      = "storage.github.repository"
+	githubAppIDKey           = "storage.github.app-id"
+	githubInstallationIDKey  = "storage.github.installation-id"
+	githubPrivateKeyPathKey  = "storage.github.private-key-path"
+	gitlabURLKey             = "storage.gitlab.url"
+	gitlabProjectKey         = "storage.gitlab.project"
+	gitlabTokenPathKey       = "storage.gitlab.token-path"
+	gitlabPackageKey         = "storage.gitlab.package"
 
 	// PubSub - General
 	pubsubProvider = "storage.pubsub.provider"
@@ -177,6 +907,33 @@ const (
 	// PubSub - Kafka
 	pubsubKafkaBootstrapServer = "storage.pubsub.kafka.bootstrap.servers"
 
+	// PubSub - Event Hubs
+	pubsubEventHubsNamespaceKey = "storage.pubsub.eventhubs.namespace"
+
+	// PubSub - Batching
+	pubsubBatchSizeKey   = "storage.pubsub.batch-size"
+	pubsubBatchLingerKey = "storage.pubsub.batch-linger"
+
+	// PubSub - gRPC
+	pubsubGRPCAddressKey     = "storage.pubsub.grpc.address"
+	pubsubGRPCInsecureKey    = "storage.pubsub.grpc.insecure"
+	pubsubGRPCCAPathKey      = "storage.pubsub.grpc.ca-path"
+	pubsubGRPCMaxInFlightKey = "storage.pubsub.grpc.max-in-flight"
+	pubsubGRPCTimeoutKey     = "storage.pubsub.grpc.timeout"
+
+	// PubSub - MQTT
+	pubsubMQTTBrokerKey          = "storage.pubsub.mqtt.broker"
+	pubsubMQTTQoSKey             = "storage.pubsub.mqtt.qos"
+	pubsubMQTTClientIDKey        = "storage.pubsub.mqtt.client-id"
+	pubsubMQTTCredentialsPathKey = "storage.pubsub.mqtt.credentials-path"
+	pubsubMQTTCAPathKey          = "storage.pubsub.mqtt.ca-path"
+
+	// PubSub - Schema registry
+	pubsubSchemaRegistryURLKey           = "storage.pubsub.schema-registry.url"
+	pubsubSchemaRegistryFormatKey        = "storage.pubsub.schema-registry.format"
+	pubsubSchemaRegistrySubjectNameKey   = "storage.pubsub.schema-registry.subject-name-strategy"
+	pubsubSchemaRegistryBasicAuthPathKey = "storage.pubsub.schema-registry.basic-auth-path"
+
 	// KMS
 	kmsSignerKMSRef      = "signers.kms.kmsref"
 	kmsAuthAddress       = "signers.kms.auth.address"
@@ -192,21 +949,221 @@ const (
 	x509SignerFulcioOIDCIssuer  = "signers.x509.fulcio.issuer"
 	x509SignerFulcioProvider    = "signers.x509.fulcio.provider"
 	x509SignerIdentityTokenFile = "signers.x509.identity.token.file"
+	x509SignerIdentityTokenCmd  = "signers.x509.identity.token.command"
+	x509SignerIdentityTokenAud  = "signers.x509.identity.token.audience"
 	x509SignerTUFMirrorURL      = "signers.x509.tuf.mirror.url"
 
+	// Signing secret
+	signingSecretNamespaceKey         = "signers.secret.namespace"
+	signingSecretNameKey              = "signers.secret.name"
+	signingSecretAllowedNamespacesKey = "signers.secret.allowed-namespaces"
+
+	// External secrets, signers.external.<file> is the reference of a file of the
+	// signing secrets.
+	externalSecretsPrefix             = "signers.external."
+	externalSecretsRefreshIntervalKey = "signers.external.refresh-interval"
+
 	// Builder config
-	builderIDKey = "builder.id"
+	builderIDKey        = "builder.id"
+	builderClusterKey   = "builder.cluster"
+	builderBuildTypeKey = "builder.build-type"
+
+	transparencyEnabledKey       = "transparency.enabled"
+	transparencyURLKey           = "transparency.url"
+	transparencyVersionKey       = "transparency.version"
+	transparencyCAPathKey        = "transparency.tls.ca-path"
+	transparencyCertPathKey      = "transparency.tls.cert-path"
+	transparencyKeyPathKey       = "transparency.tls.key-path"
+	transparencyProxyURLKey      = "transparency.proxy-url"
+	transparencyQueueKey         = "transparency.queue.enabled"
+	transparencyQueueIntervalKey = "transparency.queue.interval"
+	transparencyTimeoutKey       = "transparency.timeout"
+
+	// Namespaces
+	watchedNamespacesKey  = "watched-namespaces"
+	excludedNamespacesKey = "excluded-namespaces"
+
+	// Selector
+	labelSelectorKey = "label-selector"
+
+	// Retry policy
+	retryMaxRetriesKey        = "retry.max-retries"
+	retryInitialBackoffKey    = "retry.backoff.initial"
+	retryBackoffMultiplierKey = "retry.backoff.multiplier"
+	retryMaxBackoffKey        = "retry.backoff.max"
+	retryJitterKey            = "retry.backoff.jitter"
+	retryNoRetryErrorsKey     = "retry.no-retry-errors"
+
+	// Finalizer
+	finalizerBlockDeletionKey = "finalizer.block-deletion"
+	finalizerTimeoutKey       = "finalizer.timeout"
+
+	// Dry-run
+	dryRunEnabledKey    = "dryrun.enabled"
+	dryRunNamespacesKey = "dryrun.namespaces"
+	dryRunDirectoryKey  = "dryrun.directory"
+
+	// Audit log
+	auditSinkKey     = "audit.sink"
+	auditFilePathKey = "audit.file.path"
+	auditKeyUsageKey = "audit.key-usage"
+
+	// Webhook
+	webhookRejectInvalidKey = "webhook.reject-invalid"
+
+	// Per-run overrides
+	overridesAllowedKeysKey = "overrides.allowed-keys"
 
-	transparencyEnabledKey = "transparency.enabled"
-	transparencyURLKey     = "transparency.url"
+	// Concurrency
+	taskrunWorkersKey           = "controller.taskrun.workers"
+	pipelinerunWorkersKey       = "controller.pipelinerun.workers"
+	signingRateKey              = "signing.rate"
+	signingBurstKey             = "signing.burst"
+	backendMaxConcurrencySuffix = ".max-concurrency"
+	timeoutSuffix               = ".timeout"
+	namespaceLimitPrefix        = "signing.namespace."
+	namespaceLimitSuffix        = ".max-in-flight"
+	namespaceLimitKey           = namespaceLimitPrefix + "max-in-flight"
+	uploadParallelismKey        = "storage.parallelism"
+
+	// Signing leases
+	signingLeaseEnabledKey  = "signing.lease.enabled"
+	signingLeaseDurationKey = "signing.lease.duration"
+
+	// Shutdown
+	drainTimeoutKey = "controller.drain-timeout"
+
+	// SigningStatus
+	signingStatusEnabledKey = "signingstatus.enabled"
+
+	// CloudEvents
+	eventsSinkKey = "events.sink"
+
+	// Policy
+	policyOPAURLKey  = "policy.opa.url"
+	policyOPAPathKey = "policy.opa.path"
+
+	// Attestation query API
+	queryAddressKey    = "query.address"
+	queryCertPathKey   = "query.tls.cert-path"
+	queryKeyPathKey    = "query.tls.key-path"
+	queryMaxResultsKey = "query.max-results"
+	queryMaxRunsKey    = "query.max-runs"
+
+	// On-demand attestation gRPC API
+	grpcAddressKey      = "grpc.address"
+	grpcCertPathKey     = "grpc.tls.cert-path"
+	grpcKeyPathKey      = "grpc.tls.key-path"
+	grpcClientCAPathKey = "grpc.tls.client-ca-path"
+
+	// Public keys
+	publicKeysEnabledKey = "publickeys.enabled"
+
+	// Conformance probe
+	conformanceEnabledKey   = "conformance.enabled"
+	conformanceIntervalKey  = "conformance.interval"
+	conformanceTimeoutKey   = "conformance.timeout"
+	conformanceNamespaceKey = "conformance.namespace"
+	conformanceImageKey     = "conformance.image"
+
+	// Health checks
+	healthEnabledKey  = "health.enabled"
+	healthIntervalKey = "health.interval"
+	healthTimeoutKey  = "health.timeout"
+	healthAddressKey  = "health.address"
+
+	// Metrics
+	metricsNamespaceLabelKey = "metrics.labels.namespace"
+	metricsPipelineLabelKey  = "metrics.labels.pipeline"
+	metricsNamespacesKey     = "metrics.labels.namespaces"
+	metricsMaxLabelValuesKey = "metrics.labels.max-values"
+
+	// Provenance
+	provenanceMaxValueKBKey        = "provenance.max-value-kb"
+	provenanceOversizedValuesKey   = "provenance.oversized-values"
+	provenanceMaxAttestationKBKey  = "provenance.max-attestation-kb"
+	provenanceChainInputsKey       = "provenance.chain-input-attestations"
+	provenanceRecordEnvironmentKey = "provenance.record-environment"
+	provenanceConfigSnapshotKey    = "provenance.config-snapshot"
+	provenanceValidatePayloadsKey  = "provenance.validate-payloads"
+	provenanceDigestAlgorithmsKey  = "provenance.digest-algorithms"
+	provenanceMaxInlineContentKey  = "provenance.max-inline-content-bytes"
+	provenanceCompletenessKey      = "provenance.compute-completeness"
+	provenanceStepEnvKey           = "provenance.step-env"
+	provenanceStepEnvAllowKey      = "provenance.step-env.allow"
+	provenanceStepEnvDenyKey       = "provenance.step-env.deny"
+
+	// Isolation
+	isolationSandboxRuntimeClassesKey = "isolation.sandbox-runtime-classes"
+	isolationNetworkLabelsKey         = "isolation.network-labels"
+
+	// Materials
+	materialsAllowedPrefixesKey = "materials.allowed-prefixes"
+	materialsEnforcementKey     = "materials.enforcement"
+
+	// Clusters
+	clustersPrefix         = "clusters."
+	clusterBuilderIDSuffix = ".builder-id"
+
+	// Sigstore stacks
+	sigstorePrefix             = "sigstore."
+	sigstoreNamespacesSuffix   = ".namespaces"
+	sigstoreTUFMirrorURLSuffix = ".tuf-mirror-url"
+	sigstoreFulcioAddrSuffix   = ".fulcio-address"
+	sigstoreFulcioIssuerSuffix = ".fulcio-issuer"
+	sigstoreRekorURLSuffix     = ".rekor-url"
+
+	// Profiling
+	profilingAddressKey      = "profiling.address"
+	profilingHeapIntervalKey = "profiling.heap-interval"
+	profilingHeapDirKey      = "profiling.heap-dir"
+	profilingHeapKeepKey     = "profiling.heap-keep"
+
+	// Tracing
+	tracingOTLPEndpointKey = "tracing.otlp.endpoint"
+	tracingOTLPInsecureKey = "tracing.otlp.insecure"
 
 	ChainsConfig = "chains-config"
 )
 
+// Supported formats and storage backends of the TaskRun, PipelineRun and CustomRun artifacts.
+var (
+	taskrunFormats             = []string{"in-toto", "slsa/v1", "slsa/v2alpha1", "slsa/v2alpha2"}
+	taskrunStorageBackends     = sets.New[string]("tekton", "attestation", "oci", "gcs", "docdb", "grafeas", "kafka", "ipfs", "github", "gitlab")
+	pipelinerunFormats         = []string{"in-toto", "slsa/v1", "slsa/v2alpha2"}
+	pipelinerunStorageBackends = sets.New[string]("tekton", "attestation", "oci", "docdb", "grafeas", "ipfs", "github", "gitlab")
+	customrunFormats           = []string{"in-toto", "slsa/v1"}
+	customrunStorageBackends   = sets.New[string]("tekton", "attestation", "oci", "docdb", "grafeas", "ipfs", "github", "gitlab")
+	// vexStorageBackends are the backends that can store OpenVEX attestations.
+	vexStorageBackends = sets.New[string]("tekton", "attestation", "oci", "gcs", "docdb", "ipfs", "github", "gitlab")
+	// tektonBundleStorageBackends are the backends that can store Tekton bundle
+	// attestations.
+	tektonBundleStorageBackends = sets.New[string]("tekton", "attestation", "oci", "gcs", "docdb", "ipfs", "github", "gitlab")
+	// sourceStorageBackends are the backends that can store source attestations. Their
+	// subjects are repositories, not images, so they can't be stored in OCI registries.
+	sourceStorageBackends = sets.New[string]("tekton", "attestation", "gcs", "docdb", "ipfs", "github", "gitlab")
+
+	// limitedBackends are the storage backends whose concurrency can be limited.
+	limitedBackends = sets.New[string]("tekton", "attestation", "oci", "gcs", "docdb", "grafeas", "pubsub", "ipfs", "github", "gitlab")
+	// timedSigners are the signers whose signing time can be bounded. x509 signers sign
+	// in the controller, without calling out to anything.
+	timedSigners = sets.New[string]("kms")
+
+	// signingSecretFiles are the files of the signing secrets signers are loaded from.
+	signingSecretFiles = sets.New[string]("x509.pem", "cosign.key", "cosign.password")
+	// externalSecretSchemes are the schemes of the references of external secrets.
+	externalSecretSchemes = sets.New[string]("awssm", "gcpsm", "hashivault")
+)
+
 func (artifact *Artifact) Enabled() bool {
 	return !(artifact.StorageBackend.Len() == 1 && artifact.StorageBackend.Has(""))
 }
 
+// BundleEnabled returns whether an aggregated bundle should be stored for the artifact.
+func (artifact *Artifact) BundleEnabled() bool {
+	return artifact.BundleStorageBackend.Len() > 0 && !(artifact.BundleStorageBackend.Len() == 1 && artifact.BundleStorageBackend.Has(""))
+}
+
 func defaultConfig() *Config {
 	return &Config{
 		Artifacts: ArtifactConfigs{
@@ -245,6 +1202,11 @@ func defaultConfig() *Config {
 		Builder: BuilderConfig{
 			ID: "https://tekton.dev/chains/v2",
 		},
+		Retry: RetryConfig{
+			MaxRetries:        3,
+			BackoffMultiplier: 2,
+			MaxBackoff:        5 * time.Minute,
+		},
 	}
 }
 
@@ -255,40 +1217,218 @@ func NewConfigFromMap(data map[string]string) (*Config, error) {
 	if err := cm.Parse(data,
 		// Artifact-specific configs
 		// TaskRuns
-		asString(taskrunFormatKey, &cfg.Artifacts.TaskRuns.Format, "in-toto", "slsa/v1", "slsa/v2alpha1", "slsa/v2alpha2"),
-		asStringSet(taskrunStorageKey, &cfg.Artifacts.TaskRuns.StorageBackend, sets.New[string]("tekton", "oci", "gcs", "docdb", "grafeas", "kafka")),
+		asString(taskrunFormatKey, &cfg.Artifacts.TaskRuns.Format, taskrunFormats...),
+		asStringSet(taskrunStorageKey, &cfg.Artifacts.TaskRuns.StorageBackend, taskrunStorageBackends),
 		asString(taskrunSignerKey, &cfg.Artifacts.TaskRuns.Signer, "x509", "kms"),
 
 		// PipelineRuns
-		asString(pipelinerunFormatKey, &cfg.Artifacts.PipelineRuns.Format, "in-toto", "slsa/v1", "slsa/v2alpha2"),
-		asStringSet(pipelinerunStorageKey, &cfg.Artifacts.PipelineRuns.StorageBackend, sets.New[string]("tekton", "oci", "docdb", "grafeas")),
+		asString(pipelinerunFormatKey, &cfg.Artifacts.PipelineRuns.Format, pipelinerunFormats...),
+		asStringSet(pipelinerunStorageKey, &cfg.Artifacts.PipelineRuns.StorageBackend, pipelinerunStorageBackends),
 		asString(pipelinerunSignerKey, &cfg.Artifacts.PipelineRuns.Signer, "x509", "kms"),
 		asBool(pipelinerunEnableDeepInspectionKey, &cfg.Artifacts.PipelineRuns.DeepInspectionEnabled),
+		asStringSet(pipelinerunBundleStorageKey, &cfg.Artifacts.PipelineRuns.BundleStorageBackend, sets.New[string]("tekton", "ipfs")),
+		asBool(pipelinerunResultDigestsKey, &cfg.Artifacts.PipelineRuns.ResultDigests),
 
 		// OCI
 		asString(ociFormatKey, &cfg.Artifacts.OCI.Format, "simplesigning"),
-		asStringSet(ociStorageKey, &cfg.Artifacts.OCI.StorageBackend, sets.New[string]("tekton", "oci", "gcs", "docdb", "grafeas", "kafka")),
+		asStringSet(ociStorageKey, &cfg.Artifacts.OCI.StorageBackend, sets.New[string]("tekton", "attestation", "oci", "gcs", "docdb", "grafeas", "kafka", "ipfs", "gitlab")),
 		asString(ociSignerKey, &cfg.Artifacts.OCI.Signer, "x509", "kms"),
 
+		// VEX
+		asStringSet(vexStorageKey, &cfg.Artifacts.VEX.StorageBackend, vexStorageBackends),
+		asString(vexSignerKey, &cfg.Artifacts.VEX.Signer, "x509", "kms"),
+
+		// Tekton bundles
+		asStringSet(tektonBundleStorageKey, &cfg.Artifacts.TektonBundles.StorageBackend, tektonBundleStorageBackends),
+		asString(tektonBundleSignerKey, &cfg.Artifacts.TektonBundles.Signer, "x509", "kms"),
+
+		// Source commits
+		asStringSet(sourceStorageKey, &cfg.Artifacts.Source.StorageBackend, sourceStorageBackends),
+		asBool(sourceFetchRunsKey, &cfg.Artifacts.Source.FetchRuns),
+
+		// CustomRuns
+		asString(customrunFormatKey, &cfg.Artifacts.CustomRuns.Format, customrunFormats...),
+		asStringSet(customrunStorageKey, &cfg.Artifacts.CustomRuns.StorageBackend, customrunStorageBackends),
+		asString(customrunSignerKey, &cfg.Artifacts.CustomRuns.Signer, "x509", "kms"),
+
 		// PubSub - General
-		asString(pubsubProvider, &cfg.Storage.PubSub.Provider, "inmemory", "kafka"),
+		asString(pubsubProvider, &cfg.Storage.PubSub.Provider, "inmemory", "kafka", "grpc", "sns", "sqs", "eventhubs", "mqtt"),
 		asString(pubsubTopic, &cfg.Storage.PubSub.Topic),
 
 		// PubSub - Kafka
 		asString(pubsubKafkaBootstrapServer, &cfg.Storage.PubSub.Kafka.BootstrapServers),
+		// PubSub - Event Hubs
+		asString(pubsubEventHubsNamespaceKey, &cfg.Storage.PubSub.EventHubs.Namespace),
+		cm.AsInt(pubsubBatchSizeKey, &cfg.Storage.PubSub.BatchSize),
+		cm.AsDuration(pubsubBatchLingerKey, &cfg.Storage.PubSub.BatchLinger),
+
+		// PubSub - gRPC
+		asString(pubsubGRPCAddressKey, &cfg.Storage.PubSub.GRPC.Address),
+		asBool(pubsubGRPCInsecureKey, &cfg.Storage.PubSub.GRPC.Insecure),
+		asString(pubsubGRPCCAPathKey, &cfg.Storage.PubSub.GRPC.CAPath),
+		cm.AsInt(pubsubGRPCMaxInFlightKey, &cfg.Storage.PubSub.GRPC.MaxInFlight),
+		cm.AsDuration(pubsubGRPCTimeoutKey, &cfg.Storage.PubSub.GRPC.Timeout),
+
+		// PubSub - MQTT
+		asString(pubsubMQTTBrokerKey, &cfg.Storage.PubSub.MQTT.Broker),
+		asString(pubsubMQTTQoSKey, &cfg.Storage.PubSub.MQTT.QoS, "0", "1", "2"),
+		asString(pubsubMQTTClientIDKey, &cfg.Storage.PubSub.MQTT.ClientID),
+		asString(pubsubMQTTCredentialsPathKey, &cfg.Storage.PubSub.MQTT.CredentialsPath),
+		asString(pubsubMQTTCAPathKey, &cfg.Storage.PubSub.MQTT.CAPath),
+
+		// PubSub - Schema registry
+		asString(pubsubSchemaRegistryURLKey, &cfg.Storage.PubSub.SchemaRegistry.URL),
+		asString(pubsubSchemaRegistryFormatKey, &cfg.Storage.PubSub.SchemaRegistry.Format, "avro", "protobuf"),
+		asString(pubsubSchemaRegistrySubjectNameKey, &cfg.Storage.PubSub.SchemaRegistry.SubjectNameStrategy, "topic-name", "record-name", "topic-record-name"),
+		asString(pubsubSchemaRegistryBasicAuthPathKey, &cfg.Storage.PubSub.SchemaRegistry.BasicAuthPath),
 
 		// Storage level configs
 		asString(gcsBucketKey, &cfg.Storage.GCS.Bucket),
+		asString(gcsNameTemplateKey, &cfg.Storage.GCS.NameTemplate),
 		asString(ociRepositoryKey, &cfg.Storage.OCI.Repository),
 		asBool(ociRepositoryInsecureKey, &cfg.Storage.OCI.Insecure),
+		asStringSet(ociTagsKey, &cfg.Storage.OCI.Tags, sets.New[string]()),
+		asBool(ociAttachToPlatformsKey, &cfg.Storage.OCI.AttachToPlatforms),
+		cm.AsInt(tektonMaxAnnotationKey, &cfg.Storage.Tekton.MaxAnnotationSize),
 		asString(docDBUrlKey, &cfg.Storage.DocDB.URL),
+		asString(docDBNameTemplateKey, &cfg.Storage.DocDB.NameTemplate),
 		asString(grafeasProjectIDKey, &cfg.Storage.Grafeas.ProjectID),
 		asString(grafeasNoteIDKey, &cfg.Storage.Grafeas.NoteID),
 		asString(grafeasNoteHint, &cfg.Storage.Grafeas.NoteHint),
+		asString(ipfsURLKey, &cfg.Storage.IPFS.URL),
+		asString(ipfsTokenPathKey, &cfg.Storage.IPFS.TokenPath),
+		asString(githubURLKey, &cfg.Storage.GitHub.URL),
+		asString(githubRepositoryKey, &cfg.Storage.GitHub.Repository),
+		cm.AsInt64(githubAppIDKey, &cfg.Storage.GitHub.AppID),
+		cm.AsInt64(githubInstallationIDKey, &cfg.Storage.GitHub.InstallationID),
+		asString(githubPrivateKeyPathKey, &cfg.Storage.GitHub.PrivateKeyPath),
+		asString(gitlabURLKey, &cfg.Storage.GitLab.URL),
+		asString(gitlabProjectKey, &cfg.Storage.GitLab.Project),
+		asString(gitlabTokenPathKey, &cfg.Storage.GitLab.TokenPath),
+		asString(gitlabPackageKey, &cfg.Storage.GitLab.Package),
 
 		oneOf(transparencyEnabledKey, &cfg.Transparency.Enabled, "true", "manual"),
 		oneOf(transparencyEnabledKey, &cfg.Transparency.VerifyAnnotation, "manual"),
 		asString(transparencyURLKey, &cfg.Transparency.URL),
+		asString(transparencyVersionKey, &cfg.Transparency.Version, TransparencyV1, TransparencyV2),
+		asString(transparencyCAPathKey, &cfg.Transparency.CAPath),
+		asString(transparencyCertPathKey, &cfg.Transparency.CertPath),
+		asString(transparencyKeyPathKey, &cfg.Transparency.KeyPath),
+		asString(transparencyProxyURLKey, &cfg.Transparency.ProxyURL),
+		asBool(transparencyQueueKey, &cfg.Transparency.QueueEnabled),
+		cm.AsDuration(transparencyQueueIntervalKey, &cfg.Transparency.QueueInterval),
+		cm.AsDuration(transparencyTimeoutKey, &cfg.Transparency.Timeout),
+
+		asStringSet(watchedNamespacesKey, &cfg.Namespaces.Watched, nil),
+		asStringSet(excludedNamespacesKey, &cfg.Namespaces.Excluded, nil),
+		asLabelSelector(labelSelectorKey, &cfg.Selector.LabelSelector),
+
+		cm.AsInt(retryMaxRetriesKey, &cfg.Retry.MaxRetries),
+		cm.AsDuration(retryInitialBackoffKey, &cfg.Retry.InitialBackoff),
+		cm.AsFloat64(retryBackoffMultiplierKey, &cfg.Retry.BackoffMultiplier),
+		cm.AsDuration(retryMaxBackoffKey, &cfg.Retry.MaxBackoff),
+		cm.AsFloat64(retryJitterKey, &cfg.Retry.Jitter),
+		asStringSet(retryNoRetryErrorsKey, &cfg.Retry.NoRetryErrors, sets.New[string]("signing", "storage", "transparency", "policy")),
+
+		asBool(finalizerBlockDeletionKey, &cfg.Finalizer.BlockDeletion),
+		cm.AsDuration(finalizerTimeoutKey, &cfg.Finalizer.Timeout),
+
+		asBool(dryRunEnabledKey, &cfg.DryRun.Enabled),
+		asStringSet(dryRunNamespacesKey, &cfg.DryRun.Namespaces, nil),
+		asString(dryRunDirectoryKey, &cfg.DryRun.Directory),
+
+		asString(auditSinkKey, &cfg.Audit.Sink, "stdout", "file"),
+		asString(auditFilePathKey, &cfg.Audit.FilePath),
+		asBool(auditKeyUsageKey, &cfg.Audit.KeyUsage),
+
+		asBool(webhookRejectInvalidKey, &cfg.Webhook.RejectInvalid),
+
+		asStringSet(overridesAllowedKeysKey, &cfg.Overrides.Allowed, sets.New[string](OverrideFormat, OverrideStorage, OverrideTransparency)),
+
+		cm.AsInt(taskrunWorkersKey, &cfg.Concurrency.TaskRunWorkers),
+		cm.AsInt(pipelinerunWorkersKey, &cfg.Concurrency.PipelineRunWorkers),
+		cm.AsFloat64(signingRateKey, &cfg.Concurrency.SigningRate),
+		cm.AsInt(signingBurstKey, &cfg.Concurrency.SigningBurst),
+		asBackendLimits(&cfg.Concurrency.BackendLimits),
+		asTimeouts("storage.", limitedBackends, &cfg.Concurrency.BackendTimeouts),
+		asTimeouts("signers.", timedSigners, &cfg.Concurrency.SignerTimeouts),
+		cm.AsInt(namespaceLimitKey, &cfg.Concurrency.NamespaceLimit),
+		asNamespaceLimits(&cfg.Concurrency.NamespaceLimits),
+		cm.AsInt(uploadParallelismKey, &cfg.Concurrency.UploadParallelism),
+
+		asBool(signingLeaseEnabledKey, &cfg.Lease.Enabled),
+		cm.AsDuration(signingLeaseDurationKey, &cfg.Lease.Duration),
+
+		cm.AsDuration(drainTimeoutKey, &cfg.Shutdown.DrainTimeout),
+
+		asBool(signingStatusEnabledKey, &cfg.SigningStatus.Enabled),
+
+		asString(eventsSinkKey, &cfg.Events.Sink),
+
+		asString(policyOPAURLKey, &cfg.Policy.URL),
+		asString(policyOPAPathKey, &cfg.Policy.Path),
+
+		asString(queryAddressKey, &cfg.Query.Address),
+		asString(queryCertPathKey, &cfg.Query.CertPath),
+		asString(queryKeyPathKey, &cfg.Query.KeyPath),
+		cm.AsInt(queryMaxResultsKey, &cfg.Query.MaxResults),
+		cm.AsInt(queryMaxRunsKey, &cfg.Query.MaxRuns),
+
+		asString(grpcAddressKey, &cfg.GRPC.Address),
+		asString(grpcCertPathKey, &cfg.GRPC.CertPath),
+		asString(grpcKeyPathKey, &cfg.GRPC.KeyPath),
+		asString(grpcClientCAPathKey, &cfg.GRPC.ClientCAPath),
+
+		asBool(publicKeysEnabledKey, &cfg.PublicKeys.Enabled),
+
+		asBool(conformanceEnabledKey, &cfg.Conformance.Enabled),
+		cm.AsDuration(conformanceIntervalKey, &cfg.Conformance.Interval),
+		cm.AsDuration(conformanceTimeoutKey, &cfg.Conformance.Timeout),
+		asString(conformanceNamespaceKey, &cfg.Conformance.Namespace),
+		asString(conformanceImageKey, &cfg.Conformance.Image),
+		asBool(healthEnabledKey, &cfg.Health.Enabled),
+		cm.AsDuration(healthIntervalKey, &cfg.Health.Interval),
+		cm.AsDuration(healthTimeoutKey, &cfg.Health.Timeout),
+		asString(healthAddressKey, &cfg.Health.Address),
+		asBool(metricsNamespaceLabelKey, &cfg.Metrics.NamespaceLabel),
+		asBool(metricsPipelineLabelKey, &cfg.Metrics.PipelineLabel),
+		asStringSet(metricsNamespacesKey, &cfg.Metrics.Namespaces, nil),
+		cm.AsInt(metricsMaxLabelValuesKey, &cfg.Metrics.MaxLabelValues),
+
+		cm.AsInt(provenanceMaxValueKBKey, &cfg.Provenance.MaxValueKB),
+		asString(provenanceOversizedValuesKey, &cfg.Provenance.OversizedValues, OversizedDigest, OversizedSkip),
+		cm.AsInt(provenanceMaxAttestationKBKey, &cfg.Provenance.MaxAttestationKB),
+		asBool(provenanceChainInputsKey, &cfg.Provenance.ChainInputAttestations),
+		asBool(provenanceRecordEnvironmentKey, &cfg.Provenance.RecordEnvironment),
+		asString(provenanceConfigSnapshotKey, &cfg.Provenance.ConfigSnapshot, ConfigSnapshotDigest, ConfigSnapshotContent),
+		asBool(provenanceValidatePayloadsKey, &cfg.Provenance.ValidatePayloads),
+		asStringSet(provenanceDigestAlgorithmsKey, &cfg.Provenance.DigestAlgorithms, sets.New[string]("sha1", "sha256", "sha384", "sha512")),
+		cm.AsInt(provenanceMaxInlineContentKey, &cfg.Provenance.MaxInlineContentBytes),
+		asBool(provenanceCompletenessKey, &cfg.Provenance.ComputeCompleteness),
+		asString(provenanceStepEnvKey, &cfg.Provenance.StepEnv, StepEnvInclude, StepEnvExclude),
+		asStringSet(provenanceStepEnvAllowKey, &cfg.Provenance.StepEnvAllow, nil),
+		asStringSet(provenanceStepEnvDenyKey, &cfg.Provenance.StepEnvDeny, nil),
+
+		// Isolation
+		asStringSet(isolationSandboxRuntimeClassesKey, &cfg.Isolation.SandboxRuntimeClasses, sets.New[string]()),
+		asStringSet(isolationNetworkLabelsKey, &cfg.Isolation.NetworkLabels, sets.New[string]()),
+
+		// Materials
+		asStringSet(materialsAllowedPrefixesKey, &cfg.Materials.AllowedPrefixes, sets.New[string]()),
+		asString(materialsEnforcementKey, &cfg.Materials.Enforcement, MaterialsAnnotate, MaterialsBlock, MaterialsProvenance),
+
+		// Clusters
+		asClusterBuilderIDs(&cfg.Clusters.BuilderIDs),
+
+		asSigstoreStacks(&cfg.Sigstore.Stacks),
+
+		asString(profilingAddressKey, &cfg.Profiling.Address),
+		cm.AsDuration(profilingHeapIntervalKey, &cfg.Profiling.HeapInterval),
+		asString(profilingHeapDirKey, &cfg.Profiling.HeapDir),
+		cm.AsInt(profilingHeapKeepKey, &cfg.Profiling.HeapKeep),
+
+		asString(tracingOTLPEndpointKey, &cfg.Tracing.Endpoint),
+		asBool(tracingOTLPInsecureKey, &cfg.Tracing.Insecure),
 
 		asString(kmsSignerKMSRef, &cfg.Signers.KMS.KMSRef),
 		asString(kmsAuthAddress, &cfg.Signers.KMS.Auth.Address),
@@ -304,13 +1444,39 @@ func NewConfigFromMap(data map[string]string) (*Config, error) {
 		asString(x509SignerFulcioOIDCIssuer, &cfg.Signers.X509.FulcioOIDCIssuer),
 		asString(x509SignerFulcioProvider, &cfg.Signers.X509.FulcioProvider),
 		asString(x509SignerIdentityTokenFile, &cfg.Signers.X509.IdentityTokenFile),
+		asString(x509SignerIdentityTokenCmd, &cfg.Signers.X509.IdentityTokenCommand),
+		asString(x509SignerIdentityTokenAud, &cfg.Signers.X509.IdentityTokenAudience),
 		asString(x509SignerTUFMirrorURL, &cfg.Signers.X509.TUFMirrorURL),
 
+		// Signing secret
+		asString(signingSecretNamespaceKey, &cfg.Signers.Secret.Namespace),
+		asString(signingSecretNameKey, &cfg.Signers.Secret.Name),
+		asStringSet(signingSecretAllowedNamespacesKey, &cfg.Signers.Secret.AllowedNamespaces, nil),
+		asExternalSecretRefs(&cfg.Signers.External.Refs),
+		cm.AsDuration(externalSecretsRefreshIntervalKey, &cfg.Signers.External.RefreshInterval),
+
 		// Build config
 		asString(builderIDKey, &cfg.Builder.ID),
+		asString(builderClusterKey, &cfg.Builder.Cluster),
+		asString(builderBuildTypeKey, &cfg.Builder.BuildType),
 	); err != nil {
 		return nil, fmt.Errorf("failed to parse data: %w", err)
 	}
+	if cfg.Signers.Secret.Namespace != "" && cfg.Signers.Secret.Name == "" {
+		return nil, fmt.Errorf("%s is set without %s", signingSecretNamespaceKey, signingSecretNameKey)
+	}
+	if cfg.Storage.PubSub.SchemaRegistry.URL != "" && cfg.Storage.PubSub.BatchSize > 1 {
+		return nil, fmt.Errorf("%s can't be set with %s, batches aren't encoded with the schemas of the registry", pubsubSchemaRegistryURLKey, pubsubBatchSizeKey)
+	}
+	if cfg.Query.Address != "" && (cfg.Query.CertPath == "" || cfg.Query.KeyPath == "") {
+		return nil, fmt.Errorf("%s is set without %s and %s, the API is only served with TLS", queryAddressKey, queryCertPathKey, queryKeyPathKey)
+	}
+	if cfg.GRPC.Address != "" && (cfg.GRPC.CertPath == "" || cfg.GRPC.KeyPath == "") {
+		return nil, fmt.Errorf("%s is set without %s and %s, the API is only served with TLS", grpcAddressKey, grpcCertPathKey, grpcKeyPathKey)
+	}
+	if cfg.Storage.PubSub.Provider == "mqtt" && cfg.Storage.PubSub.BatchSize > 1 {
+		return nil, fmt.Errorf("%s can't be set with the mqtt provider, payloads are published to the topics of their runs", pubsubBatchSizeKey)
+	}
 
 	return cfg, nil
 }
@@ -375,6 +1541,181 @@ func asString(key string, target *string, values ...string) cm.ParseFunc {
 	}
 }
 
+// asLabelSelector validates the label selector at key and passes it through into the target, if it exists.
+func asLabelSelector(key string, target *string) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok {
+			return nil
+		}
+		if _, err := labels.Parse(raw); err != nil {
+			return fmt.Errorf("invalid label selector %q: %w", raw, err)
+		}
+		*target = raw
+		return nil
+	}
+}
+
+// asBackendLimits parses the storage.<backend>.max-concurrency keys into target, keyed by backend.
+func asBackendLimits(target *map[string]int) cm.ParseFunc {
+	return func(data map[string]string) error {
+		for _, backend := range sets.List(limitedBackends) {
+			key := "storage." + backend + backendMaxConcurrencySuffix
+			raw, ok := data[key]
+			if !ok {
+				continue
+			}
+			limit, err := strconv.Atoi(raw)
+			if err != nil || limit < 0 {
+				return fmt.Errorf("invalid value %q for %s: must be a non-negative integer", raw, key)
+			}
+			if *target == nil {
+				*target = map[string]int{}
+			}
+			(*target)[backend] = limit
+		}
+		return nil
+	}
+}
+
+// asNamespaceLimits parses the signing.namespace.<namespace>.max-in-flight keys into
+// target, keyed by namespace.
+func asNamespaceLimits(target *map[string]int) cm.ParseFunc {
+	return func(data map[string]string) error {
+		for key, raw := range data {
+			namespace := strings.TrimSuffix(strings.TrimPrefix(key, namespaceLimitPrefix), namespaceLimitSuffix)
+			if namespace == "" || key != namespaceLimitPrefix+namespace+namespaceLimitSuffix {
+				continue
+			}
+			limit, err := strconv.Atoi(raw)
+			if err != nil || limit < 0 {
+				return fmt.Errorf("invalid value %q for %s: must be a non-negative integer", raw, key)
+			}
+			if *target == nil {
+				*target = map[string]int{}
+			}
+			(*target)[namespace] = limit
+		}
+		return nil
+	}
+}
+
+// asTimeouts parses the <prefix><name>.timeout keys of names into target, keyed by name.
+func asTimeouts(prefix string, names sets.Set[string], target *map[string]time.Duration) cm.ParseFunc {
+	return func(data map[string]string) error {
+		for _, name := range sets.List(names) {
+			key := prefix + name + timeoutSuffix
+			raw, ok := data[key]
+			if !ok {
+				continue
+			}
+			timeout, err := time.ParseDuration(raw)
+			if err != nil || timeout < 0 {
+				return fmt.Errorf("invalid value %q for %s: must be a non-negative duration", raw, key)
+			}
+			if *target == nil {
+				*target = map[string]time.Duration{}
+			}
+			(*target)[name] = timeout
+		}
+		return nil
+	}
+}
+
+// asExternalSecretRefs parses the signers.external.<file> keys into target, by file of
+// the signing secrets.
+func asExternalSecretRefs(target *map[string]string) cm.ParseFunc {
+	return func(data map[string]string) error {
+		for key, ref := range data {
+			if !strings.HasPrefix(key, externalSecretsPrefix) || key == externalSecretsRefreshIntervalKey {
+				continue
+			}
+			file := strings.TrimPrefix(key, externalSecretsPrefix)
+			if !signingSecretFiles.Has(file) {
+				return fmt.Errorf("invalid key %q: %s isn't one of %v", key, file, sets.List(signingSecretFiles))
+			}
+			scheme, _, ok := strings.Cut(ref, "://")
+			if !ok || !externalSecretSchemes.Has(scheme) {
+				return fmt.Errorf("invalid reference %q of %s: the scheme must be one of %v", ref, key, sets.List(externalSecretSchemes))
+			}
+			if *target == nil {
+				*target = map[string]string{}
+			}
+			(*target)[file] = ref
+		}
+		return nil
+	}
+}
+
+// asSigstoreStacks parses the sigstore.<stack>.<field> keys into target, keyed by stack.
+func asSigstoreStacks(target *map[string]SigstoreStack) cm.ParseFunc {
+	return func(data map[string]string) error {
+		owners := map[string]string{}
+		for key, value := range data {
+			if !strings.HasPrefix(key, sigstorePrefix) {
+				continue
+			}
+			rest := strings.TrimPrefix(key, sigstorePrefix)
+			i := strings.LastIndex(rest, ".")
+			if i <= 0 || strings.Contains(rest[:i], ".") {
+				return fmt.Errorf("invalid key %q: must be %s<stack>.<field>", key, sigstorePrefix)
+			}
+			name, suffix := rest[:i], rest[i:]
+			if *target == nil {
+				*target = map[string]SigstoreStack{}
+			}
+			stack := (*target)[name]
+			switch suffix {
+			case sigstoreNamespacesSuffix:
+				stack.Namespaces = sets.New[string]()
+				for _, ns := range strings.Split(value, ",") {
+					ns = strings.TrimSpace(ns)
+					if ns == "" {
+						continue
+					}
+					if owner, ok := owners[ns]; ok {
+						return fmt.Errorf("namespace %s belongs to the Sigstore stacks %s and %s", ns, owner, name)
+					}
+					owners[ns] = name
+					stack.Namespaces.Insert(ns)
+				}
+			case sigstoreTUFMirrorURLSuffix:
+				stack.TUFMirrorURL = value
+			case sigstoreFulcioAddrSuffix:
+				stack.FulcioAddr = value
+			case sigstoreFulcioIssuerSuffix:
+				stack.FulcioOIDCIssuer = value
+			case sigstoreRekorURLSuffix:
+				stack.RekorURL = value
+			default:
+				return fmt.Errorf("invalid key %q: unknown field %s", key, strings.TrimPrefix(suffix, "."))
+			}
+			(*target)[name] = stack
+		}
+		return nil
+	}
+}
+
+// asClusterBuilderIDs parses the clusters.<cluster>.builder-id keys into target, keyed by cluster.
+func asClusterBuilderIDs(target *map[string]string) cm.ParseFunc {
+	return func(data map[string]string) error {
+		for key, id := range data {
+			if !strings.HasPrefix(key, clustersPrefix) || !strings.HasSuffix(key, clusterBuilderIDSuffix) {
+				continue
+			}
+			cluster := strings.TrimSuffix(strings.TrimPrefix(key, clustersPrefix), clusterBuilderIDSuffix)
+			if cluster == "" || strings.Contains(cluster, ".") {
+				return fmt.Errorf("invalid key %q: must be %s<cluster>%s", key, clustersPrefix, clusterBuilderIDSuffix)
+			}
+			if *target == nil {
+				*target = map[string]string{}
+			}
+			(*target)[cluster] = id
+		}
+		return nil
+	}
+}
+
 // asStringSet parses the value at key as a sets.Set[string] (split by ',') into the target, if it exists.
 func asStringSet(key string, target *sets.Set[string], allowed sets.Set[string]) cm.ParseFunc {
 	return func(data map[string]string) error {
@@ -384,12 +1725,10 @@ func asStringSet(key string, target *sets.Set[string], allowed sets.Set[string])
 				return nil
 			}
 			splitted := strings.Split(raw, ",")
-			if allowed.Len() > 0 {
-				for i, v := range splitted {
-					splitted[i] = strings.TrimSpace(v)
-					if !allowed.Has(splitted[i]) {
-						return fmt.Errorf("invalid value %q wanted one of %v", splitted[i], sets.List[string](allowed))
-					}
+			for i, v := range splitted {
+				splitted[i] = strings.TrimSpace(v)
+				if allowed.Len() > 0 && !allowed.Has(splitted[i]) {
+					return fmt.Errorf("invalid value %q wanted one of %v", splitted[i], sets.List[string](allowed))
 				}
 			}
 			*target = sets.New[string](splitted...)
@@ -47,4 +47,9 @@ type StorageOpts struct {
 
 	// PayloadFormat is the format to store payload in.
 	PayloadFormat PayloadType
+
+	// BuildGroup is the build group of the run, from its chains.tekton.dev/build-group
+	// annotation. Storage backends that index payloads index them by it too, so that
+	// the payloads of the recurring runs of a component can be looked up together.
+	BuildGroup string
 }
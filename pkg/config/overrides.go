@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Keys that runs may override with annotations, if allowed by overrides.allowed-keys.
+const (
+	OverrideFormat       = "format"
+	OverrideStorage      = "storage"
+	OverrideTransparency = "transparency.enabled"
+)
+
+// ApplyOverrides overrides the configuration of the artifact of the given kind, "taskrun"
+// or "pipelinerun", with the values in overrides, keyed by the keys above. It returns an
+// error without changing cfg if a key is not allowed or a value is not supported.
+func (cfg *Config) ApplyOverrides(kind string, overrides map[string]string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	var artifact *Artifact
+	var formats []string
+	var backends sets.Set[string]
+	switch kind {
+	case "taskrun":
+		artifact, formats, backends = &cfg.Artifacts.TaskRuns, taskrunFormats, taskrunStorageBackends
+	case "pipelinerun":
+		artifact, formats, backends = &cfg.Artifacts.PipelineRuns, pipelinerunFormats, pipelinerunStorageBackends
+	case "customrun":
+		artifact, formats, backends = &cfg.Artifacts.CustomRuns, customrunFormats, customrunStorageBackends
+	default:
+		return fmt.Errorf("overrides are not supported for %s", kind)
+	}
+
+	format := artifact.Format
+	storage := artifact.StorageBackend
+	transparency := cfg.Transparency
+	for _, key := range sets.List(sets.KeySet(overrides)) {
+		if !cfg.Overrides.Allowed.Has(key) {
+			return fmt.Errorf("overriding %s is not allowed", key)
+		}
+		value := overrides[key]
+		switch key {
+		case OverrideFormat:
+			if !sets.New(formats...).Has(value) {
+				return fmt.Errorf("invalid %s override %q wanted one of %v", key, value, formats)
+			}
+			format = value
+		case OverrideStorage:
+			storage = sets.New[string]()
+			for _, b := range strings.Split(value, ",") {
+				b = strings.TrimSpace(b)
+				if !backends.Has(b) {
+					return fmt.Errorf("invalid %s override %q wanted one of %v", key, b, sets.List(backends))
+				}
+				storage.Insert(b)
+			}
+		case OverrideTransparency:
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s override %q: %w", key, value, err)
+			}
+			transparency.Enabled, transparency.VerifyAnnotation = enabled, false
+		}
+	}
+
+	artifact.Format = format
+	artifact.StorageBackend = storage
+	cfg.Transparency = transparency
+	return nil
+}
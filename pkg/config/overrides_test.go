@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	allowAll := sets.New[string](OverrideFormat, OverrideStorage, OverrideTransparency)
+	tests := []struct {
+		name      string
+		kind      string
+		allowed   sets.Set[string]
+		overrides map[string]string
+		wantErr   bool
+		check     func(t *testing.T, cfg *Config)
+	}{
+		{
+			name:      "taskrun format and storage",
+			kind:      "taskrun",
+			allowed:   allowAll,
+			overrides: map[string]string{OverrideFormat: "slsa/v1", OverrideStorage: "oci, tekton"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Artifacts.TaskRuns.Format != "slsa/v1" {
+					t.Errorf("taskrun format = %q", cfg.Artifacts.TaskRuns.Format)
+				}
+				if !cfg.Artifacts.TaskRuns.StorageBackend.Equal(sets.New[string]("oci", "tekton")) {
+					t.Errorf("taskrun storage = %v", cfg.Artifacts.TaskRuns.StorageBackend)
+				}
+				if cfg.Artifacts.PipelineRuns.Format != "in-toto" {
+					t.Errorf("pipelinerun format = %q, want it unchanged", cfg.Artifacts.PipelineRuns.Format)
+				}
+			},
+		},
+		{
+			name:      "pipelinerun transparency",
+			kind:      "pipelinerun",
+			allowed:   sets.New[string](OverrideTransparency),
+			overrides: map[string]string{OverrideTransparency: "true"},
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Transparency.Enabled {
+					t.Error("expected transparency to be enabled")
+				}
+			},
+		},
+		{
+			name:      "customrun storage",
+			kind:      "customrun",
+			allowed:   allowAll,
+			overrides: map[string]string{OverrideStorage: "oci"},
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Artifacts.CustomRuns.StorageBackend.Equal(sets.New[string]("oci")) {
+					t.Errorf("customrun storage = %v", cfg.Artifacts.CustomRuns.StorageBackend)
+				}
+			},
+		},
+		{
+			name:      "not allowed",
+			kind:      "taskrun",
+			allowed:   sets.New[string](OverrideTransparency),
+			overrides: map[string]string{OverrideTransparency: "true", OverrideFormat: "slsa/v1"},
+			wantErr:   true,
+		},
+		{
+			name:      "nothing allowed by default",
+			kind:      "taskrun",
+			overrides: map[string]string{OverrideFormat: "slsa/v1"},
+			wantErr:   true,
+		},
+		{
+			name:      "format not supported for pipelineruns",
+			kind:      "pipelinerun",
+			allowed:   allowAll,
+			overrides: map[string]string{OverrideFormat: "slsa/v2alpha1"},
+			wantErr:   true,
+		},
+		{
+			name:      "unsupported storage backend",
+			kind:      "pipelinerun",
+			allowed:   allowAll,
+			overrides: map[string]string{OverrideStorage: "gcs"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid transparency value",
+			kind:      "taskrun",
+			allowed:   allowAll,
+			overrides: map[string]string{OverrideTransparency: "sometimes"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Overrides.Allowed = tt.allowed
+			want := cfg.DeepCopy()
+
+			err := cfg.ApplyOverrides(tt.kind, tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyOverrides() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if cfg.Artifacts.TaskRuns.Format != want.Artifacts.TaskRuns.Format || cfg.Transparency != want.Transparency {
+					t.Error("expected config to be unchanged on error")
+				}
+				return
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
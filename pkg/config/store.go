@@ -19,6 +19,7 @@ package config
 import (
 	"context"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/reconciler"
 )
@@ -38,6 +39,14 @@ func FromContext(ctx context.Context) *Config {
 	return ctx.Value(cfgKey{}).(*Config)
 }
 
+// FromContextOrDefaults fetches config from context, falling back to the defaults if none is set.
+func FromContextOrDefaults(ctx context.Context) *Config {
+	if cfg, ok := ctx.Value(cfgKey{}).(*Config); ok && cfg != nil {
+		return cfg
+	}
+	return defaultConfig()
+}
+
 // ToContext adds config to given context.
 func ToContext(ctx context.Context, c *Config) context.Context {
 	return context.WithValue(ctx, cfgKey{}, c)
@@ -66,3 +75,24 @@ func NewConfigStore(logger configmap.Logger, onAfterStore ...func(name string, v
 		),
 	}
 }
+
+// Filter returns a filter for informer events that only admits objects the current
+// configuration selects for signing. Objects that are being deleted are always admitted
+// so that any finalizer added before the object was deselected is removed.
+func (s *ConfigStore) Filter() func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		mo, ok := obj.(metav1.Object)
+		if !ok {
+			return true
+		}
+		if mo.GetDeletionTimestamp() != nil {
+			return true
+		}
+		cfg, ok := s.UntypedLoad(ChainsConfig).(*Config)
+		if !ok {
+			// The configuration has not been loaded yet.
+			return true
+		}
+		return cfg.Selects(mo)
+	}
+}
@@ -125,6 +125,12 @@ var defaultTransparency = TransparencyConfig{
 	URL: "https://rekor.sigstore.dev",
 }
 
+var defaultRetry = RetryConfig{
+	MaxRetries:        3,
+	BackoffMultiplier: 2,
+	MaxBackoff:        5 * time.Minute,
+}
+
 func TestParse(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -134,8 +140,1096 @@ func TestParse(t *testing.T) {
 		want           Config
 	}{
 		{
-			name:           "empty",
-			data:           map[string]string{},
+			name:           "empty",
+			data:           map[string]string{},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		}, {
+			name: "builder configuration",
+			data: map[string]string{
+				builderIDKey:        "builder-id-test",
+				builderClusterKey:   "prod-east",
+				builderBuildTypeKey: "https://example.com/tekton/build/v1",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					ID:        "builder-id-test",
+					Cluster:   "prod-east",
+					BuildType: "https://example.com/tekton/build/v1",
+				},
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		}, {
+			name: "storage configuration",
+			data: map[string]string{
+				grafeasNoteHint: "a test message",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: GrafeasConfig{
+						NoteHint: "a test message",
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "oci tags",
+			data: map[string]string{
+				ociTagsKey:              "$(pipeline.name)-$(params.version), $(run.uid)",
+				ociAttachToPlatformsKey: "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					OCI: OCIStorageConfig{
+						Tags:              sets.New[string]("$(pipeline.name)-$(params.version)", "$(run.uid)"),
+						AttachToPlatforms: true,
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "tekton max annotation size",
+			data: map[string]string{
+				tektonMaxAnnotationKey: "65536",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					Tekton: TektonStorageConfig{
+						MaxAnnotationSize: 65536,
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "ipfs storage configuration",
+			data: map[string]string{
+				taskrunStorageKey: "ipfs",
+				ipfsURLKey:        "http://ipfs:5001",
+				ipfsTokenPathKey:  "/etc/ipfs/token",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "in-toto",
+						StorageBackend: sets.New[string]("ipfs"),
+						Signer:         "x509",
+					},
+					PipelineRuns: defaultArtifacts.PipelineRuns,
+					OCI:          defaultArtifacts.OCI,
+				},
+				Signers: defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					IPFS: IPFSStorageConfig{
+						URL:       "http://ipfs:5001",
+						TokenPath: "/etc/ipfs/token",
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "vex configuration",
+			data: map[string]string{
+				vexStorageKey: "oci, tekton",
+				vexSignerKey:  "kms",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns:     defaultArtifacts.TaskRuns,
+					PipelineRuns: defaultArtifacts.PipelineRuns,
+					OCI:          defaultArtifacts.OCI,
+					VEX: Artifact{
+						StorageBackend: sets.New[string]("oci", "tekton"),
+						Signer:         "kms",
+					},
+				},
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "tekton bundle configuration",
+			data: map[string]string{
+				tektonBundleStorageKey: "oci",
+				tektonBundleSignerKey:  "x509",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns:     defaultArtifacts.TaskRuns,
+					PipelineRuns: defaultArtifacts.PipelineRuns,
+					OCI:          defaultArtifacts.OCI,
+					TektonBundles: Artifact{
+						StorageBackend: sets.New[string]("oci"),
+						Signer:         "x509",
+					},
+				},
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "source configuration",
+			data: map[string]string{
+				sourceStorageKey:   "gcs, tekton",
+				sourceFetchRunsKey: "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns:     defaultArtifacts.TaskRuns,
+					PipelineRuns: defaultArtifacts.PipelineRuns,
+					OCI:          defaultArtifacts.OCI,
+					Source: Artifact{
+						StorageBackend: sets.New[string]("gcs", "tekton"),
+						FetchRuns:      true,
+					},
+				},
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "customrun configuration",
+			data: map[string]string{
+				customrunFormatKey:  "slsa/v1",
+				customrunStorageKey: "tekton, oci",
+				customrunSignerKey:  "kms",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns:     defaultArtifacts.TaskRuns,
+					PipelineRuns: defaultArtifacts.PipelineRuns,
+					OCI:          defaultArtifacts.OCI,
+					CustomRuns: Artifact{
+						Format:         "slsa/v1",
+						StorageBackend: sets.New[string]("oci", "tekton"),
+						Signer:         "kms",
+					},
+				},
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "github storage configuration",
+			data: map[string]string{
+				taskrunStorageKey:       "github",
+				githubRepositoryKey:     "acme/widgets",
+				githubAppIDKey:          "7",
+				githubInstallationIDKey: "42",
+				githubPrivateKeyPathKey: "/etc/github/key.pem",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "in-toto",
+						StorageBackend: sets.New[string]("github"),
+						Signer:         "x509",
+					},
+					PipelineRuns: defaultArtifacts.PipelineRuns,
+					OCI:          defaultArtifacts.OCI,
+				},
+				Signers: defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					GitHub: GitHubStorageConfig{
+						Repository:     "acme/widgets",
+						AppID:          7,
+						InstallationID: 42,
+						PrivateKeyPath: "/etc/github/key.pem",
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "gitlab storage configuration",
+			data: map[string]string{
+				taskrunStorageKey:  "gitlab",
+				ociStorageKey:      "gitlab",
+				gitlabURLKey:       "https://gitlab.example.com",
+				gitlabProjectKey:   "acme/widgets",
+				gitlabTokenPathKey: "/etc/gitlab/token",
+				gitlabPackageKey:   "provenance",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "in-toto",
+						StorageBackend: sets.New[string]("gitlab"),
+						Signer:         "x509",
+					},
+					PipelineRuns: defaultArtifacts.PipelineRuns,
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.New[string]("gitlab"),
+						Signer:         "x509",
+					},
+				},
+				Signers: defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					GitLab: GitLabStorageConfig{
+						URL:       "https://gitlab.example.com",
+						Project:   "acme/widgets",
+						TokenPath: "/etc/gitlab/token",
+						Package:   "provenance",
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name:           "pipelinerun bundle storage",
+			data:           map[string]string{pipelinerunBundleStorageKey: "tekton, ipfs"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: defaultArtifacts.TaskRuns,
+					PipelineRuns: Artifact{
+						Format:               "in-toto",
+						StorageBackend:       sets.New[string]("tekton"),
+						Signer:               "x509",
+						BundleStorageBackend: sets.New[string]("tekton", "ipfs"),
+					},
+					OCI: defaultArtifacts.OCI,
+				},
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name:           "pipelinerun result digests",
+			data:           map[string]string{pipelinerunResultDigestsKey: "true"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: defaultBuilder,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: defaultArtifacts.TaskRuns,
+					PipelineRuns: Artifact{
+						Format:         "in-toto",
+						StorageBackend: sets.New[string]("tekton"),
+						Signer:         "x509",
+						ResultDigests:  true,
+					},
+					OCI: defaultArtifacts.OCI,
+				},
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "tracing",
+			data: map[string]string{
+				tracingOTLPEndpointKey: "otel-collector:4318",
+				tracingOTLPInsecureKey: "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Tracing: TracingConfig{
+					Endpoint: "otel-collector:4318",
+					Insecure: true,
+				},
+			},
+		},
+		{
+			name: "namespace filtering",
+			data: map[string]string{
+				watchedNamespacesKey:  "team-a, team-b",
+				excludedNamespacesKey: "team-b",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Namespaces: NamespaceConfig{
+					Watched:  sets.New[string]("team-a", "team-b"),
+					Excluded: sets.New[string]("team-b"),
+				},
+			},
+		},
+		{
+			name:           "label selector",
+			data:           map[string]string{labelSelectorKey: "chains.tekton.dev/sign=true"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Selector:     SelectorConfig{LabelSelector: "chains.tekton.dev/sign=true"},
+			},
+		},
+		{
+			name: "finalizer",
+			data: map[string]string{
+				finalizerBlockDeletionKey: "true",
+				finalizerTimeoutKey:       "30m",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Finalizer:    FinalizerConfig{BlockDeletion: true, Timeout: 30 * time.Minute},
+			},
+		},
+		{
+			name: "dry run",
+			data: map[string]string{
+				dryRunNamespacesKey: "team-a, team-b",
+				dryRunDirectoryKey:  "/var/run/chains/dryrun",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				DryRun: DryRunConfig{
+					Namespaces: sets.New[string]("team-a", "team-b"),
+					Directory:  "/var/run/chains/dryrun",
+				},
+			},
+		},
+		{
+			name: "audit log",
+			data: map[string]string{
+				auditSinkKey:     "file",
+				auditFilePathKey: "/var/log/chains/audit.log",
+				auditKeyUsageKey: "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Audit:        AuditConfig{Sink: "file", FilePath: "/var/log/chains/audit.log", KeyUsage: true},
+			},
+		},
+		{
+			name:           "webhook",
+			data:           map[string]string{webhookRejectInvalidKey: "true"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Webhook:      WebhookConfig{RejectInvalid: true},
+			},
+		},
+		{
+			name:           "overrides",
+			data:           map[string]string{overridesAllowedKeysKey: "format, transparency.enabled"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Overrides:    OverridesConfig{Allowed: sets.New[string]("format", "transparency.enabled")},
+			},
+		},
+		{
+			name: "concurrency",
+			data: map[string]string{
+				taskrunWorkersKey:                 "8",
+				pipelinerunWorkersKey:             "4",
+				signingRateKey:                    "2.5",
+				signingBurstKey:                   "5",
+				"storage.oci.max-concurrency":     "3",
+				"storage.tekton.max-concurrency":  "0",
+				"storage.unknown.max-concurrency": "1",
+				uploadParallelismKey:              "8",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Concurrency: ConcurrencyConfig{
+					TaskRunWorkers:     8,
+					PipelineRunWorkers: 4,
+					SigningRate:        2.5,
+					SigningBurst:       5,
+					BackendLimits:      map[string]int{"oci": 3, "tekton": 0},
+					UploadParallelism:  8,
+				},
+			},
+		},
+		{
+			name: "timeouts",
+			data: map[string]string{
+				"storage.gcs.timeout":     "30s",
+				"storage.oci.timeout":     "0s",
+				"storage.unknown.timeout": "1s",
+				"signers.kms.timeout":     "5s",
+				transparencyTimeoutKey:    "5s",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage:   defaultStorage,
+				Transparency: TransparencyConfig{
+					URL:     "https://rekor.sigstore.dev",
+					Timeout: 5 * time.Second,
+				},
+				Retry: defaultRetry,
+				Concurrency: ConcurrencyConfig{
+					BackendTimeouts: map[string]time.Duration{"gcs": 30 * time.Second, "oci": 0},
+					SignerTimeouts:  map[string]time.Duration{"kms": 5 * time.Second},
+				},
+			},
+		},
+		{
+			name: "namespace limits",
+			data: map[string]string{
+				namespaceLimitKey:                            "2",
+				"signing.namespace.release.max-in-flight":    "8",
+				"signing.namespace.playground.max-in-flight": "0",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Concurrency: ConcurrencyConfig{
+					NamespaceLimit:  2,
+					NamespaceLimits: map[string]int{"release": 8, "playground": 0},
+				},
+			},
+		},
+		{
+			name: "signing lease",
+			data: map[string]string{
+				signingLeaseEnabledKey:  "true",
+				signingLeaseDurationKey: "90s",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Lease:        LeaseConfig{Enabled: true, Duration: 90 * time.Second},
+			},
+		},
+		{
+			name:           "drain timeout",
+			data:           map[string]string{drainTimeoutKey: "1m"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Shutdown:     ShutdownConfig{DrainTimeout: time.Minute},
+			},
+		},
+		{
+			name:           "signing status",
+			data:           map[string]string{signingStatusEnabledKey: "true"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:       defaultBuilder,
+				Artifacts:     defaultArtifacts,
+				Signers:       defaultSigners,
+				Storage:       defaultStorage,
+				Transparency:  defaultTransparency,
+				Retry:         defaultRetry,
+				SigningStatus: SigningStatusConfig{Enabled: true},
+			},
+		},
+		{
+			name:           "events",
+			data:           map[string]string{eventsSinkKey: "http://sink.default.svc"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Events:       EventsConfig{Sink: "http://sink.default.svc"},
+			},
+		},
+		{
+			name:           "query",
+			data:           map[string]string{queryAddressKey: ":8081", queryCertPathKey: "/etc/chains-query/tls.crt", queryKeyPathKey: "/etc/chains-query/tls.key", queryMaxResultsKey: "50", queryMaxRunsKey: "200"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Query:        QueryConfig{Address: ":8081", CertPath: "/etc/chains-query/tls.crt", KeyPath: "/etc/chains-query/tls.key", MaxResults: 50, MaxRuns: 200},
+			},
+		},
+		{
+			name:           "grpc",
+			data:           map[string]string{grpcAddressKey: ":9090", grpcCertPathKey: "/etc/chains-grpc/tls.crt", grpcKeyPathKey: "/etc/chains-grpc/tls.key", grpcClientCAPathKey: "/etc/chains-grpc/ca.crt"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				GRPC:         GRPCConfig{Address: ":9090", CertPath: "/etc/chains-grpc/tls.crt", KeyPath: "/etc/chains-grpc/tls.key", ClientCAPath: "/etc/chains-grpc/ca.crt"},
+			},
+		},
+		{
+			name:           "public keys",
+			data:           map[string]string{publicKeysEnabledKey: "true"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				PublicKeys:   PublicKeysConfig{Enabled: true},
+			},
+		},
+		{
+			name: "conformance probe",
+			data: map[string]string{
+				conformanceEnabledKey:   "true",
+				conformanceIntervalKey:  "30m",
+				conformanceTimeoutKey:   "5m",
+				conformanceNamespaceKey: "chains-conformance",
+				conformanceImageKey:     "registry.example.com/busybox",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Conformance: ConformanceConfig{
+					Enabled:   true,
+					Interval:  30 * time.Minute,
+					Timeout:   5 * time.Minute,
+					Namespace: "chains-conformance",
+					Image:     "registry.example.com/busybox",
+				},
+			},
+		},
+		{
+			name: "health checks",
+			data: map[string]string{
+				healthEnabledKey:  "true",
+				healthIntervalKey: "1m",
+				healthTimeoutKey:  "10s",
+				healthAddressKey:  ":8082",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Health: HealthConfig{
+					Enabled:  true,
+					Interval: time.Minute,
+					Timeout:  10 * time.Second,
+					Address:  ":8082",
+				},
+			},
+		},
+		{
+			name: "metric labels",
+			data: map[string]string{
+				metricsNamespaceLabelKey: "true",
+				metricsPipelineLabelKey:  "true",
+				metricsNamespacesKey:     "team-a, team-b",
+				metricsMaxLabelValuesKey: "50",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Metrics: MetricsConfig{
+					NamespaceLabel: true,
+					PipelineLabel:  true,
+					Namespaces:     sets.New[string]("team-a", "team-b"),
+					MaxLabelValues: 50,
+				},
+			},
+		},
+		{
+			name: "provenance value cap",
+			data: map[string]string{
+				provenanceMaxValueKBKey:      "64",
+				provenanceOversizedValuesKey: "skip",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{MaxValueKB: 64, OversizedValues: OversizedSkip},
+			},
+		},
+		{
+			name: "pubsub batching",
+			data: map[string]string{
+				pubsubBatchSizeKey:   "50",
+				pubsubBatchLingerKey: "250ms",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					PubSub:  PubSubStorageConfig{BatchSize: 50, BatchLinger: 250 * time.Millisecond},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "pubsub schema registry",
+			data: map[string]string{
+				pubsubSchemaRegistryURLKey:           "http://schema-registry.kafka:8081",
+				pubsubSchemaRegistryFormatKey:        "protobuf",
+				pubsubSchemaRegistrySubjectNameKey:   "topic-record-name",
+				pubsubSchemaRegistryBasicAuthPathKey: "/etc/chains/schema-registry/user-info",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					PubSub: PubSubStorageConfig{
+						SchemaRegistry: SchemaRegistryConfig{
+							URL:                 "http://schema-registry.kafka:8081",
+							Format:              "protobuf",
+							SubjectNameStrategy: "topic-record-name",
+							BasicAuthPath:       "/etc/chains/schema-registry/user-info",
+						},
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "pubsub grpc",
+			data: map[string]string{
+				pubsubProvider:           "grpc",
+				pubsubGRPCAddressKey:     "collector.observability:9090",
+				pubsubGRPCCAPathKey:      "/etc/chains/collector-ca.pem",
+				pubsubGRPCMaxInFlightKey: "100",
+				pubsubGRPCTimeoutKey:     "30s",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					PubSub: PubSubStorageConfig{
+						Provider: "grpc",
+						GRPC: GRPCStorageConfig{
+							Address:     "collector.observability:9090",
+							CAPath:      "/etc/chains/collector-ca.pem",
+							MaxInFlight: 100,
+							Timeout:     30 * time.Second,
+						},
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "pubsub sns",
+			data: map[string]string{
+				pubsubProvider: "sns",
+				pubsubTopic:    "arn:aws:sns:us-east-1:123456789012:attestations",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					PubSub: PubSubStorageConfig{
+						Provider: "sns",
+						Topic:    "arn:aws:sns:us-east-1:123456789012:attestations",
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "pubsub eventhubs",
+			data: map[string]string{
+				pubsubProvider:              "eventhubs",
+				pubsubTopic:                 "attestations",
+				pubsubEventHubsNamespaceKey: "chains.servicebus.windows.net",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					PubSub: PubSubStorageConfig{
+						Provider:  "eventhubs",
+						Topic:     "attestations",
+						EventHubs: EventHubsStorageConfig{Namespace: "chains.servicebus.windows.net"},
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "pubsub mqtt",
+			data: map[string]string{
+				pubsubProvider:               "mqtt",
+				pubsubTopic:                  "chains/$(run.namespace)",
+				pubsubMQTTBrokerKey:          "ssl://broker.example.com:8883",
+				pubsubMQTTQoSKey:             "2",
+				pubsubMQTTClientIDKey:        "edge-1",
+				pubsubMQTTCredentialsPathKey: "/etc/chains/mqtt",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage: StorageConfigs{
+					Grafeas: defaultStorage.Grafeas,
+					PubSub: PubSubStorageConfig{
+						Provider: "mqtt",
+						Topic:    "chains/$(run.namespace)",
+						MQTT: MQTTStorageConfig{
+							Broker:          "ssl://broker.example.com:8883",
+							QoS:             "2",
+							ClientID:        "edge-1",
+							CredentialsPath: "/etc/chains/mqtt",
+						},
+					},
+				},
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "attestation size cap",
+			data: map[string]string{
+				provenanceMaxAttestationKBKey: "512",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{MaxAttestationKB: 512},
+			},
+		},
+		{
+			name: "input attestation chaining",
+			data: map[string]string{
+				provenanceChainInputsKey: "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{ChainInputAttestations: true},
+			},
+		},
+		{
+			name: "record environment",
+			data: map[string]string{
+				provenanceRecordEnvironmentKey: "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{RecordEnvironment: true},
+			},
+		},
+		{
+			name: "validate payloads",
+			data: map[string]string{
+				provenanceValidatePayloadsKey: "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{ValidatePayloads: true},
+			},
+		},
+		{
+			name: "digest algorithms",
+			data: map[string]string{
+				provenanceDigestAlgorithmsKey: "sha512, sha384",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{DigestAlgorithms: sets.New[string]("sha384", "sha512")},
+			},
+		},
+		{
+			name: "inline content",
+			data: map[string]string{
+				provenanceMaxInlineContentKey: "4096",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{MaxInlineContentBytes: 4096},
+			},
+		},
+		{
+			name: "computed completeness",
+			data: map[string]string{
+				provenanceCompletenessKey: "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{ComputeCompleteness: true},
+			},
+		},
+		{
+			name: "step env",
+			data: map[string]string{
+				provenanceStepEnvKey:      "exclude",
+				provenanceStepEnvAllowKey: "GOFLAGS, NPM_CONFIG_*",
+				provenanceStepEnvDenyKey:  "AWS_*",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance: ProvenanceConfig{
+					StepEnv:      StepEnvExclude,
+					StepEnvAllow: sets.New[string]("GOFLAGS", "NPM_CONFIG_*"),
+					StepEnvDeny:  sets.New[string]("AWS_*"),
+				},
+			},
+		},
+		{
+			name: "config snapshot",
+			data: map[string]string{
+				provenanceConfigSnapshotKey: "content",
+			},
 			taskrunEnabled: true,
 			ociEnbaled:     true,
 			want: Config{
@@ -144,40 +1238,253 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Provenance:   ProvenanceConfig{ConfigSnapshot: ConfigSnapshotContent},
 			},
-		}, {
-			name: "builder configuration",
+		},
+		{
+			name: "policy",
 			data: map[string]string{
-				builderIDKey: "builder-id-test",
+				policyOPAURLKey:  "http://opa.opa-system:8181",
+				policyOPAPathKey: "org/chains/deny",
 			},
 			taskrunEnabled: true,
 			ociEnbaled:     true,
 			want: Config{
-				Builder: BuilderConfig{
-					"builder-id-test",
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Policy: PolicyConfig{
+					URL:  "http://opa.opa-system:8181",
+					Path: "org/chains/deny",
 				},
+			},
+		},
+		{
+			name: "cluster builder ids",
+			data: map[string]string{
+				"clusters.edge-1.builder-id": "https://chains.example.com/edge-1",
+				"clusters.edge-2.builder-id": "https://chains.example.com/edge-2",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
 				Artifacts:    defaultArtifacts,
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Clusters: ClustersConfig{BuilderIDs: map[string]string{
+					"edge-1": "https://chains.example.com/edge-1",
+					"edge-2": "https://chains.example.com/edge-2",
+				}},
 			},
-		}, {
-			name: "storage configuration",
+		},
+		{
+			name: "identity token command",
 			data: map[string]string{
-				grafeasNoteHint: "a test message",
+				x509SignerIdentityTokenCmd: "/usr/local/bin/get-token --cluster prod",
+				x509SignerIdentityTokenAud: "chains",
 			},
 			taskrunEnabled: true,
 			ociEnbaled:     true,
 			want: Config{
 				Builder:   defaultBuilder,
 				Artifacts: defaultArtifacts,
-				Signers:   defaultSigners,
-				Storage: StorageConfigs{
-					Grafeas: GrafeasConfig{
-						NoteHint: "a test message",
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						FulcioAddr:            "https://fulcio.sigstore.dev",
+						FulcioOIDCIssuer:      "https://oauth2.sigstore.dev/auth",
+						IdentityTokenCommand:  "/usr/local/bin/get-token --cluster prod",
+						IdentityTokenAudience: "chains",
+						TUFMirrorURL:          "https://tuf-repo-cdn.sigstore.dev",
+					},
+				},
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "signing secret",
+			data: map[string]string{
+				"signers.secret.namespace":          "signing-keys",
+				"signers.secret.name":               "chains-signing-secrets",
+				"signers.secret.allowed-namespaces": "signing-keys, platform-keys",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers: SignerConfigs{
+					X509: defaultSigners.X509,
+					Secret: SigningSecretConfig{
+						Namespace:         "signing-keys",
+						Name:              "chains-signing-secrets",
+						AllowedNamespaces: sets.New[string]("signing-keys", "platform-keys"),
+					},
+				},
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "external secrets",
+			data: map[string]string{
+				"signers.external.cosign.key":       "gcpsm://projects/chains/secrets/cosign-key",
+				"signers.external.cosign.password":  "hashivault://secret/data/chains?field=password",
+				"signers.external.refresh-interval": "10m",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers: SignerConfigs{
+					X509: defaultSigners.X509,
+					External: ExternalSecretsConfig{
+						Refs: map[string]string{
+							"cosign.key":      "gcpsm://projects/chains/secrets/cosign-key",
+							"cosign.password": "hashivault://secret/data/chains?field=password",
+						},
+						RefreshInterval: 10 * time.Minute,
+					},
+				},
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+			},
+		},
+		{
+			name: "sigstore stacks",
+			data: map[string]string{
+				"sigstore.private.namespaces":     "team-a, team-b",
+				"sigstore.private.fulcio-address": "https://fulcio.sigstore.example.com",
+				"sigstore.private.rekor-url":      "https://rekor.sigstore.example.com",
+				"sigstore.staging.namespaces":     "team-c",
+				"sigstore.staging.tuf-mirror-url": "https://tuf.staging.example.com",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Sigstore: SigstoreConfig{Stacks: map[string]SigstoreStack{
+					"private": {
+						Namespaces: sets.New[string]("team-a", "team-b"),
+						FulcioAddr: "https://fulcio.sigstore.example.com",
+						RekorURL:   "https://rekor.sigstore.example.com",
+					},
+					"staging": {
+						Namespaces:   sets.New[string]("team-c"),
+						TUFMirrorURL: "https://tuf.staging.example.com",
 					},
+				}},
+			},
+		},
+		{
+			name: "profiling",
+			data: map[string]string{
+				profilingAddressKey:      "localhost:6060",
+				profilingHeapIntervalKey: "10m",
+				profilingHeapDirKey:      "/var/run/chains/profiles",
+				profilingHeapKeepKey:     "5",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Profiling: ProfilingConfig{
+					Address:      "localhost:6060",
+					HeapInterval: 10 * time.Minute,
+					HeapDir:      "/var/run/chains/profiles",
+					HeapKeep:     5,
+				},
+			},
+		},
+		{
+			name: "materials allow-list",
+			data: map[string]string{
+				materialsAllowedPrefixesKey: "gcr.io/trusted/, git+https://github.com/org/",
+				materialsEnforcementKey:     "block",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Materials: MaterialsConfig{
+					AllowedPrefixes: sets.New[string]("gcr.io/trusted/", "git+https://github.com/org/"),
+					Enforcement:     MaterialsBlock,
+				},
+			},
+		},
+		{
+			name: "isolation signals",
+			data: map[string]string{
+				isolationSandboxRuntimeClassesKey: "gvisor, kata",
+				isolationNetworkLabelsKey:         "network.example.com/egress=deny,proxy-only",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
+				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
+				Isolation: IsolationConfig{
+					SandboxRuntimeClasses: sets.New[string]("gvisor", "kata"),
+					NetworkLabels:         sets.New[string]("network.example.com/egress=deny", "proxy-only"),
 				},
+			},
+		},
+		{
+			name: "retry policy",
+			data: map[string]string{
+				retryMaxRetriesKey:        "5",
+				retryInitialBackoffKey:    "10s",
+				retryBackoffMultiplierKey: "1.5",
+				retryMaxBackoffKey:        "1m",
+				retryJitterKey:            "0.2",
+				retryNoRetryErrorsKey:     "signing",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:      defaultBuilder,
+				Artifacts:    defaultArtifacts,
+				Signers:      defaultSigners,
+				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry: RetryConfig{
+					MaxRetries:        5,
+					InitialBackoff:    10 * time.Second,
+					BackoffMultiplier: 1.5,
+					MaxBackoff:        time.Minute,
+					Jitter:            0.2,
+					NoRetryErrors:     sets.New[string]("signing"),
+				},
 			},
 		},
 		{
@@ -208,6 +1515,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		},
 		{
@@ -238,6 +1546,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		},
 		{
@@ -268,6 +1577,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		},
 		{
@@ -298,6 +1608,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		},
 		{
@@ -328,6 +1639,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		},
 		{
@@ -361,6 +1673,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		},
 		{
@@ -394,6 +1707,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		},
 		{
@@ -424,6 +1738,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		},
 		{
@@ -441,6 +1756,57 @@ func TestParse(t *testing.T) {
 					VerifyAnnotation: true,
 					URL:              "https://rekor.sigstore.dev",
 				},
+				Retry: defaultRetry,
+			},
+		},
+		{
+			name: "private rekor transparency",
+			data: map[string]string{
+				transparencyEnabledKey:       "true",
+				transparencyURLKey:           "https://rekor.corp",
+				transparencyCAPathKey:        "/etc/rekor/ca.crt",
+				transparencyCertPathKey:      "/etc/rekor/tls.crt",
+				transparencyKeyPathKey:       "/etc/rekor/tls.key",
+				transparencyProxyURLKey:      "http://proxy.corp:3128",
+				transparencyQueueKey:         "true",
+				transparencyQueueIntervalKey: "5m",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage:   defaultStorage,
+				Transparency: TransparencyConfig{
+					Enabled:       true,
+					URL:           "https://rekor.corp",
+					CAPath:        "/etc/rekor/ca.crt",
+					CertPath:      "/etc/rekor/tls.crt",
+					KeyPath:       "/etc/rekor/tls.key",
+					ProxyURL:      "http://proxy.corp:3128",
+					QueueEnabled:  true,
+					QueueInterval: 5 * time.Minute,
+				},
+				Retry: defaultRetry,
+			},
+		},
+		{
+			name:           "rekor v2 transparency",
+			data:           map[string]string{transparencyEnabledKey: "true", transparencyURLKey: "https://log2025-1.rekor.sigstore.dev", transparencyVersionKey: "v2"},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder:   defaultBuilder,
+				Artifacts: defaultArtifacts,
+				Signers:   defaultSigners,
+				Storage:   defaultStorage,
+				Transparency: TransparencyConfig{
+					Enabled: true,
+					URL:     "https://log2025-1.rekor.sigstore.dev",
+					Version: TransparencyV2,
+				},
+				Retry: defaultRetry,
 			},
 		},
 		{
@@ -475,6 +1841,7 @@ func TestParse(t *testing.T) {
 				Signers:      defaultSigners,
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		}, {
 			name: "fulcio",
@@ -515,6 +1882,7 @@ func TestParse(t *testing.T) {
 				},
 				Storage:      defaultStorage,
 				Transparency: defaultTransparency,
+				Retry:        defaultRetry,
 			},
 		}, {
 			name: "rekor - true",
@@ -538,6 +1906,7 @@ func TestParse(t *testing.T) {
 					Enabled: true,
 					URL:     "https://rekor.sigstore.dev",
 				},
+				Retry: defaultRetry,
 			},
 		}, {
 			name: "rekor - manual",
@@ -562,6 +1931,7 @@ func TestParse(t *testing.T) {
 					VerifyAnnotation: true,
 					URL:              "https://rekor.sigstore.dev",
 				},
+				Retry: defaultRetry,
 			},
 		},
 	}
@@ -583,3 +1953,227 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaceConfig_Allowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       NamespaceConfig
+		namespace string
+		want      bool
+	}{
+		{name: "no restrictions", namespace: "foo", want: true},
+		{name: "empty watched list", cfg: NamespaceConfig{Watched: sets.New[string]("")}, namespace: "foo", want: true},
+		{name: "watched", cfg: NamespaceConfig{Watched: sets.New[string]("foo")}, namespace: "foo", want: true},
+		{name: "not watched", cfg: NamespaceConfig{Watched: sets.New[string]("foo")}, namespace: "bar", want: false},
+		{name: "excluded", cfg: NamespaceConfig{Excluded: sets.New[string]("foo")}, namespace: "foo", want: false},
+		{
+			name:      "excluded takes precedence",
+			cfg:       NamespaceConfig{Watched: sets.New[string]("foo"), Excluded: sets.New[string]("foo")},
+			namespace: "foo",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.Allowed(tt.namespace); got != tt.want {
+				t.Errorf("Allowed(%q) = %t, want %t", tt.namespace, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigStore_Filter(t *testing.T) {
+	cs := NewConfigStore(logtesting.TestLogger(t))
+	filter := cs.Filter()
+
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "excluded"}}
+	if !filter(obj) {
+		t.Error("expected objects to be admitted before the config is loaded")
+	}
+
+	cs.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ChainsConfig},
+		Data:       map[string]string{excludedNamespacesKey: "excluded"},
+	})
+	if filter(obj) {
+		t.Error("expected objects in excluded namespaces to be filtered")
+	}
+	if !filter(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "other"}}) {
+		t.Error("expected objects in other namespaces to be admitted")
+	}
+
+	now := metav1.Now()
+	obj.DeletionTimestamp = &now
+	if !filter(obj) {
+		t.Error("expected objects being deleted to be admitted")
+	}
+}
+
+func TestConfig_Selects(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		labels   map[string]string
+		want     bool
+	}{
+		{name: "no selector", want: true},
+		{name: "equality match", selector: "chains.tekton.dev/sign=true", labels: map[string]string{"chains.tekton.dev/sign": "true"}, want: true},
+		{name: "equality mismatch", selector: "chains.tekton.dev/sign=true", labels: map[string]string{"chains.tekton.dev/sign": "false"}, want: false},
+		{name: "missing label", selector: "chains.tekton.dev/sign=true", want: false},
+		{name: "set based", selector: "env in (prod, staging),!skip-signing", labels: map[string]string{"env": "prod"}, want: true},
+		{name: "set based excluded", selector: "env in (prod, staging),!skip-signing", labels: map[string]string{"env": "prod", "skip-signing": ""}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Selector: SelectorConfig{LabelSelector: tt.selector}}
+			obj := &metav1.ObjectMeta{Labels: tt.labels}
+			if got := cfg.Selects(obj); got != tt.want {
+				t.Errorf("Selects() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_InvalidLabelSelector(t *testing.T) {
+	if _, err := NewConfigFromMap(map[string]string{labelSelectorKey: "foo in (bar"}); err == nil {
+		t.Error("expected an error for an invalid label selector")
+	}
+}
+
+func TestParse_InvalidOversizedValues(t *testing.T) {
+	if _, err := NewConfigFromMap(map[string]string{provenanceOversizedValuesKey: "truncate"}); err == nil {
+		t.Error("expected an error for an invalid provenance.oversized-values")
+	}
+}
+
+func TestParse_InvalidConcurrency(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"storage.gcs.timeout": "-1s"},
+		{"signers.kms.timeout": "soon"},
+		{"signing.namespace.team-a.max-in-flight": "-1"},
+	} {
+		if _, err := NewConfigFromMap(data); err == nil {
+			t.Errorf("expected an error for invalid concurrency settings %v", data)
+		}
+	}
+}
+
+func TestParse_InvalidDigestAlgorithms(t *testing.T) {
+	if _, err := NewConfigFromMap(map[string]string{provenanceDigestAlgorithmsKey: "sha256,md5"}); err == nil {
+		t.Error("expected an error for an invalid provenance.digest-algorithms")
+	}
+}
+
+func TestParse_InvalidSigstoreStacks(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"sigstore.private.namespaces": "team-a", "sigstore.public.namespaces": "team-a,team-b"},
+		{"sigstore.private.rekor": "https://rekor.sigstore.example.com"},
+		{"sigstore.namespaces": "team-a"},
+	} {
+		if _, err := NewConfigFromMap(data); err == nil {
+			t.Errorf("expected an error for invalid Sigstore stacks %v", data)
+		}
+	}
+}
+
+func TestParse_SigningSecretNamespaceWithoutName(t *testing.T) {
+	if _, err := NewConfigFromMap(map[string]string{"signers.secret.namespace": "signing-keys"}); err == nil {
+		t.Error("expected an error for a signing secret namespace without a name")
+	}
+}
+
+func TestParse_SchemaRegistryWithBatches(t *testing.T) {
+	data := map[string]string{
+		"storage.pubsub.schema-registry.url": "http://schema-registry.kafka:8081",
+		"storage.pubsub.batch-size":          "50",
+	}
+	if _, err := NewConfigFromMap(data); err == nil {
+		t.Error("expected an error for a schema registry with batches")
+	}
+}
+
+func TestParse_MQTTWithBatches(t *testing.T) {
+	data := map[string]string{
+		"storage.pubsub.provider":   "mqtt",
+		"storage.pubsub.batch-size": "50",
+	}
+	if _, err := NewConfigFromMap(data); err == nil {
+		t.Error("expected an error for the mqtt provider with batches")
+	}
+}
+
+func TestParse_GRPCWithoutTLS(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"grpc.address": ":9090"},
+		{"grpc.address": ":9090", "grpc.tls.cert-path": "/etc/chains-grpc/tls.crt"},
+	} {
+		if _, err := NewConfigFromMap(data); err == nil {
+			t.Errorf("expected an error for the gRPC API without TLS %v", data)
+		}
+	}
+}
+
+func TestParse_QueryWithoutTLS(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"query.address": ":8081"},
+		{"query.address": ":8081", "query.tls.key-path": "/etc/chains-query/tls.key"},
+	} {
+		if _, err := NewConfigFromMap(data); err == nil {
+			t.Errorf("expected an error for the query API without TLS %v", data)
+		}
+	}
+}
+
+func TestParse_InvalidExternalSecrets(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"signers.external.cosign.pub": "awssm://chains/cosign-pub"},
+		{"signers.external.cosign.password": "chains/cosign-password"},
+		{"signers.external.cosign.password": "azurekv://chains/cosign-password"},
+	} {
+		if _, err := NewConfigFromMap(data); err == nil {
+			t.Errorf("expected an error for invalid external secrets %v", data)
+		}
+	}
+}
+
+func TestApplySigstoreStack(t *testing.T) {
+	cfg := Config{
+		Signers: SignerConfigs{X509: X509Signer{
+			FulcioAddr:       "https://fulcio.sigstore.dev",
+			FulcioOIDCIssuer: "https://oauth2.sigstore.dev/auth",
+			TUFMirrorURL:     "https://tuf-repo-cdn.sigstore.dev",
+		}},
+		Transparency: TransparencyConfig{URL: "https://rekor.sigstore.dev"},
+		Sigstore: SigstoreConfig{Stacks: map[string]SigstoreStack{
+			"private": {
+				Namespaces: sets.New[string]("team-a"),
+				FulcioAddr: "https://fulcio.sigstore.example.com",
+				RekorURL:   "https://rekor.sigstore.example.com",
+			},
+		}},
+	}
+
+	public := cfg
+	if got := public.ApplySigstoreStack("team-b"); got != "" {
+		t.Errorf("ApplySigstoreStack() = %q for a namespace without a stack, want none", got)
+	}
+	if diff := cmp.Diff(cfg, public); diff != "" {
+		t.Errorf("ApplySigstoreStack() changed the config of a namespace without a stack: %s", diff)
+	}
+
+	private := cfg
+	if got := private.ApplySigstoreStack("team-a"); got != "private" {
+		t.Errorf("ApplySigstoreStack() = %q, want %q", got, "private")
+	}
+	want := X509Signer{
+		FulcioAddr:       "https://fulcio.sigstore.example.com",
+		FulcioOIDCIssuer: "https://oauth2.sigstore.dev/auth",
+		TUFMirrorURL:     "https://tuf-repo-cdn.sigstore.dev",
+	}
+	if diff := cmp.Diff(want, private.Signers.X509); diff != "" {
+		t.Errorf("ApplySigstoreStack() x509 signer -want +got: %s", diff)
+	}
+	if got, want := private.Transparency.URL, "https://rekor.sigstore.example.com"; got != want {
+		t.Errorf("ApplySigstoreStack() transparency URL = %q, want %q", got, want)
+	}
+}
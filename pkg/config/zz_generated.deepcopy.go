@@ -22,6 +22,8 @@ limitations under the License.
 package config
 
 import (
+	time "time"
+
 	sets "k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -35,6 +37,13 @@ func (in *Artifact) DeepCopyInto(out *Artifact) {
 			(*out)[key] = val
 		}
 	}
+	if in.BundleStorageBackend != nil {
+		in, out := &in.BundleStorageBackend, &out.BundleStorageBackend
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -51,8 +60,13 @@ func (in *Artifact) DeepCopy() *Artifact {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ArtifactConfigs) DeepCopyInto(out *ArtifactConfigs) {
 	*out = *in
-	in.TaskRuns.DeepCopyInto(&out.TaskRuns)
 	in.OCI.DeepCopyInto(&out.OCI)
+	in.PipelineRuns.DeepCopyInto(&out.PipelineRuns)
+	in.TaskRuns.DeepCopyInto(&out.TaskRuns)
+	in.VEX.DeepCopyInto(&out.VEX)
+	in.TektonBundles.DeepCopyInto(&out.TektonBundles)
+	in.Source.DeepCopyInto(&out.Source)
+	in.CustomRuns.DeepCopyInto(&out.CustomRuns)
 	return
 }
 
@@ -66,6 +80,22 @@ func (in *ArtifactConfigs) DeepCopy() *ArtifactConfigs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditConfig) DeepCopyInto(out *AuditConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditConfig.
+func (in *AuditConfig) DeepCopy() *AuditConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuilderConfig) DeepCopyInto(out *BuilderConfig) {
 	*out = *in
@@ -82,6 +112,73 @@ func (in *BuilderConfig) DeepCopy() *BuilderConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClustersConfig) DeepCopyInto(out *ClustersConfig) {
+	*out = *in
+	if in.BuilderIDs != nil {
+		in, out := &in.BuilderIDs, &out.BuilderIDs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClustersConfig.
+func (in *ClustersConfig) DeepCopy() *ClustersConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClustersConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencyConfig) DeepCopyInto(out *ConcurrencyConfig) {
+	*out = *in
+	if in.BackendLimits != nil {
+		in, out := &in.BackendLimits, &out.BackendLimits
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.BackendTimeouts != nil {
+		in, out := &in.BackendTimeouts, &out.BackendTimeouts
+		*out = make(map[string]time.Duration, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SignerTimeouts != nil {
+		in, out := &in.SignerTimeouts, &out.SignerTimeouts
+		*out = make(map[string]time.Duration, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NamespaceLimits != nil {
+		in, out := &in.NamespaceLimits, &out.NamespaceLimits
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencyConfig.
+func (in *ConcurrencyConfig) DeepCopy() *ConcurrencyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Config) DeepCopyInto(out *Config) {
 	*out = *in
@@ -90,6 +187,32 @@ func (in *Config) DeepCopyInto(out *Config) {
 	out.Signers = in.Signers
 	out.Builder = in.Builder
 	out.Transparency = in.Transparency
+	out.Tracing = in.Tracing
+	in.Retry.DeepCopyInto(&out.Retry)
+	in.Namespaces.DeepCopyInto(&out.Namespaces)
+	out.Selector = in.Selector
+	out.Finalizer = in.Finalizer
+	in.DryRun.DeepCopyInto(&out.DryRun)
+	out.Audit = in.Audit
+	out.Webhook = in.Webhook
+	in.Overrides.DeepCopyInto(&out.Overrides)
+	in.Concurrency.DeepCopyInto(&out.Concurrency)
+	out.Lease = in.Lease
+	out.Shutdown = in.Shutdown
+	out.SigningStatus = in.SigningStatus
+	out.Events = in.Events
+	out.Query = in.Query
+	out.PublicKeys = in.PublicKeys
+	out.Conformance = in.Conformance
+	out.Health = in.Health
+	in.Metrics.DeepCopyInto(&out.Metrics)
+	in.Provenance.DeepCopyInto(&out.Provenance)
+	in.Isolation.DeepCopyInto(&out.Isolation)
+	out.Policy = in.Policy
+	in.Materials.DeepCopyInto(&out.Materials)
+	in.Clusters.DeepCopyInto(&out.Clusters)
+	out.Profiling = in.Profiling
+	in.Sigstore.DeepCopyInto(&out.Sigstore)
 	return
 }
 
@@ -103,6 +226,22 @@ func (in *Config) DeepCopy() *Config {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConformanceConfig) DeepCopyInto(out *ConformanceConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConformanceConfig.
+func (in *ConformanceConfig) DeepCopy() *ConformanceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ConformanceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DocDBStorageConfig) DeepCopyInto(out *DocDBStorageConfig) {
 	*out = *in
@@ -119,6 +258,84 @@ func (in *DocDBStorageConfig) DeepCopy() *DocDBStorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunConfig) DeepCopyInto(out *DryRunConfig) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunConfig.
+func (in *DryRunConfig) DeepCopy() *DryRunConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventsConfig) DeepCopyInto(out *EventsConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventsConfig.
+func (in *EventsConfig) DeepCopy() *EventsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EventsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretsConfig) DeepCopyInto(out *ExternalSecretsConfig) {
+	*out = *in
+	if in.Refs != nil {
+		in, out := &in.Refs, &out.Refs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretsConfig.
+func (in *ExternalSecretsConfig) DeepCopy() *ExternalSecretsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FinalizerConfig) DeepCopyInto(out *FinalizerConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FinalizerConfig.
+func (in *FinalizerConfig) DeepCopy() *FinalizerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FinalizerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCSStorageConfig) DeepCopyInto(out *GCSStorageConfig) {
 	*out = *in
@@ -135,6 +352,36 @@ func (in *GCSStorageConfig) DeepCopy() *GCSStorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IsolationConfig) DeepCopyInto(out *IsolationConfig) {
+	*out = *in
+	if in.SandboxRuntimeClasses != nil {
+		in, out := &in.SandboxRuntimeClasses, &out.SandboxRuntimeClasses
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NetworkLabels != nil {
+		in, out := &in.NetworkLabels, &out.NetworkLabels
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IsolationConfig.
+func (in *IsolationConfig) DeepCopy() *IsolationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(IsolationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KMSSigner) DeepCopyInto(out *KMSSigner) {
 	*out = *in
@@ -151,9 +398,108 @@ func (in *KMSSigner) DeepCopy() *KMSSigner {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseConfig) DeepCopyInto(out *LeaseConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseConfig.
+func (in *LeaseConfig) DeepCopy() *LeaseConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaterialsConfig) DeepCopyInto(out *MaterialsConfig) {
+	*out = *in
+	if in.AllowedPrefixes != nil {
+		in, out := &in.AllowedPrefixes, &out.AllowedPrefixes
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaterialsConfig.
+func (in *MaterialsConfig) DeepCopy() *MaterialsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MaterialsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsConfig) DeepCopyInto(out *MetricsConfig) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsConfig.
+func (in *MetricsConfig) DeepCopy() *MetricsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceConfig) DeepCopyInto(out *NamespaceConfig) {
+	*out = *in
+	if in.Watched != nil {
+		in, out := &in.Watched, &out.Watched
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Excluded != nil {
+		in, out := &in.Excluded, &out.Excluded
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceConfig.
+func (in *NamespaceConfig) DeepCopy() *NamespaceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCIStorageConfig) DeepCopyInto(out *OCIStorageConfig) {
 	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -167,11 +513,192 @@ func (in *OCIStorageConfig) DeepCopy() *OCIStorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverridesConfig) DeepCopyInto(out *OverridesConfig) {
+	*out = *in
+	if in.Allowed != nil {
+		in, out := &in.Allowed, &out.Allowed
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverridesConfig.
+func (in *OverridesConfig) DeepCopy() *OverridesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OverridesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyConfig) DeepCopyInto(out *PolicyConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyConfig.
+func (in *PolicyConfig) DeepCopy() *PolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfilingConfig) DeepCopyInto(out *ProfilingConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfilingConfig.
+func (in *ProfilingConfig) DeepCopy() *ProfilingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfilingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvenanceConfig) DeepCopyInto(out *ProvenanceConfig) {
+	*out = *in
+	if in.DigestAlgorithms != nil {
+		in, out := &in.DigestAlgorithms, &out.DigestAlgorithms
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StepEnvAllow != nil {
+		in, out := &in.StepEnvAllow, &out.StepEnvAllow
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StepEnvDeny != nil {
+		in, out := &in.StepEnvDeny, &out.StepEnvDeny
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvenanceConfig.
+func (in *ProvenanceConfig) DeepCopy() *ProvenanceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvenanceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublicKeysConfig) DeepCopyInto(out *PublicKeysConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicKeysConfig.
+func (in *PublicKeysConfig) DeepCopy() *PublicKeysConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PublicKeysConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryConfig) DeepCopyInto(out *QueryConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryConfig.
+func (in *QueryConfig) DeepCopy() *QueryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryConfig) DeepCopyInto(out *RetryConfig) {
+	*out = *in
+	if in.NoRetryErrors != nil {
+		in, out := &in.NoRetryErrors, &out.NoRetryErrors
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryConfig.
+func (in *RetryConfig) DeepCopy() *RetryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SelectorConfig) DeepCopyInto(out *SelectorConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelectorConfig.
+func (in *SelectorConfig) DeepCopy() *SelectorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SelectorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutdownConfig) DeepCopyInto(out *ShutdownConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutdownConfig.
+func (in *ShutdownConfig) DeepCopy() *ShutdownConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutdownConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SignerConfigs) DeepCopyInto(out *SignerConfigs) {
 	*out = *in
 	out.X509 = in.X509
 	out.KMS = in.KMS
+	in.Secret.DeepCopyInto(&out.Secret)
+	in.External.DeepCopyInto(&out.External)
 	return
 }
 
@@ -185,11 +712,96 @@ func (in *SignerConfigs) DeepCopy() *SignerConfigs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningSecretConfig) DeepCopyInto(out *SigningSecretConfig) {
+	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningSecretConfig.
+func (in *SigningSecretConfig) DeepCopy() *SigningSecretConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SigningSecretConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningStatusConfig) DeepCopyInto(out *SigningStatusConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningStatusConfig.
+func (in *SigningStatusConfig) DeepCopy() *SigningStatusConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SigningStatusConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigstoreConfig) DeepCopyInto(out *SigstoreConfig) {
+	*out = *in
+	if in.Stacks != nil {
+		in, out := &in.Stacks, &out.Stacks
+		*out = make(map[string]SigstoreStack, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigstoreConfig.
+func (in *SigstoreConfig) DeepCopy() *SigstoreConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SigstoreConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigstoreStack) DeepCopyInto(out *SigstoreStack) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make(sets.Set[string], len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigstoreStack.
+func (in *SigstoreStack) DeepCopy() *SigstoreStack {
+	if in == nil {
+		return nil
+	}
+	out := new(SigstoreStack)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageConfigs) DeepCopyInto(out *StorageConfigs) {
 	*out = *in
 	out.GCS = in.GCS
-	out.OCI = in.OCI
+	in.OCI.DeepCopyInto(&out.OCI)
 	out.Tekton = in.Tekton
 	out.DocDB = in.DocDB
 	return
@@ -237,6 +849,22 @@ func (in *TektonStorageConfig) DeepCopy() *TektonStorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingConfig) DeepCopyInto(out *TracingConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingConfig.
+func (in *TracingConfig) DeepCopy() *TracingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransparencyConfig) DeepCopyInto(out *TransparencyConfig) {
 	*out = *in
@@ -253,6 +881,22 @@ func (in *TransparencyConfig) DeepCopy() *TransparencyConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookConfig) DeepCopyInto(out *WebhookConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookConfig.
+func (in *WebhookConfig) DeepCopy() *WebhookConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *X509Signer) DeepCopyInto(out *X509Signer) {
 	*out = *in
@@ -0,0 +1,346 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance continuously checks that Chains signs runs end to end. It
+// periodically creates a canary TaskRun, waits for Chains to sign it and verifies the
+// attestations stored for it the way chainsctl verify does, reporting the outcome in
+// metrics and in the Conformant condition of the chains-config ChainsConfig.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	rc "github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/sigstore/pkg/fulcioroots"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/metrics"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/verify"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// CanaryLabel is set on the canary TaskRuns.
+	CanaryLabel = "chains.tekton.dev/conformance-canary"
+
+	defaultInterval = time.Hour
+	defaultTimeout  = 10 * time.Minute
+	defaultImage    = "cgr.dev/chainguard/busybox"
+	pollInterval    = 5 * time.Second
+)
+
+// VerifyFunc verifies the attestations of a signed run.
+type VerifyFunc func(ctx context.Context, obj objects.TektonObject, cfg config.Config, backends map[string]storage.Backend) ([]verify.Result, error)
+
+// Prober runs conformance probes.
+type Prober struct {
+	Pipelineclientset versioned.Interface
+	DynamicClient     dynamic.Interface
+	// KubeClient reads the signing secret referenced by signers.secret.name, if any.
+	KubeClient kubernetes.Interface
+	// SecretPath is where the signing secrets are mounted, to verify signatures with the
+	// keys of the signers.
+	SecretPath string
+	// Namespace is the namespace canaries are created in unless conformance.namespace
+	// is set.
+	Namespace string
+	// Verify verifies the attestations of the canaries. VerifyRun is used when it is nil.
+	Verify VerifyFunc
+	// PollInterval is how often the canary is checked while waiting for it to be signed.
+	// A default of five seconds is used when it is zero.
+	PollInterval time.Duration
+
+	mu       sync.Mutex
+	cfg      config.Config
+	backends map[string]storage.Backend
+	// running is the interval of the probe loop, and stop stops it, if it runs.
+	running time.Duration
+	stop    context.CancelFunc
+}
+
+// Setup runs probes with cfg and the storage backends, if cfg enables them. It is safe
+// to call on every config update: the probe loop is only restarted when its interval
+// changed and stopped when probes are disabled, and every probe uses the config and
+// backends of the last call.
+func (p *Prober) Setup(ctx context.Context, cfg config.Config, backends map[string]storage.Backend) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cfg, p.backends = cfg, backends
+	interval := cfg.Conformance.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+	if !cfg.Conformance.Enabled {
+		interval = 0
+	}
+	if interval == p.running {
+		return
+	}
+	if p.stop != nil {
+		p.stop()
+		p.stop = nil
+	}
+	p.running = interval
+	if interval == 0 {
+		return
+	}
+
+	ctx, p.stop = context.WithCancel(ctx)
+	go func() {
+		for {
+			p.probeAndReport(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// state returns the config and storage backends probes run with.
+func (p *Prober) state() (config.Config, map[string]storage.Backend) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cfg, p.backends
+}
+
+func (p *Prober) probeAndReport(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	cfg, backends := p.state()
+
+	start := time.Now()
+	err := p.Probe(ctx, cfg, backends)
+	if ctx.Err() != nil {
+		// The probe was interrupted by a config update or a shutdown.
+		return
+	}
+	metrics.RecordConformanceProbe(ctx, err == nil, time.Since(start))
+	if err != nil {
+		logger.Errorf("Conformance probe failed: %v", err)
+	} else {
+		logger.Infof("Conformance probe passed in %s", time.Since(start).Round(time.Second))
+	}
+	if err := p.report(ctx, err); err != nil {
+		logger.Warnf("error reporting conformance probe result: %v", err)
+	}
+}
+
+// Probe creates a canary TaskRun, waits for Chains to sign it and verifies its
+// attestations. It returns why the probe failed, if it did. The canary is deleted
+// once the probe is done.
+func (p *Prober) Probe(ctx context.Context, cfg config.Config, backends map[string]storage.Backend) error {
+	if !cfg.Artifacts.TaskRuns.Enabled() {
+		return errors.New("TaskRuns are not signed, artifacts.taskrun.storage is empty")
+	}
+	timeout := cfg.Conformance.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	interval := p.PollInterval
+	if interval == 0 {
+		interval = pollInterval
+	}
+
+	tr, err := p.Pipelineclientset.TektonV1beta1().TaskRuns(p.namespace(cfg)).Create(ctx, canary(cfg), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("error creating canary TaskRun: %w", err)
+	}
+	defer func() {
+		// Deleted even if ctx is done, so canaries don't pile up across restarts.
+		err := p.Pipelineclientset.TektonV1beta1().TaskRuns(tr.Namespace).Delete(context.Background(), tr.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			logging.FromContext(ctx).Warnf("error deleting canary TaskRun %s/%s: %v", tr.Namespace, tr.Name, err)
+		}
+	}()
+
+	var signed *v1beta1.TaskRun
+	err = wait.PollUntilContextTimeout(ctx, interval, timeout, false, func(ctx context.Context) (bool, error) {
+		current, err := p.Pipelineclientset.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		tr = current
+		switch current.Annotations[chains.ChainsAnnotation] {
+		case "true":
+			signed = current
+			return true, nil
+		case "failed":
+			return false, fmt.Errorf("Chains failed to sign canary TaskRun %s/%s", current.Namespace, current.Name)
+		}
+		return false, nil
+	})
+	if signed == nil {
+		if err != nil && !wait.Interrupted(err) {
+			return err
+		}
+		if !tr.IsDone() {
+			return fmt.Errorf("canary TaskRun %s/%s did not complete within %s", tr.Namespace, tr.Name, timeout)
+		}
+		return fmt.Errorf("canary TaskRun %s/%s was not signed within %s", tr.Namespace, tr.Name, timeout)
+	}
+
+	verifyFunc := p.Verify
+	if verifyFunc == nil {
+		verifyFunc = p.VerifyRun
+	}
+	results, err := verifyFunc(ctx, objects.NewTaskRunObject(signed), cfg, backends)
+	if err != nil {
+		return fmt.Errorf("error verifying canary TaskRun %s/%s: %w", signed.Namespace, signed.Name, err)
+	}
+	var failed []string
+	for _, r := range results {
+		for _, c := range r.Checks {
+			if c.Status == verify.StatusFailed {
+				failed = append(failed, fmt.Sprintf("%s %s: %s: %s", r.Source, r.Key, c.Name, c.Detail))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("attestations of canary TaskRun %s/%s failed verification: %s", signed.Namespace, signed.Name, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// VerifyRun verifies the attestations of obj with the keys of the configured signers,
+// or the Fulcio roots if keyless signing is enabled, and the transparency log if
+// transparency is enabled.
+func (p *Prober) VerifyRun(ctx context.Context, obj objects.TektonObject, cfg config.Config, backends map[string]storage.Backend) ([]verify.Result, error) {
+	opts := verify.Options{}
+	signer := cfg.Artifacts.TaskRuns.Signer
+	if signer == signing.TypeX509 && cfg.Signers.X509.FulcioEnabled {
+		roots, err := fulcioroots.Get()
+		if err != nil {
+			return nil, fmt.Errorf("error getting Fulcio roots: %w", err)
+		}
+		intermediates, err := fulcioroots.GetIntermediates()
+		if err != nil {
+			return nil, fmt.Errorf("error getting Fulcio intermediates: %w", err)
+		}
+		opts.Roots, opts.Intermediates = roots, intermediates
+	} else {
+		sp, err := chains.SigningSecretPath(ctx, p.KubeClient, p.SecretPath, cfg)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := chains.AllSigners(ctx, sp, cfg)[signer]
+		if !ok {
+			return nil, fmt.Errorf("the %s signer is not configured", signer)
+		}
+		opts.Key = s
+	}
+	// Entries of Rekor v2 logs can't be looked up by the digest of their payload.
+	if cfg.Transparency.Enabled && cfg.Transparency.Version != config.TransparencyV2 {
+		rekor, err := rc.GetRekorClient(cfg.Transparency.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error creating Rekor client for %s: %w", cfg.Transparency.URL, err)
+		}
+		pubs, err := cosign.GetRekorPubs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting Rekor public keys: %w", err)
+		}
+		opts.Rekor, opts.RekorPubKeys = rekor, pubs
+	}
+	return verify.Run(ctx, obj, backends, cfg, opts)
+}
+
+// report records the outcome of a probe in the Conformant condition of the
+// chains-config ChainsConfig, if it exists.
+func (p *Prober) report(ctx context.Context, probeErr error) error {
+	client := p.DynamicClient.Resource(v1alpha1.ChainsConfigResource)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		u, err := client.Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		cc := &v1alpha1.ChainsConfig{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cc); err != nil {
+			return err
+		}
+
+		status := cc.Status.DeepCopy()
+		if probeErr != nil {
+			status.MarkNotConformant("%v", probeErr)
+		} else {
+			status.MarkConformant("The attestations of the canary TaskRun were verified")
+		}
+		if equality.Semantic.DeepEqual(status, &cc.Status) {
+			return nil
+		}
+		cc.Status = *status
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cc)
+		if err != nil {
+			return err
+		}
+		_, err = client.UpdateStatus(ctx, &unstructured.Unstructured{Object: obj}, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (p *Prober) namespace(cfg config.Config) string {
+	if cfg.Conformance.Namespace != "" {
+		return cfg.Conformance.Namespace
+	}
+	return p.Namespace
+}
+
+// canary returns a new canary TaskRun.
+func canary(cfg config.Config) *v1beta1.TaskRun {
+	image := cfg.Conformance.Image
+	if image == "" {
+		image = defaultImage
+	}
+	return &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "chains-conformance-" + utilrand.String(5),
+			Labels: map[string]string{
+				CanaryLabel:                 "true",
+				"app.kubernetes.io/part-of": "tekton-chains",
+			},
+		},
+		Spec: v1beta1.TaskRunSpec{
+			TaskSpec: &v1beta1.TaskSpec{
+				Steps: []v1beta1.Step{{
+					Name:   "canary",
+					Image:  image,
+					Script: "echo Tekton Chains conformance canary",
+				}},
+			},
+		},
+	}
+}
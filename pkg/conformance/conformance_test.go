@@ -0,0 +1,209 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/verify"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipeline "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	ktesting "k8s.io/client-go/testing"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+// signOnCreate makes the fake client annotate created TaskRuns the way Chains would.
+func signOnCreate(ps *fakepipeline.Clientset, annotation string) {
+	ps.PrependReactor("create", "taskruns", func(action ktesting.Action) (bool, runtime.Object, error) {
+		tr := action.(ktesting.CreateAction).GetObject().(*v1beta1.TaskRun)
+		if annotation != "" {
+			tr.Annotations = map[string]string{chains.ChainsAnnotation: annotation}
+		}
+		return false, nil, nil
+	})
+}
+
+func passing(context.Context, objects.TektonObject, config.Config, map[string]storage.Backend) ([]verify.Result, error) {
+	return []verify.Result{{Source: "tekton", Key: "taskrun-uid", Checks: []verify.Check{{Name: verify.CheckSignature, Status: verify.StatusPassed}}}}, nil
+}
+
+func failing(context.Context, objects.TektonObject, config.Config, map[string]storage.Backend) ([]verify.Result, error) {
+	return []verify.Result{{Source: "tekton", Key: "taskrun-uid", Checks: []verify.Check{{Name: verify.CheckPredicate, Status: verify.StatusFailed, Detail: "missing buildType"}}}}, nil
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		verify     VerifyFunc
+		data       map[string]string
+		wantErr    string
+	}{{
+		name:       "signed and verified",
+		annotation: "true",
+		verify:     passing,
+	}, {
+		name:       "verification failed",
+		annotation: "true",
+		verify:     failing,
+		wantErr:    "predicate: missing buildType",
+	}, {
+		name:       "verification error",
+		annotation: "true",
+		verify: func(context.Context, objects.TektonObject, config.Config, map[string]storage.Backend) ([]verify.Result, error) {
+			return nil, errors.New("no attestations found")
+		},
+		wantErr: "no attestations found",
+	}, {
+		name:       "signing failed",
+		annotation: "failed",
+		verify:     passing,
+		wantErr:    "Chains failed to sign",
+	}, {
+		name:    "not signed in time",
+		verify:  passing,
+		wantErr: "did not complete within",
+	}, {
+		name:    "taskruns not signed",
+		data:    map[string]string{"artifacts.taskrun.storage": ""},
+		verify:  passing,
+		wantErr: "TaskRuns are not signed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logtesting.TestContextWithLogger(t)
+			data := map[string]string{"conformance.timeout": "50ms", "conformance.namespace": "canaries"}
+			for k, v := range tt.data {
+				data[k] = v
+			}
+			cfg, err := config.NewConfigFromMap(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ps := fakepipeline.NewSimpleClientset()
+			signOnCreate(ps, tt.annotation)
+			p := &Prober{Pipelineclientset: ps, Namespace: "tekton-chains", Verify: tt.verify, PollInterval: 10 * time.Millisecond}
+
+			err = p.Probe(ctx, *cfg, nil)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Probe() = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Probe() = %v, want an error containing %q", err, tt.wantErr)
+			}
+
+			for _, a := range ps.Actions() {
+				if a.GetVerb() == "create" && a.GetNamespace() != "canaries" {
+					t.Errorf("canary created in namespace %q", a.GetNamespace())
+				}
+			}
+			trs, err := ps.TektonV1beta1().TaskRuns("canaries").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trs.Items) != 0 {
+				t.Errorf("expected the canary to be deleted, found %d TaskRuns", len(trs.Items))
+			}
+		})
+	}
+}
+
+func TestCanary(t *testing.T) {
+	tr := canary(config.Config{})
+	if tr.Labels[CanaryLabel] != "true" {
+		t.Errorf("expected the %s label, got %v", CanaryLabel, tr.Labels)
+	}
+	if got := tr.Spec.TaskSpec.Steps[0].Image; got != defaultImage {
+		t.Errorf("expected the default image, got %s", got)
+	}
+	if got := canary(config.Config{Conformance: config.ConformanceConfig{Image: "busybox"}}).Spec.TaskSpec.Steps[0].Image; got != "busybox" {
+		t.Errorf("expected the configured image, got %s", got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cc := &v1alpha1.ChainsConfig{ObjectMeta: metav1.ObjectMeta{Name: config.ChainsConfig}}
+	cc.Status.InitializeConditions()
+	cc.Status.MarkApplied(map[string]string{})
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &unstructured.Unstructured{Object: u}
+	obj.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("ChainsConfig"))
+	p := &Prober{DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme, obj)}
+
+	status := func() v1alpha1.ChainsConfigStatus {
+		t.Helper()
+		u, err := p.DynamicClient.Resource(v1alpha1.ChainsConfigResource).Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &v1alpha1.ChainsConfig{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Status
+	}
+
+	if err := p.report(ctx, errors.New("canary TaskRun was not signed")); err != nil {
+		t.Fatal(err)
+	}
+	s := status()
+	c := s.GetCondition(v1alpha1.ConditionConformant)
+	if c == nil || c.Status != corev1.ConditionFalse || c.Reason != v1alpha1.ReasonProbeFailed || c.Message != "canary TaskRun was not signed" {
+		t.Fatalf("unexpected Conformant condition %+v", c)
+	}
+	if !s.IsReady() {
+		t.Error("a failed probe must not affect Ready")
+	}
+
+	if err := p.report(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	s = status()
+	if c := s.GetCondition(v1alpha1.ConditionConformant); c == nil || c.Status != corev1.ConditionTrue {
+		t.Fatalf("unexpected Conformant condition %+v", c)
+	}
+}
+
+func TestReport_NoChainsConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	p := &Prober{DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme)}
+	if err := p.report(logtesting.TestContextWithLogger(t), nil); err != nil {
+		t.Errorf("report() = %v", err)
+	}
+}
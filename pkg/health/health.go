@@ -0,0 +1,304 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health checks that the storage backends and signers Chains is configured with
+// are usable, like that their bucket is writable or their KMS key accessible, when the
+// config is loaded and then periodically, so that misconfigurations are caught before
+// runs pile up unsigned. The outcome is reported in metrics, in the Healthy condition of
+// the chains-config ChainsConfig and by a readiness endpoint.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/metrics"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/internal/httpserver"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	defaultInterval = 5 * time.Minute
+	defaultTimeout  = 30 * time.Second
+)
+
+// Checker is implemented by the storage backends that can check that they are usable.
+// Backends that don't implement it aren't checked.
+type Checker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// Result is the outcome of the health check of a storage backend or signer.
+type Result struct {
+	// Component is what was checked, like storage/gcs or signer/kms.
+	Component string
+	// Err is why the check failed, if it did.
+	Err error
+}
+
+// Monitor runs health checks.
+type Monitor struct {
+	DynamicClient dynamic.Interface
+	// KubeClient reads the signing secret referenced by signers.secret.name, if any.
+	KubeClient kubernetes.Interface
+	// SecretPath is where the signing secrets are mounted.
+	SecretPath string
+
+	mu          sync.Mutex
+	cfg         config.Config
+	backends    map[string]storage.Backend
+	backendsErr error
+	// running is the interval of the check loop, stop stops it and trigger runs checks
+	// without waiting for the interval, if it runs.
+	running time.Duration
+	stop    context.CancelFunc
+	trigger chan struct{}
+	// results are the results of the latest checks, and checked whether checks ran
+	// since they were enabled.
+	results []Result
+	checked bool
+	// server serves the readiness endpoint.
+	server httpserver.Server
+}
+
+// Setup runs checks with cfg and the storage backends, if cfg enables them, right away
+// and then at the interval of cfg. backendsErr is why the backends could not be
+// initialized, if they could not. It is safe to call on every config update: the check
+// loop is only restarted when its interval changed and stopped when checks are
+// disabled, and the readiness endpoint is only restarted when its address changed.
+func (m *Monitor) Setup(ctx context.Context, cfg config.Config, backends map[string]storage.Backend, backendsErr error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cfg, m.backends, m.backendsErr = cfg, backends, backendsErr
+	interval, address := cfg.Health.Interval, cfg.Health.Address
+	if interval == 0 {
+		interval = defaultInterval
+	}
+	if !cfg.Health.Enabled {
+		interval, address = 0, ""
+	}
+
+	if interval == m.running {
+		if m.trigger != nil {
+			// The config changed, so it is checked without waiting for the interval.
+			select {
+			case m.trigger <- struct{}{}:
+			default:
+			}
+		}
+	} else {
+		if m.stop != nil {
+			m.stop()
+			m.stop, m.trigger = nil, nil
+		}
+		m.running, m.results, m.checked = interval, nil, false
+		if interval > 0 {
+			loopCtx, cancel := context.WithCancel(ctx)
+			trigger := make(chan struct{}, 1)
+			m.stop, m.trigger = cancel, trigger
+			go func() {
+				for {
+					m.checkAndReport(loopCtx)
+					select {
+					case <-loopCtx.Done():
+						return
+					case <-trigger:
+					case <-time.After(interval):
+					}
+				}
+			}()
+		}
+	}
+	m.server.Name = "readiness endpoint"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readyz", m.ready)
+	return m.server.Serve(ctx, address, mux)
+}
+
+// state returns what the checks run with.
+func (m *Monitor) state() (config.Config, map[string]storage.Backend, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cfg, m.backends, m.backendsErr
+}
+
+func (m *Monitor) checkAndReport(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	cfg, backends, backendsErr := m.state()
+
+	results := m.Check(ctx, cfg, backends, backendsErr)
+	if ctx.Err() != nil {
+		// The checks were interrupted by a config update or a shutdown.
+		return
+	}
+	var failed []string
+	for _, r := range results {
+		metrics.RecordHealthCheck(ctx, r.Component, r.Err == nil)
+		if r.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", r.Component, r.Err))
+		}
+	}
+	m.mu.Lock()
+	m.results, m.checked = results, true
+	m.mu.Unlock()
+
+	if len(failed) > 0 {
+		logger.Errorf("Health checks failed: %s", strings.Join(failed, "; "))
+	} else {
+		logger.Debugf("%d health checks passed", len(results))
+	}
+	if err := m.report(ctx, len(results), failed); err != nil {
+		logger.Warnf("error reporting health check results: %v", err)
+	}
+}
+
+// Check checks the storage backends and signers cfg uses, in that order. backendsErr is
+// why backends could not be initialized, if they could not, which fails the checks of
+// the storage backends.
+func (m *Monitor) Check(ctx context.Context, cfg config.Config, backends map[string]storage.Backend, backendsErr error) []Result {
+	timeout := cfg.Health.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	check := func(f func(ctx context.Context) error) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return f(ctx)
+	}
+
+	var results []Result
+	for _, name := range sets.List(sets.New(storage.ConfiguredBackends(cfg)...)) {
+		b, ok := backends[name]
+		switch {
+		case !ok && backendsErr != nil:
+			results = append(results, Result{Component: "storage/" + name, Err: fmt.Errorf("the storage backends could not be initialized: %w", backendsErr)})
+		case !ok:
+			// Artifacts that aren't stored anywhere have an empty backend.
+			continue
+		default:
+			var err error
+			if c, ok := b.(Checker); ok {
+				err = check(c.CheckHealth)
+			}
+			results = append(results, Result{Component: "storage/" + name, Err: err})
+		}
+	}
+
+	needed := chains.NeededSigners(cfg)
+	if len(needed) == 0 {
+		return results
+	}
+	sp, err := chains.SigningSecretPath(ctx, m.KubeClient, m.SecretPath, cfg)
+	if err != nil {
+		for _, name := range needed {
+			results = append(results, Result{Component: "signer/" + name, Err: fmt.Errorf("error reading the signing secrets: %w", err)})
+		}
+		return results
+	}
+	signers := chains.AllSigners(ctx, sp, cfg)
+	for _, name := range needed {
+		s, ok := signers[name]
+		if !ok {
+			results = append(results, Result{Component: "signer/" + name, Err: errors.New("the signer could not be loaded, see the controller logs")})
+			continue
+		}
+		// Getting the public key of KMS signers calls the KMS.
+		err := check(func(ctx context.Context) error {
+			if _, err := s.PublicKey(options.WithContext(ctx)); err != nil {
+				return fmt.Errorf("error getting the public key: %w", err)
+			}
+			return nil
+		})
+		results = append(results, Result{Component: "signer/" + name, Err: err})
+	}
+	return results
+}
+
+// report records the outcome of checks in the Healthy condition of the chains-config
+// ChainsConfig, if it exists.
+func (m *Monitor) report(ctx context.Context, checks int, failed []string) error {
+	client := m.DynamicClient.Resource(v1alpha1.ChainsConfigResource)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		u, err := client.Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		cc := &v1alpha1.ChainsConfig{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cc); err != nil {
+			return err
+		}
+
+		status := cc.Status.DeepCopy()
+		if len(failed) > 0 {
+			status.MarkNotHealthy("%s", strings.Join(failed, "; "))
+		} else {
+			status.MarkHealthy("%d health checks passed", checks)
+		}
+		if equality.Semantic.DeepEqual(status, &cc.Status) {
+			return nil
+		}
+		cc.Status = *status
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cc)
+		if err != nil {
+			return err
+		}
+		_, err = client.UpdateStatus(ctx, &unstructured.Unstructured{Object: obj}, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// ready responds with 200 once the latest checks passed, and with 503 and the checks
+// that failed before the first checks ran and when any failed.
+func (m *Monitor) ready(w http.ResponseWriter, _ *http.Request) {
+	m.mu.Lock()
+	checked, results := m.checked, m.results
+	m.mu.Unlock()
+
+	if !checked {
+		http.Error(w, "health checks have not run yet", http.StatusServiceUnavailable)
+		return
+	}
+	var failed []string
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", r.Component, r.Err))
+		}
+	}
+	if len(failed) > 0 {
+		http.Error(w, strings.Join(failed, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+// backend is a storage backend whose health check returns err.
+type backend struct {
+	storage.Backend
+	err error
+}
+
+func (b *backend) CheckHealth(context.Context) error { return b.err }
+
+// unchecked is a storage backend without health checks.
+type unchecked struct {
+	storage.Backend
+}
+
+func (unchecked) StorePayload(context.Context, objects.TektonObject, []byte, string, config.StorageOpts) error {
+	return nil
+}
+
+func newConfig(t *testing.T, data map[string]string) config.Config {
+	t.Helper()
+	cfg, err := config.NewConfigFromMap(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *cfg
+}
+
+func TestCheck(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	cfg := newConfig(t, map[string]string{
+		"artifacts.taskrun.storage":     "gcs,tekton",
+		"artifacts.oci.storage":         "",
+		"artifacts.pipelinerun.storage": "docdb",
+	})
+	// The secret path has no keys, so the x509 signer can't be loaded.
+	m := &Monitor{SecretPath: t.TempDir()}
+
+	results := m.Check(ctx, cfg, map[string]storage.Backend{
+		"docdb":  &backend{},
+		"gcs":    &backend{err: errors.New("bucket not found")},
+		"tekton": unchecked{},
+	}, nil)
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.Component] = ""
+		if r.Err != nil {
+			got[r.Component] = r.Err.Error()
+		}
+	}
+	want := map[string]string{
+		"storage/docdb":  "",
+		"storage/gcs":    "bucket not found",
+		"storage/tekton": "",
+		"signer/x509":    "the signer could not be loaded, see the controller logs",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected results for %v, got %v", want, got)
+	}
+	for component, err := range want {
+		if got[component] != err {
+			t.Errorf("%s: expected error %q, got %q", component, err, got[component])
+		}
+	}
+}
+
+func TestCheck_BackendsError(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	cfg := newConfig(t, map[string]string{
+		"artifacts.taskrun.storage":     "gcs",
+		"artifacts.oci.storage":         "",
+		"artifacts.pipelinerun.storage": "",
+	})
+	m := &Monitor{SecretPath: t.TempDir()}
+
+	results := m.Check(ctx, cfg, nil, errors.New("dialing: google: could not find default credentials"))
+	if len(results) == 0 || results[0].Component != "storage/gcs" || results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "could not find default credentials") {
+		t.Errorf("expected the gcs check to fail with the error initializing it, got %+v", results)
+	}
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	cfg := newConfig(t, map[string]string{
+		"artifacts.taskrun.storage":     "gcs",
+		"artifacts.oci.storage":         "",
+		"artifacts.pipelinerun.storage": "",
+		"health.timeout":                "10ms",
+	})
+	m := &Monitor{SecretPath: t.TempDir()}
+
+	results := m.Check(ctx, cfg, map[string]storage.Backend{"gcs": &slow{}}, nil)
+	if len(results) == 0 || !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("expected the gcs check to time out, got %+v", results)
+	}
+}
+
+// slow is a storage backend whose health check waits for its context to be done.
+type slow struct {
+	storage.Backend
+}
+
+func (*slow) CheckHealth(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestSetup(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	m := &Monitor{DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme), SecretPath: writeKey(t)}
+	cfg := newConfig(t, map[string]string{
+		"artifacts.taskrun.storage":     "gcs",
+		"artifacts.oci.storage":         "",
+		"artifacts.pipelinerun.storage": "",
+		"health.enabled":                "true",
+	})
+	if err := m.Setup(ctx, cfg, map[string]storage.Backend{"gcs": &backend{err: errors.New("bucket not found")}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = m.Setup(ctx, config.Config{}, nil, nil) })
+
+	// The checks run as soon as the config is loaded.
+	waitReady(t, m, http.StatusServiceUnavailable)
+
+	// Config updates are checked without waiting for the interval.
+	if err := m.Setup(ctx, cfg, map[string]storage.Backend{"gcs": &backend{}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitReady(t, m, http.StatusOK)
+
+	cfg.Health.Enabled = false
+	if err := m.Setup(ctx, cfg, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.checked {
+		t.Error("expected the results to be cleared once the checks are disabled")
+	}
+}
+
+// writeKey returns a secret path with an x509 signing key.
+func writeKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "x509.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// waitReady waits until the readiness endpoint of m responds with status.
+func waitReady(t *testing.T, m *Monitor, status int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		w := httptest.NewRecorder()
+		m.ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if w.Code == status {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected status %d, got %d: %s", status, w.Code, w.Body.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReady(t *testing.T) {
+	m := &Monitor{}
+	w := httptest.NewRecorder()
+	m.ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected not to be ready before the first checks, got %d", w.Code)
+	}
+
+	m.checked, m.results = true, []Result{{Component: "storage/gcs"}, {Component: "signer/kms", Err: errors.New("permission denied")}}
+	w = httptest.NewRecorder()
+	m.ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "signer/kms: permission denied") {
+		t.Errorf("expected the failed check, got %d: %s", w.Code, w.Body.String())
+	}
+
+	m.results = []Result{{Component: "storage/gcs"}}
+	w = httptest.NewRecorder()
+	m.ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected to be ready, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReport(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cc := &v1alpha1.ChainsConfig{ObjectMeta: metav1.ObjectMeta{Name: config.ChainsConfig}}
+	cc.Status.InitializeConditions()
+	cc.Status.MarkApplied(map[string]string{})
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &unstructured.Unstructured{Object: u}
+	obj.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("ChainsConfig"))
+	m := &Monitor{DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme, obj)}
+
+	status := func() v1alpha1.ChainsConfigStatus {
+		t.Helper()
+		u, err := m.DynamicClient.Resource(v1alpha1.ChainsConfigResource).Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &v1alpha1.ChainsConfig{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Status
+	}
+
+	if err := m.report(ctx, 2, []string{"storage/gcs: bucket not found"}); err != nil {
+		t.Fatal(err)
+	}
+	s := status()
+	c := s.GetCondition(v1alpha1.ConditionHealthy)
+	if c == nil || c.Status != corev1.ConditionFalse || c.Reason != v1alpha1.ReasonChecksFailed || c.Message != "storage/gcs: bucket not found" {
+		t.Fatalf("unexpected Healthy condition %+v", c)
+	}
+	if !s.IsReady() {
+		t.Error("failed checks must not affect Ready")
+	}
+
+	if err := m.report(ctx, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	s = status()
+	if c := s.GetCondition(v1alpha1.ConditionHealthy); c == nil || c.Status != corev1.ConditionTrue || c.Reason != v1alpha1.ReasonChecksPassed {
+		t.Fatalf("unexpected Healthy condition %+v", c)
+	}
+}
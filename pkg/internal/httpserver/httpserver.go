@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package httpserver runs the HTTP servers of the controller that are configured in the
+// chains config, like the readiness endpoint, so that they follow its updates.
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// readHeaderTimeout bounds how long clients may take to send request headers.
+	readHeaderTimeout = 10 * time.Second
+	// shutdownTimeout bounds how long requests in flight may take when the address changes.
+	shutdownTimeout = 5 * time.Second
+)
+
+// Server serves a handler on the address of the last call to Serve or ServeTLS.
+type Server struct {
+	// Name names what is served in errors and logs, like "pprof endpoints".
+	Name string
+
+	mu     sync.Mutex
+	served endpoint
+	server *http.Server
+}
+
+// endpoint is where and how a handler is served.
+type endpoint struct {
+	address, certPath, keyPath string
+}
+
+// Serve serves handler on address. It is safe to call on every config update: the
+// server is only restarted, with handler, when address changed, and stopped when it is
+// empty.
+func (s *Server) Serve(ctx context.Context, address string, handler http.Handler) error {
+	return s.serve(ctx, endpoint{address: address}, handler)
+}
+
+// ServeTLS is like Serve, serving handler with the PEM certificate and key at certPath
+// and keyPath, which are read again for each connection so that they can be rotated in
+// place. The server is also restarted when their paths changed.
+func (s *Server) ServeTLS(ctx context.Context, address, certPath, keyPath string, handler http.Handler) error {
+	if address != "" && (certPath == "" || keyPath == "") {
+		return fmt.Errorf("%s is only served with a TLS certificate and key", s.Name)
+	}
+	return s.serve(ctx, endpoint{address: address, certPath: certPath, keyPath: keyPath}, handler)
+}
+
+func (s *Server) serve(ctx context.Context, e endpoint, handler http.Handler) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e == s.served {
+		return nil
+	}
+	if s.server != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			logging.FromContext(ctx).Warnf("error stopping %s on %s: %v", s.Name, s.served.address, err)
+		}
+		s.server = nil
+	}
+	s.served = endpoint{}
+	if e.address == "" {
+		return nil
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	if e.certPath != "" {
+		if _, err := tls.LoadX509KeyPair(e.certPath, e.keyPath); err != nil {
+			return fmt.Errorf("loading TLS certificate: %w", err)
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				cert, err := tls.LoadX509KeyPair(e.certPath, e.keyPath)
+				return &cert, err
+			},
+		}
+	}
+	ln, err := net.Listen("tcp", e.address)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", e.address, err)
+	}
+	s.server, s.served = srv, e
+	name := s.Name
+	go func() {
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ServeTLS(ln, "", "")
+		} else {
+			err = srv.Serve(ln)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			logging.FromContext(ctx).Errorf("%s on %s stopped: %v", name, ln.Addr(), err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestServe(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	s := &Server{Name: "test endpoint"}
+	t.Cleanup(func() { _ = s.Serve(ctx, "", nil) })
+
+	get := func(address string) (string, error) {
+		t.Helper()
+		resp, err := http.Get("http://" + address)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+	handler := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, body)
+		})
+	}
+
+	first := freeAddress(t)
+	if err := s.Serve(ctx, first, handler("first")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := get(first); err != nil || got != "first" {
+		t.Fatalf("GET %s = %q, %v, want %q", first, got, err, "first")
+	}
+
+	// The server isn't restarted when the address is unchanged.
+	if err := s.Serve(ctx, first, handler("unchanged")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := get(first); err != nil || got != "first" {
+		t.Errorf("GET %s = %q, %v after serving on the same address, want %q", first, got, err, "first")
+	}
+
+	second := freeAddress(t)
+	if err := s.Serve(ctx, second, handler("second")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := get(second); err != nil || got != "second" {
+		t.Errorf("GET %s = %q, %v, want %q", second, got, err, "second")
+	}
+	if _, err := get(first); err == nil {
+		t.Errorf("expected %s to be stopped once the address changed", first)
+	}
+
+	if err := s.Serve(ctx, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(second); err == nil {
+		t.Errorf("expected %s to be stopped once the address is empty", second)
+	}
+}
+
+func TestServe_ListenError(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	s := &Server{Name: "test endpoint"}
+	if err := s.Serve(ctx, ln.Addr().String(), http.NotFoundHandler()); err == nil {
+		t.Fatal("expected an error listening on an address in use")
+	}
+	// The address is served once it is free.
+	ln.Close()
+	if err := s.Serve(ctx, ln.Addr().String(), http.NotFoundHandler()); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Serve(ctx, "", nil)
+}
+
+func TestServeTLS(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	s := &Server{Name: "test endpoint"}
+	t.Cleanup(func() { _ = s.Serve(ctx, "", nil) })
+
+	address := freeAddress(t)
+	if err := s.ServeTLS(ctx, address, "", "", http.NotFoundHandler()); err == nil {
+		t.Fatal("expected an error serving TLS without a certificate and key")
+	}
+
+	certPath, keyPath, pool := writeTLS(t)
+	if err := s.ServeTLS(ctx, address, certPath, keyPath, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})); err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+	resp, err := client.Get("https://" + address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// Plain HTTP requests are answered with an error by the TLS server.
+	if resp, err := http.Get("http://" + address); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("got status %d for a plain HTTP request, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+}
+
+// writeTLS writes a self-signed certificate of 127.0.0.1 and its key, and returns their
+// paths and a pool with the certificate.
+func writeTLS(t *testing.T) (string, string, *x509.CertPool) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return certPath, keyPath, pool
+}
+
+// freeAddress returns a local address nothing listens on.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
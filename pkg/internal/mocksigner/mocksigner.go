@@ -24,9 +24,12 @@ import (
 
 type Signer struct {
 	Signed bool
+	// Obj is the last object signed.
+	Obj objects.TektonObject
 }
 
 func (m *Signer) Sign(ctx context.Context, obj objects.TektonObject) error {
 	m.Signed = true
+	m.Obj = obj
 	return nil
 }
@@ -26,6 +26,37 @@ func GetAnnotationsPatch(newAnnotations map[string]string) ([]byte, error) {
 	return json.Marshal(p)
 }
 
+// GetAnnotationsRemovalPatch returns a merge patch that removes the annotations with the given keys
+func GetAnnotationsRemovalPatch(keys []string) ([]byte, error) {
+	annotations := make(map[string]*string, len(keys))
+	for _, key := range keys {
+		annotations[key] = nil
+	}
+	return json.Marshal(removalPatch{
+		Metadata: removalMetadata{
+			Annotations: annotations,
+		},
+	})
+}
+
+// GetAnnotationsReplacePatch returns a merge patch that sets newAnnotations and removes
+// the annotations with the keys of removed
+func GetAnnotationsReplacePatch(newAnnotations map[string]string, removed []string) ([]byte, error) {
+	annotations := make(map[string]*string, len(newAnnotations)+len(removed))
+	for _, key := range removed {
+		annotations[key] = nil
+	}
+	for key, val := range newAnnotations {
+		val := val
+		annotations[key] = &val
+	}
+	return json.Marshal(removalPatch{
+		Metadata: removalMetadata{
+			Annotations: annotations,
+		},
+	})
+}
+
 // These are used to get proper json formatting
 type patch struct {
 	Metadata metadata `json:"metadata,omitempty"`
@@ -33,3 +64,11 @@ type patch struct {
 type metadata struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 }
+
+// Null annotations are removed by merge patches.
+type removalPatch struct {
+	Metadata removalMetadata `json:"metadata,omitempty"`
+}
+type removalMetadata struct {
+	Annotations map[string]*string `json:"annotations,omitempty"`
+}
@@ -61,3 +61,42 @@ func TestGetAnnotationsPatch(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAnnotationsRemovalPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want string
+	}{
+		{
+			name: "empty",
+			want: `{"metadata":{}}`,
+		},
+		{
+			name: "many",
+			keys: []string{"foo", "baz"},
+			want: `{"metadata":{"annotations":{"baz":null,"foo":null}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetAnnotationsRemovalPatch(tt.keys)
+			if err != nil {
+				t.Fatalf("GetAnnotationsRemovalPatch() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("GetAnnotationsRemovalPatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAnnotationsReplacePatch(t *testing.T) {
+	got, err := GetAnnotationsReplacePatch(map[string]string{"foo": "bar"}, []string{"baz"})
+	if err != nil {
+		t.Fatalf("GetAnnotationsReplacePatch() error = %v", err)
+	}
+	if want := `{"metadata":{"annotations":{"baz":null,"foo":"bar"}}}`; string(got) != want {
+		t.Errorf("GetAnnotationsReplacePatch() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package payload builds the provenance payloads Chains signs from TaskRuns and
+// PipelineRuns, without a controller or a cluster, so CLI tools and CI plugins can
+// reuse the provenance Chains generates:
+//
+//	raw, err := payload.ForTaskRun(ctx, tr, payload.Options{Format: payload.FormatSLSAv1})
+//
+// Unlike the formatters Chains uses internally, its API only depends on the Tekton
+// APIs and is kept stable across releases.
+package payload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+
+	// Register the built-in formats.
+	_ "github.com/tektoncd/chains/pkg/chains/formats/all"
+)
+
+// Formats of the payloads, as configured in the artifacts.*.format keys of Chains.
+const (
+	// FormatInToto and FormatSLSAv1 are in-toto statements with a SLSA v0.2 predicate.
+	FormatInToto = string(formats.PayloadTypeInTotoIte6)
+	FormatSLSAv1 = string(formats.PayloadTypeSlsav1)
+	// FormatSLSAv2alpha1 is an in-toto statement with a SLSA v0.2 predicate holding the
+	// complete build instructions. It only supports TaskRuns.
+	FormatSLSAv2alpha1 = string(formats.PayloadTypeSlsav2alpha1)
+	// FormatSLSAv2alpha2 is an in-toto statement with a SLSA v1.0 predicate.
+	FormatSLSAv2alpha2 = string(formats.PayloadTypeSlsav2alpha2)
+)
+
+// DefaultBuilderID is the builder ID of the payloads if Options doesn't set one.
+const DefaultBuilderID = "https://tekton.dev/chains/v2"
+
+// Options configures the payloads that are built.
+type Options struct {
+	// Format is the format of the payloads, e.g. FormatSLSAv1.
+	Format string
+	// BuilderID is the ID of the builder in the predicate. DefaultBuilderID is used when
+	// it is empty.
+	BuilderID string
+	// DeepInspection lists the artifacts built by the TaskRuns of PipelineRuns as
+	// subjects of the PipelineRun payloads.
+	DeepInspection bool
+}
+
+// Formats returns the formats payloads can be built in.
+func Formats() []string {
+	return []string{FormatInToto, FormatSLSAv1, FormatSLSAv2alpha1, FormatSLSAv2alpha2}
+}
+
+// ForTaskRun returns the JSON payload for tr.
+func ForTaskRun(ctx context.Context, tr *v1beta1.TaskRun, opts Options) ([]byte, error) {
+	return build(ctx, objects.NewTaskRunObject(tr), opts)
+}
+
+// ForPipelineRun returns the JSON payload for pr. taskRuns are the TaskRuns of pr,
+// whose steps and results the payload is built from: the status of a PipelineRun only
+// references them. They are matched to the tasks of pr through its childReferences, or
+// by their tekton.dev/pipelineTask label, as set by Tekton, if it has none.
+func ForPipelineRun(ctx context.Context, pr *v1beta1.PipelineRun, taskRuns []*v1beta1.TaskRun, opts Options) ([]byte, error) {
+	pro := objects.NewPipelineRunObject(pr)
+	for _, tr := range taskRuns {
+		pro.AppendTaskRun(tr)
+	}
+	return build(ctx, pro, opts)
+}
+
+func build(ctx context.Context, obj objects.TektonObject, opts Options) ([]byte, error) {
+	if !supported(opts.Format) {
+		return nil, fmt.Errorf("unsupported format %q, expected one of %v", opts.Format, Formats())
+	}
+	var cfg config.Config
+	cfg.Builder.ID = opts.BuilderID
+	if cfg.Builder.ID == "" {
+		cfg.Builder.ID = DefaultBuilderID
+	}
+	cfg.Artifacts.PipelineRuns.DeepInspectionEnabled = opts.DeepInspection
+
+	payloader, err := formats.GetPayloader(config.PayloadType(opts.Format), cfg)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := payloader.CreatePayload(ctx, obj)
+	if err != nil {
+		return nil, fmt.Errorf("building %s payload for %s %s/%s: %w", opts.Format, obj.GetKindName(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	return json.Marshal(payload)
+}
+
+func supported(format string) bool {
+	for _, f := range Formats() {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
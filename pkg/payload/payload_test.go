@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package payload
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+const digest = "sha256:05f95b26ed10668b7183c1e2da98610e91372fa9f510046d4ce5812addad86b5"
+
+type statement struct {
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+func taskRun() *v1beta1.TaskRun {
+	return &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: "uid"},
+		Status: v1beta1.TaskRunStatus{
+			Status: duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: "True"}}},
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+				TaskRunResults: []v1beta1.TaskRunResult{
+					{Name: "IMAGE_URL", Value: *v1beta1.NewStructuredValues("gcr.io/foo/bar")},
+					{Name: "IMAGE_DIGEST", Value: *v1beta1.NewStructuredValues(digest)},
+				},
+				TaskSpec: &v1beta1.TaskSpec{},
+			},
+		},
+	}
+}
+
+func parse(t *testing.T, raw []byte) statement {
+	t.Helper()
+	var st statement
+	if err := json.Unmarshal(raw, &st); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestForTaskRun(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              Options
+		wantPredicateType string
+		wantBuilderID     string
+	}{{
+		name:              "slsa/v1",
+		opts:              Options{Format: FormatSLSAv1},
+		wantPredicateType: "https://slsa.dev/provenance/v0.2",
+		wantBuilderID:     DefaultBuilderID,
+	}, {
+		name:              "in-toto with builder id",
+		opts:              Options{Format: FormatInToto, BuilderID: "https://example.com/builder"},
+		wantPredicateType: "https://slsa.dev/provenance/v0.2",
+		wantBuilderID:     "https://example.com/builder",
+	}, {
+		name:              "slsa/v2alpha1",
+		opts:              Options{Format: FormatSLSAv2alpha1},
+		wantPredicateType: "https://slsa.dev/provenance/v0.2",
+		wantBuilderID:     DefaultBuilderID,
+	}, {
+		name:              "slsa/v2alpha2",
+		opts:              Options{Format: FormatSLSAv2alpha2},
+		wantPredicateType: "https://slsa.dev/provenance/v1",
+		wantBuilderID:     DefaultBuilderID,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := ForTaskRun(context.Background(), taskRun(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			st := parse(t, raw)
+			if st.PredicateType != tt.wantPredicateType {
+				t.Errorf("got predicate type %q, want %q", st.PredicateType, tt.wantPredicateType)
+			}
+			builderID := st.Predicate.Builder.ID
+			if builderID == "" {
+				builderID = st.Predicate.RunDetails.Builder.ID
+			}
+			if builderID != tt.wantBuilderID {
+				t.Errorf("got builder id %q, want %q", builderID, tt.wantBuilderID)
+			}
+			var subjects []string
+			for _, s := range st.Subject {
+				subjects = append(subjects, s.Name+"@sha256:"+s.Digest["sha256"])
+			}
+			if diff := cmp.Diff([]string{"gcr.io/foo/bar@" + digest}, subjects); diff != "" {
+				t.Errorf("subjects (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestForPipelineRun(t *testing.T) {
+	pr := &v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: "ns", UID: "pr-uid"},
+		Status: v1beta1.PipelineRunStatus{
+			Status: duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: "True"}}},
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				ChildReferences: []v1beta1.ChildStatusReference{{Name: "build", PipelineTaskName: "build"}},
+				PipelineSpec:    &v1beta1.PipelineSpec{Tasks: []v1beta1.PipelineTask{{Name: "build"}}},
+			},
+		},
+	}
+
+	for _, deep := range []bool{false, true} {
+		tr := taskRun()
+		tr.Labels = map[string]string{"tekton.dev/pipelineTask": "build"}
+		tr.Status.StartTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+		tr.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+		raw, err := ForPipelineRun(context.Background(), pr, []*v1beta1.TaskRun{tr}, Options{Format: FormatSLSAv1, DeepInspection: deep})
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := parse(t, raw)
+		if got, want := len(st.Subject), map[bool]int{false: 0, true: 1}[deep]; got != want {
+			t.Errorf("deep inspection %t: got %d subjects, want %d", deep, got, want)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	if _, err := ForTaskRun(context.Background(), taskRun(), Options{Format: "simplesigning"}); err == nil {
+		t.Error("expected an error for the simplesigning format")
+	}
+	if _, err := ForPipelineRun(context.Background(), &v1beta1.PipelineRun{}, nil, Options{Format: FormatSLSAv2alpha1}); err == nil {
+		t.Error("expected an error for PipelineRuns in the slsa/v2alpha1 format")
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy generates sigstore policy-controller ClusterImagePolicies that
+// admit the images whose signatures and attestations Chains stores in registries,
+// so admission policy can follow the signers and formats Chains is configured with.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	slsa02 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	slsa1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/formats/openvex"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/chains/storage/oci"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+const (
+	APIVersion = "policy.sigstore.dev/v1beta1"
+	Kind       = "ClusterImagePolicy"
+	// DefaultName is the name of the policy if Options doesn't set one.
+	DefaultName = "tekton-chains"
+)
+
+// ClusterImagePolicy is the subset of the policy-controller ClusterImagePolicy
+// resource that is generated.
+type ClusterImagePolicy struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+	Spec       Spec     `json:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name"`
+}
+
+type Spec struct {
+	Images      []ImagePattern `json:"images"`
+	Authorities []Authority    `json:"authorities"`
+}
+
+type ImagePattern struct {
+	Glob string `json:"glob"`
+}
+
+// Authority is a source of signatures or attestations images must have.
+type Authority struct {
+	Name         string        `json:"name"`
+	Key          *Key          `json:"key,omitempty"`
+	Keyless      *Keyless      `json:"keyless,omitempty"`
+	CTLog        *TLog         `json:"ctlog,omitempty"`
+	Attestations []Attestation `json:"attestations,omitempty"`
+}
+
+// Key is the public key, or the KMS key, signatures are verified with.
+type Key struct {
+	Data          string `json:"data,omitempty"`
+	KMS           string `json:"kms,omitempty"`
+	HashAlgorithm string `json:"hashAlgorithm,omitempty"`
+}
+
+// Keyless verifies signatures with certificates issued by Fulcio.
+type Keyless struct {
+	URL        string     `json:"url,omitempty"`
+	Identities []Identity `json:"identities"`
+}
+
+// Identity is an identity certificates must be issued to.
+type Identity struct {
+	Issuer        string `json:"issuer,omitempty"`
+	Subject       string `json:"subject,omitempty"`
+	IssuerRegExp  string `json:"issuerRegExp,omitempty"`
+	SubjectRegExp string `json:"subjectRegExp,omitempty"`
+}
+
+// TLog is the transparency log signatures must be recorded in.
+type TLog struct {
+	URL string `json:"url,omitempty"`
+}
+
+// Attestation is an attestation images must have.
+type Attestation struct {
+	Name          string `json:"name"`
+	PredicateType string `json:"predicateType"`
+}
+
+// Options configures the generated policy.
+type Options struct {
+	// Name is the name of the policy. DefaultName is used when it is empty.
+	Name string
+	// Images are the globs of the images the policy applies to. All images are
+	// matched when it is empty.
+	Images []string
+	// PublicKeys are the PEM encoded public keys of the signers that sign with a
+	// key, by signer type, e.g. "x509".
+	PublicKeys map[string][]byte
+	// Identities are the identities the certificates of keyless signatures are
+	// issued to. Required if Fulcio is enabled.
+	Identities []Identity
+}
+
+// requirement is what a signer signs in registries.
+type requirement struct {
+	signatures     bool
+	predicateTypes map[string]struct{}
+}
+
+// Generate returns the ClusterImagePolicy that requires the signatures and
+// attestations Chains stores in OCI registries with cfg.
+//
+// policy-controller admits an image if any authority of a policy is satisfied,
+// so images that only carry some of them are admitted too.
+func Generate(cfg config.Config, opts Options) (*ClusterImagePolicy, error) {
+	reqs := map[string]*requirement{}
+	get := func(signer string) *requirement {
+		if reqs[signer] == nil {
+			reqs[signer] = &requirement{predicateTypes: map[string]struct{}{}}
+		}
+		return reqs[signer]
+	}
+	inRegistry := func(a config.Artifact) bool {
+		return a.Enabled() && a.StorageBackend.Has(oci.StorageBackendOCI)
+	}
+
+	if inRegistry(cfg.Artifacts.OCI) {
+		get(cfg.Artifacts.OCI.Signer).signatures = true
+	}
+	for _, a := range []config.Artifact{cfg.Artifacts.TaskRuns, cfg.Artifacts.PipelineRuns} {
+		if !inRegistry(a) {
+			continue
+		}
+		pt, err := predicateType(config.PayloadType(a.Format))
+		if err != nil {
+			return nil, err
+		}
+		get(a.Signer).predicateTypes[pt] = struct{}{}
+	}
+	va := &artifacts.VEXArtifact{}
+	if va.Enabled(cfg) && inRegistry(cfg.Artifacts.VEX) {
+		get(va.Signer(cfg)).predicateTypes[openvex.PredicateType] = struct{}{}
+	}
+	ca := &artifacts.CustomRunArtifact{}
+	if ca.Enabled(cfg) && inRegistry(cfg.Artifacts.CustomRuns) {
+		pt, err := predicateType(ca.PayloadFormat(cfg))
+		if err != nil {
+			return nil, err
+		}
+		get(ca.Signer(cfg)).predicateTypes[pt] = struct{}{}
+	}
+	if len(reqs) == 0 {
+		return nil, errors.New("Chains doesn't store signatures or attestations in OCI registries with this configuration")
+	}
+
+	p := &ClusterImagePolicy{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata:   Metadata{Name: opts.Name},
+	}
+	if p.Metadata.Name == "" {
+		p.Metadata.Name = DefaultName
+	}
+	for _, glob := range opts.Images {
+		p.Spec.Images = append(p.Spec.Images, ImagePattern{Glob: glob})
+	}
+	if len(p.Spec.Images) == 0 {
+		p.Spec.Images = []ImagePattern{{Glob: "**"}}
+	}
+
+	signers := make([]string, 0, len(reqs))
+	for s := range reqs {
+		signers = append(signers, s)
+	}
+	sort.Strings(signers)
+	for _, s := range signers {
+		base, err := authority(cfg, s, opts)
+		if err != nil {
+			return nil, err
+		}
+		req := reqs[s]
+		if req.signatures {
+			a := base
+			a.Name = s + "-signatures"
+			p.Spec.Authorities = append(p.Spec.Authorities, a)
+		}
+		if len(req.predicateTypes) > 0 {
+			a := base
+			a.Name = s + "-attestations"
+			for _, pt := range sortedKeys(req.predicateTypes) {
+				a.Attestations = append(a.Attestations, Attestation{Name: attestationName(pt), PredicateType: pt})
+			}
+			p.Spec.Authorities = append(p.Spec.Authorities, a)
+		}
+	}
+	return p, nil
+}
+
+// authority returns the authority that verifies the signatures of the given signer.
+func authority(cfg config.Config, signer string, opts Options) (Authority, error) {
+	var a Authority
+	switch {
+	case signer == signing.TypeKMS:
+		if cfg.Signers.KMS.KMSRef == "" {
+			return a, errors.New("signers.kms.kmsref is not configured")
+		}
+		a.Key = &Key{KMS: cfg.Signers.KMS.KMSRef}
+	case signer == signing.TypeX509 && cfg.Signers.X509.FulcioEnabled:
+		if len(opts.Identities) == 0 {
+			return a, errors.New("keyless signing with Fulcio is enabled, the identities of the certificates are required")
+		}
+		a.Keyless = &Keyless{URL: cfg.Signers.X509.FulcioAddr, Identities: opts.Identities}
+	case signer == signing.TypeX509:
+		key, ok := opts.PublicKeys[signer]
+		if !ok {
+			return a, fmt.Errorf("the public key of the %s signer is required", signer)
+		}
+		a.Key = &Key{Data: string(key), HashAlgorithm: "sha256"}
+	default:
+		return a, fmt.Errorf("unsupported signer %q", signer)
+	}
+	if cfg.Transparency.Enabled {
+		a.CTLog = &TLog{URL: cfg.Transparency.URL}
+	}
+	return a, nil
+}
+
+// predicateType returns the predicate type of the attestations of the given format.
+func predicateType(format config.PayloadType) (string, error) {
+	switch format {
+	case formats.PayloadTypeInTotoIte6, formats.PayloadTypeSlsav1, formats.PayloadTypeSlsav2alpha1:
+		return slsa02.PredicateSLSAProvenance, nil
+	case formats.PayloadTypeSlsav2alpha2:
+		return slsa1.PredicateSLSAProvenance, nil
+	default:
+		return "", fmt.Errorf("format %q doesn't produce attestations", format)
+	}
+}
+
+func attestationName(predicateType string) string {
+	switch predicateType {
+	case slsa02.PredicateSLSAProvenance:
+		return "slsa-provenance-v0.2"
+	case slsa1.PredicateSLSAProvenance:
+		return "slsa-provenance-v1"
+	case openvex.PredicateType:
+		return "openvex"
+	default:
+		return predicateType
+	}
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
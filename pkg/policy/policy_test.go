@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+const testKey = "-----BEGIN PUBLIC KEY-----\nkey\n-----END PUBLIC KEY-----\n"
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]string
+		opts Options
+		want Spec
+	}{{
+		name: "signatures only",
+		data: map[string]string{},
+		opts: Options{PublicKeys: map[string][]byte{"x509": []byte(testKey)}},
+		want: Spec{
+			Images: []ImagePattern{{Glob: "**"}},
+			Authorities: []Authority{{
+				Name: "x509-signatures",
+				Key:  &Key{Data: testKey, HashAlgorithm: "sha256"},
+			}},
+		},
+	}, {
+		name: "attestations in oci with transparency",
+		data: map[string]string{
+			"artifacts.taskrun.format":      "in-toto",
+			"artifacts.taskrun.storage":     "oci",
+			"artifacts.pipelinerun.format":  "slsa/v2alpha2",
+			"artifacts.pipelinerun.storage": "tekton,oci",
+			"artifacts.vex.storage":         "oci",
+			"transparency.enabled":          "true",
+		},
+		opts: Options{Images: []string{"registry.example.com/**"}, PublicKeys: map[string][]byte{"x509": []byte(testKey)}},
+		want: Spec{
+			Images: []ImagePattern{{Glob: "registry.example.com/**"}},
+			Authorities: []Authority{{
+				Name:  "x509-signatures",
+				Key:   &Key{Data: testKey, HashAlgorithm: "sha256"},
+				CTLog: &TLog{URL: "https://rekor.sigstore.dev"},
+			}, {
+				Name:  "x509-attestations",
+				Key:   &Key{Data: testKey, HashAlgorithm: "sha256"},
+				CTLog: &TLog{URL: "https://rekor.sigstore.dev"},
+				Attestations: []Attestation{
+					{Name: "openvex", PredicateType: "https://openvex.dev/ns"},
+					{Name: "slsa-provenance-v0.2", PredicateType: "https://slsa.dev/provenance/v0.2"},
+					{Name: "slsa-provenance-v1", PredicateType: "https://slsa.dev/provenance/v1"},
+				},
+			}},
+		},
+	}, {
+		name: "kms and keyless signers",
+		data: map[string]string{
+			"artifacts.oci.signer":        "kms",
+			"artifacts.taskrun.storage":   "oci",
+			"signers.kms.kmsref":          "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k",
+			"signers.x509.fulcio.enabled": "true",
+		},
+		opts: Options{Identities: []Identity{{Issuer: "https://accounts.google.com", Subject: "chains@example.com"}}},
+		want: Spec{
+			Images: []ImagePattern{{Glob: "**"}},
+			Authorities: []Authority{{
+				Name: "kms-signatures",
+				Key:  &Key{KMS: "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k"},
+			}, {
+				Name: "x509-attestations",
+				Keyless: &Keyless{
+					URL:        "https://fulcio.sigstore.dev",
+					Identities: []Identity{{Issuer: "https://accounts.google.com", Subject: "chains@example.com"}},
+				},
+				Attestations: []Attestation{{Name: "slsa-provenance-v0.2", PredicateType: "https://slsa.dev/provenance/v0.2"}},
+			}},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.NewConfigFromMap(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Generate(*cfg, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.APIVersion != APIVersion || got.Kind != Kind || got.Metadata.Name != DefaultName {
+				t.Errorf("unexpected type or name: %s %s %s", got.APIVersion, got.Kind, got.Metadata.Name)
+			}
+			if diff := cmp.Diff(tt.want, got.Spec); diff != "" {
+				t.Errorf("Generate() -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]string
+		opts Options
+	}{{
+		name: "nothing in oci",
+		data: map[string]string{"artifacts.oci.storage": "tekton"},
+	}, {
+		name: "missing public key",
+		data: map[string]string{},
+	}, {
+		name: "missing identities",
+		data: map[string]string{"signers.x509.fulcio.enabled": "true"},
+	}, {
+		name: "missing kms ref",
+		data: map[string]string{"artifacts.oci.signer": "kms"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.NewConfigFromMap(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Generate(*cfg, tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
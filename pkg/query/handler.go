@@ -0,0 +1,443 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/logging"
+)
+
+// AttestationsPath is the path attestations are queried on.
+const AttestationsPath = "/v1/attestations"
+
+const (
+	// defaultMaxResults is the number of attestations returned for a query when the config doesn't say.
+	defaultMaxResults = 100
+	// defaultMaxRuns is the number of runs whose attestations are read for a query when
+	// the config doesn't say.
+	defaultMaxRuns = 500
+
+	// attestationsSubresource is the subresource of runs callers must be allowed to get
+	// to query their attestations.
+	attestationsSubresource = "attestations"
+)
+
+// Response is the body of the responses to queries.
+type Response struct {
+	Attestations []Attestation `json:"attestations"`
+	// Truncated is set if more attestations matched than were returned, or more runs may
+	// have matched than were read.
+	Truncated bool `json:"truncated,omitempty"`
+	// Errors are the storage backends that could not be read, for the runs that matched.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Attestation is a signed payload of a run.
+type Attestation struct {
+	Run Run `json:"run"`
+	// BuildGroup is the build group of the run, if it is grouped.
+	BuildGroup string `json:"buildGroup,omitempty"`
+	Backend    string `json:"backend"`
+	Key        string `json:"key"`
+	// PredicateType and Subjects are set for in-toto statements.
+	PredicateType string    `json:"predicateType,omitempty"`
+	Subjects      []Subject `json:"subjects,omitempty"`
+	// Payload is the signed payload, as JSON if it is valid JSON and as a string otherwise.
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"`
+}
+
+// Run identifies the run an attestation is for.
+type Run struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UID       string `json:"uid"`
+}
+
+// Subject is a subject of an in-toto statement.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// statement is the part of an in-toto statement queries are answered from.
+type statement struct {
+	Type          string    `json:"_type"`
+	PredicateType string    `json:"predicateType"`
+	Subject       []Subject `json:"subject"`
+}
+
+// query are the parameters of a request.
+type query struct {
+	namespace     string
+	uid           types.UID
+	digest        string
+	predicateType string
+	buildGroup    string
+}
+
+// Handler answers queries for the attestations of the signed runs in the listers,
+// retrieving them from the storage backends the runs are configured with. Callers are
+// authenticated and authorized with KubeClient.
+type Handler struct {
+	TaskRuns     listers.TaskRunLister
+	PipelineRuns listers.PipelineRunLister
+	CustomRuns   listers.CustomRunLister
+	KubeClient   kubernetes.Interface
+	// State returns the current config and storage backends.
+	State func() (config.Config, map[string]storage.Backend)
+}
+
+// ServeHTTP answers GET requests on AttestationsPath with the attestations matching
+// all of the uid, digest, predicateType and buildGroup parameters given, in the namespace
+// parameter if given, of the runs the caller may get the attestations of. Attestations
+// of the most recently completed runs come first.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != AttestationsPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	params := r.URL.Query()
+	q := query{
+		namespace:     params.Get("namespace"),
+		uid:           types.UID(params.Get("uid")),
+		digest:        params.Get("digest"),
+		predicateType: params.Get("predicateType"),
+		buildGroup:    params.Get("buildGroup"),
+	}
+	if q.uid == "" && q.digest == "" && q.predicateType == "" && q.buildGroup == "" {
+		http.Error(w, "one of the uid, digest, predicateType or buildGroup parameters is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	user, status, err := h.authenticate(r)
+	if err != nil {
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	cfg, backends := h.State()
+	limit := cfg.Query.MaxResults
+	if limit <= 0 {
+		limit = defaultMaxResults
+	}
+	maxRuns := cfg.Query.MaxRuns
+	if maxRuns <= 0 {
+		maxRuns = defaultMaxRuns
+	}
+	runs, err := h.runs(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := Response{Attestations: []Attestation{}}
+	authz := &authorizer{client: h.KubeClient, user: user, reviewed: map[string]bool{}}
+	read := 0
+	for _, obj := range runs {
+		allowed, err := authz.allowed(ctx, obj.GetKindName(), obj.GetNamespace())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			continue
+		}
+		// Reading the attestations of every run of a broad query would read every
+		// payload in the backends.
+		if read == maxRuns {
+			resp.Truncated = true
+			break
+		}
+		read++
+		stored, errs, err := chains.RetrieveAttestations(ctx, obj, backends, cfg)
+		if err != nil {
+			continue
+		}
+		for backend, err := range errs {
+			logging.FromContext(ctx).Warnf("query: error retrieving attestations of %s %s/%s from %s: %v", obj.GetKindName(), obj.GetNamespace(), obj.GetName(), backend, err)
+			resp.Errors = append(resp.Errors, fmt.Sprintf("%s %s/%s: %s: %v", obj.GetKindName(), obj.GetNamespace(), obj.GetName(), backend, err))
+		}
+		for _, s := range stored {
+			a, ok := q.match(obj, s)
+			if !ok {
+				continue
+			}
+			if len(resp.Attestations) == limit {
+				resp.Truncated = true
+				break
+			}
+			resp.Attestations = append(resp.Attestations, a)
+		}
+		if resp.Truncated {
+			break
+		}
+	}
+	sort.Strings(resp.Errors)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.FromContext(ctx).Warnf("query: error writing response: %v", err)
+	}
+}
+
+// runs returns the signed runs that may have attestations matching q, most recently
+// completed first. Runs are only known to match once their attestations were read.
+func (h *Handler) runs(q query) ([]objects.TektonObject, error) {
+	var all []objects.TektonObject
+	taskRuns, err := h.TaskRuns.TaskRuns(q.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, tr := range taskRuns {
+		all = append(all, objects.NewTaskRunObject(tr))
+	}
+	pipelineRuns, err := h.PipelineRuns.PipelineRuns(q.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range pipelineRuns {
+		all = append(all, objects.NewPipelineRunObject(pr))
+	}
+	customRuns, err := h.CustomRuns.CustomRuns(q.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range customRuns {
+		all = append(all, objects.NewCustomRunObject(cr))
+	}
+
+	var runs []objects.TektonObject
+	for _, obj := range all {
+		if obj.GetAnnotations()[chains.ChainsAnnotation] != "true" {
+			continue
+		}
+		if q.uid != "" && obj.GetUID() != q.uid {
+			continue
+		}
+		if q.buildGroup != "" && obj.GetAnnotations()[attest.BuildGroupAnnotation] != q.buildGroup {
+			continue
+		}
+		if q.digest != "" && !h.mentions(obj, digestHex(q.digest)) {
+			continue
+		}
+		runs = append(runs, obj)
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return completion(runs[i]).After(completion(runs[j]))
+	})
+	return runs, nil
+}
+
+// mentions returns whether hex appears in the results of obj or, for PipelineRuns, of
+// their TaskRuns. Subjects are read from results, so runs that don't mention a digest
+// can't have attestations about it.
+func (h *Handler) mentions(obj objects.TektonObject, hex string) bool {
+	results := [][]objects.Result{obj.GetResults()}
+	if pro, ok := obj.(*objects.PipelineRunObject); ok {
+		selector := labels.SelectorFromSet(labels.Set{pipeline.PipelineRunLabelKey: pro.Name})
+		trs, err := h.TaskRuns.TaskRuns(pro.Namespace).List(selector)
+		if err == nil {
+			for _, tr := range trs {
+				results = append(results, objects.NewTaskRunObject(tr).GetResults())
+			}
+		}
+	}
+	for _, r := range results {
+		raw, err := json.Marshal(r)
+		if err == nil && bytes.Contains(raw, []byte(hex)) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate returns the user of the bearer token of r, reviewed by the API server,
+// or the status to respond with if it can't.
+func (h *Handler) authenticate(r *http.Request) (authenticationv1.UserInfo, int, error) {
+	var token string
+	if v := r.Header.Get("Authorization"); strings.HasPrefix(v, "Bearer ") {
+		token = strings.TrimPrefix(v, "Bearer ")
+	}
+	if token == "" {
+		return authenticationv1.UserInfo{}, http.StatusUnauthorized, errors.New("a bearer token is required")
+	}
+	review, err := h.KubeClient.AuthenticationV1().TokenReviews().Create(r.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return authenticationv1.UserInfo{}, http.StatusInternalServerError, fmt.Errorf("reviewing token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return authenticationv1.UserInfo{}, http.StatusUnauthorized, errors.New("invalid bearer token")
+	}
+	return review.Status.User, http.StatusOK, nil
+}
+
+// authorizer reviews whether a user may get the attestations of the runs of a kind in a
+// namespace, once per kind and namespace of a query.
+type authorizer struct {
+	client kubernetes.Interface
+	user   authenticationv1.UserInfo
+	// reviewed are the reviews of each kind and namespace.
+	reviewed map[string]bool
+}
+
+// allowed returns whether the user may get the attestations of the runs of kind in
+// namespace, which users allowed to in every namespace may.
+func (a *authorizer) allowed(ctx context.Context, kind, namespace string) (bool, error) {
+	for _, ns := range []string{"", namespace} {
+		key := kind + "/" + ns
+		allowed, ok := a.reviewed[key]
+		if !ok {
+			var err error
+			if allowed, err = a.review(ctx, kind, ns); err != nil {
+				return false, err
+			}
+			a.reviewed[key] = allowed
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (a *authorizer) review(ctx context.Context, kind, namespace string) (bool, error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range a.user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	review, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   a.user.Username,
+			UID:    a.user.UID,
+			Groups: a.user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        "get",
+				Group:       pipeline.GroupName,
+				Resource:    kind + "s",
+				Subresource: attestationsSubresource,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("reviewing access: %w", err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// match returns the attestation of obj stored as s, and whether it matches q.
+func (q query) match(obj objects.TektonObject, s chains.StoredAttestation) (Attestation, bool) {
+	a := Attestation{
+		Run: Run{
+			Kind:      obj.GetKindName(),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			UID:       string(obj.GetUID()),
+		},
+		BuildGroup: obj.GetAnnotations()[attest.BuildGroupAnnotation],
+		Backend:    s.Backend,
+		Key:        s.Key,
+		Payload:    s.Payload,
+		Signature:  string(s.Signature),
+	}
+	if !json.Valid(s.Payload) {
+		a.Payload, _ = json.Marshal(string(s.Payload))
+	}
+	var st statement
+	if err := json.Unmarshal(s.Payload, &st); err == nil && st.Type != "" {
+		a.PredicateType, a.Subjects = st.PredicateType, st.Subject
+	}
+
+	if q.predicateType != "" && a.PredicateType != q.predicateType {
+		return a, false
+	}
+	if q.digest == "" {
+		return a, true
+	}
+	algorithm, hex := digestAlgorithm(q.digest), digestHex(q.digest)
+	for _, subject := range a.Subjects {
+		for alg, value := range subject.Digest {
+			if value == hex && (algorithm == "" || alg == algorithm) {
+				return a, true
+			}
+		}
+	}
+	return a, false
+}
+
+// digestAlgorithm returns the algorithm of a digest like sha256:abc, or "" if it has none.
+func digestAlgorithm(digest string) string {
+	if i := strings.Index(digest, ":"); i >= 0 {
+		return digest[:i]
+	}
+	return ""
+}
+
+// digestHex returns the hex encoded value of a digest like sha256:abc.
+func digestHex(digest string) string {
+	return digest[strings.Index(digest, ":")+1:]
+}
+
+// completion returns the completion time of obj, or the zero time if it isn't completed.
+func completion(obj objects.TektonObject) time.Time {
+	var t *metav1.Time
+	switch o := obj.(type) {
+	case *objects.TaskRunObject:
+		t = o.Status.CompletionTime
+	case *objects.PipelineRunObject:
+		t = o.Status.CompletionTime
+	case *objects.CustomRunObject:
+		t = o.Status.CompletionTime
+	}
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time
+}
@@ -0,0 +1,355 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/storage/tekton"
+	"github.com/tektoncd/chains/pkg/config"
+	testtekton "github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	fakecustomruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/customrun/fake"
+	fakepipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/pipelinerun/fake"
+	faketaskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun/fake"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	k8stesting "k8s.io/client-go/testing"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func provenance(digest string) string {
+	return `{
+		"_type": "https://in-toto.io/Statement/v0.1",
+		"predicateType": "https://slsa.dev/provenance/v0.2",
+		"subject": [{"name": "gcr.io/foo/bar", "digest": {"sha256": "` + digest + `"}}],
+		"predicate": {}
+	}`
+}
+
+// newHandler returns a handler of signed and unsigned TaskRuns in the ns namespace, a
+// signed TaskRun in the other namespace and a signed CustomRun in ns, which authenticates
+// the tokens of admin, allowed to get the attestations of every run, and of ci, allowed
+// to get the attestations of the TaskRuns in ns.
+func newHandler(t *testing.T, cfg config.Config) *Handler {
+	t.Helper()
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	tri := faketaskruninformer.Get(ctx)
+	cri := fakecustomruninformer.Get(ctx)
+	b := tekton.NewStorageBackend(ps, cfg)
+
+	runs := []struct {
+		kind, namespace, name, uid, digest, group string
+		signed                                    bool
+	}{
+		{kind: "taskrun", namespace: "ns", name: "build", uid: "uid-1", digest: "abc", group: "widgets-nightly", signed: true},
+		{kind: "taskrun", namespace: "ns", name: "release", uid: "uid-2", digest: "def", signed: true},
+		{kind: "taskrun", namespace: "ns", name: "unsigned", uid: "uid-3", digest: "abc", group: "widgets-nightly"},
+		{kind: "taskrun", namespace: "other", name: "foreign", uid: "uid-4", digest: "abc", signed: true},
+		{kind: "customrun", namespace: "ns", name: "approval", uid: "uid-5", digest: "abc", signed: true},
+	}
+	for i, r := range runs {
+		completion := &metav1.Time{Time: metav1.Now().Add(-time.Duration(i) * time.Minute)}
+		results := []v1beta1.TaskRunResult{
+			{Name: "IMAGE_DIGEST", Value: *v1beta1.NewStructuredValues("sha256:" + r.digest)},
+		}
+		meta := metav1.ObjectMeta{Name: r.name, Namespace: r.namespace, UID: types.UID(r.uid)}
+		var obj objects.TektonObject
+		if r.kind == "customrun" {
+			cr := &v1beta1.CustomRun{ObjectMeta: meta}
+			cr.Status.CompletionTime = completion
+			for _, result := range results {
+				cr.Status.Results = append(cr.Status.Results, v1beta1.CustomRunResult{Name: result.Name, Value: result.Value.StringVal})
+			}
+			obj = objects.NewCustomRunObject(cr)
+		} else {
+			obj = objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: meta,
+				Status: v1beta1.TaskRunStatus{TaskRunStatusFields: v1beta1.TaskRunStatusFields{
+					CompletionTime: completion,
+					TaskRunResults: results,
+				}},
+			})
+		}
+		testtekton.CreateObject(t, ctx, ps, obj)
+		if r.signed {
+			opts := config.StorageOpts{ShortKey: r.kind + "-" + r.uid}
+			if err := b.StorePayload(ctx, obj, []byte(provenance(r.digest)), "sig-"+r.name, opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+		updated, err := testtekton.GetObject(t, ctx, ps, obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		annotations := updated.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		if r.signed {
+			annotations[chains.ChainsAnnotation] = "true"
+		}
+		if r.group != "" {
+			annotations[attest.BuildGroupAnnotation] = r.group
+		}
+		switch stored := updated.GetObject().(type) {
+		case *v1beta1.TaskRun:
+			stored.Status, stored.Annotations = obj.GetObject().(*v1beta1.TaskRun).Status, annotations
+			err = tri.Informer().GetIndexer().Add(stored)
+		case *v1beta1.CustomRun:
+			stored.Status, stored.Annotations = obj.GetObject().(*v1beta1.CustomRun).Status, annotations
+			err = cri.Informer().GetIndexer().Add(stored)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kc := fakekubeclient.Get(ctx)
+	kc.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		for _, u := range []string{"admin", "ci"} {
+			if review.Spec.Token == u+"-token" {
+				review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: u}}
+			}
+		}
+		return true, review, nil
+	})
+	kc.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		if attrs.Verb == "get" && attrs.Group == "tekton.dev" && attrs.Subresource == "attestations" {
+			switch review.Spec.User {
+			case "admin":
+				review.Status.Allowed = attrs.Namespace == ""
+			case "ci":
+				review.Status.Allowed = attrs.Namespace == "ns" && attrs.Resource == "taskruns"
+			}
+		}
+		return true, review, nil
+	})
+
+	backends := map[string]storage.Backend{"tekton": b}
+	return &Handler{
+		TaskRuns:     tri.Lister(),
+		PipelineRuns: fakepipelineruninformer.Get(ctx).Lister(),
+		CustomRuns:   cri.Lister(),
+		KubeClient:   kc,
+		State:        func() (config.Config, map[string]storage.Backend) { return cfg, backends },
+	}
+}
+
+// get queries h as the user of token.
+func get(h *Handler, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	cfg := config.Config{Artifacts: config.ArtifactConfigs{
+		TaskRuns:     config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")},
+		PipelineRuns: config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")},
+	}}
+	h := newHandler(t, cfg)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{{
+		name:  "uid",
+		query: "uid=uid-2",
+		want:  []string{"release"},
+	}, {
+		name:  "digest",
+		query: "digest=sha256:abc",
+		want:  []string{"build"},
+	}, {
+		name:  "bare digest",
+		query: "digest=def",
+		want:  []string{"release"},
+	}, {
+		name:  "other digest algorithm",
+		query: "digest=sha512:abc",
+		want:  []string{},
+	}, {
+		name:  "predicate type, newest first",
+		query: "predicateType=https://slsa.dev/provenance/v0.2",
+		want:  []string{"build", "release"},
+	}, {
+		name:  "other predicate type",
+		query: "predicateType=https://slsa.dev/provenance/v1&uid=uid-1",
+		want:  []string{},
+	}, {
+		name:  "build group",
+		query: "buildGroup=widgets-nightly",
+		want:  []string{"build"},
+	}, {
+		name:  "other build group",
+		query: "buildGroup=widgets-release&uid=uid-1",
+		want:  []string{},
+	}, {
+		name:  "other namespace",
+		query: "namespace=other&uid=uid-1",
+		want:  []string{},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(h, AttestationsPath+"?"+tt.query, "ci-token")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+			}
+			var resp Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, a := range resp.Attestations {
+				got = append(got, a.Run.Name)
+				if a.Signature != "sig-"+a.Run.Name {
+					t.Errorf("got signature %q for %s", a.Signature, a.Run.Name)
+				}
+				if a.Run.Name == "build" && a.BuildGroup != "widgets-nightly" {
+					t.Errorf("got build group %q for %s", a.BuildGroup, a.Run.Name)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("attestations (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestHandler_MaxResults(t *testing.T) {
+	cfg := config.Config{
+		Artifacts: config.ArtifactConfigs{TaskRuns: config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")}},
+		Query:     config.QueryConfig{MaxResults: 1},
+	}
+	h := newHandler(t, cfg)
+
+	rec := get(h, AttestationsPath+"?predicateType=https://slsa.dev/provenance/v0.2", "ci-token")
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Attestations) != 1 || !resp.Truncated {
+		t.Errorf("got %d attestations, truncated %t; want 1, true", len(resp.Attestations), resp.Truncated)
+	}
+}
+
+func TestHandler_MaxRuns(t *testing.T) {
+	cfg := config.Config{
+		Artifacts: config.ArtifactConfigs{TaskRuns: config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")}},
+		Query:     config.QueryConfig{MaxRuns: 1},
+	}
+	h := newHandler(t, cfg)
+
+	// Only the attestations of the newest run are read, though older runs match.
+	rec := get(h, AttestationsPath+"?predicateType=https://slsa.dev/provenance/v0.2", "ci-token")
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Attestations) != 1 || resp.Attestations[0].Run.Name != "build" || !resp.Truncated {
+		t.Errorf("got attestations %v, truncated %t; want the one of build, true", resp.Attestations, resp.Truncated)
+	}
+}
+
+func TestHandler_Authorization(t *testing.T) {
+	cfg := config.Config{Artifacts: config.ArtifactConfigs{
+		TaskRuns:   config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")},
+		CustomRuns: config.Artifact{Format: "in-toto", StorageBackend: sets.New[string]("tekton")},
+	}}
+	h := newHandler(t, cfg)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		want       []string
+	}{
+		{name: "every namespace", token: "admin-token", wantStatus: http.StatusOK, want: []string{"taskrun/ns/build", "taskrun/other/foreign", "customrun/ns/approval"}},
+		{name: "taskruns of a namespace", token: "ci-token", wantStatus: http.StatusOK, want: []string{"taskrun/ns/build"}},
+		{name: "invalid token", token: "stolen-token", wantStatus: http.StatusUnauthorized},
+		{name: "no token", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(h, AttestationsPath+"?digest=sha256:abc", tt.token)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, a := range resp.Attestations {
+				got = append(got, a.Run.Kind+"/"+a.Run.Namespace+"/"+a.Run.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("attestations (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	h := newHandler(t, config.Config{})
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{name: "no filters", method: http.MethodGet, target: AttestationsPath, want: http.StatusBadRequest},
+		{name: "method", method: http.MethodPost, target: AttestationsPath + "?uid=uid-1", want: http.StatusMethodNotAllowed},
+		{name: "path", method: http.MethodGet, target: "/v1/other?uid=uid-1", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Authorization", "Bearer ci-token")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package query serves the attestations of signed runs over a read-only HTTP API, so
+// dashboards and admission controllers have a single endpoint to look them up by
+// subject digest, run UID or predicate type.
+package query
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/internal/httpserver"
+	customruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/customrun"
+	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/pipelinerun"
+	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+var (
+	mu       sync.Mutex
+	cfg      config.Config
+	backends map[string]storage.Backend
+
+	server = &httpserver.Server{Name: "attestation query API"}
+)
+
+// Setup serves the API with TLS on the address in cfg, answering queries with cfg and
+// the storage backends. It is safe to call on every config update: the server is only
+// restarted when the address or TLS config changed, and stopped when the address is
+// empty. Runs are read from the informers in ctx, and callers are authenticated and
+// authorized with the Kubernetes client in ctx.
+func Setup(ctx context.Context, c config.Config, b map[string]storage.Backend) error {
+	mu.Lock()
+	cfg, backends = c, b
+	mu.Unlock()
+	if c.Query.Address == "" {
+		return server.Serve(ctx, "", nil)
+	}
+	return server.ServeTLS(ctx, c.Query.Address, c.Query.CertPath, c.Query.KeyPath, &Handler{
+		TaskRuns:     taskruninformer.Get(ctx).Lister(),
+		PipelineRuns: pipelineruninformer.Get(ctx).Lister(),
+		CustomRuns:   customruninformer.Get(ctx).Lister(),
+		KubeClient:   kubeclient.Get(ctx),
+		State:        state,
+	})
+}
+
+// state returns the config and storage backends queries are answered with.
+func state() (config.Config, map[string]storage.Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	return cfg, backends
+}
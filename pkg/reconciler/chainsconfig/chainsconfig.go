@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chainsconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+// ManagedByAnnotation is set on the chains-config ConfigMap once it is rendered from a ChainsConfig.
+const ManagedByAnnotation = "chains.tekton.dev/managed-by"
+
+// Reconciler renders the ChainsConfig named chains-config into the chains-config ConfigMap
+// and reports the outcome in its status. While no ChainsConfig exists the ConfigMap is
+// left untouched, so existing installations keep working until they migrate.
+type Reconciler struct {
+	DynamicClient dynamic.Interface
+	KubeClient    kubernetes.Interface
+	// Namespace is the namespace of the chains-config ConfigMap.
+	Namespace string
+}
+
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler.
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorf("invalid resource key: %s", key)
+		return nil
+	}
+
+	client := r.DynamicClient.Resource(v1alpha1.ChainsConfigResource)
+	u, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	cc := &v1alpha1.ChainsConfig{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cc); err != nil {
+		return controller.NewPermanentError(err)
+	}
+
+	status := cc.Status.DeepCopy()
+	status.InitializeConditions()
+	status.ObservedGeneration = cc.Generation
+
+	var reconcileErr error
+	if name != config.ChainsConfig {
+		status.MarkNotApplied(v1alpha1.ReasonIgnored, "only the ChainsConfig named %q is applied", config.ChainsConfig)
+	} else {
+		data := config.DataFromSpec(&cc.Spec)
+		if _, err := config.NewConfigFromMap(data); err != nil {
+			status.MarkNotApplied(v1alpha1.ReasonInvalid, "%v", err)
+		} else if err := r.applyConfigMap(ctx, data); err != nil {
+			status.MarkNotApplied(v1alpha1.ReasonUpdateFailed, "%v", err)
+			reconcileErr = err
+		} else {
+			status.MarkApplied(data)
+		}
+	}
+
+	if equality.Semantic.DeepEqual(status, &cc.Status) {
+		return reconcileErr
+	}
+	cc.Status = *status
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cc)
+	if err != nil {
+		return err
+	}
+	if _, err := client.UpdateStatus(ctx, &unstructured.Unstructured{Object: obj}, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating status of ChainsConfig %s: %w", name, err)
+	}
+	return reconcileErr
+}
+
+// applyConfigMap writes data to the chains-config ConfigMap, replacing its previous contents.
+func (r *Reconciler) applyConfigMap(ctx context.Context, data map[string]string) error {
+	configMaps := r.KubeClient.CoreV1().ConfigMaps(r.Namespace)
+	cm, err := configMaps.Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        config.ChainsConfig,
+				Namespace:   r.Namespace,
+				Annotations: map[string]string{ManagedByAnnotation: "chainsconfig"},
+			},
+			Data: data,
+		}, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if cm.Annotations[ManagedByAnnotation] == "chainsconfig" && equality.Semantic.DeepEqual(cm.Data, data) {
+		return nil
+	}
+	if cm.Annotations[ManagedByAnnotation] != "chainsconfig" {
+		logging.FromContext(ctx).Infof("Taking over the %s ConfigMap from ChainsConfig %s", config.ChainsConfig, config.ChainsConfig)
+	}
+	cm = cm.DeepCopy()
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[ManagedByAnnotation] = "chainsconfig"
+	cm.Data = data
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// Migrate creates the chains-config ChainsConfig from the chains-config ConfigMap in
+// namespace, so that an existing configuration carries over unchanged. It does nothing
+// and returns false if the ChainsConfig already exists.
+func Migrate(ctx context.Context, dynamicClient dynamic.Interface, kubeClient kubernetes.Interface, namespace string) (bool, error) {
+	client := dynamicClient.Resource(v1alpha1.ChainsConfigResource)
+	if _, err := client.Get(ctx, config.ChainsConfig, metav1.GetOptions{}); err == nil {
+		return false, nil
+	} else if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("error getting %s config map: %w", config.ChainsConfig, err)
+	}
+	cfg, err := config.NewConfigFromConfigMap(cm)
+	if err != nil {
+		return false, fmt.Errorf("error parsing %s config map: %w", config.ChainsConfig, err)
+	}
+
+	cc := &v1alpha1.ChainsConfig{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "ChainsConfig"},
+		ObjectMeta: metav1.ObjectMeta{Name: config.ChainsConfig},
+		Spec:       config.SpecFromConfig(cfg),
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cc)
+	if err != nil {
+		return false, err
+	}
+	if _, err := client.Create(ctx, &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{}); err != nil {
+		return false, fmt.Errorf("error creating ChainsConfig %s: %w", config.ChainsConfig, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chainsconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+const namespace = "tekton-chains"
+
+func newReconciler(t *testing.T, cc *v1alpha1.ChainsConfig, objs ...runtime.Object) *Reconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := &unstructured.Unstructured{Object: u}
+	obj.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("ChainsConfig"))
+	return &Reconciler{
+		DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme, obj),
+		KubeClient:    fakek8s.NewSimpleClientset(objs...),
+		Namespace:     namespace,
+	}
+}
+
+func getStatus(t *testing.T, ctx context.Context, r *Reconciler, name string) v1alpha1.ChainsConfigStatus {
+	t.Helper()
+	u, err := r.DynamicClient.Resource(v1alpha1.ChainsConfigResource).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := &v1alpha1.ChainsConfig{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cc); err != nil {
+		t.Fatal(err)
+	}
+	return cc.Status
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "chains-config", Namespace: namespace},
+		Data:       map[string]string{"artifacts.oci.storage": "tekton"},
+	}
+	cc := &v1alpha1.ChainsConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "chains-config", Generation: 2},
+		Spec: v1alpha1.ChainsConfigSpec{
+			Artifacts:    v1alpha1.ArtifactsSpec{TaskRuns: v1alpha1.ArtifactSpec{Format: "slsa/v1"}},
+			Transparency: v1alpha1.TransparencySpec{Enabled: true},
+		},
+	}
+	r := newReconciler(t, cc, existing)
+
+	if err := r.Reconcile(ctx, "chains-config"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"artifacts.taskrun.format": "slsa/v1",
+		"transparency.enabled":     "true",
+	}
+	cm, err := r.KubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, "chains-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, cm.Data); diff != "" {
+		t.Errorf("ConfigMap data -want +got: %s", diff)
+	}
+	if got := cm.Annotations[ManagedByAnnotation]; got != "chainsconfig" {
+		t.Errorf("managed-by annotation = %q", got)
+	}
+
+	status := getStatus(t, ctx, r, "chains-config")
+	if !status.IsReady() {
+		t.Errorf("expected ChainsConfig to be applied, got %v", status.GetCondition(v1alpha1.ConditionApplied))
+	}
+	if status.ObservedGeneration != 2 {
+		t.Errorf("observedGeneration = %d, want 2", status.ObservedGeneration)
+	}
+	if diff := cmp.Diff(want, status.AppliedData); diff != "" {
+		t.Errorf("applied data -want +got: %s", diff)
+	}
+}
+
+func TestReconcile_CreatesConfigMap(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	cc := &v1alpha1.ChainsConfig{ObjectMeta: metav1.ObjectMeta{Name: "chains-config"}}
+	r := newReconciler(t, cc)
+
+	if err := r.Reconcile(ctx, "chains-config"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.KubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, "chains-config", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected chains-config ConfigMap to be created: %v", err)
+	}
+}
+
+func TestReconcile_NotApplied(t *testing.T) {
+	tests := []struct {
+		name       string
+		cc         *v1alpha1.ChainsConfig
+		wantReason string
+	}{
+		{
+			name: "invalid",
+			cc: &v1alpha1.ChainsConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "chains-config"},
+				Spec:       v1alpha1.ChainsConfigSpec{LabelSelector: "team in (a"},
+			},
+			wantReason: v1alpha1.ReasonInvalid,
+		},
+		{
+			name:       "other name",
+			cc:         &v1alpha1.ChainsConfig{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			wantReason: v1alpha1.ReasonIgnored,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := logtesting.TestContextWithLogger(t)
+			existing := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "chains-config", Namespace: namespace},
+				Data:       map[string]string{"artifacts.oci.storage": "tekton"},
+			}
+			r := newReconciler(t, tt.cc, existing)
+
+			if err := r.Reconcile(ctx, tt.cc.Name); err != nil {
+				t.Fatal(err)
+			}
+
+			status := getStatus(t, ctx, r, tt.cc.Name)
+			cond := status.GetCondition(v1alpha1.ConditionApplied)
+			if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != tt.wantReason {
+				t.Errorf("unexpected condition %v, want reason %s", cond, tt.wantReason)
+			}
+			cm, err := r.KubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, "chains-config", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(existing.Data, cm.Data); diff != "" {
+				t.Errorf("expected ConfigMap to be unchanged -want +got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcile_NotFound(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	r := newReconciler(t, &v1alpha1.ChainsConfig{ObjectMeta: metav1.ObjectMeta{Name: "other"}})
+	if err := r.Reconcile(ctx, "chains-config"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.KubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, "chains-config", metav1.GetOptions{}); err == nil {
+		t.Error("expected no ConfigMap to be created without a ChainsConfig")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "chains-config", Namespace: namespace},
+		Data: map[string]string{
+			"artifacts.taskrun.format": "slsa/v1",
+			"artifacts.oci.storage":    "",
+		},
+	}
+	r := newReconciler(t, &v1alpha1.ChainsConfig{ObjectMeta: metav1.ObjectMeta{Name: "other"}}, existing)
+
+	created, err := Migrate(ctx, r.DynamicClient, r.KubeClient, namespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("expected ChainsConfig to be created")
+	}
+	u, err := r.DynamicClient.Resource(v1alpha1.ChainsConfigResource).Get(ctx, "chains-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := &v1alpha1.ChainsConfig{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cc); err != nil {
+		t.Fatal(err)
+	}
+	if got := cc.Spec.Artifacts.TaskRuns.Format; got != "slsa/v1" {
+		t.Errorf("taskrun format = %q, want slsa/v1", got)
+	}
+	if !cc.Spec.Artifacts.OCI.Disabled {
+		t.Error("expected OCI artifacts to be disabled")
+	}
+
+	// Applying the migrated ChainsConfig keeps the effective configuration.
+	if err := r.Reconcile(ctx, "chains-config"); err != nil {
+		t.Fatal(err)
+	}
+	cm, err := r.KubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, "chains-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := config.NewConfigFromConfigMap(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := config.NewConfigFromConfigMap(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("migrated config -want +got: %s", diff)
+	}
+
+	if created, err := Migrate(ctx, r.DynamicClient, r.KubeClient, namespace); err != nil || created {
+		t.Errorf("Migrate() = %t, %v, want no-op once the ChainsConfig exists", created, err)
+	}
+}
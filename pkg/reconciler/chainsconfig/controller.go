@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chainsconfig
+
+import (
+	"context"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+)
+
+func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	dynamicClient := dynamicclient.Get(ctx)
+
+	r := &Reconciler{
+		DynamicClient: dynamicClient,
+		KubeClient:    kubeclient.Get(ctx),
+		Namespace:     system.Namespace(),
+	}
+	impl := controller.NewContext(ctx, r, controller.ControllerOptions{
+		WorkQueueName: "ChainsConfig",
+		Logger:        logger,
+	})
+
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, controller.GetResyncPeriod(ctx))
+	if _, err := factory.ForResource(v1alpha1.ChainsConfigResource).Informer().AddEventHandler(controller.HandleAll(impl.Enqueue)); err != nil {
+		logger.Fatalw("error adding ChainsConfig event handler", "error", err)
+	}
+	factory.Start(ctx.Done())
+
+	// Revert changes made directly to the chains-config ConfigMap while a ChainsConfig owns it.
+	cmw.Watch(config.ChainsConfig, func(*corev1.ConfigMap) {
+		impl.EnqueueKey(types.NamespacedName{Name: config.ChainsConfig})
+	})
+
+	return impl
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customrun
+
+import (
+	"context"
+
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/drain"
+	"github.com/tektoncd/chains/pkg/chains/multicluster"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/config"
+	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
+	customruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/customrun"
+	customrunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1beta1/customrun"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+
+	_ "github.com/tektoncd/chains/pkg/chains/formats/all"
+)
+
+func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	customRunInformer := customruninformer.Get(ctx)
+
+	kubeClient := kubeclient.Get(ctx)
+	pipelineClient := pipelineclient.Get(ctx)
+
+	crSigner := &chains.ObjectSigner{
+		SecretPath:        SecretPath,
+		Pipelineclientset: pipelineClient,
+		KubeClient:        kubeClient,
+		DynamicClient:     dynamicclient.Get(ctx),
+		Cluster:           multicluster.FromContext(ctx),
+		LeaseClient:       multicluster.HomeKubeClient(ctx),
+	}
+
+	backlog := &chains.Backlog{
+		Kind:    "customrun",
+		Cluster: multicluster.FromContext(ctx),
+		List: func(cfg config.Config) ([]objects.TektonObject, error) {
+			// CustomRuns aren't signed while signing them is disabled.
+			if !(&artifacts.CustomRunArtifact{}).Enabled(cfg) {
+				return nil, nil
+			}
+			crs, err := customRunInformer.Lister().List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			runs := make([]objects.TektonObject, 0, len(crs))
+			for _, cr := range crs {
+				runs = append(runs, objects.NewCustomRunObject(cr))
+			}
+			return runs, nil
+		},
+	}
+
+	c := &Reconciler{
+		CustomRunSigner:   crSigner,
+		Pipelineclientset: pipelineClient,
+		Drainer:           drain.New(ctx),
+	}
+	var cfgStore *config.ConfigStore
+	impl := customrunreconciler.NewImpl(ctx, c, func(impl *controller.Impl) controller.Options {
+		cfgStore = config.NewConfigStore(logger, func(name string, value interface{}) {
+			// get updated config
+			cfg := *value.(*config.Config)
+
+			// get all backends for storing provenance. Tracing, audit logs, events
+			// and limits are set up by the TaskRun controller, or by the PipelineRun
+			// controller when only PipelineRuns are signed.
+			backends, err := storage.InitializeBackends(ctx, pipelineClient, kubeClient, cfg)
+			if err != nil {
+				logger.Error(err)
+			}
+			crSigner.Backends = backends
+			backlog.Setup(ctx, cfg)
+		})
+
+		// setup watches for the config names provided by client
+		cfgStore.WatchConfigs(cmw)
+
+		return controller.Options{
+			// The chains reconciler shouldn't mutate the customrun's status.
+			SkipStatusUpdates: true,
+			ConfigStore:       cfgStore,
+			FinalizerName:     "chains.tekton.dev/customrun",
+		}
+	})
+
+	customRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: pkgreconciler.ChainFilterFuncs(enabled(cfgStore), cfgStore.Filter()),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	return impl
+}
+
+// enabled filters out CustomRuns while signing them is disabled, so that no finalizer
+// is added to them. CustomRuns being deleted are let through to release their
+// finalizer.
+func enabled(cfgStore *config.ConfigStore) func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		if mo, ok := obj.(metav1.Object); ok && mo.GetDeletionTimestamp() != nil {
+			return true
+		}
+		cfg, ok := cfgStore.UntypedLoad(config.ChainsConfig).(*config.Config)
+		if !ok {
+			// The configuration has not been loaded yet.
+			return true
+		}
+		return (&artifacts.CustomRunArtifact{}).Enabled(*cfg)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customrun
+
+import (
+	"context"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/artifacts"
+	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/drain"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/tracing"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	customrunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1beta1/customrun"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+)
+
+const (
+	// SecretPath contains the path to the secrets volume that is mounted in.
+	SecretPath = "/etc/signing-secrets"
+)
+
+type Reconciler struct {
+	CustomRunSigner   signing.Signer
+	Pipelineclientset versioned.Interface
+	Drainer           *drain.Drainer
+}
+
+// Check that our Reconciler implements customrunreconciler.Interface and customrunreconciler.Finalizer
+var _ customrunreconciler.Interface = (*Reconciler)(nil)
+var _ customrunreconciler.Finalizer = (*Reconciler)(nil)
+
+// ReconcileKind handles a changed or created CustomRun.
+func (r *Reconciler) ReconcileKind(ctx context.Context, cr *v1beta1.CustomRun) pkgreconciler.Event {
+	return r.FinalizeKind(ctx, cr)
+}
+
+// FinalizeKind implements customrunreconciler.Finalizer. Like TaskRuns, CustomRuns
+// hold a finalizer so that they are signed before they are cleaned up. CustomRuns are
+// only enqueued while signing them is enabled, and their finalizer is released once
+// it is disabled.
+func (r *Reconciler) FinalizeKind(ctx context.Context, cr *v1beta1.CustomRun) (event pkgreconciler.Event) {
+	ctx, span := tracing.Start(ctx, "ReconcileCustomRun",
+		tracing.ObjectKeyAttr.String(cr.Namespace+"/"+cr.Name),
+		tracing.KindAttr.String("customrun"))
+	defer func() { tracing.End(span, event) }()
+
+	cfg := config.FromContextOrDefaults(ctx)
+	if !(&artifacts.CustomRunArtifact{}).Enabled(*cfg) {
+		return nil
+	}
+	if !cfg.Selects(cr) {
+		logging.FromContext(ctx).Infof("customrun %s/%s is not selected for signing", cr.Namespace, cr.Name)
+		if cr.IsDone() {
+			audit.Skipped(ctx, "customrun", cr, "not selected by namespace or label selector")
+		}
+		return nil
+	}
+
+	// Check to make sure the CustomRun is finished.
+	if !cr.IsDone() {
+		logging.FromContext(ctx).Infof("customrun %s/%s is still running", cr.Namespace, cr.Name)
+		return nil
+	}
+
+	obj := objects.NewCustomRunObject(cr)
+
+	// Check to see if it has already been signed. Runs being deleted whose signing
+	// failed are tried again while the finalizer is held for them.
+	holdFinalizer := signing.HoldFinalizer(cfg.Finalizer, cr, time.Now())
+	if signing.Reconciled(ctx, r.Pipelineclientset, obj) && !(holdFinalizer && signing.Failed(obj)) {
+		logging.FromContext(ctx).Infof("customrun %s/%s has been reconciled", cr.Namespace, cr.Name)
+		return nil
+	}
+
+	if r.Drainer.Draining() {
+		logging.FromContext(ctx).Infof("controller is shutting down, not signing customrun %s/%s", cr.Namespace, cr.Name)
+		return controller.NewRequeueImmediately()
+	}
+	ctx, cancel := r.Drainer.Context(ctx, cfg.Shutdown.DrainTimeout)
+	defer cancel()
+
+	if err := r.CustomRunSigner.Sign(ctx, obj); err != nil {
+		if cfg.Finalizer.BlockDeletion && cr.DeletionTimestamp != nil && !holdFinalizer {
+			logging.FromContext(ctx).Warnf("releasing finalizer of deleted customrun %s/%s after timeout: %v", cr.Namespace, cr.Name, err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customrun
+
+import (
+	"context"
+	"testing"
+
+	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/drain"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/internal/mocksigner"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	_ "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/customrun/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	_ "knative.dev/pkg/client/injection/kube/client/fake"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	_ "knative.dev/pkg/injection/clients/dynamicclient/fake"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	rtesting "knative.dev/pkg/reconciler/testing"
+	"knative.dev/pkg/system"
+)
+
+var enabledConfig = &config.Config{
+	Artifacts: config.ArtifactConfigs{
+		CustomRuns: config.Artifact{StorageBackend: sets.New[string]("tekton")},
+	},
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	cr := &v1beta1.CustomRun{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "foo"}}
+	if _, err := c.TektonV1beta1().CustomRuns(cr.Namespace).Create(ctx, cr, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	configMapWatcher := configmap.NewStaticWatcher(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      config.ChainsConfig,
+		},
+	})
+	ctl := NewController(ctx, configMapWatcher)
+
+	if la, ok := ctl.Reconciler.(pkgreconciler.LeaderAware); ok {
+		if err := la.Promote(pkgreconciler.UniversalBucket(), func(pkgreconciler.Bucket, types.NamespacedName) {}); err != nil {
+			t.Fatalf("Promote() = %v", err)
+		}
+	}
+
+	if err := ctl.Reconciler.Reconcile(ctx, "foo/bar"); err != nil {
+		t.Errorf("Reconciler.Reconcile() error = %v", err)
+	}
+}
+
+func TestReconciler_handleCustomRun(t *testing.T) {
+	done := v1beta1.CustomRunStatus{
+		Status: duckv1.Status{
+			Conditions: []apis.Condition{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue}},
+		},
+	}
+	tests := []struct {
+		name       string
+		cr         *v1beta1.CustomRun
+		cfg        *config.Config
+		shouldSign bool
+	}{
+		{
+			name: "complete, not already signed",
+			cr: &v1beta1.CustomRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "unsigned"},
+				Status:     done,
+			},
+			cfg:        enabledConfig,
+			shouldSign: true,
+		},
+		{
+			name: "complete, already signed",
+			cr: &v1beta1.CustomRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "signed",
+					Annotations: map[string]string{signing.ChainsAnnotation: "true"},
+				},
+				Status: done,
+			},
+			cfg:        enabledConfig,
+			shouldSign: false,
+		},
+		{
+			name: "not complete",
+			cr: &v1beta1.CustomRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "running"},
+			},
+			cfg:        enabledConfig,
+			shouldSign: false,
+		},
+		{
+			name: "complete, signing disabled",
+			cr: &v1beta1.CustomRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "disabled"},
+				Status:     done,
+			},
+			shouldSign: false,
+		},
+		{
+			name: "complete, excluded namespace",
+			cr: &v1beta1.CustomRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "excluded", Namespace: "untrusted"},
+				Status:     done,
+			},
+			cfg: &config.Config{
+				Artifacts:  enabledConfig.Artifacts,
+				Namespaces: config.NamespaceConfig{Excluded: sets.New[string]("untrusted")},
+			},
+			shouldSign: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := &mocksigner.Signer{}
+			ctx, _ := rtesting.SetupFakeContext(t)
+			if tt.cfg != nil {
+				ctx = config.ToContext(ctx, tt.cfg)
+			}
+			c := fakepipelineclient.Get(ctx)
+			tekton.CreateObject(t, ctx, c, objects.NewCustomRunObject(tt.cr))
+
+			r := &Reconciler{
+				CustomRunSigner:   signer,
+				Pipelineclientset: c,
+			}
+			if err := r.ReconcileKind(ctx, tt.cr); err != nil {
+				t.Errorf("Reconciler.handleCustomRun() error = %v", err)
+			}
+			if signer.Signed != tt.shouldSign {
+				t.Errorf("Reconciler.handleCustomRun() signed = %v, wanted %v", signer.Signed, tt.shouldSign)
+			}
+		})
+	}
+}
+
+func TestReconciler_Draining(t *testing.T) {
+	cr := &v1beta1.CustomRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "draining"},
+		Status: v1beta1.CustomRunStatus{
+			Status: duckv1.Status{
+				Conditions: []apis.Condition{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue}},
+			}},
+	}
+	signer := &mocksigner.Signer{}
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ctx = config.ToContext(ctx, enabledConfig)
+	c := fakepipelineclient.Get(ctx)
+	tekton.CreateObject(t, ctx, c, objects.NewCustomRunObject(cr))
+
+	controllerCtx, shutdown := context.WithCancel(ctx)
+	shutdown()
+	r := &Reconciler{
+		CustomRunSigner:   signer,
+		Pipelineclientset: c,
+		Drainer:           drain.New(controllerCtx),
+	}
+	err := r.ReconcileKind(ctx, cr)
+	if ok, _ := controller.IsRequeueKey(err); !ok {
+		t.Errorf("Reconciler.ReconcileKind() error = %v, want the customrun to be requeued", err)
+	}
+	if signer.Signed {
+		t.Error("expected customrun not to be signed while the controller shuts down")
+	}
+}
@@ -16,19 +16,37 @@ package pipelinerun
 import (
 	"context"
 
+	"github.com/tektoncd/chains/pkg/attestation"
 	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/drain"
+	"github.com/tektoncd/chains/pkg/chains/events"
+	"github.com/tektoncd/chains/pkg/chains/limits"
+	"github.com/tektoncd/chains/pkg/chains/metrics"
+	"github.com/tektoncd/chains/pkg/chains/multicluster"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/profiling"
+	"github.com/tektoncd/chains/pkg/chains/reduce"
+	"github.com/tektoncd/chains/pkg/chains/runtypes"
 	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/tracing"
 	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/health"
+	"github.com/tektoncd/chains/pkg/query"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	informers "github.com/tektoncd/pipeline/pkg/client/informers/externalversions/pipeline/v1beta1"
 	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
 	pipelineruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/pipelinerun"
 	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun"
 	pipelinerunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1beta1/pipelinerun"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/cache"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
 
 	_ "github.com/tektoncd/chains/pkg/chains/formats/all"
 )
@@ -36,7 +54,24 @@ import (
 func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
 	logger := logging.FromContext(ctx)
 	pipelineRunInformer := pipelineruninformer.Get(ctx)
-	taskRunInformer := taskruninformer.Get(ctx)
+	// TaskRuns are fetched from the API server when only PipelineRuns are signed, so
+	// their informer isn't requested, which keeps it from being started.
+	var taskRunInformer informers.TaskRunInformer
+	if !runtypes.PipelineRunsOnly(ctx) {
+		taskRunInformer = taskruninformer.Get(ctx)
+	}
+	if reduce.Enabled(ctx) {
+		if err := pipelineRunInformer.Informer().SetTransform(reduce.PipelineRun); err != nil {
+			logger.Errorf("error reducing cached pipelineruns: %v", err)
+		}
+		// The TaskRun informer is shared with the TaskRun controller, which sets the
+		// same transform.
+		if taskRunInformer != nil {
+			if err := taskRunInformer.Informer().SetTransform(reduce.TaskRun); err != nil {
+				logger.Errorf("error reducing cached taskruns: %v", err)
+			}
+		}
+	}
 
 	kubeClient := kubeclient.Get(ctx)
 	pipelineClient := pipelineclient.Get(ctx)
@@ -44,22 +79,117 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 	psSigner := &chains.ObjectSigner{
 		SecretPath:        SecretPath,
 		Pipelineclientset: pipelineClient,
+		KubeClient:        kubeClient,
+		DynamicClient:     dynamicclient.Get(ctx),
+		Cluster:           multicluster.FromContext(ctx),
+		LeaseClient:       multicluster.HomeKubeClient(ctx),
+	}
+
+	// Entries queued while the transparency log was unreachable are uploaded by the
+	// TaskRun controller of the cluster they were queued in, or by this one when there is
+	// no TaskRun controller.
+	var tlogQueue *chains.TransparencyQueue
+	// The health checks are also run by this controller when there is no TaskRun
+	// controller.
+	var monitor *health.Monitor
+	if runtypes.PipelineRunsOnly(ctx) {
+		tlogQueue = &chains.TransparencyQueue{
+			DynamicClient:     dynamicclient.Get(ctx),
+			Pipelineclientset: pipelineClient,
+		}
+		monitor = &health.Monitor{
+			DynamicClient: dynamicclient.Get(ctx),
+			KubeClient:    kubeClient,
+			SecretPath:    SecretPath,
+		}
+	}
+
+	backlog := &chains.Backlog{
+		Kind:    "pipelinerun",
+		Cluster: multicluster.FromContext(ctx),
+		List: func(config.Config) ([]objects.TektonObject, error) {
+			prs, err := pipelineRunInformer.Lister().List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			runs := make([]objects.TektonObject, 0, len(prs))
+			for _, pr := range prs {
+				runs = append(runs, objects.NewPipelineRunObject(pr))
+			}
+			return runs, nil
+		},
 	}
 
 	c := &Reconciler{
 		PipelineRunSigner: psSigner,
 		Pipelineclientset: pipelineClient,
-		TaskRunLister:     taskRunInformer.Lister(),
+		Drainer:           drain.New(ctx),
+		ReducedCache:      reduce.Enabled(ctx),
+		PipelineRunsOnly:  runtypes.PipelineRunsOnly(ctx),
 	}
+	if taskRunInformer != nil {
+		c.TaskRunLister = taskRunInformer.Lister()
+	}
+	var cfgStore *config.ConfigStore
 	impl := pipelinerunreconciler.NewImpl(ctx, c, func(impl *controller.Impl) controller.Options {
-		cfgStore := config.NewConfigStore(logger, func(name string, value interface{}) {
+		cfgStore = config.NewConfigStore(logger, func(name string, value interface{}) {
 			// get updated config
 			cfg := *value.(*config.Config)
 
 			// get all backends for storing provenance
-			backends, err := storage.InitializeBackends(ctx, pipelineClient, kubeClient, cfg)
-			if err != nil {
-				logger.Error(err)
+			backends, backendsErr := storage.InitializeBackends(ctx, pipelineClient, kubeClient, cfg)
+			if backendsErr != nil {
+				logger.Error(backendsErr)
+			}
+
+			if err := tracing.Setup(ctx, cfg.Tracing); err != nil {
+				logger.Errorf("error configuring tracing: %v", err)
+			}
+			if err := audit.Setup(cfg.Audit); err != nil {
+				logger.Errorf("error configuring audit log: %v", err)
+			}
+			if err := events.Setup(cfg.Events); err != nil {
+				logger.Errorf("error configuring CloudEvents: %v", err)
+			}
+			// The query and attestation APIs, the health checks and profiling serve the
+			// cluster the controller runs in. They are also set up here since there is no
+			// TaskRun controller when only PipelineRuns are signed.
+			if multicluster.FromContext(ctx) == "" {
+				if err := query.Setup(ctx, cfg, backends); err != nil {
+					logger.Errorf("error configuring attestation query API: %v", err)
+				}
+				if err := attestation.Setup(ctx, cfg, &chains.ObjectSigner{
+					Backends:          backends,
+					SecretPath:        SecretPath,
+					Pipelineclientset: pipelineClient,
+					KubeClient:        kubeClient,
+				}); err != nil {
+					logger.Errorf("error configuring attestation gRPC API: %v", err)
+				}
+				if monitor != nil {
+					// The canary TaskRuns of the conformance probe aren't signed without
+					// the TaskRun controller.
+					if cfg.Conformance.Enabled {
+						logger.Warn("conformance probes don't run with --pipelineruns-only")
+					}
+					if err := monitor.Setup(ctx, cfg, backends, backendsErr); err != nil {
+						logger.Errorf("error configuring health checks: %v", err)
+					}
+				}
+				if err := profiling.Setup(ctx, cfg.Profiling); err != nil {
+					logger.Errorf("error configuring profiling: %v", err)
+				}
+			}
+			if tlogQueue != nil {
+				tlogQueue.Setup(ctx, cfg)
+			}
+			limits.Setup(cfg.Concurrency)
+			metrics.Setup(cfg.Metrics)
+			backlog.Setup(ctx, cfg)
+			// Workers are only read when the controller starts, which happens after the
+			// initial config was loaded.
+			if cfg.Concurrency.PipelineRunWorkers > 0 {
+				impl.Concurrency = cfg.Concurrency.PipelineRunWorkers
 			}
 			psSigner.Backends = backends
 		})
@@ -77,12 +207,17 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 
 	c.Tracker = impl.Tracker
 
-	pipelineRunInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
-
-	taskRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: controller.FilterController(&v1beta1.PipelineRun{}),
-		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+	pipelineRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: cfgStore.Filter(),
+		Handler:    controller.HandleAll(impl.Enqueue),
 	})
 
+	if taskRunInformer != nil {
+		taskRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+			FilterFunc: pkgreconciler.ChainFilterFuncs(controller.FilterController(&v1beta1.PipelineRun{}), cfgStore.Filter()),
+			Handler:    controller.HandleAll(impl.EnqueueControllerOf),
+		})
+	}
+
 	return impl
 }
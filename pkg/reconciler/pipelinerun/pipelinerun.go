@@ -16,14 +16,23 @@ package pipelinerun
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/drain"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/tracing"
+	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	pipelinerunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1beta1/pipelinerun"
 	listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	"knative.dev/pkg/tracker"
@@ -32,6 +41,9 @@ import (
 const (
 	// SecretPath contains the path to the secrets volume that is mounted in.
 	SecretPath = "/etc/signing-secrets"
+	// taskRunRequeueDelay is how long to wait before trying again to sign a PipelineRun
+	// whose TaskRuns aren't complete, when they aren't watched.
+	taskRunRequeueDelay = 10 * time.Second
 )
 
 type Reconciler struct {
@@ -39,6 +51,14 @@ type Reconciler struct {
 	Pipelineclientset versioned.Interface
 	TaskRunLister     listers.TaskRunLister
 	Tracker           tracker.Interface
+	Drainer           *drain.Drainer
+	// ReducedCache is set when the informers cache reduced PipelineRuns and TaskRuns,
+	// in which case the full runs are fetched before signing them.
+	ReducedCache bool
+	// PipelineRunsOnly is set when TaskRuns aren't signed or watched, in which case the
+	// TaskRuns of PipelineRuns are fetched from the API server and not waited on to be
+	// signed.
+	PipelineRunsOnly bool
 }
 
 // Check that our Reconciler implements pipelinerunreconciler.Interface and pipelinerunreconciler.Finalizer
@@ -56,7 +76,21 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, pr *v1beta1.PipelineRun)
 // We utilize finalizers to ensure that we get a crack at signing every pipelinerun
 // that we see flowing through the system.  If we don't add a finalizer, it could
 // get cleaned up before we see the final state and sign it.
-func (r *Reconciler) FinalizeKind(ctx context.Context, pr *v1beta1.PipelineRun) pkgreconciler.Event {
+func (r *Reconciler) FinalizeKind(ctx context.Context, pr *v1beta1.PipelineRun) (event pkgreconciler.Event) {
+	ctx, span := tracing.Start(ctx, "ReconcilePipelineRun",
+		tracing.ObjectKeyAttr.String(pr.Namespace+"/"+pr.Name),
+		tracing.KindAttr.String("pipelinerun"))
+	defer func() { tracing.End(span, event) }()
+
+	cfg := config.FromContextOrDefaults(ctx)
+	if !cfg.Selects(pr) {
+		logging.FromContext(ctx).Infof("pipelinerun is not selected for signing")
+		if pr.IsDone() {
+			audit.Skipped(ctx, "pipelinerun", pr, "not selected by namespace or label selector")
+		}
+		return nil
+	}
+
 	// Check to make sure the PipelineRun is finished.
 	if !pr.IsDone() {
 		logging.FromContext(ctx).Infof("pipelinerun is still running")
@@ -64,14 +98,19 @@ func (r *Reconciler) FinalizeKind(ctx context.Context, pr *v1beta1.PipelineRun)
 	}
 	pro := objects.NewPipelineRunObject(pr)
 
-	// Check to see if it has already been signed.
-	if signing.Reconciled(ctx, r.Pipelineclientset, pro) {
+	// Check to see if it has already been signed. Runs being deleted whose signing
+	// failed are tried again while the finalizer is held for them.
+	holdFinalizer := signing.HoldFinalizer(cfg.Finalizer, pr, time.Now())
+	if signing.Reconciled(ctx, r.Pipelineclientset, pro) && !(holdFinalizer && signing.Failed(pro)) {
 		logging.FromContext(ctx).Infof("pipelinerun has been reconciled")
 		return nil
 	}
 
 	// Get TaskRun names depending on whether embeddedstatus feature is set or not
 	var trs []string
+	// iterations are the pipeline tasks of the custom tasks whose iterations the TaskRuns
+	// with the given names ran for.
+	iterations := map[string]string{}
 	if len(pr.Status.ChildReferences) == 0 || len(pr.Status.TaskRuns) > 0 || len(pr.Status.Runs) > 0 { //nolint:all //incompatible with pipelines v0.45
 		for trName, ptrs := range pr.Status.TaskRuns { //nolint:all //incompatible with pipelines v0.45
 			// TaskRuns within a PipelineRun may not have been finalized yet if the PipelineRun timeout
@@ -85,6 +124,19 @@ func (r *Reconciler) FinalizeKind(ctx context.Context, pr *v1beta1.PipelineRun)
 		}
 	} else {
 		for _, cr := range pr.Status.ChildReferences {
+			if cr.Kind == "CustomRun" || cr.Kind == "Run" {
+				// The TaskRuns of the iterations of custom tasks, if any, are included in the
+				// provenance of the PipelineRun.
+				its, err := r.iterationTaskRuns(ctx, pr.Namespace, cr.Name)
+				if err != nil {
+					return err
+				}
+				for _, name := range its {
+					trs = append(trs, name)
+					iterations[name] = cr.PipelineTaskName
+				}
+				continue
+			}
 			trs = append(trs, cr.Name)
 		}
 	}
@@ -92,39 +144,146 @@ func (r *Reconciler) FinalizeKind(ctx context.Context, pr *v1beta1.PipelineRun)
 	// Signing both taskruns and pipelineruns causes a race condition when using oci storage
 	// during the push to the registry. This checks the taskruns to ensure they've been reconciled
 	// before attempting to sign the pippelinerun.
+	var taskRuns []*v1beta1.TaskRun
 	for _, name := range trs {
-		tr, err := r.TaskRunLister.TaskRuns(pr.Namespace).Get(name)
+		tr, err := r.getTaskRun(ctx, pr.Namespace, name)
 		if err != nil {
 			logging.FromContext(ctx).Errorf("Unable to get reconciled status of taskrun %s within pipelinerun", name)
 			if errors.IsNotFound(err) {
 				// Since this is an unrecoverable scenario, returning the error would prevent the
 				// finalizer from being removed, thus preventing the PipelineRun from being deleted.
+				audit.Skipped(ctx, "pipelinerun", pr, fmt.Sprintf("taskrun %s not found", name))
 				return nil
 			}
 			return err
 		}
 		if tr == nil {
 			logging.FromContext(ctx).Infof("taskrun %s within pipelinerun is not found", name)
+			audit.Skipped(ctx, "pipelinerun", pr, fmt.Sprintf("taskrun %s not found", name))
 			return nil
 		}
 		if tr.Status.CompletionTime == nil {
 			logging.FromContext(ctx).Infof("taskrun %s within pipelinerun is not yet finalized: status is not complete", name)
+			if r.PipelineRunsOnly {
+				return controller.NewRequeueAfter(taskRunRequeueDelay)
+			}
 			return r.trackTaskRun(tr, pr)
 		}
-		reconciled := signing.Reconciled(ctx, r.Pipelineclientset, objects.NewTaskRunObject(tr))
+		// Nothing is pushed in dry-run mode, and TaskRuns aren't signed when only
+		// PipelineRuns are, so there is no need to wait for the TaskRuns then.
+		reconciled := cfg.DryRun.Applies(pr.Namespace) || r.PipelineRunsOnly || signing.Reconciled(ctx, r.Pipelineclientset, objects.NewTaskRunObject(tr))
 		if !reconciled {
 			logging.FromContext(ctx).Infof("taskrun %s within pipelinerun is not yet reconciled", name)
 			return r.trackTaskRun(tr, pr)
 		}
+		taskRuns = append(taskRuns, tr)
+	}
+
+	// Runs that weren't started when the controller began shutting down are left to
+	// the next controller, while runs in flight are given time to finish.
+	if r.Drainer.Draining() {
+		logging.FromContext(ctx).Infof("controller is shutting down, not signing pipelinerun")
+		return controller.NewRequeueImmediately()
+	}
+	ctx, cancel := r.Drainer.Context(ctx, cfg.Shutdown.DrainTimeout)
+	defer cancel()
+
+	if r.ReducedCache {
+		full, err := r.fetchRuns(ctx, pr, taskRuns)
+		if errors.IsNotFound(err) {
+			logging.FromContext(ctx).Infof("pipelinerun or one of its taskruns was deleted before it was signed: %v", err)
+			audit.Skipped(ctx, "pipelinerun", pr, err.Error())
+			return nil
+		} else if err != nil {
+			return err
+		}
+		pr, pro = full, objects.NewPipelineRunObject(full)
+	}
+	for _, tr := range taskRuns {
+		if taskName, ok := iterations[tr.Name]; ok {
+			pro.AppendIterationTaskRun(taskName, tr)
+			continue
+		}
 		pro.AppendTaskRun(tr)
 	}
 
 	if err := r.PipelineRunSigner.Sign(ctx, pro); err != nil {
+		if cfg.Finalizer.BlockDeletion && pr.DeletionTimestamp != nil && !holdFinalizer {
+			logging.FromContext(ctx).Warnf("releasing finalizer of deleted pipelinerun %s/%s after timeout: %v", pr.Namespace, pr.Name, err)
+			return nil
+		}
 		return err
 	}
 	return nil
 }
 
+// fetchRuns returns the full PipelineRun of the reduced pr from the API server, and
+// replaces the reduced taskRuns with the full ones.
+func (r *Reconciler) fetchRuns(ctx context.Context, pr *v1beta1.PipelineRun, taskRuns []*v1beta1.TaskRun) (*v1beta1.PipelineRun, error) {
+	client := r.Pipelineclientset.TektonV1beta1()
+	full, err := client.PipelineRuns(pr.Namespace).Get(ctx, pr.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if r.PipelineRunsOnly {
+		// The TaskRuns were fetched from the API server already.
+		return full, nil
+	}
+	for i, tr := range taskRuns {
+		if taskRuns[i], err = client.TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{}); err != nil {
+			return nil, err
+		}
+	}
+	return full, nil
+}
+
+// getTaskRun returns the TaskRun with the given namespace and name from the lister, or
+// from the API server when TaskRuns aren't watched.
+func (r *Reconciler) getTaskRun(ctx context.Context, namespace, name string) (*v1beta1.TaskRun, error) {
+	if r.PipelineRunsOnly {
+		return r.Pipelineclientset.TektonV1beta1().TaskRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	return r.TaskRunLister.TaskRuns(namespace).Get(name)
+}
+
+// iterationTaskRuns returns the names of the TaskRuns of the iterations of the CustomRun
+// with the given namespace and name, labeled with one of objects.IterationLabels,
+// ordered by iteration.
+func (r *Reconciler) iterationTaskRuns(ctx context.Context, namespace, customRun string) ([]string, error) {
+	var trs []*v1beta1.TaskRun
+	for _, l := range objects.IterationLabels {
+		selector := labels.SelectorFromSet(labels.Set{l.CustomRun: customRun})
+		if r.PipelineRunsOnly {
+			list, err := r.Pipelineclientset.TektonV1beta1().TaskRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+			if err != nil {
+				return nil, err
+			}
+			for i := range list.Items {
+				trs = append(trs, &list.Items[i])
+			}
+			continue
+		}
+		list, err := r.TaskRunLister.TaskRuns(namespace).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		trs = append(trs, list...)
+	}
+	sort.SliceStable(trs, func(i, j int) bool {
+		a, _ := objects.Iteration(trs[i])
+		b, _ := objects.Iteration(trs[j])
+		if a != b {
+			return a < b
+		}
+		return trs[i].Name < trs[j].Name
+	})
+	names := make([]string, 0, len(trs))
+	for _, tr := range trs {
+		names = append(names, tr.Name)
+	}
+	return names, nil
+}
+
 func (r *Reconciler) trackTaskRun(tr *v1beta1.TaskRun, pr *v1beta1.PipelineRun) error {
 	ref := tracker.Reference{
 		APIVersion: "tekton.dev/v1beta1",
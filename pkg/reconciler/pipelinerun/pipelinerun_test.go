@@ -15,11 +15,13 @@ package pipelinerun
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
 	signing "github.com/tektoncd/chains/pkg/chains"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/reduce"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/chains/pkg/internal/mocksigner"
 	"github.com/tektoncd/chains/pkg/test/tekton"
@@ -31,11 +33,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	_ "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	_ "knative.dev/pkg/injection/clients/dynamicclient/fake"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	reconcilertesting "knative.dev/pkg/reconciler/testing"
 	rtesting "knative.dev/pkg/reconciler/testing"
@@ -407,3 +412,199 @@ func TestReconciler_handlePipelineRun(t *testing.T) {
 		})
 	}
 }
+
+func TestReconciler_ReducedCache(t *testing.T) {
+	completed := metav1.Now()
+	pr := &v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "pipelinerun", Namespace: "default"},
+		Spec:       v1beta1.PipelineRunSpec{Params: []v1beta1.Param{{Name: "url"}}},
+		Status: v1beta1.PipelineRunStatus{
+			Status: duckv1.Status{
+				Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+			},
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				ChildReferences: []v1beta1.ChildStatusReference{{Name: "taskrun1", PipelineTaskName: "task1"}},
+			},
+		},
+	}
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "taskrun1",
+			Namespace:   "default",
+			Annotations: map[string]string{signing.ChainsAnnotation: "true"},
+		},
+		Spec: v1beta1.TaskRunSpec{Params: []v1beta1.Param{{Name: "revision"}}},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{CompletionTime: &completed},
+		},
+	}
+	signer := &mocksigner.Signer{}
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	tekton.CreateObject(t, ctx, c, objects.NewPipelineRunObject(pr))
+	tekton.CreateObject(t, ctx, c, objects.NewTaskRunObject(tr))
+
+	tri := faketaskruninformer.Get(ctx)
+	reducedTaskRun, err := reduce.TaskRun(tr.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tri.Informer().GetIndexer().Add(reducedTaskRun); err != nil {
+		t.Fatalf("Adding TaskRun to informer: %v", err)
+	}
+	reducedPipelineRun, err := reduce.PipelineRun(pr.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Reconciler{
+		PipelineRunSigner: signer,
+		Pipelineclientset: c,
+		TaskRunLister:     tri.Lister(),
+		Tracker:           &reconcilertesting.FakeTracker{},
+		ReducedCache:      true,
+	}
+	if err := r.ReconcileKind(ctx, reducedPipelineRun.(*v1beta1.PipelineRun)); err != nil {
+		t.Fatalf("Reconciler.ReconcileKind() error = %v", err)
+	}
+	if !signer.Signed {
+		t.Fatal("expected the pipelinerun to be signed")
+	}
+	pro := signer.Obj.(*objects.PipelineRunObject)
+	if len(pro.Spec.Params) != 1 {
+		t.Errorf("expected the full pipelinerun to be signed, got params %v", pro.Spec.Params)
+	}
+	if trs := pro.GetTaskRuns(); len(trs) != 1 || len(trs[0].Spec.Params) != 1 {
+		t.Errorf("expected the full taskruns to be signed, got %v", trs)
+	}
+}
+
+func TestReconciler_PipelineRunsOnly(t *testing.T) {
+	completed := metav1.Now()
+	pr := &v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "pipelinerun", Namespace: "default"},
+		Status: v1beta1.PipelineRunStatus{
+			Status: duckv1.Status{
+				Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+			},
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				ChildReferences: []v1beta1.ChildStatusReference{{Name: "taskrun1", PipelineTaskName: "task1"}},
+			},
+		},
+	}
+	// The TaskRun isn't signed, and is only known to the API server.
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "taskrun1", Namespace: "default"},
+		Status: v1beta1.TaskRunStatus{
+			TaskRunStatusFields: v1beta1.TaskRunStatusFields{CompletionTime: &completed},
+		},
+	}
+	signer := &mocksigner.Signer{}
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	tekton.CreateObject(t, ctx, c, objects.NewPipelineRunObject(pr))
+	tekton.CreateObject(t, ctx, c, objects.NewTaskRunObject(tr))
+
+	r := &Reconciler{
+		PipelineRunSigner: signer,
+		Pipelineclientset: c,
+		Tracker:           &reconcilertesting.FakeTracker{},
+		PipelineRunsOnly:  true,
+	}
+	if err := r.ReconcileKind(ctx, pr); err != nil {
+		t.Fatalf("Reconciler.ReconcileKind() error = %v", err)
+	}
+	if !signer.Signed {
+		t.Fatal("expected the pipelinerun to be signed")
+	}
+	if trs := signer.Obj.(*objects.PipelineRunObject).GetTaskRuns(); len(trs) != 1 || trs[0].Name != "taskrun1" {
+		t.Errorf("expected the taskrun to be fetched, got %v", trs)
+	}
+
+	// TaskRuns that aren't complete aren't tracked, since they aren't watched.
+	tr.Status.CompletionTime = nil
+	if _, err := c.TektonV1beta1().TaskRuns(tr.Namespace).UpdateStatus(ctx, tr, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	signer.Signed = false
+	err := r.ReconcileKind(ctx, pr)
+	if ok, delay := controller.IsRequeueKey(err); !ok || delay != taskRunRequeueDelay {
+		t.Errorf("Reconciler.ReconcileKind() error = %v, want a requeue after %s", err, taskRunRequeueDelay)
+	}
+	if signer.Signed {
+		t.Error("expected the pipelinerun not to be signed")
+	}
+}
+
+func TestReconciler_CustomTaskIterations(t *testing.T) {
+	completed := metav1.Now()
+	pr := &v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "pipelinerun", Namespace: "default"},
+		Status: v1beta1.PipelineRunStatus{
+			Status: duckv1.Status{
+				Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+			},
+			PipelineRunStatusFields: v1beta1.PipelineRunStatusFields{
+				ChildReferences: []v1beta1.ChildStatusReference{
+					{Name: "taskrun1", PipelineTaskName: "task1"},
+					{TypeMeta: runtime.TypeMeta{Kind: "CustomRun"}, Name: "loop-run", PipelineTaskName: "loop"},
+				},
+			},
+		},
+	}
+	taskRun := func(name string, labels map[string]string) *v1beta1.TaskRun {
+		return &v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Labels:      labels,
+				Annotations: map[string]string{signing.ChainsAnnotation: "true"},
+			},
+			Status: v1beta1.TaskRunStatus{
+				TaskRunStatusFields: v1beta1.TaskRunStatusFields{CompletionTime: &completed},
+			},
+		}
+	}
+	iteration := func(i string) map[string]string {
+		return map[string]string{
+			"custom.tekton.dev/pipelineLoopRun":       "loop-run",
+			"custom.tekton.dev/pipelineLoopIteration": i,
+		}
+	}
+	signer := &mocksigner.Signer{}
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	tekton.CreateObject(t, ctx, c, objects.NewPipelineRunObject(pr))
+	tri := faketaskruninformer.Get(ctx)
+	for _, tr := range []*v1beta1.TaskRun{
+		taskRun("taskrun1", nil),
+		taskRun("loop-run-10", iteration("10")),
+		taskRun("loop-run-2", iteration("2")),
+		taskRun("other-loop-0", map[string]string{"custom.tekton.dev/pipelineLoopRun": "other"}),
+	} {
+		tekton.CreateObject(t, ctx, c, objects.NewTaskRunObject(tr))
+		if err := tri.Informer().GetIndexer().Add(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &Reconciler{
+		PipelineRunSigner: signer,
+		Pipelineclientset: c,
+		TaskRunLister:     tri.Lister(),
+		Tracker:           &reconcilertesting.FakeTracker{},
+	}
+	if err := r.ReconcileKind(ctx, pr); err != nil {
+		t.Fatalf("Reconciler.ReconcileKind() error = %v", err)
+	}
+	if !signer.Signed {
+		t.Fatal("expected the pipelinerun to be signed")
+	}
+	var got []string
+	for _, tr := range signer.Obj.(*objects.PipelineRunObject).GetTaskRuns() {
+		got = append(got, tr.Name)
+	}
+	if want := []string{"taskrun1", "loop-run-2", "loop-run-10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("taskruns = %v, want the iterations of the custom task in order %v", got, want)
+	}
+}
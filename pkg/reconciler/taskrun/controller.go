@@ -16,16 +16,34 @@ package taskrun
 import (
 	"context"
 
+	"github.com/tektoncd/chains/pkg/attestation"
 	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/drain"
+	"github.com/tektoncd/chains/pkg/chains/events"
+	"github.com/tektoncd/chains/pkg/chains/limits"
+	"github.com/tektoncd/chains/pkg/chains/metrics"
+	"github.com/tektoncd/chains/pkg/chains/multicluster"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/profiling"
+	"github.com/tektoncd/chains/pkg/chains/reduce"
 	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/tracing"
 	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/conformance"
+	"github.com/tektoncd/chains/pkg/health"
+	"github.com/tektoncd/chains/pkg/query"
 	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
 	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun"
 	taskrunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1beta1/taskrun"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
 
 	_ "github.com/tektoncd/chains/pkg/chains/formats/all"
 )
@@ -33,6 +51,11 @@ import (
 func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
 	logger := logging.FromContext(ctx)
 	taskRunInformer := taskruninformer.Get(ctx)
+	if reduce.Enabled(ctx) {
+		if err := taskRunInformer.Informer().SetTransform(reduce.TaskRun); err != nil {
+			logger.Errorf("error reducing cached taskruns: %v", err)
+		}
+	}
 
 	kubeClient := kubeclient.Get(ctx)
 	pipelineClient := pipelineclient.Get(ctx)
@@ -40,21 +63,106 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 	tsSigner := &chains.ObjectSigner{
 		SecretPath:        SecretPath,
 		Pipelineclientset: pipelineClient,
+		KubeClient:        kubeClient,
+		DynamicClient:     dynamicclient.Get(ctx),
+		Cluster:           multicluster.FromContext(ctx),
+		LeaseClient:       multicluster.HomeKubeClient(ctx),
+	}
+
+	prober := &conformance.Prober{
+		Pipelineclientset: pipelineClient,
+		DynamicClient:     dynamicclient.Get(ctx),
+		KubeClient:        kubeClient,
+		SecretPath:        SecretPath,
+		Namespace:         system.Namespace(),
+	}
+
+	monitor := &health.Monitor{
+		DynamicClient: dynamicclient.Get(ctx),
+		KubeClient:    kubeClient,
+		SecretPath:    SecretPath,
+	}
+
+	// Entries queued while the transparency log was unreachable are uploaded by the
+	// controller of the cluster they were queued in.
+	tlogQueue := &chains.TransparencyQueue{
+		DynamicClient:     dynamicclient.Get(ctx),
+		Pipelineclientset: pipelineClient,
+	}
+
+	backlog := &chains.Backlog{
+		Kind:    "taskrun",
+		Cluster: multicluster.FromContext(ctx),
+		List: func(config.Config) ([]objects.TektonObject, error) {
+			trs, err := taskRunInformer.Lister().List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			runs := make([]objects.TektonObject, 0, len(trs))
+			for _, tr := range trs {
+				runs = append(runs, objects.NewTaskRunObject(tr))
+			}
+			return runs, nil
+		},
 	}
 
 	c := &Reconciler{
 		TaskRunSigner:     tsSigner,
 		Pipelineclientset: pipelineClient,
+		Drainer:           drain.New(ctx),
+		ReducedCache:      reduce.Enabled(ctx),
 	}
+	var cfgStore *config.ConfigStore
 	impl := taskrunreconciler.NewImpl(ctx, c, func(impl *controller.Impl) controller.Options {
-		cfgStore := config.NewConfigStore(logger, func(name string, value interface{}) {
+		cfgStore = config.NewConfigStore(logger, func(name string, value interface{}) {
 			// get updated config
 			cfg := *value.(*config.Config)
 
 			// get all backends for storing provenance
-			backends, err := storage.InitializeBackends(ctx, pipelineClient, kubeClient, cfg)
-			if err != nil {
-				logger.Error(err)
+			backends, backendsErr := storage.InitializeBackends(ctx, pipelineClient, kubeClient, cfg)
+			if backendsErr != nil {
+				logger.Error(backendsErr)
+			}
+
+			if err := tracing.Setup(ctx, cfg.Tracing); err != nil {
+				logger.Errorf("error configuring tracing: %v", err)
+			}
+			if err := audit.Setup(cfg.Audit); err != nil {
+				logger.Errorf("error configuring audit log: %v", err)
+			}
+			if err := events.Setup(cfg.Events); err != nil {
+				logger.Errorf("error configuring CloudEvents: %v", err)
+			}
+			// The query and attestation APIs, the conformance probe, the health checks and
+			// profiling serve the cluster the controller runs in.
+			if multicluster.FromContext(ctx) == "" {
+				if err := query.Setup(ctx, cfg, backends); err != nil {
+					logger.Errorf("error configuring attestation query API: %v", err)
+				}
+				if err := attestation.Setup(ctx, cfg, &chains.ObjectSigner{
+					Backends:          backends,
+					SecretPath:        SecretPath,
+					Pipelineclientset: pipelineClient,
+					KubeClient:        kubeClient,
+				}); err != nil {
+					logger.Errorf("error configuring attestation gRPC API: %v", err)
+				}
+				prober.Setup(ctx, cfg, backends)
+				if err := monitor.Setup(ctx, cfg, backends, backendsErr); err != nil {
+					logger.Errorf("error configuring health checks: %v", err)
+				}
+				if err := profiling.Setup(ctx, cfg.Profiling); err != nil {
+					logger.Errorf("error configuring profiling: %v", err)
+				}
+			}
+			tlogQueue.Setup(ctx, cfg)
+			backlog.Setup(ctx, cfg)
+			limits.Setup(cfg.Concurrency)
+			metrics.Setup(cfg.Metrics)
+			// Workers are only read when the controller starts, which happens after the
+			// initial config was loaded.
+			if cfg.Concurrency.TaskRunWorkers > 0 {
+				impl.Concurrency = cfg.Concurrency.TaskRunWorkers
 			}
 			tsSigner.Backends = backends
 		})
@@ -70,7 +178,10 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 		}
 	})
 
-	taskRunInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+	taskRunInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: cfgStore.Filter(),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
 
 	return impl
 }
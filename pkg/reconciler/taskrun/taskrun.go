@@ -15,12 +15,20 @@ package taskrun
 
 import (
 	"context"
+	"time"
 
 	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/audit"
+	"github.com/tektoncd/chains/pkg/chains/drain"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/tracing"
+	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	taskrunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1beta1/taskrun"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
 )
@@ -33,6 +41,10 @@ const (
 type Reconciler struct {
 	TaskRunSigner     signing.Signer
 	Pipelineclientset versioned.Interface
+	Drainer           *drain.Drainer
+	// ReducedCache is set when the informer caches reduced TaskRuns, in which case
+	// the full TaskRun is fetched before signing it.
+	ReducedCache bool
 }
 
 // Check that our Reconciler implements taskrunreconciler.Interface and taskrunreconciler.Finalizer
@@ -49,7 +61,21 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, tr *v1beta1.TaskRun) pkg
 // We utilize finalizers to ensure that we get a crack at signing every taskrun
 // that we see flowing through the system.  If we don't add a finalizer, it could
 // get cleaned up before we see the final state and sign it.
-func (r *Reconciler) FinalizeKind(ctx context.Context, tr *v1beta1.TaskRun) pkgreconciler.Event {
+func (r *Reconciler) FinalizeKind(ctx context.Context, tr *v1beta1.TaskRun) (event pkgreconciler.Event) {
+	ctx, span := tracing.Start(ctx, "ReconcileTaskRun",
+		tracing.ObjectKeyAttr.String(tr.Namespace+"/"+tr.Name),
+		tracing.KindAttr.String("taskrun"))
+	defer func() { tracing.End(span, event) }()
+
+	cfg := config.FromContextOrDefaults(ctx)
+	if !cfg.Selects(tr) {
+		logging.FromContext(ctx).Infof("taskrun %s/%s is not selected for signing", tr.Namespace, tr.Name)
+		if tr.IsDone() {
+			audit.Skipped(ctx, "taskrun", tr, "not selected by namespace or label selector")
+		}
+		return nil
+	}
+
 	// Check to make sure the TaskRun is finished.
 	if !tr.IsDone() {
 		logging.FromContext(ctx).Infof("taskrun %s/%s is still running", tr.Namespace, tr.Name)
@@ -58,13 +84,39 @@ func (r *Reconciler) FinalizeKind(ctx context.Context, tr *v1beta1.TaskRun) pkgr
 
 	obj := objects.NewTaskRunObject(tr)
 
-	// Check to see if it has already been signed.
-	if signing.Reconciled(ctx, r.Pipelineclientset, obj) {
+	// Check to see if it has already been signed. Runs being deleted whose signing
+	// failed are tried again while the finalizer is held for them.
+	holdFinalizer := signing.HoldFinalizer(cfg.Finalizer, tr, time.Now())
+	if signing.Reconciled(ctx, r.Pipelineclientset, obj) && !(holdFinalizer && signing.Failed(obj)) {
 		logging.FromContext(ctx).Infof("taskrun %s/%s has been reconciled", tr.Namespace, tr.Name)
 		return nil
 	}
 
+	// Runs that weren't started when the controller began shutting down are left to
+	// the next controller, while runs in flight are given time to finish.
+	if r.Drainer.Draining() {
+		logging.FromContext(ctx).Infof("controller is shutting down, not signing taskrun %s/%s", tr.Namespace, tr.Name)
+		return controller.NewRequeueImmediately()
+	}
+	ctx, cancel := r.Drainer.Context(ctx, cfg.Shutdown.DrainTimeout)
+	defer cancel()
+
+	if r.ReducedCache {
+		full, err := r.Pipelineclientset.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			logging.FromContext(ctx).Infof("taskrun %s/%s was deleted before it was signed", tr.Namespace, tr.Name)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		obj = objects.NewTaskRunObject(full)
+	}
+
 	if err := r.TaskRunSigner.Sign(ctx, obj); err != nil {
+		if cfg.Finalizer.BlockDeletion && tr.DeletionTimestamp != nil && !holdFinalizer {
+			logging.FromContext(ctx).Warnf("releasing finalizer of deleted taskrun %s/%s after timeout: %v", tr.Namespace, tr.Name, err)
+			return nil
+		}
 		return err
 	}
 	return nil
@@ -16,9 +16,12 @@ package taskrun
 import (
 	"context"
 	"testing"
+	"time"
 
 	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/drain"
 	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/reduce"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/chains/pkg/internal/mocksigner"
 	"github.com/tektoncd/chains/pkg/test/tekton"
@@ -29,10 +32,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	_ "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	_ "knative.dev/pkg/injection/clients/dynamicclient/fake"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	rtesting "knative.dev/pkg/reconciler/testing"
 	"knative.dev/pkg/system"
@@ -108,6 +114,7 @@ func TestReconciler_handleTaskRun(t *testing.T) {
 	tests := []struct {
 		name       string
 		tr         *v1beta1.TaskRun
+		cfg        *config.Config
 		shouldSign bool
 	}{
 		{
@@ -149,11 +156,97 @@ func TestReconciler_handleTaskRun(t *testing.T) {
 			},
 			shouldSign: false,
 		},
+		{
+			name: "complete, matches label selector",
+			tr: &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"chains.tekton.dev/sign": "true"},
+				},
+				Status: v1beta1.TaskRunStatus{
+					Status: duckv1.Status{
+						Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+					}},
+			},
+			cfg:        &config.Config{Selector: config.SelectorConfig{LabelSelector: "chains.tekton.dev/sign=true"}},
+			shouldSign: true,
+		},
+		{
+			name: "complete, does not match label selector",
+			tr: &v1beta1.TaskRun{
+				Status: v1beta1.TaskRunStatus{
+					Status: duckv1.Status{
+						Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+					}},
+			},
+			cfg:        &config.Config{Selector: config.SelectorConfig{LabelSelector: "chains.tekton.dev/sign=true"}},
+			shouldSign: false,
+		},
+		{
+			name: "complete, excluded namespace",
+			tr: &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "untrusted",
+				},
+				Status: v1beta1.TaskRunStatus{
+					Status: duckv1.Status{
+						Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+					}},
+			},
+			cfg:        &config.Config{Namespaces: config.NamespaceConfig{Excluded: sets.New[string]("untrusted")}},
+			shouldSign: false,
+		},
+		{
+			name: "deleted, failed, finalizer held",
+			tr: &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations:       map[string]string{signing.ChainsAnnotation: "failed"},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				},
+				Status: v1beta1.TaskRunStatus{
+					Status: duckv1.Status{
+						Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+					}},
+			},
+			cfg:        &config.Config{Finalizer: config.FinalizerConfig{BlockDeletion: true}},
+			shouldSign: true,
+		},
+		{
+			name: "deleted, failed, finalizer timeout elapsed",
+			tr: &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations:       map[string]string{signing.ChainsAnnotation: "failed"},
+					DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+				},
+				Status: v1beta1.TaskRunStatus{
+					Status: duckv1.Status{
+						Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+					}},
+			},
+			cfg:        &config.Config{Finalizer: config.FinalizerConfig{BlockDeletion: true, Timeout: time.Minute}},
+			shouldSign: false,
+		},
+		{
+			name: "deleted, failed, finalizer not held",
+			tr: &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations:       map[string]string{signing.ChainsAnnotation: "failed"},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				},
+				Status: v1beta1.TaskRunStatus{
+					Status: duckv1.Status{
+						Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+					}},
+			},
+			shouldSign: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			signer := &mocksigner.Signer{}
 			ctx, _ := rtesting.SetupFakeContext(t)
+			if tt.cfg != nil {
+				ctx = config.ToContext(ctx, tt.cfg)
+			}
 			c := fakepipelineclient.Get(ctx)
 			tekton.CreateObject(t, ctx, c, objects.NewTaskRunObject(tt.tr))
 
@@ -170,3 +263,66 @@ func TestReconciler_handleTaskRun(t *testing.T) {
 		})
 	}
 }
+
+func TestReconciler_Draining(t *testing.T) {
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "draining"},
+		Status: v1beta1.TaskRunStatus{
+			Status: duckv1.Status{
+				Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+			}},
+	}
+	signer := &mocksigner.Signer{}
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	tekton.CreateObject(t, ctx, c, objects.NewTaskRunObject(tr))
+
+	controllerCtx, shutdown := context.WithCancel(ctx)
+	shutdown()
+	r := &Reconciler{
+		TaskRunSigner:     signer,
+		Pipelineclientset: c,
+		Drainer:           drain.New(controllerCtx),
+	}
+	err := r.ReconcileKind(ctx, tr)
+	if ok, _ := controller.IsRequeueKey(err); !ok {
+		t.Errorf("Reconciler.ReconcileKind() error = %v, want the taskrun to be requeued", err)
+	}
+	if signer.Signed {
+		t.Error("expected taskrun not to be signed while the controller shuts down")
+	}
+}
+
+func TestReconciler_ReducedCache(t *testing.T) {
+	full := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "reduced", Namespace: "default"},
+		Spec:       v1beta1.TaskRunSpec{Params: []v1beta1.Param{{Name: "url"}}},
+		Status: v1beta1.TaskRunStatus{
+			Status: duckv1.Status{
+				Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+			}},
+	}
+	signer := &mocksigner.Signer{}
+	ctx, _ := rtesting.SetupFakeContext(t)
+	c := fakepipelineclient.Get(ctx)
+	tekton.CreateObject(t, ctx, c, objects.NewTaskRunObject(full))
+
+	reduced, err := reduce.TaskRun(full.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Reconciler{
+		TaskRunSigner:     signer,
+		Pipelineclientset: c,
+		ReducedCache:      true,
+	}
+	if err := r.ReconcileKind(ctx, reduced.(*v1beta1.TaskRun)); err != nil {
+		t.Fatalf("Reconciler.ReconcileKind() error = %v", err)
+	}
+	if !signer.Signed {
+		t.Fatal("expected the taskrun to be signed")
+	}
+	if got := signer.Obj.(*objects.TaskRunObject).Spec.Params; len(got) != 1 {
+		t.Errorf("expected the full taskrun to be signed, got params %v", got)
+	}
+}
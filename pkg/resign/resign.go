@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resign clears what Chains recorded on runs it already handled, so that the
+// controller signs them again, for example after a key was compromised or a format
+// generated wrong payloads.
+package resign
+
+import (
+	"context"
+	"time"
+
+	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+)
+
+// pageSize is the number of runs requested from the API server at a time.
+const pageSize = 500
+
+// Resigner resets the runs it selects.
+type Resigner struct {
+	Pipelineclientset versioned.Interface
+	// Namespace restricts the runs to a single namespace. Runs in all namespaces are
+	// selected when it is empty.
+	Namespace string
+	// LabelSelector restricts the runs to those matching it.
+	LabelSelector string
+	// TaskRuns and PipelineRuns select the kinds of runs to reset.
+	TaskRuns, PipelineRuns bool
+	// Names restricts the runs to those with the given names. All runs are selected
+	// when it is empty.
+	Names sets.Set[string]
+	// Since and Until restrict the runs to those that completed in [Since, Until).
+	// Either bound is ignored when zero.
+	Since, Until time.Time
+	// Outdated restricts the runs to those signed with a format version other than the
+	// one they would be signed with now under Config, so that their attestations are
+	// regenerated after the formats or their configuration changed.
+	Outdated bool
+	Config   config.Config
+	// DryRun selects the runs without resetting them.
+	DryRun bool
+}
+
+// Result summarizes a reset.
+type Result struct {
+	// Reset are the runs that were reset, or would have been in dry-run mode, as
+	// "<kind> <namespace>/<name>".
+	Reset []string
+	// Failed is the number of runs that could not be reset.
+	Failed int
+}
+
+// Run resets the TaskRuns and then the PipelineRuns that are selected. Runs Chains
+// didn't handle yet are left alone, the controller signs them anyway. Failures to
+// reset individual runs are logged and counted in the result; an error is only
+// returned if runs could not be listed.
+func (r *Resigner) Run(ctx context.Context) (Result, error) {
+	var result Result
+	if r.TaskRuns {
+		trs, err := r.listTaskRuns(ctx)
+		if err != nil {
+			return result, err
+		}
+		for i := range trs {
+			tr := &trs[i]
+			if obj := objects.NewTaskRunObject(tr); r.selects(ctx, obj, tr.Status.CompletionTime, tr.IsDone()) {
+				r.reset(ctx, &result, obj)
+			}
+		}
+	}
+	// PipelineRuns are reset last, the controller waits for their TaskRuns to be signed.
+	if r.PipelineRuns {
+		prs, err := r.listPipelineRuns(ctx)
+		if err != nil {
+			return result, err
+		}
+		for i := range prs {
+			pr := &prs[i]
+			if obj := objects.NewPipelineRunObject(pr); r.selects(ctx, obj, pr.Status.CompletionTime, pr.IsDone()) {
+				r.reset(ctx, &result, obj)
+			}
+		}
+	}
+	return result, nil
+}
+
+// selects returns whether a run is completed, carries annotations to reset, and is
+// selected by its name, format version and completion time.
+func (r *Resigner) selects(ctx context.Context, obj objects.TektonObject, completed *metav1.Time, done bool) bool {
+	if !done || len(signing.ResetAnnotations(obj.GetAnnotations())) == 0 {
+		return false
+	}
+	if r.Names.Len() > 0 && !r.Names.Has(obj.GetName()) {
+		return false
+	}
+	if r.Outdated {
+		outdated, err := signing.Outdated(r.Config, obj)
+		if err != nil {
+			logging.FromContext(ctx).Warnf("resign: error getting the format version of %s %s/%s: %v", obj.GetKindName(), obj.GetNamespace(), obj.GetName(), err)
+			return false
+		}
+		if !outdated {
+			return false
+		}
+	}
+	if completed == nil {
+		return r.Since.IsZero() && r.Until.IsZero()
+	}
+	if !r.Since.IsZero() && completed.Time.Before(r.Since) {
+		return false
+	}
+	return r.Until.IsZero() || completed.Time.Before(r.Until)
+}
+
+func (r *Resigner) reset(ctx context.Context, result *Result, obj objects.TektonObject) {
+	run := obj.GetKindName() + " " + obj.GetNamespace() + "/" + obj.GetName()
+	if !r.DryRun {
+		if err := signing.Reset(ctx, obj, r.Pipelineclientset); err != nil {
+			logging.FromContext(ctx).Warnf("resign: error resetting %s: %v", run, err)
+			result.Failed++
+			return
+		}
+	}
+	result.Reset = append(result.Reset, run)
+}
+
+func (r *Resigner) listOptions() metav1.ListOptions {
+	return metav1.ListOptions{Limit: pageSize, LabelSelector: r.LabelSelector}
+}
+
+func (r *Resigner) listTaskRuns(ctx context.Context) ([]v1beta1.TaskRun, error) {
+	var trs []v1beta1.TaskRun
+	opts := r.listOptions()
+	for {
+		list, err := r.Pipelineclientset.TektonV1beta1().TaskRuns(r.Namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		trs = append(trs, list.Items...)
+		if list.Continue == "" {
+			return trs, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+func (r *Resigner) listPipelineRuns(ctx context.Context) ([]v1beta1.PipelineRun, error) {
+	var prs []v1beta1.PipelineRun
+	opts := r.listOptions()
+	for {
+		list, err := r.Pipelineclientset.TektonV1beta1().PipelineRuns(r.Namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, list.Items...)
+		if list.Continue == "" {
+			return prs, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resign
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func completed() duckv1.Status {
+	return duckv1.Status{
+		Conditions: []apis.Condition{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue}},
+	}
+}
+
+func TestResigner_Run(t *testing.T) {
+	now := time.Now()
+	recent := &metav1.Time{Time: now.Add(-time.Hour)}
+	old := &metav1.Time{Time: now.Add(-48 * time.Hour)}
+	signed := map[string]string{signing.ChainsAnnotation: "true", signing.RetryAnnotation: "0"}
+	cfg, err := config.NewConfigFromMap(map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := map[string]string{signing.ChainsAnnotation: "true", signing.FormatVersionAnnotation: signing.FormatVersion(*cfg)}
+
+	taskRun := func(name, namespace string, labels, annotations map[string]string, completion *metav1.Time) *v1beta1.TaskRun {
+		tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels, Annotations: annotations}}
+		if completion != nil {
+			tr.Status.Status = completed()
+			tr.Status.CompletionTime = completion
+		}
+		return tr
+	}
+	runs := []*v1beta1.TaskRun{
+		taskRun("signed", "default", map[string]string{"app": "foo"}, signed, recent),
+		taskRun("old", "default", nil, map[string]string{signing.ChainsAnnotation: "failed"}, old),
+		taskRun("unsigned", "default", nil, nil, recent),
+		taskRun("running", "default", nil, signed, nil),
+		taskRun("other", "team-a", nil, signed, recent),
+		taskRun("current", "team-a", nil, current, recent),
+	}
+	pr := &v1beta1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "pipeline", Namespace: "default", Annotations: signed},
+	}
+	pr.Status.Status = completed()
+	pr.Status.CompletionTime = recent
+
+	tests := []struct {
+		name     string
+		resigner Resigner
+		want     []string
+	}{{
+		name:     "all",
+		resigner: Resigner{TaskRuns: true, PipelineRuns: true},
+		want:     []string{"taskrun default/old", "taskrun default/signed", "taskrun team-a/current", "taskrun team-a/other", "pipelinerun default/pipeline"},
+	}, {
+		name:     "namespace and kind",
+		resigner: Resigner{Namespace: "default", TaskRuns: true},
+		want:     []string{"taskrun default/old", "taskrun default/signed"},
+	}, {
+		name:     "label selector",
+		resigner: Resigner{LabelSelector: "app=foo", TaskRuns: true, PipelineRuns: true},
+		want:     []string{"taskrun default/signed"},
+	}, {
+		name:     "names",
+		resigner: Resigner{Names: sets.New[string]("old", "pipeline"), TaskRuns: true, PipelineRuns: true},
+		want:     []string{"taskrun default/old", "pipelinerun default/pipeline"},
+	}, {
+		name:     "time range",
+		resigner: Resigner{Since: now.Add(-72 * time.Hour), Until: now.Add(-24 * time.Hour), TaskRuns: true, PipelineRuns: true},
+		want:     []string{"taskrun default/old"},
+	}, {
+		name:     "outdated",
+		resigner: Resigner{Outdated: true, Config: *cfg, TaskRuns: true, PipelineRuns: true},
+		want:     []string{"taskrun default/signed", "taskrun team-a/other", "pipelinerun default/pipeline"},
+	}}
+	for _, tt := range tests {
+		for _, dryRun := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s dry-run=%t", tt.name, dryRun), func(t *testing.T) {
+				ctx, _ := rtesting.SetupFakeContext(t)
+				ps := fakepipelineclient.Get(ctx)
+				for _, tr := range runs {
+					tekton.CreateObject(t, ctx, ps, objects.NewTaskRunObject(tr.DeepCopy()))
+				}
+				tekton.CreateObject(t, ctx, ps, objects.NewPipelineRunObject(pr.DeepCopy()))
+
+				r := tt.resigner
+				r.Pipelineclientset, r.DryRun = ps, dryRun
+				result, err := r.Run(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(Result{Reset: tt.want}, result); diff != "" {
+					t.Errorf("Run() (-want +got): %s", diff)
+				}
+
+				reset := sets.New[string](tt.want...)
+				if dryRun {
+					reset = sets.New[string]()
+				}
+				objs := []objects.TektonObject{objects.NewPipelineRunObject(pr)}
+				for _, tr := range runs {
+					objs = append(objs, objects.NewTaskRunObject(tr))
+				}
+				for _, obj := range objs {
+					latest, err := tekton.GetObject(t, ctx, ps, obj)
+					if err != nil {
+						t.Fatal(err)
+					}
+					run := obj.GetKindName() + " " + obj.GetNamespace() + "/" + obj.GetName()
+					_, had := obj.GetAnnotations()[signing.ChainsAnnotation]
+					if _, ok := latest.GetAnnotations()[signing.ChainsAnnotation]; ok != (had && !reset.Has(run)) {
+						t.Errorf("%s has annotations %v, want reset: %t", run, latest.GetAnnotations(), reset.Has(run))
+					}
+				}
+			})
+		}
+	}
+}
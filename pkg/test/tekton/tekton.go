@@ -39,6 +39,12 @@ func CreateObject(t *testing.T, ctx context.Context, ps pipelineclientset.Interf
 			t.Fatalf("error creating taskrun: %v", err)
 		}
 		return objects.NewTaskRunObject(tr)
+	case *v1beta1.CustomRun:
+		cr, err := ps.TektonV1beta1().CustomRuns(obj.GetNamespace()).Create(ctx, o, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("error creating customrun: %v", err)
+		}
+		return objects.NewCustomRunObject(cr)
 	}
 	return nil
 }
@@ -50,6 +56,8 @@ func GetObject(t *testing.T, ctx context.Context, ps pipelineclientset.Interface
 		return GetPipelineRun(t, ctx, ps, obj.GetNamespace(), obj.GetName())
 	case *v1beta1.TaskRun:
 		return GetTaskRun(t, ctx, ps, obj.GetNamespace(), obj.GetName())
+	case *v1beta1.CustomRun:
+		return GetCustomRun(t, ctx, ps, obj.GetNamespace(), obj.GetName())
 	}
 	t.Fatalf("unknown object type %T", obj.GetObject())
 	return nil, fmt.Errorf("unknown object type %T", obj.GetObject())
@@ -71,6 +79,14 @@ func GetTaskRun(t *testing.T, ctx context.Context, ps pipelineclientset.Interfac
 	return objects.NewTaskRunObject(tr), nil
 }
 
+func GetCustomRun(t *testing.T, ctx context.Context, ps pipelineclientset.Interface, namespace, name string) (objects.TektonObject, error) {
+	cr, err := ps.TektonV1beta1().CustomRuns(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting customrun: %v", err)
+	}
+	return objects.NewCustomRunObject(cr), nil
+}
+
 func WatchObject(t *testing.T, ctx context.Context, ps pipelineclientset.Interface, obj objects.TektonObject) (watch.Interface, error) {
 	switch o := obj.GetObject().(type) {
 	case *v1beta1.PipelineRun:
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/cosign/v2/pkg/oci"
+	sigs "github.com/sigstore/cosign/v2/pkg/signature"
+)
+
+// Image verifies the signatures and attestations Chains stored for the image ref in
+// its registry. cosign verifies the signatures, certificates and transparency log
+// entries; the predicates of the attestations are then checked.
+func Image(ctx context.Context, ref name.Reference, opts Options) []Result {
+	var results []Result
+
+	co := opts.checkOpts()
+	co.ClaimVerifier = cosign.SimpleClaimVerifier
+	signatures, _, err := cosign.VerifyImageSignatures(ctx, ref, co)
+	if err != nil {
+		results = append(results, failedResult("oci", ref.String()+" signature", err.Error()))
+	}
+	for _, sig := range signatures {
+		r := imageResult(opts, ref.String()+" signature", sig)
+		r.Checks = append(r.Checks, Check{Name: CheckPredicate, Status: StatusSkipped, Detail: "payload is not an in-toto statement"})
+		results = append(results, r)
+	}
+
+	co = opts.checkOpts()
+	co.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
+	attestations, _, err := cosign.VerifyImageAttestations(ctx, ref, co)
+	if err != nil {
+		results = append(results, failedResult("oci", ref.String()+" attestation", err.Error()))
+	}
+	for _, att := range attestations {
+		r := imageResult(opts, ref.String()+" attestation", att)
+		r.Checks = append(r.Checks, attestationPredicate(att))
+		results = append(results, r)
+	}
+	return results
+}
+
+// imageResult returns the result of the checks cosign ran on sig.
+func imageResult(opts Options, key string, sig oci.Signature) Result {
+	r := Result{Source: "oci", Key: key}
+
+	certCheck := Check{Name: CheckCertificate, Status: StatusSkipped, Detail: "signature verified with a key"}
+	if opts.Key == nil {
+		certCheck.Status, certCheck.Detail = StatusPassed, ""
+		if cert, err := sig.Cert(); err == nil && cert != nil {
+			certCheck.Detail = fmt.Sprintf("issued to %s", sigs.CertSubject(cert))
+		}
+	}
+	r.Checks = append(r.Checks, certCheck, Check{Name: CheckSignature, Status: StatusPassed})
+
+	tlogCheck := Check{Name: CheckTransparency, Status: StatusSkipped, Detail: "transparency log verification disabled"}
+	if opts.Rekor != nil {
+		tlogCheck.Status, tlogCheck.Detail = StatusPassed, ""
+		if b, err := sig.Bundle(); err == nil && b != nil {
+			tlogCheck.Detail = fmt.Sprintf("log index %d", b.Payload.LogIndex)
+		}
+	}
+	r.Checks = append(r.Checks, tlogCheck)
+	return r
+}
+
+// attestationPredicate checks the predicate of the statement in the DSSE envelope att.
+func attestationPredicate(att oci.Signature) Check {
+	raw, err := att.Payload()
+	if err != nil {
+		return Check{Name: CheckPredicate, Status: StatusFailed, Detail: err.Error()}
+	}
+	var env ssldsse.Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return Check{Name: CheckPredicate, Status: StatusFailed, Detail: fmt.Sprintf("invalid envelope: %v", err)}
+	}
+	payload, err := env.DecodeB64Payload()
+	if err != nil {
+		return Check{Name: CheckPredicate, Status: StatusFailed, Detail: fmt.Sprintf("invalid envelope payload: %v", err)}
+	}
+	return checkPredicate(payload)
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"errors"
+
+	"github.com/tektoncd/chains/pkg/chains/validation"
+)
+
+// checkPredicate runs basic sanity checks on the in-toto statement in payload and its
+// SLSA provenance predicate. It doesn't check the build against any policy.
+func checkPredicate(payload []byte) Check {
+	check := Check{Name: CheckPredicate}
+	predicateType, err := validation.Validate(payload)
+	switch {
+	case errors.Is(err, validation.ErrNotStatement), errors.Is(err, validation.ErrUnknownPredicateType):
+		check.Status, check.Detail = StatusSkipped, err.Error()
+	case err != nil:
+		check.Status, check.Detail = StatusFailed, err.Error()
+	default:
+		check.Status, check.Detail = StatusPassed, predicateType
+	}
+	return check
+}
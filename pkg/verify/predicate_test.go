@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import "testing"
+
+func TestCheckPredicate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    Status
+	}{{
+		name:    "slsa v0.2",
+		payload: provenance,
+		want:    StatusPassed,
+	}, {
+		name: "slsa v1",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v1",
+			"predicateType": "https://slsa.dev/provenance/v1",
+			"subject": [{"name": "gcr.io/foo/bar", "digest": {"sha256": "abc"}}],
+			"predicate": {
+				"buildDefinition": {"buildType": "https://tekton.dev/chains/v2/slsa"},
+				"runDetails": {
+					"builder": {"id": "https://tekton.dev/chains/v2"},
+					"metadata": {"startedOn": "2023-01-01T10:00:00Z", "finishedOn": "2023-01-01T10:05:00Z"}
+				}
+			}
+		}`,
+		want: StatusPassed,
+	}, {
+		name: "missing builder",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://slsa.dev/provenance/v0.2",
+			"predicate": {"buildType": "tekton.dev/v1beta1/TaskRun"}
+		}`,
+		want: StatusFailed,
+	}, {
+		name: "finished before started",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://slsa.dev/provenance/v0.2",
+			"predicate": {
+				"builder": {"id": "https://tekton.dev/chains/v2"},
+				"buildType": "tekton.dev/v1beta1/TaskRun",
+				"metadata": {"buildStartedOn": "2023-01-01T10:05:00Z", "buildFinishedOn": "2023-01-01T10:00:00Z"}
+			}
+		}`,
+		want: StatusFailed,
+	}, {
+		name: "subject without digest",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://slsa.dev/provenance/v0.2",
+			"subject": [{"name": "gcr.io/foo/bar"}],
+			"predicate": {
+				"builder": {"id": "https://tekton.dev/chains/v2"},
+				"buildType": "tekton.dev/v1beta1/TaskRun"
+			}
+		}`,
+		want: StatusFailed,
+	}, {
+		name: "unknown predicate type",
+		payload: `{
+			"_type": "https://in-toto.io/Statement/v0.1",
+			"predicateType": "https://example.com/custom",
+			"predicate": {}
+		}`,
+		want: StatusSkipped,
+	}, {
+		name:    "not a statement",
+		payload: `{"status": "Succeeded"}`,
+		want:    StatusSkipped,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkPredicate([]byte(tt.payload))
+			if got.Status != tt.want {
+				t.Errorf("checkPredicate() = %+v, want status %s", got, tt.want)
+			}
+		})
+	}
+}
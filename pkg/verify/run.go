@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/storage/tekton"
+	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Run verifies the attestations of the TaskRun, PipelineRun or CustomRun obj stored in the
+// storage backends that cfg, with the overrides in the annotations of obj, configures
+// for it. It returns an error if no attestation was found.
+func Run(ctx context.Context, obj objects.TektonObject, backends map[string]storage.Backend, cfg config.Config, opts Options) ([]Result, error) {
+	stored, errs, err := chains.RetrieveAttestations(ctx, obj, backends, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var shortKey string
+	switch obj.(type) {
+	case *objects.PipelineRunObject:
+		shortKey = (&artifacts.PipelineRunArtifact{}).ShortKey(obj)
+	case *objects.CustomRunObject:
+		shortKey = (&artifacts.CustomRunArtifact{}).ShortKey(obj)
+	default:
+		shortKey = (&artifacts.TaskRunArtifact{}).ShortKey(obj)
+	}
+	annotations := obj.GetAnnotations()
+	_, uploaded := annotations[chains.ChainsTransparencyAnnotation]
+	// Only the tekton backend stores the signing certificate, in an annotation of the run.
+	cert := decodedAnnotation(annotations, fmt.Sprintf(tekton.CertAnnotationsFormat, shortKey))
+	chain := decodedAnnotation(annotations, fmt.Sprintf(tekton.ChainAnnotationFormat, shortKey))
+
+	var results []Result
+	for _, name := range sets.List[string](sets.KeySet(errs)) {
+		results = append(results, failedResult(name, shortKey, errs[name].Error()))
+	}
+	for _, s := range stored {
+		a := attestation{
+			source:    s.Backend,
+			key:       s.Key,
+			payload:   s.Payload,
+			signature: s.Signature,
+			cert:      cert,
+			chain:     chain,
+			uploaded:  uploaded,
+		}
+		results = append(results, opts.verify(ctx, a))
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no attestations found for %s %s/%s", obj.GetKindName(), obj.GetNamespace(), obj.GetName())
+	}
+	return results, nil
+}
+
+func decodedAnnotation(annotations map[string]string, key string) []byte {
+	val, _, err := tekton.AnnotationValue(annotations, key)
+	if err != nil {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(val)
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
+
+func failedResult(source, key, detail string) Result {
+	return Result{Source: source, Key: key, Checks: []Check{{Name: CheckSignature, Status: StatusFailed, Detail: detail}}}
+}
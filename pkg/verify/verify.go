@@ -0,0 +1,316 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package verify checks the attestations Chains produced for runs and images: their
+// signatures, signing certificates, transparency log entries and SLSA predicates.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	ssldsse "github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	sigs "github.com/sigstore/cosign/v2/pkg/signature"
+	"github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/client/index"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/dsse"
+)
+
+// Names of the checks run on every attestation.
+const (
+	CheckSignature    = "signature"
+	CheckCertificate  = "certificate"
+	CheckTransparency = "transparency"
+	CheckPredicate    = "predicate"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Check is the outcome of a single check of an attestation.
+type Check struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Detail explains why the check failed or was skipped, or what was verified.
+	Detail string `json:"detail,omitempty"`
+}
+
+// Result is the outcome of verifying a single attestation.
+type Result struct {
+	// Source is where the attestation was retrieved from, e.g. a storage backend.
+	Source string `json:"source"`
+	// Key identifies the attestation in its source.
+	Key    string  `json:"key"`
+	Checks []Check `json:"checks"`
+}
+
+// Failed returns whether any check of r failed.
+func (r Result) Failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Options configures how attestations are verified.
+type Options struct {
+	// Key verifies signatures made with a key. If it is nil, signatures are verified
+	// with the public key of their signing certificate, which must chain up to Roots.
+	Key signature.Verifier
+	// Roots and Intermediates are the certificates signing certificates are checked
+	// against.
+	Roots         *x509.CertPool
+	Intermediates *x509.CertPool
+	// Identities restricts the subjects and issuers of signing certificates. Any
+	// certificate is accepted if it is empty.
+	Identities []cosign.Identity
+	// Rekor is the transparency log inclusion is checked in. Transparency is not
+	// checked if it is nil.
+	Rekor *client.Rekor
+	// RekorPubKeys are the keys the signed entry timestamps of Rekor are checked with.
+	RekorPubKeys *cosign.TrustedTransparencyLogPubKeys
+}
+
+// checkOpts returns the cosign options equivalent to o.
+func (o Options) checkOpts() *cosign.CheckOpts {
+	return &cosign.CheckOpts{
+		SigVerifier:       o.Key,
+		RootCerts:         o.Roots,
+		IntermediateCerts: o.Intermediates,
+		Identities:        o.Identities,
+		RekorClient:       o.Rekor,
+		RekorPubKeys:      o.RekorPubKeys,
+		IgnoreTlog:        o.Rekor == nil,
+		// Chains doesn't record detached SCTs, and embedded ones are covered by the
+		// transparency log entry.
+		IgnoreSCT: true,
+	}
+}
+
+// attestation is a signed payload retrieved from a storage backend.
+type attestation struct {
+	source    string
+	key       string
+	payload   []byte
+	signature []byte
+	// cert and chain are the PEM-encoded signing certificate and its chain, if any.
+	cert  []byte
+	chain []byte
+	// uploaded is whether the run records that the signature was uploaded to the
+	// transparency log.
+	uploaded bool
+}
+
+// verify runs all checks on a.
+func (o Options) verify(ctx context.Context, a attestation) Result {
+	r := Result{Source: a.source, Key: a.key}
+
+	verifier, cert, certCheck := o.verifier(a)
+	r.Checks = append(r.Checks, certCheck)
+
+	statement, sigCheck := checkSignature(verifier, a)
+	r.Checks = append(r.Checks, sigCheck)
+
+	r.Checks = append(r.Checks, o.checkTransparency(ctx, a, cert))
+
+	if sigCheck.Status == StatusPassed {
+		r.Checks = append(r.Checks, checkPredicate(statement))
+	} else {
+		r.Checks = append(r.Checks, Check{Name: CheckPredicate, Status: StatusSkipped, Detail: "signature not verified"})
+	}
+	return r
+}
+
+// verifier returns the verifier of the signature of a and its signing certificate,
+// if any, along with the outcome of checking the certificate.
+func (o Options) verifier(a attestation) (signature.Verifier, *x509.Certificate, Check) {
+	check := Check{Name: CheckCertificate}
+	var cert *x509.Certificate
+	if len(a.cert) > 0 {
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(a.cert)
+		if err != nil || len(certs) == 0 {
+			check.Status, check.Detail = StatusFailed, fmt.Sprintf("invalid certificate: %v", err)
+			return o.Key, nil, check
+		}
+		cert = certs[0]
+	}
+
+	switch {
+	case o.Key != nil:
+		check.Status, check.Detail = StatusSkipped, "signature verified with a key"
+		return o.Key, cert, check
+	case cert == nil:
+		check.Status, check.Detail = StatusFailed, "no certificate stored and no key to verify the signature with"
+		return nil, nil, check
+	}
+
+	co := o.checkOpts()
+	if len(a.chain) > 0 {
+		// The stored chain only helps to build a path to the trusted roots, it is not
+		// trusted itself.
+		chain, err := cryptoutils.UnmarshalCertificatesFromPEM(a.chain)
+		if err != nil {
+			check.Status, check.Detail = StatusFailed, fmt.Sprintf("invalid certificate chain: %v", err)
+			return nil, cert, check
+		}
+		if co.IntermediateCerts == nil {
+			co.IntermediateCerts = x509.NewCertPool()
+		} else {
+			co.IntermediateCerts = co.IntermediateCerts.Clone()
+		}
+		for _, c := range chain {
+			co.IntermediateCerts.AddCert(c)
+		}
+	}
+	verifier, err := cosign.ValidateAndUnpackCert(cert, co)
+	if err != nil {
+		check.Status, check.Detail = StatusFailed, err.Error()
+		return nil, cert, check
+	}
+	check.Status, check.Detail = StatusPassed, fmt.Sprintf("issued to %s", sigs.CertSubject(cert))
+	return verifier, cert, check
+}
+
+// checkSignature verifies the signature of a with verifier. It returns the signed
+// payload, which is the payload of the envelope if the signature is a DSSE envelope.
+func checkSignature(verifier signature.Verifier, a attestation) ([]byte, Check) {
+	check := Check{Name: CheckSignature}
+	if verifier == nil {
+		check.Status, check.Detail = StatusFailed, "no key or certificate to verify the signature with"
+		return nil, check
+	}
+
+	var env ssldsse.Envelope
+	if err := json.Unmarshal(a.signature, &env); err == nil && len(env.Signatures) > 0 {
+		if err := dsse.WrapVerifier(verifier).VerifySignature(bytes.NewReader(a.signature), nil); err != nil {
+			check.Status, check.Detail = StatusFailed, fmt.Sprintf("invalid envelope signature: %v", err)
+			return nil, check
+		}
+		payload, err := env.DecodeB64Payload()
+		if err != nil {
+			check.Status, check.Detail = StatusFailed, fmt.Sprintf("invalid envelope payload: %v", err)
+			return nil, check
+		}
+		if len(a.payload) > 0 && !sameJSON(payload, a.payload) {
+			check.Status, check.Detail = StatusFailed, "stored payload differs from the signed envelope payload"
+			return nil, check
+		}
+		check.Status, check.Detail = StatusPassed, "DSSE envelope"
+		return payload, check
+	}
+
+	sig := a.signature
+	err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(a.payload))
+	if err != nil {
+		// Some backends store signatures base64-encoded.
+		if decoded, derr := base64.StdEncoding.DecodeString(string(sig)); derr == nil {
+			err = verifier.VerifySignature(bytes.NewReader(decoded), bytes.NewReader(a.payload))
+		}
+	}
+	if err != nil {
+		check.Status, check.Detail = StatusFailed, err.Error()
+		return nil, check
+	}
+	check.Status = StatusPassed
+	return a.payload, check
+}
+
+// checkTransparency checks that an entry for the payload of a is included in the
+// transparency log, and that cert, if any, was valid when the entry was integrated.
+func (o Options) checkTransparency(ctx context.Context, a attestation, cert *x509.Certificate) Check {
+	check := Check{Name: CheckTransparency}
+	if o.Rekor == nil {
+		check.Status, check.Detail = StatusSkipped, "transparency log verification disabled"
+		return check
+	}
+
+	entry, err := o.findEntry(ctx, a)
+	switch {
+	case errors.Is(err, errNoEntry) && !a.uploaded:
+		check.Status, check.Detail = StatusSkipped, "not uploaded to the transparency log"
+		return check
+	case err != nil:
+		check.Status, check.Detail = StatusFailed, err.Error()
+		return check
+	}
+	if cert != nil && entry.IntegratedTime != nil {
+		if err := cosign.CheckExpiry(cert, time.Unix(*entry.IntegratedTime, 0)); err != nil {
+			check.Status, check.Detail = StatusFailed, fmt.Sprintf("certificate not valid when the entry was integrated: %v", err)
+			return check
+		}
+	}
+	check.Status, check.Detail = StatusPassed, fmt.Sprintf("log index %d", *entry.LogIndex)
+	return check
+}
+
+var errNoEntry = errors.New("no entry for the payload in the transparency log")
+
+// findEntry returns the first entry of the transparency log that records the digest
+// of the payload of a and whose inclusion proof and signed entry timestamp are valid.
+func (o Options) findEntry(ctx context.Context, a attestation) (*models.LogEntryAnon, error) {
+	digest := sha256.Sum256(a.payload)
+	params := index.NewSearchIndexParamsWithContext(ctx)
+	params.SetQuery(&models.SearchIndex{Hash: "sha256:" + hex.EncodeToString(digest[:])})
+	resp, err := o.Rekor.Index.SearchIndex(params)
+	if err != nil {
+		return nil, fmt.Errorf("searching the transparency log: %w", err)
+	}
+
+	var lastErr error = errNoEntry
+	for _, uuid := range resp.Payload {
+		entry, err := cosign.GetTlogEntry(ctx, o.Rekor, uuid)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := cosign.VerifyTLogEntryOffline(ctx, entry, o.RekorPubKeys); err != nil {
+			lastErr = fmt.Errorf("entry %s: %w", uuid, err)
+			continue
+		}
+		return entry, nil
+	}
+	return nil, lastErr
+}
+
+// sameJSON returns whether a and b are the same JSON document, ignoring insignificant
+// whitespace.
+func sameJSON(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/dsse"
+	"github.com/tektoncd/chains/pkg/chains/objects"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/storage/tekton"
+	"github.com/tektoncd/chains/pkg/config"
+	testtekton "github.com/tektoncd/chains/pkg/test/tekton"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+const provenance = `{
+	"_type": "https://in-toto.io/Statement/v0.1",
+	"predicateType": "https://slsa.dev/provenance/v0.2",
+	"subject": [{"name": "gcr.io/foo/bar", "digest": {"sha256": "abc"}}],
+	"predicate": {
+		"builder": {"id": "https://tekton.dev/chains/v2"},
+		"buildType": "tekton.dev/v1beta1/TaskRun"
+	}
+}`
+
+func newSignerVerifier(t *testing.T) (*ecdsa.PrivateKey, signature.SignerVerifier) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, sv
+}
+
+// newCert returns a CA and a code signing certificate it issued for priv.
+func newCert(t *testing.T, priv *ecdsa.PrivateKey) (*x509.Certificate, []byte) {
+	t.Helper()
+	caPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caPriv.PublicKey, caPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		NotBefore:      time.Now().Add(-time.Minute),
+		NotAfter:       time.Now().Add(10 * time.Minute),
+		EmailAddresses: []string{"chains@example.com"},
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, &priv.PublicKey, caPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pem, err := cryptoutils.MarshalCertificateToPEM(leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, pem
+}
+
+func statuses(results []Result) map[string]map[string]Status {
+	out := map[string]map[string]Status{}
+	for _, r := range results {
+		out[r.Source] = map[string]Status{}
+		for _, c := range r.Checks {
+			out[r.Source][c.Name] = c.Status
+		}
+	}
+	return out
+}
+
+func TestRun(t *testing.T) {
+	priv, sv := newSignerVerifier(t)
+	_, otherSV := newSignerVerifier(t)
+	ca, cert := newCert(t, priv)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	otherCA, _ := newCert(t, priv)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(otherCA)
+
+	envelope, err := dsse.WrapSigner(sv, in_toto.PayloadType).SignMessage(bytes.NewReader([]byte(provenance)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tektonPayload := []byte(`{"status": "Succeeded"}`)
+	rawSig, err := sv.SignMessage(bytes.NewReader(tektonPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature []byte
+		cert      []byte
+		opts      Options
+		want      map[string]Status
+	}{{
+		name:      "in-toto signed with key",
+		payload:   []byte(provenance),
+		signature: envelope,
+		opts:      Options{Key: sv},
+		want: map[string]Status{
+			CheckCertificate: StatusSkipped, CheckSignature: StatusPassed,
+			CheckTransparency: StatusSkipped, CheckPredicate: StatusPassed,
+		},
+	}, {
+		name:      "raw signature of tekton payload",
+		payload:   tektonPayload,
+		signature: rawSig,
+		opts:      Options{Key: sv},
+		want: map[string]Status{
+			CheckCertificate: StatusSkipped, CheckSignature: StatusPassed,
+			CheckTransparency: StatusSkipped, CheckPredicate: StatusSkipped,
+		},
+	}, {
+		name:      "wrong key",
+		payload:   []byte(provenance),
+		signature: envelope,
+		opts:      Options{Key: otherSV},
+		want: map[string]Status{
+			CheckCertificate: StatusSkipped, CheckSignature: StatusFailed,
+			CheckTransparency: StatusSkipped, CheckPredicate: StatusSkipped,
+		},
+	}, {
+		name:      "tampered payload",
+		payload:   []byte(`{"_type": "https://in-toto.io/Statement/v0.1"}`),
+		signature: envelope,
+		opts:      Options{Key: sv},
+		want: map[string]Status{
+			CheckCertificate: StatusSkipped, CheckSignature: StatusFailed,
+			CheckTransparency: StatusSkipped, CheckPredicate: StatusSkipped,
+		},
+	}, {
+		name:      "trusted certificate",
+		payload:   []byte(provenance),
+		signature: envelope,
+		cert:      cert,
+		opts:      Options{Roots: roots},
+		want: map[string]Status{
+			CheckCertificate: StatusPassed, CheckSignature: StatusPassed,
+			CheckTransparency: StatusSkipped, CheckPredicate: StatusPassed,
+		},
+	}, {
+		name:      "untrusted certificate",
+		payload:   []byte(provenance),
+		signature: envelope,
+		cert:      cert,
+		opts:      Options{Roots: otherRoots},
+		want: map[string]Status{
+			CheckCertificate: StatusFailed, CheckSignature: StatusFailed,
+			CheckTransparency: StatusSkipped, CheckPredicate: StatusSkipped,
+		},
+	}, {
+		name:      "no key or certificate",
+		payload:   []byte(provenance),
+		signature: envelope,
+		opts:      Options{Roots: roots},
+		want: map[string]Status{
+			CheckCertificate: StatusFailed, CheckSignature: StatusFailed,
+			CheckTransparency: StatusSkipped, CheckPredicate: StatusSkipped,
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: types.UID("uid")},
+			})
+			testtekton.CreateObject(t, ctx, ps, obj)
+
+			b := tekton.NewStorageBackend(ps, config.Config{})
+			opts := config.StorageOpts{ShortKey: "taskrun-uid", Cert: string(tt.cert)}
+			if err := b.StorePayload(ctx, obj, tt.payload, string(tt.signature), opts); err != nil {
+				t.Fatal(err)
+			}
+			updated, err := testtekton.GetObject(t, ctx, ps, obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := config.Config{Artifacts: config.ArtifactConfigs{TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.New[string]("tekton"),
+			}}}
+			results, err := Run(ctx, updated, map[string]storage.Backend{"tekton": b}, cfg, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(map[string]map[string]Status{"tekton": tt.want}, statuses(results)); diff != "" {
+				t.Errorf("Run() (-want +got): %s\n%+v", diff, results)
+			}
+		})
+	}
+}
+
+func TestRun_Unsigned(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	obj := objects.NewTaskRunObject(&v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: types.UID("uid")},
+	})
+	testtekton.CreateObject(t, ctx, ps, obj)
+
+	cfg := config.Config{Artifacts: config.ArtifactConfigs{TaskRuns: config.Artifact{
+		Format:         "in-toto",
+		StorageBackend: sets.New[string]("tekton"),
+	}}}
+	backends := map[string]storage.Backend{"tekton": tekton.NewStorageBackend(ps, cfg)}
+	if _, err := Run(ctx, obj, backends, cfg, Options{}); err == nil {
+		t.Error("expected an error for a run without attestations")
+	}
+}
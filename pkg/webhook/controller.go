@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook implements an optional validating admission webhook that checks
+// the type hints and Chains annotations of Tekton resources before runs execute.
+package webhook
+
+import (
+	"context"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/webhook/resourcesemantics"
+	"knative.dev/pkg/webhook/resourcesemantics/validation"
+)
+
+const (
+	// WebhookName is the name of the ValidatingWebhookConfiguration the webhook manages.
+	WebhookName = "validation.webhook.chains.tekton.dev"
+	path        = "/resource-validation"
+)
+
+// handlers are the Tekton resources the webhook validates.
+var handlers = func() map[schema.GroupVersionKind]resourcesemantics.GenericCRD {
+	h := map[schema.GroupVersionKind]resourcesemantics.GenericCRD{}
+	for _, version := range []string{"v1", "v1beta1"} {
+		for _, kind := range []string{"Task", "Pipeline"} {
+			h[schema.GroupVersionKind{Group: "tekton.dev", Version: version, Kind: kind}] = &definition{}
+		}
+		for _, kind := range []string{"TaskRun", "PipelineRun"} {
+			h[schema.GroupVersionKind{Group: "tekton.dev", Version: version, Kind: kind}] = &run{}
+		}
+	}
+	h[schema.GroupVersionKind{Group: "tekton.dev", Version: "v1beta1", Kind: "ClusterTask"}] = &definition{}
+	return h
+}()
+
+// NewAdmissionController returns the validating admission controller. It watches the
+// Chains config to decide whether invalid resources are rejected or admitted with warnings.
+func NewAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	store := config.NewConfigStore(logging.FromContext(ctx).Named("config-store"))
+	store.WatchConfigs(cmw)
+
+	return validation.NewAdmissionController(ctx, WebhookName, path, handlers, store.ToContext, false)
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	"github.com/tektoncd/chains/pkg/config"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/webhook/resourcesemantics"
+)
+
+// result is the part of a Task or Pipeline result that type hints are declared with.
+type result struct {
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// resultsSpec is the part of a Task or Pipeline spec that declares results.
+type resultsSpec struct {
+	Results []result `json:"results,omitempty"`
+}
+
+// pipelineTask is the part of a Pipeline task that may embed a Task spec.
+type pipelineTask struct {
+	Name     string       `json:"name"`
+	TaskSpec *resultsSpec `json:"taskSpec,omitempty"`
+}
+
+// spec is the union of the parts of the Task, Pipeline, TaskRun and PipelineRun
+// specs the webhook validates. Their field names are the same in v1 and v1beta1.
+type spec struct {
+	Results      []result       `json:"results,omitempty"`
+	Tasks        []pipelineTask `json:"tasks,omitempty"`
+	Finally      []pipelineTask `json:"finally,omitempty"`
+	TaskSpec     *resultsSpec   `json:"taskSpec,omitempty"`
+	PipelineSpec *spec          `json:"pipelineSpec,omitempty"`
+}
+
+// resource is a Tekton resource decoded for validation. Unlike the Tekton types,
+// it only validates what Chains relies on.
+type resource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              spec `json:"spec,omitempty"`
+}
+
+// definition is a Task, ClusterTask or Pipeline.
+type definition struct {
+	resource `json:",inline"`
+}
+
+// run is a TaskRun or PipelineRun.
+type run struct {
+	resource `json:",inline"`
+}
+
+var (
+	_ resourcesemantics.GenericCRD  = (*definition)(nil)
+	_ resourcesemantics.VerbLimited = (*definition)(nil)
+	_ resourcesemantics.GenericCRD  = (*run)(nil)
+	_ resourcesemantics.VerbLimited = (*run)(nil)
+)
+
+// SetDefaults implements apis.Defaultable. The webhook only validates.
+func (d *definition) SetDefaults(context.Context) {}
+
+// Validate implements apis.Validatable.
+func (d *definition) Validate(ctx context.Context) *apis.FieldError {
+	errs := validateAnnotations(d.Annotations).ViaField("metadata")
+	errs = errs.Also(d.Spec.validate().ViaField("spec"))
+	return applyMode(ctx, errs)
+}
+
+// SupportedVerbs implements resourcesemantics.VerbLimited.
+func (d *definition) SupportedVerbs() []admissionregistrationv1.OperationType {
+	return []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update}
+}
+
+// DeepCopyObject implements runtime.Object.
+func (d *definition) DeepCopyObject() runtime.Object {
+	return &definition{resource: *d.resource.deepCopy()}
+}
+
+// SetDefaults implements apis.Defaultable. The webhook only validates.
+func (r *run) SetDefaults(context.Context) {}
+
+// Validate implements apis.Validatable.
+func (r *run) Validate(ctx context.Context) *apis.FieldError {
+	errs := validateAnnotations(r.Annotations).ViaField("metadata")
+	errs = errs.Also(r.Spec.validate().ViaField("spec"))
+	return applyMode(ctx, errs)
+}
+
+// SupportedVerbs implements resourcesemantics.VerbLimited. Runs are only validated
+// when they are created, since Chains itself annotates them afterwards.
+func (r *run) SupportedVerbs() []admissionregistrationv1.OperationType {
+	return []admissionregistrationv1.OperationType{admissionregistrationv1.Create}
+}
+
+// DeepCopyObject implements runtime.Object.
+func (r *run) DeepCopyObject() runtime.Object {
+	return &run{resource: *r.resource.deepCopy()}
+}
+
+// applyMode downgrades all errors to warnings unless invalid resources are rejected.
+func applyMode(ctx context.Context, errs *apis.FieldError) *apis.FieldError {
+	if errs == nil || config.FromContextOrDefaults(ctx).Webhook.RejectInvalid {
+		return errs
+	}
+	return errs.At(apis.WarningLevel)
+}
+
+func (r *resource) deepCopy() *resource {
+	out := &resource{TypeMeta: r.TypeMeta}
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = *r.Spec.deepCopy()
+	return out
+}
+
+func (s *spec) deepCopy() *spec {
+	out := &spec{
+		Results: copyResults(s.Results),
+		Tasks:   copyPipelineTasks(s.Tasks),
+		Finally: copyPipelineTasks(s.Finally),
+	}
+	if s.TaskSpec != nil {
+		out.TaskSpec = &resultsSpec{Results: copyResults(s.TaskSpec.Results)}
+	}
+	if s.PipelineSpec != nil {
+		out.PipelineSpec = s.PipelineSpec.deepCopy()
+	}
+	return out
+}
+
+func copyPipelineTasks(in []pipelineTask) []pipelineTask {
+	if in == nil {
+		return nil
+	}
+	out := make([]pipelineTask, len(in))
+	for i, t := range in {
+		out[i] = pipelineTask{Name: t.Name}
+		if t.TaskSpec != nil {
+			out[i].TaskSpec = &resultsSpec{Results: copyResults(t.TaskSpec.Results)}
+		}
+	}
+	return out
+}
+
+func copyResults(in []result) []result {
+	if in == nil {
+		return nil
+	}
+	out := make([]result, len(in))
+	for i, r := range in {
+		out[i] = result{Name: r.Name, Type: r.Type}
+		if r.Properties != nil {
+			out[i].Properties = make(map[string]interface{}, len(r.Properties))
+			for k, v := range r.Properties {
+				out[i].Properties[k] = v
+			}
+		}
+	}
+	return out
+}
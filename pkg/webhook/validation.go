@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/formats/slsa/attest"
+	"github.com/tektoncd/chains/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/pkg/apis"
+)
+
+const annotationPrefix = "chains.tekton.dev/"
+
+// userAnnotations are the Chains annotations users may set. They take a boolean value.
+var userAnnotations = sets.New[string](chains.RekorAnnotation, attest.ChainsReproducibleAnnotation, chains.StepEnvAnnotation)
+
+// overrideKeys are the config keys runs may override with annotations.
+var overrideKeys = sets.New[string](config.OverrideFormat, config.OverrideStorage, config.OverrideTransparency)
+
+// managedAnnotations are the Chains annotations set by Chains itself. Setting them
+// on a Task or Pipeline propagates them to its runs, which may prevent the runs
+// from being signed.
+var managedAnnotations = sets.New[string](
+	chains.ChainsAnnotation,
+	chains.RetryAnnotation,
+	chains.ChainsTransparencyAnnotation,
+	chains.DryRunAnnotation,
+	chains.TruncatedAnnotation,
+	chains.FormatVersionAnnotation,
+	chains.TransparencyPendingAnnotation,
+	chains.InvalidAnnotation,
+	chains.DisallowedMaterialsAnnotation,
+	chains.LocationsAnnotation,
+)
+
+// managedAnnotationPrefixes are the prefixes of the annotations the tekton, ipfs and
+// github storage backends set.
+var managedAnnotationPrefixes = []string{
+	annotationPrefix + "payload-",
+	annotationPrefix + "signature-",
+	annotationPrefix + "cert-",
+	annotationPrefix + "chain-",
+	annotationPrefix + "ipfs-cid-",
+	annotationPrefix + "github-attestation-",
+}
+
+// validateAnnotations validates the Chains annotations of a resource.
+func validateAnnotations(annotations map[string]string) *apis.FieldError {
+	var errs *apis.FieldError
+	for _, key := range sets.List[string](sets.KeySet(annotations)) {
+		if !strings.HasPrefix(key, annotationPrefix) {
+			continue
+		}
+		value := annotations[key]
+		switch {
+		case userAnnotations.Has(key):
+			if value != "true" && value != "false" {
+				errs = errs.Also(&apis.FieldError{
+					Message: fmt.Sprintf("invalid value %q for annotation %s", value, key),
+					Paths:   []string{"annotations"},
+					Details: `must be "true" or "false"`,
+				})
+			}
+		case key == attest.BuildGroupAnnotation:
+			// Build groups are storage index keys, so they are restricted like label values.
+			if msgs := validation.IsValidLabelValue(value); value == "" || len(msgs) > 0 {
+				errs = errs.Also(&apis.FieldError{
+					Message: fmt.Sprintf("invalid value %q for annotation %s", value, key),
+					Paths:   []string{"annotations"},
+					Details: "must be a non-empty valid label value",
+				})
+			}
+		case key == attest.RedactAnnotation:
+			if strings.TrimSpace(strings.ReplaceAll(value, ",", "")) == "" {
+				errs = errs.Also(&apis.FieldError{
+					Message: fmt.Sprintf("annotation %s must not be empty", key),
+					Paths:   []string{"annotations"},
+					Details: "must list the names of params and results, comma-separated",
+				})
+			}
+		case key == attest.EventBodyAnnotation:
+			// Triggers sets it to the JSON body of the event with $(body).
+			if !json.Valid([]byte(value)) {
+				errs = errs.Also(&apis.FieldError{
+					Message: fmt.Sprintf("invalid value for annotation %s", key),
+					Paths:   []string{"annotations"},
+					Details: "must be the JSON body of the event, set with $(body) in a TriggerTemplate",
+				})
+			}
+		case strings.HasPrefix(key, chains.OverrideAnnotationPrefix):
+			errs = errs.Also(validateOverride(key, value))
+		case managedAnnotations.Has(key) || hasManagedPrefix(key):
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("annotation %s is managed by Chains and must not be set", key),
+				Paths:   []string{"annotations"},
+			})
+		default:
+			errs = errs.Also((&apis.FieldError{
+				Message: fmt.Sprintf("unknown Chains annotation %s", key),
+				Paths:   []string{"annotations"},
+			}).At(apis.WarningLevel))
+		}
+	}
+	return errs
+}
+
+// validateOverride validates an annotation overriding a config key. Whether the key may
+// be overridden is only checked when the run is signed.
+func validateOverride(key, value string) *apis.FieldError {
+	overrideKey := strings.TrimPrefix(key, chains.OverrideAnnotationPrefix)
+	switch {
+	case !overrideKeys.Has(overrideKey):
+		return &apis.FieldError{
+			Message: fmt.Sprintf("config key %s of annotation %s cannot be overridden", overrideKey, key),
+			Paths:   []string{"annotations"},
+			Details: fmt.Sprintf("supported keys are %v", sets.List(overrideKeys)),
+		}
+	case overrideKey == config.OverrideTransparency:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return &apis.FieldError{
+				Message: fmt.Sprintf("invalid value %q for annotation %s", value, key),
+				Paths:   []string{"annotations"},
+				Details: `must be "true" or "false"`,
+			}
+		}
+	case value == "":
+		return &apis.FieldError{
+			Message: fmt.Sprintf("annotation %s must not be empty", key),
+			Paths:   []string{"annotations"},
+		}
+	}
+	return nil
+}
+
+func hasManagedPrefix(key string) bool {
+	for _, prefix := range managedAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate validates the type hints declared by the results of a spec and of the
+// Task and Pipeline specs embedded in it.
+func (s *spec) validate() *apis.FieldError {
+	errs := validateResults(s.Results)
+	for i, t := range s.Tasks {
+		if t.TaskSpec != nil {
+			errs = errs.Also(validateResults(t.TaskSpec.Results).ViaField("taskSpec").ViaFieldIndex("tasks", i))
+		}
+	}
+	for i, t := range s.Finally {
+		if t.TaskSpec != nil {
+			errs = errs.Also(validateResults(t.TaskSpec.Results).ViaField("taskSpec").ViaFieldIndex("finally", i))
+		}
+	}
+	if s.TaskSpec != nil {
+		errs = errs.Also(validateResults(s.TaskSpec.Results).ViaField("taskSpec"))
+	}
+	if s.PipelineSpec != nil {
+		errs = errs.Also(s.PipelineSpec.validate().ViaField("pipelineSpec"))
+	}
+	return errs
+}
+
+// pairs maps the suffix of a type hint result to the suffix of the result it must be declared with.
+var pairs = map[string]string{
+	"IMAGE_URL":       "IMAGE_DIGEST",
+	"IMAGE_DIGEST":    "IMAGE_URL",
+	"ARTIFACT_URI":    "ARTIFACT_DIGEST",
+	"ARTIFACT_DIGEST": "ARTIFACT_URI",
+}
+
+// validateResults validates the type hints declared by results.
+func validateResults(results []result) *apis.FieldError {
+	names := sets.New[string]()
+	for _, r := range results {
+		names.Insert(r.Name)
+	}
+	var errs *apis.FieldError
+	for i, r := range results {
+		errs = errs.Also(validateResult(r, names).ViaFieldIndex("results", i))
+	}
+	return errs
+}
+
+func validateResult(r result, names sets.Set[string]) *apis.FieldError {
+	if r.Name == "IMAGES" {
+		return requireType(r, "string")
+	}
+	for suffix, pair := range pairs {
+		if !strings.HasSuffix(r.Name, suffix) {
+			continue
+		}
+		errs := requireType(r, "string")
+		if want := strings.TrimSuffix(r.Name, suffix) + pair; !names.Has(want) {
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("type hint %s has no matching %s result", r.Name, want),
+				Paths:   []string{"name"},
+			})
+		}
+		return errs
+	}
+	for _, suffix := range []string{artifacts.ArtifactsInputsResultName, artifacts.ArtifactsOutputsResultName} {
+		if !strings.HasSuffix(r.Name, suffix) {
+			continue
+		}
+		errs := requireType(r, "object")
+		if r.Properties != nil {
+			for _, p := range []string{"uri", "digest"} {
+				if _, ok := r.Properties[p]; !ok {
+					errs = errs.Also(apis.ErrMissingField(p).ViaField("properties"))
+				}
+			}
+		}
+		return errs
+	}
+	return nil
+}
+
+// requireType returns an error if the result is not of the given type. Results are
+// strings unless they declare a type.
+func requireType(r result, want string) *apis.FieldError {
+	got := r.Type
+	if got == "" {
+		got = "string"
+	}
+	if got != want {
+		return apis.ErrInvalidValue(got, "type", fmt.Sprintf("type hint %s must be a %s result", r.Name, want))
+	}
+	return nil
+}
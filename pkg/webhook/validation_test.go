@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/config"
+	"knative.dev/pkg/apis"
+)
+
+func decode(t *testing.T, raw string) *definition {
+	t.Helper()
+	d := &definition{}
+	if err := json.Unmarshal([]byte(raw), d); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDefinition_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		wantError string
+		wantWarn  string
+	}{
+		{
+			name: "valid type hints",
+			raw: `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/transparency-upload": "true"}},
+			       "spec": {"results": [
+			         {"name": "IMAGES"},
+			         {"name": "APP_IMAGE_URL"}, {"name": "APP_IMAGE_DIGEST", "type": "string"},
+			         {"name": "BIN_ARTIFACT_URI"}, {"name": "BIN_ARTIFACT_DIGEST"},
+			         {"name": "SRC_ARTIFACT_INPUTS", "type": "object", "properties": {"uri": {}, "digest": {}}}]}}`,
+		},
+		{
+			name:      "IMAGES is not a string",
+			raw:       `{"metadata": {"name": "build"}, "spec": {"results": [{"name": "IMAGES", "type": "array"}]}}`,
+			wantError: "invalid value: array: spec.results[0].type\ntype hint IMAGES must be a string result",
+		},
+		{
+			name:      "mismatched IMAGE_URL",
+			raw:       `{"metadata": {"name": "build"}, "spec": {"results": [{"name": "APP_IMAGE_URL"}]}}`,
+			wantError: "type hint APP_IMAGE_URL has no matching APP_IMAGE_DIGEST result: spec.results[0].name",
+		},
+		{
+			name:      "mismatched ARTIFACT_DIGEST in embedded pipeline task",
+			raw:       `{"metadata": {"name": "release"}, "spec": {"tasks": [{"name": "build", "taskSpec": {"results": [{"name": "BIN_ARTIFACT_DIGEST"}]}}]}}`,
+			wantError: "type hint BIN_ARTIFACT_DIGEST has no matching BIN_ARTIFACT_URI result: spec.tasks[0].taskSpec.results[0].name",
+		},
+		{
+			name:      "structured result missing digest",
+			raw:       `{"metadata": {"name": "build"}, "spec": {"results": [{"name": "ARTIFACT_OUTPUTS", "type": "object", "properties": {"uri": {}}}]}}`,
+			wantError: "missing field(s): spec.results[0].properties.digest",
+		},
+		{
+			name:      "managed annotation",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/signed": "true"}}, "spec": {}}`,
+			wantError: "annotation chains.tekton.dev/signed is managed by Chains and must not be set: metadata.annotations",
+		},
+		{
+			name:      "managed invalid annotation",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/invalid": ""}}, "spec": {}}`,
+			wantError: "annotation chains.tekton.dev/invalid is managed by Chains and must not be set: metadata.annotations",
+		},
+		{
+			name:      "managed disallowed materials annotation",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/disallowed-materials": "[]"}}, "spec": {}}`,
+			wantError: "annotation chains.tekton.dev/disallowed-materials is managed by Chains and must not be set: metadata.annotations",
+		},
+		{
+			name:      "managed locations annotation",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/locations": "{}"}}, "spec": {}}`,
+			wantError: "annotation chains.tekton.dev/locations is managed by Chains and must not be set: metadata.annotations",
+		},
+		{
+			name:      "managed github attestation annotation",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/github-attestation-taskrun-uid": "1"}}, "spec": {}}`,
+			wantError: "annotation chains.tekton.dev/github-attestation-taskrun-uid is managed by Chains and must not be set: metadata.annotations",
+		},
+		{
+			name: "event body annotation",
+			raw:  `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/event-body": "{\"ref\": \"refs/heads/main\"}"}}, "spec": {}}`,
+		},
+		{
+			name:      "invalid event body annotation",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/event-body": "$(body)"}}, "spec": {}}`,
+			wantError: "invalid value for annotation chains.tekton.dev/event-body: metadata.annotations\nmust be the JSON body of the event, set with $(body) in a TriggerTemplate",
+		},
+		{
+			name:      "invalid user annotation",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/reproducible": "yes"}}, "spec": {}}`,
+			wantError: "invalid value \"yes\" for annotation chains.tekton.dev/reproducible: metadata.annotations\nmust be \"true\" or \"false\"",
+		},
+		{
+			name: "valid build group",
+			raw:  `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/build-group": "widgets-nightly"}}, "spec": {}}`,
+		},
+		{
+			name:      "invalid build group",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/build-group": "widgets/nightly"}}, "spec": {}}`,
+			wantError: "invalid value \"widgets/nightly\" for annotation chains.tekton.dev/build-group: metadata.annotations\nmust be a non-empty valid label value",
+		},
+		{
+			name: "valid redaction",
+			raw:  `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/redact": "token, LICENSE_KEY"}}, "spec": {}}`,
+		},
+		{
+			name:      "empty redaction",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/redact": " , "}}, "spec": {}}`,
+			wantError: "annotation chains.tekton.dev/redact must not be empty: metadata.annotations\nmust list the names of params and results, comma-separated",
+		},
+		{
+			name: "valid config overrides",
+			raw: `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/config.format": "slsa/v1",
+			       "chains.tekton.dev/config.transparency.enabled": "true"}}, "spec": {}}`,
+		},
+		{
+			name:      "unsupported config override",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/config.signers.kms.kmsref": "gcpkms://foo"}}, "spec": {}}`,
+			wantError: "config key signers.kms.kmsref of annotation chains.tekton.dev/config.signers.kms.kmsref cannot be overridden: metadata.annotations\nsupported keys are [format storage transparency.enabled]",
+		},
+		{
+			name:      "invalid transparency override",
+			raw:       `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/config.transparency.enabled": "sometimes"}}, "spec": {}}`,
+			wantError: "invalid value \"sometimes\" for annotation chains.tekton.dev/config.transparency.enabled: metadata.annotations\nmust be \"true\" or \"false\"",
+		},
+		{
+			name:     "unknown annotation",
+			raw:      `{"metadata": {"name": "build", "annotations": {"chains.tekton.dev/sign": "true"}}, "spec": {}}`,
+			wantWarn: "unknown Chains annotation chains.tekton.dev/sign: metadata.annotations",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := config.ToContext(context.Background(), &config.Config{Webhook: config.WebhookConfig{RejectInvalid: true}})
+			errs := decode(t, tt.raw).Validate(ctx)
+			if diff := cmp.Diff(tt.wantError, errs.Filter(apis.ErrorLevel).Error()); diff != "" {
+				t.Errorf("errors (-want +got): %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantWarn, errs.Filter(apis.WarningLevel).Error()); diff != "" {
+				t.Errorf("warnings (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidate_WarnMode(t *testing.T) {
+	d := decode(t, `{"metadata": {"name": "build"}, "spec": {"results": [{"name": "APP_IMAGE_URL"}]}}`)
+	errs := d.Validate(context.Background())
+	if got := errs.Filter(apis.ErrorLevel); got != nil {
+		t.Errorf("expected no errors in warn mode, got %v", got)
+	}
+	if got := errs.Filter(apis.WarningLevel); got == nil {
+		t.Error("expected a warning in warn mode")
+	}
+}
+
+func TestRun_Validate(t *testing.T) {
+	r := &run{}
+	raw := `{"metadata": {"name": "build-1", "annotations": {"chains.tekton.dev/retries": "0"}},
+	         "spec": {"pipelineSpec": {"results": [{"name": "IMAGES", "type": "object"}]}}}`
+	if err := json.Unmarshal([]byte(raw), r); err != nil {
+		t.Fatal(err)
+	}
+	ctx := config.ToContext(context.Background(), &config.Config{Webhook: config.WebhookConfig{RejectInvalid: true}})
+	want := "annotation chains.tekton.dev/retries is managed by Chains and must not be set: metadata.annotations\n" +
+		"invalid value: object: spec.pipelineSpec.results[0].type\ntype hint IMAGES must be a string result"
+	if diff := cmp.Diff(want, r.Validate(ctx).Error()); diff != "" {
+		t.Errorf("errors (-want +got): %s", diff)
+	}
+
+	cp, ok := r.DeepCopyObject().(*run)
+	if !ok || cp.Spec.PipelineSpec == nil || cp.Spec.PipelineSpec == r.Spec.PipelineSpec {
+		t.Error("expected DeepCopyObject to copy the embedded pipeline spec")
+	}
+}